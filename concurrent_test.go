@@ -13,7 +13,7 @@ func TestConcurrentMapLogging(t *testing.T) {
 	config := ZapConfig{
 		Level:           "debug",
 		Format:          "console",
-		Director:        "./test_logs",
+		Director:        t.TempDir(),
 		ShowLine:        true,
 		LogInConsole:    true,
 		EnableAsync:     true,
@@ -91,7 +91,7 @@ func TestConcurrentMapLoggingWithLockProtection(t *testing.T) {
 	config := ZapConfig{
 		Level:           "info",
 		Format:          "console",
-		Director:        "./test_logs",
+		Director:        t.TempDir(),
 		ShowLine:        true,
 		LogInConsole:    false, // 关闭控制台输出以提高性能
 		EnableAsync:     true,
@@ -153,7 +153,7 @@ func TestAsyncLoggingPerformance(t *testing.T) {
 	config := ZapConfig{
 		Level:           "info",
 		Format:          "console",
-		Director:        "./test_logs",
+		Director:        t.TempDir(),
 		ShowLine:        true,
 		LogInConsole:    false,
 		EnableAsync:     true,
@@ -189,7 +189,7 @@ func TestComplexDataStructures(t *testing.T) {
 	config := ZapConfig{
 		Level:           "debug",
 		Format:          "console",
-		Director:        "./test_logs",
+		Director:        t.TempDir(),
 		ShowLine:        true,
 		LogInConsole:    true,
 		EnableAsync:     true,
@@ -247,7 +247,7 @@ func TestConcurrentMapLoggingWithoutLock(t *testing.T) {
 	config := ZapConfig{
 		Level:           "info",
 		Format:          "console",
-		Director:        "./test_logs",
+		Director:        t.TempDir(),
 		ShowLine:        false,
 		LogInConsole:    false,
 		EnableAsync:     true,
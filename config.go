@@ -1,5 +1,7 @@
 package mlog
 
+import "sync/atomic"
+
 // LogSafetyMode 日志安全模式
 type LogSafetyMode int
 
@@ -15,6 +17,8 @@ const (
 var (
 	// 全局安全模式设置
 	globalSafetyMode = SafetyModeDefault
+	// 异步日志入队时是否对引用类型字段做快照
+	fieldSnapshotEnabled int32
 )
 
 // SetLogSafetyMode 设置日志安全模式
@@ -27,6 +31,18 @@ func GetLogSafetyMode() LogSafetyMode {
 	return globalSafetyMode
 }
 
+// SetFieldSnapshot 设置异步日志入队时是否对引用类型字段做快照
+// 开启后 zap.Any、zap.Object、zap.Stringer、zap.Error 等字段在入队时立即序列化，
+// 写入时不再读取调用方仍在修改的结构体、map 或切片（性能较低，但最安全）
+func SetFieldSnapshot(enable bool) {
+	atomic.StoreInt32(&fieldSnapshotEnabled, boolToInt32(enable))
+}
+
+// GetFieldSnapshot 获取异步日志入队时是否对引用类型字段做快照
+func GetFieldSnapshot() bool {
+	return atomic.LoadInt32(&fieldSnapshotEnabled) == 1
+}
+
 // shouldUseSafeFormat 判断是否应该使用安全格式化
 func shouldUseSafeFormat(isAsync bool) bool {
 	switch globalSafetyMode {
@@ -14,7 +14,7 @@ func TestExtremeConcurrentMapAccess(t *testing.T) {
 	config := ZapConfig{
 		Level:           "info",
 		Format:          "console",
-		Director:        "./test_logs",
+		Director:        t.TempDir(),
 		ShowLine:        false,
 		LogInConsole:    false,
 		EnableAsync:     true,
@@ -118,7 +118,7 @@ func TestConcurrentMapWithDifferentTypes(t *testing.T) {
 	config := ZapConfig{
 		Level:           "debug",
 		Format:          "console",
-		Director:        "./test_logs",
+		Director:        t.TempDir(),
 		ShowLine:        true,
 		LogInConsole:    false,
 		EnableAsync:     true,
@@ -1,9 +1,13 @@
 package mlog
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // SafeFormatter 提供并发安全的格式化功能
@@ -222,6 +226,70 @@ func isZeroValue(v reflect.Value) bool {
 	}
 }
 
+// SnapshotField 对引用类型字段做快照，返回不再引用调用方数据的字段
+// 基本类型字段原样返回；结构体、map、切片等通过 JSON 序列化固定当前内容，
+// 序列化失败时退回到 makeArgSafe 的安全表示
+func (sf *SafeFormatter) SnapshotField(field zap.Field) zap.Field {
+	switch field.Type {
+	case zapcore.ReflectType:
+		if field.Interface == nil {
+			return field
+		}
+		if data, err := json.Marshal(field.Interface); err == nil {
+			return zap.Reflect(field.Key, json.RawMessage(data))
+		}
+		return zap.Any(field.Key, sf.makeArgSafe(field.Interface))
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.InlineMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		field.AddTo(enc)
+		if field.Type == zapcore.InlineMarshalerType {
+			return zap.Inline(snapshotObject(enc.Fields))
+		}
+		if data, err := json.Marshal(enc.Fields[field.Key]); err == nil {
+			return zap.Reflect(field.Key, json.RawMessage(data))
+		}
+		return zap.String(field.Key, fmt.Sprintf("%v", enc.Fields[field.Key]))
+	case zapcore.StringerType:
+		if field.Interface == nil {
+			return field
+		}
+		return zap.String(field.Key, field.Interface.(fmt.Stringer).String())
+	case zapcore.ErrorType:
+		if field.Interface == nil {
+			return field
+		}
+		return zap.String(field.Key, field.Interface.(error).Error())
+	case zapcore.BinaryType:
+		return zap.Binary(field.Key, append([]byte(nil), field.Interface.([]byte)...))
+	case zapcore.ByteStringType:
+		return zap.ByteString(field.Key, append([]byte(nil), field.Interface.([]byte)...))
+	default:
+		return field
+	}
+}
+
+// snapshotObject 内联字段的快照，写入时按 JSON 快照逐个输出
+type snapshotObject map[string]interface{}
+
+// MarshalLogObject 实现 zapcore.ObjectMarshaler
+func (o snapshotObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for key, value := range o {
+		if data, err := json.Marshal(value); err == nil {
+			if err := enc.AddReflected(key, json.RawMessage(data)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SnapshotFields 对字段切片中的引用类型字段原地做快照
+func SnapshotFields(fields []zap.Field) {
+	for i := range fields {
+		fields[i] = globalSafeFormatter.SnapshotField(fields[i])
+	}
+}
+
 // SafeFormat 全局安全格式化函数
 var globalSafeFormatter = NewSafeFormatter()
 
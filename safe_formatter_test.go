@@ -1,9 +1,13 @@
 package mlog
 
 import (
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // TestSafeFormatterWithConcurrentMap 测试安全格式化器处理并发 map
@@ -191,7 +195,7 @@ func TestIntegrationWithAsyncLogger(t *testing.T) {
 	config := ZapConfig{
 		Level:           "info",
 		Format:          "console",
-		Director:        "./test_logs",
+		Director:        t.TempDir(),
 		ShowLine:        true,
 		LogInConsole:    false,
 		EnableAsync:     true,
@@ -287,3 +291,32 @@ func TestLogSafetyModes(t *testing.T) {
 		})
 	}
 }
+
+// TestSnapshotFields 测试字段快照后修改原始数据不影响写入内容
+func TestSnapshotFields(t *testing.T) {
+	type player struct {
+		Name  string
+		Items map[string]int
+	}
+	p := &player{Name: "alice", Items: map[string]int{"sword": 1}}
+	scores := []int{1, 2, 3}
+	fields := []zap.Field{zap.Any("player", p), zap.Ints("scores", scores), zap.Int("level", 10)}
+	SnapshotFields(fields)
+
+	p.Name = "bob"
+	p.Items["shield"] = 2
+	scores[0] = 100
+
+	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	buf, err := encoder.EncodeEntry(zapcore.Entry{Message: "snapshot"}, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `"player":{"Name":"alice","Items":{"sword":1}}`) {
+		t.Errorf("结构体快照错误: %s", out)
+	}
+	if !strings.Contains(out, `"scores":[1,2,3]`) || !strings.Contains(out, `"level":10`) {
+		t.Errorf("切片或基本类型快照错误: %s", out)
+	}
+}
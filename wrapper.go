@@ -1,6 +1,7 @@
 package mlog
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
@@ -20,6 +21,9 @@ var (
 	stopNetFlag int32
 	zapConfig   ZapConfig
 	atomicLevel zap.AtomicLevel
+	// 最近一次 InitialZap 的服务名和服务 ID
+	serviceName string
+	serviceID   uint64
 	initialized int32
 	// 优化的无锁logger访问
 	loggerPtr unsafe.Pointer // *zap.Logger，使用unsafe.Pointer实现无锁访问
@@ -54,37 +58,57 @@ func GetConfig() *ZapConfig {
 
 	// 返回配置的副本，避免外部修改影响内部状态
 	config := zapConfig
+	// 初始化后热更新的配置项不写回 zapConfig
+	if hot := activeHotCores.Load(); hot != nil {
+		hot.config.Load().applyTo(&config)
+	}
 	return &config
 }
 
+// InitialZap 初始化日志系统，调用过 LoadEnvOverrides 时环境变量覆盖 zc 和 logLevel 参数中的配置
+// 配置中的无效项输出到 stderr 后按默认值处理；需要在启动时发现配置错误时使用 InitialZapE
 func InitialZap(name string, id uint64, logLevel string, zc *ZapConfig) {
+	initialZap(name, id, "", resolveConfig(logLevel, zc))
+}
+
+// InitialZapE 与 InitialZap 相同，但先按 Validate 校验合并后的配置，有错误时不初始化并返回所有错误
+func InitialZapE(name string, id uint64, logLevel string, zc *ZapConfig) error {
+	config := resolveConfig(logLevel, zc)
+	if errs := config.Validate(); len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	initialZap(name, id, "", config)
+	return nil
+}
+
+// resolveConfig 合并 zc（为 nil 时使用当前配置）、logLevel 参数和环境变量覆盖，返回初始化使用的配置
+func resolveConfig(logLevel string, zc *ZapConfig) *ZapConfig {
+	config := GetConfig()
+	if zc != nil {
+		*config = *zc
+	}
+	if logLevel != "" {
+		config.Level = logLevel
+	}
+	applyEnvOverrides(config)
+	return config
+}
+
+// initialZap 按 zc 初始化日志系统，不应用环境变量覆盖
+func initialZap(name string, id uint64, logLevel string, zc *ZapConfig) {
 	globalMutex.Lock()
 	defer globalMutex.Unlock()
 
-	// 如果已经初始化，先关闭现有的日志器
+	// 如果已经初始化，先按 Close 的流程关闭现有的日志器：异步日志器、所有 ZapCore 的日志文件（含缓存的特殊目录文件）
+	// 以及附加输出和后台协程，避免重复初始化时泄露文件句柄和协程
 	if atomic.LoadInt32(&initialized) == 1 {
-		if logger := (*zap.Logger)(atomic.LoadPointer(&loggerPtr)); logger != nil {
-			logger.Sync() // 确保所有日志都被写入
-		}
-		if zapLogger != nil {
-			zapLogger.Sync() // 兼容性：同时同步旧的logger
-		}
-
-		// 关闭现有的 ZapCore 实例，防止 lumberjack goroutine 泄露
-		coreMutex.Lock()
-		for _, core := range zapCores {
-			if core != nil {
-				if err := core.Close(); err != nil {
-					fmt.Fprintf(os.Stderr, "关闭现有 ZapCore 失败: %v\n", err)
-				}
-			}
-		}
-		coreMutex.Unlock()
+		Close()
 	}
 
 	if zc != nil {
 		zapConfig = *zc
 	}
+	serviceName, serviceID = name, id
 	// 如果提供了 logLevel 参数，优先使用它
 	finalLevel := zapConfig.Level
 	if logLevel != "" {
@@ -99,8 +123,8 @@ func InitialZap(name string, id uint64, logLevel string, zc *ZapConfig) {
 	}
 	atomicLevel = zap.NewAtomicLevelAt(level)
 
-	// 更新优化的日志级别缓存
-	updateLevelCacheOptimized(atomicLevel.Level())
+	// 按模块级别更新原子级别和优化的日志级别缓存
+	applyLevel(level)
 
 	// 初始化zap日志库
 	logger := initZap(name, id)
@@ -120,13 +144,11 @@ func InitialZap(name string, id uint64, logLevel string, zc *ZapConfig) {
 			globalAsyncLogger.close()
 		}
 
-		// 设置默认值
-		bufferSize := zapConfig.AsyncBufferSize
-		if bufferSize <= 0 {
-			bufferSize = 10000 // 默认缓冲区大小
+		// core 为 nil 表示写入全局日志器
+		globalAsyncLogger = NewAsyncLogger(nil, asyncOptionsFromConfig(&zapConfig)...)
+		if zapConfig.AsyncSnapshotFields {
+			SetFieldSnapshot(true)
 		}
-
-		globalAsyncLogger = newAsyncLogger(bufferSize, zapConfig.AsyncDropOnFull)
 		asyncMutex.Unlock()
 	}
 	// 初始化路径缓存（如果启用）
@@ -141,6 +163,10 @@ func InitialZap(name string, id uint64, logLevel string, zc *ZapConfig) {
 	// 标记为已初始化
 	atomic.StoreInt32(&initialized, 1)
 
+	// 回放初始化前缓存的日志
+	replayPreInitEntries(logger)
+	initializedOnce.Store(true)
+
 	// 仅在控制台模式输出初始化信息（简洁版本）
 	if zapConfig.LogInConsole {
 		asyncMode := "sync"
@@ -252,6 +278,17 @@ func Close() {
 	}
 	asyncMutex.Unlock()
 
+	closeSyncLogger()
+}
+
+// closeSyncLogger 同步并关闭同步日志器及所有 ZapCore，重置初始化状态
+func closeSyncLogger() {
+	// 归档所有未卸载的场景日志
+	closeAllScenes()
+
+	// 写出去重窗口内尚未输出的汇总日志
+	flushDedup()
+
 	// 关闭同步日志器（使用优化的获取方式）
 	logger := getLoggerOptimized()
 	if logger != nil {
@@ -273,6 +310,7 @@ func Close() {
 	}
 	// 清空 zapCores 切片
 	zapCores = nil
+	closeSinksLocked()
 	coreMutex.Unlock()
 
 	// 清理优化的logger指针
@@ -288,8 +326,8 @@ func Debug(msg string, args ...any) {
 	if !isDebugEnabledFast() {
 		return
 	}
-	// 有参数时使用原有的格式化逻辑
-	zapDebug(msg, args...)
+	// 调用栈：用户代码 -> mlog.Debug()，在入口处捕获调用位置
+	logAt(zapcore.DebugLevel, captureCaller(1), msg, args)
 }
 
 // DebugW 输出带结构化字段的调试级别日志
@@ -298,23 +336,8 @@ func DebugW(msg string, fields ...zap.Field) {
 	if !isDebugEnabledFast() {
 		return
 	}
-	// 检查是否使用异步模式
-	if isAsyncEnabled() {
-		debugAsync(msg, nil, fields...)
-		return
-	}
-	// 获取日志构造器
-	logger := getLoggerOptimized()
-	if logger == nil {
-		ExitGame("zapLogger 还没有初始化，请先调用 InitialZap")
-		return
-	}
-
-	// 为 mlog 包装函数调用创建带有正确 caller skip 的 logger
-	// 调用栈：用户代码 -> mlog.DebugW() -> logger.Debug()
-	// 需要跳过 1 层：mlog.DebugW()
-	loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(1))
-	loggerWithSkip.Debug(msg, fields...)
+	// 调用栈：用户代码 -> mlog.DebugW()
+	logAt(zapcore.DebugLevel, captureCaller(1), msg, nil, fields...)
 }
 
 // Info 输出信息级别日志
@@ -323,8 +346,7 @@ func Info(msg string, args ...any) {
 	if !isInfoEnabledFast() {
 		return
 	}
-	// 有参数时使用原有的格式化逻辑
-	zapInfo(msg, args...)
+	logAt(zapcore.InfoLevel, captureCaller(1), msg, args)
 }
 
 // InfoW 输出带结构化字段的信息级别日志
@@ -333,23 +355,7 @@ func InfoW(msg string, fields ...zap.Field) {
 	if !isInfoEnabledFast() {
 		return
 	}
-	// 检查是否使用异步模式
-	if isAsyncEnabled() {
-		infoAsync(msg, nil, fields...)
-		return
-	}
-	// 获取日志构造器
-	logger := getLoggerOptimized()
-	if logger == nil {
-		ExitGame("zapLogger 还没有初始化，请先调用 InitialZap")
-		return
-	}
-
-	// 为 mlog 包装函数调用创建带有正确 caller skip 的 logger
-	// 调用栈：用户代码 -> mlog.InfoW() -> logger.Info()
-	// 需要跳过 1 层：mlog.InfoW()
-	loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(1))
-	loggerWithSkip.Info(msg, fields...)
+	logAt(zapcore.InfoLevel, captureCaller(1), msg, nil, fields...)
 }
 
 func Warn(msg string, args ...any) {
@@ -357,8 +363,7 @@ func Warn(msg string, args ...any) {
 	if !isWarnEnabledFast() {
 		return
 	}
-	// 有参数时使用原有的格式化逻辑
-	zapWarn(msg, args...)
+	logAt(zapcore.WarnLevel, captureCaller(1), msg, args)
 }
 
 func WarnW(msg string, fields ...zap.Field) {
@@ -366,22 +371,7 @@ func WarnW(msg string, fields ...zap.Field) {
 	if !isWarnEnabledFast() {
 		return
 	}
-	if isAsyncEnabled() {
-		warnAsync(msg, nil, fields...)
-		return
-	}
-	// 获取日志构造器
-	logger := getLoggerOptimized()
-	if logger == nil {
-		ExitGame("zapLogger 还没有初始化，请先调用 InitialZap")
-		return
-	}
-
-	// 为 mlog 包装函数调用创建带有正确 caller skip 的 logger
-	// 调用栈：用户代码 -> mlog.WarnW() -> logger.Warn()
-	// 需要跳过 1 层：mlog.WarnW()
-	loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(1))
-	loggerWithSkip.Warn(msg, fields...)
+	logAt(zapcore.WarnLevel, captureCaller(1), msg, nil, fields...)
 }
 
 func Error(arg0 string, args ...interface{}) {
@@ -389,8 +379,7 @@ func Error(arg0 string, args ...interface{}) {
 	if !isErrorEnabledFast() {
 		return
 	}
-	// 有参数时使用原有的格式化逻辑
-	zapError(arg0, args...)
+	logAt(zapcore.ErrorLevel, captureCaller(1), arg0, args)
 }
 
 // ErrorW 输出带结构化字段的错误级别日志
@@ -399,35 +388,53 @@ func ErrorW(msg string, fields ...zap.Field) {
 	if !isErrorEnabledFast() {
 		return
 	}
+	logAt(zapcore.ErrorLevel, captureCaller(1), msg, nil, fields...)
+}
+
+// DPanic 输出"不应该发生"级别的日志，介于 Error 和 Fatal 之间
+// 开发模式（Development=true）下记录后 panic，生产模式下只记录日志
+func DPanic(msg string, args ...any) {
+	logDPanic(formatMessage(msg, args, false), nil)
+}
 
-	// 检查是否使用异步模式
-	if isAsyncEnabled() {
-		errorAsync(msg, nil, fields...)
+// DPanicW 输出带结构化字段的 DPanic 级别日志
+func DPanicW(msg string, fields ...zap.Field) {
+	logDPanic(msg, fields)
+}
+
+// logDPanic DPanic 日志的公共实现，始终同步写入以便开发模式下在调用方 goroutine 中 panic
+func logDPanic(msg string, fields []zap.Field) {
+	logger, ok := getLogger()
+	if !ok {
+		logBeforeInit(zapcore.DPanicLevel, 2, msg, fields...)
 		return
 	}
-	logger := getLoggerOptimized()
-	if logger == nil {
-		ExitGame("zapLogger 还没有初始化，请先调用 InitialZap")
-		return
+
+	// 异步模式下先刷新队列，保证 DPanic 之前的日志已经写入
+	if al, ok := getAsyncLogger(); ok {
+		al.Flush()
 	}
 
-	// 为 mlog 包装函数调用创建带有正确 caller skip 的 logger
-	// 调用栈：用户代码 -> mlog.ErrorW() -> logger.Error()
-	// 需要跳过 1 层：mlog.ErrorW()
-	loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(1))
-	loggerWithSkip.Error(msg, fields...)
+	// 调用栈：用户代码 -> mlog.DPanic() -> logDPanic() -> logger.DPanic()
+	// 需要跳过 2 层：logDPanic() 和 mlog.DPanic()
+	loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(2))
+	loggerWithSkip.DPanic(msg, fields...)
 }
 
 // ReturnError 输出错误日志并返回error对象
 func ReturnError(msg string, args ...any) error {
-	return zapReturnError(msg, args...)
+	if isErrorEnabledFast() {
+		// 调用栈：用户代码 -> mlog.ReturnError()
+		logAt(zapcore.ErrorLevel, captureCaller(1), msg, args)
+	}
+	return formatError(msg, args...)
 }
 
 // Lock 输出锁定相关的日志
 func Lock(msg string, args ...any) {
 	logger, ok := getLogger()
 	if !ok {
-		ExitGame("zapLogger 还没有初始化，请先调用 InitialZap")
+		logBeforeInit(zapcore.InfoLevel, 1, formatMessage(msg, args, false), zap.String("directory", "concurrent"))
 		return
 	}
 
@@ -457,8 +464,7 @@ func Lock(msg string, args ...any) {
 func Critical(msg string, args ...any) {
 	logger, ok := getLogger()
 	if !ok {
-		// 避免无限递归，直接 panic 而不调用 ExitGame
-		ExitGame("zapLogger 还没有初始化，请先调用 InitialZap")
+		logBeforeInit(zapcore.WarnLevel, 1, formatMessage(msg, args, false), zap.String("directory", "emergency"))
 		return
 	}
 
@@ -486,7 +492,7 @@ func Critical(msg string, args ...any) {
 func Disaster(msg string, args ...interface{}) {
 	logger, ok := getLogger()
 	if !ok {
-		ExitGame("zapLogger 还没有初始化，请先调用 InitialZap")
+		logBeforeInit(zapcore.ErrorLevel, 1, formatMessage(msg, args, false), zap.String("directory", "emergency"))
 		return
 	}
 
@@ -507,7 +513,7 @@ func Disaster(msg string, args ...interface{}) {
 		loggerWithSkip.Error(msg, zap.Error(err), zap.String("directory", "emergency"))
 		return
 	}
-	loggerWithSkip.Error(sb.String())
+	loggerWithSkip.Error(sb.String(), zap.String("directory", "emergency"))
 }
 
 // ExitGame 输出严重错误并退出游戏
@@ -530,7 +536,11 @@ func ExitGame(format string, args ...any) {
 	}
 	// 优化：直接传递消息，避免额外的格式化
 	Disaster("%s", msg)
-	time.Sleep(3000 * time.Millisecond)
+	if atomic.LoadInt32(&crashHooksInstalled) == 1 {
+		CrashFlush()
+	} else {
+		time.Sleep(3000 * time.Millisecond)
+	}
 	panic(msg)
 }
 
@@ -566,18 +576,23 @@ func GrpcAssert(format string, args ...any) {
 		stringStack = convertStackPathsToRelative(stringStack)
 	}
 
-	// 优化：将堆栈信息作为消息主体，保持完整性以支持IDE跳转
-	// 使用格式化的多行消息，在日志文件中有良好的可读性
-	stackMessage := fmt.Sprintf("[GrpcAssert] %s\n\nStack Trace:\n%s", msg, stringStack)
-
 	// 直接使用 logger 而不是 InfoW，因为我们已经手动获取了调用信息
 	// 调用栈：用户代码 -> mlog.GrpcAssert() -> logger.Info()
 	// 需要跳过 1 层：mlog.GrpcAssert()
 	logger := getLoggerOptimized()
-	if logger != nil {
-		loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(1))
-		loggerWithSkip.Info(stackMessage, zap.String("directory", "assert"))
+	if logger == nil {
+		return
 	}
+	loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(1))
+	if zapConfig.EscapeNewlines {
+		// 单行模式：堆栈作为单独的字段输出，消息保持一行
+		loggerWithSkip.Info("[GrpcAssert] "+msg, zap.String("directory", "assert"), stackField(stringStack))
+		return
+	}
+	// 优化：将堆栈信息作为消息主体，保持完整性以支持IDE跳转
+	// 使用格式化的多行消息，在日志文件中有良好的可读性
+	stackMessage := fmt.Sprintf("[GrpcAssert] %s\n\nStack Trace:\n%s", msg, stringStack)
+	loggerWithSkip.Info(stackMessage, zap.String("directory", "assert"))
 }
 
 // AssertString 输出断言信息（优化版本：保持堆栈信息完整性以支持IDE跳转）
@@ -612,18 +627,23 @@ func AssertString(format string, args ...interface{}) {
 		stringStack = convertStackPathsToRelative(stringStack)
 	}
 
-	// 优化：将堆栈信息作为消息主体，保持完整性以支持IDE跳转
-	// 使用格式化的多行消息，在日志文件中有良好的可读性
-	stackMessage := fmt.Sprintf("[Assert] %s\n\nStack Trace:\n%s", msg, stringStack)
-
 	// 直接使用 logger 而不是 InfoW，因为我们已经手动获取了调用信息
 	// 调用栈：用户代码 -> mlog.AssertString() -> logger.Info()
 	// 需要跳过 1 层：mlog.AssertString()
 	logger := getLoggerOptimized()
-	if logger != nil {
-		loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(1))
-		loggerWithSkip.Info(stackMessage, zap.String("directory", "assert"))
+	if logger == nil {
+		return
+	}
+	loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(1))
+	if zapConfig.EscapeNewlines {
+		// 单行模式：堆栈作为单独的字段输出，消息保持一行
+		loggerWithSkip.Info("[Assert] "+msg, zap.String("directory", "assert"), stackField(stringStack))
+		return
 	}
+	// 优化：将堆栈信息作为消息主体，保持完整性以支持IDE跳转
+	// 使用格式化的多行消息，在日志文件中有良好的可读性
+	stackMessage := fmt.Sprintf("[Assert] %s\n\nStack Trace:\n%s", msg, stringStack)
+	loggerWithSkip.Info(stackMessage, zap.String("directory", "assert"))
 }
 
 // BytesToString 将字节数组转换为字符串
@@ -732,69 +752,11 @@ func replacePathInField(field string) string {
 	return getRelativePath(field)
 }
 
-// syncLoggerSafely 安全地同步日志器，避免 stdout/stderr 同步错误
+// syncLoggerSafely 同步日志器
+// 控制台和文件输出各自实现 Sync，控制台的 Sync 为空操作，
+// 因此不再需要判断终端类型或过滤 stdout 同步产生的 ioctl 错误
 func syncLoggerSafely(logger *zap.Logger) error {
-	// 检查当前配置是否输出到控制台
-	if zapConfig.LogInConsole {
-		// 如果配置为输出到控制台，检查是否为交互式终端
-		if !isInteractiveTerminal() {
-			// 非交互式终端（如重定向、管道、CI环境），跳过同步
-			return nil
-		}
-	}
-
-	// 尝试同步，但忽略特定的错误
-	if err := logger.Sync(); err != nil {
-		// 检查是否为已知的无害错误
-		if isHarmlessSyncError(err) {
-			return nil
-		}
-		return err
-	}
-
-	return nil
-}
-
-// isInteractiveTerminal 检查是否为交互式终端
-func isInteractiveTerminal() bool {
-	// 检查 stdout 是否连接到终端
-	if fileInfo, err := os.Stdout.Stat(); err == nil {
-		// 如果是字符设备，通常表示连接到终端
-		return (fileInfo.Mode() & os.ModeCharDevice) != 0
-	}
-	return false
-}
-
-// isHarmlessSyncError 检查是否为无害的同步错误
-func isHarmlessSyncError(err error) bool {
-	if err == nil {
-		return true
-	}
-
-	errStr := err.Error()
-
-	// 常见的无害错误模式
-	harmlessPatterns := []string{
-		"sync /dev/stdout: inappropriate ioctl for device",
-		"sync /dev/stderr: inappropriate ioctl for device",
-		"sync /dev/stdout: invalid argument",
-		"sync /dev/stderr: invalid argument",
-		"sync /dev/stdout: operation not supported",
-		"sync /dev/stderr: operation not supported",
-		"sync /dev/stdout: bad file descriptor",
-		"sync /dev/stderr: bad file descriptor",
-		"inappropriate ioctl",
-		"invalid argument",
-		"bad file descriptor",
-	}
-
-	for _, pattern := range harmlessPatterns {
-		if strings.Contains(errStr, pattern) {
-			return true
-		}
-	}
-
-	return false
+	return logger.Sync()
 }
 
 // StopFlag 检查停止标志
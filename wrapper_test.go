@@ -0,0 +1,80 @@
+package mlog
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// dpanicRecovered 调用 fn，返回是否 panic 以及 panic 时日志文件的内容
+func dpanicRecovered(t *testing.T, path string, fn func()) (panicked bool, content string) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			content = readLogFile(t, path)
+		}
+	}()
+	fn()
+	return false, ""
+}
+
+// TestDPanicDevelopment 测试开发模式下 DPanic 先写入日志再 panic
+func TestDPanicDevelopment(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, SingleFile: true, Development: true}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	path := filepath.Join(dir, "2/gate/all.log")
+	panicked, content := dpanicRecovered(t, path, func() { DPanicW("不应该发生") })
+	if !panicked {
+		t.Fatal("开发模式下 DPanic 应 panic")
+	}
+	if !strings.Contains(content, "不应该发生") {
+		t.Fatalf("panic 之前应已写入日志: %s", content)
+	}
+}
+
+// TestDPanicProduction 测试生产模式下 DPanic 只记录日志
+func TestDPanicProduction(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, SingleFile: true}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	path := filepath.Join(dir, "2/gate/all.log")
+	if panicked, _ := dpanicRecovered(t, path, func() { DPanic("不应该发生 %d", 1) }); panicked {
+		t.Fatal("生产模式下 DPanic 不应 panic")
+	}
+	Close()
+	if content := readLogFile(t, path); !strings.Contains(content, "不应该发生 1") || !strings.Contains(content, "dpanic") {
+		t.Fatalf("生产模式下应记录 DPanic 日志: %s", content)
+	}
+}
+
+// TestDPanicFlushesAsync 测试异步模式下 DPanic 先写出队列中之前的日志再 panic
+func TestDPanicFlushesAsync(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, SingleFile: true, Development: true, EnableAsync: true}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	path := filepath.Join(dir, "2/gate/all.log")
+	panicked, content := dpanicRecovered(t, path, func() {
+		for i := 0; i < 100; i++ {
+			Info("排队日志 %d", i)
+		}
+		DPanicW("不应该发生")
+	})
+	if !panicked {
+		t.Fatal("开发模式下 DPanic 应 panic")
+	}
+	last := strings.Index(content, "排队日志 99")
+	if last < 0 || last > strings.Index(content, "不应该发生") {
+		t.Fatalf("panic 之前队列中的日志应已按顺序写入: %s", content)
+	}
+}
@@ -64,8 +64,8 @@ func zapUpdateLevel(logLevel string) {
 	// 更新 zapConfig 配置
 	zapConfig.Level = logLevel
 
-	// 使用原子级别控制器动态更新日志级别
-	atomicLevel.SetLevel(level)
+	// 使用原子级别控制器动态更新日志级别（包含模块级别覆盖）
+	applyLevel(level)
 
 	// 更新级别缓存映射（使用锁保护）
 	levelCacheMutex.Lock()
@@ -73,7 +73,7 @@ func zapUpdateLevel(logLevel string) {
 	levelCacheMutex.Unlock()
 
 	// 仅在 Debug 级别记录级别更新（减少日志噪音）
-	if atomicLevel.Level() <= zapcore.DebugLevel {
+	if level <= zapcore.DebugLevel {
 		logger, ok := getLogger()
 		if ok {
 			// 为 UpdateLevel 调用创建带有正确 caller skip 的 logger
@@ -106,107 +106,13 @@ func zapCheckLevel(logLevel string) bool {
 		levelCacheMutex.Unlock()
 	}
 
-	// 使用原子级别控制器获取当前级别
-	currentLevel := atomicLevel.Level()
+	// 使用全局级别判断，不含模块级别覆盖
+	currentLevel := globalLevel()
 	return currentLevel <= checkLevel
 }
 
-// zapDebug 调试日志
-func zapDebug(msg string, args ...any) {
-	//是否开启异步日志
-	if isAsyncEnabled() {
-		debugAsync(msg, args)
-	} else {
-		logger, ok := getLogger()
-		if !ok {
-			ExitGame("zapLogger 还没有初始化，请先调用 InitialZap")
-			return
-		}
-
-		// 为 mlog 包装函数调用创建带有正确 caller skip 的 logger
-		// 调用栈：用户代码 -> mlog.Debug() -> zapDebug() -> logger.Debug()
-		// 需要跳过 2 层：zapDebug() 和 mlog.Debug()
-		loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(2))
-
-		// 格式化消息
-		formattedMsg := formatMessage(msg, args, false)
-		loggerWithSkip.Debug(formattedMsg)
-	}
-}
-
-// zapInfo 信息日志
-func zapInfo(arg0 string, args ...any) {
-	//是否开启异步日志
-	if isAsyncEnabled() {
-		infoAsync(arg0, args)
-	} else {
-		logger, ok := getLogger()
-		if !ok {
-			ExitGame("zapLogger 还没有初始化，请先调用 InitialZap")
-			return
-		}
-
-		// 为 mlog 包装函数调用创建带有正确 caller skip 的 logger
-		// 调用栈：用户代码 -> mlog.Info() -> zapInfo() -> logger.Info()
-		// 需要跳过 2 层：zapInfo() 和 mlog.Info()
-		loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(2))
-
-		// 格式化消息
-		formattedMsg := formatMessage(arg0, args, false)
-		loggerWithSkip.Info(formattedMsg)
-	}
-}
-
-// zapWarn 警告日志
-func zapWarn(arg0 string, args ...any) {
-	//是否开启异步日志
-	if isAsyncEnabled() {
-		warnAsync(arg0, args)
-	} else {
-		logger, ok := getLogger()
-		if !ok {
-			ExitGame("zapLogger 还没有初始化，请先调用 InitialZap")
-			return
-		}
-
-		// 为 mlog 包装函数调用创建带有正确 caller skip 的 logger
-		// 调用栈：用户代码 -> mlog.Warn() -> zapWarn() -> logger.Warn()
-		// 需要跳过 2 层：zapWarn() 和 mlog.Warn()
-		loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(2))
-
-		// 格式化消息
-		formattedMsg := formatMessage(arg0, args, false)
-		loggerWithSkip.Warn(formattedMsg)
-	}
-}
-
-// zapError 错误日志
-func zapError(arg0 string, args ...any) {
-	//是否开启异步日志
-	if isAsyncEnabled() {
-		errorAsync(arg0, args)
-	} else {
-		logger, ok := getLogger()
-		if !ok {
-			ExitGame("zapLogger 还没有初始化，请先调用 InitialZap")
-			return
-		}
-
-		// 为 mlog 包装函数调用创建带有正确 caller skip 的 logger
-		// 调用栈：用户代码 -> mlog.Error() -> zapError() -> logger.Error()
-		// 需要跳过 2 层：zapError() 和 mlog.Error()
-		loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(2))
-
-		// 格式化消息
-		formattedMsg := formatMessage(arg0, args, false)
-		loggerWithSkip.Error(formattedMsg)
-	}
-}
-
-// zapReturnError 返回错误
-func zapReturnError(arg0 string, args ...any) error {
-	zapError(arg0, args...)
-
+// formatError 按日志消息的格式化规则构造 error
+func formatError(arg0 string, args ...any) error {
 	// 优化的错误消息格式化
 	if len(args) == 0 {
 		// 无参数情况，直接使用原始字符串
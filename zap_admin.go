@@ -0,0 +1,213 @@
+package mlog
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// maxAdminBodyBytes 管理接口请求体的最大字节数
+const maxAdminBodyBytes = 4 << 10
+
+// AdminStats GET /stats 返回的统计信息
+type AdminStats struct {
+	Level          string                     `json:"level"`                  // 全局日志级别
+	LogInConsole   bool                       `json:"log_in_console"`         // 是否输出控制台
+	Async          *QueueStats                `json:"async,omitempty"`        // 异步队列汇总，未启用异步时为空
+	AsyncQueues    map[string]QueueStats      `json:"async_queues,omitempty"` // 各异步队列，键为 default 或级别名
+	SamplerDropped map[string]uint64          `json:"sampler_dropped"`        // 各级别被 Core 采样丢弃的累计条数
+	DroppedEvicted uint64                     `json:"dropped_evicted"`        // 计数表已满被淘汰、未能附加到 sampled_dropped 的丢弃条数
+	RemoteSinks    map[string]RemoteSinkStats `json:"remote_sinks"`           // 各远程输出的缓冲和发送统计
+	PathCache      PathCacheStats             `json:"path_cache"`             // 相对路径缓存
+}
+
+// PathCacheStats 相对路径缓存的统计信息，未开启 UseRelativePath 时全部为 0
+type PathCacheStats struct {
+	Size    int     `json:"size"`     // 缓存的路径数
+	Hits    uint64  `json:"hits"`     // 累计命中次数
+	Misses  uint64  `json:"misses"`   // 累计未命中次数
+	HitRate float64 `json:"hit_rate"` // 命中率（0-1）
+}
+
+// ServeAdmin 在 addr 上启动日志管理 HTTP 接口，返回用于关闭的函数
+// 所有请求需带 Authorization: Bearer <AdminToken>，未配置 AdminToken 时返回错误。接口：
+//
+//	GET    /level           查询全局日志级别和所有模块的日志级别
+//	PUT    /level           修改全局日志级别，请求体 {"level":"debug"}
+//	PUT    /level/{module}  修改模块的日志级别（见 SetModuleLevel），请求体 {"level":"debug"}
+//	DELETE /level/{module}  取消模块的日志级别
+//	POST   /flush           写出异步队列中的日志并同步所有日志文件
+//	GET    /stats           异步队列、丢弃条数、远程输出和路径缓存的统计信息
+//	GET    /console         查询是否输出控制台
+//	PUT    /console         开启或关闭控制台输出（直接切换，不重建日志器），请求体 {"enabled":true}
+//
+// 管理接口只应监听内网或本机地址
+func ServeAdmin(addr string) (stop func(), err error) {
+	token := GetConfig().AdminToken
+	if token == "" {
+		return nil, errors.New("mlog: 未配置 admin-token，不能启动管理接口")
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("mlog: 管理接口监听失败 [%s]: %w", addr, err)
+	}
+	srv := &http.Server{Handler: newAdminHandler(token), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "[mlog] 管理接口退出: %v\n", err)
+		}
+	}()
+	return func() { srv.Close() }, nil
+}
+
+// newAdminHandler 创建校验令牌的管理接口处理器
+func newAdminHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /level", handleGetLevel)
+	mux.HandleFunc("PUT /level", handlePutLevel)
+	mux.HandleFunc("PUT /level/{module...}", handlePutModuleLevel)
+	mux.HandleFunc("DELETE /level/{module...}", handleDeleteModuleLevel)
+	mux.HandleFunc("POST /flush", handleFlush)
+	mux.HandleFunc("GET /stats", handleStats)
+	mux.HandleFunc("GET /console", handleGetConsole)
+	mux.HandleFunc("PUT /console", handlePutConsole)
+
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			writeAdminError(w, http.StatusUnauthorized, "令牌无效")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxAdminBodyBytes)
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// levelBody 级别接口的请求和响应
+type levelBody struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules,omitempty"` // 模块的日志级别，只在响应中返回
+}
+
+// consoleBody 控制台接口的请求和响应
+type consoleBody struct {
+	Enabled bool `json:"enabled"`
+}
+
+func handleGetLevel(w http.ResponseWriter, r *http.Request) {
+	writeAdminJSON(w, http.StatusOK, levelBody{Level: currentLevel(), Modules: ModuleLevels()})
+}
+
+func handlePutLevel(w http.ResponseWriter, r *http.Request) {
+	var body levelBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "解析请求失败: "+err.Error())
+		return
+	}
+	if _, err := zapcore.ParseLevel(body.Level); err != nil || body.Level == "" {
+		writeAdminError(w, http.StatusBadRequest, "日志级别无效: "+body.Level)
+		return
+	}
+	if !isInitialized() {
+		writeAdminError(w, http.StatusServiceUnavailable, "日志系统未初始化")
+		return
+	}
+	UpdateLevel(body.Level)
+	writeAdminJSON(w, http.StatusOK, levelBody{Level: currentLevel()})
+}
+
+func handlePutModuleLevel(w http.ResponseWriter, r *http.Request) {
+	var body levelBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "解析请求失败: "+err.Error())
+		return
+	}
+	if body.Level == "" {
+		writeAdminError(w, http.StatusBadRequest, "日志级别不能为空")
+		return
+	}
+	if err := SetModuleLevel(r.PathValue("module"), body.Level); err != nil {
+		writeAdminError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, levelBody{Level: currentLevel(), Modules: ModuleLevels()})
+}
+
+func handleDeleteModuleLevel(w http.ResponseWriter, r *http.Request) {
+	SetModuleLevel(r.PathValue("module"), "")
+	writeAdminJSON(w, http.StatusOK, levelBody{Level: currentLevel(), Modules: ModuleLevels()})
+}
+
+func handleFlush(w http.ResponseWriter, r *http.Request) {
+	Flush()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleStats(w http.ResponseWriter, r *http.Request) {
+	writeAdminJSON(w, http.StatusOK, collectAdminStats())
+}
+
+func handleGetConsole(w http.ResponseWriter, r *http.Request) {
+	writeAdminJSON(w, http.StatusOK, consoleBody{Enabled: GetConfig().LogInConsole})
+}
+
+func handlePutConsole(w http.ResponseWriter, r *http.Request) {
+	var body consoleBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeAdminError(w, http.StatusBadRequest, "解析请求失败: "+err.Error())
+		return
+	}
+	SetLogInConsole(body.Enabled)
+	writeAdminJSON(w, http.StatusOK, consoleBody{Enabled: GetConfig().LogInConsole})
+}
+
+// currentLevel 返回当前的全局日志级别，未初始化时返回配置中的级别
+func currentLevel() string {
+	globalMutex.RLock()
+	defer globalMutex.RUnlock()
+	if isInitialized() {
+		return globalLevel().String()
+	}
+	return zapConfig.Level
+}
+
+// collectAdminStats 汇总当前的统计信息
+func collectAdminStats() AdminStats {
+	stats := AdminStats{
+		Level:          currentLevel(),
+		LogInConsole:   GetConfig().LogInConsole,
+		SamplerDropped: SamplerDroppedCounts(),
+		DroppedEvicted: SampledDroppedEvicted(),
+		RemoteSinks:    RemoteSinkStatsAll(),
+	}
+	if logger, ok := getAsyncLogger(); ok {
+		total := logger.QueueStats()
+		stats.Async = &total
+		stats.AsyncQueues = logger.LevelQueueStats()
+	}
+	hits, misses := globalPathCache.GetCacheStats()
+	stats.PathCache = PathCacheStats{Size: globalPathCache.cacheSize(), Hits: uint64(hits), Misses: uint64(misses)}
+	if hits+misses > 0 {
+		stats.PathCache.HitRate = float64(hits) / float64(hits+misses)
+	}
+	return stats
+}
+
+// writeAdminJSON 以 JSON 写出响应
+func writeAdminJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeAdminError 以 {"error": msg} 写出错误响应
+func writeAdminError(w http.ResponseWriter, status int, msg string) {
+	writeAdminJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,102 @@
+package mlog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestAdminHandler 测试管理接口的令牌校验、级别修改、控制台开关和统计信息
+func TestAdminHandler(t *testing.T) {
+	Close()
+	config := ZapConfig{Level: "info", Director: t.TempDir(), MaxSize: 10, SingleFile: true, EnableAsync: true, BufferSizeKB: 16}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	srv := httptest.NewServer(newAdminHandler("secret"))
+	defer srv.Close()
+	do := func(method, path, token, body string) (*http.Response, map[string]any) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var result map[string]any
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp, result
+	}
+
+	if resp, _ := do("GET", "/level", "", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("没有令牌时应返回 401: %d", resp.StatusCode)
+	}
+	if resp, _ := do("GET", "/level", "wrong", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("令牌错误时应返回 401: %d", resp.StatusCode)
+	}
+
+	if resp, body := do("PUT", "/level", "secret", `{"level":"debug"}`); resp.StatusCode != http.StatusOK || body["level"] != "debug" {
+		t.Fatalf("修改级别失败: %d %v", resp.StatusCode, body)
+	}
+	if _, body := do("GET", "/level", "secret", ""); body["level"] != "debug" {
+		t.Fatalf("查询级别错误: %v", body)
+	}
+	if resp, _ := do("PUT", "/level", "secret", `{"level":"verbose"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("无效的级别应返回 400: %d", resp.StatusCode)
+	}
+	if resp, body := do("PUT", "/level/server/battle", "secret", `{"level":"warn"}`); resp.StatusCode != http.StatusOK ||
+		body["modules"].(map[string]any)["server/battle"] != "warn" {
+		t.Fatalf("修改模块级别失败: %d %v", resp.StatusCode, body)
+	}
+	if resp, body := do("DELETE", "/level/server/battle", "secret", ""); resp.StatusCode != http.StatusOK || body["modules"] != nil {
+		t.Fatalf("取消模块级别失败: %d %v", resp.StatusCode, body)
+	}
+
+	// 控制台开关直接切换，不重建日志器和异步日志器
+	console, err := os.CreateTemp(t.TempDir(), "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer console.Close()
+	oldConsole := consoleSink
+	consoleSink = newConsoleWriteSyncer(console)
+	defer func() { consoleSink = oldConsole }()
+	logger := GLOG()
+	al, _ := getAsyncLogger()
+	if resp, body := do("PUT", "/console", "secret", `{"enabled":true}`); resp.StatusCode != http.StatusOK || body["enabled"] != true ||
+		!GetConfig().LogInConsole || GetConfig().Level != "debug" {
+		t.Fatalf("开启控制台输出失败: %d %v", resp.StatusCode, body)
+	}
+	// 异步日志在写入时判断控制台开关，切换前先刷新
+	InfoW("控制台日志")
+	Flush()
+	SetLogInConsole(false)
+	InfoW("只写文件")
+	Flush()
+	if current, _ := getAsyncLogger(); GLOG() != logger || current != al {
+		t.Fatal("切换控制台输出不应重建日志器")
+	}
+	if data, _ := os.ReadFile(console.Name()); !strings.Contains(string(data), "控制台日志") || strings.Contains(string(data), "只写文件") {
+		t.Fatalf("控制台输出应按开关切换: %s", data)
+	}
+
+	InfoW("统计")
+	if resp, _ := do("POST", "/flush", "secret", ""); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("刷新应返回 204: %d", resp.StatusCode)
+	}
+	resp, body := do("GET", "/stats", "secret", "")
+	async, _ := body["async"].(map[string]any)
+	if resp.StatusCode != http.StatusOK || body["level"] != "debug" || async == nil || async["processed"].(float64) < 1 {
+		t.Fatalf("统计信息错误: %d %v", resp.StatusCode, body)
+	}
+
+	if _, err := ServeAdmin("127.0.0.1:0"); err == nil {
+		t.Fatal("未配置令牌时不应启动管理接口")
+	}
+}
@@ -0,0 +1,373 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 告警 Webhook 的消息格式
+const (
+	AlertFormatFeishu   = "feishu"   // 飞书自定义机器人
+	AlertFormatDingTalk = "dingtalk" // 钉钉自定义机器人
+	AlertFormatSlack    = "slack"    // Slack Incoming Webhook
+	AlertFormatJSON     = "json"     // 通用 JSON
+)
+
+// 告警 Webhook 的默认参数
+const (
+	defaultAlertRateLimit   = 10
+	defaultAlertDedupWindow = 5 * time.Minute
+)
+
+// emergencyDirectory Critical 和 Disaster 日志使用的分类目录
+const emergencyDirectory = "emergency"
+
+// AlertWebhookConfig Critical/Disaster 告警 Webhook 配置
+type AlertWebhookConfig struct {
+	Enable bool   `mapstructure:"enable" json:"enable" yaml:"enable"` // 启用告警 Webhook
+	URL    string `mapstructure:"url" json:"url" yaml:"url"`          // Webhook 地址
+	Format string `mapstructure:"format" json:"format" yaml:"format"` // feishu、dingtalk、slack 或 json（默认 json）
+	Secret string `mapstructure:"secret" json:"secret" yaml:"secret"` // 飞书、钉钉机器人的签名密钥（未开启签名校验时留空）
+	// 触发告警的最低严重程度：critical（Critical 和 Disaster）或 disaster（仅 Disaster），默认 critical
+	MinSeverity    string `mapstructure:"min-severity" json:"min-severity" yaml:"min-severity"`
+	RateLimit      int    `mapstructure:"rate-limit" json:"rate-limit" yaml:"rate-limit"`                   // 每分钟最多发送的告警数（默认 10）
+	DedupWindowSec int    `mapstructure:"dedup-window-sec" json:"dedup-window-sec" yaml:"dedup-window-sec"` // 相同告警的合并窗口（秒，默认 300）
+}
+
+// alertEvent 一条待发送的告警
+type alertEvent struct {
+	Severity   string         `json:"severity"` // critical 或 disaster
+	Service    string         `json:"service"`
+	ServiceID  uint64         `json:"service_id"`
+	Host       string         `json:"host"`
+	Time       time.Time      `json:"time"`
+	Caller     string         `json:"caller,omitempty"`
+	Message    string         `json:"message"`
+	Fields     map[string]any `json:"fields,omitempty"`
+	Suppressed int            `json:"suppressed,omitempty"` // 上一次告警之后被合并或限流的条数
+}
+
+// alertLimiter 告警去重和限流状态，同一个告警 Core 的所有副本共享
+type alertLimiter struct {
+	mu          sync.Mutex
+	rate        int
+	window      time.Duration
+	minuteStart time.Time
+	sentInMin   int
+	lastSent    map[string]time.Time // 告警键到最近一次发送时间
+	suppressed  int
+}
+
+// admit 判断告警是否发送，返回自上次发送以来被抑制的条数
+func (l *alertLimiter) admit(key string, now time.Time) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if last, ok := l.lastSent[key]; ok && now.Sub(last) < l.window {
+		l.suppressed++
+		return false, 0
+	}
+	if now.Sub(l.minuteStart) >= time.Minute {
+		l.minuteStart = now
+		l.sentInMin = 0
+	}
+	if l.sentInMin >= l.rate {
+		l.suppressed++
+		return false, 0
+	}
+	l.sentInMin++
+	l.lastSent[key] = now
+	// 清理过期的去重记录
+	if len(l.lastSent) > 1024 {
+		for k, t := range l.lastSent {
+			if now.Sub(t) >= l.window {
+				delete(l.lastSent, k)
+			}
+		}
+	}
+	suppressed := l.suppressed
+	l.suppressed = 0
+	return true, suppressed
+}
+
+// alertWebhook 告警 Webhook 客户端
+type alertWebhook struct {
+	url        string
+	format     string
+	secret     string
+	httpClient *http.Client
+}
+
+// newAlertCore 创建告警 Core
+// 只处理 directory 为 emergency 的日志：Warn 级别为 Critical，Error 及以上为 Disaster
+func newAlertCore(cfg AlertWebhookConfig, serviceName string, serviceID uint64) (*alertCore, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("未配置告警 Webhook 地址")
+	}
+	format := cfg.Format
+	if format == "" {
+		format = AlertFormatJSON
+	}
+	switch format {
+	case AlertFormatFeishu, AlertFormatDingTalk, AlertFormatSlack, AlertFormatJSON:
+	default:
+		return nil, fmt.Errorf("不支持的告警消息格式: %s", format)
+	}
+	minLevel := zapcore.WarnLevel
+	switch cfg.MinSeverity {
+	case "", "critical":
+	case "disaster":
+		minLevel = zapcore.ErrorLevel
+	default:
+		return nil, fmt.Errorf("不支持的告警严重程度: %s", cfg.MinSeverity)
+	}
+	window := defaultAlertDedupWindow
+	if cfg.DedupWindowSec > 0 {
+		window = time.Duration(cfg.DedupWindowSec) * time.Second
+	}
+
+	webhook := &alertWebhook{
+		url:        cfg.URL,
+		format:     format,
+		secret:     cfg.Secret,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	// 告警逐条发送，关闭时尽量发送完剩余告警
+	batcher := newRemoteBatcher("webhook", RemoteBatchConfig{BufferSize: 100, BatchSize: 1, FlushIntervalMs: 100}, webhook.send)
+	addSinkCloser(batcher)
+
+	host, _ := os.Hostname()
+	return &alertCore{
+		LevelEnabler: minLevel,
+		batcher:      batcher,
+		limiter: &alertLimiter{
+			rate:     positiveOr(cfg.RateLimit, defaultAlertRateLimit),
+			window:   window,
+			lastSent: make(map[string]time.Time),
+		},
+		service:   serviceName,
+		serviceID: serviceID,
+		host:      host,
+	}, nil
+}
+
+// send 发送一条告警
+func (w *alertWebhook) send(ctx context.Context, batch []remoteRecord) error {
+	for i := range batch {
+		var event alertEvent
+		if err := json.Unmarshal(batch[i].Data, &event); err != nil {
+			return &remotePartialError{Retry: batch[i+1:], Rejected: 1, Err: err}
+		}
+		if err := w.post(ctx, &event); err != nil {
+			return &remotePartialError{Retry: batch[i:], Err: err}
+		}
+	}
+	return nil
+}
+
+// post 按格式构造消息并发送
+func (w *alertWebhook) post(ctx context.Context, event *alertEvent) error {
+	target := w.url
+	var payload map[string]any
+	switch w.format {
+	case AlertFormatFeishu:
+		payload = map[string]any{
+			"msg_type": "text",
+			"content":  map[string]any{"text": event.text()},
+		}
+		if w.secret != "" {
+			// 飞书签名：以 timestamp + "\n" + secret 为密钥对空串做 HMAC-SHA256
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			mac := hmac.New(sha256.New, []byte(timestamp+"\n"+w.secret))
+			payload["timestamp"] = timestamp
+			payload["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		}
+	case AlertFormatDingTalk:
+		payload = map[string]any{
+			"msgtype": "text",
+			"text":    map[string]any{"content": event.text()},
+		}
+		if w.secret != "" {
+			// 钉钉签名：以 secret 为密钥对 timestamp + "\n" + secret 做 HMAC-SHA256，放在查询参数中
+			timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+			mac := hmac.New(sha256.New, []byte(w.secret))
+			mac.Write([]byte(timestamp + "\n" + w.secret))
+			sep := "?"
+			if strings.Contains(target, "?") {
+				sep = "&"
+			}
+			target += sep + "timestamp=" + timestamp + "&sign=" + url.QueryEscape(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		}
+	case AlertFormatSlack:
+		payload = map[string]any{"text": event.text()}
+	default:
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		return w.postJSON(ctx, target, data)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return w.postJSON(ctx, target, data)
+}
+
+// postJSON 发送 JSON 请求，飞书和钉钉的业务错误码非 0 时也视为失败
+func (w *alertWebhook) postJSON(ctx context.Context, target string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("告警 Webhook 返回 %s: %s", resp.Status, body)
+	}
+	var result struct {
+		Code    *int   `json:"code"`
+		ErrCode *int   `json:"errcode"`
+		Msg     string `json:"msg"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if json.Unmarshal(body, &result) == nil {
+		if result.Code != nil && *result.Code != 0 {
+			return fmt.Errorf("告警 Webhook 返回错误: %d %s", *result.Code, result.Msg)
+		}
+		if result.ErrCode != nil && *result.ErrCode != 0 {
+			return fmt.Errorf("告警 Webhook 返回错误: %d %s", *result.ErrCode, result.ErrMsg)
+		}
+	}
+	return nil
+}
+
+// text 生成文本格式的告警内容
+func (e *alertEvent) text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[%s] %s(%d) @ %s\n", strings.ToUpper(e.Severity), e.Service, e.ServiceID, e.Host)
+	fmt.Fprintf(&sb, "时间: %s\n", e.Time.Format("2006-01-02 15:04:05.000"))
+	if e.Caller != "" {
+		fmt.Fprintf(&sb, "位置: %s\n", e.Caller)
+	}
+	sb.WriteString(e.Message)
+	if len(e.Fields) > 0 {
+		keys := make([]string, 0, len(e.Fields))
+		for k := range e.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "\n%s: %v", k, e.Fields[k])
+		}
+	}
+	if e.Suppressed > 0 {
+		fmt.Fprintf(&sb, "\n（此前另有 %d 条告警被合并或限流）", e.Suppressed)
+	}
+	return sb.String()
+}
+
+// alertCore 将 Critical/Disaster 日志转换为告警的 zapcore.Core
+type alertCore struct {
+	zapcore.LevelEnabler
+	batcher   *remoteBatcher
+	limiter   *alertLimiter
+	service   string
+	serviceID uint64
+	host      string
+	fields    []zapcore.Field // 通过 With 附加的字段
+	emergency bool            // With 附加了 directory=emergency
+}
+
+func (c *alertCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(append([]zapcore.Field(nil), c.fields...), fields...)
+	clone.emergency = c.emergency || hasEmergencyField(fields)
+	return &clone
+}
+
+func (c *alertCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *alertCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if !c.emergency && !hasEmergencyField(fields) {
+		return nil
+	}
+	event := alertEvent{
+		Severity:  "critical",
+		Service:   c.service,
+		ServiceID: c.serviceID,
+		Host:      c.host,
+		Time:      entry.Time,
+		Message:   entry.Message,
+	}
+	if entry.Level >= zapcore.ErrorLevel {
+		event.Severity = "disaster"
+	}
+	if entry.Caller.Defined {
+		event.Caller = entry.Caller.TrimmedPath()
+	}
+	// 相同位置和内容的告警在窗口内只发送一次
+	ok, suppressed := c.limiter.admit(event.Severity+"|"+event.Caller+"|"+entry.Message, time.Now())
+	if !ok {
+		return nil
+	}
+	event.Suppressed = suppressed
+
+	enc := zapcore.NewMapObjectEncoder()
+	for i := range c.fields {
+		c.fields[i].AddTo(enc)
+	}
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	delete(enc.Fields, "directory")
+	if len(enc.Fields) > 0 {
+		event.Fields = enc.Fields
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	c.batcher.add(remoteRecord{Entry: entry, Data: data})
+	return nil
+}
+
+func (c *alertCore) Sync() error {
+	return nil
+}
+
+// hasEmergencyField 字段中是否包含 directory=emergency
+func hasEmergencyField(fields []zapcore.Field) bool {
+	for i := range fields {
+		if fields[i].Key == "directory" && fields[i].Type == zapcore.StringType && fields[i].String == emergencyDirectory {
+			return true
+		}
+	}
+	return false
+}
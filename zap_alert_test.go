@@ -0,0 +1,76 @@
+package mlog
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TestAlertWebhookFeishu 测试只对 emergency 日志告警、飞书签名和合并窗口
+func TestAlertWebhookFeishu(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		payloads []map[string]any
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		json.Unmarshal(body, &payload)
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+		w.Write([]byte(`{"code":0,"msg":"success"}`))
+	}))
+	defer server.Close()
+
+	core, err := newAlertCore(AlertWebhookConfig{URL: server.URL, Format: AlertFormatFeishu, Secret: "s"}, "game", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := zap.New(core)
+	emergency := zap.String("directory", emergencyDirectory)
+	log.Warn("普通警告")
+	log.Warn("背包数据异常", emergency, zap.Int64("player", 1001))
+	log.Warn("背包数据异常", emergency, zap.Int64("player", 1002))
+	log.Error("存档写入失败", emergency)
+	core.batcher.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(payloads) != 2 {
+		t.Fatalf("告警条数错误: %v", payloads)
+	}
+	if payloads[0]["msg_type"] != "text" || payloads[0]["sign"] == "" || payloads[0]["timestamp"] == "" {
+		t.Fatalf("飞书消息格式错误: %v", payloads[0])
+	}
+	first := payloads[0]["content"].(map[string]any)["text"].(string)
+	if !strings.HasPrefix(first, "[CRITICAL] game(3)") || !strings.Contains(first, "背包数据异常") || !strings.Contains(first, "player: 1001") {
+		t.Fatalf("告警内容错误: %s", first)
+	}
+	second := payloads[1]["content"].(map[string]any)["text"].(string)
+	if !strings.HasPrefix(second, "[DISASTER]") || !strings.Contains(second, "另有 1 条告警") {
+		t.Fatalf("告警内容错误: %s", second)
+	}
+}
+
+// TestAlertLimiter 测试每分钟限流
+func TestAlertLimiter(t *testing.T) {
+	l := &alertLimiter{rate: 2, window: time.Minute, lastSent: make(map[string]time.Time)}
+	now := time.Now()
+	for i, want := range []bool{true, true, false} {
+		if ok, _ := l.admit(string(rune('a'+i)), now); ok != want {
+			t.Fatalf("第 %d 条告警: 期望 %v", i, want)
+		}
+	}
+	ok, suppressed := l.admit("d", now.Add(time.Minute))
+	if !ok || suppressed != 1 {
+		t.Fatalf("限流窗口重置错误: %v %d", ok, suppressed)
+	}
+}
@@ -0,0 +1,422 @@
+package mlog
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 轮转文件归档的默认参数
+const (
+	defaultArchiveKeyLayout    = "{service}/{id}/{date}/{dir}{level}-{time}{ext}"
+	defaultArchiveScanInterval = time.Minute
+	archiveMaxRetryDelay       = time.Hour
+	archiveUploadTimeout       = 10 * time.Minute
+	// archiveUploadedSuffix 保留本地文件时记录上传完成的标记文件后缀，扫描时跳过带标记的文件
+	archiveUploadedSuffix = ".uploaded"
+)
+
+// lumberjackBackupTimeFormat lumberjack 备份文件名中的时间格式
+const lumberjackBackupTimeFormat = "2006-01-02T15-04-05.000"
+
+// lumberjackBackupPattern 匹配 lumberjack 轮转生成的备份文件：<名称>-<时间><扩展名>[.gz|.zst][.enc]
+var lumberjackBackupPattern = regexp.MustCompile(`^(.+)-(\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}\.\d{3})(\.[^.]+)(\.gz|\.zst)?(\.enc)?$`)
+
+// ArchiveConfig 轮转日志文件归档配置
+// 兼容 S3 协议的对象存储均可使用（AWS S3、阿里云 OSS、MinIO 等），也可通过 SetObjectUploader 自定义上传方式
+type ArchiveConfig struct {
+	Enable          bool   `mapstructure:"enable" json:"enable" yaml:"enable"`                                  // 启用轮转文件归档
+	Endpoint        string `mapstructure:"endpoint" json:"endpoint" yaml:"endpoint"`                            // 对象存储地址，如 https://s3.ap-east-1.amazonaws.com、https://oss-cn-hangzhou.aliyuncs.com
+	Region          string `mapstructure:"region" json:"region" yaml:"region"`                                  // 签名使用的区域，如 ap-east-1、oss-cn-hangzhou
+	Bucket          string `mapstructure:"bucket" json:"bucket" yaml:"bucket"`                                  // 存储桶
+	AccessKeyID     string `mapstructure:"access-key-id" json:"access-key-id" yaml:"access-key-id"`             // 访问密钥 ID
+	SecretAccessKey string `mapstructure:"secret-access-key" json:"secret-access-key" yaml:"secret-access-key"` // 访问密钥
+	PathStyle       bool   `mapstructure:"path-style" json:"path-style" yaml:"path-style"`                      // 使用路径形式的地址（MinIO 等），默认使用虚拟主机形式
+	// 对象键模板，可用占位符：{service} {id} {date} {time} {dir} {level} {ext}
+	// {level} 为不含扩展名的日志文件名（按级别分文件时即级别名），{dir} 为分类子目录（如 pay/），{ext} 含 .gz/.zst 后缀
+	KeyLayout       string `mapstructure:"key-layout" json:"key-layout" yaml:"key-layout"`
+	ScanIntervalSec int    `mapstructure:"scan-interval-sec" json:"scan-interval-sec" yaml:"scan-interval-sec"` // 扫描轮转文件的间隔（秒，默认 60）
+	KeepLocal       bool   `mapstructure:"keep-local" json:"keep-local" yaml:"keep-local"`                      // 上传成功后保留本地文件（默认删除），并创建 .uploaded 标记避免重复上传
+}
+
+// ObjectUploader 对象存储上传接口
+// 上传失败时文件保留在本地，之后按指数退避重试
+type ObjectUploader interface {
+	Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error
+}
+
+var (
+	objectUploader      ObjectUploader
+	objectUploaderMutex sync.RWMutex
+)
+
+// SetObjectUploader 设置自定义的对象存储上传方式，需要在 InitialZap 之前调用，传入 nil 恢复使用内置的 S3 客户端
+func SetObjectUploader(uploader ObjectUploader) {
+	objectUploaderMutex.Lock()
+	objectUploader = uploader
+	objectUploaderMutex.Unlock()
+}
+
+// getObjectUploader 获取当前的自定义上传方式
+func getObjectUploader() ObjectUploader {
+	objectUploaderMutex.RLock()
+	defer objectUploaderMutex.RUnlock()
+	return objectUploader
+}
+
+// archiveRetry 上传失败文件的重试状态
+type archiveRetry struct {
+	next  time.Time
+	delay time.Duration
+}
+
+// logArchiver 轮转日志文件归档器
+// lumberjack 没有轮转回调，这里定期扫描日志目录中的备份文件：启用压缩时只上传压缩完成的 .gz/.zst 文件，
+// 上传成功后删除本地文件，保留本地文件（KeepLocal）时在旁边创建 .uploaded 标记，避免重复上传。注意 MaxBackups/MaxAge 清理仍由 lumberjack 执行，可能先于上传删除文件。
+type logArchiver struct {
+	root        string // 服务日志目录
+	serviceName string
+	serviceID   uint64
+	layout      string
+	compressed  bool
+	encrypted   bool
+	keepLocal   bool
+	interval    time.Duration
+	uploader    ObjectUploader
+
+	retries map[string]*archiveRetry
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// newLogArchiver 创建轮转日志文件归档器并启动后台扫描
+func newLogArchiver(cfg ArchiveConfig, serviceName string, serviceID uint64) (*logArchiver, error) {
+	uploader := getObjectUploader()
+	if uploader == nil {
+		s3, err := newS3Uploader(cfg)
+		if err != nil {
+			return nil, err
+		}
+		uploader = s3
+	}
+	a := &logArchiver{
+		root:        logDirFor(serviceName, serviceID),
+		serviceName: serviceName,
+		serviceID:   serviceID,
+		layout:      cfg.KeyLayout,
+		compressed:  zapConfig.EnableCompress,
+		encrypted:   zapConfig.Encryption.Enable,
+		keepLocal:   cfg.KeepLocal,
+		interval:    defaultArchiveScanInterval,
+		uploader:    uploader,
+		retries:     make(map[string]*archiveRetry),
+		done:        make(chan struct{}),
+	}
+	if a.layout == "" {
+		a.layout = defaultArchiveKeyLayout
+	}
+	if cfg.ScanIntervalSec > 0 {
+		a.interval = time.Duration(cfg.ScanIntervalSec) * time.Second
+	}
+	a.wg.Add(1)
+	go a.run()
+	return a, nil
+}
+
+// run 后台扫描循环，启动时先扫描一次，上传上次运行遗留的文件
+func (a *logArchiver) run() {
+	defer a.wg.Done()
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		a.scan()
+		select {
+		case <-ticker.C:
+		case <-a.done:
+			return
+		}
+	}
+}
+
+// scan 上传一轮已完成轮转的备份文件
+func (a *logArchiver) scan() {
+	now := time.Now()
+	filepath.WalkDir(a.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		select {
+		case <-a.done:
+			return filepath.SkipAll
+		default:
+		}
+		if strings.HasSuffix(path, archiveUploadedSuffix) {
+			// 备份文件已被 lumberjack 等清理时删除遗留的标记
+			if _, err := os.Stat(strings.TrimSuffix(path, archiveUploadedSuffix)); os.IsNotExist(err) {
+				os.Remove(path)
+			}
+			return nil
+		}
+		key, ok := a.objectKey(path)
+		if !ok {
+			return nil
+		}
+		if _, err := os.Stat(path + archiveUploadedSuffix); err == nil {
+			return nil
+		}
+		if r := a.retries[path]; r != nil && now.Before(r.next) {
+			return nil
+		}
+		if err := a.upload(path, key); err != nil {
+			r := a.retries[path]
+			if r == nil {
+				r = &archiveRetry{delay: a.interval}
+				a.retries[path] = r
+			} else {
+				r.delay = min(r.delay*2, archiveMaxRetryDelay)
+			}
+			r.next = now.Add(r.delay)
+			fmt.Fprintf(os.Stderr, "[mlog] 上传轮转日志失败 %s，%v 后重试: %v\n", path, r.delay, err)
+			return nil
+		}
+		delete(a.retries, path)
+		return nil
+	})
+}
+
+// objectKey 判断文件是否为待上传的备份文件，并按模板生成对象键
+func (a *logArchiver) objectKey(path string) (string, bool) {
+	m := lumberjackBackupPattern.FindStringSubmatch(filepath.Base(path))
+	if m == nil {
+		return "", false
+	}
+	if a.encrypted {
+		// 启用加密时只上传加密完成的文件，加密文件是写完后重命名得到的
+		if m[5] == "" {
+			return "", false
+		}
+	} else if a.compressed {
+		// 启用压缩时原文件在压缩完成后才会被删除，只上传压缩完成的文件
+		if m[4] == "" {
+			return "", false
+		}
+		if _, err := os.Stat(strings.TrimSuffix(path, m[4])); err == nil {
+			return "", false
+		}
+	}
+	t, err := time.ParseInLocation(lumberjackBackupTimeFormat, m[2], time.Local)
+	if err != nil {
+		return "", false
+	}
+	dir, _ := filepath.Rel(a.root, filepath.Dir(path))
+	if dir == "." {
+		dir = ""
+	} else {
+		dir = filepath.ToSlash(dir) + "/"
+	}
+	key := strings.NewReplacer(
+		"{service}", a.serviceName,
+		"{id}", strconv.FormatUint(a.serviceID, 10),
+		"{date}", t.Format("2006-01-02"),
+		"{time}", m[2],
+		"{dir}", dir,
+		"{level}", m[1],
+		"{ext}", m[3]+m[4]+m[5],
+	).Replace(a.layout)
+	return strings.TrimPrefix(key, "/"), true
+}
+
+// upload 上传一个文件，成功后删除本地文件，保留本地文件时创建上传标记
+func (a *logArchiver) upload(path, key string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), archiveUploadTimeout)
+	go func() {
+		// 关闭日志器时中断正在进行的上传，文件留到下次启动再上传
+		select {
+		case <-a.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	err = a.uploader.Upload(ctx, key, f, info.Size())
+	cancel()
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if a.keepLocal {
+		if err := os.WriteFile(path+archiveUploadedSuffix, nil, 0644); err != nil {
+			return fmt.Errorf("记录上传状态失败: %w", err)
+		}
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("删除已上传的文件失败: %w", err)
+	}
+	return nil
+}
+
+// Close 停止后台扫描
+func (a *logArchiver) Close() error {
+	close(a.done)
+	if !waitGroupTimeout(&a.wg, remoteCloseTimeout) {
+		return errors.New("轮转日志归档关闭超时")
+	}
+	return nil
+}
+
+// s3Uploader 兼容 S3 协议的对象存储客户端，使用 AWS Signature V4 签名
+type s3Uploader struct {
+	endpoint   *url.URL
+	region     string
+	bucket     string
+	accessKey  string
+	secretKey  string
+	pathStyle  bool
+	httpClient *http.Client
+}
+
+// newS3Uploader 创建 S3 客户端，地址中的路径（如 https://gateway/s3）作为请求路径的前缀
+func newS3Uploader(cfg ArchiveConfig) (*s3Uploader, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("未配置对象存储地址或存储桶")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("未配置对象存储访问密钥")
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("对象存储地址格式错误: %s", cfg.Endpoint)
+	}
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &s3Uploader{
+		endpoint:   endpoint,
+		region:     region,
+		bucket:     cfg.Bucket,
+		accessKey:  cfg.AccessKeyID,
+		secretKey:  cfg.SecretAccessKey,
+		pathStyle:  cfg.PathStyle,
+		httpClient: &http.Client{Timeout: archiveUploadTimeout},
+	}, nil
+}
+
+// Upload 以 PUT Object 上传文件
+func (u *s3Uploader) Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	// 先计算内容摘要再从头上传，避免把整个文件读入内存
+	hash := sha256.New()
+	if _, err := io.Copy(hash, body); err != nil {
+		return err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	payloadHash := hex.EncodeToString(hash.Sum(nil))
+
+	host := u.endpoint.Host
+	path := "/" + s3URIEncode(key)
+	if u.pathStyle {
+		path = "/" + s3URIEncode(u.bucket) + path
+	} else {
+		host = u.bucket + "." + host
+	}
+	path = s3URIEncode(u.endpoint.Path) + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.endpoint.Scheme+"://"+host+path, io.NopCloser(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.URL.RawPath = path
+	u.sign(req, host, path, payloadHash, time.Now().UTC())
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("对象存储返回 %s: %s", resp.Status, msg)
+	}
+	return nil
+}
+
+// sign 为请求添加 Signature V4 签名
+func (u *s3Uploader) sign(req *http.Request, host, path, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Host = host
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		path,
+		"",
+		"host:" + host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + u.region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+u.secretKey), date)
+	key = hmacSHA256(key, u.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+u.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// hmacSHA256 计算 HMAC-SHA256
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3URIEncode 按 S3 签名规则编码对象键，保留未保留字符和路径分隔符
+func s3URIEncode(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,170 @@
+package mlog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testUploader 记录上传内容，前 failures 次上传返回错误
+type testUploader struct {
+	mu       sync.Mutex
+	failures int
+	objects  map[string]string
+}
+
+func (u *testUploader) Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.failures > 0 {
+		u.failures--
+		return errors.New("网络不可用")
+	}
+	data, _ := io.ReadAll(body)
+	u.objects[key] = string(data)
+	return nil
+}
+
+// TestArchiveUpload 测试只上传压缩完成的备份文件、按模板生成对象键，失败时保留本地文件
+func TestArchiveUpload(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"info.log":                                "当前文件",
+		"info-2024-05-01T10-00-00.000.log.gz":     "压缩完成",
+		"error-2024-05-01T11-00-00.000.log":       "压缩中",
+		"error-2024-05-01T11-00-00.000.log.gz":    "压缩中",
+		"pay/info-2024-05-02T09-30-00.000.log.gz": "分类目录",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	uploader := &testUploader{failures: 1, objects: make(map[string]string)}
+	a := &logArchiver{
+		root:        root,
+		serviceName: "game",
+		serviceID:   7,
+		layout:      defaultArchiveKeyLayout,
+		compressed:  true,
+		interval:    time.Millisecond,
+		uploader:    uploader,
+		retries:     make(map[string]*archiveRetry),
+		done:        make(chan struct{}),
+	}
+	a.scan()
+	time.Sleep(5 * time.Millisecond)
+	a.scan()
+
+	want := map[string]string{
+		"game/7/2024-05-01/info-2024-05-01T10-00-00.000.log.gz":     "压缩完成",
+		"game/7/2024-05-02/pay/info-2024-05-02T09-30-00.000.log.gz": "分类目录",
+	}
+	if len(uploader.objects) != len(want) {
+		t.Fatalf("上传的对象错误: %v", uploader.objects)
+	}
+	for key, content := range want {
+		if uploader.objects[key] != content {
+			t.Fatalf("对象 %s 内容错误: %v", key, uploader.objects)
+		}
+	}
+	for name := range files {
+		_, err := os.Stat(filepath.Join(root, name))
+		uploaded := strings.HasPrefix(name, "pay/") || strings.HasPrefix(name, "info-")
+		if uploaded != os.IsNotExist(err) {
+			t.Fatalf("本地文件 %s 状态错误: %v", name, err)
+		}
+	}
+}
+
+// TestArchiveKeepLocal 测试保留本地文件时上传后创建标记，之后的扫描不再重复上传，备份文件被清理后删除标记
+func TestArchiveKeepLocal(t *testing.T) {
+	root := t.TempDir()
+	backup := filepath.Join(root, "info-2024-05-01T10-00-00.000.log")
+	if err := os.WriteFile(backup, []byte("保留"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	uploads := 0
+	a := &logArchiver{
+		root:        root,
+		serviceName: "game",
+		serviceID:   7,
+		layout:      defaultArchiveKeyLayout,
+		keepLocal:   true,
+		interval:    time.Millisecond,
+		uploader: uploaderFunc(func(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+			uploads++
+			return nil
+		}),
+		retries: make(map[string]*archiveRetry),
+		done:    make(chan struct{}),
+	}
+	a.scan()
+	a.scan()
+	if uploads != 1 {
+		t.Fatalf("保留本地文件时只应上传一次，实际 %d 次", uploads)
+	}
+	if _, err := os.Stat(backup); err != nil {
+		t.Fatalf("本地文件应保留: %v", err)
+	}
+
+	os.Remove(backup)
+	a.scan()
+	if _, err := os.Stat(backup + archiveUploadedSuffix); !os.IsNotExist(err) {
+		t.Fatalf("备份文件清理后应删除上传标记: %v", err)
+	}
+}
+
+// uploaderFunc 函数形式的 ObjectUploader
+type uploaderFunc func(ctx context.Context, key string, body io.ReadSeeker, size int64) error
+
+func (f uploaderFunc) Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	return f(ctx, key, body, size)
+}
+
+// TestS3UploaderSign 测试 S3 上传请求的地址（保留地址中的路径前缀）和签名头
+func TestS3UploaderSign(t *testing.T) {
+	var (
+		path, auth, body string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		auth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	u, err := newS3Uploader(ArchiveConfig{
+		Endpoint:        server.URL + "/gateway/",
+		Region:          "cn-test",
+		Bucket:          "logs",
+		AccessKeyID:     "AK",
+		SecretAccessKey: "SK",
+		PathStyle:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Upload(context.Background(), "game/7/info 1.log.gz", strings.NewReader("hello"), 5); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/gateway/logs/game/7/info%201.log.gz" || body != "hello" {
+		t.Fatalf("请求错误: %s %q", path, body)
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AK/") ||
+		!strings.Contains(auth, "/cn-test/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Fatalf("签名头错误: %s", auth)
+	}
+}
@@ -1,7 +1,8 @@
 package mlog
 
 import (
-	"runtime"
+	"fmt"
+	"os"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -24,19 +25,108 @@ type AsyncLogEntry struct {
 	Extras    []any
 	Caller    zapcore.EntryCaller // 保存原始调用者信息
 	Timestamp time.Time           // 日志产生时的时间戳
+	Seq       uint64              // 进程内单调递增的序号，时间戳相同时用于确定先后顺序
+	Stack     string              // 入队时按 StacktraceLevel 获取的调用栈
+
+	fieldsBuf *[]zap.Field  // 从对象池获取的字段切片，写入完成后归还
+	flushDone chan struct{} // 非空时表示刷新屏障：消费者处理到此处后同步文件并关闭通道
+	flushOnly bool          // 仅作为刷新屏障，不写入日志
+}
+
+// asyncFieldsPool 异步日志字段切片对象池
+// 入队时将调用方的字段复制到池化切片中，调用方的可变参数切片不再逃逸到堆上，
+// 写入完成后清空并归还，降低高吞吐场景下的 GC 压力
+var asyncFieldsPool = sync.Pool{
+	New: func() interface{} {
+		fields := make([]zap.Field, 0, 8)
+		return &fields
+	},
+}
+
+// maxPooledFieldsCap 超过该容量的字段切片不归还对象池，避免偶发的大切片长期占用内存
+const maxPooledFieldsCap = 64
+
+// copyFieldsToEntry 将字段复制到池化切片并挂到条目上
+func (e *AsyncLogEntry) copyFieldsToEntry(fields []zap.Field) {
+	if len(fields) == 0 {
+		return
+	}
+	buf := asyncFieldsPool.Get().(*[]zap.Field)
+	*buf = append((*buf)[:0], fields...)
+	e.fieldsBuf = buf
+	e.Fields = *buf
 }
 
-// OptimizedSkipCache 优化的调用栈跳过层数缓存
-type OptimizedSkipCache struct {
-	cache   sync.Map // 使用sync.Map提高并发性能
-	maxSize int64    // 最大缓存大小
-	size    int64    // 当前缓存大小
+// appendField 向条目追加一个字段，必要时从对象池获取字段切片
+func (e *AsyncLogEntry) appendField(field zap.Field) {
+	if e.fieldsBuf == nil {
+		e.fieldsBuf = asyncFieldsPool.Get().(*[]zap.Field)
+		*e.fieldsBuf = (*e.fieldsBuf)[:0]
+	}
+	*e.fieldsBuf = append(*e.fieldsBuf, field)
+	e.Fields = *e.fieldsBuf
+}
 
-	// 性能监控指标
-	hits   int64
-	misses int64
+// stampSeq 为条目分配序号，用于合并多个队列时确定先后顺序
+// output 为 true（开启 SeqField）时附带 asyncSeqField，由 seqCore 以 seq 字段输出，
+// 多个目录的日志合并后，毫秒级时间戳相同的条目仍可按序号完全排序
+func (e *AsyncLogEntry) stampSeq(output bool) {
+	e.Seq = nextSeq()
+	if output {
+		e.appendField(asyncSeqField(e.Seq))
+	}
+}
+
+// before 判断条目是否应排在 other 之前：先比较时间戳，相同时比较序号
+func (e *AsyncLogEntry) before(other *AsyncLogEntry) bool {
+	if !e.Timestamp.Equal(other.Timestamp) {
+		return e.Timestamp.Before(other.Timestamp)
+	}
+	return e.Seq != 0 && other.Seq != 0 && e.Seq < other.Seq
 }
 
+// releaseFields 清空字段引用并将切片归还对象池
+func (e *AsyncLogEntry) releaseFields() {
+	buf := e.fieldsBuf
+	if buf == nil {
+		return
+	}
+	e.fieldsBuf = nil
+	e.Fields = nil
+	if cap(*buf) > maxPooledFieldsCap {
+		return
+	}
+	clear(*buf)
+	*buf = (*buf)[:0]
+	asyncFieldsPool.Put(buf)
+}
+
+// OptimizedSkipCache 调用栈跳过层数缓存
+//
+// Deprecated: 调用方位置改为在公开 API 入口处捕获，不再遍历调用栈，缓存始终为空；保留仅为兼容旧代码
+type OptimizedSkipCache struct{}
+
+// NewOptimizedSkipCache 创建跳过层数缓存
+//
+// Deprecated: 见 OptimizedSkipCache
+func NewOptimizedSkipCache(maxSize int64) *OptimizedSkipCache {
+	return &OptimizedSkipCache{}
+}
+
+// Get 获取缓存值，始终未命中
+func (c *OptimizedSkipCache) Get(pc uintptr) (int, bool) { return 0, false }
+
+// Set 设置缓存值，不做任何事
+func (c *OptimizedSkipCache) Set(pc uintptr, skip int) {}
+
+// GetStats 获取缓存统计信息，始终为零值
+func (c *OptimizedSkipCache) GetStats() (hits, misses int64, size int64, hitRate float64) {
+	return 0, 0, 0, 0
+}
+
+// Clear 清空缓存，不做任何事
+func (c *OptimizedSkipCache) Clear() {}
+
 // StringBuilderPool 字符串构建器对象池
 type StringBuilderPool struct {
 	pool sync.Pool
@@ -155,90 +245,109 @@ func boolToInt32(b bool) int32 {
 
 // AsyncLogger 异步日志器
 type AsyncLogger struct {
-	logChan    chan AsyncLogEntry
-	done       chan struct{}
-	wg         sync.WaitGroup
-	dropOnFull bool
-	skipCache  *OptimizedSkipCache
-	sbPool     *StringBuilderPool // 字符串构建器池
-	levelCache *LevelCache        // 级别检查缓存
-}
-
-// NewOptimizedSkipCache 创建新的优化缓存
-func NewOptimizedSkipCache(maxSize int64) *OptimizedSkipCache {
-	return &OptimizedSkipCache{
-		maxSize: maxSize,
-	}
-}
-
-// Get 获取缓存值
-func (c *OptimizedSkipCache) Get(pc uintptr) (int, bool) {
-	if value, ok := c.cache.Load(pc); ok {
-		atomic.AddInt64(&c.hits, 1)
-		return value.(int), true
-	}
-	atomic.AddInt64(&c.misses, 1)
-	return 0, false
-}
-
-// Set 设置缓存值
-func (c *OptimizedSkipCache) Set(pc uintptr, skip int) {
-	// 简单的大小控制：如果缓存未满，则添加
-	if atomic.LoadInt64(&c.size) < c.maxSize {
-		if _, loaded := c.cache.LoadOrStore(pc, skip); !loaded {
-			atomic.AddInt64(&c.size, 1)
+	// core 独立异步日志器的写入目标，为 nil 时写入全局日志器
+	core zapcore.Core
+	// queues 所有异步队列，queues[0] 为默认队列
+	queues []*asyncQueue
+	// levelQueues 日志级别到队列的映射，未单独配置的级别使用默认队列
+	levelQueues [zapcore.FatalLevel - zapcore.DebugLevel + 1]*asyncQueue
+	notify      chan struct{} // 消费者空闲等待时用于唤醒
+	waiting     int32         // 消费者是否处于空闲等待状态
+	aborted     int32         // 关闭超时后置位，消费者处理完当前条目即退出，剩余条目由关闭方接管
+	done        chan struct{}
+	wg          sync.WaitGroup
+	// flushOnError Error 及以上级别的日志入队后等待其之前的所有日志写入并同步到磁盘
+	flushOnError bool
+	sampler      *adaptiveSampler   // 队列过载时的自适应采样器，未启用时为 nil
+	sbPool       *StringBuilderPool // 字符串构建器池
+	levelCache   *LevelCache        // 级别检查缓存
+	// flushInterval 定时刷新间隔，<= 0 表示不定时刷新
+	flushInterval time.Duration
+	// syncFallbackAfter 队列持续饱和超过该时长后切换为同步写入，0 表示不启用
+	syncFallbackAfter time.Duration
+	// now 计算饱和时长使用的时钟，为 nil 时使用 time.Now
+	now func() time.Time
+	// seqField 条目以 seq 字段输出入队时分配的序号
+	seqField bool
+}
+
+// QueueStats 异步日志队列统计信息
+type QueueStats struct {
+	Depth         int    `json:"depth"`          // 当前队列深度
+	Capacity      int    `json:"capacity"`       // 队列容量
+	HighWatermark int    `json:"high_watermark"` // 队列深度历史最高值
+	Enqueued      uint64 `json:"enqueued"`       // 累计入队条目数
+	Processed     uint64 `json:"processed"`      // 累计处理条目数
+	Dropped       uint64 `json:"dropped"`        // 累计丢弃条目数（缓冲区满或关闭过程中）
+	Sampling      bool   `json:"sampling"`       // 是否正处于过载采样状态
+	SampledOut    uint64 `json:"sampled_out"`    // 累计因过载采样被跳过的条目数
+	SyncFallback  bool   `json:"sync_fallback"`  // 是否正处于饱和同步写入状态
+	SyncWrites    uint64 `json:"sync_writes"`    // 累计因队列饱和而同步写入的条目数
+}
+
+// setupQueues 创建默认队列和按级别单独配置的队列
+func (al *AsyncLogger) setupQueues(bufferSize int, dropOnFull bool, levelConfigs map[string]AsyncQueueConfig) {
+	defaultQueue := newAsyncQueue("default", bufferSize, dropOnFull)
+	al.queues = append(al.queues, defaultQueue)
+	for i := range al.levelQueues {
+		al.levelQueues[i] = defaultQueue
+	}
+	for name, cfg := range levelConfigs {
+		level, err := zapcore.ParseLevel(name)
+		if err != nil || level < zapcore.DebugLevel || level > zapcore.FatalLevel {
+			fmt.Fprintf(os.Stderr, "[mlog] 忽略无效的异步队列级别配置: %s\n", name)
+			continue
 		}
+		size := cfg.BufferSize
+		if size <= 0 {
+			size = bufferSize
+		}
+		queue := newAsyncQueue(level.String(), size, cfg.DropOnFull)
+		al.queues = append(al.queues, queue)
+		al.levelQueues[level-zapcore.DebugLevel] = queue
 	}
 }
 
-// GetStats 获取缓存统计信息
-func (c *OptimizedSkipCache) GetStats() (hits, misses int64, size int64, hitRate float64) {
-	hits = atomic.LoadInt64(&c.hits)
-	misses = atomic.LoadInt64(&c.misses)
-	size = atomic.LoadInt64(&c.size)
-
-	total := hits + misses
-	if total > 0 {
-		hitRate = float64(hits) / float64(total)
-	}
-
-	return hits, misses, size, hitRate
-}
-
-// Clear 清空缓存（用于测试或重置）
-func (c *OptimizedSkipCache) Clear() {
-	c.cache.Range(func(key, value interface{}) bool {
-		c.cache.Delete(key)
-		return true
-	})
-	atomic.StoreInt64(&c.size, 0)
-	atomic.StoreInt64(&c.hits, 0)
-	atomic.StoreInt64(&c.misses, 0)
-}
+// processLogEntry 处理单个日志条目（优化版本）
+func (al *AsyncLogger) processLogEntry(entry AsyncLogEntry) {
+	// 写入完成后归还字段切片
+	defer entry.releaseFields()
 
-// newAsyncLogger 创建新的异步日志器
-func newAsyncLogger(bufferSize int, dropOnFull bool) *AsyncLogger {
-	al := &AsyncLogger{
-		logChan:    make(chan AsyncLogEntry, bufferSize),
-		done:       make(chan struct{}),
-		dropOnFull: dropOnFull,
-		skipCache:  NewOptimizedSkipCache(1000), // 默认最大1000个缓存条目
-		sbPool:     NewStringBuilderPool(),      // 初始化字符串构建器池
-		levelCache: NewLevelCache(),             // 初始化级别检查缓存
+	if al.core != nil {
+		// 独立的异步日志器直接写入自己的 Core
+		if !entry.flushOnly {
+			al.writeEntryToCore(al.core, entry)
+		}
+		if entry.flushDone != nil {
+			if err := al.core.Sync(); err != nil {
+				fmt.Fprintf(os.Stderr, "[mlog] 异步日志刷新失败: %v\n", err)
+			}
+			close(entry.flushDone)
+		}
+		return
 	}
 
-	al.wg.Add(1)
-	go al.processLogs()
-	return al
-}
-
-// processLogEntry 处理单个日志条目（优化版本）
-func (al *AsyncLogger) processLogEntry(entry AsyncLogEntry) {
 	logger, ok := getLogger()
 	if !ok {
+		if entry.flushDone != nil {
+			close(entry.flushDone)
+		}
 		return
 	}
 
+	if entry.flushDone != nil {
+		// 刷新屏障：写入本条日志后同步文件，再通知等待的生产者
+		defer func() {
+			if err := syncLoggerSafely(logger); err != nil {
+				fmt.Fprintf(os.Stderr, "[mlog] 异步日志刷新失败: %v\n", err)
+			}
+			close(entry.flushDone)
+		}()
+		if entry.flushOnly {
+			return
+		}
+	}
+
 	// 【并发安全修复】消息已经在发送前格式化完成，这里不再需要处理 Extras
 	// entry.Message 已经是格式化后的最终消息
 
@@ -255,11 +364,51 @@ func (al *AsyncLogger) processLogEntry(entry AsyncLogEntry) {
 func (al *AsyncLogger) processLogs() {
 	defer al.wg.Done()
 
+	// 定时刷新：即使没有新日志到达，也定期同步已写入的日志，
+	// 避免安静时段的日志长时间停留在带缓冲的写入器中
+	var tick <-chan time.Time
+	if al.flushInterval > 0 {
+		ticker := time.NewTicker(al.flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	dirty := false // 上次刷新之后是否写入过日志
+
+	var entry AsyncLogEntry
 	for {
-		select {
-		case entry := <-al.logChan:
+		if atomic.LoadInt32(&al.aborted) == 1 {
+			return
+		}
+		if al.tryPop(&entry) {
 			al.processLogEntry(entry)
+			dirty = dirty || entry.flushDone == nil
+			// 持续有日志时同样按间隔刷新
+			select {
+			case <-tick:
+				dirty = al.flushIfDirty(dirty)
+			default:
+			}
+			continue
+		}
+
+		// 缓冲区为空，先声明进入等待状态再复查一次，
+		// 与生产者的"写入后检查 waiting"配合，保证不会丢失唤醒
+		atomic.StoreInt32(&al.waiting, 1)
+		if al.tryPop(&entry) {
+			atomic.StoreInt32(&al.waiting, 0)
+			al.processLogEntry(entry)
+			dirty = dirty || entry.flushDone == nil
+			continue
+		}
+
+		select {
+		case <-al.notify:
+			atomic.StoreInt32(&al.waiting, 0)
+		case <-tick:
+			atomic.StoreInt32(&al.waiting, 0)
+			dirty = al.flushIfDirty(dirty)
 		case <-al.done:
+			atomic.StoreInt32(&al.waiting, 0)
 			// 处理剩余的日志
 			al.drainRemainingLogs()
 			return
@@ -267,14 +416,163 @@ func (al *AsyncLogger) processLogs() {
 	}
 }
 
+// flushIfDirty 在上次刷新后写入过日志时同步日志器，返回新的 dirty 状态
+func (al *AsyncLogger) flushIfDirty(dirty bool) bool {
+	if !dirty {
+		return false
+	}
+	if al.core != nil {
+		if err := al.core.Sync(); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 异步日志定时刷新失败: %v\n", err)
+		}
+		return false
+	}
+	if logger, ok := getLogger(); ok {
+		if err := syncLoggerSafely(logger); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 异步日志定时刷新失败: %v\n", err)
+		}
+	}
+	return false
+}
+
 // drainRemainingLogs 处理剩余的日志
 func (al *AsyncLogger) drainRemainingLogs() {
-	for {
+	var entry AsyncLogEntry
+	for atomic.LoadInt32(&al.aborted) == 0 && al.tryPop(&entry) {
+		al.processLogEntry(entry)
+	}
+}
+
+// queueFor 返回指定级别对应的队列
+func (al *AsyncLogger) queueFor(level zapcore.Level) *asyncQueue {
+	if level < zapcore.DebugLevel || level > zapcore.FatalLevel {
+		return al.queues[0]
+	}
+	return al.levelQueues[level-zapcore.DebugLevel]
+}
+
+// tryPop 从所有队列中取出队首时间戳最早的条目，尽量保持跨级别的先后顺序
+// 只能由消费者 goroutine 调用
+func (al *AsyncLogger) tryPop(entry *AsyncLogEntry) bool {
+	var next *asyncQueue
+	if len(al.queues) == 1 {
+		next = al.queues[0]
+	} else {
+		var earliest *AsyncLogEntry
+		for _, q := range al.queues {
+			head := q.ring.peek()
+			if head != nil && (next == nil || head.before(earliest)) {
+				next = q
+				earliest = head
+			}
+		}
+		if next == nil {
+			return false
+		}
+	}
+
+	if !next.ring.tryPop(entry) {
+		return false
+	}
+	next.signalSpace()
+	if !entry.flushOnly {
+		atomic.AddUint64(&next.processed, 1)
+	}
+	return true
+}
+
+// enqueue 将条目写入环形缓冲区，必要时唤醒消费者
+// 缓冲区满时根据 dropOnFull 决定丢弃还是阻塞等待消费者腾出空位，返回条目是否成功入队
+func (al *AsyncLogger) enqueue(entry *AsyncLogEntry) bool {
+	q := al.queueFor(entry.Level)
+	if al.syncFallbackActive(q) {
+		al.writeSyncFallback(q, *entry)
+		return true
+	}
+	if !q.ring.tryPush(entry) {
+		// 缓冲区满时丢弃日志；阻塞等待期间开始关闭时同样丢弃
+		if q.dropOnFull || !q.waitSpace(func() bool { return q.ring.tryPush(entry) }, al.done) {
+			atomic.AddUint64(&q.dropped, 1)
+			notifyDrop(entry.Level, entry.Message)
+			return false
+		}
+	}
+
+	atomic.AddUint64(&q.enqueued, 1)
+	q.updateHighWatermark()
+	al.wakeConsumer()
+	return true
+}
+
+// enqueueBatch 一次性写入一批条目，超过缓冲区容量的批次按容量分段写入
+// 缓冲区满时的处理方式与 enqueue 一致，返回成功入队的条目数
+// 同一批条目必须属于同一级别
+func (al *AsyncLogger) enqueueBatch(entries []AsyncLogEntry) int {
+	if len(entries) == 0 {
+		return 0
+	}
+	q := al.queueFor(entries[0].Level)
+	if al.syncFallbackActive(q) {
+		for i := range entries {
+			al.writeSyncFallback(q, entries[i])
+		}
+		return len(entries)
+	}
+	written := 0
+	for written < len(entries) {
+		chunk := entries[written:]
+		if len(chunk) > q.ring.capacity() {
+			chunk = chunk[:q.ring.capacity()]
+		}
+		if !q.ring.tryPushBatch(chunk) {
+			if q.dropOnFull || !q.waitSpace(func() bool { return q.ring.tryPushBatch(chunk) }, al.done) {
+				atomic.AddUint64(&q.dropped, uint64(len(entries)-written))
+				notifyDropBatch(entries[written:])
+				return written
+			}
+		}
+		written += len(chunk)
+		atomic.AddUint64(&q.enqueued, uint64(len(chunk)))
+		q.updateHighWatermark()
+		al.wakeConsumer()
+	}
+	return written
+}
+
+// QueueStats 获取异步日志队列统计信息（所有队列汇总）
+func (al *AsyncLogger) QueueStats() QueueStats {
+	var total QueueStats
+	for _, q := range al.queues {
+		stats := q.stats()
+		total.Depth += stats.Depth
+		total.Capacity += stats.Capacity
+		total.HighWatermark += stats.HighWatermark
+		total.Enqueued += stats.Enqueued
+		total.Processed += stats.Processed
+		total.Dropped += stats.Dropped
+		total.Sampling = total.Sampling || stats.Sampling
+		total.SampledOut += stats.SampledOut
+		total.SyncFallback = total.SyncFallback || stats.SyncFallback
+		total.SyncWrites += stats.SyncWrites
+	}
+	return total
+}
+
+// LevelQueueStats 获取每个队列的统计信息，键为队列名称（default 或级别名）
+func (al *AsyncLogger) LevelQueueStats() map[string]QueueStats {
+	result := make(map[string]QueueStats, len(al.queues))
+	for _, q := range al.queues {
+		result[q.name] = q.stats()
+	}
+	return result
+}
+
+// wakeConsumer 消费者处于空闲等待时唤醒它
+func (al *AsyncLogger) wakeConsumer() {
+	if atomic.LoadInt32(&al.waiting) == 1 {
 		select {
-		case entry := <-al.logChan:
-			al.processLogEntry(entry)
+		case al.notify <- struct{}{}:
 		default:
-			return
 		}
 	}
 }
@@ -299,15 +597,10 @@ func (al *AsyncLogger) writeLogEntryFallback(logger *zap.Logger, entry AsyncLogE
 	}
 }
 
-// logAsync 异步记录日志
-func (al *AsyncLogger) logAsync(level zapcore.Level, msg string, args []any, fields ...zap.Field) {
-	al.logAsyncWithSkip(level, msg, args, 3, fields...) // 默认skip 3层调用栈
-}
-
-// logAsyncWithSkip 异步记录日志，指定调用栈跳过层数
-func (al *AsyncLogger) logAsyncWithSkip(level zapcore.Level, msg string, args []any, skip int, fields ...zap.Field) {
+// logAsyncAt 异步记录日志，caller 为公开 API 入口处捕获的调用方位置
+func (al *AsyncLogger) logAsyncAt(level zapcore.Level, caller zapcore.EntryCaller, msg string, args []any, fields ...zap.Field) {
 	// 快速级别检查，避免不必要的处理
-	if !al.levelCache.isLevelEnabled(level) {
+	if !al.levelEnabled(level) {
 		return
 	}
 
@@ -315,15 +608,6 @@ func (al *AsyncLogger) logAsyncWithSkip(level zapcore.Level, msg string, args []
 	// 这确保时间戳反映的是日志产生的真实时间，而非异步处理时的时间
 	timestamp := time.Now()
 
-	// 动态检测调用路径并调整skip值
-	adjustedSkip := al.detectAndAdjustSkip(skip)
-
-	// 在进入异步队列之前捕获caller信息
-	caller := zapcore.NewEntryCaller(uintptr(0), "", 0, false)
-	if pc, file, line, ok := runtime.Caller(adjustedSkip); ok {
-		caller = zapcore.NewEntryCaller(pc, file, line, true)
-	}
-
 	// 【并发安全修复 - 安全格式化方案】
 	// 使用 SafeFormatter 进行安全的参数序列化
 	// 这个方案会将所有参数转换为不可变的形式，完全避免并发问题
@@ -333,93 +617,80 @@ func (al *AsyncLogger) logAsyncWithSkip(level zapcore.Level, msg string, args []
 	// 2. 不依赖用户的并发安全保证
 	// 3. 对于 map 类型，会立即创建快照（JSON 序列化）
 	// 4. 对于其他复杂类型，也会进行安全的转换
+	// 过载采样在格式化之前进行，被跳过的日志不产生格式化开销
+	sampleRate := 0
+	if al.sampler != nil {
+		keep, sampled := al.sampler.admit(al.queueFor(level), level)
+		if !keep {
+			// 带格式化参数的消息各不相同，不参与计数，也避免为跳过的日志做格式化
+			if len(args) == 0 {
+				recordDropped(level, msg)
+			}
+			return
+		}
+		if sampled {
+			sampleRate = al.sampler.rate
+		}
+	}
+
 	formattedMsg := SafeFormat(msg, args...)
 
 	entry := AsyncLogEntry{
 		Level:     level,
 		Message:   formattedMsg,
-		Fields:    fields,
 		Extras:    nil,       // 已经格式化完成，不再需要传递原始参数
 		Caller:    caller,    // 保存原始调用者信息
 		Timestamp: timestamp, // 保存日志产生时的时间戳
+		Stack:     captureStack(level),
 	}
-
-	if al.dropOnFull {
-		select {
-		case al.logChan <- entry:
-		default:
-			// 缓冲区满时丢弃日志
-		}
-	} else {
-		select {
-		case al.logChan <- entry:
-		case <-al.done:
-			// 如果正在关闭，直接返回
-			return
-		}
+	entry.copyFieldsToEntry(fields)
+	if GetFieldSnapshot() {
+		SnapshotFields(entry.Fields)
+	}
+	entry.stampSeq(al.seqField)
+	if sampleRate > 0 {
+		entry.appendField(zap.Int("sampled", sampleRate))
 	}
-}
-
-// detectAndAdjustSkip 动态检测调用路径并调整skip值（优化缓存版本）
-func (al *AsyncLogger) detectAndAdjustSkip(skip int) int {
-	// 获取调用者的PC值作为缓存键
-	if pc, _, _, ok := runtime.Caller(2); ok { // skip=2 跳过当前函数和logAsyncWithSkip
-		// 先检查缓存
-		if cachedSkip, exists := al.skipCache.Get(pc); exists {
-			return cachedSkip
-		}
-
-		// 缓存未命中，进行检测
-		adjustedSkip := al.detectSkipSlow(skip)
 
-		// 更新缓存
-		al.skipCache.Set(pc, adjustedSkip)
+	// 开启 flushOnError 时，错误日志作为刷新屏障，保证其之前的日志已经落盘
+	if al.flushOnError && level >= zapcore.ErrorLevel {
+		entry.flushDone = make(chan struct{})
+	}
 
-		return adjustedSkip
+	if !al.enqueue(&entry) {
+		// 未能入队（丢弃或正在关闭），直接归还字段切片
+		entry.releaseFields()
+		return
 	}
 
-	// 如果无法获取PC值，回退到慢速检测
-	return al.detectSkipSlow(skip)
+	if entry.flushDone != nil {
+		al.waitFlush(entry.flushDone)
+	}
 }
 
-// detectSkipSlow 慢速路径：遍历调用栈检测
-func (al *AsyncLogger) detectSkipSlow(skip int) int {
-	// 检查调用栈中是否包含zapDebug、zapInfo等函数
-	for i := 0; i < 8; i++ { // 减少循环次数从10到8
-		if pc, _, _, ok := runtime.Caller(i); ok {
-			fn := runtime.FuncForPC(pc)
-			if fn != nil {
-				funcName := fn.Name()
-				// 使用更高效的字符串匹配
-				if al.isZapFunction(funcName) {
-					return skip + 1
-				}
-			}
-		}
+// waitFlush 等待刷新屏障被消费者处理
+func (al *AsyncLogger) waitFlush(flushDone chan struct{}) {
+	select {
+	case <-flushDone:
+	case <-al.done:
+		// 关闭过程中剩余日志会被 drainRemainingLogs 处理，无需继续等待
 	}
-	// 如果没有找到zap*函数，说明是通过DebugW、InfoW等直接调用的
-	return skip
 }
 
-// isZapFunction 检查是否为zap函数（优化字符串匹配）
-func (al *AsyncLogger) isZapFunction(funcName string) bool {
-	// 使用更高效的字符串匹配，避免多次调用strings.Contains
-	if len(funcName) < 7 { // "zapDebug"最短7个字符
-		return false
+// Flush 等待当前已入队的所有日志写入完成并同步到磁盘
+func (al *AsyncLogger) Flush() {
+	entry := AsyncLogEntry{
+		Timestamp: time.Now(),
+		flushDone: make(chan struct{}),
+		flushOnly: true,
 	}
-
-	// 查找"zap"子串的位置
-	zapIndex := strings.Index(funcName, "zap")
-	if zapIndex == -1 {
-		return false
+	// 刷新屏障不能被丢弃，临时按阻塞方式入队
+	q := al.queues[0]
+	if !q.ring.tryPush(&entry) && !q.waitSpace(func() bool { return q.ring.tryPush(&entry) }, al.done) {
+		return
 	}
-
-	// 检查zap后面是否跟着Debug、Info、Warn、Error
-	remaining := funcName[zapIndex+3:]
-	return strings.HasPrefix(remaining, "Debug") ||
-		strings.HasPrefix(remaining, "Info") ||
-		strings.HasPrefix(remaining, "Warn") ||
-		strings.HasPrefix(remaining, "Error")
+	al.wakeConsumer()
+	al.waitFlush(entry.flushDone)
 }
 
 // writeLogEntryWithCaller 使用保存的caller信息写入日志条目
@@ -431,7 +702,7 @@ func (al *AsyncLogger) writeLogEntryWithCaller(logger *zap.Logger, entry AsyncLo
 		LoggerName: "",
 		Message:    entry.Message,
 		Caller:     entry.Caller,
-		Stack:      "",
+		Stack:      entry.Stack,
 	}
 
 	// 获取logger的core并直接写入
@@ -440,16 +711,32 @@ func (al *AsyncLogger) writeLogEntryWithCaller(logger *zap.Logger, entry AsyncLo
 	}
 }
 
-// GetCacheStats 获取缓存统计信息
-func (al *AsyncLogger) GetCacheStats() (hits, misses int64, size int64, hitRate float64) {
-	return al.skipCache.GetStats()
+// writeEntryToCore 将日志条目写入指定的 Core，保留原始的 caller 和时间戳
+func (al *AsyncLogger) writeEntryToCore(core zapcore.Core, entry AsyncLogEntry) {
+	zapEntry := zapcore.Entry{
+		Level:   entry.Level,
+		Time:    entry.Timestamp,
+		Message: entry.Message,
+		Caller:  entry.Caller,
+		Stack:   entry.Stack,
+	}
+	if ce := core.Check(zapEntry, nil); ce != nil {
+		ce.Write(entry.Fields...)
+	}
 }
 
-// ClearCache 清空缓存（用于测试或重置）
-func (al *AsyncLogger) ClearCache() {
-	al.skipCache.Clear()
+// GetCacheStats 获取调用栈跳过层数缓存的统计信息，始终为零值
+//
+// Deprecated: 见 OptimizedSkipCache
+func (al *AsyncLogger) GetCacheStats() (hits, misses int64, size int64, hitRate float64) {
+	return 0, 0, 0, 0
 }
 
+// ClearCache 清空调用栈跳过层数缓存，不做任何事
+//
+// Deprecated: 见 OptimizedSkipCache
+func (al *AsyncLogger) ClearCache() {}
+
 // UpdateLevelCache 更新级别缓存
 func (al *AsyncLogger) UpdateLevelCache() {
 	al.levelCache.updateCache()
@@ -466,34 +753,6 @@ func (al *AsyncLogger) close() {
 	al.Close()
 }
 
-// debugAsync 异步调试日志
-func (al *AsyncLogger) debugAsync(msg string, args []any, fields ...zap.Field) {
-	// 调用栈：用户代码 -> mlog.Debug() -> zapDebug() -> debugAsync() -> al.debugAsync() -> al.logAsyncWithSkip()
-	// 需要跳过 5 层才能到达用户代码
-	al.logAsyncWithSkip(zapcore.DebugLevel, msg, args, 5, fields...)
-}
-
-// infoAsync 异步信息日志
-func (al *AsyncLogger) infoAsync(msg string, args []any, fields ...zap.Field) {
-	// 调用栈：用户代码 -> mlog.Info() -> zapInfo() -> infoAsync() -> al.infoAsync() -> al.logAsyncWithSkip()
-	// 需要跳过 5 层才能到达用户代码
-	al.logAsyncWithSkip(zapcore.InfoLevel, msg, args, 5, fields...)
-}
-
-// warnAsync 异步警告日志
-func (al *AsyncLogger) warnAsync(msg string, args []any, fields ...zap.Field) {
-	// 调用栈：用户代码 -> mlog.Warn() -> zapWarn() -> warnAsync() -> al.warnAsync() -> al.logAsyncWithSkip()
-	// 需要跳过 5 层才能到达用户代码
-	al.logAsyncWithSkip(zapcore.WarnLevel, msg, args, 5, fields...)
-}
-
-// errorAsync 异步错误日志
-func (al *AsyncLogger) errorAsync(msg string, args []any, fields ...zap.Field) {
-	// 调用栈：用户代码 -> mlog.Error() -> zapError() -> errorAsync() -> al.errorAsync() -> al.logAsyncWithSkip()
-	// 需要跳过 5 层才能到达用户代码
-	al.logAsyncWithSkip(zapcore.ErrorLevel, msg, args, 5, fields...)
-}
-
 // getAsyncLogger 安全地获取全局异步日志器
 func getAsyncLogger() (*AsyncLogger, bool) {
 	asyncMutex.RLock()
@@ -501,67 +760,33 @@ func getAsyncLogger() (*AsyncLogger, bool) {
 	return globalAsyncLogger, globalAsyncLogger != nil
 }
 
-// debugAsync 异步调试日志（全局函数）
-func debugAsync(msg string, args []any, fields ...zap.Field) {
-	if logger, ok := getAsyncLogger(); ok {
-		// 调试代码已移除
-
-		// 使用基础skip值3，detectAndAdjustSkip会根据调用栈动态调整
-		// 调用栈：用户代码 -> mlog.DebugW()/Debug() -> [zapDebug()] -> debugAsync() -> logger.logAsyncWithSkip()
-		// 基础skip=3，如果有zapDebug会自动+1变成4
-		logger.logAsyncWithSkip(zapcore.DebugLevel, msg, args, 3, fields...)
-	} else {
-		// 如果异步日志器未启用，回退到同步日志
-		DebugW(msg, fields...)
-	}
-}
-
-// infoAsync 异步信息日志（全局函数）
-func infoAsync(msg string, args []any, fields ...zap.Field) {
-	if logger, ok := getAsyncLogger(); ok {
-		// 使用基础skip值3，detectAndAdjustSkip会根据调用栈动态调整
-		logger.logAsyncWithSkip(zapcore.InfoLevel, msg, args, 3, fields...)
-	} else {
-		// 如果异步日志器未启用，回退到同步日志
-		InfoW(msg, fields...)
-	}
-}
-
-// warnAsync 异步警告日志（全局函数）
-func warnAsync(msg string, args []any, fields ...zap.Field) {
-	if logger, ok := getAsyncLogger(); ok {
-		// 使用基础skip值3，detectAndAdjustSkip会根据调用栈动态调整
-		logger.logAsyncWithSkip(zapcore.WarnLevel, msg, args, 3, fields...)
-	} else {
-		// 如果异步日志器未启用，回退到同步日志
-		WarnW(msg, fields...)
-	}
+// GetAsyncCacheStats 获取全局异步日志器的缓存统计信息，始终为零值
+//
+// Deprecated: 见 OptimizedSkipCache
+func GetAsyncCacheStats() (hits, misses int64, size int64, hitRate float64) {
+	return 0, 0, 0, 0
 }
 
-// errorAsync 异步错误日志（全局函数）
-func errorAsync(msg string, args []any, fields ...zap.Field) {
-	if logger, ok := getAsyncLogger(); ok {
-		// 使用基础skip值3，detectAndAdjustSkip会根据调用栈动态调整
-		logger.logAsyncWithSkip(zapcore.ErrorLevel, msg, args, 3, fields...)
-	} else {
-		// 如果异步日志器未启用，回退到同步日志
-		ErrorW(msg, fields...)
-	}
-}
+// ClearAsyncCache 清空全局异步日志器的缓存，不做任何事
+//
+// Deprecated: 见 OptimizedSkipCache
+func ClearAsyncCache() {}
 
-// GetAsyncCacheStats 获取全局异步日志器的缓存统计信息
-func GetAsyncCacheStats() (hits, misses int64, size int64, hitRate float64) {
+// AsyncQueueStats 获取全局异步日志器的队列统计信息，未启用异步日志时返回零值
+// 可用于根据真实的队列深度和高水位调整 AsyncBufferSize
+func AsyncQueueStats() QueueStats {
 	if logger, ok := getAsyncLogger(); ok {
-		return logger.GetCacheStats()
+		return logger.QueueStats()
 	}
-	return 0, 0, 0, 0
+	return QueueStats{}
 }
 
-// ClearAsyncCache 清空全局异步日志器的缓存
-func ClearAsyncCache() {
+// AsyncLevelQueueStats 获取全局异步日志器每个队列的统计信息，未启用异步日志时返回 nil
+func AsyncLevelQueueStats() map[string]QueueStats {
 	if logger, ok := getAsyncLogger(); ok {
-		logger.ClearCache()
+		return logger.LevelQueueStats()
 	}
+	return nil
 }
 
 // UpdateAsyncLevelCache 更新全局异步日志器的级别缓存
@@ -576,8 +801,15 @@ func UpdateAsyncLevelCache() {
 	}
 }
 
-// isAsyncEnabled 检查异步日志是否启用
-func isAsyncEnabled() bool {
-	_, enabled := getAsyncLogger()
-	return enabled
+// Flush 等待异步队列中已有的日志写入完成并同步所有日志文件
+func Flush() {
+	if logger, ok := getAsyncLogger(); ok {
+		logger.Flush()
+		return
+	}
+	if logger, ok := getLogger(); ok {
+		if err := syncLoggerSafely(logger); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 日志刷新失败: %v\n", err)
+		}
+	}
 }
@@ -0,0 +1,191 @@
+package mlog
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// defaultAsyncBufferSize 默认的异步缓冲区大小
+	defaultAsyncBufferSize = 10000
+	// defaultAsyncFlushInterval 默认的空闲刷新间隔
+	defaultAsyncFlushInterval = time.Second
+)
+
+// asyncOptions 异步日志器的创建参数
+type asyncOptions struct {
+	bufferSize        int
+	dropOnFull        bool
+	levelQueues       map[string]AsyncQueueConfig
+	flushInterval     time.Duration
+	flushOnError      bool
+	sampling          AdaptiveSamplingConfig
+	syncFallbackAfter time.Duration
+	seqField          bool
+}
+
+// AsyncOption 异步日志器选项
+type AsyncOption func(*asyncOptions)
+
+// WithBufferSize 设置缓冲区大小（默认 10000）
+func WithBufferSize(size int) AsyncOption {
+	return func(o *asyncOptions) {
+		if size > 0 {
+			o.bufferSize = size
+		}
+	}
+}
+
+// WithDropOnFull 设置缓冲区满时丢弃日志而不是阻塞等待
+func WithDropOnFull(drop bool) AsyncOption {
+	return func(o *asyncOptions) {
+		o.dropOnFull = drop
+	}
+}
+
+// WithLevelQueues 设置按级别独立的队列
+func WithLevelQueues(queues map[string]AsyncQueueConfig) AsyncOption {
+	return func(o *asyncOptions) {
+		o.levelQueues = queues
+	}
+}
+
+// WithFlushInterval 设置定时刷新间隔（默认 1 秒），<= 0 时不定时刷新
+func WithFlushInterval(interval time.Duration) AsyncOption {
+	return func(o *asyncOptions) {
+		o.flushInterval = interval
+	}
+}
+
+// WithFlushOnError 设置 Error 及以上级别日志等待之前的日志全部落盘
+func WithFlushOnError(enable bool) AsyncOption {
+	return func(o *asyncOptions) {
+		o.flushOnError = enable
+	}
+}
+
+// WithAdaptiveSampling 设置队列过载时的自适应采样
+func WithAdaptiveSampling(cfg AdaptiveSamplingConfig) AsyncOption {
+	return func(o *asyncOptions) {
+		o.sampling = cfg
+	}
+}
+
+// WithSyncFallback 设置队列持续饱和超过 after 后临时改为同步写入，<= 0 时不启用
+func WithSyncFallback(after time.Duration) AsyncOption {
+	return func(o *asyncOptions) {
+		o.syncFallbackAfter = after
+	}
+}
+
+// WithSeqField 设置每条日志以 seq 字段输出入队时分配的单调递增序号
+func WithSeqField(enable bool) AsyncOption {
+	return func(o *asyncOptions) {
+		o.seqField = enable
+	}
+}
+
+// asyncOptionsFromConfig 将日志配置转换为全局异步日志器的选项
+func asyncOptionsFromConfig(c *ZapConfig) []AsyncOption {
+	flushInterval := defaultAsyncFlushInterval
+	if c.AsyncFlushIntervalMs != 0 {
+		flushInterval = time.Duration(c.AsyncFlushIntervalMs) * time.Millisecond
+	}
+	return []AsyncOption{
+		WithBufferSize(c.AsyncBufferSize),
+		WithDropOnFull(c.AsyncDropOnFull),
+		WithLevelQueues(c.AsyncLevelQueues),
+		WithFlushInterval(flushInterval),
+		WithFlushOnError(c.AsyncFlushOnError),
+		WithAdaptiveSampling(c.AsyncAdaptiveSampling),
+		WithSyncFallback(time.Duration(c.AsyncSyncFallbackMs) * time.Millisecond),
+		WithSeqField(c.SeqField),
+	}
+}
+
+// NewAsyncLogger 创建独立的异步日志器
+// 日志在调用方 goroutine 上完成格式化和 caller 捕获后进入独立的队列，由专属的写入协程写入 core。
+// 适合高吞吐的子系统（如遥测通道）使用自己的缓冲区和丢弃策略，与全局日志器的队列互不影响。
+// core 为 nil 时写入全局日志器。使用完毕后需要调用 Close。
+func NewAsyncLogger(core zapcore.Core, opts ...AsyncOption) *AsyncLogger {
+	o := asyncOptions{
+		bufferSize:    defaultAsyncBufferSize,
+		flushInterval: defaultAsyncFlushInterval,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.seqField && core != nil {
+		// 独立的异步日志器没有经过全局的 seqCore，由自己的 Core 输出序号
+		core = &seqCore{Core: core}
+	}
+	al := &AsyncLogger{
+		core:              core,
+		flushInterval:     o.flushInterval,
+		flushOnError:      o.flushOnError,
+		sampler:           newAdaptiveSampler(o.sampling),
+		syncFallbackAfter: o.syncFallbackAfter,
+		seqField:          o.seqField,
+		notify:            make(chan struct{}, 1),
+		done:              make(chan struct{}),
+		sbPool:            NewStringBuilderPool(), // 初始化字符串构建器池
+		levelCache:        NewLevelCache(),        // 初始化级别检查缓存
+	}
+	al.setupQueues(o.bufferSize, o.dropOnFull, o.levelQueues)
+
+	al.wg.Add(1)
+	go al.processLogs()
+	return al
+}
+
+// levelEnabled 检查级别是否启用，独立的异步日志器以自己的 Core 为准
+func (al *AsyncLogger) levelEnabled(level zapcore.Level) bool {
+	if al.core != nil {
+		return al.core.Enabled(level)
+	}
+	return al.levelCache.isLevelEnabled(level)
+}
+
+// Debug 输出调试级别日志
+func (al *AsyncLogger) Debug(msg string, args ...any) {
+	// 调用栈：用户代码 -> al.Debug()
+	al.logAsyncAt(zapcore.DebugLevel, captureCaller(1), msg, args)
+}
+
+// Info 输出信息级别日志
+func (al *AsyncLogger) Info(msg string, args ...any) {
+	al.logAsyncAt(zapcore.InfoLevel, captureCaller(1), msg, args)
+}
+
+// Warn 输出警告级别日志
+func (al *AsyncLogger) Warn(msg string, args ...any) {
+	al.logAsyncAt(zapcore.WarnLevel, captureCaller(1), msg, args)
+}
+
+// Error 输出错误级别日志
+func (al *AsyncLogger) Error(msg string, args ...any) {
+	al.logAsyncAt(zapcore.ErrorLevel, captureCaller(1), msg, args)
+}
+
+// DebugW 输出带结构化字段的调试级别日志
+func (al *AsyncLogger) DebugW(msg string, fields ...zap.Field) {
+	al.logAsyncAt(zapcore.DebugLevel, captureCaller(1), msg, nil, fields...)
+}
+
+// InfoW 输出带结构化字段的信息级别日志
+func (al *AsyncLogger) InfoW(msg string, fields ...zap.Field) {
+	al.logAsyncAt(zapcore.InfoLevel, captureCaller(1), msg, nil, fields...)
+}
+
+// WarnW 输出带结构化字段的警告级别日志
+func (al *AsyncLogger) WarnW(msg string, fields ...zap.Field) {
+	al.logAsyncAt(zapcore.WarnLevel, captureCaller(1), msg, nil, fields...)
+}
+
+// ErrorW 输出带结构化字段的错误级别日志
+func (al *AsyncLogger) ErrorW(msg string, fields ...zap.Field) {
+	al.logAsyncAt(zapcore.ErrorLevel, captureCaller(1), msg, nil, fields...)
+}
@@ -0,0 +1,42 @@
+package mlog
+
+import (
+	"runtime"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestStandaloneAsyncLogger 测试独立的异步日志器写入自己的 Core，不依赖全局日志器
+func TestStandaloneAsyncLogger(t *testing.T) {
+	Close()
+	core, logs := observer.New(zapcore.InfoLevel)
+	al := NewAsyncLogger(core, WithBufferSize(8), WithFlushInterval(0))
+
+	al.Debug("debug %d", 1)
+	_, file, line, _ := runtime.Caller(0)
+	al.Info("info %d", 1)
+	al.WarnW("warn", zap.Int("n", 2))
+	al.Flush()
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("期望 2 条日志，实际 %d", len(entries))
+	}
+	if entries[0].Message != "info 1" || entries[1].Message != "warn" || entries[1].ContextMap()["n"] != int64(2) {
+		t.Fatalf("日志内容错误: %+v", entries)
+	}
+	if caller := entries[0].Caller; !caller.Defined || caller.File != file || caller.Line != line+1 {
+		t.Fatalf("caller 应指向调用方: %+v", entries[0].Caller)
+	}
+	if _, ok := getAsyncLogger(); ok {
+		t.Fatal("独立的异步日志器不应影响全局日志器")
+	}
+
+	al.Close()
+	if stats := al.QueueStats(); stats.Capacity != 8 || stats.Processed != 2 {
+		t.Fatalf("队列统计错误: %+v", stats)
+	}
+}
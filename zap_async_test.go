@@ -0,0 +1,178 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestAsyncQueueStats 测试全局异步日志器的队列统计，未启用异步日志时返回零值
+func TestAsyncQueueStats(t *testing.T) {
+	Close()
+	if stats := AsyncQueueStats(); stats != (QueueStats{}) {
+		t.Fatalf("未启用异步日志时应返回零值: %+v", stats)
+	}
+
+	InitialZap("gate", 2, "info", &ZapConfig{Director: t.TempDir(), EnableAsync: true, AsyncBufferSize: 64})
+	defer Close()
+	for i := 0; i < 10; i++ {
+		Info("queue %d", i)
+	}
+	Debug("filtered")
+	Flush()
+
+	stats := AsyncQueueStats()
+	if stats.Capacity != 64 || stats.Enqueued != 10 || stats.Processed != 10 || stats.Depth != 0 || stats.Dropped != 0 {
+		t.Fatalf("队列统计错误: %+v", stats)
+	}
+	if stats.HighWatermark < 1 || stats.HighWatermark > 10 {
+		t.Fatalf("高水位应在 1 到 10 之间: %d", stats.HighWatermark)
+	}
+}
+
+// TestAsyncLevelQueueStats 测试按级别配置队列后，混合级别的日志分别计入各自的队列
+func TestAsyncLevelQueueStats(t *testing.T) {
+	Close()
+	if stats := AsyncLevelQueueStats(); stats != nil {
+		t.Fatalf("未启用异步日志时应返回 nil: %v", stats)
+	}
+
+	InitialZap("gate", 2, "debug", &ZapConfig{Director: t.TempDir(), EnableAsync: true, AsyncBufferSize: 64,
+		AsyncLevelQueues: map[string]AsyncQueueConfig{"debug": {BufferSize: 32}, "error": {BufferSize: 16}}})
+	defer Close()
+	for i := 0; i < 5; i++ {
+		Debug("debug %d", i)
+	}
+	for i := 0; i < 3; i++ {
+		Info("info %d", i)
+	}
+	Warn("warn")
+	Error("error 1")
+	Error("error 2")
+	Flush()
+
+	stats := AsyncLevelQueueStats()
+	want := map[string]struct {
+		capacity int
+		count    uint64
+	}{"debug": {32, 5}, "error": {16, 2}, "default": {64, 4}}
+	if len(stats) != len(want) {
+		t.Fatalf("队列数量错误: %v", stats)
+	}
+	for name, w := range want {
+		s := stats[name]
+		if s.Capacity != w.capacity || s.Enqueued != w.count || s.Processed != w.count {
+			t.Fatalf("%s 队列统计错误: %+v", name, s)
+		}
+	}
+	if total := AsyncQueueStats(); total.Processed != 11 || total.Capacity != 112 {
+		t.Fatalf("汇总统计错误: %+v", total)
+	}
+}
+
+// TestAsyncEntryFieldsPool 测试入队时字段复制到池化切片，不受调用方修改影响，写入后清空归还
+func TestAsyncEntryFieldsPool(t *testing.T) {
+	fields := []zap.Field{zap.Int("hp", 100), zap.String("name", "hero")}
+	var entry AsyncLogEntry
+	entry.copyFieldsToEntry(fields)
+	fields[0] = zap.Int("hp", 0)
+	if len(entry.Fields) != 2 || entry.Fields[0].Integer != 100 || entry.fieldsBuf == nil {
+		t.Fatalf("字段应复制到池化切片: %v", entry.Fields)
+	}
+
+	buf := entry.fieldsBuf
+	entry.releaseFields()
+	if entry.Fields != nil || entry.fieldsBuf != nil || len(*buf) != 0 || (*buf)[:2][1].String != "" {
+		t.Fatal("归还后应清空字段引用")
+	}
+	entry.releaseFields()
+
+	var empty AsyncLogEntry
+	empty.copyFieldsToEntry(nil)
+	if empty.fieldsBuf != nil {
+		t.Fatal("没有字段时不应从对象池获取切片")
+	}
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	al := NewAsyncLogger(core, WithFlushInterval(0))
+	al.InfoW("pooled", zap.Int("hp", 100))
+	al.Close()
+	if entries := logs.AllUntimed(); len(entries) != 1 || entries[0].ContextMap()["hp"] != int64(100) {
+		t.Fatalf("池化的字段应正确写入: %v", entries)
+	}
+}
+
+// syncCountingCore 记录 Sync 调用次数的测试 Core
+type syncCountingCore struct {
+	zapcore.Core
+	syncs *atomic.Int32
+}
+
+func (c syncCountingCore) With(fields []zapcore.Field) zapcore.Core {
+	return syncCountingCore{c.Core.With(fields), c.syncs}
+}
+
+func (c syncCountingCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c syncCountingCore) Sync() error {
+	c.syncs.Add(1)
+	return c.Core.Sync()
+}
+
+// TestAsyncFlushOnError 测试开启 flush-on-error 后错误日志返回时之前的日志已经写入并同步
+func TestAsyncFlushOnError(t *testing.T) {
+	observed, logs := observer.New(zapcore.InfoLevel)
+	var syncs atomic.Int32
+	al := NewAsyncLogger(syncCountingCore{observed, &syncs}, WithFlushOnError(true), WithFlushInterval(0))
+	defer al.Close()
+
+	for i := 0; i < 3; i++ {
+		al.Info("before %d", i)
+	}
+	al.ErrorW("failed")
+	if entries := logs.AllUntimed(); len(entries) != 4 || entries[3].Message != "failed" {
+		t.Fatalf("错误日志返回时之前的日志应已写入: %v", entries)
+	}
+	if syncs.Load() != 1 {
+		t.Fatalf("错误日志应触发一次同步，实际 %d", syncs.Load())
+	}
+
+	al.Warn("after")
+	if syncs.Load() != 1 {
+		t.Fatal("Error 以下级别不应触发同步")
+	}
+}
+
+// TestAsyncFlushTick 测试空闲时单条缓冲中的日志按定时刷新间隔写入文件，无需调用 Sync 或 Flush
+func TestAsyncFlushTick(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	InitialZap("gate", 2, "info", &ZapConfig{Director: dir, SingleFile: true, EnableAsync: true, AsyncBufferSize: 16,
+		AsyncFlushIntervalMs: 50, BufferSizeKB: 64, FlushIntervalMs: 60000})
+	defer Close()
+
+	Info("idle entry")
+	path := filepath.Join(dir, "2/gate/all.log")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if data, _ := os.ReadFile(path); strings.Contains(string(data), "idle entry") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("缓冲中的日志应在定时刷新间隔内写入文件")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
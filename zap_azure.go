@@ -0,0 +1,200 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Azure Monitor 输出的默认参数
+const (
+	defaultAzureLogType   = "MlogLogs"
+	azureDataCollectorAPI = "/api/logs"
+	azureAPIVersion       = "2016-04-01"
+)
+
+// AzureMonitorConfig Azure Monitor（Log Analytics）输出配置
+// 通过 HTTP Data Collector API 批量写入自定义日志表 <LogType>_CL，列固定为：
+// TimeGenerated、Level、Service、ServiceID、Directory、Caller、Message、Fields（其余字段的 JSON）
+type AzureMonitorConfig struct {
+	Enable      bool   `mapstructure:"enable" json:"enable" yaml:"enable"`                   // 启用 Azure Monitor 输出
+	WorkspaceID string `mapstructure:"workspace-id" json:"workspace-id" yaml:"workspace-id"` // Log Analytics 工作区 ID
+	SharedKey   string `mapstructure:"shared-key" json:"shared-key" yaml:"shared-key"`       // 工作区主密钥或辅助密钥（Base64）
+	// 自定义日志类型（默认 MlogLogs），只能包含字母、数字和下划线，Azure 会自动添加 _CL 后缀
+	LogType string `mapstructure:"log-type" json:"log-type" yaml:"log-type"`
+	// 接口地址（默认 https://<工作区ID>.ods.opinsights.azure.com），Azure 中国等主权云需要修改
+	Endpoint          string `mapstructure:"endpoint" json:"endpoint" yaml:"endpoint"`
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// azureMonitorClient HTTP Data Collector API 客户端
+type azureMonitorClient struct {
+	url         string
+	workspaceID string
+	key         []byte // 解码后的共享密钥
+	logType     string
+	httpClient  *http.Client
+}
+
+// newAzureMonitorCore 创建 Azure Monitor 输出 Core
+func newAzureMonitorCore(cfg AzureMonitorConfig, serviceName string, serviceID uint64) (*azureMonitorCore, error) {
+	if cfg.WorkspaceID == "" || cfg.SharedKey == "" {
+		return nil, errors.New("未配置 Log Analytics 工作区 ID 或共享密钥")
+	}
+	key, err := base64.StdEncoding.DecodeString(cfg.SharedKey)
+	if err != nil {
+		return nil, fmt.Errorf("Log Analytics 共享密钥不是有效的 Base64: %w", err)
+	}
+	logType := cfg.LogType
+	if logType == "" {
+		logType = defaultAzureLogType
+	}
+	if len(logType) > 100 || strings.TrimFunc(logType, func(r rune) bool {
+		return r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+	}) != "" {
+		return nil, fmt.Errorf("Log Analytics 日志类型只能包含字母、数字和下划线: %s", logType)
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://" + cfg.WorkspaceID + ".ods.opinsights.azure.com"
+	}
+
+	client := &azureMonitorClient{
+		url:         strings.TrimSuffix(endpoint, "/") + azureDataCollectorAPI + "?api-version=" + azureAPIVersion,
+		workspaceID: cfg.WorkspaceID,
+		key:         key,
+		logType:     logType,
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+	}
+	batcher := newRemoteBatcher("azure-monitor", cfg.RemoteBatchConfig, client.post)
+	addSinkCloser(batcher)
+	return &azureMonitorCore{
+		LevelEnabler: atomicLevel,
+		batcher:      batcher,
+		service:      serviceName,
+		serviceID:    serviceID,
+	}, nil
+}
+
+// signature 计算 SharedKey 授权头
+func (c *azureMonitorClient) signature(date string, contentLength int) string {
+	stringToSign := "POST\n" + strconv.Itoa(contentLength) + "\napplication/json\nx-ms-date:" + date + "\n" + azureDataCollectorAPI
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(stringToSign))
+	return "SharedKey " + c.workspaceID + ":" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// post 以 JSON 数组发送一批日志，400/403/404 等请求错误不重试
+func (c *azureMonitorClient) post(ctx context.Context, batch []remoteRecord) error {
+	var body bytes.Buffer
+	body.WriteByte('[')
+	for i := range batch {
+		if i > 0 {
+			body.WriteByte(',')
+		}
+		body.Write(batch[i].Data)
+	}
+	body.WriteByte(']')
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return err
+	}
+	date := time.Now().UTC().Format(http.TimeFormat)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Log-Type", c.logType)
+	req.Header.Set("x-ms-date", date)
+	req.Header.Set("time-generated-field", "TimeGenerated")
+	req.Header.Set("Authorization", c.signature(date, body.Len()))
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("Log Analytics 返回 %s: %s", resp.Status, bytes.TrimSpace(msg))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return &remotePartialError{Rejected: len(batch), Err: err}
+	}
+	return err
+}
+
+// azureMonitorRecord 自定义日志表的一行
+type azureMonitorRecord struct {
+	TimeGenerated string `json:"TimeGenerated"`
+	Level         string `json:"Level"`
+	Service       string `json:"Service"`
+	ServiceID     uint64 `json:"ServiceID"`
+	Directory     string `json:"Directory"`
+	Caller        string `json:"Caller"`
+	Message       string `json:"Message"`
+	Fields        string `json:"Fields"`
+}
+
+// azureMonitorCore 将日志转换为固定列的 zapcore.Core
+// business/folder/directory 字段写入 Directory 列，其余字段编码为 JSON 写入 Fields 列
+type azureMonitorCore struct {
+	zapcore.LevelEnabler
+	batcher   *remoteBatcher
+	service   string
+	serviceID uint64
+	fields    []zapcore.Field // 通过 With 附加的字段
+}
+
+func (c *azureMonitorCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(append([]zapcore.Field(nil), c.fields...), fields...)
+	return &clone
+}
+
+func (c *azureMonitorCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *azureMonitorCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	directory, fieldsJSON, err := encodeStructuredFields(entry, c.fields, fields)
+	if err != nil {
+		return err
+	}
+	record := azureMonitorRecord{
+		TimeGenerated: entry.Time.UTC().Format(time.RFC3339Nano),
+		Level:         entry.Level.String(),
+		Service:       c.service,
+		ServiceID:     c.serviceID,
+		Directory:     directory,
+		Message:       entry.Message,
+		Fields:        fieldsJSON,
+	}
+	if entry.Caller.Defined {
+		record.Caller = entry.Caller.TrimmedPath()
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	c.batcher.add(remoteRecord{Entry: entry, Data: data})
+	return nil
+}
+
+func (c *azureMonitorCore) Sync() error {
+	return nil
+}
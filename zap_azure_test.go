@@ -0,0 +1,96 @@
+package mlog
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestAzureMonitor 测试 SharedKey 签名、请求头和表结构映射
+func TestAzureMonitor(t *testing.T) {
+	key := []byte("workspace-secret")
+	var (
+		mu      sync.Mutex
+		records []azureMonitorRecord
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		stringToSign := "POST\n" + strconv.Itoa(len(body)) + "\napplication/json\nx-ms-date:" + r.Header.Get("x-ms-date") + "\n/api/logs"
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(stringToSign))
+		want := "SharedKey ws-1:" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		if r.Header.Get("Authorization") != want || r.Header.Get("Log-Type") != "GameLogs" ||
+			r.URL.Query().Get("api-version") != "2016-04-01" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var batch []azureMonitorRecord
+		json.Unmarshal(body, &batch)
+		mu.Lock()
+		records = append(records, batch...)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	core, err := newAzureMonitorCore(AzureMonitorConfig{
+		WorkspaceID: "ws-1",
+		SharedKey:   base64.StdEncoding.EncodeToString(key),
+		LogType:     "GameLogs",
+		Endpoint:    server.URL,
+	}, "shard", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	zap.New(core).Error("副本重置失败", zap.String("business", "dungeon"), zap.Int("instance", 7))
+	core.batcher.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(records) != 1 {
+		t.Fatalf("写入条数错误: %+v", records)
+	}
+	r := records[0]
+	if r.Level != "error" || r.Service != "shard" || r.ServiceID != 12 || r.Directory != "dungeon" ||
+		r.Message != "副本重置失败" || r.Fields != `{"instance":7}` || r.TimeGenerated == "" {
+		t.Fatalf("表结构映射错误: %+v", r)
+	}
+	if stats := core.batcher.stats(); stats.Sent != 1 {
+		t.Fatalf("统计错误: %+v", stats)
+	}
+}
+
+// TestAzureMonitorRejected 测试请求错误不重试
+func TestAzureMonitorRejected(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	core, err := newAzureMonitorCore(AzureMonitorConfig{
+		WorkspaceID: "ws-1",
+		SharedKey:   base64.StdEncoding.EncodeToString([]byte("k")),
+		Endpoint:    server.URL,
+	}, "shard", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	zap.New(core).Info("上线")
+	core.batcher.Close()
+	if stats := core.batcher.stats(); calls != 1 || stats.Failed != 1 {
+		t.Fatalf("请求 %d 次，统计 %+v", calls, stats)
+	}
+}
@@ -0,0 +1,122 @@
+package mlog
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// BatchEntry 批量日志中的单条记录
+type BatchEntry struct {
+	Message string      // 日志消息（已格式化）
+	Fields  []zap.Field // 结构化字段
+}
+
+// DebugBatch 批量输出调试级别日志
+func DebugBatch(entries []BatchEntry) {
+	if !isDebugEnabledFast() {
+		return
+	}
+	logBatch(zapcore.DebugLevel, captureCaller(1), entries)
+}
+
+// InfoBatch 批量输出信息级别日志
+// 适用于每帧产生大量日志的系统（如伤害事件），整批日志共享一次时间戳读取和调用位置获取，
+// 异步模式下只需一次队列操作即可全部入队
+func InfoBatch(entries []BatchEntry) {
+	if !isInfoEnabledFast() {
+		return
+	}
+	logBatch(zapcore.InfoLevel, captureCaller(1), entries)
+}
+
+// WarnBatch 批量输出警告级别日志
+func WarnBatch(entries []BatchEntry) {
+	if !isWarnEnabledFast() {
+		return
+	}
+	logBatch(zapcore.WarnLevel, captureCaller(1), entries)
+}
+
+// ErrorBatch 批量输出错误级别日志
+func ErrorBatch(entries []BatchEntry) {
+	if !isErrorEnabledFast() {
+		return
+	}
+	logBatch(zapcore.ErrorLevel, captureCaller(1), entries)
+}
+
+// logBatch 批量日志的公共实现，caller 为入口处捕获的调用位置
+func logBatch(level zapcore.Level, caller zapcore.EntryCaller, entries []BatchEntry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	logger, ok := getLogger()
+	if !ok {
+		for i := range entries {
+			logBeforeInitAt(level, caller, entries[i].Message, entries[i].Fields...)
+		}
+		return
+	}
+
+	// 整批日志共享同一个时间戳和调用位置
+	timestamp := time.Now()
+
+	if al, ok := getAsyncLogger(); ok {
+		if !al.levelEnabled(level) {
+			return
+		}
+		batch := make([]AsyncLogEntry, 0, len(entries))
+		stack := captureStack(level)
+		for i := range entries {
+			sampleRate := 0
+			if al.sampler != nil {
+				keep, sampled := al.sampler.admit(al.queueFor(level), level)
+				if !keep {
+					recordDropped(level, entries[i].Message)
+					continue
+				}
+				if sampled {
+					sampleRate = al.sampler.rate
+				}
+			}
+			entry := AsyncLogEntry{
+				Level:     level,
+				Message:   entries[i].Message,
+				Caller:    caller,
+				Timestamp: timestamp,
+				Stack:     stack,
+			}
+			entry.copyFieldsToEntry(entries[i].Fields)
+			if GetFieldSnapshot() {
+				SnapshotFields(entry.Fields)
+			}
+			entry.stampSeq(al.seqField)
+			if sampleRate > 0 {
+				entry.appendField(zap.Int("sampled", sampleRate))
+			}
+			batch = append(batch, entry)
+		}
+		written := al.enqueueBatch(batch)
+		// 未能入队的条目归还字段切片
+		for i := written; i < len(batch); i++ {
+			batch[i].releaseFields()
+		}
+		return
+	}
+
+	core := logger.Core()
+	for i := range entries {
+		entry := zapcore.Entry{
+			Level:   level,
+			Time:    timestamp,
+			Message: entries[i].Message,
+			Caller:  caller,
+		}
+		if ce := core.Check(entry, nil); ce != nil {
+			ce.Write(entries[i].Fields...)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package mlog
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// batchLogLines 读取批量日志测试的日志文件并逐行解析为 JSON
+func batchLogLines(t *testing.T, dir string) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(readLogFile(t, filepath.Join(dir, "2", "gate", "all.log"))), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("日志行不是 JSON: %s", line)
+		}
+		lines = append(lines, record)
+	}
+	return lines
+}
+
+// TestBatchLog 测试同步和异步模式下批量日志按级别过滤，整批共享时间戳和调用位置并保留各自的字段
+func TestBatchLog(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		Close()
+		dir := t.TempDir()
+		InitialZap("gate", 2, "info", &ZapConfig{Director: dir, SingleFile: true, Format: "json",
+			TimeFormat: "rfc3339nano", ShowLine: true, EnableAsync: async})
+
+		DebugBatch([]BatchEntry{{Message: "filtered"}})
+		_, file, line, _ := runtime.Caller(0)
+		InfoBatch([]BatchEntry{
+			{Message: "hit 0", Fields: []zap.Field{zap.Int("damage", 0)}},
+			{Message: "hit 1", Fields: []zap.Field{zap.Int("damage", 1)}},
+			{Message: "hit 2", Fields: []zap.Field{zap.Int("damage", 2)}},
+		})
+		WarnBatch(nil)
+		Flush()
+		Close()
+
+		lines := batchLogLines(t, dir)
+		if len(lines) != 3 {
+			t.Fatalf("async=%v 期望 3 行日志，实际 %v", async, lines)
+		}
+		wantCaller := filepath.Base(file) + ":" + strconv.Itoa(line+1)
+		for i, record := range lines {
+			if record["message"] != "hit "+strconv.Itoa(i) || record["damage"] != float64(i) {
+				t.Fatalf("async=%v 第 %d 行内容错误: %v", async, i, record)
+			}
+			if record["time"] != lines[0]["time"] {
+				t.Fatalf("async=%v 整批日志应共享时间戳: %v", async, lines)
+			}
+			if caller, _ := record["caller"].(string); !strings.HasSuffix(caller, wantCaller) {
+				t.Fatalf("async=%v 调用位置应为 %s，实际 %v", async, wantCaller, record["caller"])
+			}
+		}
+	}
+}
+
+// TestBatchDropOnFull 测试异步队列满且开启丢弃时，批量日志中未能入队的条目计为丢弃
+func TestBatchDropOnFull(t *testing.T) {
+	Close()
+	InitialZap("gate", 2, "info", &ZapConfig{Director: t.TempDir(), SingleFile: true})
+	defer Close()
+
+	// 没有消费者的异步日志器，队列写满后不会腾出空位
+	al := &AsyncLogger{notify: make(chan struct{}, 1), done: make(chan struct{}), levelCache: NewLevelCache()}
+	al.setupQueues(4, true, nil)
+	asyncMutex.Lock()
+	globalAsyncLogger = al
+	asyncMutex.Unlock()
+	defer func() {
+		asyncMutex.Lock()
+		globalAsyncLogger = nil
+		asyncMutex.Unlock()
+	}()
+
+	entries := make([]BatchEntry, 6)
+	for i := range entries {
+		entries[i] = BatchEntry{Message: "hit", Fields: []zap.Field{zap.Int("damage", i)}}
+	}
+	InfoBatch(entries)
+
+	stats := al.QueueStats()
+	if stats.Enqueued != 4 || stats.Dropped != 2 {
+		t.Fatalf("期望入队 4 条、丢弃 2 条，实际 %+v", stats)
+	}
+	var entry, first AsyncLogEntry
+	for i := 0; al.tryPop(&entry); i++ {
+		if i == 0 {
+			first = entry
+		}
+		if entry.Level != zapcore.InfoLevel || !entry.Timestamp.Equal(first.Timestamp) || entry.Fields[0].Integer != int64(i) {
+			t.Fatalf("第 %d 条入队内容错误: %+v", i, entry)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package mlog
+
+import "time"
+
+// defaultBufferFlushInterval 写缓冲定时写入文件的默认间隔
+const defaultBufferFlushInterval = time.Second
+
+// bufferSize 日志文件写缓冲大小（字节），0 表示不缓冲
+func (c *ZapConfig) bufferSize() int {
+	if c.BufferSizeKB <= 0 {
+		return 0
+	}
+	return c.BufferSizeKB * 1024
+}
+
+// bufferFlushInterval 写缓冲定时写入文件的间隔
+func (c *ZapConfig) bufferFlushInterval() time.Duration {
+	if c.FlushIntervalMs <= 0 {
+		return defaultBufferFlushInterval
+	}
+	return time.Duration(c.FlushIntervalMs) * time.Millisecond
+}
+
+// unbufferedFile 写缓冲的下游，缓冲写满或定时刷新时直接写入文件
+type unbufferedFile struct {
+	f *fileWriteSyncer
+}
+
+func (u unbufferedFile) Write(p []byte) (int, error) {
+	return u.f.write(p)
+}
+
+// Sync 文件写入返回后数据已经交给内核，不需要额外同步
+func (u unbufferedFile) Sync() error {
+	return nil
+}
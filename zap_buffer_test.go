@@ -0,0 +1,40 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBufferedFileSink 测试开启写缓冲后日志先留在内存中，Flush 后写入文件，定时刷新也会写入文件
+func TestBufferedFileSink(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, BufferSizeKB: 64, FlushIntervalMs: 200}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+	file := filepath.Join(dir, "2", "gate", "info.log")
+
+	InfoW("缓冲中的日志")
+	if data, _ := os.ReadFile(file); strings.Contains(string(data), "缓冲中的日志") {
+		t.Fatal("日志应先写入缓冲")
+	}
+	Flush()
+	if data, _ := os.ReadFile(file); !strings.Contains(string(data), "缓冲中的日志") {
+		t.Fatalf("Flush 后日志应写入文件: %s", data)
+	}
+
+	InfoW("定时刷新的日志")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if data, _ := os.ReadFile(file); strings.Contains(string(data), "定时刷新的日志") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("定时刷新后日志应写入文件")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	lru "github.com/hashicorp/golang-lru/v2"
 )
@@ -39,6 +40,9 @@ type PathCache struct {
 	projectRoots []string
 	// 预编译的正则表达式用于堆栈处理
 	stackPathRegex *regexp.Regexp
+	// 命中和未命中次数
+	hits   atomic.Uint64
+	misses atomic.Uint64
 }
 
 // initPathCache 初始化路径缓存
@@ -79,9 +83,11 @@ func (pc *PathCache) getRelativePathCached(absolutePath string) string {
 	pc.mutex.RLock()
 	if entry, ok := pc.cache.Get(absolutePath); ok {
 		pc.mutex.RUnlock()
+		pc.hits.Add(1)
 		return entry.relativePath
 	}
 	pc.mutex.RUnlock()
+	pc.misses.Add(1)
 
 	// 缓存未命中，计算相对路径
 	relativePath := pc.computeRelativePath(absolutePath)
@@ -203,14 +209,22 @@ func (pc *PathCache) ClearCache() {
 	pc.mutex.Unlock()
 }
 
-// GetCacheStats 获取缓存统计信息
+// GetCacheStats 获取缓存的累计命中和未命中次数
 func (pc *PathCache) GetCacheStats() (hits, misses int) {
 	if pc == nil {
 		return 0, 0
 	}
+	return int(pc.hits.Load()), int(pc.misses.Load())
+}
+
+// cacheSize 返回缓存的路径数
+func (pc *PathCache) cacheSize() int {
+	if pc == nil {
+		return 0
+	}
 	pc.mutex.RLock()
 	defer pc.mutex.RUnlock()
-	return pc.cache.Len(), 0 // LRU v2 不直接提供 miss 统计
+	return pc.cache.Len()
 }
 
 // UpdateWorkingDirectory 更新工作目录（用于动态配置）
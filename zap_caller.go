@@ -0,0 +1,46 @@
+package mlog
+
+import (
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// captureCaller 捕获调用方位置
+// skip 为调用 captureCaller 的函数之上需要跳过的层数，公开 API 入口处传 1 即为用户代码
+func captureCaller(skip int) zapcore.EntryCaller {
+	if pc, file, line, ok := runtime.Caller(skip + 1); ok {
+		return zapcore.NewEntryCaller(pc, file, line, true)
+	}
+	return zapcore.NewEntryCaller(0, "", 0, false)
+}
+
+// logAt 以入口处捕获的调用方位置记录日志
+// 启用异步日志时入队，否则同步写入；调用位置由入口传入，无需再按调用栈层数推算
+func logAt(level zapcore.Level, caller zapcore.EntryCaller, msg string, args []any, fields ...zap.Field) {
+	if al, ok := getAsyncLogger(); ok {
+		al.logAsyncAt(level, caller, msg, args, fields...)
+		return
+	}
+	writeSyncAt(level, caller, formatMessage(msg, args, false), fields...)
+}
+
+// writeSyncAt 同步写入日志，使用入口处捕获的调用方位置
+// 经过 *zap.Logger 写入，hooks、Development 下 DPanic 的 panic、logger 名称、ErrorOutput 和 AddStacktrace 都照常生效；
+// 是否输出调用位置由 logger 的 AddCaller（ShowLine）决定，输出时替换为入口处捕获的位置
+func writeSyncAt(level zapcore.Level, caller zapcore.EntryCaller, msg string, fields ...zap.Field) {
+	logger, ok := getLogger()
+	if !ok {
+		logBeforeInitAt(level, caller, msg, fields...)
+		return
+	}
+	ce := logger.Check(level, msg)
+	if ce == nil {
+		return
+	}
+	if ce.Caller.Defined {
+		ce.Caller = caller
+	}
+	ce.Write(fields...)
+}
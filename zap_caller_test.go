@@ -0,0 +1,81 @@
+package mlog
+
+import (
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestCallerCapturedAtEntry 测试同步和异步模式下各入口记录的调用位置都指向用户代码
+func TestCallerCapturedAtEntry(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		Close()
+		config := ZapConfig{
+			Level:         "info",
+			Format:        "console",
+			Director:      t.TempDir(),
+			SingleFile:    true,
+			ShowLine:      true,
+			CallSiteStats: true,
+			EnableAsync:   async,
+		}
+		InitialZap("caller", 0, "info", &config)
+		ResetCallSiteStats()
+
+		Info("格式化 %d", 1)
+		InfoW("结构化")
+		Warn("警告")
+		ErrorW("错误")
+		_ = ReturnError("返回错误 %s", "x")
+		InfoBatch([]BatchEntry{{Message: "批量"}})
+		Flush()
+
+		sites := TopCallSites(0, false)
+		if len(sites) != 6 {
+			t.Fatalf("async=%v 期望 6 个调用位置，实际 %+v", async, sites)
+		}
+		for _, site := range sites {
+			if !strings.HasSuffix(site.File, "zap_caller_test.go") || !strings.Contains(site.Function, "TestCallerCapturedAtEntry") {
+				t.Errorf("async=%v 调用位置错误: %+v", async, site)
+			}
+		}
+		Close()
+	}
+}
+
+// TestSyncWriteUsesLoggerOptions 测试同步写入经过 *zap.Logger：hooks 和 logger 名称生效，
+// 调用位置替换为入口处捕获的位置，未开启 ShowLine 时不输出调用位置
+func TestSyncWriteUsesLoggerOptions(t *testing.T) {
+	for _, showLine := range []bool{true, false} {
+		Close()
+		config := ZapConfig{Level: "info", Director: t.TempDir(), SingleFile: true, ShowLine: showLine}
+		InitialZap("caller", 0, "info", &config)
+
+		var hooked []zapcore.Entry
+		logger := GLOG().Named("battle").WithOptions(zap.Hooks(func(e zapcore.Entry) error {
+			hooked = append(hooked, e)
+			return nil
+		}))
+		atomic.StorePointer(&loggerPtr, unsafe.Pointer(logger))
+
+		_, file, line, _ := runtime.Caller(0)
+		InfoW("结构化")
+		Close()
+
+		if len(hooked) != 1 || hooked[0].LoggerName != "battle" {
+			t.Fatalf("showLine=%v hooks 和 logger 名称应生效: %+v", showLine, hooked)
+		}
+		caller := hooked[0].Caller
+		if showLine && (caller.File != file || caller.Line != line+1) {
+			t.Fatalf("调用位置应为入口处捕获的位置: %s:%d", caller.File, caller.Line)
+		}
+		if !showLine && caller.Defined {
+			t.Fatalf("未开启 ShowLine 时不应输出调用位置: %+v", caller)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// clickhouseTimeFormat 写入 DateTime64(3, 'UTC') 列的时间格式
+const clickhouseTimeFormat = "2006-01-02 15:04:05.000"
+
+// ClickHouseConfig ClickHouse 输出配置
+// 通过 HTTP 接口以 JSONEachRow 格式批量插入，表结构固定：
+// ts、level、service、id、directory、caller、message、fields（其余字段的 JSON）
+type ClickHouseConfig struct {
+	Enable   bool   `mapstructure:"enable" json:"enable" yaml:"enable"`       // 启用 ClickHouse 输出
+	Address  string `mapstructure:"address" json:"address" yaml:"address"`    // HTTP 接口地址，如 http://127.0.0.1:8123
+	Database string `mapstructure:"database" json:"database" yaml:"database"` // 数据库（默认 default）
+	Table    string `mapstructure:"table" json:"table" yaml:"table"`          // 表名（默认 mlog_logs）
+	Username string `mapstructure:"username" json:"username" yaml:"username"` // 用户名
+	Password string `mapstructure:"password" json:"password" yaml:"password"` // 密码
+	// 首次写入前执行 CREATE TABLE IF NOT EXISTS（MergeTree，按天分区）
+	CreateTable       bool `mapstructure:"create-table" json:"create-table" yaml:"create-table"`
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// clickhouseClient ClickHouse HTTP 接口客户端
+type clickhouseClient struct {
+	address    string
+	database   string
+	table      string // 带数据库前缀的表名
+	username   string
+	password   string
+	httpClient *http.Client
+
+	createTable bool
+	tableMutex  sync.Mutex
+	tableReady  bool
+}
+
+// newClickHouseCore 创建 ClickHouse 输出 Core
+func newClickHouseCore(cfg ClickHouseConfig, serviceName string, serviceID uint64) (*clickhouseCore, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("未配置 ClickHouse 地址")
+	}
+	database := cfg.Database
+	if database == "" {
+		database = "default"
+	}
+	table := cfg.Table
+	if table == "" {
+		table = "mlog_logs"
+	}
+	client := &clickhouseClient{
+		address:     strings.TrimSuffix(cfg.Address, "/"),
+		database:    database,
+		table:       clickhouseIdentifier(database) + "." + clickhouseIdentifier(table),
+		username:    cfg.Username,
+		password:    cfg.Password,
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		createTable: cfg.CreateTable,
+	}
+	batcher := newRemoteBatcher("clickhouse", cfg.RemoteBatchConfig, client.insert)
+	addSinkCloser(batcher)
+	return &clickhouseCore{
+		LevelEnabler: atomicLevel,
+		batcher:      batcher,
+		service:      serviceName,
+		serviceID:    serviceID,
+	}, nil
+}
+
+// clickhouseIdentifier 以反引号引用标识符
+func clickhouseIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+// query 执行一条语句，body 非空时作为语句的数据部分
+func (c *clickhouseClient) query(ctx context.Context, query string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.address+"/?query="+url.QueryEscape(query), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if c.username != "" {
+		req.Header.Set("X-ClickHouse-User", c.username)
+		req.Header.Set("X-ClickHouse-Key", c.password)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("ClickHouse 返回 %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// ensureTable 创建日志表，成功一次后不再重复
+func (c *clickhouseClient) ensureTable(ctx context.Context) error {
+	c.tableMutex.Lock()
+	defer c.tableMutex.Unlock()
+	if !c.createTable || c.tableReady {
+		return nil
+	}
+	ddl := "CREATE TABLE IF NOT EXISTS " + c.table + ` (
+	ts DateTime64(3, 'UTC'),
+	level LowCardinality(String),
+	service LowCardinality(String),
+	id UInt64,
+	directory LowCardinality(String),
+	caller String,
+	message String,
+	fields String
+) ENGINE = MergeTree
+PARTITION BY toYYYYMMDD(ts)
+ORDER BY (service, level, ts)`
+	if err := c.query(ctx, ddl, nil); err != nil {
+		return fmt.Errorf("创建 ClickHouse 日志表失败: %w", err)
+	}
+	c.tableReady = true
+	return nil
+}
+
+// insert 以 JSONEachRow 格式插入一批日志
+func (c *clickhouseClient) insert(ctx context.Context, batch []remoteRecord) error {
+	if err := c.ensureTable(ctx); err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	for i := range batch {
+		body.Write(batch[i].Data)
+		body.WriteByte('\n')
+	}
+	return c.query(ctx, "INSERT INTO "+c.table+" FORMAT JSONEachRow", body.Bytes())
+}
+
+// clickhouseRow 日志表的一行
+type clickhouseRow struct {
+	TS        string `json:"ts"`
+	Level     string `json:"level"`
+	Service   string `json:"service"`
+	ID        uint64 `json:"id"`
+	Directory string `json:"directory"`
+	Caller    string `json:"caller"`
+	Message   string `json:"message"`
+	Fields    string `json:"fields"`
+}
+
+// clickhouseCore 将日志转换为固定表结构的 zapcore.Core
+// business/folder/directory 字段写入 directory 列，其余字段编码为 JSON 写入 fields 列
+type clickhouseCore struct {
+	zapcore.LevelEnabler
+	batcher   *remoteBatcher
+	service   string
+	serviceID uint64
+	fields    []zapcore.Field // 通过 With 附加的字段
+}
+
+func (c *clickhouseCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(append([]zapcore.Field(nil), c.fields...), fields...)
+	return &clone
+}
+
+func (c *clickhouseCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *clickhouseCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	directory, fieldsJSON, err := encodeStructuredFields(entry, c.fields, fields)
+	if err != nil {
+		return err
+	}
+	row := clickhouseRow{
+		TS:        entry.Time.UTC().Format(clickhouseTimeFormat),
+		Level:     entry.Level.String(),
+		Service:   c.service,
+		ID:        c.serviceID,
+		Directory: directory,
+		Message:   entry.Message,
+		Fields:    fieldsJSON,
+	}
+	if entry.Caller.Defined {
+		row.Caller = entry.Caller.TrimmedPath()
+	}
+
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	c.batcher.add(remoteRecord{Entry: entry, Data: data})
+	return nil
+}
+
+// encodeStructuredFields 拆分日志字段：business/folder/directory 作为分类目录返回，
+// 其余字段（含堆栈）编码为 JSON 对象，供按列存储日志的输出使用
+func encodeStructuredFields(entry zapcore.Entry, base, fields []zapcore.Field) (directory, fieldsJSON string, err error) {
+	enc := zapcore.NewMapObjectEncoder()
+	for i := range base {
+		base[i].AddTo(enc)
+	}
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	for k, v := range enc.Fields {
+		if gelfCategoryFields[k] {
+			directory = fmt.Sprint(v)
+			delete(enc.Fields, k)
+		}
+	}
+	if entry.Stack != "" {
+		enc.Fields["stacktrace"] = entry.Stack
+	}
+	if len(enc.Fields) == 0 {
+		return directory, "{}", nil
+	}
+	data, err := json.Marshal(enc.Fields)
+	if err != nil {
+		return "", "", err
+	}
+	return directory, string(data), nil
+}
+
+func (c *clickhouseCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,77 @@
+package mlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestClickHouseInsert 测试建表语句和 JSONEachRow 行内容
+func TestClickHouseInsert(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		queries []string
+		rows    []clickhouseRow
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-ClickHouse-User") != "writer" || r.Header.Get("X-ClickHouse-Key") != "pw" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		queries = append(queries, r.URL.Query().Get("query"))
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var row clickhouseRow
+			if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+				t.Error(err)
+			}
+			rows = append(rows, row)
+		}
+	}))
+	defer server.Close()
+
+	core, err := newClickHouseCore(ClickHouseConfig{
+		Address:     server.URL,
+		Database:    "game",
+		Username:    "writer",
+		Password:    "pw",
+		CreateTable: true,
+	}, "battle", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 123e6, time.UTC)
+	ce := core.With([]zapcore.Field{zap.String("business", "pay")}).Check(zapcore.Entry{Level: zapcore.WarnLevel, Time: ts, Message: "充值失败"}, nil)
+	ce.Write(zap.Int("amount", 30))
+	core.batcher.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(queries) != 2 || !strings.HasPrefix(queries[0], "CREATE TABLE IF NOT EXISTS `game`.`mlog_logs`") ||
+		queries[1] != "INSERT INTO `game`.`mlog_logs` FORMAT JSONEachRow" {
+		t.Fatalf("语句错误: %q", queries)
+	}
+	want := clickhouseRow{
+		TS:        "2024-05-01 10:00:00.123",
+		Level:     "warn",
+		Service:   "battle",
+		ID:        12,
+		Directory: "pay",
+		Message:   "充值失败",
+		Fields:    `{"amount":30}`,
+	}
+	if len(rows) != 1 || rows[0] != want {
+		t.Fatalf("行内容错误: %+v", rows)
+	}
+}
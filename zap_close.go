@@ -0,0 +1,153 @@
+package mlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrCloseTimeout 关闭时未能在期限内写完异步队列中的日志
+var ErrCloseTimeout = errors.New("mlog: 关闭超时，仍有日志未写入")
+
+// closeAbortGrace 超时后等待消费者写完当前条目并退出的宽限时间
+const closeAbortGrace = 100 * time.Millisecond
+
+// CloseWithTimeout 在指定期限内关闭日志系统
+// 与 Close 不同，异步队列未能在期限内写完时不再继续等待，
+// 剩余条目按当前的脱敏配置处理后写入日志目录下的 .pending 恢复文件（JSON 行格式），
+// 容器模式（OutputMode: stdout）下不创建文件，以 JSON 行输出到标准输出；
+// 返回未写入正常日志的条目数；存在剩余条目时 err 包装 ErrCloseTimeout 并注明恢复文件路径。
+func CloseWithTimeout(d time.Duration) (remaining int, err error) {
+	config := GetConfig()
+	asyncMutex.Lock()
+	if globalAsyncLogger != nil {
+		remaining, err = globalAsyncLogger.closeWithTimeout(d, currentDirector(), config.stdoutMode())
+		globalAsyncLogger = nil
+	}
+	asyncMutex.Unlock()
+
+	closeSyncLogger()
+	return remaining, err
+}
+
+// closeWithTimeout 关闭异步日志器，超时后接管剩余条目并写入 dir 下的恢复文件，stdout 为 true 时输出到标准输出
+func (al *AsyncLogger) closeWithTimeout(d time.Duration, dir string, stdout bool) (int, error) {
+	close(al.done)
+	if waitGroupTimeout(&al.wg, d) {
+		return 0, nil
+	}
+
+	// 期限已到：通知消费者在当前条目写完后退出
+	atomic.StoreInt32(&al.aborted, 1)
+	if !waitGroupTimeout(&al.wg, closeAbortGrace) {
+		// 消费者卡在写入中（如磁盘挂起），无法安全接管队列，只能报告剩余数量
+		remaining := al.QueueStats().Depth
+		return remaining, fmt.Errorf("%w: %d 条日志滞留在队列中，写入协程无响应", ErrCloseTimeout, remaining)
+	}
+
+	// 消费者已退出，当前 goroutine 成为唯一消费者
+	var pending []AsyncLogEntry
+	var entry AsyncLogEntry
+	for al.tryPop(&entry) {
+		if entry.flushDone != nil {
+			close(entry.flushDone)
+		}
+		if entry.flushOnly {
+			continue
+		}
+		pending = append(pending, entry)
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	if stdout {
+		if err := writePendingEntries(consoleSink, pending); err != nil {
+			return len(pending), fmt.Errorf("%w: %d 条日志未写入，输出到标准输出失败: %v", ErrCloseTimeout, len(pending), err)
+		}
+		return len(pending), fmt.Errorf("%w: %d 条日志已以 JSON 行输出到标准输出", ErrCloseTimeout, len(pending))
+	}
+	path, err := writePendingFile(dir, pending)
+	if err != nil {
+		return len(pending), fmt.Errorf("%w: %d 条日志未写入，恢复文件写入失败: %v", ErrCloseTimeout, len(pending), err)
+	}
+	return len(pending), fmt.Errorf("%w: %d 条日志已保存到 %s", ErrCloseTimeout, len(pending), path)
+}
+
+// waitGroupTimeout 等待 WaitGroup 中的 goroutine 全部退出，期限内退出返回 true
+func waitGroupTimeout(wg *sync.WaitGroup, d time.Duration) bool {
+	exited := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(exited)
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-exited:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// writePendingFile 将未写入的条目以 JSON 行格式写入恢复文件，返回文件路径
+func writePendingFile(dir string, entries []AsyncLogEntry) (string, error) {
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("mlog-%s-%d.pending", time.Now().Format("20060102-150405"), os.Getpid())
+	path := filepath.Join(dir, name)
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := writePendingEntries(file, entries); err != nil {
+		return path, err
+	}
+	return path, file.Sync()
+}
+
+// writePendingEntries 将未写入的条目以 JSON 行格式写入 ws
+// 条目入队时尚未经过 Core 链，写出前按当前的脱敏配置（RedactKeys、MaskRules）处理消息和字段
+func writePendingEntries(ws zapcore.WriteSyncer, entries []AsyncLogEntry) error {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	core := pendingRedactor().wrap(zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), ws, zapcore.DebugLevel))
+	var err error
+	for i := range entries {
+		zapEntry := zapcore.Entry{
+			Level:   entries[i].Level,
+			Time:    entries[i].Timestamp,
+			Message: entries[i].Message,
+			Caller:  entries[i].Caller,
+		}
+		if err == nil {
+			err = core.Write(zapEntry, entries[i].Fields)
+		}
+		entries[i].releaseFields()
+	}
+	return err
+}
+
+// pendingRedactor 返回当前日志器的脱敏包装层，未初始化时按当前配置创建
+func pendingRedactor() *coreWrapper {
+	if hot := activeHotCores.Load(); hot != nil {
+		return hot.redact.Load()
+	}
+	return redactWrapper(GetConfig())
+}
@@ -0,0 +1,148 @@
+package mlog
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newStuckAsyncLogger 创建消费者卡住的异步日志器，并按 fields 依次入队 n 条日志
+func newStuckAsyncLogger(n int, message string, fields func(i int) []zap.Field) *AsyncLogger {
+	al := &AsyncLogger{notify: make(chan struct{}, 1), done: make(chan struct{})}
+	al.setupQueues(16, false, nil)
+
+	// 模拟一个卡住的消费者：直到被通知中止才退出
+	al.wg.Add(1)
+	go func() {
+		defer al.wg.Done()
+		for atomic.LoadInt32(&al.aborted) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		entry := AsyncLogEntry{Level: zapcore.InfoLevel, Message: message, Timestamp: time.Now()}
+		entry.copyFieldsToEntry(fields(i))
+		al.enqueue(&entry)
+	}
+	return al
+}
+
+// TestCloseWithTimeoutPending 测试消费者超时未写完时剩余条目被写入 .pending 恢复文件
+func TestCloseWithTimeoutPending(t *testing.T) {
+	al := newStuckAsyncLogger(3, "pending", func(i int) []zap.Field {
+		return []zap.Field{zap.Int("index", i)}
+	})
+
+	dir := t.TempDir()
+	remaining, err := al.closeWithTimeout(20*time.Millisecond, dir, false)
+	if remaining != 3 {
+		t.Fatalf("期望剩余 3 条，实际 %d", remaining)
+	}
+	if !errors.Is(err, ErrCloseTimeout) {
+		t.Fatalf("期望 ErrCloseTimeout，实际 %v", err)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.pending"))
+	if len(matches) != 1 {
+		t.Fatalf("期望 1 个恢复文件，实际 %v", matches)
+	}
+	file, err := os.Open(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if !strings.Contains(scanner.Text(), `"index":`) {
+			t.Fatalf("恢复文件内容缺少字段: %s", scanner.Text())
+		}
+		lines++
+	}
+	if lines != 3 {
+		t.Fatalf("恢复文件期望 3 行，实际 %d", lines)
+	}
+}
+
+// TestCloseWithTimeoutPendingRedact 测试恢复文件中的条目按脱敏配置处理
+func TestCloseWithTimeoutPendingRedact(t *testing.T) {
+	hot := &hotCores{}
+	hot.redact.Store(redactWrapper(&ZapConfig{RedactKeys: []string{"password"}}))
+	oldHot := activeHotCores.Swap(hot)
+	defer activeHotCores.Store(oldHot)
+
+	al := newStuckAsyncLogger(2, "login password=hunter2", func(i int) []zap.Field {
+		return []zap.Field{zap.String("password", "hunter2"), zap.Int("index", i)}
+	})
+
+	dir := t.TempDir()
+	if _, err := al.closeWithTimeout(20*time.Millisecond, dir, false); !errors.Is(err, ErrCloseTimeout) {
+		t.Fatalf("期望 ErrCloseTimeout，实际 %v", err)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.pending"))
+	if len(matches) != 1 {
+		t.Fatalf("期望 1 个恢复文件，实际 %v", matches)
+	}
+	content, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "hunter2") {
+		t.Fatalf("恢复文件中出现了未脱敏的值: %s", content)
+	}
+	if strings.Count(string(content), `"password":"***"`) != 2 {
+		t.Fatalf("恢复文件中的字段未脱敏: %s", content)
+	}
+}
+
+// TestCloseWithTimeoutPendingStdout 测试容器模式下剩余条目以 JSON 行输出到标准输出且不创建目录和文件
+func TestCloseWithTimeoutPendingStdout(t *testing.T) {
+	console, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer console.Close()
+	oldConsole := consoleSink
+	consoleSink = newConsoleWriteSyncer(console)
+	defer func() { consoleSink = oldConsole }()
+
+	al := newStuckAsyncLogger(3, "pending", func(i int) []zap.Field {
+		return []zap.Field{zap.Int("index", i)}
+	})
+
+	dir := filepath.Join(t.TempDir(), "logs")
+	remaining, err := al.closeWithTimeout(20*time.Millisecond, dir, true)
+	if remaining != 3 {
+		t.Fatalf("期望剩余 3 条，实际 %d", remaining)
+	}
+	if !errors.Is(err, ErrCloseTimeout) {
+		t.Fatalf("期望 ErrCloseTimeout，实际 %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("容器模式下不应创建日志目录: %v", err)
+	}
+
+	content, err := os.ReadFile(console.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("标准输出期望 3 行，实际 %q", content)
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "{") || !strings.Contains(line, `"index":`) {
+			t.Fatalf("标准输出内容不是 JSON 行: %s", line)
+		}
+	}
+}
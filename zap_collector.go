@@ -0,0 +1,426 @@
+package mlog
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// collectorShipPath 日志收集服务 Ship 方法的 gRPC 路径，协议定义见 proto/collector.proto
+const collectorShipPath = "/mlog.collector.v1.LogCollector/Ship"
+
+// 日志收集服务客户端的默认参数
+const (
+	defaultCollectorMaxInFlight = 16
+	collectorDialTimeout        = 5 * time.Second
+	collectorCloseTimeout       = 3 * time.Second
+)
+
+// CollectorConfig 中心日志收集服务配置
+type CollectorConfig struct {
+	Enable   bool   `mapstructure:"enable" json:"enable" yaml:"enable"`       // 启用日志收集服务输出
+	Endpoint string `mapstructure:"endpoint" json:"endpoint" yaml:"endpoint"` // 收集服务地址，如 http://127.0.0.1:7070（https 使用 TLS）
+	// 附加的请求头（如认证信息）
+	Headers      map[string]string `mapstructure:"headers" json:"headers" yaml:"headers"`
+	MaxInFlight  int               `mapstructure:"max-in-flight" json:"max-in-flight" yaml:"max-in-flight"`    // 最多未确认的批次数（默认 16）
+	MaxBackoffMs int               `mapstructure:"max-backoff-ms" json:"max-backoff-ms" yaml:"max-backoff-ms"` // 重连退避间隔上限（毫秒，默认 30000）
+	// 日志编码：json（默认，写入 LogEntry.json）、msgpack（写入 LogEntry.msgpack）或 protobuf（写入 LogEntry.record）
+	Encoding string `mapstructure:"encoding" json:"encoding" yaml:"encoding"`
+	// 断线期间日志保留在缓冲区并持续重连，缓冲区满时丢弃新日志
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// collectorDataFields 各编码的日志内容在 LogEntry 中的字段号
+var collectorDataFields = map[string]int{
+	SinkEncodingJSON:     3,
+	SinkEncodingMsgpack:  4,
+	SinkEncodingProtobuf: 5,
+}
+
+// collectorBatch 已发送但尚未被确认的批次
+type collectorBatch struct {
+	seq     uint64
+	payload []byte // 编码后的 LogBatch
+}
+
+// collectorStream 一条 Ship 双向流
+type collectorStream struct {
+	body   *io.PipeWriter
+	cancel context.CancelFunc
+	ready  bool  // 已收到对 hello 的回复
+	err    error // 流结束的原因，非空表示流已断开
+}
+
+// collectorShipper 日志收集服务客户端
+// 使用标准库的 HTTP/2 直接实现 gRPC 双向流：发送受服务端授予的额度和未确认批次数限制，
+// 断线重连时在 hello 中带上续传令牌，服务端回复续传位置后重发未确认的批次
+type collectorShipper struct {
+	url         string
+	headers     map[string]string
+	hello       []byte // 不含续传令牌的 Hello 消息
+	maxInFlight int
+	httpClient  *http.Client
+	dataField   int // 日志内容在 LogEntry 中的字段号，见 collectorDataFields
+
+	mu          sync.Mutex
+	cond        *sync.Cond // 确认、额度或流状态变化时广播
+	stream      *collectorStream
+	nextSeq     uint64
+	unacked     []collectorBatch
+	credits     int
+	resumeToken string
+}
+
+// newCollectorCore 创建日志收集服务输出 Core
+func newCollectorCore(cfg CollectorConfig, serviceName string, serviceID uint64) (*remoteCore, error) {
+	if cfg.Endpoint == "" {
+		return nil, errors.New("未配置日志收集服务地址")
+	}
+	encoding, err := parseSinkEncoding(cfg.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := strings.TrimSuffix(cfg.Endpoint, "/")
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		endpoint = "http://" + endpoint
+	}
+	protocols := new(http.Protocols)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	s := &collectorShipper{
+		url:         endpoint + collectorShipPath,
+		headers:     cfg.Headers,
+		maxInFlight: positiveOr(cfg.MaxInFlight, defaultCollectorMaxInFlight),
+		// 流的生命周期不受超时限制，建立连接的超时在 openLocked 中单独控制
+		httpClient: &http.Client{Transport: &http.Transport{Protocols: protocols}},
+		dataField:  collectorDataFields[encoding],
+	}
+	s.cond = sync.NewCond(&s.mu)
+	s.hello = appendPBString(nil, 1, serviceName)
+	s.hello = appendPBVarint(s.hello, 2, serviceID)
+	s.hello = appendPBString(s.hello, 4, Version)
+
+	batcher := newRemoteBatcher("collector", cfg.RemoteBatchConfig, s.send)
+	batcher.persistent = true
+	batcher.paused = StopNetFlag
+	if cfg.MaxBackoffMs > 0 {
+		batcher.maxBackoff = time.Duration(cfg.MaxBackoffMs) * time.Millisecond
+	}
+	// 先关闭批量发送器发送剩余日志，再结束流
+	addSinkCloser(batcher)
+	addSinkCloser(s)
+
+	core := newRemoteCore(batcher, "", "")
+	core.useEncoding(encoding, serviceName, serviceID)
+	return core, nil
+}
+
+// send 在流上发送一批日志，返回时日志已写入流但不一定已被确认
+func (s *collectorShipper) send(ctx context.Context, batch []remoteRecord) error {
+	var payload []byte
+	for i := range batch {
+		var entry []byte
+		entry = appendPBFixed64(entry, 1, uint64(batch[i].Entry.Time.UnixNano()))
+		entry = appendPBString(entry, 2, batch[i].Entry.Level.String())
+		entry = appendPBBytes(entry, s.dataField, batch[i].Data)
+		payload = appendPBBytes(payload, 2, entry)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ensureStreamLocked(ctx); err != nil {
+		return err
+	}
+
+	// 等待服务端授予额度
+	stop := context.AfterFunc(ctx, s.cond.Broadcast)
+	defer stop()
+	for (s.credits <= 0 || len(s.unacked) >= s.maxInFlight) && s.stream != nil && s.stream.err == nil && ctx.Err() == nil {
+		s.cond.Wait()
+	}
+	if err := s.streamErrLocked(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("等待日志收集服务额度超时: %w", err)
+	}
+
+	b := collectorBatch{
+		seq:     s.nextSeq + 1,
+		payload: append(appendPBVarint(nil, 1, s.nextSeq+1), payload...),
+	}
+	if err := s.writeBatchLocked(b); err != nil {
+		return err
+	}
+	s.nextSeq = b.seq
+	s.credits--
+	s.unacked = append(s.unacked, b)
+	return nil
+}
+
+// ensureStreamLocked 流不可用时重新建立，调用方需持有 mu
+func (s *collectorShipper) ensureStreamLocked(ctx context.Context) error {
+	if s.stream != nil && s.stream.err == nil {
+		return nil
+	}
+	if s.stream != nil {
+		s.stream.cancel()
+		s.stream = nil
+	}
+	return s.openLocked(ctx)
+}
+
+// streamErrLocked 返回当前流的错误，流已断开时清理以便下次重连，调用方需持有 mu
+func (s *collectorShipper) streamErrLocked() error {
+	if s.stream == nil {
+		return errors.New("日志收集服务连接已关闭")
+	}
+	if err := s.stream.err; err != nil {
+		s.stream.cancel()
+		s.stream = nil
+		return err
+	}
+	return nil
+}
+
+// openLocked 建立新的 Ship 流，完成握手后重发未确认的批次，调用方需持有 mu
+func (s *collectorShipper) openLocked(ctx context.Context) error {
+	hello := appendPBString(append([]byte(nil), s.hello...), 3, s.resumeToken)
+	streamCtx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	stream := &collectorStream{body: pw, cancel: cancel}
+
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodPost, s.url, pr)
+	if err != nil {
+		cancel()
+		return err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	// 请求体在后台写入 hello，服务端可以在读取 hello 之后才返回响应头
+	go pw.Write(appendGRPCFrame(nil, appendPBBytes(nil, 1, hello)))
+	timer := time.AfterFunc(collectorDialTimeout, cancel)
+	stopDial := context.AfterFunc(ctx, cancel)
+	resp, err := s.httpClient.Do(req)
+	timer.Stop()
+	stopDial()
+	if err != nil {
+		cancel()
+		pw.CloseWithError(err)
+		return fmt.Errorf("连接日志收集服务失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		pw.CloseWithError(io.ErrClosedPipe)
+		return fmt.Errorf("连接日志收集服务失败: %s", resp.Status)
+	}
+	if status := resp.Header.Get("Grpc-Status"); status != "" && status != "0" {
+		resp.Body.Close()
+		cancel()
+		pw.CloseWithError(io.ErrClosedPipe)
+		return fmt.Errorf("连接日志收集服务失败: grpc-status=%s %s", status, resp.Header.Get("Grpc-Message"))
+	}
+
+	// 额度只在授予它的流上有效
+	s.stream = stream
+	s.credits = 0
+	go s.readLoop(stream, resp)
+
+	// 等待服务端回复续传位置
+	stop := context.AfterFunc(ctx, s.cond.Broadcast)
+	defer stop()
+	for !stream.ready && stream.err == nil && ctx.Err() == nil {
+		s.cond.Wait()
+	}
+	if err := s.streamErrLocked(); err != nil {
+		return err
+	}
+	if !stream.ready {
+		s.stream = nil
+		cancel()
+		return fmt.Errorf("等待日志收集服务握手超时: %w", ctx.Err())
+	}
+
+	// 服务端未确认的批次按原序号重发
+	for _, b := range s.unacked {
+		if err := s.writeBatchLocked(b); err != nil {
+			return err
+		}
+		s.credits--
+	}
+	return nil
+}
+
+// writeBatchLocked 在当前流上写入一个批次，调用方需持有 mu
+func (s *collectorShipper) writeBatchLocked(b collectorBatch) error {
+	frame := appendGRPCFrame(nil, appendPBBytes(nil, 2, b.payload))
+	if _, err := s.stream.body.Write(frame); err != nil {
+		s.stream.cancel()
+		s.stream = nil
+		return fmt.Errorf("发送日志到收集服务失败: %w", err)
+	}
+	return nil
+}
+
+// readLoop 读取服务端的确认消息，直到流结束
+func (s *collectorShipper) readLoop(stream *collectorStream, resp *http.Response) {
+	defer resp.Body.Close()
+	err := func() error {
+		header := make([]byte, 5)
+		for {
+			if _, err := io.ReadFull(resp.Body, header); err != nil {
+				return err
+			}
+			msg := make([]byte, binary.BigEndian.Uint32(header[1:]))
+			if _, err := io.ReadFull(resp.Body, msg); err != nil {
+				return err
+			}
+			if err := s.handleResponse(stream, msg); err != nil {
+				return err
+			}
+		}
+	}()
+	if errors.Is(err, io.EOF) {
+		// 正常结束时以 trailer 中的状态为准
+		err = errors.New("日志收集服务关闭了连接")
+		if status := resp.Trailer.Get("Grpc-Status"); status != "" && status != "0" {
+			err = fmt.Errorf("日志收集服务关闭了连接: grpc-status=%s %s", status, resp.Trailer.Get("Grpc-Message"))
+		}
+	}
+
+	s.mu.Lock()
+	stream.err = err
+	s.cond.Broadcast()
+	s.mu.Unlock()
+}
+
+// handleResponse 处理一条 ShipResponse
+func (s *collectorShipper) handleResponse(stream *collectorStream, msg []byte) error {
+	var ackSeq, credits uint64
+	var token string
+	hasToken := false
+	for len(msg) > 0 {
+		field, wireType, value, data, n := consumePBField(msg)
+		if n <= 0 {
+			return errors.New("日志收集服务响应格式错误")
+		}
+		msg = msg[n:]
+		switch {
+		case field == 1 && wireType == 0:
+			ackSeq = value
+		case field == 2 && wireType == 0:
+			credits = value
+		case field == 3 && wireType == 2:
+			token, hasToken = string(data), true
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stream.ready = true
+	if hasToken && token != "" {
+		s.resumeToken = token
+	}
+	s.credits += int(credits)
+	acked := 0
+	for acked < len(s.unacked) && s.unacked[acked].seq <= ackSeq {
+		acked++
+	}
+	if acked > 0 {
+		s.unacked = append(s.unacked[:0], s.unacked[acked:]...)
+	}
+	s.cond.Broadcast()
+	return nil
+}
+
+// Close 结束发送并等待服务端确认剩余批次，最多等待 collectorCloseTimeout
+// 已设置停止网络标志时直接断开，不再等待
+func (s *collectorShipper) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), collectorCloseTimeout)
+	defer cancel()
+	if !StopNetFlag() && len(s.unacked) > 0 {
+		// 流已断开时重连一次，让服务端确认或接收未确认的批次
+		s.ensureStreamLocked(ctx)
+	}
+	if s.stream == nil {
+		return nil
+	}
+	stream := s.stream
+	s.stream = nil
+	stream.body.Close()
+	if !StopNetFlag() {
+		stop := context.AfterFunc(ctx, s.cond.Broadcast)
+		defer stop()
+		for len(s.unacked) > 0 && stream.err == nil && ctx.Err() == nil {
+			s.cond.Wait()
+		}
+	}
+	stream.cancel()
+	if n := len(s.unacked); n > 0 {
+		return fmt.Errorf("日志收集服务还有 %d 个批次未确认", n)
+	}
+	return nil
+}
+
+// appendGRPCFrame 追加 gRPC 消息帧：1 字节压缩标志 + 4 字节长度 + 消息
+func appendGRPCFrame(buf, msg []byte) []byte {
+	buf = append(buf, 0)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(msg)))
+	return append(buf, msg...)
+}
+
+// consumePBField 解析一个 protobuf 字段，返回字段号、线格式、varint/fixed 值、
+// length-delimited 数据和消耗的字节数（格式错误时为 -1）
+func consumePBField(buf []byte) (field int, wireType byte, value uint64, data []byte, n int) {
+	tag, m := binary.Uvarint(buf)
+	if m <= 0 {
+		return 0, 0, 0, nil, -1
+	}
+	field, wireType, n = int(tag>>3), byte(tag&7), m
+	switch wireType {
+	case 0:
+		value, m = binary.Uvarint(buf[n:])
+		if m <= 0 {
+			return 0, 0, 0, nil, -1
+		}
+		n += m
+	case 1:
+		if len(buf) < n+8 {
+			return 0, 0, 0, nil, -1
+		}
+		value = binary.LittleEndian.Uint64(buf[n:])
+		n += 8
+	case 2:
+		l, m := binary.Uvarint(buf[n:])
+		if m <= 0 || uint64(len(buf)-n-m) < l {
+			return 0, 0, 0, nil, -1
+		}
+		n += m
+		data = buf[n : n+int(l)]
+		n += int(l)
+	case 5:
+		if len(buf) < n+4 {
+			return 0, 0, 0, nil, -1
+		}
+		value = uint64(binary.LittleEndian.Uint32(buf[n:]))
+		n += 4
+	default:
+		return 0, 0, 0, nil, -1
+	}
+	return field, wireType, value, data, n
+}
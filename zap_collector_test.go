@@ -0,0 +1,152 @@
+package mlog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// testCollector 测试用的日志收集服务
+// 第一条流在收到 dropAfter 个批次后只确认前面的批次并断开，用于验证续传
+type testCollector struct {
+	t         *testing.T
+	dropAfter int
+
+	mu      sync.Mutex
+	streams int
+	tokens  []string // 每条流 hello 中的续传令牌
+	seqs    []uint64 // 收到的批次序号（含重发）
+	acked   uint64
+	msgs    []string
+}
+
+func (c *testCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 || r.URL.Path != collectorShipPath || r.Header.Get("Content-Type") != "application/grpc" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	c.streams++
+	first := c.streams == 1
+	c.mu.Unlock()
+
+	w.Header().Set("Trailer", "Grpc-Status")
+	w.Header().Set("Content-Type", "application/grpc")
+	w.WriteHeader(http.StatusOK)
+	flusher := w.(http.Flusher)
+	flusher.Flush()
+	reply := func(ack uint64, credits uint64, token string) {
+		msg := appendPBVarint(appendPBVarint(nil, 1, ack), 2, credits)
+		if token != "" {
+			msg = appendPBString(msg, 3, token)
+		}
+		w.Write(appendGRPCFrame(nil, msg))
+		flusher.Flush()
+	}
+
+	received := 0
+	header := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(r.Body, header); err != nil {
+			break
+		}
+		msg := make([]byte, binary.BigEndian.Uint32(header[1:]))
+		if _, err := io.ReadFull(r.Body, msg); err != nil {
+			break
+		}
+		req := decodePB(c.t, msg)
+		if req[1] != nil {
+			hello := decodePB(c.t, req[1][0])
+			token := ""
+			if hello[3] != nil {
+				token = string(hello[3][0])
+			}
+			c.mu.Lock()
+			c.tokens = append(c.tokens, token)
+			ack := c.acked
+			c.mu.Unlock()
+			reply(ack, 2, "token-1")
+			continue
+		}
+
+		batch := decodePB(c.t, req[2][0])
+		seq := binary.LittleEndian.Uint64(batch[1][0])
+		c.mu.Lock()
+		c.seqs = append(c.seqs, seq)
+		if seq > c.acked {
+			for _, e := range batch[2] {
+				var entry map[string]any
+				json.Unmarshal(decodePB(c.t, e)[3][0], &entry)
+				c.msgs = append(c.msgs, entry["msg"].(string))
+			}
+		}
+		received++
+		if first && received == c.dropAfter {
+			// 不确认最后一个批次，直接断开
+			c.mu.Unlock()
+			return
+		}
+		c.acked = max(c.acked, seq)
+		c.mu.Unlock()
+		reply(seq, 1, "")
+	}
+	w.Header().Set("Grpc-Status", "0")
+}
+
+// TestCollectorShipResume 测试流量控制、断线续传和关闭时等待确认
+func TestCollectorShipResume(t *testing.T) {
+	collector := &testCollector{t: t, dropAfter: 2}
+	server := httptest.NewUnstartedServer(collector)
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+
+	core, err := newCollectorCore(CollectorConfig{
+		Endpoint:          server.URL,
+		RemoteBatchConfig: RemoteBatchConfig{BatchSize: 1, RetryBackoffMs: 10},
+	}, "game", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	log := zap.New(core)
+	for _, msg := range []string{"a", "b", "c"} {
+		log.Info(msg)
+	}
+	core.batcher.Close()
+	var shipper *collectorShipper
+	coreMutex.Lock()
+	for _, c := range sinkClosers {
+		if s, ok := c.(*collectorShipper); ok {
+			shipper = s
+		}
+	}
+	coreMutex.Unlock()
+	if err := shipper.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	if collector.streams != 2 || len(collector.tokens) != 2 || collector.tokens[0] != "" || collector.tokens[1] != "token-1" {
+		t.Fatalf("续传令牌错误: streams=%d tokens=%v", collector.streams, collector.tokens)
+	}
+	// 第 2 个批次未被确认，重连后按原序号重发
+	if len(collector.seqs) != 4 || collector.seqs[1] != 2 || collector.seqs[2] != 2 || collector.seqs[3] != 3 {
+		t.Fatalf("批次序号错误: %v", collector.seqs)
+	}
+	if collector.acked != 3 {
+		t.Fatalf("确认序号错误: %d", collector.acked)
+	}
+	if len(collector.msgs) != 4 || collector.msgs[0] != "a" || collector.msgs[3] != "c" {
+		t.Fatalf("日志内容错误: %v", collector.msgs)
+	}
+}
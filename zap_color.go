@@ -0,0 +1,144 @@
+package mlog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 控制台颜色模式
+const (
+	ConsoleColorAuto   = "auto"   // 默认：终端支持时保留颜色，Windows 旧控制台无法开启 ANSI 支持时去掉颜色
+	ConsoleColorAlways = "always" // 始终输出颜色
+	ConsoleColorNever  = "never"  // 控制台输出始终去掉颜色
+)
+
+// colorNames 可以在 LevelColors 中使用的颜色名及其 ANSI 颜色代码
+var colorNames = map[string]string{
+	"black": "30", "red": "31", "green": "32", "yellow": "33", "blue": "34", "magenta": "35", "cyan": "36", "white": "37",
+	"bright-black": "90", "bright-red": "91", "bright-green": "92", "bright-yellow": "93",
+	"bright-blue": "94", "bright-magenta": "95", "bright-cyan": "96", "bright-white": "97",
+}
+
+// sgrPattern 直接填写的 ANSI SGR 参数，如 1;31（红色加粗）
+var sgrPattern = regexp.MustCompile(`^\d{1,3}(;\d{1,3})*$`)
+
+// parseLevelColors 解析 LevelColors，值可以是颜色名或 SGR 参数，无效的条目跳过并返回错误
+func parseLevelColors(colors map[string]string) (map[zapcore.Level]string, error) {
+	if len(colors) == 0 {
+		return nil, nil
+	}
+	var (
+		parsed  = make(map[zapcore.Level]string, len(colors))
+		invalid []string
+	)
+	for name, color := range colors {
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(name)); err != nil {
+			invalid = append(invalid, name)
+			continue
+		}
+		code, ok := colorNames[strings.ToLower(color)]
+		if !ok {
+			if !sgrPattern.MatchString(color) {
+				invalid = append(invalid, name+": "+color)
+				continue
+			}
+			code = color
+		}
+		parsed[level] = code
+	}
+	if len(invalid) > 0 {
+		return parsed, fmt.Errorf("无效的级别颜色 %v", invalid)
+	}
+	return parsed, nil
+}
+
+// defaultLevelColors zap 默认的级别颜色，LevelNames 改写了级别名而没有配置颜色时使用
+var defaultLevelColors = map[zapcore.Level]string{
+	zapcore.DebugLevel:  "35",
+	zapcore.InfoLevel:   "34",
+	zapcore.WarnLevel:   "33",
+	zapcore.ErrorLevel:  "31",
+	zapcore.DPanicLevel: "31",
+	zapcore.PanicLevel:  "31",
+	zapcore.FatalLevel:  "31",
+}
+
+// colorLevelEncoder 按 LevelColors 着色、按 names 显示的级别编码器，未配置颜色的级别使用 zap 的默认颜色
+func (c *ZapConfig) colorLevelEncoder(capital bool, names map[zapcore.Level]string) zapcore.LevelEncoder {
+	colors, _ := parseLevelColors(c.LevelColors)
+	fallback := zapcore.LowercaseColorLevelEncoder
+	if capital {
+		fallback = zapcore.CapitalColorLevelEncoder
+	}
+	if len(colors) == 0 && len(names) == 0 {
+		return fallback
+	}
+	colored := make(map[zapcore.Level]string, len(defaultLevelColors))
+	for level, defaultCode := range defaultLevelColors {
+		code, hasColor := colors[level]
+		text, hasName := names[level]
+		if !hasColor && !hasName {
+			continue
+		}
+		if !hasColor {
+			code = defaultCode
+		}
+		if !hasName {
+			text = level.String()
+			if capital {
+				text = level.CapitalString()
+			}
+		}
+		colored[level] = "\x1b[" + code + "m" + text + "\x1b[0m"
+	}
+	return func(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+		if s, ok := colored[l]; ok {
+			enc.AppendString(s)
+			return
+		}
+		fallback(l, enc)
+	}
+}
+
+// configureConsoleColors 按 ConsoleColor 决定控制台输出是否去掉颜色
+func configureConsoleColors(mode string) error {
+	switch mode {
+	case ConsoleColorAlways:
+		enableVirtualTerminal(os.Stdout)
+		consoleSink.stripColors.Store(false)
+	case ConsoleColorNever:
+		consoleSink.stripColors.Store(true)
+	default:
+		consoleSink.stripColors.Store(!enableVirtualTerminal(os.Stdout))
+		if mode != "" && mode != ConsoleColorAuto {
+			return fmt.Errorf("不支持的控制台颜色模式 %s", mode)
+		}
+	}
+	return nil
+}
+
+// stripANSI 去掉 ANSI 控制序列（ESC [ 参数 结束符），没有控制序列时返回原切片
+func stripANSI(p []byte) []byte {
+	if bytes.IndexByte(p, 0x1b) < 0 {
+		return p
+	}
+	out := make([]byte, 0, len(p))
+	for i := 0; i < len(p); i++ {
+		if p[i] != 0x1b || i+1 >= len(p) || p[i+1] != '[' {
+			out = append(out, p[i])
+			continue
+		}
+		j := i + 2
+		for j < len(p) && (p[j] < 0x40 || p[j] > 0x7e) {
+			j++
+		}
+		i = j
+	}
+	return out
+}
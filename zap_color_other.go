@@ -0,0 +1,10 @@
+//go:build !windows
+
+package mlog
+
+import "os"
+
+// enableVirtualTerminal 非 Windows 平台的终端都支持 ANSI 控制序列
+func enableVirtualTerminal(f *os.File) bool {
+	return true
+}
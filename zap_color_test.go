@@ -0,0 +1,70 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestLevelColors 测试按级别自定义颜色，无效的颜色跳过，未配置的级别使用默认颜色
+func TestLevelColors(t *testing.T) {
+	colors, err := parseLevelColors(map[string]string{"info": "Green", "error": "1;31", "warn": "purple", "trace": "red"})
+	if err == nil || len(colors) != 2 || colors[zapcore.InfoLevel] != "32" || colors[zapcore.ErrorLevel] != "1;31" {
+		t.Fatalf("解析结果错误: %v %v", colors, err)
+	}
+
+	oldConfig := zapConfig
+	defer func() { zapConfig = oldConfig }()
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "console", SingleFile: true,
+		EncodeLevel: "CapitalColorLevelEncoder", LevelColors: map[string]string{"info": "green", "error": "1;31"}}
+	InitialZap("gate", 2, "info", &config)
+	Info("玩家登录")
+	Warn("背包已满")
+	Error("存档失败")
+	Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "2", "gate", "all.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"\x1b[32mINFO\x1b[0m", "\x1b[33mWARN\x1b[0m", "\x1b[1;31mERROR\x1b[0m"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("缺少 %q: %q", want, data)
+		}
+	}
+}
+
+// TestConsoleColorStrip 测试 never 模式下控制台输出去掉 ANSI 控制序列
+func TestConsoleColorStrip(t *testing.T) {
+	if got := string(stripANSI([]byte("\x1b[1;31mERROR\x1b[0m\t存档失败"))); got != "ERROR\t存档失败" {
+		t.Fatalf("去掉颜色结果错误: %q", got)
+	}
+	defer configureConsoleColors(ConsoleColorAuto)
+	if err := configureConsoleColors("rainbow"); err == nil {
+		t.Fatal("不支持的模式应返回错误")
+	}
+	if err := configureConsoleColors(ConsoleColorNever); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "console.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	sink := newConsoleWriteSyncer(file)
+	sink.stripColors.Store(consoleSink.stripColors.Load())
+	line := "\x1b[34mINFO\x1b[0m\t玩家登录\n"
+	if n, err := sink.Write([]byte(line)); err != nil || n != len(line) {
+		t.Fatalf("写入结果错误: %d %v", n, err)
+	}
+	data, _ := os.ReadFile(file.Name())
+	if string(data) != "INFO\t玩家登录\n" {
+		t.Fatalf("控制台输出应去掉颜色: %q", data)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package mlog
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// enableVirtualTerminalProcessing 控制台模式中开启 ANSI 控制序列解析的标志
+const enableVirtualTerminalProcessing = 0x0004
+
+var (
+	kernel32           = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleMode = kernel32.NewProc("GetConsoleMode")
+	procSetConsoleMode = kernel32.NewProc("SetConsoleMode")
+)
+
+// enableVirtualTerminal 为控制台开启 ANSI 控制序列支持（Windows 10 1511 及以上），
+// 旧版本控制台或输出被重定向到文件、管道时返回 false
+func enableVirtualTerminal(f *os.File) bool {
+	var mode uint32
+	if r, _, _ := procGetConsoleMode.Call(f.Fd(), uintptr(unsafe.Pointer(&mode))); r == 0 {
+		return false
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true
+	}
+	r, _, _ := procSetConsoleMode.Call(f.Fd(), uintptr(mode|enableVirtualTerminalProcessing))
+	return r != 0
+}
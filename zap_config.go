@@ -4,7 +4,6 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"go.uber.org/zap/zapcore"
 )
@@ -12,23 +11,186 @@ import (
 type ZapConfig struct {
 	Level         string `mapstructure:"level" json:"level" yaml:"level"`                            // 级别
 	Prefix        string `mapstructure:"prefix" json:"prefix" yaml:"prefix"`                         // 日志前缀
-	Format        string `mapstructure:"format" json:"format" yaml:"format"`                         // 输出
+	Format        string `mapstructure:"format" json:"format" yaml:"format"`                         // 输出格式：console、json、json-pretty（文件为 JSON，控制台美化输出）、ecs（Elastic Common Schema）、gcp（Cloud Logging 结构化日志）或 RegisterEncoderFormat 注册的格式
 	Director      string `mapstructure:"director" json:"director"  yaml:"director"`                  // 日志文件夹
 	EncodeLevel   string `mapstructure:"encode-level" json:"encode-level" yaml:"encode-level"`       // 编码级
 	StacktraceKey string `mapstructure:"stacktrace-key" json:"stacktrace-key" yaml:"stacktrace-key"` // 栈名
 	ShowLine      bool   `mapstructure:"show-line" json:"show-line" yaml:"show-line"`                // 显示行
 	LogInConsole  bool   `mapstructure:"log-in-console" json:"log-in-console" yaml:"log-in-console"` // 输出控制台
 	RetentionDay  int    `mapstructure:"retention-day" json:"retention-day" yaml:"retention-day"`    // 日志保留天数
+	Development   bool   `mapstructure:"development" json:"development" yaml:"development"`          // 开发模式（DPanic 级别日志记录后 panic）
+	// json、json-pretty 格式中的字段按键名排序（含 With 添加的字段和嵌套对象的键），时间、级别、调用位置和消息仍在最前，
+	// 同样内容的日志输出完全相同，便于审计渠道比对和快照测试；排序需要重新解析每行 JSON，有额外开销
+	SortKeys bool `mapstructure:"sort-keys" json:"sort-keys" yaml:"sort-keys"`
+	// 以 CSV 格式写入的子目录，键为 directory（或 business、folder）字段指定的子目录名，值为列名，如 {audit: [time, player_id, action, amount]}
+	// 列名 time、level、logger、caller、message、stacktrace 取日志本身的属性，其他列按字段键名取值；新文件的第一行为列名，可直接用表格软件打开
+	// 只在按级别分文件模式下生效（单文件模式不拆分子目录）
+	CSVChannels map[string][]string `mapstructure:"csv-channels" json:"csv-channels" yaml:"csv-channels"`
+	// console 格式的行布局模板，如 {time} |{level}| {caller} {msg} {fields}，迁移到 mlog 后原有的 grep/awk 脚本可以继续使用
+	// 占位符：{time}、{level}、{logger}、{caller}、{msg}、{fields}（JSON 对象）、{stacktrace}，{{ 和 }} 输出为花括号；为空时使用 zap 的 console 布局
+	ConsoleTemplate string `mapstructure:"console-template" json:"console-template" yaml:"console-template"`
+	// 时间格式：Go 时间布局（默认 2006-01-02 15:04:05.000）、rfc3339、rfc3339nano、epoch（秒）、epoch-millis 或 epoch-nanos，
+	// epoch 系列输出为数字，不加 Prefix；ecs、gcp 格式使用各自规定的时间格式
+	TimeFormat string `mapstructure:"time-format" json:"time-format" yaml:"time-format"`
+	// 时区：IANA 时区名（如 UTC、Asia/Shanghai），为空时使用本地时区
+	TimeZone string `mapstructure:"time-zone" json:"time-zone" yaml:"time-zone"`
+	// 单行模式：console 和自定义格式中一条日志内部的换行转义为 \n；AssertString、GrpcAssert 的堆栈作为单独的字段输出，不再拼接在消息中
+	EscapeNewlines bool `mapstructure:"escape-newlines" json:"escape-newlines" yaml:"escape-newlines"`
+	// console 和自定义格式中耗时字段输出为 12.3ms、Bytes 字段输出为 4.2MiB；JSON 格式不受影响，始终输出数值
+	HumanizeUnits bool `mapstructure:"humanize-units" json:"humanize-units" yaml:"humanize-units"`
+	// 自动附加调用栈的最低级别（如 error），为空时只有 zap.Stack 等显式请求的日志带调用栈
+	// 调用栈从业务代码的调用位置开始，开启 UseRelativePath 时与 AssertString 一样转换为相对路径，写入 StacktraceKey（未配置时为 stacktrace）
+	StacktraceLevel string `mapstructure:"stacktrace-level" json:"stacktrace-level" yaml:"stacktrace-level"`
+	StackDepth      int    `mapstructure:"stack-depth" json:"stack-depth" yaml:"stack-depth"` // 自动附加的调用栈最多保留的帧数（默认 32）
+	StackSkip       int    `mapstructure:"stack-skip" json:"stack-skip" yaml:"stack-skip"`    // 跳过 mlog 和 zap 内部的帧之后再跳过的帧数，用于隐藏业务自己封装的日志函数
+	// 控制台颜色：auto（默认，Windows 上尝试开启 ANSI 支持，旧控制台或输出被重定向时去掉颜色）、always 或 never
+	ConsoleColor string `mapstructure:"console-color" json:"console-color" yaml:"console-color"`
+	// 带颜色的级别编码器中各级别的颜色，键为级别名，值为颜色名（red、bright-blue 等）或 ANSI SGR 参数（如 1;31），未配置的级别使用默认颜色
+	LevelColors map[string]string `mapstructure:"level-colors" json:"level-colors" yaml:"level-colors"`
+	// 级别的显示名，键为级别名，值为输出的文本（如 warn: WARNING 或 ChineseLevelNames 中的中文名），未配置的级别按 EncodeLevel 输出
+	// ecs、gcp 格式和远端输出使用各自规定的级别名，不受影响
+	LevelNames map[string]string `mapstructure:"level-names" json:"level-names" yaml:"level-names"`
+	// 输出模式：file（默认，写入日志目录）或 stdout（容器模式，不创建目录和文件，每条日志一行 JSON 输出到标准输出）
+	OutputMode string `mapstructure:"output-mode" json:"output-mode" yaml:"output-mode"`
 	// 日志分割配置
 	MaxSize        int  `mapstructure:"max-size" json:"max-size" yaml:"max-size"`                      // 日志文件最大大小（MB）
 	MaxBackups     int  `mapstructure:"max-backups" json:"max-backups" yaml:"max-backups"`             // 日志文件数量
 	EnableSplit    bool `mapstructure:"enable-split" json:"enable-split" yaml:"enable-split"`          // 启用日志分片
 	EnableCompress bool `mapstructure:"enable-compress" json:"enable-compress" yaml:"enable-compress"` // 启用日志压缩
+	// 轮转文件的压缩算法：gzip（默认）或 zstd（需先调用 SetZstdEncoder，压缩速度快 3~5 倍），EnableCompress 为 true 时生效
+	CompressAlgo string `mapstructure:"compress-algo" json:"compress-algo" yaml:"compress-algo"`
+	// 整个日志目录（含所有服务和子目录）的总大小上限（MB），超过时从最早的轮转文件开始删除，0 表示不限制
+	// 启用前可调用 PreviewCleanup 预览按 RetentionDay、MaxBackups 和该上限将要删除的文件
+	MaxTotalSizeMB int `mapstructure:"max-total-size-mb" json:"max-total-size-mb" yaml:"max-total-size-mb"`
+	// 启动时和之后每小时清理日志目录：删除修改时间早于 RetentionDay 的日志文件（正在写入的除外）和长时间未使用的空目录
+	CleanStaleDirs bool `mapstructure:"clean-stale-dirs" json:"clean-stale-dirs" yaml:"clean-stale-dirs"`
+	// 多个进程共用日志目录时的写入模式：空（默认，单进程）、pid（文件名带进程 ID）或 flock（追加写入同一文件，用文件锁协调轮转）
+	MultiProcess string `mapstructure:"multi-process" json:"multi-process" yaml:"multi-process"`
+	// 日志文件写缓冲大小（KB），大于 0 时日志先写入内存缓冲，写满或每隔 FlushIntervalMs 写入文件，大幅减少高频日志的系统调用次数
+	// 进程异常退出时可能丢失缓冲中的日志，调用 Flush、Close 以及记录 Panic、Fatal 日志时会立即写出
+	BufferSizeKB    int `mapstructure:"buffer-size-kb" json:"buffer-size-kb" yaml:"buffer-size-kb"`
+	FlushIntervalMs int `mapstructure:"flush-interval-ms" json:"flush-interval-ms" yaml:"flush-interval-ms"` // 写缓冲定时写入文件的间隔（毫秒，默认 1000）
+	// 每次打开新的日志文件（启动、轮转、日期变化、重新打开）时先写入一行文件头，记录版本、GitCommit、BuildTime、服务、进程和主机
+	FileHeader bool `mapstructure:"file-header" json:"file-header" yaml:"file-header"`
+	// 日志文件轮转（或文件名模板的日期变化）后计算旧文件的 SHA-256，追加到同一目录的 manifest.jsonl，用于审计时发现篡改或截断
+	IntegrityManifest bool `mapstructure:"integrity-manifest" json:"integrity-manifest" yaml:"integrity-manifest"`
+	// 日志文件名模板，如 {service}-{level}-{date:2006-01-02}.log，单文件和按级别分文件模式都生效，为空时使用 级别.log 或 SingleFileName
+	// 支持 {service}、{id}、{level}（单文件模式为 all）、{date} 或 {date:<Go 时间格式>}；带日期时跨日自动切换到新文件
+	FilePattern string `mapstructure:"file-pattern" json:"file-pattern" yaml:"file-pattern"`
+	// 在日志目录中维护指向当前日志文件的符号链接：单文件模式为 current.log，按级别分文件为 <级别>-current.log
+	CurrentSymlink bool `mapstructure:"current-symlink" json:"current-symlink" yaml:"current-symlink"`
+	// 按时间轮转的间隔，如 1h（整点）或 24h（每天零点），需能整除 24h；与 MaxSize 同时生效，为空时只按大小轮转
+	RotateInterval string `mapstructure:"rotate-interval" json:"rotate-interval" yaml:"rotate-interval"`
 
 	// 异步日志配置
-	EnableAsync     bool `mapstructure:"enable-async" json:"enable-async" yaml:"enable-async"`                   // 启用异步日志
-	AsyncBufferSize int  `mapstructure:"async-buffer-size" json:"async-buffer-size" yaml:"async-buffer-size"`    // 异步日志缓冲区大小
-	AsyncDropOnFull bool `mapstructure:"async-drop-on-full" json:"async-drop-on-full" yaml:"async-drop-on-full"` // 缓冲区满时是否丢弃日志
+	EnableAsync          bool `mapstructure:"enable-async" json:"enable-async" yaml:"enable-async"`                                  // 启用异步日志
+	AsyncBufferSize      int  `mapstructure:"async-buffer-size" json:"async-buffer-size" yaml:"async-buffer-size"`                   // 异步日志缓冲区大小
+	AsyncDropOnFull      bool `mapstructure:"async-drop-on-full" json:"async-drop-on-full" yaml:"async-drop-on-full"`                // 缓冲区满时是否丢弃日志
+	AsyncFlushOnError    bool `mapstructure:"async-flush-on-error" json:"async-flush-on-error" yaml:"async-flush-on-error"`          // Error 及以上级别日志等待之前的日志全部落盘
+	AsyncSyncFallbackMs  int  `mapstructure:"async-sync-fallback-ms" json:"async-sync-fallback-ms" yaml:"async-sync-fallback-ms"`    // 队列持续 95% 以上满超过该毫秒数后临时改为同步写入（0 表示不启用）
+	AsyncFlushIntervalMs int  `mapstructure:"async-flush-interval-ms" json:"async-flush-interval-ms" yaml:"async-flush-interval-ms"` // 异步日志定时刷新间隔（0 使用默认 1000ms，负数不定时刷新）
+	AsyncSnapshotFields  bool `mapstructure:"async-snapshot-fields" json:"async-snapshot-fields" yaml:"async-snapshot-fields"`       // 入队时对 zap.Any 等引用类型字段做快照
+	// 按级别独立配置的异步队列（键为级别名，如 debug、error），未配置的级别共享默认队列
+	AsyncLevelQueues map[string]AsyncQueueConfig `mapstructure:"async-level-queues" json:"async-level-queues" yaml:"async-level-queues"`
+	// 队列过载时自动对 Debug/Info 日志进行采样
+	AsyncAdaptiveSampling AdaptiveSamplingConfig `mapstructure:"async-adaptive-sampling" json:"async-adaptive-sampling" yaml:"async-adaptive-sampling"`
+
+	// 反作弊证据包配置
+	EvidenceBufferSize int    `mapstructure:"evidence-buffer-size" json:"evidence-buffer-size" yaml:"evidence-buffer-size"` // 最近日志缓冲区条数（0 表示不启用）
+	EvidencePlayerKey  string `mapstructure:"evidence-player-key" json:"evidence-player-key" yaml:"evidence-player-key"`    // 关联玩家的字段名（默认 player_id）
+
+	CallSiteStats bool `mapstructure:"call-site-stats" json:"call-site-stats" yaml:"call-site-stats"` // 统计每个调用位置的日志条数和字节数（TopCallSites）
+
+	// syslog 输出配置
+	Syslog SyslogConfig `mapstructure:"syslog" json:"syslog" yaml:"syslog"`
+
+	// Kafka 输出配置
+	Kafka KafkaConfig `mapstructure:"kafka" json:"kafka" yaml:"kafka"`
+
+	// Pulsar 输出配置
+	Pulsar PulsarConfig `mapstructure:"pulsar" json:"pulsar" yaml:"pulsar"`
+
+	// Elasticsearch 输出配置
+	Elasticsearch ElasticsearchConfig `mapstructure:"elasticsearch" json:"elasticsearch" yaml:"elasticsearch"`
+
+	// Graylog GELF 输出配置
+	GELF GELFConfig `mapstructure:"gelf" json:"gelf" yaml:"gelf"`
+
+	// OpenTelemetry OTLP 日志导出配置
+	OTLP OTLPConfig `mapstructure:"otlp" json:"otlp" yaml:"otlp"`
+
+	// Google Cloud Logging 输出配置
+	GCPLogging GCPLoggingConfig `mapstructure:"gcp-logging" json:"gcp-logging" yaml:"gcp-logging"`
+
+	// Azure Monitor（Log Analytics）输出配置
+	AzureMonitor AzureMonitorConfig `mapstructure:"azure-monitor" json:"azure-monitor" yaml:"azure-monitor"`
+
+	// 通用 TCP/UDP 网络输出配置
+	NetSink NetSinkConfig `mapstructure:"net-sink" json:"net-sink" yaml:"net-sink"`
+
+	// 通用 HTTP 批量输出配置
+	HTTPSink HTTPSinkConfig `mapstructure:"http-sink" json:"http-sink" yaml:"http-sink"`
+
+	// Unix 域套接字输出配置
+	UnixSocket UnixSocketConfig `mapstructure:"unix-socket" json:"unix-socket" yaml:"unix-socket"`
+
+	// ClickHouse 输出配置
+	ClickHouse ClickHouseConfig `mapstructure:"clickhouse" json:"clickhouse" yaml:"clickhouse"`
+
+	// 本地 SQLite 输出配置
+	SQLite SQLiteConfig `mapstructure:"sqlite" json:"sqlite" yaml:"sqlite"`
+
+	// NATS 输出配置
+	NATS NATSConfig `mapstructure:"nats" json:"nats" yaml:"nats"`
+
+	// MQTT 输出配置
+	MQTT MQTTConfig `mapstructure:"mqtt" json:"mqtt" yaml:"mqtt"`
+
+	// Redis Stream 输出配置
+	RedisStream RedisStreamConfig `mapstructure:"redis-stream" json:"redis-stream" yaml:"redis-stream"`
+
+	// 中心日志收集服务（gRPC 双向流）配置
+	Collector CollectorConfig `mapstructure:"collector" json:"collector" yaml:"collector"`
+
+	// 轮转日志文件归档到对象存储的配置
+	Archive ArchiveConfig `mapstructure:"archive" json:"archive" yaml:"archive"`
+
+	// 轮转日志文件的静态加密配置
+	Encryption EncryptionConfig `mapstructure:"encryption" json:"encryption" yaml:"encryption"`
+
+	// Critical/Disaster 告警 Webhook 配置
+	AlertWebhook AlertWebhookConfig `mapstructure:"alert-webhook" json:"alert-webhook" yaml:"alert-webhook"`
+
+	// Disaster 邮件摘要配置
+	EmailDigest EmailDigestConfig `mapstructure:"email-digest" json:"email-digest" yaml:"email-digest"`
+
+	// 按级别路由输出，未配置时所有级别写文件、按 LogInConsole 输出控制台并写入所有已启用的附加输出
+	Routes []LevelRouteConfig `mapstructure:"routes" json:"routes" yaml:"routes"`
+
+	// 通过 RegisterSink 注册的可插拔输出列表
+	Sinks []SinkConfig `mapstructure:"sinks" json:"sinks" yaml:"sinks"`
+
+	// 按级别的 Core 采样配置（键为级别名，如 debug、info），未配置的级别不采样
+	// 采样、去重和异步队列丢弃的条数以 sampled_dropped 字段附加到之后写出的相同级别和消息的日志上
+	Sampling map[string]SamplingConfig `mapstructure:"sampling" json:"sampling" yaml:"sampling"`
+
+	// 重复日志去重配置
+	EnableDedup   bool `mapstructure:"enable-dedup" json:"enable-dedup" yaml:"enable-dedup"`          // 合并窗口内级别和消息都相同的日志，汇总日志带 repeat_count 字段
+	DedupWindowMs int  `mapstructure:"dedup-window-ms" json:"dedup-window-ms" yaml:"dedup-window-ms"` // 去重窗口（毫秒，默认 1000）
+
+	// 敏感字段键名（不区分大小写，如 password、token、card_no），匹配的字段值以及消息中 key=value 形式的片段在写入任何输出前替换为 ***
+	RedactKeys []string `mapstructure:"redact-keys" json:"redact-keys" yaml:"redact-keys"`
+	// 正则脱敏规则，按顺序应用于消息和字符串字段的值，如隐藏聊天、支付日志中的手机号、邮箱和身份证号
+	MaskRules []MaskRule `mapstructure:"mask-rules" json:"mask-rules" yaml:"mask-rules"`
+	// 消息的最大字节数，超过时截断并加上 …(truncated, N bytes) 后缀，0 表示不限制
+	MaxMessageBytes int `mapstructure:"max-message-bytes" json:"max-message-bytes" yaml:"max-message-bytes"`
+	// 单个字段值的最大字节数（zap.Any 等反射值按 JSON 编码后计算），超过时截断为带后缀的字符串，0 表示不限制
+	MaxFieldBytes int `mapstructure:"max-field-bytes" json:"max-field-bytes" yaml:"max-field-bytes"`
+	// 添加到每条日志上的元数据字段：hostname、pid、service、service_id、version、git_commit，为空时不添加
+	// 汇总上百个分服的日志后不依赖文件路径也能区分来源
+	GlobalFields []string `mapstructure:"global-fields" json:"global-fields" yaml:"global-fields"`
+	// 为每条日志添加单调递增的 seq 字段，用于发现丢失的日志和对时间戳相同的条目排序；用户自己的 seq 字段改名为 user_seq
+	SeqField bool `mapstructure:"seq-field" json:"seq-field" yaml:"seq-field"`
 
 	// 路径显示配置
 	UseRelativePath bool   `mapstructure:"use-relative-path" json:"use-relative-path" yaml:"use-relative-path"` // 使用相对路径显示（默认false 使用绝对路径）
@@ -37,6 +199,32 @@ type ZapConfig struct {
 	// 单文件日志配置
 	SingleFile     bool   `mapstructure:"single-file" json:"single-file" yaml:"single-file"`                // 是否将所有级别的日志写入到同一个文件（默认false 按级别分文件）
 	SingleFileName string `mapstructure:"single-file-name" json:"single-file-name" yaml:"single-file-name"` // 单文件模式下的日志文件名（默认为 "all.log"）
+	// 单文件模式下 Error 及以上级别的日志额外写入 error.log（配置了 FilePattern 时按模板生成，{level} 为 error）
+	AlwaysSeparateErrors bool `mapstructure:"always-separate-errors" json:"always-separate-errors" yaml:"always-separate-errors"`
+
+	// ServeAdmin 管理接口的访问令牌，请求需带 Authorization: Bearer <令牌>，为空时不能启动管理接口
+	AdminToken string `mapstructure:"admin-token" json:"admin-token" yaml:"admin-token"`
+}
+
+// AsyncQueueConfig 单个级别的异步队列配置
+type AsyncQueueConfig struct {
+	BufferSize int  `mapstructure:"buffer-size" json:"buffer-size" yaml:"buffer-size"`    // 缓冲区大小（<=0 时使用 AsyncBufferSize）
+	DropOnFull bool `mapstructure:"drop-on-full" json:"drop-on-full" yaml:"drop-on-full"` // 缓冲区满时是否丢弃日志
+}
+
+// AdaptiveSamplingConfig 异步队列过载时的自适应采样配置
+type AdaptiveSamplingConfig struct {
+	Enable        bool    `mapstructure:"enable" json:"enable" yaml:"enable"`                         // 启用自适应采样
+	HighWatermark float64 `mapstructure:"high-watermark" json:"high-watermark" yaml:"high-watermark"` // 队列使用率达到该比例时开始采样（默认 0.8）
+	LowWatermark  float64 `mapstructure:"low-watermark" json:"low-watermark" yaml:"low-watermark"`    // 队列使用率回落到该比例时恢复全量日志（默认为 HighWatermark 的一半）
+	Rate          int     `mapstructure:"rate" json:"rate" yaml:"rate"`                               // 采样期间每 N 条 Debug/Info 日志保留 1 条（默认 10）
+}
+
+// SamplingConfig 单个级别的采样配置
+// 每秒内相同消息的前 Initial 条全部保留，之后每 Thereafter 条保留 1 条
+type SamplingConfig struct {
+	Initial    int `mapstructure:"initial" json:"initial" yaml:"initial"`          // 每秒全部保留的条数
+	Thereafter int `mapstructure:"thereafter" json:"thereafter" yaml:"thereafter"` // 超出后每 N 条保留 1 条（0 表示全部丢弃）
 }
 
 // Levels
@@ -51,41 +239,89 @@ func (c *ZapConfig) Levels() []zapcore.Level {
 }
 
 func (c *ZapConfig) Encoder() zapcore.Encoder {
+	switch c.Format {
+	case FormatECS:
+		return newECSEncoder(c)
+	case FormatGCP:
+		return newGCPEncoder(c)
+	}
+	stacktraceKey := c.StacktraceKey
+	if stacktraceKey == "" && c.StacktraceLevel != "" {
+		stacktraceKey = "stacktrace"
+	}
 	config := zapcore.EncoderConfig{
-		TimeKey:       "time",
-		NameKey:       "name",
-		LevelKey:      "level",
-		CallerKey:     "caller",
-		MessageKey:    "message",
-		StacktraceKey: c.StacktraceKey,
-		LineEnding:    zapcore.DefaultLineEnding,
-		EncodeTime: func(t time.Time, encoder zapcore.PrimitiveArrayEncoder) {
-			encoder.AppendString(c.Prefix + t.Format("2006-01-02 15:04:05.000"))
-		},
+		TimeKey:        "time",
+		NameKey:        "name",
+		LevelKey:       "level",
+		CallerKey:      "caller",
+		MessageKey:     "message",
+		StacktraceKey:  stacktraceKey,
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeTime:     c.timeEncoder(),
 		EncodeLevel:    c.LevelEncoder(),
 		EncodeCaller:   c.CallerEncoder(),
 		EncodeDuration: zapcore.SecondsDurationEncoder,
 	}
-	if c.Format == "json" {
+	if c.Format == FormatJSON || c.Format == FormatJSONPretty {
+		if c.SortKeys {
+			return newSortedKeysEncoder(zapcore.NewJSONEncoder(config), config)
+		}
 		return zapcore.NewJSONEncoder(config)
 	}
-	return zapcore.NewConsoleEncoder(config)
+	if c.HumanizeUnits {
+		config.EncodeDuration = zapcore.StringDurationEncoder
+	}
+	var encoder zapcore.Encoder
+	if factory := getEncoderFactory(c.Format); factory != nil {
+		encoder = factory(config)
+	} else if c.ConsoleTemplate != "" {
+		// 模板无效时 initZap 已输出错误，这里回退到 zap 的 console 格式
+		if encoder, _ = newTemplateEncoder(config, c.ConsoleTemplate); encoder == nil {
+			encoder = zapcore.NewConsoleEncoder(config)
+		}
+	} else {
+		encoder = zapcore.NewConsoleEncoder(config)
+	}
+	if c.EscapeNewlines {
+		encoder = newEscapeNewlinesEncoder(encoder, config.LineEnding)
+	}
+	if c.HumanizeUnits {
+		// 位于最外层，With 添加的 Bytes 字段才能识别出编码器
+		encoder = &humanizeEncoder{Encoder: encoder}
+	}
+	return encoder
+
+}
 
+// structuredFormat 是否为按采集平台字段名输出的 JSON 格式（ecs、gcp），这些格式的服务信息由编码器输出
+func (c *ZapConfig) structuredFormat() bool {
+	return c.Format == FormatECS || c.Format == FormatGCP
+}
+
+// addEncoderService 在 ecs、gcp 格式的编码器上添加服务名和服务 ID，其他格式的服务信息体现在日志目录中，不做处理
+func addEncoderService(enc zapcore.Encoder, serviceName string, serviceID uint64) {
+	switch e := enc.(type) {
+	case *ecsEncoder:
+		e.addService(serviceName, serviceID)
+	case *gcpEncoder:
+		e.addService(serviceName, serviceID)
+	}
 }
 
 // LevelEncoder 根据 EncodeLevel 返回 zapcore.LevelEncoder
 func (c *ZapConfig) LevelEncoder() zapcore.LevelEncoder {
+	names, _ := parseLevelNames(c.LevelNames)
 	switch {
 	case c.EncodeLevel == "LowercaseLevelEncoder": // 小写编码器(默认)
-		return zapcore.LowercaseLevelEncoder
+		return namedLevelEncoder(names, zapcore.LowercaseLevelEncoder)
 	case c.EncodeLevel == "LowercaseColorLevelEncoder": // 小写编码器带颜色
-		return zapcore.LowercaseColorLevelEncoder
+		return c.colorLevelEncoder(false, names)
 	case c.EncodeLevel == "CapitalLevelEncoder": // 大写编码器
-		return zapcore.CapitalLevelEncoder
+		return namedLevelEncoder(names, zapcore.CapitalLevelEncoder)
 	case c.EncodeLevel == "CapitalColorLevelEncoder": // 大写编码器带颜色
-		return zapcore.CapitalColorLevelEncoder
+		return c.colorLevelEncoder(true, names)
 	default:
-		return zapcore.LowercaseLevelEncoder
+		return namedLevelEncoder(names, zapcore.LowercaseLevelEncoder)
 	}
 }
 
@@ -1,12 +1,13 @@
 package mlog
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
-	"github.com/ai-mmo/lumberjack"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -16,29 +17,50 @@ type ZapCore struct {
 	serviceName string // 保存创建时的服务名称
 	serviceID   uint64 // 保存创建时的服务ID
 	zapcore.Core
-	// 添加 lumberjack logger 引用，用于正确关闭
-	lumberjackLogger *lumberjack.Logger
+	// 主日志文件输出，与控制台输出分开同步，关闭时释放其中的 lumberjack logger
+	fileSyncer *fileWriteSyncer
 	// 缓存编码器，避免重复创建
 	encoder zapcore.Encoder
-	// 缓存特殊目录的 lumberjack logger，避免重复创建和 goroutine 泄露
-	specialLoggers map[string]*lumberjack.Logger
-	// 保护 specialLoggers 的互斥锁
+	// 文件名模板，未配置 FilePattern 时为 nil
+	filePattern *fileNamePattern
+	// 单文件模式下额外写入 error.log 的 Core（AlwaysSeparateErrors），按级别分文件的规则命名且不输出控制台
+	errorCopy bool
+	// 缓存特殊目录的文件输出，避免重复创建 lumberjack logger 和 goroutine 泄露，键为目录路径
+	specialSyncers map[string]*fileWriteSyncer
+	// CSVChannels 中子目录的 CSV 编码器，键为子目录名，由 specialLoggersMutex 保护
+	csvEncoders map[string]zapcore.Encoder
+	// 保护 specialSyncers 的互斥锁
 	specialLoggersMutex sync.RWMutex
 }
 
 // NewZapCoreWithService 创建带有指定服务信息的 ZapCore（优化版本）
 func NewZapCoreWithService(level zapcore.Level, svcName string, svcID uint64) *ZapCore {
+	return newZapCore(level, svcName, svcID, false)
+}
+
+// newErrorCopyCore 创建单文件模式下把 Error 及以上级别日志额外写入 error.log 的 ZapCore
+func newErrorCopyCore(svcName string, svcID uint64) *ZapCore {
+	return newZapCore(zapcore.ErrorLevel, svcName, svcID, true)
+}
+
+// newZapCore 创建 ZapCore
+func newZapCore(level zapcore.Level, svcName string, svcID uint64, errorCopy bool) *ZapCore {
 	// 直接使用传入的服务信息，避免访问全局变量
 	entity := &ZapCore{
 		level:          level,
 		serviceName:    svcName,
 		serviceID:      svcID,
-		specialLoggers: make(map[string]*lumberjack.Logger),
+		errorCopy:      errorCopy,
+		specialSyncers: make(map[string]*fileWriteSyncer),
+		csvEncoders:    make(map[string]zapcore.Encoder),
 	}
+	// 模板无效时 initZap 已输出错误，这里回退到默认文件名
+	entity.filePattern, _ = parseFilePattern(zapConfig.FilePattern)
 	syncer := entity.WriteSyncer()
 
 	// 创建并缓存编码器，避免重复创建
 	encoder := zapConfig.Encoder()
+	addEncoderService(encoder, svcName, svcID)
 	entity.encoder = encoder
 
 	// 【修复】使用动态级别控制器
@@ -59,12 +81,25 @@ func NewZapCoreWithService(level zapcore.Level, svcName string, svcID uint64) *Z
 	return entity
 }
 
+// singleFile 文件名、链接名和控制台输出是否按单文件模式处理，error.log 副本按级别分文件的规则处理
+func (z *ZapCore) singleFile() bool {
+	return zapConfig.SingleFile && !z.errorCopy
+}
+
 // getLogFileName 根据配置获取日志文件名
-// 如果启用了单文件模式，返回配置的单文件名或默认的 "all.log"
-// 否则返回基于日志级别的文件名，如 "debug.log"、"info.log" 等
-func (z *ZapCore) getLogFileName() string {
+// 配置了 FilePattern 时按模板生成（单文件模式下 {level} 为 all），
+// 否则单文件模式返回配置的单文件名或默认的 "all.log"，
+// 按级别分文件时返回基于日志级别的文件名，如 "debug.log"、"info.log" 等
+func (z *ZapCore) getLogFileName(now time.Time) string {
+	if z.filePattern != nil {
+		level := z.level.String()
+		if z.singleFile() {
+			level = "all"
+		}
+		return z.filePattern.render(z.serviceName, z.serviceID, level, now)
+	}
 	// 如果启用了单文件模式
-	if zapConfig.SingleFile {
+	if z.singleFile() {
 		// 如果配置了自定义文件名，使用自定义文件名
 		if zapConfig.SingleFileName != "" {
 			return zapConfig.SingleFileName
@@ -82,15 +117,8 @@ func (z *ZapCore) WriteSyncer(formats ...string) zapcore.WriteSyncer {
 
 // createWriteSyncer 创建写入同步器，接受服务名称和ID作为参数以避免锁竞争
 func (z *ZapCore) createWriteSyncer(currentServiceName string, currentServiceID uint64, formats ...string) zapcore.WriteSyncer {
-	// 构建包含服务名称的日志目录路径
-	logDir := zapConfig.Director
-	if currentServiceID != 0 {
-		logDir = filepath.Join(zapConfig.Director, fmt.Sprintf("%d", currentServiceID))
-	}
-	// 有具体服务的名字要加入到目录中
-	if currentServiceName != "" {
-		logDir = filepath.Join(logDir, currentServiceName)
-	}
+	// 构建包含服务ID和服务名称的日志目录路径
+	logDir := logDirFor(currentServiceName, currentServiceID)
 	// 如果有额外的格式化目录（如business、folder等），添加到路径中
 	if len(formats) > 0 && formats[0] != "" {
 		logDir = filepath.Join(logDir, formats[0])
@@ -98,70 +126,81 @@ func (z *ZapCore) createWriteSyncer(currentServiceName string, currentServiceID
 	// 确保目录存在
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		// 如果创建目录失败，使用默认目录
-		logDir = zapConfig.Director
+		logDir = currentDirector()
 		os.MkdirAll(logDir, 0755)
 	}
 
-	var lumberjackLogger *lumberjack.Logger
-
-	// 获取日志文件名（根据配置决定是单文件还是按级别分文件）
-	logFileName := z.getLogFileName()
+	var fileSyncer *fileWriteSyncer
 
 	// 如果是特殊目录，使用缓存的 logger 避免重复创建和 goroutine 泄露
 	if len(formats) > 0 && formats[0] != "" {
-		// 构建缓存键：目录路径 + 文件名
-		cacheKey := filepath.Join(logDir, logFileName)
-
 		z.specialLoggersMutex.RLock()
-		cachedLogger, exists := z.specialLoggers[cacheKey]
+		cachedSyncer, exists := z.specialSyncers[logDir]
 		z.specialLoggersMutex.RUnlock()
 
 		if exists {
 			// 使用缓存的 logger
-			lumberjackLogger = cachedLogger
+			fileSyncer = cachedSyncer
 		} else {
 			// 创建新的 logger 并缓存
-			lumberjackLogger = &lumberjack.Logger{
-				Filename:   filepath.Join(logDir, logFileName),
-				MaxSize:    zapConfig.MaxSize,        // MB
-				MaxBackups: zapConfig.MaxBackups,     // 保留备份文件数量
-				MaxAge:     zapConfig.RetentionDay,   // 保留天数
-				Compress:   zapConfig.EnableCompress, // 是否压缩
-				LocalTime:  true,                     // 使用本地时间
-			}
-
-			// 缓存新创建的 logger
 			z.specialLoggersMutex.Lock()
-			z.specialLoggers[cacheKey] = lumberjackLogger
+			if fileSyncer = z.specialSyncers[logDir]; fileSyncer == nil {
+				fileSyncer = z.newFileSyncer(logDir)
+				if columns := zapConfig.csvColumns(formats[0]); len(columns) > 0 {
+					// CSV 文件的第一行为列名，代替 FileHeader
+					fileSyncer.header = csvFileHeader(columns)
+				}
+				z.specialSyncers[logDir] = fileSyncer
+			}
 			z.specialLoggersMutex.Unlock()
 		}
 	} else {
-		// 主要的 lumberjack logger（非特殊目录）
-		lumberjackLogger = &lumberjack.Logger{
-			Filename:   filepath.Join(logDir, logFileName),
-			MaxSize:    zapConfig.MaxSize,        // MB
-			MaxBackups: zapConfig.MaxBackups,     // 保留备份文件数量
-			MaxAge:     zapConfig.RetentionDay,   // 保留天数
-			Compress:   zapConfig.EnableCompress, // 是否压缩
-			LocalTime:  true,                     // 使用本地时间
-		}
-
-		// 保存主要的 lumberjack logger 引用，用于后续关闭
-		z.lumberjackLogger = lumberjackLogger
+		// 主要的文件输出（非特殊目录），保存引用用于后续关闭
+		fileSyncer = z.newFileSyncer(logDir)
+		z.fileSyncer = fileSyncer
 	}
 
 	// 同步日志写入 到 控制台
-	if zapConfig.LogInConsole {
-		multiSyncer := zapcore.NewMultiWriteSyncer(os.Stdout, zapcore.AddSync(lumberjackLogger))
+	// 控制台和文件使用各自的 WriteSyncer，同步文件时不会因为控制台不支持 fsync 而报错
+	// 是否输出控制台在写入时判断，配置了按级别路由时控制台由单独的 Core 输出
+	// error.log 副本中的日志已经由单文件 Core 输出到控制台
+	if !z.errorCopy {
+		multiSyncer := zapcore.NewMultiWriteSyncer(consoleMirror{}, fileSyncer)
 		return multiSyncer
 	}
-	return zapcore.AddSync(lumberjackLogger)
+	return fileSyncer
+}
+
+// newFileSyncer 创建 logDir 下的日志文件输出，文件名模板带日期时跨日自动切换到新文件
+func (z *ZapCore) newFileSyncer(logDir string) *fileWriteSyncer {
+	now := time.Now()
+	syncer := newFileWriteSyncer(filepath.Join(logDir, zapConfig.processFileName(z.getLogFileName(now))))
+	if z.filePattern != nil && z.filePattern.hasDate {
+		syncer.fileName = func(t time.Time) string {
+			return zapConfig.processFileName(z.getLogFileName(t))
+		}
+		syncer.nameChecked.Store(now.Unix())
+	}
+	if zapConfig.CurrentSymlink {
+		syncer.linkName = z.currentLinkName()
+	}
+	if zapConfig.FileHeader {
+		syncer.header = func(file string, t time.Time) []byte {
+			return fileHeader(z.serviceName, z.serviceID, file, t)
+		}
+	}
+	// 与 createWriteSyncer 中同时输出控制台的条件一致
+	syncer.consoleMirrored = !z.errorCopy
+	return syncer
 }
 
 func (z *ZapCore) Enabled(level zapcore.Level) bool {
 	// 【修复】根据SingleFile配置决定过滤逻辑
 	currentAtomicLevel := atomicLevel.Level()
 
+	if !routeAllows(level, OutputFile) {
+		return false
+	}
 	if zapConfig.SingleFile {
 		// 单文件模式：Core的level是它能记录的最低级别
 		return level >= z.level && level >= currentAtomicLevel
@@ -213,47 +252,85 @@ func (z *ZapCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
 		// 创建临时的 Core 用于这次写入，不影响原始 Core
 		// 使用缓存的编码器，避免重复创建
 		syncer := z.createWriteSyncer(z.serviceName, z.serviceID, specialDirectory)
-		tempCore := zapcore.NewCore(z.encoder, syncer, z.level)
+		tempCore := zapcore.NewCore(z.directoryEncoder(specialDirectory), syncer, z.level)
 		return tempCore.Write(entry, filteredFields)
 	}
 	// 使用原始的 Core（写入主日志目录）
+	if zapConfig.SingleFile {
+		// 单文件模式没有过滤字段，直接写入全部字段
+		return z.Core.Write(entry, fields)
+	}
 	return z.Core.Write(entry, filteredFields)
 }
 
+// directoryEncoder 返回子目录使用的编码器，CSVChannels 中的子目录使用 CSV 编码器
+func (z *ZapCore) directoryEncoder(directory string) zapcore.Encoder {
+	columns := zapConfig.csvColumns(directory)
+	if len(columns) == 0 {
+		return z.encoder
+	}
+	z.specialLoggersMutex.RLock()
+	encoder, ok := z.csvEncoders[directory]
+	z.specialLoggersMutex.RUnlock()
+	if ok {
+		return encoder
+	}
+	z.specialLoggersMutex.Lock()
+	defer z.specialLoggersMutex.Unlock()
+	if encoder, ok = z.csvEncoders[directory]; !ok {
+		encoder = newCSVEncoder(&zapConfig, columns)
+		z.csvEncoders[directory] = encoder
+	}
+	return encoder
+}
+
+// Sync 同步文件和控制台输出，某个输出同步失败时仍然同步其余输出，返回所有错误
 func (z *ZapCore) Sync() error {
-	return z.Core.Sync()
+	err := z.SyncFiles()
+	if consoleEnabled.Load() {
+		err = errors.Join(err, SyncConsoleSink())
+	}
+	return err
+}
+
+// SyncFiles 同步主日志文件和所有特殊目录日志文件，不涉及控制台
+func (z *ZapCore) SyncFiles() error {
+	var errs []error
+	if z.fileSyncer != nil {
+		errs = append(errs, z.fileSyncer.Sync())
+	}
+	z.specialLoggersMutex.RLock()
+	for _, syncer := range z.specialSyncers {
+		errs = append(errs, syncer.Sync())
+	}
+	z.specialLoggersMutex.RUnlock()
+	return errors.Join(errs...)
 }
 
 // Close 关闭 ZapCore，包括关闭 lumberjack logger 以防止 goroutine 泄露
 func (z *ZapCore) Close() error {
-	// 先同步日志（忽略无害错误）
-	if err := z.Core.Sync(); err != nil {
-		// 检查是否为无害错误
-		if !isHarmlessSyncError(err) {
-			// 只记录真正的错误
-			fmt.Fprintf(os.Stderr, "[mlog] ZapCore 同步失败: %v\n", err)
-		}
+	// 先同步日志文件
+	if err := z.SyncFiles(); err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] ZapCore 同步失败: %v\n", err)
 	}
 
 	// 关闭主要的 lumberjack logger
-	if z.lumberjackLogger != nil {
-		if err := z.lumberjackLogger.Close(); err != nil {
+	if z.fileSyncer != nil {
+		if err := z.fileSyncer.Close(); err != nil {
 			fmt.Fprintf(os.Stderr, "[mlog] 关闭主要 lumberjack logger 失败: %v\n", err)
 		}
-		z.lumberjackLogger = nil
+		z.fileSyncer = nil
 	}
 
 	// 关闭所有缓存的特殊目录 logger
 	z.specialLoggersMutex.Lock()
-	for cacheKey, logger := range z.specialLoggers {
-		if logger != nil {
-			if err := logger.Close(); err != nil {
-				fmt.Fprintf(os.Stderr, "[mlog] 关闭特殊目录 lumberjack logger 失败 [%s]: %v\n", cacheKey, err)
-			}
+	for cacheKey, syncer := range z.specialSyncers {
+		if err := syncer.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 关闭特殊目录 lumberjack logger 失败 [%s]: %v\n", cacheKey, err)
 		}
 	}
 	// 清空缓存
-	z.specialLoggers = make(map[string]*lumberjack.Logger)
+	z.specialSyncers = make(map[string]*fileWriteSyncer)
 	z.specialLoggersMutex.Unlock()
 
 	return nil
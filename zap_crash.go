@@ -0,0 +1,103 @@
+package mlog
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// crashFlushTimeout 崩溃刷新等待异步队列写完的最长时间
+const crashFlushTimeout = 3 * time.Second
+
+// crashHooksInstalled 是否已安装崩溃刷新钩子
+var crashHooksInstalled int32
+
+// InstallCrashHandler 安装崩溃刷新钩子，返回用于卸载的函数
+// 安装后：
+//   - 收到指定信号（默认 SIGINT、SIGTERM）时先写完异步队列并同步所有日志文件，再按信号的默认行为退出进程
+//   - ExitGame 在 panic 之前主动刷新日志，不再依赖固定的等待时间
+//
+// Go 无法全局拦截其他 goroutine 的 panic，需要在 main 和业务 goroutine 入口处配合 defer mlog.RecoverAndFlush() 使用
+func InstallCrashHandler(signals ...os.Signal) (uninstall func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	atomic.StoreInt32(&crashHooksInstalled, 1)
+
+	ch := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+	signal.Notify(ch, signals...)
+	go func() {
+		select {
+		case sig := <-ch:
+			WarnW("[CrashHandler] 收到退出信号，刷新日志", zap.String("signal", sig.String()))
+			CrashFlush()
+			// 恢复信号的默认行为并重新发送，保持进程原有的退出方式和退出码
+			signal.Reset(sig)
+			if p, err := os.FindProcess(os.Getpid()); err == nil && p.Signal(sig) == nil {
+				time.Sleep(time.Second)
+			}
+			os.Exit(1)
+		case <-stop:
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		close(stop)
+		atomic.StoreInt32(&crashHooksInstalled, 0)
+	}
+}
+
+// CrashFlush 写完异步队列中的日志并同步所有日志文件，最多等待 3 秒
+// 适合在进程即将退出时调用，即使写入协程卡住也不会永久阻塞
+func CrashFlush() {
+	if al, ok := getAsyncLogger(); ok {
+		done := make(chan struct{})
+		go func() {
+			al.Flush()
+			close(done)
+		}()
+		timer := time.NewTimer(crashFlushTimeout)
+		select {
+		case <-done:
+		case <-timer.C:
+			fmt.Fprintf(os.Stderr, "[mlog] 崩溃刷新超时，仍有 %d 条日志未写入\n", al.QueueStats().Depth)
+		}
+		timer.Stop()
+	}
+
+	coreMutex.RLock()
+	for _, core := range zapCores {
+		if core == nil {
+			continue
+		}
+		if err := core.SyncFiles(); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 崩溃刷新同步失败: %v\n", err)
+		}
+	}
+	coreMutex.RUnlock()
+}
+
+// RecoverAndFlush 捕获 panic，记录 panic 信息和调用栈并刷新日志后重新 panic
+// 必须直接通过 defer 调用：
+//
+//	func main() {
+//		defer mlog.RecoverAndFlush()
+//		...
+//	}
+func RecoverAndFlush() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	ErrorW("[CrashHandler] panic", zap.Any("panic", r), zap.ByteString("stack", debug.Stack()))
+	CrashFlush()
+	panic(r)
+}
@@ -0,0 +1,45 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRecoverAndFlush 测试 panic 被记录并在重新 panic 之前写入日志文件
+func TestRecoverAndFlush(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{
+		Level:           "info",
+		Format:          "console",
+		Director:        dir,
+		SingleFile:      true,
+		EnableAsync:     true,
+		AsyncBufferSize: 1000,
+	}
+	InitialZap("crash", 0, "info", &config)
+	defer Close()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("应重新抛出原始 panic，实际 %v", r)
+			}
+		}()
+		defer RecoverAndFlush()
+		Info("panic 之前的日志")
+		panic("boom")
+	}()
+
+	// 不调用 Close，日志应已经由 RecoverAndFlush 写入文件
+	data, err := os.ReadFile(filepath.Join(dir, "crash", "all.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if !strings.Contains(content, "panic 之前的日志") || !strings.Contains(content, "boom") {
+		t.Errorf("panic 日志没有被刷新到文件: %s", content)
+	}
+}
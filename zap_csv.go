@@ -0,0 +1,166 @@
+package mlog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// CSV 列中表示日志本身属性的列名，其他列名按字段键名取值
+const (
+	CSVColumnTime       = "time"
+	CSVColumnLevel      = "level"
+	CSVColumnLogger     = "logger"
+	CSVColumnCaller     = "caller"
+	CSVColumnMessage    = "message"
+	CSVColumnStacktrace = "stacktrace"
+)
+
+// utf8BOM 写在 CSV 文件开头，Excel 据此按 UTF-8 打开，中文不会乱码
+const utf8BOM = "\xef\xbb\xbf"
+
+// csvPool 编码结果的缓冲
+var csvPool = buffer.NewPool()
+
+// csvEncoder 按固定列输出 CSV 行的编码器，供审计、经济等需要导出到表格的子目录使用
+// 每条日志一行，缺少的字段为空单元格，不在列中的字段不输出；对象和数组编码为 JSON 文本。
+// 以 = + - @ 开头的字符串值前加单引号，避免表格软件把单元格当作公式执行
+type csvEncoder struct {
+	*zapcore.MapObjectEncoder
+	columns []string
+	layout  string
+	loc     *time.Location
+}
+
+// newCSVEncoder 创建 CSV 编码器，时间按 TimeFormat 和 TimeZone 格式化（epoch 系列使用默认布局）
+func newCSVEncoder(c *ZapConfig, columns []string) *csvEncoder {
+	layout := c.timeLayout()
+	if layout == "" {
+		layout = defaultTimeLayout
+	}
+	loc, _ := c.timeLocation()
+	return &csvEncoder{MapObjectEncoder: zapcore.NewMapObjectEncoder(), columns: columns, layout: layout, loc: loc}
+}
+
+// csvColumns 返回子目录配置的 CSV 列，未配置时返回 nil
+func (c *ZapConfig) csvColumns(directory string) []string {
+	if directory == "" {
+		return nil
+	}
+	return c.CSVChannels[directory]
+}
+
+// csvFileHeader 返回写入 CSV 列名的文件头函数，只在文件为空时写入
+func csvFileHeader(columns []string) func(file string, now time.Time) []byte {
+	return func(file string, now time.Time) []byte {
+		if info, err := os.Stat(file); err == nil && info.Size() > 0 {
+			return nil
+		}
+		var b bytes.Buffer
+		b.WriteString(utf8BOM)
+		w := csv.NewWriter(&b)
+		w.Write(columns)
+		w.Flush()
+		return b.Bytes()
+	}
+}
+
+func (e *csvEncoder) Clone() zapcore.Encoder {
+	clone := &csvEncoder{MapObjectEncoder: zapcore.NewMapObjectEncoder(), columns: e.columns, layout: e.layout, loc: e.loc}
+	for k, v := range e.Fields {
+		clone.Fields[k] = v
+	}
+	return clone
+}
+
+func (e *csvEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := e.Clone().(*csvEncoder)
+	for i := range fields {
+		fields[i].AddTo(final)
+	}
+
+	record := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		switch column {
+		case CSVColumnTime:
+			record[i] = e.formatTime(entry.Time)
+		case CSVColumnLevel:
+			record[i] = entry.Level.String()
+		case CSVColumnLogger:
+			record[i] = entry.LoggerName
+		case CSVColumnCaller:
+			if entry.Caller.Defined {
+				record[i] = entry.Caller.TrimmedPath()
+			}
+		case CSVColumnMessage:
+			record[i] = csvSafe(entry.Message)
+		case CSVColumnStacktrace:
+			record[i] = entry.Stack
+		default:
+			if v, ok := final.Fields[column]; ok {
+				record[i] = e.formatValue(v)
+			}
+		}
+	}
+
+	buf := csvPool.Get()
+	w := csv.NewWriter(buf)
+	if err := w.Write(record); err != nil {
+		buf.Free()
+		return nil, err
+	}
+	w.Flush()
+	return buf, w.Error()
+}
+
+// formatTime 按配置的布局和时区格式化时间
+func (e *csvEncoder) formatTime(t time.Time) string {
+	if e.loc != nil {
+		t = t.In(e.loc)
+	}
+	return t.Format(e.layout)
+}
+
+// formatValue 把 MapObjectEncoder 中的字段值转换为单元格文本
+func (e *csvEncoder) formatValue(v any) string {
+	switch value := v.(type) {
+	case string:
+		return csvSafe(value)
+	case []byte:
+		return csvSafe(string(value))
+	case bool:
+		return strconv.FormatBool(value)
+	case time.Time:
+		return e.formatTime(value)
+	case time.Duration:
+		return value.String()
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr, float32, float64:
+		return fmt.Sprint(value)
+	case nil:
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return csvSafe(fmt.Sprint(v))
+	}
+	return csvSafe(string(data))
+}
+
+// csvSafe 以公式字符开头的文本前加单引号
+func csvSafe(s string) string {
+	if s == "" {
+		return s
+	}
+	switch s[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + s
+	}
+	return s
+}
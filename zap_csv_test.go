@@ -0,0 +1,57 @@
+package mlog
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TestCSVChannels 测试 CSVChannels 中的子目录按列输出 CSV，重新打开已有文件时不重复写入列名
+func TestCSVChannels(t *testing.T) {
+	oldConfig := zapConfig
+	defer func() { zapConfig = oldConfig }()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "json", TimeZone: "UTC", FileHeader: true,
+		CSVChannels: map[string][]string{"audit": {"time", "player_id", "action", "amount", "message", "items"}}}
+	for round := 0; round < 2; round++ {
+		Close()
+		InitialZap("gate", 2, "info", &config)
+		GLOG().Info("充值, 首次", zap.String("directory", "audit"),
+			zap.Int64("player_id", 1001), zap.String("action", "=SUM(A1)"), zap.Int64("amount", -30), zap.Strings("items", []string{"a", "b"}))
+		GLOG().Info("普通日志", zap.String("directory", "chat"))
+	}
+	Close()
+
+	f, err := os.Open(filepath.Join(dir, "2", "gate", "audit", "info.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("期望列名和 2 行记录，实际 %d 行: %v", len(records), records)
+	}
+	if strings.Join(records[0], ",") != utf8BOM+"time,player_id,action,amount,message,items" {
+		t.Fatalf("列名错误: %q", records[0])
+	}
+	row := records[1]
+	if _, err := time.Parse(defaultTimeLayout, row[0]); err != nil {
+		t.Fatalf("时间格式错误: %q", row[0])
+	}
+	if got := strings.Join(row[1:], "|"); got != `1001|'=SUM(A1)|-30|充值, 首次|["a","b"]` {
+		t.Fatalf("记录错误: %q", got)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "2", "gate", "chat", "info.log"))
+	if err != nil || !strings.Contains(string(data), `"message":"普通日志"`) {
+		t.Fatalf("未配置的子目录应保持原格式: %s %v", data, err)
+	}
+}
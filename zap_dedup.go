@@ -0,0 +1,165 @@
+package mlog
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultDedupWindow 默认的去重窗口
+const defaultDedupWindow = time.Second
+
+// dedupKey 去重键：级别和消息都相同的日志视为重复
+type dedupKey struct {
+	level   zapcore.Level
+	message string
+}
+
+// dedupRecord 一个去重窗口内的重复日志记录
+type dedupRecord struct {
+	start      time.Time       // 窗口开始时间（首条日志的时间）
+	suppressed int             // 窗口内被合并的条数
+	core       zapcore.Core    // 最后一条重复日志所属的 Core（保留 With 附加的字段）
+	entry      zapcore.Entry   // 最后一条重复日志
+	fields     []zapcore.Field // 最后一条重复日志的字段副本
+}
+
+// dedupState 去重状态，通过 With 派生的 Core 共享同一份状态
+type dedupState struct {
+	window    time.Duration
+	mu        sync.Mutex
+	records   map[dedupKey]*dedupRecord
+	lastSweep time.Time
+	dropped   *droppedCounter // 非空时记录被合并的日志，汇总日志写出时附加 sampled_dropped
+}
+
+// activeDedup 当前日志器的去重状态，未启用去重时为 nil
+var activeDedup atomic.Pointer[dedupState]
+
+// dedupCore 合并去重窗口内相同级别和消息的日志
+// 窗口内的首条日志立即写入，之后的重复日志只计数，窗口结束时写入一条带 repeat_count 字段的汇总日志
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+}
+
+// newDedupCore 创建去重 Core，window <= 0 时使用默认窗口
+func newDedupCore(core zapcore.Core, window time.Duration) *dedupCore {
+	if window <= 0 {
+		window = defaultDedupWindow
+	}
+	return &dedupCore{
+		Core: core,
+		state: &dedupState{
+			window:  window,
+			records: make(map[dedupKey]*dedupRecord),
+		},
+	}
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{Core: c.Core.With(fields), state: c.state}
+}
+
+func (c *dedupCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *dedupCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	// DPanic 及以上级别始终写入，不参与去重
+	if entry.Level >= zapcore.DPanicLevel {
+		return writeChecked(c.Core, entry, fields)
+	}
+
+	s := c.state
+	key := dedupKey{level: entry.Level, message: entry.Message}
+	s.mu.Lock()
+	expired := s.sweepLocked(entry.Time, false)
+	record, exists := s.records[key]
+	if exists {
+		s.dropped.add(entry.Level, entry.Message)
+		record.suppressed++
+		record.core = c.Core
+		record.entry = entry
+		record.fields = append(record.fields[:0], fields...)
+		s.mu.Unlock()
+		writeDedupSummaries(expired)
+		return nil
+	}
+	s.records[key] = &dedupRecord{start: entry.Time}
+	s.mu.Unlock()
+
+	writeDedupSummaries(expired)
+	return writeChecked(c.Core, entry, fields)
+}
+
+func (c *dedupCore) Sync() error {
+	c.state.mu.Lock()
+	expired := c.state.sweepLocked(time.Now(), true)
+	c.state.mu.Unlock()
+	writeDedupSummaries(expired)
+	return c.Core.Sync()
+}
+
+// sweepLocked 移除已结束的去重窗口，返回需要写入汇总的记录
+// force 为 false 时每个窗口周期最多扫描一次，避免每条日志都遍历全部记录
+func (s *dedupState) sweepLocked(now time.Time, force bool) []*dedupRecord {
+	if !force && now.Sub(s.lastSweep) < s.window {
+		return nil
+	}
+	s.lastSweep = now
+	var expired []*dedupRecord
+	for key, record := range s.records {
+		if now.Sub(record.start) < s.window {
+			continue
+		}
+		delete(s.records, key)
+		if record.suppressed > 0 {
+			expired = append(expired, record)
+		}
+	}
+	return expired
+}
+
+// flushAll 结束所有去重窗口，写入汇总日志
+func (s *dedupState) flushAll() {
+	s.mu.Lock()
+	var pending []*dedupRecord
+	for key, record := range s.records {
+		delete(s.records, key)
+		if record.suppressed > 0 {
+			pending = append(pending, record)
+		}
+	}
+	s.mu.Unlock()
+	writeDedupSummaries(pending)
+}
+
+// writeDedupSummaries 写入去重汇总日志，消息和字段取自窗口内最后一条重复日志
+func writeDedupSummaries(records []*dedupRecord) {
+	for _, record := range records {
+		fields := append(record.fields, zap.Int("repeat_count", record.suppressed))
+		writeChecked(record.core, record.entry, fields)
+	}
+}
+
+// writeChecked 经过 Check 写入日志，保证 Tee 中每个 Core 只写入自己启用的级别
+func writeChecked(core zapcore.Core, entry zapcore.Entry, fields []zapcore.Field) error {
+	if ce := core.Check(entry, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+// flushDedup 结束当前日志器的所有去重窗口
+func flushDedup() {
+	if s := activeDedup.Load(); s != nil {
+		s.flushAll()
+	}
+}
@@ -0,0 +1,47 @@
+package mlog
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestDedupCore 测试窗口内重复日志被合并为一条带 repeat_count 的汇总日志
+func TestDedupCore(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	core := newDedupCore(observed, 50*time.Millisecond)
+	logger := zap.New(core)
+
+	for i := 0; i < 5; i++ {
+		logger.Error("每帧都出错", zap.Int("tick", i))
+	}
+	logger.Warn("每帧都出错")
+	logger.Error("另一条错误")
+	if logs.Len() != 3 {
+		t.Fatalf("窗口内重复日志应被合并，实际 %d 条", logs.Len())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	entries := logs.AllUntimed()
+	if len(entries) != 4 {
+		t.Fatalf("窗口结束后应输出 1 条汇总日志，实际 %d 条", len(entries))
+	}
+	summary := entries[3].ContextMap()
+	if entries[3].Message != "每帧都出错" || summary["repeat_count"] != int64(4) || summary["tick"] != int64(4) {
+		t.Fatalf("汇总日志错误: %s %v", entries[3].Message, summary)
+	}
+
+	// 新窗口中首条日志立即写入，未结束的窗口可由 flushAll 强制输出
+	logger.Error("每帧都出错")
+	logger.Error("每帧都出错")
+	core.state.flushAll()
+	if logs.Len() != 6 || logs.AllUntimed()[5].ContextMap()["repeat_count"] != int64(1) {
+		t.Fatalf("flushAll 结果错误: %d 条", logs.Len())
+	}
+}
@@ -0,0 +1,120 @@
+package mlog
+
+import (
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DeprecationStat 废弃功能的使用统计
+type DeprecationStat struct {
+	Feature     string    `json:"feature"`      // 废弃功能名称
+	Count       int64     `json:"count"`        // 累计调用次数
+	FirstSeen   time.Time `json:"first_seen"`   // 首次调用时间
+	FirstCaller string    `json:"first_caller"` // 首次调用位置
+	Callers     int       `json:"callers"`      // 不同调用位置的数量
+}
+
+// deprecationRecord 单个废弃功能的跟踪记录
+type deprecationRecord struct {
+	stat    DeprecationStat
+	callers map[string]int64 // 调用位置 -> 调用次数
+}
+
+var (
+	deprecationMutex   sync.Mutex
+	deprecationRecords = make(map[string]*deprecationRecord)
+)
+
+// Deprecated 记录废弃功能的使用情况
+// 日志统一写入 deprecation 目录，包含调用位置、首次出现时间和累计次数。
+// once 为 true 时每个调用位置只输出一次日志（统计仍然持续累计），适合放在高频路径上。
+func Deprecated(feature string, once bool) {
+	caller := "unknown"
+	if _, file, line, ok := runtime.Caller(1); ok {
+		if zapConfig.UseRelativePath {
+			file = getRelativePath(file)
+		}
+		caller = file + ":" + strconv.Itoa(line)
+	}
+
+	deprecationMutex.Lock()
+	record, exists := deprecationRecords[feature]
+	if !exists {
+		record = &deprecationRecord{
+			stat: DeprecationStat{
+				Feature:     feature,
+				FirstSeen:   time.Now(),
+				FirstCaller: caller,
+			},
+			callers: make(map[string]int64),
+		}
+		deprecationRecords[feature] = record
+	}
+	record.stat.Count++
+	record.callers[caller]++
+	callerCount := record.callers[caller]
+	stat := record.stat
+	stat.Callers = len(record.callers)
+	deprecationMutex.Unlock()
+
+	if once && callerCount > 1 {
+		return
+	}
+	if !isWarnEnabledFast() {
+		return
+	}
+
+	msg := "[Deprecated] " + feature
+	fields := []zap.Field{
+		zap.String("directory", "deprecation"),
+		zap.String("feature", feature),
+		zap.Int64("count", stat.Count),
+		zap.Int64("caller_count", callerCount),
+		zap.Time("first_seen", stat.FirstSeen),
+		zap.String("first_caller", stat.FirstCaller),
+	}
+
+	logger, ok := getLogger()
+	if !ok {
+		logBeforeInit(zapcore.WarnLevel, 1, msg, fields...)
+		return
+	}
+
+	// 调用栈：用户代码 -> mlog.Deprecated() -> logger.Warn()
+	// 需要跳过 1 层：mlog.Deprecated()
+	loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(1))
+	loggerWithSkip.Warn(msg, fields...)
+}
+
+// GetDeprecationStats 获取所有废弃功能的使用统计，按调用次数降序排列
+func GetDeprecationStats() []DeprecationStat {
+	deprecationMutex.Lock()
+	stats := make([]DeprecationStat, 0, len(deprecationRecords))
+	for _, record := range deprecationRecords {
+		stat := record.stat
+		stat.Callers = len(record.callers)
+		stats = append(stats, stat)
+	}
+	deprecationMutex.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Feature < stats[j].Feature
+	})
+	return stats
+}
+
+// ResetDeprecationStats 清空废弃功能统计（用于测试或周期性上报后重置）
+func ResetDeprecationStats() {
+	deprecationMutex.Lock()
+	deprecationRecords = make(map[string]*deprecationRecord)
+	deprecationMutex.Unlock()
+}
@@ -0,0 +1,60 @@
+package mlog
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDeprecated 测试废弃警告的输出格式，once 为 true 时每个调用位置只输出一次而统计持续累计
+func TestDeprecated(t *testing.T) {
+	Close()
+	ResetDeprecationStats()
+	defer ResetDeprecationStats()
+	dir := t.TempDir()
+	InitialZap("gate", 2, "info", &ZapConfig{Director: dir, Format: "json"})
+	defer Close()
+
+	for i := 0; i < 3; i++ {
+		Deprecated("OldLogin", true)
+	}
+	Deprecated("OldLogin", true)
+	for i := 0; i < 2; i++ {
+		Deprecated("OldShop", false)
+	}
+	Close()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(readLogFile(t, filepath.Join(dir, "2/gate/deprecation/warn.log"))), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("日志不是 JSON: %s", line)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("期望 4 条警告（OldLogin 两个调用位置各一条，OldShop 两条），实际 %d: %v", len(entries), entries)
+	}
+
+	first := entries[0]
+	if first["level"] != "warn" || first["message"] != "[Deprecated] OldLogin" || first["feature"] != "OldLogin" ||
+		first["count"] != 1.0 || first["caller_count"] != 1.0 || first["first_seen"] == nil {
+		t.Fatalf("警告格式错误: %v", first)
+	}
+	if caller, _ := first["first_caller"].(string); !strings.Contains(caller, "zap_deprecation_test.go:") {
+		t.Fatalf("首次调用位置应指向调用方: %v", first["first_caller"])
+	}
+	if second := entries[1]; second["count"] != 4.0 || second["caller_count"] != 1.0 || second["first_caller"] != first["first_caller"] {
+		t.Fatalf("第二个调用位置的警告应携带累计次数和首次调用位置: %v", second)
+	}
+	if last := entries[3]; last["message"] != "[Deprecated] OldShop" || last["caller_count"] != 2.0 {
+		t.Fatalf("once 为 false 时每次都应输出: %v", last)
+	}
+
+	stats := GetDeprecationStats()
+	if len(stats) != 2 || stats[0].Feature != "OldLogin" || stats[0].Count != 4 || stats[0].Callers != 2 ||
+		stats[1].Feature != "OldShop" || stats[1].Count != 2 || stats[1].Callers != 1 {
+		t.Fatalf("废弃统计错误: %+v", stats)
+	}
+}
@@ -0,0 +1,123 @@
+package mlog
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDirCleanInterval 过期日志文件和空目录的清理间隔
+	defaultDirCleanInterval = time.Hour
+	// dirCleanMinAge 目录最近修改时间超过该时长才会被当作空目录删除，避免与正在创建日志文件的进程竞争
+	dirCleanMinAge = time.Hour
+)
+
+// logDirCleaner 过期日志文件和空目录清理器
+// 每个 serviceID/serviceName/business 组合都会创建目录，长期运行的主机上已下线服务的目录和日志文件
+// 不会被 lumberjack 清理（它只管理正在写入的文件的备份）。启动时和之后每小时遍历 Director 目录树，
+// 按保留策略删除修改时间早于 RetentionDay 的日志文件，再自底向上删除空目录；正在写入的文件和 Director 本身不会被删除
+type logDirCleaner struct {
+	root     string
+	maxAge   time.Duration  // 日志文件保留时长，0 表示只删除空目录
+	pattern  *regexp.Regexp // 文件名模板生成的文件，未配置时为 nil
+	interval time.Duration
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// newLogDirCleaner 创建过期日志文件和空目录清理器并启动后台清理
+func newLogDirCleaner(root string, retentionDay int) *logDirCleaner {
+	c := &logDirCleaner{
+		root:     root,
+		interval: defaultDirCleanInterval,
+		done:     make(chan struct{}),
+	}
+	if retentionDay > 0 {
+		c.maxAge = time.Duration(retentionDay) * 24 * time.Hour
+	}
+	if p, err := parseFilePattern(zapConfig.FilePattern); err == nil && p != nil {
+		c.pattern = p.matcher()
+	}
+	c.wg.Add(1)
+	go c.run()
+	return c
+}
+
+// run 后台清理循环，启动时先清理一次
+func (c *logDirCleaner) run() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		c.clean(time.Now())
+		select {
+		case <-ticker.C:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// clean 清理一轮，返回删除的文件数和目录数
+func (c *logDirCleaner) clean(now time.Time) (files, dirs int) {
+	cleaned := make(map[string]bool) // 本轮删除过文件或子目录的目录
+	if c.maxAge > 0 {
+		policy := retentionPolicy{maxAge: c.maxAge, stale: true, named: c.pattern}
+		planned, _ := policy.plan(c.root, now)
+		for _, f := range planned {
+			if err := os.Remove(f.Path); err != nil {
+				fmt.Fprintf(os.Stderr, "[mlog] 删除过期日志文件失败 %s: %v\n", f.Path, err)
+				continue
+			}
+			files++
+			cleaned[filepath.Dir(f.Path)] = true
+		}
+	}
+
+	var subdirs []string
+	filepath.WalkDir(c.root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path != c.root {
+			subdirs = append(subdirs, path)
+		}
+		return nil
+	})
+
+	// 先删除最深的目录，父目录在子目录删除后才可能为空
+	sort.Slice(subdirs, func(a, b int) bool {
+		return strings.Count(subdirs[a], string(filepath.Separator)) > strings.Count(subdirs[b], string(filepath.Separator))
+	})
+	for _, dir := range subdirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			continue
+		}
+		// 本轮删除过子项的目录修改时间会被刷新，不受最近修改时间的限制
+		info, err := os.Stat(dir)
+		if err != nil || (now.Sub(info.ModTime()) < dirCleanMinAge && !cleaned[dir]) {
+			continue
+		}
+		if err := os.Remove(dir); err == nil {
+			dirs++
+			cleaned[filepath.Dir(dir)] = true
+		}
+	}
+	return files, dirs
+}
+
+// Close 停止后台清理
+func (c *logDirCleaner) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	c.wg.Wait()
+	return nil
+}
@@ -0,0 +1,53 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestLogDirCleaner 测试删除过期日志文件和长时间未使用的空目录，保留最近使用的目录和非日志文件
+func TestLogDirCleaner(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	old := now.Add(-10 * 24 * time.Hour)
+	write := func(path string, modTime time.Time) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, modTime, modTime)
+	}
+	write(filepath.Join(root, "1", "gate", "info.log"), old)
+	write(filepath.Join(root, "1", "gate", "info-2026-01-01T00-00-00.000.log.gz"), old)
+	write(filepath.Join(root, "2", "gate", "info.log"), now)
+	write(filepath.Join(root, "3", "gate", "notes.txt"), old)
+	for _, dir := range []string{filepath.Join(root, "4", "empty"), filepath.Join(root, "5", "fresh")} {
+		os.MkdirAll(dir, 0o755)
+	}
+	os.Chtimes(filepath.Join(root, "4", "empty"), old, old)
+	os.Chtimes(filepath.Join(root, "4"), old, old)
+
+	c := &logDirCleaner{root: root, maxAge: 7 * 24 * time.Hour}
+	files, dirs := c.clean(now)
+	if files != 2 {
+		t.Fatalf("应删除 2 个过期日志文件: %d", files)
+	}
+	// 1/gate、1、4/empty、4
+	if dirs != 4 {
+		t.Fatalf("应删除 4 个空目录: %d", dirs)
+	}
+	for _, path := range []string{"2/gate/info.log", "3/gate/notes.txt", "5/fresh"} {
+		if _, err := os.Stat(filepath.Join(root, path)); err != nil {
+			t.Fatalf("%s 不应被删除: %v", path, err)
+		}
+	}
+	for _, path := range []string{"1", "4"} {
+		if _, err := os.Stat(filepath.Join(root, path)); !os.IsNotExist(err) {
+			t.Fatalf("%s 应被删除", path)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package mlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// activeDirector 当前使用的日志根目录，SetDirector 修改后写入中的日志立即使用新目录
+var activeDirector atomic.Pointer[string]
+
+// currentDirector 返回当前的日志根目录，未初始化时使用配置中的 Director
+func currentDirector() string {
+	if dir := activeDirector.Load(); dir != nil {
+		return *dir
+	}
+	return zapConfig.Director
+}
+
+// SetDirector 运行时修改日志根目录
+// 所有日志文件（包括 business/folder/directory 特殊目录中缓存的文件）写出缓冲后关闭，之后的日志写入新目录下相同的相对路径，
+// 旧目录中的文件保留不动；压缩、加密、清理和归档等后台任务按新目录重新启动。已加载的场景日志仍写入原文件。
+// 未初始化时只修改配置，初始化后生效
+func SetDirector(path string) error {
+	if path == "" {
+		return errors.New("mlog: 日志目录不能为空")
+	}
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return fmt.Errorf("mlog: 创建日志目录失败 [%s]: %w", path, err)
+	}
+
+	globalMutex.Lock()
+	defer globalMutex.Unlock()
+
+	oldDir := currentDirector()
+	zapConfig.Director = path
+	if !isInitialized() || zapConfig.stdoutMode() {
+		activeDirector.Store(&path)
+		return nil
+	}
+
+	// 先写完异步队列中的日志，这些日志仍属于旧目录
+	Flush()
+	activeDirector.Store(&path)
+
+	coreMutex.Lock()
+	defer coreMutex.Unlock()
+	var errs []error
+	for _, core := range zapCores {
+		if core != nil {
+			errs = append(errs, core.moveDirector(oldDir, path))
+		}
+	}
+
+	closeMaintenanceLocked()
+	if len(zapCores) > 0 && zapCores[0] != nil {
+		maintenanceClosers = startMaintenance(zapCores[0].serviceName, zapCores[0].serviceID)
+	}
+	return errors.Join(errs...)
+}
+
+// moveDirector 把主日志文件和所有特殊目录日志文件从 oldDir 移到 newDir 下相同的相对路径
+func (z *ZapCore) moveDirector(oldDir, newDir string) error {
+	var errs []error
+	if z.fileSyncer != nil {
+		errs = append(errs, z.fileSyncer.moveTo(rebaseDir(z.fileSyncer.filename(), oldDir, newDir)))
+	}
+
+	z.specialLoggersMutex.Lock()
+	defer z.specialLoggersMutex.Unlock()
+	moved := make(map[string]*fileWriteSyncer, len(z.specialSyncers))
+	for logDir, syncer := range z.specialSyncers {
+		errs = append(errs, syncer.moveTo(rebaseDir(syncer.filename(), oldDir, newDir)))
+		moved[rebaseDir(logDir, oldDir, newDir)] = syncer
+	}
+	z.specialSyncers = moved
+	return errors.Join(errs...)
+}
+
+// rebaseDir 把 oldDir 下的 path 转换为 newDir 下相同的相对路径，不在 oldDir 下时原样返回
+func rebaseDir(path, oldDir, newDir string) string {
+	rel, err := filepath.Rel(oldDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.Join(newDir, rel)
+}
+
+// filename 返回当前写入的文件路径
+func (f *fileWriteSyncer) filename() string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.logger.Filename
+}
+
+// moveTo 写出缓冲并关闭当前文件，之后的日志写入 name
+func (f *fileWriteSyncer) moveTo(name string) error {
+	var errs []error
+	if f.buffer != nil {
+		errs = append(errs, f.buffer.Sync())
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		errs = append(errs, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed || f.logger.Filename == name {
+		return errors.Join(errs...)
+	}
+	errs = append(errs, f.logger.Close())
+	f.logger = newLumberjackLogger(name)
+	f.nextRotate.Store(0)
+	f.linked.Store(false)
+	f.sizeKnown.Store(false)
+	f.headerPending.Store(true)
+	f.current = nil
+	if f.shared != nil {
+		f.shared.Close()
+		f.shared = newDirLock(filepath.Dir(name))
+	}
+	return errors.Join(errs...)
+}
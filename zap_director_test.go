@@ -0,0 +1,51 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestSetDirector 测试修改日志目录后主日志和特殊目录日志都写入新目录，旧文件只保留修改前的日志
+func TestSetDirector(t *testing.T) {
+	Close()
+	oldDir, newDir := t.TempDir(), filepath.Join(t.TempDir(), "moved")
+	config := ZapConfig{Level: "info", Director: oldDir, MaxSize: 10, EnableAsync: true, BufferSizeKB: 16}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	InfoW("修改目录前")
+	InfoW("修改目录前", zap.String("folder", "net"))
+	if err := SetDirector(newDir); err != nil {
+		t.Fatal(err)
+	}
+	InfoW("修改目录后")
+	InfoW("修改目录后", zap.String("folder", "net"))
+	Flush()
+
+	for _, rel := range []string{"2/gate/info.log", "2/gate/net/info.log"} {
+		old := readLogFile(t, filepath.Join(oldDir, rel))
+		if !strings.Contains(old, "修改目录前") || strings.Contains(old, "修改目录后") {
+			t.Fatalf("旧目录文件 %s 内容错误: %s", rel, old)
+		}
+		moved := readLogFile(t, filepath.Join(newDir, rel))
+		if strings.Contains(moved, "修改目录前") || !strings.Contains(moved, "修改目录后") {
+			t.Fatalf("新目录文件 %s 内容错误: %s", rel, moved)
+		}
+	}
+	if got := GetConfig().Director; got != newDir {
+		t.Fatalf("配置中的日志目录应为 %s: %s", newDir, got)
+	}
+}
+
+func readLogFile(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,92 @@
+package mlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// diskFullProbeInterval 日志文件写入失败后重新尝试写入文件的间隔
+const diskFullProbeInterval = 30 * time.Second
+
+// InternalErrorHandler 日志系统内部错误回调
+type InternalErrorHandler func(err error)
+
+// internalErrorHandler 当前的内部错误回调，未设置时输出到标准错误
+var internalErrorHandler atomic.Pointer[InternalErrorHandler]
+
+// SetInternalErrorHandler 设置日志系统内部错误回调，传入 nil 恢复输出到标准错误
+// 目前在日志文件写入失败（如磁盘已满）并降级为只输出控制台时调用，每次故障只通知一次，
+// 可用于推送告警。回调在写日志的 goroutine 上同步调用，不能再调用 mlog 的日志函数。
+func SetInternalErrorHandler(handler InternalErrorHandler) {
+	if handler == nil {
+		internalErrorHandler.Store(nil)
+		return
+	}
+	internalErrorHandler.Store(&handler)
+}
+
+// reportInternalError 通知一个内部错误
+func reportInternalError(err error) {
+	if handler := internalErrorHandler.Load(); handler != nil {
+		(*handler)(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "[mlog] %v\n", err)
+}
+
+// degradedFiles 当前降级为只输出控制台的日志文件数，从 0 变为 1 时通知一次
+var degradedFiles atomic.Int32
+
+// isDiskFull 判断写入错误是否由磁盘空间不足引起
+// lumberjack 打开新文件失败时不保留原始错误，只能按错误信息判断
+func isDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || strings.Contains(err.Error(), "no space left on device")
+}
+
+// writeDegraded 文件写入失败期间的写入：输出到控制台（已经同时输出控制台时不重复），
+// 每隔 diskFullProbeInterval 重新尝试写入文件，成功后恢复。调用方需持有 f.mu 的读锁
+func (f *fileWriteSyncer) writeDegraded(p []byte, now time.Time) (int, error) {
+	next := f.nextProbe.Load()
+	if now.UnixNano() >= next && f.nextProbe.CompareAndSwap(next, now.Add(diskFullProbeInterval).UnixNano()) {
+		if _, err := f.writeFile(p); err == nil {
+			f.sizeKnown.Store(false)
+			if f.degraded.CompareAndSwap(true, false) && degradedFiles.Add(-1) == 0 {
+				fmt.Fprintf(os.Stderr, "[mlog] 日志文件已恢复写入: %s\n", f.logger.Filename)
+			}
+			return len(p), nil
+		}
+	}
+	if !f.mirroredToConsole() {
+		consoleSink.Write(p)
+	}
+	return len(p), nil
+}
+
+// degrade 文件写入失败后降级为只输出控制台，所有日志文件中第一个失败时通知一次内部错误。调用方需持有 f.mu 的读锁
+func (f *fileWriteSyncer) degrade(p []byte, err error, now time.Time) (int, error) {
+	if f.degraded.CompareAndSwap(false, true) {
+		f.nextProbe.Store(now.Add(diskFullProbeInterval).UnixNano())
+		if degradedFiles.Add(1) == 1 {
+			reason := "写入失败"
+			if isDiskFull(err) {
+				reason = "磁盘已满"
+			}
+			reportInternalError(fmt.Errorf("日志文件 %s %s，暂时只输出到控制台，每 %v 重试: %w",
+				f.logger.Filename, reason, diskFullProbeInterval, err))
+		}
+	}
+	if !f.mirroredToConsole() {
+		consoleSink.Write(p)
+	}
+	return len(p), nil
+}
+
+// FileOutputDegraded 是否有日志文件因写入失败（如磁盘已满）而降级为只输出控制台
+func FileOutputDegraded() bool {
+	return degradedFiles.Load() > 0
+}
@@ -0,0 +1,64 @@
+package mlog
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/ai-mmo/lumberjack"
+)
+
+// TestFileWriteDegrade 测试文件写入失败后只通知一次、降级输出控制台，重新探测成功后恢复
+func TestFileWriteDegrade(t *testing.T) {
+	var reported []error
+	SetInternalErrorHandler(func(err error) { reported = append(reported, err) })
+	defer SetInternalErrorHandler(nil)
+
+	console, err := os.CreateTemp(t.TempDir(), "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer console.Close()
+	oldConsole := consoleSink
+	consoleSink = newConsoleWriteSyncer(console)
+	defer func() { consoleSink = oldConsole }()
+
+	filename := filepath.Join(t.TempDir(), "info.log")
+	f := &fileWriteSyncer{logger: &lumberjack.Logger{Filename: filename, MaxSize: 1}}
+	defer f.Close()
+
+	// 超过 MaxSize 的单次写入会被 lumberjack 拒绝，用来模拟写入失败
+	if n, err := f.Write(bytes.Repeat([]byte("x"), 2*1024*1024)); err != nil || n != 2*1024*1024 {
+		t.Fatalf("降级后写入应返回成功: %d %v", n, err)
+	}
+	f.Write([]byte("降级期间\n"))
+	if len(reported) != 1 || !FileOutputDegraded() {
+		t.Fatalf("应通知一次内部错误: %v", reported)
+	}
+	if data, _ := os.ReadFile(console.Name()); !strings.HasSuffix(string(data), "降级期间\n") {
+		t.Fatalf("降级期间应输出到控制台")
+	}
+
+	f.nextProbe.Store(0)
+	f.Write([]byte("恢复\n"))
+	if FileOutputDegraded() {
+		t.Fatal("探测写入成功后应恢复")
+	}
+	if data, _ := os.ReadFile(filename); string(data) != "恢复\n" {
+		t.Fatalf("恢复后文件内容错误: %q", data)
+	}
+}
+
+// TestIsDiskFull 测试识别磁盘已满错误
+func TestIsDiskFull(t *testing.T) {
+	if !isDiskFull(&os.PathError{Op: "write", Path: "info.log", Err: syscall.ENOSPC}) {
+		t.Fatal("ENOSPC 应识别为磁盘已满")
+	}
+	if isDiskFull(errors.New("permission denied")) {
+		t.Fatal("其他错误不应识别为磁盘已满")
+	}
+}
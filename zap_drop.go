@@ -0,0 +1,46 @@
+package mlog
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// DropHandler 异步日志丢弃回调
+type DropHandler func(level zapcore.Level, msg string)
+
+// dropHandler 当前的丢弃回调，未设置时为 nil
+var dropHandler atomic.Pointer[DropHandler]
+
+// SetDropHandler 设置异步日志丢弃回调，传入 nil 取消
+// 缓冲区满（async-drop-on-full）或关闭过程中丢弃日志时，在写日志的 goroutine 上同步调用，
+// 可用于累加业务自己的监控指标或推送告警。回调应尽量轻量，且不能再调用 mlog 的日志函数。
+func SetDropHandler(handler DropHandler) {
+	if handler == nil {
+		dropHandler.Store(nil)
+		return
+	}
+	dropHandler.Store(&handler)
+}
+
+// notifyDrop 通知一条日志被丢弃，同时计入 sampled_dropped
+func notifyDrop(level zapcore.Level, msg string) {
+	recordDropped(level, msg)
+	if handler := dropHandler.Load(); handler != nil {
+		(*handler)(level, msg)
+	}
+}
+
+// notifyDropBatch 通知一批日志被丢弃，同时计入 sampled_dropped
+func notifyDropBatch(entries []AsyncLogEntry) {
+	for i := range entries {
+		recordDropped(entries[i].Level, entries[i].Message)
+	}
+	handler := dropHandler.Load()
+	if handler == nil {
+		return
+	}
+	for i := range entries {
+		(*handler)(entries[i].Level, entries[i].Message)
+	}
+}
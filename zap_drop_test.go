@@ -0,0 +1,30 @@
+package mlog
+
+import (
+	"strconv"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestDropHandler 测试缓冲区满丢弃日志时调用丢弃回调
+func TestDropHandler(t *testing.T) {
+	var dropped []string
+	SetDropHandler(func(level zapcore.Level, msg string) {
+		if level == zapcore.InfoLevel {
+			dropped = append(dropped, msg)
+		}
+	})
+	defer SetDropHandler(nil)
+
+	al := &AsyncLogger{notify: make(chan struct{}, 1), done: make(chan struct{})}
+	al.setupQueues(2, true, nil)
+	for i := 0; i < 3; i++ {
+		al.enqueue(&AsyncLogEntry{Level: zapcore.InfoLevel, Message: strconv.Itoa(i)})
+	}
+	al.enqueueBatch([]AsyncLogEntry{{Level: zapcore.InfoLevel, Message: "batch"}})
+
+	if len(dropped) != 2 || dropped[0] != "2" || dropped[1] != "batch" {
+		t.Fatalf("丢弃回调结果错误: %v", dropped)
+	}
+}
@@ -0,0 +1,131 @@
+package mlog
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// sampledDroppedKey 被采样或去重丢弃的条数字段的键名
+const sampledDroppedKey = "sampled_dropped"
+
+// maxDroppedKeys 最多记录的消息数，超过时淘汰最久未被丢弃的消息，避免只被丢弃、不再出现的消息长期占用内存
+const maxDroppedKeys = 4096
+
+// droppedEvicted 因计数表已满被淘汰、无法再附加到日志上的丢弃条数
+var droppedEvicted atomic.Uint64
+
+// droppedCounter 按级别和消息统计尚未报告的丢弃条数，
+// Core 采样、去重、异步队列溢出和异步自适应采样共用
+type droppedCounter struct {
+	mu     sync.Mutex
+	size   atomic.Int64 // 当前记录的消息数，为 0 时 take 不加锁
+	counts map[dedupKey]*list.Element
+	order  *list.List // 按最近一次丢弃的时间排序，最久的在前
+}
+
+// droppedEntry 一条消息尚未报告的丢弃条数
+type droppedEntry struct {
+	key   dedupKey
+	count int
+}
+
+// activeDropped 当前日志器的丢弃计数器，未初始化时为 nil
+var activeDropped atomic.Pointer[droppedCounter]
+
+// newDroppedCounter 创建计数器
+// 日志器总是创建计数器，热更新开启采样后丢弃的条数同样能附加到日志上；没有丢弃时写日志只多一次原子读
+func newDroppedCounter() *droppedCounter {
+	return &droppedCounter{counts: make(map[dedupKey]*list.Element), order: list.New()}
+}
+
+// recordDropped 在当前日志器的计数器中记录一条在 Core 之前被丢弃的日志
+func recordDropped(level zapcore.Level, message string) {
+	activeDropped.Load().add(level, message)
+}
+
+// add 记录一条被丢弃的日志，d 为 nil 时不做处理
+func (d *droppedCounter) add(level zapcore.Level, message string) {
+	if d == nil {
+		return
+	}
+	key := dedupKey{level: level, message: message}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if elem, ok := d.counts[key]; ok {
+		elem.Value.(*droppedEntry).count++
+		d.order.MoveToBack(elem)
+		return
+	}
+	if len(d.counts) >= maxDroppedKeys {
+		oldest := d.order.Remove(d.order.Front()).(*droppedEntry)
+		delete(d.counts, oldest.key)
+		droppedEvicted.Add(uint64(oldest.count))
+	}
+	d.counts[key] = d.order.PushBack(&droppedEntry{key: key, count: 1})
+	d.size.Store(int64(len(d.counts)))
+}
+
+// take 取出并清零相同级别和消息的丢弃条数
+func (d *droppedCounter) take(level zapcore.Level, message string) int {
+	if d.size.Load() == 0 {
+		return 0
+	}
+	key := dedupKey{level: level, message: message}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	elem, ok := d.counts[key]
+	if !ok {
+		return 0
+	}
+	d.order.Remove(elem)
+	delete(d.counts, key)
+	d.size.Store(int64(len(d.counts)))
+	return elem.Value.(*droppedEntry).count
+}
+
+// SampledDroppedEvicted 获取因计数表已满被淘汰的丢弃条数，这部分丢弃没有体现在 sampled_dropped 字段中
+func SampledDroppedEvicted() uint64 {
+	return droppedEvicted.Load()
+}
+
+// droppedCore 位于采样和去重之后、所有输出之前，
+// 为每条实际写出的日志附加此前被丢弃的相同级别和消息的条数（sampled_dropped 字段），
+// 丢弃包括 Core 采样、去重合并、异步队列满和异步自适应采样，
+// 看板按 1 + sampled_dropped 累加即可还原真实的事件频率；没有丢弃时不添加字段
+type droppedCore struct {
+	zapcore.Core
+	counter *droppedCounter
+}
+
+// newDroppedCore 包装 Core，counter 为 nil 时原样返回
+func newDroppedCore(core zapcore.Core, counter *droppedCounter) zapcore.Core {
+	if counter == nil {
+		return core
+	}
+	return &droppedCore{Core: core, counter: counter}
+}
+
+func (c *droppedCore) With(fields []zapcore.Field) zapcore.Core {
+	return &droppedCore{Core: c.Core.With(fields), counter: c.counter}
+}
+
+func (c *droppedCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *droppedCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	n := c.counter.take(entry.Level, entry.Message)
+	if n == 0 {
+		return writeChecked(c.Core, entry, fields)
+	}
+	stamped := make([]zapcore.Field, len(fields), len(fields)+1)
+	copy(stamped, fields)
+	return writeChecked(c.Core, entry, append(stamped, zap.Int(sampledDroppedKey, n)))
+}
@@ -0,0 +1,121 @@
+package mlog
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestSampledDropped 测试采样和去重丢弃的条数附加到之后写出的相同日志上，其他消息不受影响
+func TestSampledDropped(t *testing.T) {
+	config := ZapConfig{Sampling: map[string]SamplingConfig{"info": {Initial: 1, Thereafter: 3}}, EnableDedup: true}
+	dropped := newDroppedCounter()
+	observed, logs := observer.New(zapcore.DebugLevel)
+	core := newLevelSampledCore(newDroppedCore(observed, dropped), config.Sampling, dropped)
+	dedup := newDedupCore(core, 50*time.Millisecond)
+	dedup.state.dropped = dropped
+	logger := zap.New(dedup)
+
+	// 采样：第 1 条写出，第 2、3 条丢弃，第 4 条写出并带有 sampled_dropped=2
+	for i := 0; i < 4; i++ {
+		zap.New(core).Info("心跳")
+	}
+	zap.New(core).Info("其他消息")
+	entries := logs.TakeAll()
+	if len(entries) != 3 || entries[0].ContextMap()[sampledDroppedKey] != nil || entries[1].ContextMap()[sampledDroppedKey] != int64(2) ||
+		entries[2].ContextMap()[sampledDroppedKey] != nil {
+		t.Fatalf("采样结果错误: %v", entries)
+	}
+
+	// 去重：窗口内合并的 3 条体现在汇总日志上
+	for i := 0; i < 4; i++ {
+		logger.Error("存档失败")
+	}
+	time.Sleep(60 * time.Millisecond)
+	logger.Sync()
+	entries = logs.TakeAll()
+	if len(entries) != 2 || entries[1].ContextMap()["repeat_count"] != int64(3) || entries[1].ContextMap()[sampledDroppedKey] != int64(3) {
+		t.Fatalf("去重结果错误: %v", entries)
+	}
+}
+
+// TestAsyncDroppedCounted 测试异步队列满和自适应采样丢弃的日志同样计入 sampled_dropped
+func TestAsyncDroppedCounted(t *testing.T) {
+	dropped := newDroppedCounter()
+	activeDropped.Store(dropped)
+	defer activeDropped.Store(nil)
+
+	al := &AsyncLogger{notify: make(chan struct{}, 1), done: make(chan struct{})}
+	al.setupQueues(2, true, nil)
+	for i := 0; i < 4; i++ {
+		al.enqueue(&AsyncLogEntry{Level: zapcore.InfoLevel, Message: "心跳"})
+	}
+	al.enqueueBatch([]AsyncLogEntry{{Level: zapcore.InfoLevel, Message: "心跳"}})
+
+	// 自适应采样：队列已满，每 10 条保留 1 条，跳过的 9 条和保留后因队列满丢弃的 1 条都计入
+	observed, logs := observer.New(zapcore.DebugLevel)
+	al.core = observed
+	al.sampler = newAdaptiveSampler(AdaptiveSamplingConfig{Enable: true})
+	for i := 0; i < 10; i++ {
+		al.logAsyncAt(zapcore.InfoLevel, zapcore.EntryCaller{}, "心跳", nil)
+	}
+
+	logger := zap.New(newDroppedCore(observed, dropped))
+	logger.Info("心跳")
+	logger.Info("心跳")
+	entries := logs.TakeAll()
+	if len(entries) != 2 || entries[0].ContextMap()[sampledDroppedKey] != int64(13) || entries[1].ContextMap()[sampledDroppedKey] != nil {
+		t.Fatalf("异步丢弃计数错误: %v", entries)
+	}
+}
+
+// TestDroppedCounterEvict 测试计数表已满时只淘汰最久未被丢弃的消息，并统计淘汰损失的条数
+func TestDroppedCounterEvict(t *testing.T) {
+	d := newDroppedCounter()
+	before := SampledDroppedEvicted()
+	d.add(zapcore.InfoLevel, "最早")
+	d.add(zapcore.InfoLevel, "最早")
+	d.add(zapcore.InfoLevel, "较早")
+	for i := 0; i < maxDroppedKeys-2; i++ {
+		d.add(zapcore.DebugLevel, strconv.Itoa(i))
+	}
+	// "较早" 再次被丢弃后变为最近，表满时淘汰 "最早"
+	d.add(zapcore.InfoLevel, "较早")
+	d.add(zapcore.InfoLevel, "新消息")
+
+	if got := SampledDroppedEvicted() - before; got != 2 {
+		t.Fatalf("应统计淘汰的 2 条丢弃，实际 %d", got)
+	}
+	if d.take(zapcore.InfoLevel, "最早") != 0 || d.take(zapcore.InfoLevel, "较早") != 2 ||
+		d.take(zapcore.InfoLevel, "新消息") != 1 || d.take(zapcore.DebugLevel, "0") != 1 {
+		t.Fatal("只应淘汰最久未被丢弃的消息")
+	}
+}
+
+// TestSampledDroppedHotSampling 测试初始化时未配置采样，热更新开启采样后丢弃的条数同样附加到日志上
+func TestSampledDroppedHotSampling(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, SingleFile: true, Format: "json"}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	applyHotConfig(func(c *ZapConfig) {
+		c.Sampling = map[string]SamplingConfig{"info": {Initial: 1, Thereafter: 3}}
+	})
+	for i := 0; i < 4; i++ {
+		InfoW("心跳")
+	}
+	Close()
+
+	content := readLogFile(t, filepath.Join(dir, "2/gate/all.log"))
+	if !strings.Contains(content, `"sampled_dropped":2`) {
+		t.Fatalf("热更新开启采样后应附加 sampled_dropped: %s", content)
+	}
+}
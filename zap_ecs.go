@@ -0,0 +1,91 @@
+package mlog
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// FormatECS Format 配置为 ecs 时按 Elastic Common Schema 输出 JSON，日志可以直接导入 Elasticsearch 并用 Kibana 的默认视图查看
+const FormatECS = "ecs"
+
+// ecsVersion 输出的 ECS 版本，写入每条日志的 ecs.version 字段
+const ecsVersion = "8.11.0"
+
+// ecsEncoder ECS JSON 编码器
+// 时间、级别、消息和日志器名称使用 ECS 字段名，调用位置拆分为 log.origin.file.name、log.origin.file.line 和 log.origin.function，
+// zap.Error 添加的 error 字段转换为 error.message 和 error.type（ECS 中 error 是对象，字符串值会导致映射冲突），
+// ErrField 的错误链另外输出 error.cause，错误的调用栈在日志本身没有调用栈时写入 error.stack_trace
+type ecsEncoder struct {
+	zapcore.Encoder
+	relativePath bool
+}
+
+// newECSEncoder 创建 ECS JSON 编码器
+func newECSEncoder(c *ZapConfig) zapcore.Encoder {
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		TimeKey:       "@timestamp",
+		NameKey:       "log.logger",
+		LevelKey:      "log.level",
+		MessageKey:    "message",
+		StacktraceKey: "error.stack_trace",
+		LineEnding:    zapcore.DefaultLineEnding,
+		EncodeTime: func(t time.Time, encoder zapcore.PrimitiveArrayEncoder) {
+			encoder.AppendString(t.UTC().Format("2006-01-02T15:04:05.000Z"))
+		},
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+	})
+	enc.AddString("ecs.version", ecsVersion)
+	return &ecsEncoder{Encoder: enc, relativePath: c.UseRelativePath}
+}
+
+// addService 添加 service.name 和 service.id 字段
+func (e *ecsEncoder) addService(serviceName string, serviceID uint64) {
+	if serviceName != "" {
+		e.AddString("service.name", serviceName)
+	}
+	if serviceID != 0 {
+		e.AddString("service.id", strconv.FormatUint(serviceID, 10))
+	}
+}
+
+func (e *ecsEncoder) Clone() zapcore.Encoder {
+	return &ecsEncoder{Encoder: e.Encoder.Clone(), relativePath: e.relativePath}
+}
+
+func (e *ecsEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	converted := make([]zapcore.Field, 0, len(fields)+3)
+	for _, f := range fields {
+		if err, ok := f.Interface.(error); ok && f.Type == zapcore.ErrorType && f.Key == "error" {
+			converted = append(converted, zap.String("error.message", err.Error()), zap.String("error.type", fmt.Sprintf("%T", err)))
+			continue
+		}
+		if chain, ok := f.Interface.(errorChain); ok && f.Type == zapcore.InlineMarshalerType {
+			converted = append(converted, zap.String("error.message", chain.err.Error()), zap.String("error.type", fmt.Sprintf("%T", chain.err)))
+			if causes := errorCauses(chain.err); len(causes) > 0 {
+				converted = append(converted, zap.Strings("error.cause", causes))
+			}
+			if stack := errorStack(chain.err); stack != "" && entry.Stack == "" {
+				entry.Stack = stack
+			}
+			continue
+		}
+		converted = append(converted, f)
+	}
+	if entry.Caller.Defined {
+		file := entry.Caller.File
+		if e.relativePath {
+			file = getRelativePath(file)
+		}
+		converted = append(converted, zap.String("log.origin.file.name", file), zap.Int("log.origin.file.line", entry.Caller.Line))
+		if entry.Caller.Function != "" {
+			converted = append(converted, zap.String("log.origin.function", entry.Caller.Function))
+		}
+	}
+	return e.Encoder.EncodeEntry(entry, converted)
+}
@@ -0,0 +1,58 @@
+package mlog
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestECSEncoder 测试 ecs 格式按 ECS 字段名输出时间、级别、调用位置、服务和错误
+func TestECSEncoder(t *testing.T) {
+	Close()
+	// 格式配置会影响之后直接使用全局配置的测试，结束时恢复
+	oldConfig := zapConfig
+	defer func() { zapConfig = oldConfig }()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: FormatECS, ShowLine: true}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	ErrorW("登录失败", zap.Error(errors.New("密码错误")), zap.Int("player", 10086))
+	Flush()
+
+	data, err := os.ReadFile(filepath.Join(dir, "2", "gate", "error.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &entry); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]any{
+		"message":       "登录失败",
+		"log.level":     "error",
+		"service.name":  "gate",
+		"service.id":    "2",
+		"ecs.version":   ecsVersion,
+		"error.message": "密码错误",
+		"player":        float64(10086),
+	} {
+		if entry[key] != want {
+			t.Fatalf("%s 应为 %v: %v", key, want, entry)
+		}
+	}
+	if ts, _ := entry["@timestamp"].(string); !strings.HasSuffix(ts, "Z") {
+		t.Fatalf("@timestamp 应为 UTC 时间: %v", entry["@timestamp"])
+	}
+	if file, _ := entry["log.origin.file.name"].(string); !strings.HasSuffix(file, "zap_ecs_test.go") || entry["log.origin.file.line"] == nil {
+		t.Fatalf("调用位置错误: %v", entry)
+	}
+	if _, ok := entry["error"]; ok {
+		t.Fatalf("不应输出字符串类型的 error 字段: %v", entry)
+	}
+}
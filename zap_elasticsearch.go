@@ -0,0 +1,215 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ElasticsearchConfig Elasticsearch 输出配置
+type ElasticsearchConfig struct {
+	Enable      bool     `mapstructure:"enable" json:"enable" yaml:"enable"`                   // 启用 Elasticsearch 输出
+	Addresses   []string `mapstructure:"addresses" json:"addresses" yaml:"addresses"`          // 节点地址，如 http://127.0.0.1:9200，失败时轮换
+	IndexPrefix string   `mapstructure:"index-prefix" json:"index-prefix" yaml:"index-prefix"` // 索引前缀（默认 mlog-<服务名>），按天生成 <前缀>-2006.01.02 索引
+	Username    string   `mapstructure:"username" json:"username" yaml:"username"`             // Basic 认证用户名
+	Password    string   `mapstructure:"password" json:"password" yaml:"password"`             // Basic 认证密码
+	APIKey      string   `mapstructure:"api-key" json:"api-key" yaml:"api-key"`                // API Key 认证（优先于用户名密码）
+	// 首次发送前创建或更新索引模板，为 ts、level 等字段设置映射
+	ManageTemplate    bool `mapstructure:"manage-template" json:"manage-template" yaml:"manage-template"`
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// esBulkTimeFormat 按天划分索引的日期格式
+const esBulkTimeFormat = "2006.01.02"
+
+// elasticsearchClient Elasticsearch bulk 接口客户端
+type elasticsearchClient struct {
+	addresses   []string
+	next        uint32 // 下一次请求使用的节点下标
+	indexPrefix string
+	username    string
+	password    string
+	apiKey      string
+	httpClient  *http.Client
+
+	manageTemplate bool
+	templateMutex  sync.Mutex
+	templateReady  bool
+}
+
+// newElasticsearchCore 创建 Elasticsearch 输出 Core
+func newElasticsearchCore(cfg ElasticsearchConfig, serviceName string) (*remoteCore, error) {
+	if len(cfg.Addresses) == 0 {
+		return nil, errors.New("未配置 Elasticsearch 节点地址")
+	}
+	prefix := cfg.IndexPrefix
+	if prefix == "" {
+		prefix = "mlog-" + serviceName
+	}
+	client := &elasticsearchClient{
+		addresses:      make([]string, len(cfg.Addresses)),
+		indexPrefix:    strings.ToLower(strings.TrimSuffix(prefix, "-")),
+		username:       cfg.Username,
+		password:       cfg.Password,
+		apiKey:         cfg.APIKey,
+		httpClient:     &http.Client{Timeout: 10 * time.Second},
+		manageTemplate: cfg.ManageTemplate,
+	}
+	for i, addr := range cfg.Addresses {
+		client.addresses[i] = strings.TrimSuffix(addr, "/")
+	}
+
+	batcher := newRemoteBatcher("elasticsearch", cfg.RemoteBatchConfig, client.bulk)
+	addSinkCloser(batcher)
+	return newRemoteCore(batcher, "", ""), nil
+}
+
+// indexName 返回日志所属的按天索引名
+func (c *elasticsearchClient) indexName(t time.Time) string {
+	return c.indexPrefix + "-" + t.Format(esBulkTimeFormat)
+}
+
+// do 发送请求，连接失败时轮换到下一个节点
+func (c *elasticsearchClient) do(ctx context.Context, method, path string, body []byte, contentType string) (*http.Response, error) {
+	var errs []error
+	for range c.addresses {
+		idx := atomic.LoadUint32(&c.next) % uint32(len(c.addresses))
+		req, err := http.NewRequestWithContext(ctx, method, c.addresses[idx]+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", contentType)
+		if c.apiKey != "" {
+			req.Header.Set("Authorization", "ApiKey "+c.apiKey)
+		} else if c.username != "" {
+			req.SetBasicAuth(c.username, c.password)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, err)
+		atomic.CompareAndSwapUint32(&c.next, idx, idx+1)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// ensureTemplate 创建或更新索引模板，成功一次后不再重复
+func (c *elasticsearchClient) ensureTemplate(ctx context.Context) error {
+	c.templateMutex.Lock()
+	defer c.templateMutex.Unlock()
+	if !c.manageTemplate || c.templateReady {
+		return nil
+	}
+	template := map[string]any{
+		"index_patterns": []string{c.indexPrefix + "-*"},
+		"template": map[string]any{
+			"mappings": map[string]any{
+				"properties": map[string]any{
+					"ts":         map[string]any{"type": "date"},
+					"level":      map[string]any{"type": "keyword"},
+					"logger":     map[string]any{"type": "keyword"},
+					"caller":     map[string]any{"type": "keyword"},
+					"msg":        map[string]any{"type": "text"},
+					"stacktrace": map[string]any{"type": "text", "index": false},
+				},
+			},
+		},
+	}
+	body, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodPut, "/_index_template/"+c.indexPrefix, body, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("创建索引模板失败: %s %s", resp.Status, msg)
+	}
+	c.templateReady = true
+	return nil
+}
+
+// esBulkResponse bulk 接口响应中需要的部分
+type esBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// bulk 通过 bulk 接口批量写入日志
+// 429 和 5xx 的条目返回给批量发送器重试，其余失败的条目（如映射冲突）直接丢弃
+func (c *elasticsearchClient) bulk(ctx context.Context, batch []remoteRecord) error {
+	if err := c.ensureTemplate(ctx); err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	for i := range batch {
+		body.WriteString(`{"index":{"_index":"`)
+		body.WriteString(c.indexName(batch[i].Entry.Time))
+		body.WriteString("\"}}\n")
+		body.Write(batch[i].Data)
+		body.WriteByte('\n')
+	}
+
+	resp, err := c.do(ctx, http.MethodPost, "/_bulk", body.Bytes(), "application/x-ndjson")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("bulk 请求失败: %s %s", resp.Status, msg)
+	}
+
+	var result esBulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("解析 bulk 响应失败: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+
+	partial := &remotePartialError{}
+	for i, item := range result.Items {
+		if i >= len(batch) {
+			break
+		}
+		for _, r := range item {
+			switch {
+			case r.Status < 300:
+			case r.Status == http.StatusTooManyRequests || r.Status >= 500:
+				partial.Retry = append(partial.Retry, batch[i])
+			default:
+				partial.Rejected++
+				if partial.Err == nil {
+					partial.Err = fmt.Errorf("%s: %s", r.Error.Type, r.Error.Reason)
+				}
+			}
+		}
+	}
+	if partial.Err == nil {
+		partial.Err = errors.New("部分条目写入失败")
+	}
+	return partial
+}
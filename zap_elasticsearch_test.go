@@ -0,0 +1,94 @@
+package mlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestElasticsearchBulk 测试按天索引、索引模板和部分失败重试
+func TestElasticsearchBulk(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		template  bool
+		indices   []string
+		docs      []string
+		bulkCalls int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPut && r.URL.Path == "/_index_template/mlog-game" {
+			template = r.Header.Get("Authorization") == "ApiKey secret"
+			w.Write([]byte(`{"acknowledged":true}`))
+			return
+		}
+		bulkCalls++
+		var items []string
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var action struct {
+				Index struct {
+					Index string `json:"_index"`
+				} `json:"index"`
+			}
+			json.Unmarshal(scanner.Bytes(), &action)
+			indices = append(indices, action.Index.Index)
+			scanner.Scan()
+			docs = append(docs, scanner.Text())
+			status := 201
+			switch {
+			case strings.Contains(scanner.Text(), "限流") && bulkCalls == 1:
+				status = 429
+			case strings.Contains(scanner.Text(), "映射冲突"):
+				status = 400
+			}
+			items = append(items, `{"index":{"status":`+strconv.Itoa(status)+`,"error":{"type":"x","reason":"y"}}}`)
+		}
+		w.Write([]byte(`{"errors":true,"items":[` + strings.Join(items, ",") + `]}`))
+	}))
+	defer server.Close()
+
+	core, err := newElasticsearchCore(ElasticsearchConfig{
+		Addresses:         []string{server.URL + "/"},
+		APIKey:            "secret",
+		ManageTemplate:    true,
+		RemoteBatchConfig: RemoteBatchConfig{BatchSize: 10, RetryBackoffMs: 1},
+	}, "game")
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	logger := zap.New(core)
+	logger.Info("正常")
+	logger.Info("限流")
+	logger.Info("映射冲突")
+	core.batcher.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !template {
+		t.Fatal("应先创建索引模板")
+	}
+	wantIndex := "mlog-game-" + time.Now().Format("2006.01.02")
+	for _, index := range indices {
+		if index != wantIndex {
+			t.Fatalf("索引名错误: %s", index)
+		}
+	}
+	if bulkCalls != 2 || len(docs) != 4 || !strings.Contains(docs[3], "限流") {
+		t.Fatalf("只应重试被限流的条目: calls=%d docs=%v", bulkCalls, docs)
+	}
+	if stats := core.batcher.stats(); stats.Sent != 2 || stats.Failed != 1 {
+		t.Fatalf("统计错误: %+v", stats)
+	}
+}
@@ -0,0 +1,394 @@
+package mlog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 邮件摘要的默认参数
+const (
+	defaultEmailPort          = 587
+	defaultEmailIntervalMin   = 10
+	defaultEmailMaxEntries    = 50
+	defaultEmailSubjectPrefix = "[mlog]"
+	// emailGatherDelay 距上一封邮件已超过发送间隔时，收到第一条日志后等待的时间，把同一波故障合并到一封邮件
+	emailGatherDelay = 10 * time.Second
+	emailSendTimeout = 30 * time.Second
+)
+
+// EmailDigestConfig Disaster 邮件摘要配置
+// 适合没有值班告警系统的小团队：Disaster/ExitGame 日志先收集起来，每 N 分钟最多发送一封汇总邮件
+type EmailDigestConfig struct {
+	Enable   bool     `mapstructure:"enable" json:"enable" yaml:"enable"`       // 启用邮件摘要
+	Host     string   `mapstructure:"host" json:"host" yaml:"host"`             // SMTP 服务器地址，如 smtp.exmail.qq.com
+	Port     int      `mapstructure:"port" json:"port" yaml:"port"`             // SMTP 端口（默认 587，465 使用 SSL 直连）
+	Username string   `mapstructure:"username" json:"username" yaml:"username"` // 认证用户名（为空时不认证）
+	Password string   `mapstructure:"password" json:"password" yaml:"password"` // 认证密码或授权码
+	From     string   `mapstructure:"from" json:"from" yaml:"from"`             // 发件人（默认同 Username）
+	To       []string `mapstructure:"to" json:"to" yaml:"to"`                   // 收件人列表
+	// 邮件标题前缀（默认 [mlog]）
+	SubjectPrefix string `mapstructure:"subject-prefix" json:"subject-prefix" yaml:"subject-prefix"`
+	IntervalMin   int    `mapstructure:"interval-min" json:"interval-min" yaml:"interval-min"` // 两封邮件的最小间隔（分钟，默认 10）
+	MaxEntries    int    `mapstructure:"max-entries" json:"max-entries" yaml:"max-entries"`    // 每封邮件最多列出的日志条数（默认 50），超出部分只计数
+	SSL           bool   `mapstructure:"ssl" json:"ssl" yaml:"ssl"`                            // 使用 SSL 直连（端口 465 时自动启用），否则在服务器支持时使用 STARTTLS
+}
+
+// emailDigestEntry 邮件摘要中的一条日志
+type emailDigestEntry struct {
+	Time    time.Time
+	Caller  string
+	Message string
+	Fields  map[string]any
+	Stack   string
+}
+
+// emailDigest 收集 Disaster 日志并按间隔发送摘要邮件，同一个邮件 Core 的所有副本共享
+type emailDigest struct {
+	service    string
+	serviceID  uint64
+	host       string
+	prefix     string
+	interval   time.Duration
+	maxEntries int
+	send       func(subject, body string) error
+
+	mu       sync.Mutex
+	entries  []emailDigestEntry
+	overflow int // 超出 maxEntries 未列出的条数
+	lastSent time.Time
+
+	notify    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// emailDigestCore 将 Disaster 日志写入邮件摘要的 zapcore.Core
+type emailDigestCore struct {
+	zapcore.LevelEnabler
+	digest    *emailDigest
+	fields    []zapcore.Field // 通过 With 附加的字段
+	emergency bool            // With 附加了 directory=emergency
+}
+
+// newEmailDigestCore 创建邮件摘要 Core
+// 只收集 directory 为 emergency 的 Error 及以上级别日志（Disaster 和 ExitGame）
+func newEmailDigestCore(cfg EmailDigestConfig, serviceName string, serviceID uint64) (*emailDigestCore, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("未配置 SMTP 服务器地址")
+	}
+	if len(cfg.To) == 0 {
+		return nil, errors.New("未配置邮件收件人")
+	}
+	from := cfg.From
+	if from == "" {
+		from = cfg.Username
+	}
+	if from == "" {
+		return nil, errors.New("未配置邮件发件人")
+	}
+	port := positiveOr(cfg.Port, defaultEmailPort)
+	prefix := cfg.SubjectPrefix
+	if prefix == "" {
+		prefix = defaultEmailSubjectPrefix
+	}
+
+	mailer := &smtpMailer{
+		host:     cfg.Host,
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		ssl:      cfg.SSL || port == 465,
+		username: cfg.Username,
+		password: cfg.Password,
+		from:     from,
+		to:       cfg.To,
+	}
+	host, _ := os.Hostname()
+	d := &emailDigest{
+		service:    serviceName,
+		serviceID:  serviceID,
+		host:       host,
+		prefix:     prefix,
+		interval:   time.Duration(positiveOr(cfg.IntervalMin, defaultEmailIntervalMin)) * time.Minute,
+		maxEntries: positiveOr(cfg.MaxEntries, defaultEmailMaxEntries),
+		send:       mailer.send,
+		notify:     make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	d.wg.Add(1)
+	go d.run()
+	addSinkCloser(d)
+	return &emailDigestCore{LevelEnabler: zapcore.ErrorLevel, digest: d}, nil
+}
+
+func (c *emailDigestCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(append([]zapcore.Field(nil), c.fields...), fields...)
+	clone.emergency = c.emergency || hasEmergencyField(fields)
+	return &clone
+}
+
+func (c *emailDigestCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *emailDigestCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if !c.emergency && !hasEmergencyField(fields) {
+		return nil
+	}
+	item := emailDigestEntry{
+		Time:    entry.Time,
+		Message: entry.Message,
+		Stack:   entry.Stack,
+	}
+	if entry.Caller.Defined {
+		item.Caller = entry.Caller.TrimmedPath()
+	}
+	if item.Stack == "" {
+		// Logger 未开启 AddStacktrace 时在这里取当前协程的调用栈
+		item.Stack = string(debug.Stack())
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	for i := range c.fields {
+		c.fields[i].AddTo(enc)
+	}
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	delete(enc.Fields, "directory")
+	if len(enc.Fields) > 0 {
+		item.Fields = enc.Fields
+	}
+	c.digest.add(item)
+	return nil
+}
+
+func (c *emailDigestCore) Sync() error {
+	return nil
+}
+
+// add 收集一条日志，超出每封邮件的上限时只计数
+func (d *emailDigest) add(item emailDigestEntry) {
+	d.mu.Lock()
+	if len(d.entries) < d.maxEntries {
+		d.entries = append(d.entries, item)
+	} else {
+		d.overflow++
+	}
+	d.mu.Unlock()
+	select {
+	case d.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run 后台发送循环，两封邮件之间至少间隔 interval
+func (d *emailDigest) run() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.notify:
+		case <-d.done:
+			d.flush()
+			return
+		}
+		d.mu.Lock()
+		wait := time.Until(d.lastSent.Add(d.interval))
+		d.mu.Unlock()
+		if wait < emailGatherDelay {
+			wait = emailGatherDelay
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-d.done:
+			timer.Stop()
+			d.flush()
+			return
+		}
+		d.flush()
+	}
+}
+
+// flush 发送已收集的日志，发送失败时放回队列等下一个间隔重试
+func (d *emailDigest) flush() {
+	d.mu.Lock()
+	entries, overflow := d.entries, d.overflow
+	d.entries, d.overflow = nil, 0
+	d.lastSent = time.Now()
+	d.mu.Unlock()
+	if len(entries) == 0 && overflow == 0 {
+		return
+	}
+
+	subject, body := d.compose(entries, overflow)
+	if err := d.send(subject, body); err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] 发送 Disaster 邮件摘要失败: %v\n", err)
+		d.mu.Lock()
+		merged := append(entries, d.entries...)
+		if len(merged) > d.maxEntries {
+			overflow += len(merged) - d.maxEntries
+			merged = merged[:d.maxEntries]
+		}
+		d.entries = merged
+		d.overflow += overflow
+		d.mu.Unlock()
+		select {
+		case d.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// compose 生成邮件标题和正文
+func (d *emailDigest) compose(entries []emailDigestEntry, overflow int) (string, string) {
+	total := len(entries) + overflow
+	subject := fmt.Sprintf("%s %s(%d) Disaster x %d", d.prefix, d.service, d.serviceID, total)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "服务: %s(%d) @ %s\n", d.service, d.serviceID, d.host)
+	if len(entries) > 0 {
+		fmt.Fprintf(&sb, "时间: %s ~ %s\n",
+			entries[0].Time.Format("2006-01-02 15:04:05.000"),
+			entries[len(entries)-1].Time.Format("2006-01-02 15:04:05.000"))
+	}
+	fmt.Fprintf(&sb, "共 %d 条", total)
+	if overflow > 0 {
+		fmt.Fprintf(&sb, "（另有 %d 条超出单封邮件上限未列出）", overflow)
+	}
+	sb.WriteString("\n")
+	for i, e := range entries {
+		fmt.Fprintf(&sb, "\n==== [%d] %s", i+1, e.Time.Format("2006-01-02 15:04:05.000"))
+		if e.Caller != "" {
+			fmt.Fprintf(&sb, " %s", e.Caller)
+		}
+		fmt.Fprintf(&sb, "\n%s\n", e.Message)
+		if len(e.Fields) > 0 {
+			keys := make([]string, 0, len(e.Fields))
+			for k := range e.Fields {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Fprintf(&sb, "%s: %v\n", k, e.Fields[k])
+			}
+		}
+		if e.Stack != "" {
+			fmt.Fprintf(&sb, "调用栈:\n%s\n", strings.TrimRight(e.Stack, "\n"))
+		}
+	}
+	return subject, sb.String()
+}
+
+// Close 发送剩余的日志并停止后台协程
+func (d *emailDigest) Close() error {
+	d.closeOnce.Do(func() {
+		close(d.done)
+	})
+	d.wg.Wait()
+	return nil
+}
+
+// smtpMailer SMTP 发信客户端
+type smtpMailer struct {
+	host     string
+	addr     string
+	ssl      bool
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// send 发送一封纯文本邮件
+func (m *smtpMailer) send(subject, body string) error {
+	dialer := &net.Dialer{Timeout: netSinkDialTimeout}
+	var conn net.Conn
+	var err error
+	if m.ssl {
+		conn, err = tls.DialWithDialer(dialer, "tcp", m.addr, &tls.Config{ServerName: m.host})
+	} else {
+		conn, err = dialer.Dial("tcp", m.addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(emailSendTimeout))
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if !m.ssl {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+				return err
+			}
+		}
+	}
+	if m.username != "" {
+		// PlainAuth 只允许在 TLS 连接或本机地址上发送密码
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(m.from); err != nil {
+		return err
+	}
+	for _, to := range m.to {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildEmailMessage(m.from, m.to, subject, body, time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildEmailMessage 生成 UTF-8 纯文本邮件，标题按 RFC 2047 编码，正文使用 base64
+func buildEmailMessage(from string, to []string, subject, body string, now time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76])
+		buf.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded)
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,104 @@
+package mlog
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"mime"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TestEmailDigest 测试只收集 Disaster 日志、超出上限只计数，关闭时发送一封汇总邮件
+func TestEmailDigest(t *testing.T) {
+	core, err := newEmailDigestCore(EmailDigestConfig{Host: "127.0.0.1", From: "mlog@example.com", To: []string{"ops@example.com"}, MaxEntries: 2}, "game", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		mu      sync.Mutex
+		bodies  []string
+		subject string
+	)
+	core.digest.send = func(s, body string) error {
+		mu.Lock()
+		subject = s
+		bodies = append(bodies, body)
+		mu.Unlock()
+		return nil
+	}
+	core.digest.interval = time.Hour
+
+	log := zap.New(core)
+	emergency := zap.String("directory", emergencyDirectory)
+	log.Error("普通错误")
+	log.Warn("背包数据异常", emergency)
+	log.Error("存档写入失败", emergency, zap.Int64("player", 1001))
+	log.With(emergency).Error("数据库断开")
+	log.Error("存档写入失败", emergency, zap.Int64("player", 1002))
+	core.digest.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 {
+		t.Fatalf("邮件数错误: %d", len(bodies))
+	}
+	if subject != "[mlog] game(3) Disaster x 3" {
+		t.Fatalf("邮件标题错误: %s", subject)
+	}
+	body := bodies[0]
+	for _, want := range []string{"存档写入失败", "player: 1001", "数据库断开", "另有 1 条", "调用栈:"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("邮件正文缺少 %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "普通错误") || strings.Contains(body, "背包数据异常") || strings.Contains(body, "player: 1002") {
+		t.Fatalf("邮件正文包含不应收集的日志:\n%s", body)
+	}
+}
+
+// TestEmailDigestRetry 测试发送失败的日志保留到下一封邮件
+func TestEmailDigestRetry(t *testing.T) {
+	d := &emailDigest{service: "game", prefix: defaultEmailSubjectPrefix, maxEntries: 10, notify: make(chan struct{}, 1)}
+	var bodies []string
+	d.send = func(_, body string) error {
+		bodies = append(bodies, body)
+		if len(bodies) == 1 {
+			return errors.New("smtp unavailable")
+		}
+		return nil
+	}
+	d.add(emailDigestEntry{Time: time.Now(), Message: "第一条"})
+	d.flush()
+	d.add(emailDigestEntry{Time: time.Now(), Message: "第二条"})
+	d.flush()
+	if len(bodies) != 2 || !strings.Contains(bodies[1], "第一条") || !strings.Contains(bodies[1], "第二条") {
+		t.Fatalf("重试邮件内容错误: %q", bodies)
+	}
+}
+
+// TestBuildEmailMessage 测试邮件标题和正文编码
+func TestBuildEmailMessage(t *testing.T) {
+	data := buildEmailMessage("mlog@example.com", []string{"a@example.com", "b@example.com"}, "[mlog] 存档失败", strings.Repeat("调用栈", 40), time.Now())
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "[mlog] 存档失败" {
+		t.Fatalf("邮件标题错误: %q %v", subject, err)
+	}
+	if msg.Header.Get("To") != "a@example.com, b@example.com" {
+		t.Fatalf("收件人错误: %s", msg.Header.Get("To"))
+	}
+	var body bytes.Buffer
+	body.ReadFrom(base64.NewDecoder(base64.StdEncoding, msg.Body))
+	if body.String() != strings.Repeat("调用栈", 40) {
+		t.Fatalf("邮件正文错误: %s", body.String())
+	}
+}
@@ -0,0 +1,65 @@
+package mlog
+
+import (
+	"sort"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 内置的日志格式
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+// EncoderFactory 根据编码配置创建自定义格式的编码器
+// cfg 为 mlog 按当前配置生成的编码配置（字段名、时间格式、级别编码、调用位置编码等），可以直接使用或修改后使用
+type EncoderFactory func(cfg zapcore.EncoderConfig) zapcore.Encoder
+
+var (
+	encoderFactoriesMutex sync.RWMutex
+	encoderFactories      = make(map[string]EncoderFactory)
+)
+
+// RegisterEncoderFormat 注册自定义日志格式，之后在 ZapConfig.Format 中按名称选用，重复注册同名格式时覆盖之前的注册
+// 不能覆盖内置格式（console、json、json-pretty、ecs、gcp）；需要在 InitialZap 之前调用，一般放在格式实现包的 init 中
+func RegisterEncoderFormat(name string, factory EncoderFactory) {
+	if name == "" || factory == nil {
+		panic("mlog: RegisterEncoderFormat 的格式名和 factory 不能为空")
+	}
+	if builtinFormat(name) {
+		panic("mlog: 不能覆盖内置日志格式 " + name)
+	}
+	encoderFactoriesMutex.Lock()
+	encoderFactories[name] = factory
+	encoderFactoriesMutex.Unlock()
+}
+
+// RegisteredEncoderFormats 返回已注册的自定义日志格式名（按字母排序）
+func RegisteredEncoderFormats() []string {
+	encoderFactoriesMutex.RLock()
+	defer encoderFactoriesMutex.RUnlock()
+	names := make([]string, 0, len(encoderFactories))
+	for name := range encoderFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getEncoderFactory 返回自定义日志格式的 factory，未注册时返回 nil
+func getEncoderFactory(name string) EncoderFactory {
+	encoderFactoriesMutex.RLock()
+	defer encoderFactoriesMutex.RUnlock()
+	return encoderFactories[name]
+}
+
+// builtinFormat 是否为内置日志格式，空字符串按 console 处理
+func builtinFormat(name string) bool {
+	switch name {
+	case "", FormatConsole, FormatJSON, FormatJSONPretty, FormatECS, FormatGCP:
+		return true
+	}
+	return false
+}
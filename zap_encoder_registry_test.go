@@ -0,0 +1,55 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestRegisterEncoderFormat 测试注册的自定义格式可以在 Format 中选用，并收到按配置生成的编码配置
+func TestRegisterEncoderFormat(t *testing.T) {
+	var got zapcore.EncoderConfig
+	RegisterEncoderFormat("mycorp", func(cfg zapcore.EncoderConfig) zapcore.Encoder {
+		got = cfg
+		cfg.MessageKey = "msg"
+		cfg.ConsoleSeparator = " | "
+		return zapcore.NewConsoleEncoder(cfg)
+	})
+	defer func() {
+		encoderFactoriesMutex.Lock()
+		delete(encoderFactories, "mycorp")
+		encoderFactoriesMutex.Unlock()
+	}()
+	if names := RegisteredEncoderFormats(); len(names) != 1 || names[0] != "mycorp" {
+		t.Fatalf("已注册格式错误: %v", names)
+	}
+
+	Close()
+	oldConfig := zapConfig
+	defer func() { zapConfig = oldConfig }()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "mycorp", TimeFormat: TimeFormatEpoch}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+	InfoW("自定义格式", zap.Int("player", 10086))
+	Flush()
+
+	data, err := os.ReadFile(filepath.Join(dir, "2", "gate", "info.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), " | 自定义格式 | ") || got.TimeKey != "time" || got.EncodeTime == nil {
+		t.Fatalf("自定义格式输出错误: %s", data)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("覆盖内置格式应 panic")
+		}
+	}()
+	RegisterEncoderFormat(FormatJSON, func(cfg zapcore.EncoderConfig) zapcore.Encoder { return nil })
+}
@@ -0,0 +1,341 @@
+package mlog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// encryptSuffix 加密后的文件后缀
+const encryptSuffix = ".enc"
+
+// encryptScanInterval 扫描待加密文件的间隔
+const encryptScanInterval = 10 * time.Second
+
+// 加密文件格式：8 字节文件头 + 若干块，每块为 4 字节大端密文长度 + 12 字节随机 nonce + 密文（含 16 字节认证标签）
+// 每块的附加数据为 文件头 + 8 字节块序号 + 是否最后一块，块被删除、调换顺序或文件被截断都无法通过认证
+const (
+	encryptMagic     = "MLOGENC1"
+	encryptChunkSize = 64 * 1024
+)
+
+// ErrLogDecrypt 解密失败：密钥错误、文件被篡改或被截断
+var ErrLogDecrypt = errors.New("日志文件解密失败：密钥错误或文件被篡改、截断")
+
+// EncryptionConfig 轮转日志文件的静态加密配置
+// 日志文件不再写入后（lumberjack 轮转出的备份或文件名模板的旧日期文件，启用压缩时在压缩之后）使用 AES-GCM 加密为 .enc 文件并删除原文件，
+// 正在写入的文件不加密。密钥优先使用 SetEncryptionKeyProvider 设置的获取方式（如 KMS），其次是 Key，最后是 KeyEnv 指定的环境变量
+type EncryptionConfig struct {
+	Enable bool   `mapstructure:"enable" json:"enable" yaml:"enable"`    // 启用加密
+	Key    string `mapstructure:"key" json:"key" yaml:"key"`             // base64 编码的 16、24 或 32 字节密钥（AES-128/192/256）
+	KeyEnv string `mapstructure:"key-env" json:"key-env" yaml:"key-env"` // 保存 base64 密钥的环境变量名，避免密钥写在配置文件中
+}
+
+// EncryptionKeyProvider 获取加密密钥（原始字节），可用于从 KMS 获取密钥，初始化日志系统时调用一次
+type EncryptionKeyProvider func() ([]byte, error)
+
+// encryptionKeyProvider 当前的密钥获取方式，未设置时使用配置中的密钥
+var encryptionKeyProvider atomic.Pointer[EncryptionKeyProvider]
+
+// SetEncryptionKeyProvider 设置加密密钥的获取方式，需要在 InitialZap 之前调用，传入 nil 恢复使用配置中的密钥
+func SetEncryptionKeyProvider(provider EncryptionKeyProvider) {
+	if provider == nil {
+		encryptionKeyProvider.Store(nil)
+		return
+	}
+	encryptionKeyProvider.Store(&provider)
+}
+
+// encryptionKey 按优先级获取加密密钥
+func (c *EncryptionConfig) encryptionKey() ([]byte, error) {
+	if provider := encryptionKeyProvider.Load(); provider != nil {
+		return (*provider)()
+	}
+	encoded := c.Key
+	if encoded == "" && c.KeyEnv != "" {
+		encoded = os.Getenv(c.KeyEnv)
+	}
+	if encoded == "" {
+		return nil, errors.New("未配置加密密钥")
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("加密密钥不是有效的 base64: %w", err)
+	}
+	return key, nil
+}
+
+// newLogAEAD 创建 AES-GCM
+func newLogAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkAdditionalData 块的附加认证数据
+func chunkAdditionalData(index uint64, final bool) []byte {
+	ad := make([]byte, len(encryptMagic)+9)
+	copy(ad, encryptMagic)
+	binary.BigEndian.PutUint64(ad[len(encryptMagic):], index)
+	if final {
+		ad[len(ad)-1] = 1
+	}
+	return ad
+}
+
+// encryptLog 将 src 加密后写入 dst
+func encryptLog(dst io.Writer, src io.Reader, aead cipher.AEAD) error {
+	if _, err := io.WriteString(dst, encryptMagic); err != nil {
+		return err
+	}
+	r := bufio.NewReaderSize(src, encryptChunkSize)
+	plain := make([]byte, encryptChunkSize)
+	nonce := make([]byte, aead.NonceSize())
+	var header [4]byte
+	for index := uint64(0); ; index++ {
+		n, err := io.ReadFull(r, plain)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return err
+		}
+		_, peekErr := r.Peek(1)
+		final := peekErr == io.EOF
+		if peekErr != nil && !final {
+			return peekErr
+		}
+		if _, err := rand.Read(nonce); err != nil {
+			return err
+		}
+		sealed := aead.Seal(nil, nonce, plain[:n], chunkAdditionalData(index, final))
+		binary.BigEndian.PutUint32(header[:], uint32(len(sealed)))
+		for _, b := range [][]byte{header[:], nonce, sealed} {
+			if _, err := dst.Write(b); err != nil {
+				return err
+			}
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// DecryptLog 解密 mlog 加密的日志文件，明文写入 dst
+// key 为加密时使用的原始密钥；密钥错误、文件被篡改或被截断时返回 ErrLogDecrypt，此前已写入 dst 的内容不可信
+func DecryptLog(dst io.Writer, src io.Reader, key []byte) error {
+	aead, err := newLogAEAD(key)
+	if err != nil {
+		return err
+	}
+	r := bufio.NewReaderSize(src, encryptChunkSize+64)
+	magic := make([]byte, len(encryptMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, []byte(encryptMagic)) {
+		return fmt.Errorf("不是 mlog 加密的日志文件: %w", ErrLogDecrypt)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	var header [4]byte
+	for index := uint64(0); ; index++ {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return ErrLogDecrypt
+		}
+		size := binary.BigEndian.Uint32(header[:])
+		if size < uint32(aead.Overhead()) || size > encryptChunkSize+uint32(aead.Overhead()) {
+			return ErrLogDecrypt
+		}
+		sealed := make([]byte, size)
+		if _, err := io.ReadFull(r, nonce); err != nil {
+			return ErrLogDecrypt
+		}
+		if _, err := io.ReadFull(r, sealed); err != nil {
+			return ErrLogDecrypt
+		}
+		_, peekErr := r.Peek(1)
+		final := peekErr == io.EOF
+		if peekErr != nil && !final {
+			return peekErr
+		}
+		plain, err := aead.Open(sealed[:0], nonce, sealed, chunkAdditionalData(index, final))
+		if err != nil {
+			return ErrLogDecrypt
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// logEncryptor 轮转日志文件加密器
+// 定期扫描服务日志目录，把不再写入的日志文件加密为 .enc 后删除原文件。启用压缩时等待压缩完成后加密压缩文件；
+// lumberjack 的 MaxBackups/MaxAge 清理不识别 .enc 文件，因此加密后的备份也在这里按相同规则清理
+type logEncryptor struct {
+	root       string // 服务日志目录
+	aead       cipher.AEAD
+	compressed string         // 启用压缩时压缩文件的后缀，未压缩的备份等待压缩后再加密
+	pattern    *regexp.Regexp // 文件名模板带日期时匹配旧日期的文件，未配置时为 nil
+	maxBackups int
+	maxAge     time.Duration
+	interval   time.Duration
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// newLogEncryptor 创建轮转日志文件加密器并启动后台扫描
+func newLogEncryptor(cfg EncryptionConfig, root string) (*logEncryptor, error) {
+	key, err := cfg.encryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newLogAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	e := &logEncryptor{
+		root:       root,
+		aead:       aead,
+		maxBackups: zapConfig.MaxBackups,
+		maxAge:     time.Duration(zapConfig.RetentionDay) * 24 * time.Hour,
+		interval:   encryptScanInterval,
+		done:       make(chan struct{}),
+	}
+	switch {
+	case zapConfig.zstdCompression():
+		e.compressed = zstdSuffix
+	case zapConfig.gzipCompression():
+		e.compressed = gzipSuffix
+	}
+	if p, err := parseFilePattern(zapConfig.FilePattern); err == nil && p != nil && p.hasDate {
+		e.pattern = p.matcher()
+	}
+	e.wg.Add(1)
+	go e.run()
+	return e, nil
+}
+
+// run 后台扫描循环，启动时先扫描一次，加密上次运行遗留的文件
+func (e *logEncryptor) run() {
+	defer e.wg.Done()
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		e.scan()
+		select {
+		case <-ticker.C:
+		case <-e.done:
+			return
+		}
+	}
+}
+
+// scan 加密一轮不再写入的日志文件，并按 MaxBackups/MaxAge 清理加密后的备份
+func (e *logEncryptor) scan() {
+	active := activeLogFiles()
+	// 按 目录/文件名/扩展名 分组，同一个日志文件的备份一起清理
+	groups := make(map[string][]backupFile)
+	filepath.WalkDir(e.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() || active[filepath.Clean(path)] {
+			return nil
+		}
+		select {
+		case <-e.done:
+			return filepath.SkipAll
+		default:
+		}
+		name := d.Name()
+		m := lumberjackBackupPattern.FindStringSubmatch(name)
+		if m == nil {
+			// 文件名模板生成的旧日期文件
+			if e.pattern != nil && e.pattern.MatchString(name) {
+				if err := e.encrypt(path); err != nil {
+					fmt.Fprintf(os.Stderr, "[mlog] 加密日志文件失败 %s: %v\n", path, err)
+				}
+			}
+			return nil
+		}
+		t, err := time.ParseInLocation(lumberjackBackupTimeFormat, m[2], time.Local)
+		if err != nil {
+			return nil
+		}
+		b := backupFile{path: path, time: t, managed: m[5] != ""}
+		if !b.managed && m[4] == e.compressed && !e.compressing(path, m[4]) {
+			if err := e.encrypt(path); err != nil {
+				fmt.Fprintf(os.Stderr, "[mlog] 加密轮转日志失败 %s: %v\n", path, err)
+			} else {
+				b.path, b.managed = path+encryptSuffix, true
+			}
+		}
+		key := filepath.Join(filepath.Dir(path), m[1]+m[3])
+		groups[key] = append(groups[key], b)
+		return nil
+	})
+	for _, backups := range groups {
+		removeStaleBackups(backups, e.maxBackups, e.maxAge)
+	}
+}
+
+// compressing 启用压缩时原文件还在说明压缩文件尚未写完
+func (e *logEncryptor) compressing(path, suffix string) bool {
+	if suffix == "" {
+		return false
+	}
+	_, err := os.Stat(strings.TrimSuffix(path, suffix))
+	return err == nil
+}
+
+// encrypt 将文件加密为 .enc，先写入临时文件再重命名，中途退出不会留下不完整的 .enc 文件
+func (e *logEncryptor) encrypt(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := path + encryptSuffix + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	err = encryptLog(dst, src, e.aead)
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+encryptSuffix)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
+
+// Close 停止后台扫描，未加密的文件在下次启动时加密
+func (e *logEncryptor) Close() error {
+	e.closeOnce.Do(func() {
+		close(e.done)
+	})
+	e.wg.Wait()
+	return nil
+}
@@ -0,0 +1,83 @@
+package mlog
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestEncryptLogRoundTrip 测试多块数据加密后解密一致，篡改、截断和错误密钥都无法解密
+func TestEncryptLogRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	aead, err := newLogAEAD(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := []byte(strings.Repeat("玩家 10086 登录\n", 20000))
+	var sealed bytes.Buffer
+	if err := encryptLog(&sealed, bytes.NewReader(plain), aead); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := DecryptLog(&out, bytes.NewReader(sealed.Bytes()), key); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), plain) {
+		t.Fatal("解密结果与原文不一致")
+	}
+
+	tampered := bytes.Clone(sealed.Bytes())
+	tampered[len(tampered)/2] ^= 1
+	truncated := sealed.Bytes()[:len(encryptMagic)+4+12+encryptChunkSize+16]
+	wrongKey := bytes.Repeat([]byte{8}, 32)
+	for name, c := range map[string]struct {
+		data []byte
+		key  []byte
+	}{"篡改": {tampered, key}, "截断": {truncated, key}, "错误密钥": {sealed.Bytes(), wrongKey}} {
+		if err := DecryptLog(&bytes.Buffer{}, bytes.NewReader(c.data), c.key); !errors.Is(err, ErrLogDecrypt) {
+			t.Fatalf("%s 后应解密失败: %v", name, err)
+		}
+	}
+
+	var empty bytes.Buffer
+	encryptLog(&empty, bytes.NewReader(nil), aead)
+	out.Reset()
+	if err := DecryptLog(&out, &empty, key); err != nil || out.Len() != 0 {
+		t.Fatalf("空文件解密错误: %v", err)
+	}
+}
+
+// TestLogEncryptorScan 测试加密轮转出的备份文件并删除原文件，正在写入的文件不加密
+func TestLogEncryptorScan(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{1}, 16)
+	aead, _ := newLogAEAD(key)
+	e := &logEncryptor{root: dir, aead: aead, done: make(chan struct{})}
+
+	backup := filepath.Join(dir, "info-"+time.Now().Format(lumberjackBackupTimeFormat)+".log")
+	os.WriteFile(backup, []byte("轮转后的日志\n"), 0o644)
+	current := filepath.Join(dir, "info.log")
+	os.WriteFile(current, []byte("正在写入\n"), 0o644)
+	e.scan()
+
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Fatal("加密后应删除原文件")
+	}
+	if _, err := os.Stat(current + encryptSuffix); !os.IsNotExist(err) {
+		t.Fatal("不应加密当前日志文件")
+	}
+	file, err := os.Open(backup + encryptSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var out bytes.Buffer
+	if err := DecryptLog(&out, file, key); err != nil || out.String() != "轮转后的日志\n" {
+		t.Fatalf("解密结果错误: %q %v", out.String(), err)
+	}
+}
@@ -0,0 +1,130 @@
+package mlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// envPrefix 配置环境变量的前缀
+const envPrefix = "MLOG_"
+
+// envAliases 环境变量的简写，其他环境变量按配置键名转换，如 log-in-console 为 MLOG_LOG_IN_CONSOLE
+var envAliases = map[string]string{
+	"ASYNC":   "enable-async",
+	"CONSOLE": "log-in-console",
+}
+
+// envReserved 其他功能读取的环境变量（见 zap_preinit.go 的自动初始化），不作为配置覆盖
+var envReserved = map[string]bool{
+	"MLOG_AUTO_INIT":        true,
+	"MLOG_AUTO_INIT_LEVEL":  true,
+	"MLOG_AUTO_INIT_FORMAT": true,
+}
+
+var (
+	envOverridesMutex sync.Mutex
+	// envOverrides LoadEnvOverrides 读取的配置覆盖，键为配置键名
+	envOverrides map[string]string
+)
+
+// LoadEnvOverrides 读取 MLOG_ 开头的环境变量，之后的 InitialZap 在代码传入的配置和 logLevel 参数之上应用这些覆盖，
+// 镜像中固化的配置不必重新打包即可按部署环境调整。环境变量名为 MLOG_ 加上大写的配置键名（- 换为 _），
+// 如 MLOG_LEVEL、MLOG_FORMAT、MLOG_DIRECTOR、MLOG_MAX_SIZE；MLOG_ASYNC 和 MLOG_CONSOLE 分别为 enable-async 和 log-in-console 的简写。
+// 字符串原样使用，列表可以写为逗号分隔的值，其他类型按 YAML 解析（如 true、100、{info: {initial: 10}}），
+// 对象和映射与代码中的配置合并。自动初始化使用的 MLOG_AUTO_INIT* 不属于配置覆盖，直接忽略；
+// 未知或无效的环境变量返回错误并跳过，其余环境变量仍然生效；
+// 需要在 InitialZap 之前调用，再次调用时以最新的环境变量为准
+func LoadEnvOverrides() error {
+	var names []string
+	for _, kv := range os.Environ() {
+		if name, _, _ := strings.Cut(kv, "="); strings.HasPrefix(name, envPrefix) && !envReserved[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	overrides := make(map[string]string)
+	var errs []error
+	for _, name := range names {
+		key := envConfigKey(name)
+		value := os.Getenv(name)
+		var scratch ZapConfig
+		if err := setConfigValue(&scratch, key, value); err != nil {
+			errs = append(errs, fmt.Errorf("环境变量 %s 无效: %w", name, err))
+			continue
+		}
+		overrides[key] = value
+	}
+
+	envOverridesMutex.Lock()
+	envOverrides = overrides
+	envOverridesMutex.Unlock()
+	return errors.Join(errs...)
+}
+
+// envConfigKey 把环境变量名转换为配置键名
+func envConfigKey(name string) string {
+	name = strings.TrimPrefix(name, envPrefix)
+	if key, ok := envAliases[name]; ok {
+		return key
+	}
+	return strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+}
+
+// applyEnvOverrides 把 LoadEnvOverrides 读取的覆盖应用到 c
+func applyEnvOverrides(c *ZapConfig) {
+	envOverridesMutex.Lock()
+	defer envOverridesMutex.Unlock()
+	for key, value := range envOverrides {
+		setConfigValue(c, key, value)
+	}
+}
+
+// configFieldIndex 配置键名到 ZapConfig 字段下标的映射
+var configFieldIndex = sync.OnceValue(func() map[string]int {
+	index := make(map[string]int)
+	t := reflect.TypeOf(ZapConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		if key, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ","); key != "" && key != "-" {
+			index[key] = i
+		}
+	}
+	return index
+})
+
+// setConfigValue 按配置键名设置 c 的字段
+func setConfigValue(c *ZapConfig, key, value string) error {
+	i, ok := configFieldIndex()[key]
+	if !ok {
+		return fmt.Errorf("未知的配置项 %s", key)
+	}
+	field := reflect.ValueOf(c).Elem().Field(i)
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+		return nil
+	case field.Type() == reflect.TypeOf([]string(nil)) && !strings.HasPrefix(strings.TrimSpace(value), "["):
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+		return nil
+	}
+	decoded := reflect.New(field.Type())
+	decoded.Elem().Set(field)
+	if err := yaml.Unmarshal([]byte(value), decoded.Interface()); err != nil {
+		return err
+	}
+	field.Set(decoded.Elem())
+	return nil
+}
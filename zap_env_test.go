@@ -0,0 +1,71 @@
+package mlog
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLoadEnvOverrides 测试环境变量覆盖代码中的配置和 logLevel 参数，无效的环境变量返回错误并跳过
+func TestLoadEnvOverrides(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	// 清理按注册的逆序执行，环境变量恢复之后再重新读取，不影响其他测试
+	t.Cleanup(func() { LoadEnvOverrides() })
+	t.Setenv("MLOG_LEVEL", "warn")
+	t.Setenv("MLOG_DIRECTOR", dir)
+	t.Setenv("MLOG_FORMAT", "json")
+	t.Setenv("MLOG_CONSOLE", "false")
+	t.Setenv("MLOG_SINGLE_FILE", "true")
+	t.Setenv("MLOG_REDACT_KEYS", "password, token")
+	t.Setenv("MLOG_SAMPLING", "{info: {initial: 5, thereafter: 10}}")
+	t.Setenv("MLOG_KAFKA", "{topic: game-logs}")
+	t.Setenv("MLOG_MAX_SIZE", "many")
+	t.Setenv("MLOG_NO_SUCH_OPTION", "1")
+
+	err := LoadEnvOverrides()
+	if err == nil || !strings.Contains(err.Error(), "MLOG_MAX_SIZE") || !strings.Contains(err.Error(), "MLOG_NO_SUCH_OPTION") {
+		t.Fatalf("应返回无效和未知的环境变量: %v", err)
+	}
+
+	config := ZapConfig{Level: "debug", Director: filepath.Join(dir, "unused"), MaxSize: 10, LogInConsole: true,
+		Kafka: KafkaConfig{PartitionKey: "player_id"}}
+	InitialZap("gate", 2, "debug", &config)
+	defer Close()
+
+	got := GetConfig()
+	if got.Level != "warn" || currentLevel() != "warn" || got.Director != dir || got.Format != "json" || got.LogInConsole ||
+		!got.SingleFile || got.MaxSize != 10 {
+		t.Fatalf("环境变量覆盖错误: %+v", got)
+	}
+	if len(got.RedactKeys) != 2 || got.RedactKeys[1] != "token" || got.Sampling["info"].Thereafter != 10 {
+		t.Fatalf("列表和映射的覆盖错误: %v %v", got.RedactKeys, got.Sampling)
+	}
+	if got.Kafka.Topic != "game-logs" || got.Kafka.PartitionKey != "player_id" {
+		t.Fatalf("对象应与代码中的配置合并: %+v", got.Kafka)
+	}
+
+	WarnW("环境变量配置")
+	Close()
+	if content := readLogFile(t, filepath.Join(dir, "2/gate/all.log")); !strings.Contains(content, `"message":"环境变量配置"`) {
+		t.Fatalf("应按环境变量的目录和格式写入: %s", content)
+	}
+}
+
+// TestLoadEnvOverridesSkipsAutoInit 测试自动初始化的环境变量不作为配置覆盖，也不返回错误
+func TestLoadEnvOverridesSkipsAutoInit(t *testing.T) {
+	t.Cleanup(func() { LoadEnvOverrides() })
+	t.Setenv("MLOG_AUTO_INIT", "1")
+	t.Setenv("MLOG_AUTO_INIT_LEVEL", "debug")
+	t.Setenv("MLOG_AUTO_INIT_FORMAT", "json")
+	t.Setenv("MLOG_FORMAT", "json")
+
+	if err := LoadEnvOverrides(); err != nil {
+		t.Fatalf("自动初始化的环境变量不应报错: %v", err)
+	}
+	var config ZapConfig
+	applyEnvOverrides(&config)
+	if config.Format != "json" || config.Level != "" {
+		t.Fatalf("只应应用配置覆盖: %+v", config)
+	}
+}
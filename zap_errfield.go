@@ -0,0 +1,105 @@
+package mlog
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxErrorCauses 展开错误链时最多输出的原因条数，避免异常的错误链无限展开
+const maxErrorCauses = 32
+
+// ErrField 展开错误链的字段，代替 zap.Error 的 %v 拼接
+// error 为错误消息，error.cause 为逐层 Unwrap（或 pkg/errors 的 Cause）得到的各层错误消息，
+// 错误带有调用栈（实现了 StackTrace 方法，如 pkg/errors 创建的错误）时以 error.stack 输出最内层的调用栈，
+// 开启 UseRelativePath 时与 AssertString 一样转换为相对路径；err 为 nil 时不输出字段
+func ErrField(err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Inline(errorChain{err: err})
+}
+
+// errorChain 按 error、error.cause、error.stack 输出的错误
+type errorChain struct {
+	err error
+}
+
+func (c errorChain) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("error", c.err.Error())
+	if causes := errorCauses(c.err); len(causes) > 0 {
+		enc.AddArray("error.cause", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			for _, cause := range causes {
+				arr.AppendString(cause)
+			}
+			return nil
+		}))
+	}
+	if stack := errorStack(c.err); stack != "" {
+		enc.AddString("error.stack", stack)
+	}
+	return nil
+}
+
+// unwrapError 返回 err 包装的错误，支持 Unwrap() error、Unwrap() []error 和 pkg/errors 的 Cause() error
+func unwrapError(err error) []error {
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		return e.Unwrap()
+	case interface{ Cause() error }:
+		if cause := e.Cause(); cause != nil {
+			return []error{cause}
+		}
+	}
+	if cause := errors.Unwrap(err); cause != nil {
+		return []error{cause}
+	}
+	return nil
+}
+
+// errorCauses 深度优先展开错误链，返回各层错误的消息，与上一条相同的消息（如 pkg/errors 附加调用栈的层）只保留一条
+func errorCauses(err error) []string {
+	var (
+		causes []string
+		last   = err.Error()
+		stack  = unwrapError(err)
+	)
+	for len(stack) > 0 && len(causes) < maxErrorCauses {
+		cause := stack[0]
+		stack = append(unwrapError(cause), stack[1:]...)
+		if cause == nil {
+			continue
+		}
+		if msg := cause.Error(); msg != last {
+			causes = append(causes, msg)
+			last = msg
+		}
+	}
+	return causes
+}
+
+// errorStack 返回错误链中最内层的调用栈，没有时返回空字符串
+// StackTrace 的返回值类型因库而异，通过反射调用并以 %+v 格式化
+func errorStack(err error) string {
+	var stack string
+	for i := 0; err != nil && i < maxErrorCauses; i++ {
+		if m := reflect.ValueOf(err).MethodByName("StackTrace"); m.IsValid() && m.Type().NumIn() == 0 && m.Type().NumOut() == 1 {
+			if s := strings.TrimLeft(fmt.Sprintf("%+v", m.Call(nil)[0].Interface()), "\n"); s != "" {
+				stack = s
+			}
+		}
+		causes := unwrapError(err)
+		if len(causes) == 0 {
+			break
+		}
+		err = causes[0]
+	}
+	if stack != "" && zapConfig.UseRelativePath {
+		stack = convertStackPathsToRelative(stack)
+	}
+	return stack
+}
@@ -0,0 +1,90 @@
+package mlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// fakeStackTrace 模拟 pkg/errors 的 StackTrace，%+v 输出以换行开头的帧列表
+type fakeStackTrace []string
+
+func (s fakeStackTrace) Format(f fmt.State, verb rune) {
+	for _, frame := range s {
+		fmt.Fprintf(f, "\n%s", frame)
+	}
+}
+
+// stackError 模拟 pkg/errors 的 withStack：消息与被包装的错误相同，带有调用栈
+type stackError struct {
+	cause error
+	stack fakeStackTrace
+}
+
+func (e *stackError) Error() string              { return e.cause.Error() }
+func (e *stackError) Cause() error               { return e.cause }
+func (e *stackError) StackTrace() fakeStackTrace { return e.stack }
+
+// encodeErrField 用 JSON 编码器编码字段
+func encodeErrField(t *testing.T, enc zapcore.Encoder, field zap.Field) map[string]any {
+	t.Helper()
+	buf, err := enc.EncodeEntry(zapcore.Entry{Message: "存档失败"}, []zapcore.Field{field})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Free()
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+// TestErrField 测试错误链展开为 error、error.cause 和 error.stack
+func TestErrField(t *testing.T) {
+	root := errors.New("connection refused")
+	inner := &stackError{cause: root, stack: fakeStackTrace{"game.dial\n\t/src/game/db.go:12"}}
+	err := fmt.Errorf("save player 1001: %w", errors.Join(fmt.Errorf("write: %w", inner), errors.New("rollback failed")))
+
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "message"})
+	entry := encodeErrField(t, enc, ErrField(err))
+	if entry["error"] != err.Error() {
+		t.Fatalf("error 错误: %v", entry["error"])
+	}
+	causes, _ := json.Marshal(entry["error.cause"])
+	want := `["write: connection refused\nrollback failed","write: connection refused","connection refused","rollback failed"]`
+	if string(causes) != want {
+		t.Fatalf("error.cause 错误: %s", causes)
+	}
+	if entry["error.stack"] != "game.dial\n\t/src/game/db.go:12" {
+		t.Fatalf("error.stack 错误: %q", entry["error.stack"])
+	}
+
+	entry = encodeErrField(t, enc, ErrField(root))
+	if entry["error"] != "connection refused" || entry["error.cause"] != nil || entry["error.stack"] != nil {
+		t.Fatalf("没有包装的错误只输出 error: %v", entry)
+	}
+	if field := ErrField(nil); field.Type != zapcore.SkipType {
+		t.Fatalf("nil 错误应跳过: %v", field)
+	}
+}
+
+// TestErrFieldECS 测试 ecs 格式把错误链转换为 error.message、error.cause 和 error.stack_trace
+func TestErrFieldECS(t *testing.T) {
+	inner := &stackError{cause: errors.New("connection refused"), stack: fakeStackTrace{"game.dial\n\t/src/game/db.go:12"}}
+	entry := encodeErrField(t, newECSEncoder(&ZapConfig{}), ErrField(fmt.Errorf("write: %w", inner)))
+	if entry["error.message"] != "write: connection refused" || entry["error.stack_trace"] != "game.dial\n\t/src/game/db.go:12" {
+		t.Fatalf("ECS 字段错误: %v", entry)
+	}
+	if causes, ok := entry["error.cause"].([]any); !ok || len(causes) != 1 || !strings.Contains(causes[0].(string), "refused") {
+		t.Fatalf("error.cause 错误: %v", entry["error.cause"])
+	}
+	if _, ok := entry["error"]; ok {
+		t.Fatalf("ECS 不应输出字符串 error 字段: %v", entry)
+	}
+}
@@ -0,0 +1,74 @@
+package mlog
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// errorRefAlphabet Crockford Base32 字符集，去掉了容易混淆的 I、L、O、U，方便玩家抄写
+const errorRefAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// errorRefFallback 随机数不可用时的回退计数器
+var errorRefFallback uint64
+
+// ErrorRef 输出错误日志并返回一个简短的错误参考码（如 "K3F9-2QXA"）
+// 参考码会以 error_ref 字段写入日志，可以展示给玩家或客服，
+// 通过截图中的参考码即可在服务器日志中定位到对应的错误条目。
+func ErrorRef(msg string, fields ...zap.Field) string {
+	ref := newErrorRef()
+	if !isErrorEnabledFast() {
+		return ref
+	}
+
+	allFields := make([]zap.Field, 0, len(fields)+1)
+	allFields = append(allFields, zap.String("error_ref", ref))
+	allFields = append(allFields, fields...)
+
+	if al, ok := getAsyncLogger(); ok {
+		// 调用栈：用户代码 -> mlog.ErrorRef()
+		al.logAsyncAt(zapcore.ErrorLevel, captureCaller(1), msg, nil, allFields...)
+		return ref
+	}
+
+	logger, ok := getLogger()
+	if !ok {
+		logBeforeInit(zapcore.ErrorLevel, 1, msg, allFields...)
+		return ref
+	}
+
+	// 调用栈：用户代码 -> mlog.ErrorRef() -> logger.Error()
+	// 需要跳过 1 层：mlog.ErrorRef()
+	loggerWithSkip := logger.WithOptions(zap.AddCallerSkip(1))
+	loggerWithSkip.Error(msg, allFields...)
+	return ref
+}
+
+// newErrorRef 生成 8 位 Crockford Base32 参考码，格式为 XXXX-XXXX
+// 高位混入秒级时间，低位为随机数，同一时刻产生的参考码也不会重复
+func newErrorRef() string {
+	var random [4]byte
+	var n uint64
+	if _, err := rand.Read(random[:]); err == nil {
+		n = uint64(binary.BigEndian.Uint32(random[:]))
+	} else {
+		n = atomic.AddUint64(&errorRefFallback, 1)
+	}
+	// 40 位：高 8 位为时间（秒，循环），低 32 位为随机数
+	n = (uint64(time.Now().Unix())&0xff)<<32 | (n & 0xffffffff)
+
+	var buf [9]byte
+	for i := 8; i >= 0; i-- {
+		if i == 4 {
+			buf[i] = '-'
+			continue
+		}
+		buf[i] = errorRefAlphabet[n&0x1f]
+		n >>= 5
+	}
+	return string(buf[:])
+}
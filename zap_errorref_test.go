@@ -0,0 +1,44 @@
+package mlog
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestErrorRef 测试返回的参考码写入错误日志的 error_ref 字段，调用位置指向调用方
+func TestErrorRef(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		Close()
+		dir := t.TempDir()
+		InitialZap("gate", 2, "info", &ZapConfig{Director: dir, Format: "json", SingleFile: true, ShowLine: true, EnableAsync: async})
+
+		ref := ErrorRef("充值失败", zap.Int64("player_id", 1001))
+		_, _, line, _ := runtime.Caller(0)
+		Close()
+
+		if len(ref) != 9 || ref[4] != '-' || strings.Trim(strings.ReplaceAll(ref, "-", ""), errorRefAlphabet) != "" {
+			t.Fatalf("async=%v 参考码格式错误: %q", async, ref)
+		}
+		var entry map[string]any
+		content := strings.TrimSpace(readLogFile(t, filepath.Join(dir, "2/gate/all.log")))
+		if err := json.Unmarshal([]byte(content), &entry); err != nil {
+			t.Fatalf("async=%v 日志应为一条 JSON: %s", async, content)
+		}
+		if entry["level"] != "error" || entry["message"] != "充值失败" || entry["error_ref"] != ref || entry["player_id"] != 1001.0 {
+			t.Fatalf("async=%v 日志应携带参考码 %s: %v", async, ref, entry)
+		}
+		if caller, _ := entry["caller"].(string); !strings.HasSuffix(caller, "zap_errorref_test.go:"+strconv.Itoa(line-1)) {
+			t.Fatalf("async=%v 调用位置应指向调用方: %v", async, entry["caller"])
+		}
+	}
+
+	if a, b := newErrorRef(), newErrorRef(); a == b {
+		t.Fatalf("参考码不应重复: %s", a)
+	}
+}
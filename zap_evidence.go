@@ -0,0 +1,288 @@
+package mlog
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrEvidenceDisabled 未配置 evidence-buffer-size 时无法生成证据包
+var ErrEvidenceDisabled = errors.New("mlog: 未启用最近日志缓冲区（evidence-buffer-size）")
+
+// defaultEvidencePlayerKey 默认用于识别玩家的字段名
+const defaultEvidencePlayerKey = "player_id"
+
+// recentEntries 最近日志缓冲区，未启用时为 nil
+var (
+	recentEntries      *recentEntriesBuffer
+	recentEntriesMutex sync.RWMutex
+)
+
+// EvidenceAttachment 证据包附件（如回放文件、客户端上报数据）
+type EvidenceAttachment struct {
+	Name string // 附件文件名，写入证据包的 attachments/ 目录
+	Data []byte
+}
+
+// EvidenceManifest 证据包清单，记录包内每个文件的 SHA-256，便于审核时校验完整性
+type EvidenceManifest struct {
+	PlayerID   string                 `json:"player_id"`
+	Reason     string                 `json:"reason"`
+	CreatedAt  time.Time              `json:"created_at"`
+	EntryCount int                    `json:"entry_count"`
+	Files      []EvidenceManifestFile `json:"files"`
+}
+
+// EvidenceManifestFile 证据包清单中的单个文件
+type EvidenceManifestFile struct {
+	Name   string `json:"name"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// recentEntry 最近日志缓冲区中的单条日志
+type recentEntry struct {
+	line   []byte // JSON 编码后的日志行
+	player string // 玩家字段的值，没有该字段时为空
+}
+
+// recentEntriesBuffer 固定容量的最近日志环形缓冲区，写满后覆盖最旧的条目
+type recentEntriesBuffer struct {
+	mu        sync.Mutex
+	entries   []recentEntry
+	next      int
+	full      bool
+	playerKey string
+}
+
+// newRecentEntriesBuffer 创建最近日志缓冲区
+func newRecentEntriesBuffer(size int, playerKey string) *recentEntriesBuffer {
+	if playerKey == "" {
+		playerKey = defaultEvidencePlayerKey
+	}
+	return &recentEntriesBuffer{
+		entries:   make([]recentEntry, size),
+		playerKey: playerKey,
+	}
+}
+
+// add 写入一条日志
+func (b *recentEntriesBuffer) add(line []byte, player string) {
+	b.mu.Lock()
+	b.entries[b.next] = recentEntry{line: line, player: player}
+	b.next++
+	if b.next == len(b.entries) {
+		b.next = 0
+		b.full = true
+	}
+	b.mu.Unlock()
+}
+
+// collect 按时间顺序返回指定玩家的日志行
+func (b *recentEntriesBuffer) collect(player string) [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var lines [][]byte
+	start, count := 0, b.next
+	if b.full {
+		start, count = b.next, len(b.entries)
+	}
+	for i := 0; i < count; i++ {
+		entry := b.entries[(start+i)%len(b.entries)]
+		if entry.player == player {
+			lines = append(lines, entry.line)
+		}
+	}
+	return lines
+}
+
+// recentEntriesCore 将日志同时记录到最近日志缓冲区的 zapcore.Core
+type recentEntriesCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	buffer  *recentEntriesBuffer
+	player  string // 通过 With 附加的玩家字段值
+}
+
+// newRecentEntriesCore 创建最近日志记录 Core，统一使用 JSON 编码以便审核工具解析
+func newRecentEntriesCore(buffer *recentEntriesBuffer) *recentEntriesCore {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	return &recentEntriesCore{
+		LevelEnabler: atomicLevel,
+		encoder:      zapcore.NewJSONEncoder(encoderConfig),
+		buffer:       buffer,
+	}
+}
+
+func (c *recentEntriesCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &recentEntriesCore{
+		LevelEnabler: c.LevelEnabler,
+		encoder:      c.encoder.Clone(),
+		buffer:       c.buffer,
+		player:       c.player,
+	}
+	for i := range fields {
+		fields[i].AddTo(clone.encoder)
+		if fields[i].Key == c.buffer.playerKey {
+			clone.player = fieldValueString(fields[i])
+		}
+	}
+	return clone
+}
+
+func (c *recentEntriesCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *recentEntriesCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	player := c.player
+	for i := range fields {
+		if fields[i].Key == c.buffer.playerKey {
+			player = fieldValueString(fields[i])
+		}
+	}
+	// 只有带玩家字段的日志才可能进入证据包
+	if player == "" {
+		return nil
+	}
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	line := append([]byte(nil), buf.Bytes()...)
+	buf.Free()
+	c.buffer.add(line, player)
+	return nil
+}
+
+func (c *recentEntriesCore) Sync() error {
+	return nil
+}
+
+// fieldValueString 将字段值转换为字符串，用于匹配玩家ID
+func fieldValueString(field zapcore.Field) string {
+	switch field.Type {
+	case zapcore.StringType:
+		return field.String
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return strconv.FormatInt(field.Integer, 10)
+	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		return strconv.FormatUint(uint64(field.Integer), 10)
+	case zapcore.StringerType:
+		if s, ok := field.Interface.(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+	if field.Interface != nil {
+		return fmt.Sprint(field.Interface)
+	}
+	return ""
+}
+
+// setRecentEntriesBuffer 替换全局最近日志缓冲区
+func setRecentEntriesBuffer(buffer *recentEntriesBuffer) {
+	recentEntriesMutex.Lock()
+	recentEntries = buffer
+	recentEntriesMutex.Unlock()
+}
+
+// WriteEvidenceBundle 为被反作弊系统标记的玩家生成证据包，返回证据包文件路径
+// 证据包为单个 zip 文件，写入服务日志目录下的 evidence/ 目录，包含：
+//   - entries.jsonl      最近日志缓冲区中该玩家的日志（JSON 行，按时间顺序）
+//   - attachments/<name> 调用方提供的附件
+//   - manifest.json      以上每个文件的大小和 SHA-256
+//
+// 需要配置 evidence-buffer-size 启用最近日志缓冲区，日志通过 evidence-player-key 字段（默认 player_id）关联玩家
+func WriteEvidenceBundle(playerID, reason string, attachments ...EvidenceAttachment) (string, error) {
+	recentEntriesMutex.RLock()
+	buffer := recentEntries
+	recentEntriesMutex.RUnlock()
+	if buffer == nil {
+		return "", ErrEvidenceDisabled
+	}
+
+	// 异步模式下先确保已入队的日志写入缓冲区
+	if al, ok := getAsyncLogger(); ok {
+		al.Flush()
+	}
+
+	lines := buffer.collect(playerID)
+	var entries []byte
+	for _, line := range lines {
+		entries = append(entries, line...)
+	}
+
+	dir := filepath.Join(serviceLogDir(), "evidence")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	bundlePath := filepath.Join(dir, fmt.Sprintf("%s-%s.zip", sanitizeSceneID(playerID), now.Format("20060102-150405.000")))
+
+	file, err := os.OpenFile(bundlePath, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	if err != nil {
+		return "", err
+	}
+	manifest := EvidenceManifest{
+		PlayerID:   playerID,
+		Reason:     reason,
+		CreatedAt:  now,
+		EntryCount: len(lines),
+	}
+	zw := zip.NewWriter(file)
+	writeFile := func(name string, data []byte) error {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		manifest.Files = append(manifest.Files, EvidenceManifestFile{Name: name, Size: len(data), SHA256: hex.EncodeToString(sum[:])})
+		return nil
+	}
+
+	err = writeFile("entries.jsonl", entries)
+	for i := 0; err == nil && i < len(attachments); i++ {
+		err = writeFile(path.Join("attachments", path.Base(filepath.ToSlash(attachments[i].Name))), attachments[i].Data)
+	}
+	if err == nil {
+		var data []byte
+		if data, err = json.MarshalIndent(manifest, "", "  "); err == nil {
+			var w io.Writer
+			if w, err = zw.Create("manifest.json"); err == nil {
+				_, err = w.Write(data)
+			}
+		}
+	}
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(bundlePath)
+		return "", err
+	}
+	return bundlePath, nil
+}
@@ -0,0 +1,78 @@
+package mlog
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestWriteEvidenceBundle 测试证据包只包含目标玩家的最近日志，且清单哈希与内容一致
+func TestWriteEvidenceBundle(t *testing.T) {
+	Close()
+	config := ZapConfig{
+		Level:              "info",
+		Format:             "console",
+		Director:           t.TempDir(),
+		SingleFile:         true,
+		EvidenceBufferSize: 3,
+	}
+	InitialZap("evidence", 0, "info", &config)
+	defer Close()
+
+	InfoW("被覆盖的旧日志", zap.Int64("player_id", 42))
+	InfoW("移动速度异常", zap.Int64("player_id", 42), zap.Float64("speed", 99.5))
+	InfoW("其他玩家", zap.Int64("player_id", 7))
+	WarnW("瞬移", zap.String("player_id", "42"))
+
+	bundle, err := WriteEvidenceBundle("42", "speed hack", EvidenceAttachment{Name: "../replay.bin", Data: []byte("replay")})
+	if err != nil {
+		t.Fatalf("生成证据包失败: %v", err)
+	}
+
+	zr, err := zip.OpenReader(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	contents := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+
+	entries := string(contents["entries.jsonl"])
+	if strings.Count(entries, "\n") != 2 || !strings.Contains(entries, "移动速度异常") || !strings.Contains(entries, "瞬移") {
+		t.Errorf("证据包日志内容错误: %s", entries)
+	}
+	if strings.Contains(entries, "被覆盖的旧日志") || strings.Contains(entries, "其他玩家") {
+		t.Errorf("证据包包含不应出现的日志: %s", entries)
+	}
+
+	var manifest EvidenceManifest
+	if err := json.Unmarshal(contents["manifest.json"], &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.EntryCount != 2 || len(manifest.Files) != 2 {
+		t.Fatalf("清单内容错误: %+v", manifest)
+	}
+	for _, f := range manifest.Files {
+		sum := sha256.Sum256(contents[f.Name])
+		if hex.EncodeToString(sum[:]) != f.SHA256 {
+			t.Errorf("文件 %s 哈希不一致", f.Name)
+		}
+	}
+	if string(contents["attachments/replay.bin"]) != "replay" {
+		t.Error("附件内容缺失")
+	}
+}
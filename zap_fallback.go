@@ -0,0 +1,69 @@
+package mlog
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// syncFallbackSaturation 队列使用率达到该比例视为饱和
+	syncFallbackSaturation = 0.95
+	// syncFallbackRecovery 同步写入期间队列使用率回落到该比例以下视为恢复
+	syncFallbackRecovery = 0.5
+)
+
+// syncFallbackActive 检查队列是否应切换为同步写入
+// 队列持续饱和超过 syncFallbackAfter 后，生产者改为直接同步写入，避免消费者永远追不上；
+// 队列回落到 syncFallbackRecovery 以下后恢复异步写入。未配置 async-sync-fallback-ms 时始终返回 false
+func (al *AsyncLogger) syncFallbackActive(q *asyncQueue) bool {
+	if al.syncFallbackAfter <= 0 {
+		return false
+	}
+
+	utilization := float64(q.ring.len()) / float64(q.ring.capacity())
+	active := atomic.LoadInt32(&q.syncFallback) == 1
+	if utilization >= syncFallbackSaturation {
+		now := al.clock().UnixNano()
+		since := atomic.LoadInt64(&q.saturatedSince)
+		if since == 0 {
+			atomic.CompareAndSwapInt64(&q.saturatedSince, 0, now)
+			return active
+		}
+		if !active && time.Duration(now-since) >= al.syncFallbackAfter && atomic.CompareAndSwapInt32(&q.syncFallback, 0, 1) {
+			warnSyncFallback(fmt.Sprintf("异步队列 %s 持续饱和超过 %v，切换为同步写入", q.name, al.syncFallbackAfter), utilization)
+			return true
+		}
+		return active
+	}
+
+	atomic.StoreInt64(&q.saturatedSince, 0)
+	if active && utilization <= syncFallbackRecovery && atomic.CompareAndSwapInt32(&q.syncFallback, 1, 0) {
+		warnSyncFallback(fmt.Sprintf("异步队列 %s 已恢复，切换回异步写入", q.name), utilization)
+		return false
+	}
+	return active
+}
+
+// clock 返回当前时间，测试可通过 now 注入时钟
+func (al *AsyncLogger) clock() time.Time {
+	if al.now != nil {
+		return al.now()
+	}
+	return time.Now()
+}
+
+// writeSyncFallback 在生产者 goroutine 上直接写入日志条目
+func (al *AsyncLogger) writeSyncFallback(q *asyncQueue, entry AsyncLogEntry) {
+	atomic.AddUint64(&q.syncWrites, 1)
+	al.processLogEntry(entry)
+}
+
+// warnSyncFallback 直接通过同步日志器输出同步写入切换的警告
+func warnSyncFallback(msg string, utilization float64) {
+	if logger, ok := getLogger(); ok {
+		logger.Warn(msg, zap.Float64("queue_utilization", utilization))
+	}
+}
@@ -0,0 +1,44 @@
+package mlog
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestSyncFallback 测试队列持续饱和后切换为同步写入，恢复后切回异步
+func TestSyncFallback(t *testing.T) {
+	Close()
+	now := time.Unix(1000, 0)
+	al := &AsyncLogger{notify: make(chan struct{}, 1), done: make(chan struct{}), syncFallbackAfter: time.Second,
+		now: func() time.Time { return now }}
+	al.setupQueues(4, false, nil)
+	q := al.queues[0]
+
+	for i := 0; i < 4; i++ {
+		al.enqueue(&AsyncLogEntry{Level: zapcore.InfoLevel})
+	}
+	if al.syncFallbackActive(q) {
+		t.Fatal("刚开始饱和时不应切换为同步写入")
+	}
+	now = now.Add(500 * time.Millisecond)
+	if al.syncFallbackActive(q) {
+		t.Fatal("饱和时长未达到阈值时不应切换为同步写入")
+	}
+	now = now.Add(500 * time.Millisecond)
+	if !al.enqueue(&AsyncLogEntry{Level: zapcore.InfoLevel}) {
+		t.Fatal("同步写入时应返回成功")
+	}
+	if stats := q.stats(); !stats.SyncFallback || stats.SyncWrites != 1 || stats.Enqueued != 4 {
+		t.Fatalf("同步写入统计错误: %+v", stats)
+	}
+
+	var out AsyncLogEntry
+	for al.tryPop(&out) {
+	}
+	al.enqueue(&AsyncLogEntry{Level: zapcore.InfoLevel})
+	if stats := q.stats(); stats.SyncFallback || stats.Enqueued != 5 {
+		t.Fatalf("队列恢复后应切回异步写入: %+v", stats)
+	}
+}
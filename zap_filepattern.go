@@ -0,0 +1,134 @@
+package mlog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultFilePatternDateLayout {date} 未指定格式时使用的日期格式
+const defaultFilePatternDateLayout = "2006-01-02"
+
+// filePatternPart 文件名模板的一段：固定文本或占位符
+type filePatternPart struct {
+	text        string // 固定文本
+	placeholder string // service、id、level 或 date，为空表示固定文本
+	layout      string // date 占位符的时间格式
+}
+
+// fileNamePattern 解析后的日志文件名模板
+// 支持的占位符：{service} 服务名、{id} 服务ID、{level} 级别（单文件模式为 all）、
+// {date} 或 {date:<Go 时间格式>} 日期，如 {service}-{level}-{date:2006-01-02}.log
+type fileNamePattern struct {
+	parts   []filePatternPart
+	hasDate bool
+}
+
+// parseFilePattern 解析文件名模板，空字符串返回 nil 表示使用默认文件名
+func parseFilePattern(s string) (*fileNamePattern, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if strings.ContainsAny(s, `/\`) {
+		return nil, fmt.Errorf("文件名模板 %q 不能包含路径分隔符", s)
+	}
+	p := &fileNamePattern{}
+	rest := s
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			p.parts = append(p.parts, filePatternPart{text: rest})
+			break
+		}
+		if open > 0 {
+			p.parts = append(p.parts, filePatternPart{text: rest[:open]})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("文件名模板 %q 中的占位符没有闭合", s)
+		}
+		name, layout, _ := strings.Cut(rest[open+1:open+end], ":")
+		switch name {
+		case "service", "id", "level":
+			if layout != "" {
+				return nil, fmt.Errorf("文件名模板 %q 中的占位符 {%s} 不支持格式", s, name)
+			}
+		case "date":
+			if layout == "" {
+				layout = defaultFilePatternDateLayout
+			}
+			if strings.ContainsAny(layout, `/\`) {
+				return nil, fmt.Errorf("文件名模板 %q 的日期格式不能包含路径分隔符", s)
+			}
+			p.hasDate = true
+		default:
+			return nil, fmt.Errorf("文件名模板 %q 中有未知的占位符 {%s}", s, name)
+		}
+		p.parts = append(p.parts, filePatternPart{placeholder: name, layout: layout})
+		rest = rest[open+end+1:]
+	}
+	return p, nil
+}
+
+// render 按服务信息、级别和时间生成文件名
+func (p *fileNamePattern) render(serviceName string, serviceID uint64, level string, now time.Time) string {
+	var sb strings.Builder
+	for _, part := range p.parts {
+		switch part.placeholder {
+		case "":
+			sb.WriteString(part.text)
+		case "service":
+			sb.WriteString(serviceName)
+		case "id":
+			sb.WriteString(strconv.FormatUint(serviceID, 10))
+		case "level":
+			sb.WriteString(level)
+		case "date":
+			sb.WriteString(now.Format(part.layout))
+		}
+	}
+	return sb.String()
+}
+
+// matcher 生成匹配模板文件名的正则表达式，用于在日志目录中识别按模板生成的文件
+// 服务名和服务ID匹配任意值，日期按格式中数字串和字母串的位置匹配
+func (p *fileNamePattern) matcher() *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, part := range p.parts {
+		switch part.placeholder {
+		case "":
+			sb.WriteString(regexp.QuoteMeta(part.text))
+		case "service":
+			sb.WriteString(`.+?`)
+		case "id":
+			sb.WriteString(`\d+`)
+		case "level":
+			sb.WriteString(`(?:debug|info|warn|error|dpanic|panic|fatal|all)`)
+		case "date":
+			sample := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC).Format(part.layout)
+			for i := 0; i < len(sample); {
+				c := sample[i]
+				switch {
+				case c >= '0' && c <= '9':
+					for i < len(sample) && sample[i] >= '0' && sample[i] <= '9' {
+						i++
+					}
+					sb.WriteString(`\d+`)
+				case c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z':
+					for i < len(sample) && (sample[i] >= 'A' && sample[i] <= 'Z' || sample[i] >= 'a' && sample[i] <= 'z') {
+						i++
+					}
+					sb.WriteString(`[A-Za-z]+`)
+				default:
+					sb.WriteString(regexp.QuoteMeta(string(c)))
+					i++
+				}
+			}
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
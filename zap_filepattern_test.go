@@ -0,0 +1,74 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestFilePatternRender 测试文件名模板的占位符和校验
+func TestFilePatternRender(t *testing.T) {
+	p, err := parseFilePattern("{service}-{id}-{level}-{date:20060102}.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 5, 13, 0, 0, 0, time.Local)
+	if got := p.render("game", 3, "info", now); got != "game-3-info-20260305.log" {
+		t.Fatalf("文件名错误: %s", got)
+	}
+	p, _ = parseFilePattern("{level}.{date}.log")
+	if got := p.render("game", 3, "all", now); got != "all.2026-03-05.log" || !p.hasDate {
+		t.Fatalf("默认日期格式错误: %s", got)
+	}
+	for _, s := range []string{"{service", "{host}.log", "logs/{level}.log", "{level:x}.log"} {
+		if _, err := parseFilePattern(s); err == nil {
+			t.Fatalf("文件名模板 %s 应校验失败", s)
+		}
+	}
+}
+
+// TestFilePatternSwitchDate 测试跨日后切换到新日期的文件
+func TestFilePatternSwitchDate(t *testing.T) {
+	dir := t.TempDir()
+	p, _ := parseFilePattern("{level}-{date}.log")
+	name := func(now time.Time) string {
+		return p.render("", 0, "info", now)
+	}
+	day1 := time.Date(2026, 3, 5, 23, 59, 59, 0, time.Local)
+	f := newFileWriteSyncer(filepath.Join(dir, name(day1)))
+	f.fileName = name
+	defer f.Close()
+
+	f.switchFileIfDue(day1)
+	f.logger.Write([]byte("第一天\n"))
+	f.switchFileIfDue(day1.Add(time.Second))
+	f.logger.Write([]byte("第二天\n"))
+
+	for file, want := range map[string]string{"info-2026-03-05.log": "第一天\n", "info-2026-03-06.log": "第二天\n"} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil || string(data) != want {
+			t.Fatalf("%s 内容错误: %q %v", file, data, err)
+		}
+	}
+}
+
+// TestFilePatternInitialZap 测试按级别分文件模式使用模板文件名
+func TestFilePatternInitialZap(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{
+		Level:       "info",
+		Director:    dir,
+		MaxSize:     10,
+		FilePattern: "{service}-{level}-{date:2006-01-02}.log",
+	}
+	InitialZap("gate", 2, "info", &config)
+	Info("模板文件名")
+	Close()
+
+	file := filepath.Join(dir, "2", "gate", "gate-info-"+time.Now().Format("2006-01-02")+".log")
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("未按模板创建日志文件: %v", err)
+	}
+}
@@ -0,0 +1,303 @@
+package mlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Cloud Logging 输出的默认参数
+const (
+	// gcpMetadataTimeout 探测 GCE/GKE 元数据服务的超时时间，不在 GCP 上运行时尽快放弃
+	gcpMetadataTimeout = 500 * time.Millisecond
+	// defaultGCPMetadataHost 元数据服务地址，与官方客户端一样可以通过 GCE_METADATA_HOST 环境变量覆盖
+	defaultGCPMetadataHost = "metadata.google.internal"
+)
+
+// GCPLoggingConfig Google Cloud Logging 输出配置
+type GCPLoggingConfig struct {
+	Enable    bool   `mapstructure:"enable" json:"enable" yaml:"enable"`             // 启用 Cloud Logging 输出（需先调用 SetGCPLogWriter）
+	ProjectID string `mapstructure:"project-id" json:"project-id" yaml:"project-id"` // 项目 ID（默认从元数据服务获取）
+	LogID     string `mapstructure:"log-id" json:"log-id" yaml:"log-id"`             // 日志名（默认服务名）
+	// 附加到每条日志的标签，service、service_id 和 directory 标签自动添加
+	Labels map[string]string `mapstructure:"labels" json:"labels" yaml:"labels"`
+	// 受监控资源类型，为空时自动探测：GKE 上为 k8s_container，GCE 上为 gce_instance，其余为 global
+	ResourceType      string `mapstructure:"resource-type" json:"resource-type" yaml:"resource-type"`
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// GCPResource Cloud Logging 受监控资源
+type GCPResource struct {
+	Type   string
+	Labels map[string]string
+}
+
+// GCPSourceLocation 日志的调用位置
+type GCPSourceLocation struct {
+	File     string
+	Line     int64
+	Function string
+}
+
+// GCPLogEntry 写入 Cloud Logging 的一条日志
+type GCPLogEntry struct {
+	Timestamp      time.Time
+	Severity       string          // DEBUG、INFO、WARNING、ERROR、CRITICAL、ALERT 或 EMERGENCY
+	Payload        json.RawMessage // jsonPayload，包含 message 和日志字段
+	Labels         map[string]string
+	Resource       GCPResource
+	SourceLocation *GCPSourceLocation // 未记录调用位置时为 nil
+}
+
+// GCPLogWriter Cloud Logging 写入接口
+// mlog 不直接依赖 GCP 客户端库，由应用使用官方客户端（cloud.google.com/go/logging）实现，
+// 例如对每条日志调用 Logger.Log 并在最后 Flush，Payload 可直接作为 logging.Entry.Payload。
+// 返回错误时整批重试。
+type GCPLogWriter interface {
+	WriteLogEntries(ctx context.Context, logID string, entries []GCPLogEntry) error
+}
+
+var (
+	gcpLogWriter      GCPLogWriter
+	gcpLogWriterMutex sync.RWMutex
+)
+
+// SetGCPLogWriter 设置 Cloud Logging 写入接口，需要在 InitialZap 之前调用，传入 nil 取消
+func SetGCPLogWriter(writer GCPLogWriter) {
+	gcpLogWriterMutex.Lock()
+	gcpLogWriter = writer
+	gcpLogWriterMutex.Unlock()
+}
+
+// getGCPLogWriter 获取当前的 Cloud Logging 写入接口
+func getGCPLogWriter() GCPLogWriter {
+	gcpLogWriterMutex.RLock()
+	defer gcpLogWriterMutex.RUnlock()
+	return gcpLogWriter
+}
+
+// gcpSeverity 将日志级别映射为 Cloud Logging 严重程度
+// Critical（Warn + emergency 目录）映射为 CRITICAL，Disaster（Error + emergency 目录）映射为 ALERT
+func gcpSeverity(level zapcore.Level, directory string) string {
+	if directory == emergencyDirectory {
+		if level >= zapcore.ErrorLevel {
+			return "ALERT"
+		}
+		return "CRITICAL"
+	}
+	switch level {
+	case zapcore.DebugLevel:
+		return "DEBUG"
+	case zapcore.InfoLevel:
+		return "INFO"
+	case zapcore.WarnLevel:
+		return "WARNING"
+	case zapcore.ErrorLevel:
+		return "ERROR"
+	case zapcore.DPanicLevel:
+		return "CRITICAL"
+	case zapcore.PanicLevel:
+		return "ALERT"
+	case zapcore.FatalLevel:
+		return "EMERGENCY"
+	}
+	return "DEFAULT"
+}
+
+// newGCPLoggingCore 创建 Cloud Logging 输出 Core
+func newGCPLoggingCore(cfg GCPLoggingConfig, serviceName string, serviceID uint64) (*gcpCore, error) {
+	writer := getGCPLogWriter()
+	if writer == nil {
+		return nil, errors.New("未设置 Cloud Logging 写入接口，请先调用 SetGCPLogWriter")
+	}
+	logID := cfg.LogID
+	if logID == "" {
+		logID = serviceName
+	}
+	if logID == "" {
+		return nil, errors.New("未配置 Cloud Logging 日志名")
+	}
+	resource, err := detectGCPResource(cfg.ProjectID, cfg.ResourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{
+		"service":    serviceName,
+		"service_id": fmt.Sprint(serviceID),
+	}
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+	batcher := newRemoteBatcher("gcp", cfg.RemoteBatchConfig, func(ctx context.Context, batch []remoteRecord) error {
+		entries := make([]GCPLogEntry, len(batch))
+		for i := range batch {
+			entry := batch[i].Entry
+			entryLabels := labels
+			if batch[i].Key != "" {
+				entryLabels = make(map[string]string, len(labels)+1)
+				for k, v := range labels {
+					entryLabels[k] = v
+				}
+				entryLabels["directory"] = batch[i].Key
+			}
+			entries[i] = GCPLogEntry{
+				Timestamp: entry.Time,
+				Severity:  gcpSeverity(entry.Level, batch[i].Key),
+				Payload:   batch[i].Data,
+				Labels:    entryLabels,
+				Resource:  resource,
+			}
+			if entry.Caller.Defined {
+				entries[i].SourceLocation = &GCPSourceLocation{
+					File:     entry.Caller.File,
+					Line:     int64(entry.Caller.Line),
+					Function: entry.Caller.Function,
+				}
+			}
+		}
+		return writer.WriteLogEntries(ctx, logID, entries)
+	})
+	addSinkCloser(batcher)
+	return &gcpCore{LevelEnabler: atomicLevel, batcher: batcher}, nil
+}
+
+// detectGCPResource 确定受监控资源，projectID 为空时从元数据服务获取
+func detectGCPResource(projectID, resourceType string) (GCPResource, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = defaultGCPMetadataHost
+	}
+	client := &http.Client{Timeout: gcpMetadataTimeout}
+	metadata := func(path string) string { return "" }
+	if id, err := gcpMetadata(client, host, "project/project-id"); err == nil {
+		metadata = func(path string) string {
+			v, _ := gcpMetadata(client, host, path)
+			return v
+		}
+		if projectID == "" {
+			projectID = id
+		}
+	}
+	if projectID == "" {
+		return GCPResource{}, errors.New("未配置 GCP 项目 ID，且无法从元数据服务获取")
+	}
+
+	if resourceType == "" {
+		switch {
+		case metadata("instance/id") == "":
+			resourceType = "global"
+		case os.Getenv("KUBERNETES_SERVICE_HOST") != "":
+			resourceType = "k8s_container"
+		default:
+			resourceType = "gce_instance"
+		}
+	}
+	labels := map[string]string{"project_id": projectID}
+	switch resourceType {
+	case "gce_instance":
+		labels["instance_id"] = metadata("instance/id")
+		labels["zone"] = lastPathSegment(metadata("instance/zone"))
+	case "k8s_container":
+		labels["location"] = metadata("instance/attributes/cluster-location")
+		labels["cluster_name"] = metadata("instance/attributes/cluster-name")
+		labels["namespace_name"] = os.Getenv("POD_NAMESPACE")
+		if labels["namespace_name"] == "" {
+			if ns, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace"); err == nil {
+				labels["namespace_name"] = strings.TrimSpace(string(ns))
+			}
+		}
+		labels["pod_name"] = os.Getenv("POD_NAME")
+		if labels["pod_name"] == "" {
+			labels["pod_name"], _ = os.Hostname()
+		}
+		labels["container_name"] = os.Getenv("CONTAINER_NAME")
+	}
+	return GCPResource{Type: resourceType, Labels: labels}, nil
+}
+
+// gcpMetadata 查询元数据服务
+func gcpMetadata(client *http.Client, host, path string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, "http://"+host+"/computeMetadata/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("元数据服务返回 %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return strings.TrimSpace(string(body)), err
+}
+
+// lastPathSegment 返回路径的最后一段，如 projects/123/zones/asia-east1-a 返回 asia-east1-a
+func lastPathSegment(path string) string {
+	return path[strings.LastIndexByte(path, '/')+1:]
+}
+
+// gcpCore 将日志转换为 Cloud Logging 条目的 zapcore.Core
+// business/folder/directory 字段作为 directory 标签，消息和其余字段编码为 jsonPayload
+type gcpCore struct {
+	zapcore.LevelEnabler
+	batcher *remoteBatcher
+	fields  []zapcore.Field // 通过 With 附加的字段
+}
+
+func (c *gcpCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(append([]zapcore.Field(nil), c.fields...), fields...)
+	return &clone
+}
+
+func (c *gcpCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *gcpCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for i := range c.fields {
+		c.fields[i].AddTo(enc)
+	}
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	directory := ""
+	for k, v := range enc.Fields {
+		if gelfCategoryFields[k] {
+			directory = fmt.Sprint(v)
+			delete(enc.Fields, k)
+		}
+	}
+	enc.Fields["message"] = entry.Message
+	if entry.Stack != "" {
+		// Error Reporting 从 stack_trace 字段识别错误
+		enc.Fields["stack_trace"] = entry.Message + "\n" + entry.Stack
+	}
+	payload, err := json.Marshal(enc.Fields)
+	if err != nil {
+		return err
+	}
+	c.batcher.add(remoteRecord{Entry: entry, Key: directory, Data: payload})
+	return nil
+}
+
+func (c *gcpCore) Sync() error {
+	return nil
+}
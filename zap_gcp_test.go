@@ -0,0 +1,95 @@
+package mlog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// fakeGCPLogWriter 记录日志的测试写入接口
+type fakeGCPLogWriter struct {
+	mu      sync.Mutex
+	logID   string
+	entries []GCPLogEntry
+}
+
+func (w *fakeGCPLogWriter) WriteLogEntries(ctx context.Context, logID string, entries []GCPLogEntry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.logID = logID
+	w.entries = append(w.entries, entries...)
+	return nil
+}
+
+// startGCPMetadataServer 启动模拟 GCE 元数据服务的测试服务端
+func startGCPMetadataServer(t *testing.T) {
+	values := map[string]string{
+		"project/project-id": "mmo-prod",
+		"instance/id":        "4520031799277581759",
+		"instance/zone":      "projects/123456/zones/asia-east1-b",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, ok := values[strings.TrimPrefix(r.URL.Path, "/computeMetadata/v1/")]
+		if !ok || r.Header.Get("Metadata-Flavor") != "Google" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(v))
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+}
+
+// TestGCPLogging 测试严重程度映射、资源探测、标签和 jsonPayload
+func TestGCPLogging(t *testing.T) {
+	startGCPMetadataServer(t)
+	writer := &fakeGCPLogWriter{}
+	SetGCPLogWriter(writer)
+	defer SetGCPLogWriter(nil)
+
+	core, err := newGCPLoggingCore(GCPLoggingConfig{Labels: map[string]string{"region": "tw"}}, "battle", 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	log := zap.New(core)
+	log.Warn("匹配超时", zap.Int("waited", 30))
+	log.Error("战斗服崩溃", zap.String("directory", emergencyDirectory))
+	core.batcher.Close()
+
+	if writer.logID != "battle" || len(writer.entries) != 2 {
+		t.Fatalf("写入的日志错误: %s %+v", writer.logID, writer.entries)
+	}
+	first, second := writer.entries[0], writer.entries[1]
+	if first.Severity != "WARNING" || second.Severity != "ALERT" {
+		t.Fatalf("严重程度映射错误: %s %s", first.Severity, second.Severity)
+	}
+	resource := first.Resource
+	if resource.Type != "gce_instance" || resource.Labels["project_id"] != "mmo-prod" ||
+		resource.Labels["zone"] != "asia-east1-b" || resource.Labels["instance_id"] != "4520031799277581759" {
+		t.Fatalf("受监控资源错误: %+v", resource)
+	}
+	if first.Labels["service"] != "battle" || first.Labels["service_id"] != "9" || first.Labels["region"] != "tw" ||
+		first.Labels["directory"] != "" || second.Labels["directory"] != emergencyDirectory {
+		t.Fatalf("标签错误: %v %v", first.Labels, second.Labels)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(first.Payload, &payload); err != nil || payload["message"] != "匹配超时" || payload["waited"] != float64(30) {
+		t.Fatalf("jsonPayload 错误: %s", first.Payload)
+	}
+}
+
+// TestGCPLoggingRequiresWriter 测试未设置写入接口时返回错误
+func TestGCPLoggingRequiresWriter(t *testing.T) {
+	if _, err := newGCPLoggingCore(GCPLoggingConfig{ProjectID: "p"}, "battle", 1); err == nil {
+		t.Fatal("未设置写入接口应该返回错误")
+	}
+}
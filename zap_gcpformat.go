@@ -0,0 +1,115 @@
+package mlog
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// FormatGCP Format 配置为 gcp 时按 Cloud Logging 结构化日志格式输出 JSON，
+// GKE、Cloud Run 等环境的日志代理直接识别其中的级别、时间、代码位置和链路
+const FormatGCP = "gcp"
+
+// Cloud Logging 结构化日志中的特殊字段
+const (
+	gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+	gcpTraceKey          = "logging.googleapis.com/trace"
+	gcpSpanIDKey         = "logging.googleapis.com/spanId"
+	gcpLabelsKey         = "logging.googleapis.com/labels"
+)
+
+// gcpEncoder Cloud Logging 结构化日志编码器
+// severity 与 Cloud Logging 输出的映射一致（emergency 目录的日志提升为 CRITICAL/ALERT），调用位置输出为 sourceLocation，
+// trace_id/span_id 字段转换为 trace/spanId（配置了项目 ID 时 trace 补全为 projects/<项目>/traces/<ID>），服务信息输出为标签
+type gcpEncoder struct {
+	zapcore.Encoder
+	project      string
+	relativePath bool
+}
+
+// newGCPEncoder 创建 Cloud Logging 结构化日志编码器
+// 项目 ID 使用 GCPLogging.ProjectID，未配置时使用 GOOGLE_CLOUD_PROJECT 环境变量
+func newGCPEncoder(c *ZapConfig) zapcore.Encoder {
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		TimeKey:        "timestamp",
+		NameKey:        "logger",
+		MessageKey:     "message",
+		StacktraceKey:  "stack_trace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+	})
+	project := c.GCPLogging.ProjectID
+	if project == "" {
+		project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	return &gcpEncoder{Encoder: enc, project: project, relativePath: c.UseRelativePath}
+}
+
+// addService 添加 service 和 service_id 标签
+func (e *gcpEncoder) addService(serviceName string, serviceID uint64) {
+	e.AddObject(gcpLabelsKey, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		if serviceName != "" {
+			enc.AddString("service", serviceName)
+		}
+		if serviceID != 0 {
+			enc.AddString("service_id", strconv.FormatUint(serviceID, 10))
+		}
+		return nil
+	}))
+}
+
+func (e *gcpEncoder) Clone() zapcore.Encoder {
+	return &gcpEncoder{Encoder: e.Encoder.Clone(), project: e.project, relativePath: e.relativePath}
+}
+
+func (e *gcpEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	converted := make([]zapcore.Field, 0, len(fields)+2)
+	directory := ""
+	for _, f := range fields {
+		switch {
+		case f.Type != zapcore.StringType:
+		case f.Key == "directory":
+			directory = f.String
+		case f.Key == "trace_id" && f.String != "":
+			converted = append(converted, zap.String(gcpTraceKey, e.trace(f.String)))
+			continue
+		case f.Key == "span_id" && f.String != "":
+			converted = append(converted, zap.String(gcpSpanIDKey, f.String))
+			continue
+		}
+		converted = append(converted, f)
+	}
+	converted = append(converted, zap.String("severity", gcpSeverity(entry.Level, directory)))
+	if entry.Caller.Defined {
+		file := entry.Caller.File
+		if e.relativePath {
+			file = getRelativePath(file)
+		}
+		caller := entry.Caller
+		converted = append(converted, zap.Object(gcpSourceLocationKey, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("file", file)
+			enc.AddString("line", strconv.Itoa(caller.Line))
+			if caller.Function != "" {
+				enc.AddString("function", caller.Function)
+			}
+			return nil
+		})))
+	}
+	// 时间统一使用 UTC，与日志代理的解析结果一致
+	entry.Time = entry.Time.In(time.UTC)
+	return e.Encoder.EncodeEntry(entry, converted)
+}
+
+// trace 返回 Cloud Logging 的 trace 字段值，已经是完整资源名或未配置项目 ID 时原样返回
+func (e *gcpEncoder) trace(id string) string {
+	if e.project == "" || strings.HasPrefix(id, "projects/") {
+		return id
+	}
+	return "projects/" + e.project + "/traces/" + id
+}
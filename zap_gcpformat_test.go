@@ -0,0 +1,57 @@
+package mlog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestGCPFormat 测试 gcp 格式输出 severity、timestamp、sourceLocation、trace 和服务标签
+func TestGCPFormat(t *testing.T) {
+	Close()
+	// 格式配置会影响之后直接使用全局配置的测试，结束时恢复
+	oldConfig := zapConfig
+	defer func() { zapConfig = oldConfig }()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: FormatGCP, ShowLine: true,
+		GCPLogging: GCPLoggingConfig{ProjectID: "mmo-prod"}}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	WarnW("登录排队", zap.String("trace_id", "4bf92f3577b34da6"), zap.String("span_id", "00f067aa0ba902b7"))
+	Flush()
+
+	data, err := os.ReadFile(filepath.Join(dir, "2", "gate", "warn.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &entry); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]any{
+		"message":    "登录排队",
+		"severity":   "WARNING",
+		gcpTraceKey:  "projects/mmo-prod/traces/4bf92f3577b34da6",
+		gcpSpanIDKey: "00f067aa0ba902b7",
+	} {
+		if entry[key] != want {
+			t.Fatalf("%s 应为 %v: %v", key, want, entry)
+		}
+	}
+	if ts, _ := entry["timestamp"].(string); !strings.HasSuffix(ts, "Z") {
+		t.Fatalf("timestamp 应为 UTC 时间: %v", entry["timestamp"])
+	}
+	location, _ := entry[gcpSourceLocationKey].(map[string]any)
+	if file, _ := location["file"].(string); !strings.HasSuffix(file, "zap_gcpformat_test.go") || location["line"] == nil {
+		t.Fatalf("sourceLocation 错误: %v", entry)
+	}
+	labels, _ := entry[gcpLabelsKey].(map[string]any)
+	if labels["service"] != "gate" || labels["service_id"] != "2" {
+		t.Fatalf("服务标签错误: %v", entry)
+	}
+}
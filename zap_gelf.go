@@ -0,0 +1,259 @@
+package mlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// GELF UDP 分块相关常量
+const (
+	defaultGELFChunkSize = 1420
+	gelfChunkHeaderSize  = 12
+	gelfMaxChunks        = 128
+)
+
+// gelfChunkMagic GELF 分块消息的魔数
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+// gelfCategoryFields 日志分目录字段，在 GELF 中统一映射为 _category，便于 Graylog 按分类建立 Stream
+var gelfCategoryFields = map[string]bool{"business": true, "folder": true, "directory": true}
+
+// GELFConfig Graylog GELF 输出配置
+type GELFConfig struct {
+	Enable            bool   `mapstructure:"enable" json:"enable" yaml:"enable"`             // 启用 GELF 输出
+	Network           string `mapstructure:"network" json:"network" yaml:"network"`          // udp 或 tcp（默认 udp）
+	Address           string `mapstructure:"address" json:"address" yaml:"address"`          // Graylog 输入地址，如 127.0.0.1:12201
+	Compress          bool   `mapstructure:"compress" json:"compress" yaml:"compress"`       // UDP 消息使用 gzip 压缩（TCP 不支持压缩）
+	ChunkSize         int    `mapstructure:"chunk-size" json:"chunk-size" yaml:"chunk-size"` // UDP 分块大小（字节，默认 1420）
+	Host              string `mapstructure:"host" json:"host" yaml:"host"`                   // host 字段（默认为本机主机名）
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// gelfWriter GELF 消息发送器
+type gelfWriter struct {
+	network   string
+	address   string
+	compress  bool
+	chunkSize int
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// newGELFCore 创建 GELF 输出 Core
+func newGELFCore(cfg GELFConfig, serviceName string, serviceID uint64) (*gelfCore, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("未配置 GELF 服务器地址")
+	}
+	network := cfg.Network
+	if network == "" {
+		network = "udp"
+	}
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("不支持的 GELF 连接方式: %s", network)
+	}
+	chunkSize := positiveOr(cfg.ChunkSize, defaultGELFChunkSize)
+	if chunkSize <= gelfChunkHeaderSize {
+		return nil, fmt.Errorf("GELF 分块大小过小: %d", chunkSize)
+	}
+	host := cfg.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+
+	w := &gelfWriter{
+		network:   network,
+		address:   cfg.Address,
+		compress:  cfg.Compress && network == "udp",
+		chunkSize: chunkSize,
+	}
+	batcher := newRemoteBatcher("gelf", cfg.RemoteBatchConfig, w.sendBatch)
+	addSinkCloser(batcher)
+	addSinkCloser(w)
+
+	return &gelfCore{
+		LevelEnabler: atomicLevel,
+		batcher:      batcher,
+		base: map[string]any{
+			"version":       "1.1",
+			"host":          host,
+			"_service_name": serviceName,
+			"_service_id":   serviceID,
+		},
+	}, nil
+}
+
+// sendBatch 逐条发送一批 GELF 消息
+func (w *gelfWriter) sendBatch(ctx context.Context, batch []remoteRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range batch {
+		if err := w.sendLocked(batch[i].Data); err != nil {
+			// 已发送的条目不再重试
+			return &remotePartialError{Retry: batch[i:], Err: err}
+		}
+	}
+	return nil
+}
+
+// sendLocked 发送一条消息，连接断开时重连一次后重试
+func (w *gelfWriter) sendLocked(data []byte) error {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			if w.conn, err = net.DialTimeout(w.network, w.address, 3*time.Second); err != nil {
+				w.conn = nil
+				continue
+			}
+		}
+		if w.network == "tcp" {
+			// TCP 每条消息以空字节结尾
+			_, err = w.conn.Write(append(data, 0))
+		} else {
+			err = w.writeUDP(data)
+		}
+		if err == nil {
+			return nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	return err
+}
+
+// writeUDP 发送 UDP 消息，超过分块大小时按 GELF 分块格式拆分
+func (w *gelfWriter) writeUDP(data []byte) error {
+	if w.compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(data)
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+	if len(data) <= w.chunkSize {
+		_, err := w.conn.Write(data)
+		return err
+	}
+
+	payload := w.chunkSize - gelfChunkHeaderSize
+	count := (len(data) + payload - 1) / payload
+	if count > gelfMaxChunks {
+		return fmt.Errorf("GELF 消息过大: %d 字节超过 %d 个分块", len(data), gelfMaxChunks)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	chunk := make([]byte, 0, w.chunkSize)
+	for i := 0; i < count; i++ {
+		end := min((i+1)*payload, len(data))
+		chunk = append(chunk[:0], gelfChunkMagic...)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, data[i*payload:end]...)
+		if _, err := w.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close 关闭连接
+func (w *gelfWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// gelfCore 将日志编码为 GELF 1.1 消息的 zapcore.Core
+type gelfCore struct {
+	zapcore.LevelEnabler
+	batcher *remoteBatcher
+	base    map[string]any // 固定字段和通过 With 附加的字段
+}
+
+func (c *gelfCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &gelfCore{
+		LevelEnabler: c.LevelEnabler,
+		batcher:      c.batcher,
+		base:         make(map[string]any, len(c.base)+len(fields)),
+	}
+	for k, v := range c.base {
+		clone.base[k] = v
+	}
+	addGELFFields(clone.base, fields)
+	return clone
+}
+
+func (c *gelfCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *gelfCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	msg := make(map[string]any, len(c.base)+len(fields)+6)
+	for k, v := range c.base {
+		msg[k] = v
+	}
+	addGELFFields(msg, fields)
+	msg["short_message"] = entry.Message
+	msg["timestamp"] = float64(entry.Time.UnixMicro()) / 1e6
+	msg["level"] = defaultSyslogSeverity[entry.Level]
+	msg["_level_name"] = entry.Level.String()
+	if entry.Caller.Defined {
+		msg["_caller"] = entry.Caller.TrimmedPath()
+	}
+	if entry.Stack != "" {
+		msg["full_message"] = entry.Stack
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.batcher.add(remoteRecord{Entry: entry, Data: data})
+	return nil
+}
+
+func (c *gelfCore) Sync() error {
+	return nil
+}
+
+// addGELFFields 将日志字段转换为 GELF 附加字段（以下划线开头）
+func addGELFFields(msg map[string]any, fields []zapcore.Field) {
+	enc := zapcore.NewMapObjectEncoder()
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	for k, v := range enc.Fields {
+		switch {
+		case gelfCategoryFields[k]:
+			msg["_category"] = v
+		case k == "id":
+			// GELF 保留 _id 字段
+			msg["_field_id"] = v
+		default:
+			msg["_"+k] = v
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package mlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestGELFUDP 测试 GELF 字段映射、分块和 gzip 压缩
+func TestGELFUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	core, err := newGELFCore(GELFConfig{
+		Address:   conn.LocalAddr().String(),
+		Compress:  true,
+		ChunkSize: 100,
+		Host:      "host1",
+	}, "game", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	zap.New(core).With(zap.String("business", "pay")).Warn(strings.Repeat("充值失败", 200), zap.Int("id", 9))
+	core.batcher.Close()
+
+	// 按序号重组分块
+	var chunks [][]byte
+	buf := make([]byte, 2048)
+	for {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n > 100 || !bytes.HasPrefix(buf[:n], gelfChunkMagic) {
+			t.Fatalf("分块格式错误: %d 字节", n)
+		}
+		if chunks == nil {
+			chunks = make([][]byte, buf[11])
+		}
+		chunks[buf[10]] = append([]byte(nil), buf[gelfChunkHeaderSize:n]...)
+		complete := true
+		for _, c := range chunks {
+			complete = complete && c != nil
+		}
+		if complete {
+			break
+		}
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(bytes.Join(chunks, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(zr)
+
+	var msg map[string]any
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg["version"] != "1.1" || msg["host"] != "host1" || msg["level"] != float64(4) ||
+		msg["_category"] != "pay" || msg["_field_id"] != float64(9) || msg["_service_id"] != float64(3) ||
+		!strings.HasPrefix(msg["short_message"].(string), "充值失败") {
+		t.Fatalf("GELF 字段错误: %v", msg)
+	}
+}
+
+// TestGELFTCP 测试 TCP 消息以空字节分隔
+func TestGELFTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- data
+	}()
+
+	core, err := newGELFCore(GELFConfig{Network: "tcp", Address: ln.Addr().String(), Compress: true}, "game", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	logger := zap.New(core)
+	logger.Info("a")
+	logger.Info("b")
+	// 关闭全部附加输出，断开连接以结束服务端读取
+	coreMutex.Lock()
+	closeSinksLocked()
+	coreMutex.Unlock()
+
+	select {
+	case data := <-received:
+		parts := bytes.Split(bytes.TrimSuffix(data, []byte{0}), []byte{0})
+		if len(parts) != 2 || !json.Valid(parts[0]) || !json.Valid(parts[1]) {
+			t.Fatalf("TCP 分隔错误: %q", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("未收到消息")
+	}
+}
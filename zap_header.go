@@ -0,0 +1,48 @@
+package mlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// fileHeaderMessage 文件头的日志消息
+const fileHeaderMessage = "[mlog] 日志文件头"
+
+// fileHeader 按日志格式编码一行文件头，记录构建信息（version.go）、服务和进程信息
+func fileHeader(serviceName string, serviceID uint64, file string, now time.Time) []byte {
+	hostname, _ := os.Hostname()
+	entry := zapcore.Entry{Level: zapcore.InfoLevel, Time: now, Message: fileHeaderMessage}
+	buf, err := zapConfig.Encoder().EncodeEntry(entry, []zapcore.Field{
+		zap.String("version", Version),
+		zap.String("git_commit", GitCommit),
+		zap.String("build_time", BuildTime),
+		zap.String("service", serviceName),
+		zap.Uint64("service_id", serviceID),
+		zap.Int("pid", os.Getpid()),
+		zap.String("hostname", hostname),
+		zap.String("file", filepath.Base(file)),
+	})
+	if err != nil {
+		return nil
+	}
+	defer buf.Free()
+	return bytes.Clone(buf.Bytes())
+}
+
+// writeFile 写入当前文件，新打开的文件先写入文件头
+func (f *fileWriteSyncer) writeFile(p []byte) (int, error) {
+	if f.header != nil && f.headerPending.CompareAndSwap(true, false) {
+		if header := f.header(f.logger.Filename, time.Now()); len(header) > 0 {
+			if _, err := f.logger.Write(header); err != nil {
+				f.headerPending.Store(true)
+				return 0, err
+			}
+		}
+	}
+	return f.logger.Write(p)
+}
@@ -0,0 +1,48 @@
+package mlog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFileHeader 测试新打开的日志文件第一行为文件头，重新打开后的新文件也写入文件头
+func TestFileHeader(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "json", FileHeader: true}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+	file := filepath.Join(dir, "2", "gate", "info.log")
+
+	InfoW("第一条日志")
+	checkFileHeader(t, file)
+	if err := os.Rename(file, file+".1"); err != nil {
+		t.Fatal(err)
+	}
+	ReopenLogFiles()
+	InfoW("重新打开后的日志")
+	checkFileHeader(t, file)
+}
+
+func checkFileHeader(t *testing.T, file string) {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("应有文件头和一条日志: %s", data)
+	}
+	var header map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header["message"] != fileHeaderMessage || header["version"] != Version || header["service"] != "gate" ||
+		header["service_id"] != float64(2) || header["pid"] != float64(os.Getpid()) || header["file"] != "info.log" {
+		t.Fatalf("文件头内容错误: %v", header)
+	}
+}
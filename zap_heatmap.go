@@ -0,0 +1,130 @@
+package mlog
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// callSites 按调用位置 PC 统计的日志量，进程生命周期内累计
+var callSites sync.Map // map[uintptr]*callSiteCounter
+
+// callSiteCounter 单个调用位置的计数器
+type callSiteCounter struct {
+	file  string
+	line  int
+	count uint64
+	bytes uint64
+}
+
+// CallSiteStat 调用位置日志量统计
+type CallSiteStat struct {
+	Function string `json:"function"` // 调用日志的函数名
+	File     string `json:"file"`     // 源文件
+	Line     int    `json:"line"`     // 行号
+	Count    uint64 `json:"count"`    // 累计日志条数
+	Bytes    uint64 `json:"bytes"`    // 累计编码后的字节数
+}
+
+// callSiteCore 统计每个调用位置日志条数和字节数的 zapcore.Core
+// 使用与日志文件相同的编码器编码以得到真实的输出大小，只在开启 call-site-stats 时加入日志链路
+type callSiteCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+}
+
+// newCallSiteCore 创建调用位置统计 Core
+func newCallSiteCore() *callSiteCore {
+	return &callSiteCore{
+		LevelEnabler: atomicLevel,
+		encoder:      zapConfig.Encoder(),
+	}
+}
+
+func (c *callSiteCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &callSiteCore{LevelEnabler: c.LevelEnabler, encoder: c.encoder.Clone()}
+	for i := range fields {
+		fields[i].AddTo(clone.encoder)
+	}
+	return clone
+}
+
+func (c *callSiteCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *callSiteCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	size := 0
+	if buf, err := c.encoder.EncodeEntry(entry, fields); err == nil {
+		size = buf.Len()
+		buf.Free()
+	}
+	recordCallSite(entry.Caller, size)
+	return nil
+}
+
+func (c *callSiteCore) Sync() error {
+	return nil
+}
+
+// recordCallSite 累加调用位置的日志条数和字节数
+func recordCallSite(caller zapcore.EntryCaller, size int) {
+	var pc uintptr
+	if caller.Defined {
+		pc = caller.PC
+	}
+	counter, ok := callSites.Load(pc)
+	if !ok {
+		counter, _ = callSites.LoadOrStore(pc, &callSiteCounter{file: caller.File, line: caller.Line})
+	}
+	c := counter.(*callSiteCounter)
+	atomic.AddUint64(&c.count, 1)
+	atomic.AddUint64(&c.bytes, uint64(size))
+}
+
+// TopCallSites 返回日志量最大的 n 个调用位置（n <= 0 时返回全部）
+// byBytes 为 true 时按字节数排序，否则按条数排序。
+// 需要开启 call-site-stats；未开启 show-line 的同步日志没有调用位置，会统计在 Function 为空的条目中
+func TopCallSites(n int, byBytes bool) []CallSiteStat {
+	var stats []CallSiteStat
+	callSites.Range(func(key, value interface{}) bool {
+		pc := key.(uintptr)
+		c := value.(*callSiteCounter)
+		stat := CallSiteStat{
+			File:  c.file,
+			Line:  c.line,
+			Count: atomic.LoadUint64(&c.count),
+			Bytes: atomic.LoadUint64(&c.bytes),
+		}
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			stat.Function = fn.Name()
+		}
+		stats = append(stats, stat)
+		return true
+	})
+
+	sort.Slice(stats, func(i, j int) bool {
+		if byBytes {
+			return stats[i].Bytes > stats[j].Bytes
+		}
+		return stats[i].Count > stats[j].Count
+	})
+	if n > 0 && len(stats) > n {
+		stats = stats[:n]
+	}
+	return stats
+}
+
+// ResetCallSiteStats 清空调用位置统计
+func ResetCallSiteStats() {
+	callSites.Range(func(key, _ interface{}) bool {
+		callSites.Delete(key)
+		return true
+	})
+}
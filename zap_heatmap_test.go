@@ -0,0 +1,39 @@
+package mlog
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestTopCallSites 测试按条数统计日志量最大的调用位置
+func TestTopCallSites(t *testing.T) {
+	Close()
+	config := ZapConfig{
+		Level:         "info",
+		Format:        "console",
+		Director:      t.TempDir(),
+		SingleFile:    true,
+		ShowLine:      true,
+		CallSiteStats: true,
+	}
+	InitialZap("heatmap", 0, "info", &config)
+	defer Close()
+	ResetCallSiteStats()
+
+	for i := 0; i < 3; i++ {
+		Info("高频日志 %d", i)
+	}
+	Info("低频但很长的日志 %s", strings.Repeat("x", 500))
+	Debug("低于日志级别，不统计")
+
+	top := TopCallSites(1, false)
+	if len(top) != 1 || top[0].Count != 3 || !strings.HasSuffix(top[0].File, "zap_heatmap_test.go") {
+		t.Fatalf("按条数统计错误: %+v", top)
+	}
+	if !strings.Contains(top[0].Function, "TestTopCallSites") {
+		t.Errorf("函数名解析错误: %s", top[0].Function)
+	}
+	if all := TopCallSites(0, true); len(all) != 2 || all[0].Count != 1 {
+		t.Fatalf("按字节统计错误: %+v", all)
+	}
+}
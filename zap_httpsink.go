@@ -0,0 +1,126 @@
+package mlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultHTTPSinkTimeoutMs HTTP 批量输出单次请求的默认超时
+const defaultHTTPSinkTimeoutMs = 10000
+
+// HTTPSinkConfig 通用 HTTP 批量输出配置
+// 每批日志以 JSON 数组 POST 到指定地址，网络错误、429 和 5xx 按指数退避重试，其余 4xx 不重试
+type HTTPSinkConfig struct {
+	Enable      bool              `mapstructure:"enable" json:"enable" yaml:"enable"`                   // 启用 HTTP 批量输出
+	URL         string            `mapstructure:"url" json:"url" yaml:"url"`                            // 接收地址，如 https://collector.internal/api/logs
+	Headers     map[string]string `mapstructure:"headers" json:"headers" yaml:"headers"`                // 附加请求头，如 X-Api-Key
+	BearerToken string            `mapstructure:"bearer-token" json:"bearer-token" yaml:"bearer-token"` // Bearer 令牌认证
+	Username    string            `mapstructure:"username" json:"username" yaml:"username"`             // Basic 认证用户名
+	Password    string            `mapstructure:"password" json:"password" yaml:"password"`             // Basic 认证密码
+	Gzip        bool              `mapstructure:"gzip" json:"gzip" yaml:"gzip"`                         // 请求体使用 gzip 压缩
+	TimeoutMs   int               `mapstructure:"timeout-ms" json:"timeout-ms" yaml:"timeout-ms"`       // 单次请求超时（毫秒，默认 10000）
+	// 重试退避间隔上限（毫秒，默认 30000），重试期间新日志在有界缓冲区中等待，满时丢弃
+	MaxBackoffMs      int `mapstructure:"max-backoff-ms" json:"max-backoff-ms" yaml:"max-backoff-ms"`
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// httpSinkClient HTTP 批量输出客户端
+type httpSinkClient struct {
+	url        string
+	headers    map[string]string
+	token      string
+	username   string
+	password   string
+	gzip       bool
+	httpClient *http.Client
+}
+
+// newHTTPSinkCore 创建 HTTP 批量输出 Core
+func newHTTPSinkCore(cfg HTTPSinkConfig) (*remoteCore, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("未配置 HTTP 输出地址")
+	}
+	timeout := cfg.TimeoutMs
+	if timeout <= 0 {
+		timeout = defaultHTTPSinkTimeoutMs
+	}
+	client := &httpSinkClient{
+		url:        cfg.URL,
+		headers:    cfg.Headers,
+		token:      cfg.BearerToken,
+		username:   cfg.Username,
+		password:   cfg.Password,
+		gzip:       cfg.Gzip,
+		httpClient: &http.Client{Timeout: time.Duration(timeout) * time.Millisecond},
+	}
+	batcher := newRemoteBatcher("http", cfg.RemoteBatchConfig, client.post)
+	if cfg.MaxBackoffMs > 0 {
+		batcher.maxBackoff = time.Duration(cfg.MaxBackoffMs) * time.Millisecond
+	}
+	addSinkCloser(batcher)
+	return newRemoteCore(batcher, "", ""), nil
+}
+
+// post 以 JSON 数组发送一批日志
+func (c *httpSinkClient) post(ctx context.Context, batch []remoteRecord) error {
+	var body bytes.Buffer
+	var w io.Writer = &body
+	var zw *gzip.Writer
+	if c.gzip {
+		zw = gzip.NewWriter(&body)
+		w = zw
+	}
+	w.Write([]byte{'['})
+	for i := range batch {
+		if i > 0 {
+			w.Write([]byte{','})
+		}
+		w.Write(batch[i].Data)
+	}
+	w.Write([]byte{']'})
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("HTTP 输出返回 %s: %s", resp.Status, bytes.TrimSpace(msg))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests &&
+		resp.StatusCode != http.StatusRequestTimeout {
+		return &remotePartialError{Rejected: len(batch), Err: err}
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package mlog
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestHTTPSinkRetry 测试 gzip 请求体、认证头和 5xx 重试
+func TestHTTPSinkRetry(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		calls   int
+		entries []map[string]any
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer t0ken" || r.Header.Get("X-Game") != "mmo" ||
+			r.Header.Get("Content-Encoding") != "gzip" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var batch []map[string]any
+		if err := json.NewDecoder(zr).Decode(&batch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		entries = append(entries, batch...)
+	}))
+	defer server.Close()
+
+	core, err := newHTTPSinkCore(HTTPSinkConfig{
+		URL:               server.URL,
+		Headers:           map[string]string{"X-Game": "mmo"},
+		BearerToken:       "t0ken",
+		Gzip:              true,
+		RemoteBatchConfig: RemoteBatchConfig{RetryBackoffMs: 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	log := zap.New(core)
+	log.Info("开服")
+	log.Error("数据库超时", zap.Int("ms", 5000))
+	core.batcher.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 2 || len(entries) != 2 || entries[1]["msg"] != "数据库超时" || entries[1]["ms"] != float64(5000) {
+		t.Fatalf("请求 %d 次，收到 %v", calls, entries)
+	}
+	if stats := core.batcher.stats(); stats.Sent != 2 || stats.Failed != 0 {
+		t.Fatalf("统计错误: %+v", stats)
+	}
+}
+
+// TestHTTPSinkRejected 测试 4xx 不重试
+func TestHTTPSinkRejected(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	core, err := newHTTPSinkCore(HTTPSinkConfig{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	zap.New(core).Info("开服")
+	core.batcher.Close()
+	if stats := core.batcher.stats(); calls != 1 || stats.Failed != 1 {
+		t.Fatalf("请求 %d 次，统计 %+v", calls, stats)
+	}
+}
@@ -0,0 +1,83 @@
+package mlog
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultJanitorInterval 日志目录总大小检查的间隔
+const defaultJanitorInterval = time.Minute
+
+// logJanitor 日志目录总大小清理器
+// RetentionDay 和 MaxBackups 只约束单个日志文件的备份，business/folder 子目录和多个服务共用日志目录时
+// 总占用没有上限。这里定期统计整个 Director 目录树的大小，超过上限时从最早的轮转文件开始删除，
+// 正在写入的文件只计入大小，不会被删除；同时按保留策略删除超过 RetentionDay 和 MaxBackups 的备份
+type logJanitor struct {
+	root     string
+	policy   retentionPolicy
+	interval time.Duration
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// newLogJanitor 创建日志目录清理器并启动后台检查
+func newLogJanitor(root string) *logJanitor {
+	j := &logJanitor{
+		root:     root,
+		policy:   newRetentionPolicy(),
+		interval: defaultJanitorInterval,
+		done:     make(chan struct{}),
+	}
+	// 不再写入的 .log 文件由 logDirCleaner 按清理间隔处理
+	j.policy.stale = false
+	j.wg.Add(1)
+	go j.run()
+	return j
+}
+
+// run 后台检查循环，启动时先检查一次
+func (j *logJanitor) run() {
+	defer j.wg.Done()
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		j.enforce()
+		select {
+		case <-ticker.C:
+		case <-j.done:
+			return
+		}
+	}
+}
+
+// enforce 按保留策略检查一轮，超过上限时删除最早的轮转文件，返回删除的文件数
+func (j *logJanitor) enforce() int {
+	planned, total := j.policy.plan(j.root, time.Now())
+	removed := 0
+	for _, f := range planned {
+		if err := os.Remove(f.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 删除轮转日志文件失败 %s: %v\n", f.Path, err)
+			total += f.Size
+			continue
+		}
+		removed++
+	}
+	if j.policy.maxBytes > 0 && total > j.policy.maxBytes {
+		fmt.Fprintf(os.Stderr, "[mlog] 日志目录 %s 删除 %d 个轮转文件后仍超过总大小上限（%d MB）\n",
+			j.root, removed, total/1024/1024)
+	}
+	return removed
+}
+
+// Close 停止后台检查
+func (j *logJanitor) Close() error {
+	j.closeOnce.Do(func() {
+		close(j.done)
+	})
+	j.wg.Wait()
+	return nil
+}
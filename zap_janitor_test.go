@@ -0,0 +1,47 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLogJanitor 测试超过总大小上限时从最早的轮转文件开始删除，正在写入的文件和其他文件保留
+func TestLogJanitor(t *testing.T) {
+	root := t.TempDir()
+	p, _ := parseFilePattern("{level}-{date}.log")
+	j := &logJanitor{root: root, policy: retentionPolicy{maxBytes: 2000, dated: p.matcher()}}
+
+	now := time.Now()
+	write := func(rel string, size int, age time.Duration) string {
+		path := filepath.Join(root, rel)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, now.Add(-age), now.Add(-age))
+		return path
+	}
+	oldest := write("1/game/info-2026-01-01T00-00-00.000.log.gz", 1000, 3*time.Hour)
+	dated := write("1/game/net/info-2026-01-02.log", 1000, 2*time.Hour)
+	newest := write("2/gate/error-2026-01-03T00-00-00.000.log", 1000, time.Hour)
+	other := write("1/game/evidence/player.zip", 100, 4*time.Hour)
+
+	active := write("1/game/info-2025-12-31.log", 400, 5*time.Hour)
+	syncer := newFileWriteSyncer(active)
+	defer syncer.Close()
+
+	if removed := j.enforce(); removed != 2 {
+		t.Fatalf("删除文件数错误: %d", removed)
+	}
+	for path, exists := range map[string]bool{oldest: false, dated: false, newest: true, other: true, active: true} {
+		if _, err := os.Stat(path); (err == nil) != exists {
+			t.Fatalf("%s 存在状态错误: %v", path, err)
+		}
+	}
+	if removed := j.enforce(); removed != 0 {
+		t.Fatalf("未超过上限时不应删除: %d", removed)
+	}
+}
@@ -0,0 +1,93 @@
+package mlog
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// KafkaConfig Kafka 输出配置
+type KafkaConfig struct {
+	Enable bool   `mapstructure:"enable" json:"enable" yaml:"enable"` // 启用 Kafka 输出（需先调用 SetKafkaProducer）
+	Topic  string `mapstructure:"topic" json:"topic" yaml:"topic"`    // 主题
+	// 分区键字段名，相同键的日志写入同一分区；为空或 service_id 时按服务ID分区，字段不存在时也使用服务ID
+	PartitionKey string `mapstructure:"partition-key" json:"partition-key" yaml:"partition-key"`
+	// 消息值的编码：json（默认）、msgpack 或 protobuf（proto/logentry.proto 中的 LogEntry）
+	Encoding          string `mapstructure:"encoding" json:"encoding" yaml:"encoding"`
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// KafkaMessage 发送到 Kafka 的一条消息
+type KafkaMessage struct {
+	Topic string
+	Key   []byte    // 分区键
+	Value []byte    // 按 Encoding 编码的日志（默认为 JSON）
+	Time  time.Time // 日志产生时间
+}
+
+// KafkaProducer Kafka 生产者
+// mlog 不直接依赖 Kafka 客户端库，由应用使用自己的客户端（如 sarama、kafka-go）实现，
+// 按 Key 哈希选择分区即可保证同一键的日志有序。Produce 返回错误时整批重试。
+type KafkaProducer interface {
+	Produce(ctx context.Context, msgs []KafkaMessage) error
+}
+
+var (
+	kafkaProducer      KafkaProducer
+	kafkaProducerMutex sync.RWMutex
+)
+
+// SetKafkaProducer 设置 Kafka 生产者，需要在 InitialZap 之前调用，传入 nil 取消
+func SetKafkaProducer(producer KafkaProducer) {
+	kafkaProducerMutex.Lock()
+	kafkaProducer = producer
+	kafkaProducerMutex.Unlock()
+}
+
+// getKafkaProducer 获取当前的 Kafka 生产者
+func getKafkaProducer() KafkaProducer {
+	kafkaProducerMutex.RLock()
+	defer kafkaProducerMutex.RUnlock()
+	return kafkaProducer
+}
+
+// newKafkaCore 创建 Kafka 输出 Core
+// 日志先进入有界缓冲区，由后台协程批量发送；Kafka 不可用时丢弃远端日志，文件日志照常写入
+func newKafkaCore(cfg KafkaConfig, serviceName string, serviceID uint64) (*remoteCore, error) {
+	producer := getKafkaProducer()
+	if producer == nil {
+		return nil, errors.New("未设置 Kafka 生产者，请先调用 SetKafkaProducer")
+	}
+	if cfg.Topic == "" {
+		return nil, errors.New("未配置 Kafka 主题")
+	}
+	encoding, err := parseSinkEncoding(cfg.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	topic := cfg.Topic
+	batcher := newRemoteBatcher("kafka", cfg.RemoteBatchConfig, func(ctx context.Context, batch []remoteRecord) error {
+		msgs := make([]KafkaMessage, len(batch))
+		for i := range batch {
+			msgs[i] = KafkaMessage{
+				Topic: topic,
+				Key:   []byte(batch[i].Key),
+				Value: batch[i].Data,
+				Time:  batch[i].Entry.Time,
+			}
+		}
+		return producer.Produce(ctx, msgs)
+	})
+	addSinkCloser(batcher)
+
+	keyField := cfg.PartitionKey
+	if keyField == "service_id" {
+		keyField = ""
+	}
+	core := newRemoteCore(batcher, keyField, strconv.FormatUint(serviceID, 10))
+	core.useEncoding(encoding, serviceName, serviceID)
+	return core, nil
+}
@@ -0,0 +1,103 @@
+package mlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.yaml.in/yaml/v3"
+)
+
+// fakeKafkaProducer 记录消息的测试生产者，前 failures 次发送返回错误
+type fakeKafkaProducer struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	msgs     []KafkaMessage
+}
+
+func (p *fakeKafkaProducer) Produce(ctx context.Context, msgs []KafkaMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker not available")
+	}
+	p.msgs = append(p.msgs, msgs...)
+	return nil
+}
+
+// TestKafkaCore 测试按字段分区、批量发送和失败重试
+func TestKafkaCore(t *testing.T) {
+	producer := &fakeKafkaProducer{failures: 1}
+	SetKafkaProducer(producer)
+	defer SetKafkaProducer(nil)
+
+	core, err := newKafkaCore(KafkaConfig{
+		Topic:             "game-logs",
+		PartitionKey:      "player_id",
+		RemoteBatchConfig: RemoteBatchConfig{BatchSize: 10, RetryBackoffMs: 1},
+	}, "game", 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	logger := zap.New(core)
+	logger.Info("登录", zap.Uint64("player_id", 1001))
+	logger.With(zap.Uint64("player_id", 1002)).Info("下线")
+	logger.Warn("没有玩家")
+	if err := core.batcher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if producer.calls != 2 || len(producer.msgs) != 3 {
+		t.Fatalf("期望重试 1 次后整批发送，实际调用 %d 次、%d 条消息", producer.calls, len(producer.msgs))
+	}
+	for i, want := range []string{"1001", "1002", "42"} {
+		msg := producer.msgs[i]
+		if msg.Topic != "game-logs" || string(msg.Key) != want || !json.Valid(msg.Value) {
+			t.Fatalf("第 %d 条消息错误: topic=%s key=%s value=%s", i, msg.Topic, msg.Key, msg.Value)
+		}
+	}
+	if stats := core.batcher.stats(); stats.Sent != 3 || stats.Failed != 0 {
+		t.Fatalf("统计错误: %+v", stats)
+	}
+}
+
+// TestKafkaCoreDegrade 测试缓冲区满时丢弃、重试耗尽后放弃整批
+func TestKafkaCoreDegrade(t *testing.T) {
+	release := make(chan struct{})
+	batcher := newRemoteBatcher("kafka-test", RemoteBatchConfig{BufferSize: 2, BatchSize: 1, MaxRetries: -1}, func(ctx context.Context, batch []remoteRecord) error {
+		<-release
+		return errors.New("broker not available")
+	})
+	for i := 0; i < 10; i++ {
+		batcher.add(remoteRecord{Data: []byte("{}")})
+	}
+	close(release)
+	batcher.Close()
+
+	stats := batcher.stats()
+	if stats.Dropped == 0 || stats.Sent != 0 || stats.Dropped+stats.Failed != 10 {
+		t.Fatalf("统计错误: %+v", stats)
+	}
+	if _, ok := RemoteSinkStatsAll()["kafka-test"]; ok {
+		t.Fatal("关闭后应从统计中移除")
+	}
+}
+
+// TestKafkaConfigYAML 测试批量发送配置内联在 kafka 配置中
+func TestKafkaConfigYAML(t *testing.T) {
+	var cfg ZapConfig
+	if err := yaml.Unmarshal([]byte("kafka:\n  topic: t\n  batch-size: 7\n"), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Kafka.Topic != "t" || cfg.Kafka.BatchSize != 7 {
+		t.Fatalf("配置解析错误: %+v", cfg.Kafka)
+	}
+}
@@ -0,0 +1,55 @@
+package mlog
+
+import (
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ChineseLevelNames 中文级别名，可直接用作 LevelNames
+var ChineseLevelNames = map[string]string{
+	"debug":  "调试",
+	"info":   "信息",
+	"warn":   "警告",
+	"error":  "错误",
+	"dpanic": "严重",
+	"panic":  "崩溃",
+	"fatal":  "致命",
+}
+
+// parseLevelNames 解析 LevelNames，键为级别名，无效或显示名为空的条目跳过并返回错误
+func parseLevelNames(names map[string]string) (map[zapcore.Level]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	var (
+		parsed  = make(map[zapcore.Level]string, len(names))
+		invalid []string
+	)
+	for name, display := range names {
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(name)); err != nil || display == "" {
+			invalid = append(invalid, name)
+			continue
+		}
+		parsed[level] = display
+	}
+	if len(invalid) > 0 {
+		return parsed, fmt.Errorf("无效的级别名 %v", invalid)
+	}
+	return parsed, nil
+}
+
+// namedLevelEncoder 按 LevelNames 输出级别，未配置名称的级别交给 fallback
+func namedLevelEncoder(names map[zapcore.Level]string, fallback zapcore.LevelEncoder) zapcore.LevelEncoder {
+	if len(names) == 0 {
+		return fallback
+	}
+	return func(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+		if s, ok := names[l]; ok {
+			enc.AppendString(s)
+			return
+		}
+		fallback(l, enc)
+	}
+}
@@ -0,0 +1,61 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestLevelNames 测试自定义级别名，无效的条目跳过，未配置的级别按 EncodeLevel 输出
+func TestLevelNames(t *testing.T) {
+	names, err := parseLevelNames(map[string]string{"warn": "WARNING", "trace": "TRACE", "info": ""})
+	if err == nil || len(names) != 1 || names[zapcore.WarnLevel] != "WARNING" {
+		t.Fatalf("解析结果错误: %v %v", names, err)
+	}
+
+	oldConfig := zapConfig
+	defer func() { zapConfig = oldConfig }()
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "console", SingleFile: true,
+		EncodeLevel: "CapitalLevelEncoder", LevelNames: map[string]string{"warn": "WARNING", "error": "错误"}}
+	InitialZap("gate", 2, "info", &config)
+	Info("玩家登录")
+	Warn("背包已满")
+	Error("存档失败")
+	Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "2", "gate", "all.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"\tINFO\t", "\tWARNING\t", "\t错误\t"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("缺少 %q: %q", want, data)
+		}
+	}
+}
+
+// TestColorLevelNames 测试带颜色的级别编码器使用自定义级别名，未配置颜色时使用默认颜色
+func TestColorLevelNames(t *testing.T) {
+	config := ZapConfig{EncodeLevel: "LowercaseColorLevelEncoder", LevelNames: ChineseLevelNames,
+		LevelColors: map[string]string{"error": "1;31"}}
+	enc := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{LevelKey: "level", EncodeLevel: config.LevelEncoder()})
+	for level, want := range map[zapcore.Level]string{
+		zapcore.InfoLevel:  "\x1b[34m信息\x1b[0m",
+		zapcore.WarnLevel:  "\x1b[33m警告\x1b[0m",
+		zapcore.ErrorLevel: "\x1b[1;31m错误\x1b[0m",
+	} {
+		buf, err := enc.EncodeEntry(zapcore.Entry{Level: level}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSpace(buf.String()); got != want {
+			t.Fatalf("%s 编码结果错误: %q", level, got)
+		}
+		buf.Free()
+	}
+}
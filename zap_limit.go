@@ -0,0 +1,117 @@
+package mlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// limitCore 消息和字段大小限制
+// 超过 MaxMessageBytes 的消息和超过 MaxFieldBytes 的字段值截断并加上 …(truncated, N bytes) 后缀（N 为原始字节数），
+// 避免误把整个世界快照写进日志时产生几 MB 的单行日志，导致下游采集和解析失败。
+// 字段检查字符串、[]byte、错误、Stringer 以及 zap.Any 的反射值（按 JSON 编码后的大小），其他类型不做处理
+type limitCore struct {
+	zapcore.Core
+	maxMessage int
+	maxField   int
+}
+
+// newLimitCore 按 MaxMessageBytes 和 MaxFieldBytes 包装 Core，都未配置时原样返回
+func newLimitCore(core zapcore.Core, c *ZapConfig) zapcore.Core {
+	if c.MaxMessageBytes <= 0 && c.MaxFieldBytes <= 0 {
+		return core
+	}
+	return &limitCore{Core: core, maxMessage: c.MaxMessageBytes, maxField: c.MaxFieldBytes}
+}
+
+// truncateBytes 把 s 截断到 limit 字节以内（不拆分 UTF-8 字符）并加上截断标记，未超过时原样返回
+func truncateBytes(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + fmt.Sprintf("…(truncated, %d bytes)", len(s))
+}
+
+// fields 返回截断后的字段，没有超过限制的字段时返回原切片
+func (c *limitCore) fields(fields []zapcore.Field) []zapcore.Field {
+	if c.maxField <= 0 {
+		return fields
+	}
+	var limited []zapcore.Field
+	for i := range fields {
+		value, ok := c.oversized(fields[i])
+		if !ok {
+			continue
+		}
+		if limited == nil {
+			limited = append([]zapcore.Field(nil), fields...)
+		}
+		limited[i] = zap.String(fields[i].Key, truncateBytes(value, c.maxField))
+	}
+	if limited == nil {
+		return fields
+	}
+	return limited
+}
+
+// oversized 返回超过 MaxFieldBytes 的字段的字符串值
+func (c *limitCore) oversized(f zapcore.Field) (string, bool) {
+	var value string
+	switch f.Type {
+	case zapcore.StringType:
+		if len(f.String) <= c.maxField {
+			return "", false
+		}
+		value = f.String
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		b, _ := f.Interface.([]byte)
+		if len(b) <= c.maxField {
+			return "", false
+		}
+		value = string(b)
+	case zapcore.StringerType:
+		s, ok := f.Interface.(fmt.Stringer)
+		if !ok {
+			return "", false
+		}
+		value = s.String()
+	case zapcore.ErrorType:
+		err, ok := f.Interface.(error)
+		if !ok {
+			return "", false
+		}
+		value = err.Error()
+	case zapcore.ReflectType:
+		data, err := json.Marshal(f.Interface)
+		if err != nil {
+			return "", false
+		}
+		value = string(data)
+	default:
+		return "", false
+	}
+	return value, len(value) > c.maxField
+}
+
+func (c *limitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &limitCore{Core: c.Core.With(c.fields(fields)), maxMessage: c.maxMessage, maxField: c.maxField}
+}
+
+func (c *limitCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *limitCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = truncateBytes(entry.Message, c.maxMessage)
+	return writeChecked(c.Core, entry, c.fields(fields))
+}
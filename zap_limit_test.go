@@ -0,0 +1,60 @@
+package mlog
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestTruncateBytes 测试截断不拆分 UTF-8 字符，后缀带原始字节数
+func TestTruncateBytes(t *testing.T) {
+	if got := truncateBytes("短消息", 100); got != "短消息" {
+		t.Fatalf("未超过限制时不应截断: %s", got)
+	}
+	// "世界快照" 每个字 3 字节，限制 7 字节时保留两个字
+	if got := truncateBytes("世界快照", 7); got != "世界…(truncated, 12 bytes)" {
+		t.Fatalf("截断结果错误: %s", got)
+	}
+}
+
+// TestSizeLimits 测试超长消息和字段被截断，其他字段保留
+func TestSizeLimits(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "json", MaxMessageBytes: 64, MaxFieldBytes: 32}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	snapshot := map[string]string{"world": strings.Repeat("x", 100)}
+	InfoW(strings.Repeat("m", 200),
+		zap.String("dump", strings.Repeat("d", 100)),
+		zap.Any("snapshot", snapshot),
+		zap.Error(errors.New(strings.Repeat("e", 100))),
+		zap.String("player", "alice"))
+	Flush()
+
+	data, err := os.ReadFile(filepath.Join(dir, "2", "gate", "info.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["message"] != strings.Repeat("m", 64)+"…(truncated, 200 bytes)" {
+		t.Fatalf("消息截断错误: %v", entry["message"])
+	}
+	for _, key := range []string{"dump", "snapshot", "error"} {
+		if s, _ := entry[key].(string); !strings.Contains(s, "…(truncated, ") || len(s) > 32+len("…(truncated, 100 bytes)") {
+			t.Fatalf("字段 %s 截断错误: %v", key, entry[key])
+		}
+	}
+	if entry["player"] != "alice" {
+		t.Fatalf("未超过限制的字段不应改变: %v", entry)
+	}
+}
@@ -0,0 +1,86 @@
+package mlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// manifestFileName 日志目录中记录轮转文件校验和的清单文件名
+const manifestFileName = "manifest.jsonl"
+
+// manifestEnabled 是否在轮转时记录校验和，由 IntegrityManifest 配置
+var manifestEnabled atomic.Bool
+
+// manifestMutex 串行化清单文件的追加写入
+var manifestMutex sync.Mutex
+
+// manifestEntry 清单中的一行，对应一个不再写入的日志文件
+type manifestEntry struct {
+	Time   string `json:"time"`   // 记录时间
+	File   string `json:"file"`   // 文件名（与清单在同一目录），启用压缩时可能是压缩后的文件
+	Size   int64  `json:"size"`   // 文件大小（字节）
+	SHA256 string `json:"sha256"` // 文件内容的 SHA-256
+}
+
+// recordManifest 计算轮转完成的文件的 SHA-256，追加到同一目录的 manifest.jsonl
+// 启用压缩时原文件可能已被压缩并删除，此时记录压缩后的文件：lumberjack 和 zstd 压缩器都在压缩文件写完后才删除原文件
+func recordManifest(path string) error {
+	var (
+		entry manifestEntry
+		err   error
+	)
+	for _, candidate := range []string{path, path + gzipSuffix, path + zstdSuffix} {
+		if entry, err = hashLogFile(candidate); !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	manifestMutex.Lock()
+	defer manifestMutex.Unlock()
+	file, err := os.OpenFile(filepath.Join(filepath.Dir(path), manifestFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	// 一次写入一整行，多个进程共用日志目录时追加写入不会交错
+	_, err = file.Write(append(line, '\n'))
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// hashLogFile 计算文件的大小和 SHA-256
+func hashLogFile(path string) (manifestEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return manifestEntry{}, err
+	}
+	defer file.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return manifestEntry{}, err
+	}
+	return manifestEntry{
+		Time:   time.Now().Format(time.RFC3339),
+		File:   filepath.Base(path),
+		Size:   size,
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
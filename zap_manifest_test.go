@@ -0,0 +1,57 @@
+package mlog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ai-mmo/lumberjack"
+)
+
+// TestIntegrityManifest 测试轮转后把备份文件的校验和追加到 manifest.jsonl
+func TestIntegrityManifest(t *testing.T) {
+	manifestEnabled.Store(true)
+	defer manifestEnabled.Store(false)
+
+	dir := t.TempDir()
+	f := &fileWriteSyncer{logger: &lumberjack.Logger{Filename: filepath.Join(dir, "info.log"), MaxSize: 10, LocalTime: true}}
+	defer f.Close()
+	f.Write([]byte("轮转前的日志\n"))
+	f.rotateMu.Lock()
+	err := f.rotateLocked()
+	f.rotateMu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := filepath.Join(dir, manifestFileName)
+	var line []byte
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if data, err := os.ReadFile(manifest); err == nil && bytes.HasSuffix(data, []byte("\n")) {
+			line = bytes.TrimSpace(data)
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("未生成校验和清单")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	var entry manifestEntry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, entry.File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	if string(data) != "轮转前的日志\n" || entry.SHA256 != hex.EncodeToString(sum[:]) || entry.Size != int64(len(data)) {
+		t.Fatalf("清单内容错误: %+v", entry)
+	}
+}
@@ -0,0 +1,85 @@
+package mlog
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// MaskRule 日志内容的正则脱敏规则
+type MaskRule struct {
+	// 规则名；未设置 Pattern 时使用同名的内置规则：phone（手机号）、email（邮箱）、id-card（身份证号）、bank-card（银行卡号）
+	Name    string `mapstructure:"name" json:"name" yaml:"name"`
+	Pattern string `mapstructure:"pattern" json:"pattern" yaml:"pattern"` // 正则表达式（Go RE2 语法）
+	// 替换内容，可以用 $1、${name} 引用分组，为空时替换为 ***
+	Replacement string `mapstructure:"replacement" json:"replacement" yaml:"replacement"`
+}
+
+// builtinMaskRules 内置脱敏规则，保留部分字符便于排查问题
+var builtinMaskRules = map[string]MaskRule{
+	"phone":     {Pattern: `\b(1[3-9]\d)\d{4}(\d{4})\b`, Replacement: "${1}****${2}"},
+	"email":     {Pattern: `\b([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*(@[A-Za-z0-9.-]+\.[A-Za-z]{2,})\b`, Replacement: "${1}***${2}"},
+	"id-card":   {Pattern: `\b(\d{6})\d{8}(\d{3}[\dXx])\b`, Replacement: "${1}********${2}"},
+	"bank-card": {Pattern: `\b(\d{4})\d{8,11}(\d{4})\b`, Replacement: "${1}********${2}"},
+}
+
+// maskPatternCache 编译后的正则（键为表达式），重新初始化和创建场景日志时不重复编译
+var maskPatternCache sync.Map
+
+// maskRule 编译后的脱敏规则
+type maskRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// compileMaskRules 编译脱敏规则，无效的规则跳过并返回错误
+func compileMaskRules(rules []MaskRule) ([]maskRule, []error) {
+	var (
+		compiled []maskRule
+		errs     []error
+	)
+	for _, rule := range rules {
+		if rule.Pattern == "" {
+			builtin, ok := builtinMaskRules[rule.Name]
+			if !ok {
+				errs = append(errs, fmt.Errorf("脱敏规则 %q 未设置 pattern，且不是内置规则", rule.Name))
+				continue
+			}
+			if rule.Replacement == "" {
+				rule.Replacement = builtin.Replacement
+			}
+			rule.Pattern = builtin.Pattern
+		}
+		if rule.Replacement == "" {
+			rule.Replacement = redactedValue
+		}
+		re, err := compileMaskPattern(rule.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("脱敏规则 %q 的正则无效: %w", rule.Name, err))
+			continue
+		}
+		compiled = append(compiled, maskRule{pattern: re, replacement: rule.Replacement})
+	}
+	return compiled, errs
+}
+
+// compileMaskPattern 编译正则，相同的表达式只编译一次
+func compileMaskPattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := maskPatternCache.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	maskPatternCache.Store(expr, re)
+	return re, nil
+}
+
+// applyMaskRules 依次应用所有规则
+func applyMaskRules(rules []maskRule, s string) string {
+	for _, rule := range rules {
+		s = rule.pattern.ReplaceAllString(s, rule.replacement)
+	}
+	return s
+}
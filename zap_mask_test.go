@@ -0,0 +1,54 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestMaskRules 测试内置和自定义正则规则应用于消息和字符串字段，无效规则被跳过
+func TestMaskRules(t *testing.T) {
+	rules, errs := compileMaskRules([]MaskRule{{Name: "unknown"}, {Name: "bad", Pattern: "("}})
+	if len(rules) != 0 || len(errs) != 2 {
+		t.Fatalf("无效规则应跳过: %v %v", rules, errs)
+	}
+	if a, _ := compileMaskPattern(`\d+`); a == nil {
+		t.Fatal("编译失败")
+	} else if b, _ := compileMaskPattern(`\d+`); a != b {
+		t.Fatal("相同的表达式应使用缓存")
+	}
+
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "json", MaskRules: []MaskRule{
+		{Name: "phone"},
+		{Name: "email"},
+		{Name: "id-card"},
+		{Name: "order", Pattern: `order-\d+`, Replacement: "order-***"},
+	}}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	Info("玩家 13812345678 绑定邮箱 alice.w@example.com")
+	InfoW("实名认证", zap.String("content", "身份证 110101199003071234 订单 order-20260305"), zap.Int("player", 10086))
+	Flush()
+
+	data, err := os.ReadFile(filepath.Join(dir, "2", "gate", "info.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, want := range []string{"138****5678", "a***@example.com", "110101********1234", "order-***", `"player":10086`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("缺少 %q: %s", want, out)
+		}
+	}
+	for _, secret := range []string{"13812345678", "alice.w", "199003071234", "20260305"} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("%q 未脱敏: %s", secret, out)
+		}
+	}
+}
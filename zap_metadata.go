@@ -0,0 +1,62 @@
+package mlog
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 可以通过 GlobalFields 添加到每条日志上的元数据字段
+const (
+	MetadataHostname  = "hostname"   // 主机名
+	MetadataPID       = "pid"        // 进程 ID
+	MetadataService   = "service"    // 服务名
+	MetadataServiceID = "service_id" // 服务 ID
+	MetadataVersion   = "version"    // 版本号（version.go）
+	MetadataGitCommit = "git_commit" // 构建时注入的 GitCommit
+)
+
+// activeMetadata 当前日志器的全局元数据字段，场景日志使用相同的字段
+var activeMetadata atomic.Pointer[[]zapcore.Field]
+
+// metadataFields 按 GlobalFields 生成元数据字段，未知的字段名跳过并返回错误
+func metadataFields(names []string, serviceName string, serviceID uint64) ([]zapcore.Field, error) {
+	var (
+		fields  []zapcore.Field
+		unknown []string
+	)
+	for _, name := range names {
+		switch name {
+		case MetadataHostname:
+			hostname, _ := os.Hostname()
+			fields = append(fields, zap.String(name, hostname))
+		case MetadataPID:
+			fields = append(fields, zap.Int(name, os.Getpid()))
+		case MetadataService:
+			fields = append(fields, zap.String(name, serviceName))
+		case MetadataServiceID:
+			fields = append(fields, zap.Uint64(name, serviceID))
+		case MetadataVersion:
+			fields = append(fields, zap.String(name, Version))
+		case MetadataGitCommit:
+			fields = append(fields, zap.String(name, GitCommit))
+		default:
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fields, fmt.Errorf("未知的全局字段 %v", unknown)
+	}
+	return fields, nil
+}
+
+// withMetadata 在 logger 上添加当前的全局元数据字段
+func withMetadata(logger *zap.Logger) *zap.Logger {
+	if fields := activeMetadata.Load(); fields != nil && len(*fields) > 0 {
+		return logger.With(*fields...)
+	}
+	return logger
+}
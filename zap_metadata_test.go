@@ -0,0 +1,42 @@
+package mlog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGlobalFields 测试配置的元数据字段添加到每条日志上，未知字段跳过
+func TestGlobalFields(t *testing.T) {
+	if _, err := metadataFields([]string{MetadataPID, "region"}, "gate", 2); err == nil {
+		t.Fatal("未知字段应返回错误")
+	}
+
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "json",
+		GlobalFields: []string{MetadataHostname, MetadataPID, MetadataService, MetadataServiceID, MetadataVersion}}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	Info("玩家登录")
+	Flush()
+
+	data, err := os.ReadFile(filepath.Join(dir, "2", "gate", "info.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	hostname, _ := os.Hostname()
+	if entry["hostname"] != hostname || entry["pid"] != float64(os.Getpid()) || entry["service"] != "gate" ||
+		entry["service_id"] != float64(2) || entry["version"] != Version {
+		t.Fatalf("元数据字段错误: %v", entry)
+	}
+	if _, ok := entry["git_commit"]; ok {
+		t.Fatalf("未配置的字段不应添加: %v", entry)
+	}
+}
@@ -0,0 +1,186 @@
+package mlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	moduleLevelsMutex sync.Mutex
+	// moduleLevels 按模块覆盖的日志级别，写时复制，写日志时无锁读取
+	moduleLevels atomic.Pointer[map[string]zapcore.Level]
+	// baseLevel 全局日志级别；atomicLevel 为全局级别和所有模块级别中的最低级别，
+	// 各输出按 atomicLevel 放行，再由 moduleCore 按调用方所属模块的级别过滤
+	baseLevel atomic.Int32
+)
+
+func init() {
+	registerRuntimeState("module-levels", func() (any, error) {
+		return ModuleLevels(), nil
+	}, func(data json.RawMessage) error {
+		var levels map[string]string
+		if err := json.Unmarshal(data, &levels); err != nil {
+			return err
+		}
+		for module, level := range levels {
+			if err := SetModuleLevel(module, level); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetModuleLevel 设置模块的日志级别，覆盖全局级别，level 为空时取消覆盖
+// 模块按以下方式匹配，同时匹配多个模块时使用名称最长的模块：
+//   - 命名日志器：GLOG().Named("battle") 及其子日志器（battle.skill）的日志
+//   - 调用方的包路径：按路径段匹配，battle 匹配 aimmo/server/battle 及其子包，也可以写完整的包路径或 server/battle；
+//     直接使用 GLOG() 记录的日志需要开启 ShowLine 才能获取调用方
+//
+// 例如全局为 info 时把 battle 调到 debug 排查问题，其他模块仍为 info；也可以调高嘈杂模块的级别。
+// 模块级别低于全局级别时，其他模块的低级别日志会先格式化再被丢弃，排查结束后应及时取消
+func SetModuleLevel(module, level string) error {
+	if module == "" {
+		return fmt.Errorf("mlog: 模块名不能为空")
+	}
+	var parsed zapcore.Level
+	if level != "" {
+		var err error
+		if parsed, err = zapcore.ParseLevel(level); err != nil {
+			return fmt.Errorf("mlog: 模块 %s 的日志级别无效: %s", module, level)
+		}
+	}
+
+	moduleLevelsMutex.Lock()
+	levels := make(map[string]zapcore.Level)
+	if old := moduleLevels.Load(); old != nil {
+		for k, v := range *old {
+			levels[k] = v
+		}
+	}
+	if level == "" {
+		delete(levels, module)
+	} else {
+		levels[module] = parsed
+	}
+	moduleLevels.Store(&levels)
+	moduleLevelsMutex.Unlock()
+
+	globalMutex.Lock()
+	defer globalMutex.Unlock()
+	if isInitialized() {
+		applyLevel(globalLevel())
+		UpdateAsyncLevelCache()
+	}
+	return nil
+}
+
+// ModuleLevels 返回所有模块覆盖的日志级别
+func ModuleLevels() map[string]string {
+	result := make(map[string]string)
+	if levels := moduleLevels.Load(); levels != nil {
+		for module, level := range *levels {
+			result[module] = level.String()
+		}
+	}
+	return result
+}
+
+// globalLevel 返回全局日志级别（不含模块覆盖）
+func globalLevel() zapcore.Level {
+	return zapcore.Level(baseLevel.Load())
+}
+
+// applyLevel 设置全局日志级别，并把 atomicLevel 和级别缓存更新为包含模块覆盖的最低级别，调用方持有 globalMutex
+func applyLevel(level zapcore.Level) {
+	baseLevel.Store(int32(level))
+	effective := level
+	if levels := moduleLevels.Load(); levels != nil {
+		for _, l := range *levels {
+			effective = min(effective, l)
+		}
+	}
+	atomicLevel.SetLevel(effective)
+	updateLevelCacheOptimized(effective)
+}
+
+// moduleCore 按调用方所属模块的级别过滤日志，没有模块覆盖时不做处理
+// 调用位置在 Check 之后才获取，匹配在 Write 时进行
+type moduleCore struct {
+	zapcore.Core
+}
+
+// newModuleCore 包装 Core，模块级别可在初始化后设置，始终包装
+func newModuleCore(core zapcore.Core) zapcore.Core {
+	return &moduleCore{Core: core}
+}
+
+func (c *moduleCore) With(fields []zapcore.Field) zapcore.Core {
+	return &moduleCore{Core: c.Core.With(fields)}
+}
+
+func (c *moduleCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if levels := moduleLevels.Load(); levels == nil || len(*levels) == 0 {
+		return c.Core.Check(entry, ce)
+	}
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *moduleCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if entry.Level < entryLevel(entry) {
+		return nil
+	}
+	return writeChecked(c.Core, entry, fields)
+}
+
+// entryLevel 返回日志所属模块的级别，不属于任何模块时返回全局级别
+func entryLevel(entry zapcore.Entry) zapcore.Level {
+	levels := moduleLevels.Load()
+	if levels == nil {
+		return globalLevel()
+	}
+	pkg := ""
+	if entry.Caller.Defined {
+		function := entry.Caller.Function
+		// mlog 包装函数在入口处捕获的调用位置没有函数名
+		if function == "" && entry.Caller.PC != 0 {
+			if fn := runtime.FuncForPC(entry.Caller.PC); fn != nil {
+				function = fn.Name()
+			}
+		}
+		pkg = funcPackage(function)
+	}
+	matched, level := "", globalLevel()
+	for module, l := range *levels {
+		if len(module) > len(matched) && matchModule(module, entry.LoggerName, pkg) {
+			matched, level = module, l
+		}
+	}
+	return level
+}
+
+// matchModule 判断命名日志器或包路径是否属于模块
+func matchModule(module, loggerName, pkg string) bool {
+	if loggerName == module || strings.HasPrefix(loggerName, module+".") {
+		return true
+	}
+	return pkg != "" && strings.Contains("/"+pkg+"/", "/"+strings.Trim(module, "/")+"/")
+}
+
+// funcPackage 从函数全名（如 aimmo/server/battle.(*Room).Tick）中取出包路径
+func funcPackage(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[slash+1:], '.'); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
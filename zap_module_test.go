@@ -0,0 +1,86 @@
+package mlog
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestModuleLevel 测试按命名日志器和调用方包路径覆盖日志级别，其他模块仍按全局级别过滤
+func TestModuleLevel(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, SingleFile: true, ShowLine: true}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+	defer func() {
+		for module := range ModuleLevels() {
+			SetModuleLevel(module, "")
+		}
+	}()
+
+	if err := SetModuleLevel("battle", "verbose"); err == nil {
+		t.Fatal("无效的级别应返回错误")
+	}
+	if err := SetModuleLevel("battle", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetModuleLevel("chat", "error"); err != nil {
+		t.Fatal(err)
+	}
+	GLOG().Named("battle").Debug("战斗调试")
+	GLOG().Named("battle.skill").Debug("技能调试")
+	GLOG().Named("chat").Info("聊天信息")
+	GLOG().Named("chat").Error("聊天错误")
+	DebugW("全局调试")
+	if CheckLevel("debug") || currentLevel() != "info" {
+		t.Fatalf("模块级别不应改变全局级别: %s", currentLevel())
+	}
+
+	// 按调用方的包路径匹配
+	if err := SetModuleLevel("mlog", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	DebugW("包调试")
+	SetModuleLevel("mlog", "")
+	DebugW("取消后的调试")
+	Close()
+
+	content := readLogFile(t, filepath.Join(dir, "2/gate/all.log"))
+	for _, want := range []string{"战斗调试", "技能调试", "聊天错误", "包调试"} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("缺少日志 %s: %s", want, content)
+		}
+	}
+	for _, unwanted := range []string{"聊天信息", "全局调试", "取消后的调试"} {
+		if strings.Contains(content, unwanted) {
+			t.Fatalf("不应写入 %s: %s", unwanted, content)
+		}
+	}
+}
+
+// TestMatchModule 测试模块与命名日志器、包路径的匹配
+func TestMatchModule(t *testing.T) {
+	if pkg := funcPackage("aimmo/server/battle.(*Room).Tick"); pkg != "aimmo/server/battle" {
+		t.Fatalf("包路径错误: %s", pkg)
+	}
+	if pkg := funcPackage("main.main"); pkg != "main" {
+		t.Fatalf("包路径错误: %s", pkg)
+	}
+	cases := []struct {
+		module, logger, pkg string
+		want                bool
+	}{
+		{"battle", "", "aimmo/server/battle", true},
+		{"battle", "", "aimmo/server/battle/skill", true},
+		{"server/battle", "", "aimmo/server/battle", true},
+		{"battle", "", "aimmo/server/battlefield", false},
+		{"battle", "battle.skill", "", true},
+		{"battle", "battlefield", "", false},
+	}
+	for _, c := range cases {
+		if got := matchModule(c.module, c.logger, c.pkg); got != c.want {
+			t.Fatalf("matchModule(%q, %q, %q) = %v", c.module, c.logger, c.pkg, got)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package mlog
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// MQTT 输出的默认参数
+const (
+	mqttDialTimeout      = 5 * time.Second
+	defaultMQTTTopic     = "logs/{service}/{id}/{level}"
+	defaultMQTTMinLevel  = "warn"
+	defaultMQTTKeepAlive = 60
+	mqttProtocolLevel311 = 4
+)
+
+// MQTT 3.1.1 控制报文类型（固定头高 4 位）
+const (
+	mqttConnect    byte = 1
+	mqttConnack    byte = 2
+	mqttPublish    byte = 3
+	mqttPuback     byte = 4
+	mqttPingreq    byte = 12
+	mqttPingresp   byte = 13
+	mqttDisconnect byte = 14
+)
+
+// MQTTConfig MQTT 输出配置
+// 适合网络受限的边缘节点，默认只转发 Warn 及以上的日志
+type MQTTConfig struct {
+	Enable bool `mapstructure:"enable" json:"enable" yaml:"enable"` // 启用 MQTT 输出
+	// Broker 地址，如 tcp://127.0.0.1:1883，ssl://、mqtts:// 或 tls:// 使用 TLS（默认端口 8883）
+	Broker   string `mapstructure:"broker" json:"broker" yaml:"broker"`
+	ClientID string `mapstructure:"client-id" json:"client-id" yaml:"client-id"` // 客户端 ID（默认 mlog-<服务名>-<服务ID>）
+	Username string `mapstructure:"username" json:"username" yaml:"username"`    // 用户名
+	Password string `mapstructure:"password" json:"password" yaml:"password"`    // 密码
+	// 主题模板（默认 logs/{service}/{id}/{level}），可用 {service} {id} {level}
+	Topic    string `mapstructure:"topic" json:"topic" yaml:"topic"`
+	QoS      int    `mapstructure:"qos" json:"qos" yaml:"qos"`                   // 服务质量，0 或 1（1 时等待 Broker 确认，未确认的日志重发）
+	MinLevel string `mapstructure:"min-level" json:"min-level" yaml:"min-level"` // 最低级别（默认 warn）
+	// 心跳间隔（秒，默认 60），空闲超过该时间后下次发布前重新连接
+	KeepAliveSec       int    `mapstructure:"keep-alive-sec" json:"keep-alive-sec" yaml:"keep-alive-sec"`
+	CAFile             string `mapstructure:"ca-file" json:"ca-file" yaml:"ca-file"`                                        // TLS 根证书文件（默认使用系统证书）
+	InsecureSkipVerify bool   `mapstructure:"insecure-skip-verify" json:"insecure-skip-verify" yaml:"insecure-skip-verify"` // 跳过 TLS 证书校验（仅用于测试环境）
+	RemoteBatchConfig  `mapstructure:",squash" yaml:",inline"`
+}
+
+// mqttPublisher MQTT 发布客户端
+// 直接实现 MQTT 3.1.1 的发布子集：整批写入 PUBLISH 报文，QoS 1 等待每条的 PUBACK，
+// QoS 0 以 PINGREQ/PINGRESP 确认 Broker 已读取之前的报文
+type mqttPublisher struct {
+	address   string
+	tlsConfig *tls.Config // 为 nil 时不使用 TLS
+	connect   []byte      // CONNECT 报文
+	topic     string      // 已替换 {service} 和 {id} 的主题模板
+	qos       byte
+	keepAlive time.Duration
+
+	mu         sync.Mutex
+	conn       net.Conn
+	reader     *bufio.Reader
+	packetID   uint16
+	lastActive time.Time
+}
+
+// newMQTTCore 创建 MQTT 输出 Core
+func newMQTTCore(cfg MQTTConfig, serviceName string, serviceID uint64) (*remoteCore, error) {
+	if cfg.Broker == "" {
+		return nil, errors.New("未配置 MQTT Broker 地址")
+	}
+	u, err := url.Parse(cfg.Broker)
+	if err != nil {
+		return nil, fmt.Errorf("MQTT Broker 地址格式错误: %w", err)
+	}
+	useTLS := false
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "mqtts", "tls":
+		useTLS = true
+	default:
+		return nil, fmt.Errorf("不支持的 MQTT 地址协议: %s", u.Scheme)
+	}
+	if cfg.QoS != 0 && cfg.QoS != 1 {
+		return nil, fmt.Errorf("不支持的 MQTT QoS: %d", cfg.QoS)
+	}
+	minLevelName := cfg.MinLevel
+	if minLevelName == "" {
+		minLevelName = defaultMQTTMinLevel
+	}
+	minLevel, err := zapcore.ParseLevel(minLevelName)
+	if err != nil {
+		return nil, err
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		port := "1883"
+		if useTLS {
+			port = "8883"
+		}
+		address = net.JoinHostPort(u.Hostname(), port)
+	}
+	p := &mqttPublisher{address: address, qos: byte(cfg.QoS)}
+	if useTLS {
+		p.tlsConfig = &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: cfg.InsecureSkipVerify}
+		if cfg.CAFile != "" {
+			pem, err := os.ReadFile(cfg.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("读取 MQTT 根证书失败: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("MQTT 根证书文件中没有有效证书: %s", cfg.CAFile)
+			}
+			p.tlsConfig.RootCAs = pool
+		}
+	}
+
+	keepAlive := cfg.KeepAliveSec
+	if keepAlive <= 0 {
+		keepAlive = defaultMQTTKeepAlive
+	}
+	p.keepAlive = time.Duration(keepAlive) * time.Second
+	clientID := cfg.ClientID
+	if clientID == "" {
+		clientID = fmt.Sprintf("mlog-%s-%d", serviceName, serviceID)
+	}
+	username, password := cfg.Username, cfg.Password
+	if username == "" && u.User != nil {
+		username = u.User.Username()
+		password, _ = u.User.Password()
+	}
+	p.connect = appendMQTTConnect(nil, clientID, username, password, uint16(min(keepAlive, 65535)))
+
+	topic := cfg.Topic
+	if topic == "" {
+		topic = defaultMQTTTopic
+	}
+	p.topic = strings.NewReplacer("{service}", serviceName, "{id}", strconv.FormatUint(serviceID, 10)).Replace(topic)
+
+	batcher := newRemoteBatcher("mqtt", cfg.RemoteBatchConfig, p.publish)
+	addSinkCloser(batcher)
+	addSinkCloser(p)
+	core := newRemoteCore(batcher, "", "")
+	core.LevelEnabler = zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l >= minLevel && atomicLevel.Enabled(l)
+	})
+	return core, nil
+}
+
+// appendMQTTString 追加带两字节长度前缀的字符串
+func appendMQTTString(buf []byte, s string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(s)))
+	return append(buf, s...)
+}
+
+// appendMQTTPacket 追加固定头（报文类型、标志、剩余长度）和报文内容
+func appendMQTTPacket(buf []byte, packetType, flags byte, body []byte) []byte {
+	buf = append(buf, packetType<<4|flags)
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(buf, body...)
+}
+
+// appendMQTTConnect 追加清除会话的 CONNECT 报文
+func appendMQTTConnect(buf []byte, clientID, username, password string, keepAlive uint16) []byte {
+	var flags byte = 0x02 // Clean Session
+	if username != "" {
+		flags |= 0x80
+		if password != "" {
+			flags |= 0x40
+		}
+	}
+	body := appendMQTTString(nil, "MQTT")
+	body = append(body, mqttProtocolLevel311, flags)
+	body = binary.BigEndian.AppendUint16(body, keepAlive)
+	body = appendMQTTString(body, clientID)
+	if username != "" {
+		body = appendMQTTString(body, username)
+		if password != "" {
+			body = appendMQTTString(body, password)
+		}
+	}
+	return appendMQTTPacket(buf, mqttConnect, 0, body)
+}
+
+// readMQTTPacket 读取一个报文，返回报文类型、标志和内容
+func readMQTTPacket(r *bufio.Reader) (packetType, flags byte, body []byte, err error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	size, shift := 0, 0
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		size |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		shift += 7
+		if shift > 21 {
+			return 0, 0, nil, errors.New("MQTT 报文剩余长度格式错误")
+		}
+	}
+	body = make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, 0, nil, err
+	}
+	return header >> 4, header & 0x0f, body, nil
+}
+
+// publish 发布一批日志
+func (p *mqttPublisher) publish(ctx context.Context, batch []remoteRecord) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Broker 会断开超过 1.5 倍心跳间隔没有报文的连接，空闲过久时直接重连
+	if p.conn != nil && time.Since(p.lastActive) >= p.keepAlive {
+		p.resetLocked()
+	}
+	if err := p.ensureConnLocked(ctx); err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		p.conn.SetDeadline(deadline)
+	}
+
+	var buf, body []byte
+	ids := make(map[uint16]int, len(batch))
+	for i := range batch {
+		topic := strings.ReplaceAll(p.topic, "{level}", batch[i].Entry.Level.String())
+		body = appendMQTTString(body[:0], topic)
+		if p.qos > 0 {
+			p.packetID++
+			if p.packetID == 0 {
+				p.packetID = 1
+			}
+			body = binary.BigEndian.AppendUint16(body, p.packetID)
+			ids[p.packetID] = i
+		}
+		body = append(body, batch[i].Data...)
+		buf = appendMQTTPacket(buf, mqttPublish, p.qos<<1, body)
+	}
+	if p.qos == 0 {
+		buf = appendMQTTPacket(buf, mqttPingreq, 0, nil)
+	}
+	if _, err := p.conn.Write(buf); err != nil {
+		p.resetLocked()
+		return err
+	}
+
+	if p.qos == 0 {
+		// 收到 PINGRESP 说明之前的 PUBLISH 已被 Broker 读取
+		for {
+			packetType, _, _, err := readMQTTPacket(p.reader)
+			if err != nil {
+				p.resetLocked()
+				return err
+			}
+			if packetType == mqttPingresp {
+				p.lastActive = time.Now()
+				return nil
+			}
+		}
+	}
+
+	// 等待每条日志的 PUBACK，连接出错时只重发未确认的日志
+	acked := make([]bool, len(batch))
+	for len(ids) > 0 {
+		packetType, _, body, err := readMQTTPacket(p.reader)
+		if err != nil {
+			p.resetLocked()
+			retry := make([]remoteRecord, 0, len(ids))
+			for i := range batch {
+				if !acked[i] {
+					retry = append(retry, batch[i])
+				}
+			}
+			return &remotePartialError{Retry: retry, Err: err}
+		}
+		if packetType != mqttPuback || len(body) < 2 {
+			continue
+		}
+		id := binary.BigEndian.Uint16(body)
+		if i, ok := ids[id]; ok {
+			acked[i] = true
+			delete(ids, id)
+		}
+	}
+	p.lastActive = time.Now()
+	return nil
+}
+
+// ensureConnLocked 连接不可用时重新连接并完成 CONNECT 握手，调用方需持有 mu
+func (p *mqttPublisher) ensureConnLocked(ctx context.Context) error {
+	if p.conn != nil {
+		return nil
+	}
+	dialer := net.Dialer{Timeout: mqttDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(mqttDialTimeout))
+	if p.tlsConfig != nil {
+		tlsConn := tls.Client(conn, p.tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return err
+		}
+		conn = tlsConn
+	}
+	if _, err := conn.Write(p.connect); err != nil {
+		conn.Close()
+		return err
+	}
+	reader := bufio.NewReader(conn)
+	packetType, _, body, err := readMQTTPacket(reader)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("MQTT 握手失败: %w", err)
+	}
+	if packetType != mqttConnack || len(body) < 2 {
+		conn.Close()
+		return fmt.Errorf("MQTT 握手失败: 收到报文类型 %d", packetType)
+	}
+	if body[1] != 0 {
+		conn.Close()
+		return fmt.Errorf("MQTT Broker 拒绝连接: 返回码 %d", body[1])
+	}
+	p.conn, p.reader = conn, reader
+	p.lastActive = time.Now()
+	return nil
+}
+
+// resetLocked 关闭当前连接，下一次发布时重连，调用方需持有 mu
+func (p *mqttPublisher) resetLocked() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn, p.reader = nil, nil
+	}
+}
+
+// Close 发送 DISCONNECT 后关闭连接
+func (p *mqttPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn != nil {
+		p.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		p.conn.Write(appendMQTTPacket(nil, mqttDisconnect, 0, nil))
+	}
+	p.resetLocked()
+	return nil
+}
@@ -0,0 +1,153 @@
+package mlog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// mqttTestMsg 测试 Broker 收到的一条 PUBLISH
+type mqttTestMsg struct {
+	topic   string
+	qos     byte
+	payload string
+}
+
+// startMQTTTestBroker 启动只实现 CONNECT/PUBLISH/PINGREQ 的 MQTT 测试 Broker
+// dropFirstAck 为 true 时不确认第一条 PUBLISH 并断开连接
+func startMQTTTestBroker(t *testing.T, dropFirstAck bool) (string, func() ([]mqttTestMsg, []byte)) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var (
+		mu      sync.Mutex
+		msgs    []mqttTestMsg
+		connect []byte
+		dropped bool
+	)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					packetType, flags, body, err := readMQTTPacket(r)
+					if err != nil {
+						return
+					}
+					switch packetType {
+					case mqttConnect:
+						mu.Lock()
+						connect = body
+						mu.Unlock()
+						conn.Write(appendMQTTPacket(nil, mqttConnack, 0, []byte{0, 0}))
+					case mqttPingreq:
+						conn.Write(appendMQTTPacket(nil, mqttPingresp, 0, nil))
+					case mqttPublish:
+						qos := flags >> 1 & 0x03
+						n := int(binary.BigEndian.Uint16(body))
+						topic, rest := string(body[2:2+n]), body[2+n:]
+						var id []byte
+						if qos > 0 {
+							id, rest = rest[:2], rest[2:]
+						}
+						mu.Lock()
+						drop := dropFirstAck && !dropped
+						dropped = dropped || drop
+						if !drop {
+							msgs = append(msgs, mqttTestMsg{topic, qos, string(rest)})
+						}
+						mu.Unlock()
+						if drop {
+							return
+						}
+						if qos > 0 {
+							conn.Write(appendMQTTPacket(nil, mqttPuback, 0, id))
+						}
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String(), func() ([]mqttTestMsg, []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]mqttTestMsg(nil), msgs...), connect
+	}
+}
+
+// TestMQTTPublish 测试主题模板、默认只发送 Warn 及以上和 CONNECT 认证信息
+func TestMQTTPublish(t *testing.T) {
+	oldLevel := atomicLevel
+	atomicLevel = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	defer func() { atomicLevel = oldLevel }()
+	addr, received := startMQTTTestBroker(t, false)
+	core, err := newMQTTCore(MQTTConfig{Broker: "tcp://edge:secret@" + addr}, "gate", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := zap.New(core)
+	log.Info("登录")
+	log.Warn("延迟过高", zap.Int("ms", 800))
+	core.batcher.Close()
+
+	msgs, connect := received()
+	if len(msgs) != 1 || msgs[0].topic != "logs/gate/5/warn" || msgs[0].qos != 0 ||
+		!strings.Contains(msgs[0].payload, `"msg":"延迟过高"`) {
+		t.Fatalf("发布内容错误: %+v", msgs)
+	}
+	if !strings.Contains(string(connect), "mlog-gate-5") || !strings.Contains(string(connect), "edge") ||
+		!strings.Contains(string(connect), "secret") {
+		t.Fatalf("CONNECT 报文错误: %q", connect)
+	}
+}
+
+// TestMQTTQoS1Retry 测试 QoS 1 断线后重发未确认的日志
+func TestMQTTQoS1Retry(t *testing.T) {
+	addr, received := startMQTTTestBroker(t, true)
+	core, err := newMQTTCore(MQTTConfig{
+		Broker:            "tcp://" + addr,
+		Topic:             "edge/{level}",
+		QoS:               1,
+		MinLevel:          "error",
+		RemoteBatchConfig: RemoteBatchConfig{RetryBackoffMs: 10},
+	}, "gate", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.ErrorLevel
+	log := zap.New(core)
+	log.Error("存档失败")
+	core.batcher.Close()
+
+	msgs, _ := received()
+	if len(msgs) != 1 || msgs[0].topic != "edge/error" || msgs[0].qos != 1 {
+		t.Fatalf("发布内容错误: %+v", msgs)
+	}
+	if stats := core.batcher.stats(); stats.Sent != 1 || stats.Failed != 0 {
+		t.Fatalf("统计错误: %+v", stats)
+	}
+}
+
+// TestMQTTConfigErrors 测试不支持的协议和 QoS
+func TestMQTTConfigErrors(t *testing.T) {
+	if _, err := newMQTTCore(MQTTConfig{Broker: "ws://127.0.0.1"}, "gate", 1); err == nil {
+		t.Fatal("不支持的协议应该返回错误")
+	}
+	if _, err := newMQTTCore(MQTTConfig{Broker: "tcp://127.0.0.1", QoS: 2}, "gate", 1); err == nil {
+		t.Fatal("不支持的 QoS 应该返回错误")
+	}
+}
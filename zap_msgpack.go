@@ -0,0 +1,450 @@
+package mlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// msgpackPool 编码结果的缓冲
+var msgpackPool = buffer.NewPool()
+
+// msgpackEncoder MessagePack 编码器，每条日志编码为一个 map
+// 字段名、级别和调用位置的编码与 EncoderConfig 一致；时间使用 msgpack timestamp 扩展类型，耗时为纳秒整数
+type msgpackEncoder struct {
+	cfg    zapcore.EncoderConfig
+	buf    []byte // 当前层已编码的键值对
+	count  int    // 当前层的键值对数量
+	spaces []msgpackNamespace
+}
+
+// msgpackNamespace OpenNamespace 打开的外层，关闭时当前层作为 key 的值写回外层
+type msgpackNamespace struct {
+	key   string
+	buf   []byte
+	count int
+}
+
+// newMsgpackEncoder 创建 MessagePack 编码器
+func newMsgpackEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return &msgpackEncoder{cfg: cfg}
+}
+
+func (e *msgpackEncoder) clone() *msgpackEncoder {
+	clone := &msgpackEncoder{cfg: e.cfg, buf: append([]byte(nil), e.buf...), count: e.count}
+	for _, ns := range e.spaces {
+		clone.spaces = append(clone.spaces, msgpackNamespace{key: ns.key, buf: append([]byte(nil), ns.buf...), count: ns.count})
+	}
+	return clone
+}
+
+func (e *msgpackEncoder) Clone() zapcore.Encoder {
+	return e.clone()
+}
+
+// closeNamespaces 关闭所有打开的 namespace
+func (e *msgpackEncoder) closeNamespaces() {
+	for len(e.spaces) > 0 {
+		ns := e.spaces[len(e.spaces)-1]
+		e.spaces = e.spaces[:len(e.spaces)-1]
+		ns.buf = mpAppendString(ns.buf, ns.key)
+		ns.buf = mpAppendMapHeader(ns.buf, e.count)
+		e.buf, e.count = append(ns.buf, e.buf...), ns.count+1
+	}
+}
+
+// key 写入键名，之后必须写入一个值
+func (e *msgpackEncoder) key(key string) {
+	e.buf = mpAppendString(e.buf, key)
+	e.count++
+}
+
+// primitive 按 EncoderConfig 的编码函数编码一个值，编码函数没有输出时不写入该字段
+func (e *msgpackEncoder) primitive(key string, encode func(zapcore.PrimitiveArrayEncoder)) {
+	arr := &msgpackArray{}
+	encode(arr)
+	if arr.count == 0 {
+		return
+	}
+	e.key(key)
+	if arr.count == 1 {
+		e.buf = append(e.buf, arr.buf...)
+		return
+	}
+	e.buf = append(mpAppendArrayHeader(e.buf, arr.count), arr.buf...)
+}
+
+func (e *msgpackEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := e.clone()
+	for i := range fields {
+		fields[i].AddTo(final)
+	}
+	final.closeNamespaces()
+
+	meta := &msgpackEncoder{cfg: e.cfg}
+	if e.cfg.TimeKey != "" {
+		meta.AddTime(e.cfg.TimeKey, entry.Time)
+	}
+	if e.cfg.LevelKey != "" {
+		if e.cfg.EncodeLevel != nil {
+			meta.primitive(e.cfg.LevelKey, func(arr zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeLevel(entry.Level, arr) })
+		} else {
+			meta.AddString(e.cfg.LevelKey, entry.Level.String())
+		}
+	}
+	if e.cfg.NameKey != "" && entry.LoggerName != "" {
+		meta.AddString(e.cfg.NameKey, entry.LoggerName)
+	}
+	if entry.Caller.Defined {
+		if e.cfg.CallerKey != "" {
+			if e.cfg.EncodeCaller != nil {
+				meta.primitive(e.cfg.CallerKey, func(arr zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeCaller(entry.Caller, arr) })
+			} else {
+				meta.AddString(e.cfg.CallerKey, entry.Caller.TrimmedPath())
+			}
+		}
+		if e.cfg.FunctionKey != "" {
+			meta.AddString(e.cfg.FunctionKey, entry.Caller.Function)
+		}
+	}
+	if e.cfg.MessageKey != "" {
+		meta.AddString(e.cfg.MessageKey, entry.Message)
+	}
+	if e.cfg.StacktraceKey != "" && entry.Stack != "" {
+		meta.AddString(e.cfg.StacktraceKey, entry.Stack)
+	}
+
+	buf := msgpackPool.Get()
+	buf.Write(mpAppendMapHeader(nil, meta.count+final.count))
+	buf.Write(meta.buf)
+	buf.Write(final.buf)
+	return buf, nil
+}
+
+func (e *msgpackEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	arr := &msgpackArray{}
+	err := marshaler.MarshalLogArray(arr)
+	e.key(key)
+	e.buf = append(mpAppendArrayHeader(e.buf, arr.count), arr.buf...)
+	return err
+}
+
+func (e *msgpackEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	obj := &msgpackEncoder{cfg: e.cfg}
+	err := marshaler.MarshalLogObject(obj)
+	obj.closeNamespaces()
+	e.key(key)
+	e.buf = append(mpAppendMapHeader(e.buf, obj.count), obj.buf...)
+	return err
+}
+
+func (e *msgpackEncoder) AddBinary(key string, value []byte) {
+	e.key(key)
+	e.buf = mpAppendBinary(e.buf, value)
+}
+
+func (e *msgpackEncoder) AddByteString(key string, value []byte) {
+	e.key(key)
+	e.buf = mpAppendString(e.buf, string(value))
+}
+
+func (e *msgpackEncoder) AddBool(key string, value bool) {
+	e.key(key)
+	e.buf = mpAppendBool(e.buf, value)
+}
+
+func (e *msgpackEncoder) AddComplex128(key string, value complex128) {
+	e.key(key)
+	e.buf = mpAppendComplex(e.buf, value, 128)
+}
+
+func (e *msgpackEncoder) AddComplex64(key string, value complex64) {
+	e.key(key)
+	e.buf = mpAppendComplex(e.buf, complex128(value), 64)
+}
+
+func (e *msgpackEncoder) AddDuration(key string, value time.Duration) {
+	e.AddInt64(key, int64(value))
+}
+
+func (e *msgpackEncoder) AddFloat64(key string, value float64) {
+	e.key(key)
+	e.buf = mpAppendFloat64(e.buf, value)
+}
+
+func (e *msgpackEncoder) AddFloat32(key string, value float32) {
+	e.key(key)
+	e.buf = mpAppendFloat32(e.buf, value)
+}
+
+func (e *msgpackEncoder) AddInt(key string, value int)     { e.AddInt64(key, int64(value)) }
+func (e *msgpackEncoder) AddInt32(key string, value int32) { e.AddInt64(key, int64(value)) }
+func (e *msgpackEncoder) AddInt16(key string, value int16) { e.AddInt64(key, int64(value)) }
+func (e *msgpackEncoder) AddInt8(key string, value int8)   { e.AddInt64(key, int64(value)) }
+
+func (e *msgpackEncoder) AddInt64(key string, value int64) {
+	e.key(key)
+	e.buf = mpAppendInt(e.buf, value)
+}
+
+func (e *msgpackEncoder) AddString(key, value string) {
+	e.key(key)
+	e.buf = mpAppendString(e.buf, value)
+}
+
+func (e *msgpackEncoder) AddTime(key string, value time.Time) {
+	e.key(key)
+	e.buf = mpAppendTime(e.buf, value)
+}
+
+func (e *msgpackEncoder) AddUint(key string, value uint)       { e.AddUint64(key, uint64(value)) }
+func (e *msgpackEncoder) AddUint32(key string, value uint32)   { e.AddUint64(key, uint64(value)) }
+func (e *msgpackEncoder) AddUint16(key string, value uint16)   { e.AddUint64(key, uint64(value)) }
+func (e *msgpackEncoder) AddUint8(key string, value uint8)     { e.AddUint64(key, uint64(value)) }
+func (e *msgpackEncoder) AddUintptr(key string, value uintptr) { e.AddUint64(key, uint64(value)) }
+
+func (e *msgpackEncoder) AddUint64(key string, value uint64) {
+	e.key(key)
+	e.buf = mpAppendUint(e.buf, value)
+}
+
+// AddReflected 按 JSON 编码的结构写入（对象、数组、字符串、数字等），无法 JSON 编码时返回错误
+func (e *msgpackEncoder) AddReflected(key string, value interface{}) error {
+	data, err := mpAppendReflected(nil, value)
+	if err != nil {
+		return err
+	}
+	e.key(key)
+	e.buf = append(e.buf, data...)
+	return nil
+}
+
+func (e *msgpackEncoder) OpenNamespace(key string) {
+	e.spaces = append(e.spaces, msgpackNamespace{key: key, buf: e.buf, count: e.count})
+	e.buf, e.count = nil, 0
+}
+
+// msgpackArray MessagePack 数组的元素编码器
+type msgpackArray struct {
+	buf   []byte
+	count int
+}
+
+func (a *msgpackArray) AppendBool(v bool)              { a.add(mpAppendBool(a.buf, v)) }
+func (a *msgpackArray) AppendByteString(v []byte)      { a.add(mpAppendString(a.buf, string(v))) }
+func (a *msgpackArray) AppendComplex128(v complex128)  { a.add(mpAppendComplex(a.buf, v, 128)) }
+func (a *msgpackArray) AppendComplex64(v complex64)    { a.add(mpAppendComplex(a.buf, complex128(v), 64)) }
+func (a *msgpackArray) AppendFloat64(v float64)        { a.add(mpAppendFloat64(a.buf, v)) }
+func (a *msgpackArray) AppendFloat32(v float32)        { a.add(mpAppendFloat32(a.buf, v)) }
+func (a *msgpackArray) AppendInt(v int)                { a.AppendInt64(int64(v)) }
+func (a *msgpackArray) AppendInt64(v int64)            { a.add(mpAppendInt(a.buf, v)) }
+func (a *msgpackArray) AppendInt32(v int32)            { a.AppendInt64(int64(v)) }
+func (a *msgpackArray) AppendInt16(v int16)            { a.AppendInt64(int64(v)) }
+func (a *msgpackArray) AppendInt8(v int8)              { a.AppendInt64(int64(v)) }
+func (a *msgpackArray) AppendString(v string)          { a.add(mpAppendString(a.buf, v)) }
+func (a *msgpackArray) AppendUint(v uint)              { a.AppendUint64(uint64(v)) }
+func (a *msgpackArray) AppendUint64(v uint64)          { a.add(mpAppendUint(a.buf, v)) }
+func (a *msgpackArray) AppendUint32(v uint32)          { a.AppendUint64(uint64(v)) }
+func (a *msgpackArray) AppendUint16(v uint16)          { a.AppendUint64(uint64(v)) }
+func (a *msgpackArray) AppendUint8(v uint8)            { a.AppendUint64(uint64(v)) }
+func (a *msgpackArray) AppendUintptr(v uintptr)        { a.AppendUint64(uint64(v)) }
+func (a *msgpackArray) AppendDuration(v time.Duration) { a.AppendInt64(int64(v)) }
+func (a *msgpackArray) AppendTime(v time.Time)         { a.add(mpAppendTime(a.buf, v)) }
+
+func (a *msgpackArray) AppendArray(marshaler zapcore.ArrayMarshaler) error {
+	arr := &msgpackArray{}
+	err := marshaler.MarshalLogArray(arr)
+	a.add(append(mpAppendArrayHeader(a.buf, arr.count), arr.buf...))
+	return err
+}
+
+func (a *msgpackArray) AppendObject(marshaler zapcore.ObjectMarshaler) error {
+	obj := &msgpackEncoder{}
+	err := marshaler.MarshalLogObject(obj)
+	obj.closeNamespaces()
+	a.add(append(mpAppendMapHeader(a.buf, obj.count), obj.buf...))
+	return err
+}
+
+func (a *msgpackArray) AppendReflected(value interface{}) error {
+	data, err := mpAppendReflected(a.buf, value)
+	if err != nil {
+		return err
+	}
+	a.add(data)
+	return nil
+}
+
+// add 更新缓冲并增加元素个数
+func (a *msgpackArray) add(buf []byte) {
+	a.buf = buf
+	a.count++
+}
+
+// mpAppendReflected 把任意值按 JSON 编码后的结构写入
+func mpAppendReflected(buf []byte, value interface{}) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	return mpAppendValue(buf, decoded), nil
+}
+
+// mpAppendValue 写入 JSON 解码得到的值，对象的键按字典序排列
+func mpAppendValue(buf []byte, value any) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, 0xc0)
+	case bool:
+		return mpAppendBool(buf, v)
+	case string:
+		return mpAppendString(buf, v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return mpAppendInt(buf, i)
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return mpAppendUint(buf, u)
+		}
+		f, _ := v.Float64()
+		return mpAppendFloat64(buf, f)
+	case []any:
+		buf = mpAppendArrayHeader(buf, len(v))
+		for _, item := range v {
+			buf = mpAppendValue(buf, item)
+		}
+		return buf
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf = mpAppendMapHeader(buf, len(v))
+		for _, k := range keys {
+			buf = mpAppendValue(mpAppendString(buf, k), v[k])
+		}
+		return buf
+	}
+	return mpAppendString(buf, fmt.Sprint(value))
+}
+
+func mpAppendBool(buf []byte, v bool) []byte {
+	if v {
+		return append(buf, 0xc3)
+	}
+	return append(buf, 0xc2)
+}
+
+func mpAppendInt(buf []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return mpAppendUint(buf, uint64(v))
+	case v >= -32:
+		return append(buf, byte(v))
+	case v >= math.MinInt8:
+		return append(buf, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(v))
+}
+
+func mpAppendUint(buf []byte, v uint64) []byte {
+	switch {
+	case v < 0x80:
+		return append(buf, byte(v))
+	case v <= math.MaxUint8:
+		return append(buf, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xce), uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, 0xcf), v)
+}
+
+func mpAppendFloat64(buf []byte, v float64) []byte {
+	return binary.BigEndian.AppendUint64(append(buf, 0xcb), math.Float64bits(v))
+}
+
+func mpAppendFloat32(buf []byte, v float32) []byte {
+	return binary.BigEndian.AppendUint32(append(buf, 0xca), math.Float32bits(v))
+}
+
+func mpAppendString(buf []byte, v string) []byte {
+	n := len(v)
+	switch {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, 0xda), uint16(n))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xdb), uint32(n))
+	}
+	return append(buf, v...)
+}
+
+// mpAppendComplex 复数写为字符串，如 1+2i
+func mpAppendComplex(buf []byte, v complex128, bitSize int) []byte {
+	s := strconv.FormatComplex(v, 'g', -1, bitSize)
+	return mpAppendString(buf, s[1:len(s)-1])
+}
+
+func mpAppendBinary(buf []byte, v []byte) []byte {
+	n := len(v)
+	switch {
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, 0xc5), uint16(n))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xc6), uint32(n))
+	}
+	return append(buf, v...)
+}
+
+func mpAppendMapHeader(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xde), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(buf, 0xdf), uint32(n))
+}
+
+func mpAppendArrayHeader(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xdc), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(buf, 0xdd), uint32(n))
+}
+
+// mpAppendTime 写入 timestamp 扩展类型（type -1，96 位格式：4 字节纳秒 + 8 字节秒）
+func mpAppendTime(buf []byte, t time.Time) []byte {
+	buf = append(buf, 0xc7, 12, 0xff)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(t.Nanosecond()))
+	return binary.BigEndian.AppendUint64(buf, uint64(t.Unix()))
+}
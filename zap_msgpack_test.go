@@ -0,0 +1,188 @@
+package mlog
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// mpDecode 解码一个 MessagePack 值（只支持编码器输出的类型），返回值和剩余数据
+func mpDecode(t *testing.T, b []byte) (any, []byte) {
+	t.Helper()
+	c := b[0]
+	switch {
+	case c < 0x80:
+		return int64(c), b[1:]
+	case c >= 0xe0:
+		return int64(int8(c)), b[1:]
+	case c&0xf0 == 0x80:
+		return mpDecodeMap(t, b[1:], int(c&0x0f))
+	case c&0xf0 == 0x90:
+		return mpDecodeArray(t, b[1:], int(c&0x0f))
+	case c&0xe0 == 0xa0:
+		n := int(c & 0x1f)
+		return string(b[1 : 1+n]), b[1+n:]
+	}
+	switch c {
+	case 0xc0:
+		return nil, b[1:]
+	case 0xc2, 0xc3:
+		return c == 0xc3, b[1:]
+	case 0xc4:
+		n := int(b[1])
+		return b[2 : 2+n], b[2+n:]
+	case 0xc7:
+		if b[1] != 12 || b[2] != 0xff {
+			t.Fatalf("不支持的扩展类型: %x", b[:3])
+		}
+		nsec := binary.BigEndian.Uint32(b[3:])
+		sec := binary.BigEndian.Uint64(b[7:])
+		return time.Unix(int64(sec), int64(nsec)), b[15:]
+	case 0xcb:
+		return math.Float64frombits(binary.BigEndian.Uint64(b[1:])), b[9:]
+	case 0xcc:
+		return int64(b[1]), b[2:]
+	case 0xcd:
+		return int64(binary.BigEndian.Uint16(b[1:])), b[3:]
+	case 0xce:
+		return int64(binary.BigEndian.Uint32(b[1:])), b[5:]
+	case 0xcf:
+		return binary.BigEndian.Uint64(b[1:]), b[9:]
+	case 0xd0:
+		return int64(int8(b[1])), b[2:]
+	case 0xd1:
+		return int64(int16(binary.BigEndian.Uint16(b[1:]))), b[3:]
+	case 0xd2:
+		return int64(int32(binary.BigEndian.Uint32(b[1:]))), b[5:]
+	case 0xd3:
+		return int64(binary.BigEndian.Uint64(b[1:])), b[9:]
+	case 0xd9:
+		n := int(b[1])
+		return string(b[2 : 2+n]), b[2+n:]
+	case 0xda:
+		n := int(binary.BigEndian.Uint16(b[1:]))
+		return string(b[3 : 3+n]), b[3+n:]
+	case 0xde:
+		return mpDecodeMap(t, b[3:], int(binary.BigEndian.Uint16(b[1:])))
+	}
+	t.Fatalf("不支持的类型: %x", c)
+	return nil, nil
+}
+
+func mpDecodeMap(t *testing.T, b []byte, n int) (any, []byte) {
+	m := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		var k, v any
+		k, b = mpDecode(t, b)
+		v, b = mpDecode(t, b)
+		m[k.(string)] = v
+	}
+	return m, b
+}
+
+func mpDecodeArray(t *testing.T, b []byte, n int) (any, []byte) {
+	arr := make([]any, n)
+	for i := range arr {
+		arr[i], b = mpDecode(t, b)
+	}
+	return arr, b
+}
+
+// TestMsgpackEncoder 测试各类字段、嵌套对象和 namespace 的编码
+func TestMsgpackEncoder(t *testing.T) {
+	enc := newMsgpackEncoder(remoteEncoderConfig())
+	enc.AddString("server", "gate-1")
+	enc.OpenNamespace("player")
+	enc.AddInt64("id", 10086)
+
+	now := time.Unix(1767225600, 123456789)
+	buf, err := enc.EncodeEntry(zapcore.Entry{Level: zapcore.WarnLevel, Time: now, Message: "背包已满"}, []zapcore.Field{
+		zap.Int("slots", -200),
+		zap.Strings("items", []string{"sword", "shield"}),
+		zap.Any("pos", map[string]any{"x": 1.5, "y": -3}),
+		zap.Duration("cost", 1500*time.Microsecond),
+		zap.Error(errors.New("full")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, rest := mpDecode(t, buf.Bytes())
+	if len(rest) != 0 {
+		t.Fatalf("剩余 %d 字节未解码", len(rest))
+	}
+	entry := value.(map[string]any)
+	if entry["level"] != "warn" || entry["msg"] != "背包已满" || entry["server"] != "gate-1" || !entry["ts"].(time.Time).Equal(now) {
+		t.Fatalf("日志元数据错误: %v", entry)
+	}
+	player := entry["player"].(map[string]any)
+	if player["id"] != int64(10086) || player["slots"] != int64(-200) || player["cost"] != int64(1500000) || player["error"] != "full" {
+		t.Fatalf("namespace 中的字段错误: %v", player)
+	}
+	if items := player["items"].([]any); len(items) != 2 || items[1] != "shield" {
+		t.Fatalf("数组字段错误: %v", player["items"])
+	}
+	if pos := player["pos"].(map[string]any); pos["x"] != 1.5 || pos["y"] != int64(-3) {
+		t.Fatalf("反射字段错误: %v", player["pos"])
+	}
+}
+
+// TestNetSinkMsgpack 测试网络输出使用 MessagePack 编码时连续写入、不加分隔符
+func TestNetSinkMsgpack(t *testing.T) {
+	if _, err := newNetSinkCore(NetSinkConfig{URL: "tcp://127.0.0.1:1", Encoding: "protobuf"}); err == nil {
+		t.Fatal("不支持的编码应返回错误")
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	core, err := newNetSinkCore(NetSinkConfig{
+		URL:               "tcp://" + ln.Addr().String(),
+		Encoding:          SinkEncodingMsgpack,
+		RemoteBatchConfig: RemoteBatchConfig{FlushIntervalMs: 20},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	log := zap.New(core)
+	log.Info("first", zap.Int("n", 10))
+	log.Info("second", zap.Int("n", 2))
+	core.batcher.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	var data []byte
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		data = append(data, buf[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	for _, want := range []string{"first", "second"} {
+		if len(data) == 0 {
+			t.Fatalf("未收到日志 %s", want)
+		}
+		var value any
+		value, data = mpDecode(t, data)
+		if msg := value.(map[string]any); msg["msg"] != want {
+			t.Fatalf("日志错误: 期望 %s，实际 %v", want, msg)
+		}
+	}
+	if len(data) != 0 {
+		t.Fatalf("多余的数据: %x", data)
+	}
+}
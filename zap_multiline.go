@@ -0,0 +1,69 @@
+package mlog
+
+import (
+	"bytes"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// escapePool 转义换行后的日志缓冲
+var escapePool = buffer.NewPool()
+
+// escapeNewlinesEncoder 把一条日志内部的换行转义为 \n（回车转义为 \r），保证每条日志只占一行
+// 用于 console 和自定义格式，消息、字段以及错误日志附带的堆栈都不再包含原始换行，按行采集的工具不会把一条日志拆成多条
+type escapeNewlinesEncoder struct {
+	zapcore.Encoder
+	lineEnding string
+}
+
+// newEscapeNewlinesEncoder 包装编码器，lineEnding 为编码器的行尾，不会被转义
+func newEscapeNewlinesEncoder(enc zapcore.Encoder, lineEnding string) zapcore.Encoder {
+	return &escapeNewlinesEncoder{Encoder: enc, lineEnding: lineEnding}
+}
+
+func (e *escapeNewlinesEncoder) Clone() zapcore.Encoder {
+	return &escapeNewlinesEncoder{Encoder: e.Encoder.Clone(), lineEnding: e.lineEnding}
+}
+
+func (e *escapeNewlinesEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return buf, err
+	}
+	body, ending := buf.Bytes(), []byte(nil)
+	if bytes.HasSuffix(body, []byte(e.lineEnding)) {
+		body, ending = body[:len(body)-len(e.lineEnding)], body[len(body)-len(e.lineEnding):]
+	}
+	if bytes.IndexAny(body, "\r\n") < 0 {
+		return buf, nil
+	}
+	escaped := escapePool.Get()
+	for _, b := range body {
+		switch b {
+		case '\n':
+			escaped.AppendString(`\n`)
+		case '\r':
+			escaped.AppendString(`\r`)
+		default:
+			escaped.AppendByte(b)
+		}
+	}
+	escaped.Write(ending)
+	buf.Free()
+	return escaped, nil
+}
+
+// stackField 断言日志的堆栈字段
+func stackField(stack string) zap.Field {
+	return zap.String(stacktraceKey(), stack)
+}
+
+// stacktraceKey 堆栈字段的键名，使用 StacktraceKey（未配置时为 stacktrace）
+func stacktraceKey() string {
+	if zapConfig.StacktraceKey == "" {
+		return "stacktrace"
+	}
+	return zapConfig.StacktraceKey
+}
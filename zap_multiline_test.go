@@ -0,0 +1,70 @@
+package mlog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestEscapeNewlinesEncoder 测试一条日志内部的换行被转义，行尾保留
+func TestEscapeNewlinesEncoder(t *testing.T) {
+	config := zapcore.EncoderConfig{MessageKey: "message", StacktraceKey: "stacktrace", LineEnding: "\n"}
+	enc := newEscapeNewlinesEncoder(zapcore.NewConsoleEncoder(config), config.LineEnding)
+	buf, err := enc.Clone().EncodeEntry(zapcore.Entry{Message: "第一行\r\n第二行", Stack: "main.main\n\tmain.go:10"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != `第一行\r\n第二行\nmain.main\n`+"\tmain.go:10\n" {
+		t.Fatalf("转义结果错误: %q", got)
+	}
+}
+
+// TestEscapeNewlines 测试单行模式下多行消息、错误堆栈和断言堆栈都只占一行
+func TestEscapeNewlines(t *testing.T) {
+	oldConfig := zapConfig
+	defer func() { zapConfig = oldConfig }()
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "console", StacktraceKey: "stacktrace", EscapeNewlines: true}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	Info("第一行\n第二行")
+	ErrorW("登录失败", zap.Error(errors.New("timeout")))
+	AssertString("背包数量异常 %d", -1)
+	Flush()
+
+	var out []string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.HasSuffix(path, ".log") {
+			data, _ := os.ReadFile(path)
+			out = append(out, strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")...)
+		}
+		return nil
+	})
+	all := strings.Join(out, "\n")
+	for _, want := range []string{`第一行\n第二行`, "登录失败", "背包数量异常 -1"} {
+		found := false
+		for _, line := range out {
+			if strings.Contains(line, want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("缺少 %q: %s", want, all)
+		}
+	}
+	for _, line := range out {
+		if strings.Contains(line, "[Assert]") && !strings.Contains(line, `"stacktrace":`) {
+			t.Fatalf("断言堆栈应作为单独字段输出: %s", line)
+		}
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "Stack Trace") {
+			t.Fatalf("日志不应跨行: %s", all)
+		}
+	}
+}
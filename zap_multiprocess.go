@@ -0,0 +1,147 @@
+package mlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ai-mmo/lumberjack"
+)
+
+// 多进程共用日志目录时的写入模式
+const (
+	MultiProcessPID   = "pid"   // 每个进程写入带 PID 后缀的文件，如 info.1234.log，互不影响
+	MultiProcessFlock = "flock" // 多个进程追加写入同一个文件，写入和轮转时持有目录级的 flock
+)
+
+// multiProcessLockName flock 模式下日志目录中的锁文件名
+const multiProcessLockName = ".mlog.lock"
+
+// multiProcess 返回生效的多进程写入模式，不支持 flock 的平台回退到 pid 模式
+func (c *ZapConfig) multiProcess() string {
+	if c.MultiProcess == MultiProcessFlock && !flockSupported {
+		return MultiProcessPID
+	}
+	return c.MultiProcess
+}
+
+// processFileName pid 模式下在文件名的扩展名之前插入进程 ID，其他模式原样返回
+func (c *ZapConfig) processFileName(name string) string {
+	if c.multiProcess() != MultiProcessPID {
+		return name
+	}
+	ext := filepath.Ext(name)
+	return name[:len(name)-len(ext)] + "." + strconv.Itoa(os.Getpid()) + ext
+}
+
+// dirLock 日志目录的进程间互斥锁
+// 多个进程的 lumberjack 各自统计文件大小并在轮转时重命名文件，同时轮转会互相覆盖，
+// 轮转后以非追加方式打开的新文件也会被其他进程的写入覆盖。flock 模式下每次写入都持有锁，
+// 在锁内检查文件是否已被其他进程轮转、按实际文件大小决定是否轮转。调用方需持有 fileWriteSyncer.rotateMu
+type dirLock struct {
+	path string
+	file *os.File
+}
+
+// newDirLock 创建 dir 目录的进程间锁，锁文件在第一次加锁时创建
+func newDirLock(dir string) *dirLock {
+	return &dirLock{path: filepath.Join(dir, multiProcessLockName)}
+}
+
+// lock 加锁，阻塞到其他进程释放
+func (l *dirLock) lock() error {
+	if l.file == nil {
+		if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR, 0o644)
+		if err != nil {
+			return err
+		}
+		l.file = file
+	}
+	if err := flockFile(l.file); err != nil {
+		return fmt.Errorf("锁定日志目录失败 [%s]: %w", l.path, err)
+	}
+	return nil
+}
+
+// unlock 解锁
+func (l *dirLock) unlock() {
+	funlockFile(l.file)
+}
+
+// Close 关闭锁文件
+func (l *dirLock) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// writeShared flock 模式下的写入：持有目录锁，其他进程轮转后重新打开文件，按文件实际大小轮转。调用方需持有 f.mu 的写锁
+func (f *fileWriteSyncer) writeShared(p []byte) (int, error) {
+	f.rotateMu.Lock()
+	defer f.rotateMu.Unlock()
+	if err := f.shared.lock(); err != nil {
+		return 0, err
+	}
+	defer f.shared.unlock()
+
+	name := f.logger.Filename
+	fi, err := os.Stat(name)
+	if err != nil {
+		// 文件不存在时由这里以追加方式创建，lumberjack 自己创建的文件不是追加方式打开的
+		f.replaceLogger()
+		if err := createAppendFile(name); err != nil {
+			return 0, err
+		}
+		f.headerPending.Store(true)
+		fi, _ = os.Stat(name)
+	} else if f.current != nil && !os.SameFile(f.current, fi) {
+		// 其他进程已经轮转，关闭旧文件，下一次写入时重新打开
+		f.replaceLogger()
+	}
+	current := fi
+	if fi != nil && fi.Size() > 0 && fi.Size()+int64(len(p)) >= f.maxBytes() {
+		if err := f.rotateLocked(); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 轮转日志文件失败 [%s]: %v\n", name, err)
+		}
+		current = nil
+	}
+	n, err := f.writeFile(p)
+	if current == nil {
+		current, _ = os.Stat(name)
+	}
+	f.current = current
+	return n, err
+}
+
+// replaceLogger 关闭当前的 lumberjack logger（关闭后不能再写入），换成相同配置的新 logger，下一次写入时重新打开文件
+func (f *fileWriteSyncer) replaceLogger() {
+	old := f.logger
+	old.Close()
+	f.logger = &lumberjack.Logger{
+		Filename:   old.Filename,
+		MaxSize:    old.MaxSize,
+		MaxBackups: old.MaxBackups,
+		MaxAge:     old.MaxAge,
+		Compress:   old.Compress,
+		LocalTime:  old.LocalTime,
+	}
+}
+
+// createAppendFile 创建不存在的日志文件
+func createAppendFile(name string) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package mlog
+
+import (
+	"errors"
+	"os"
+)
+
+// flockSupported 当前平台是否支持 flock 模式，不支持时 flock 模式回退到 pid 模式
+const flockSupported = false
+
+func flockFile(f *os.File) error {
+	return errors.ErrUnsupported
+}
+
+func funlockFile(f *os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,94 @@
+package mlog
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestProcessFileName 测试 pid 模式的文件名
+func TestProcessFileName(t *testing.T) {
+	c := ZapConfig{MultiProcess: MultiProcessPID}
+	want := "info." + strconv.Itoa(os.Getpid()) + ".log"
+	if got := c.processFileName("info.log"); got != want {
+		t.Fatalf("文件名错误: %s", got)
+	}
+	c.MultiProcess = ""
+	if got := c.processFileName("info.log"); got != "info.log" {
+		t.Fatalf("单进程模式不应修改文件名: %s", got)
+	}
+}
+
+// TestWriteShared 测试 flock 模式下两个输出（各自持有锁文件描述符，相当于两个进程）
+// 并发写入同一文件并多次轮转，所有日志完整且不丢失
+func TestWriteShared(t *testing.T) {
+	if !flockSupported {
+		t.Skip("当前平台不支持 flock")
+	}
+	dir := t.TempDir()
+	name := filepath.Join(dir, "info.log")
+	newSyncer := func() *fileWriteSyncer {
+		f := newFileWriteSyncer(name)
+		f.shared = newDirLock(dir)
+		f.logger.MaxSize = 1
+		f.logger.MaxBackups = 0
+		f.logger.MaxAge = 0
+		f.logger.Compress = false
+		return f
+	}
+	syncers := []*fileWriteSyncer{newSyncer(), newSyncer()}
+	const lines = 1500
+	padding := strings.Repeat("x", 1000)
+	var wg sync.WaitGroup
+	for i, f := range syncers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < lines; j++ {
+				if _, err := f.Write([]byte(fmt.Sprintf("%d-%d %s\n", i, j, padding))); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for _, f := range syncers {
+		f.Close()
+	}
+
+	seen := make(map[string]bool)
+	entries, _ := os.ReadDir(dir)
+	files := 0
+	for _, e := range entries {
+		if e.Name() == multiProcessLockName {
+			continue
+		}
+		files++
+		file, err := os.Open(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 4096), 4096)
+		for scanner.Scan() {
+			id, rest, ok := strings.Cut(scanner.Text(), " ")
+			if !ok || rest != padding {
+				t.Fatalf("%s 中的日志被覆盖: %.40q", e.Name(), scanner.Text())
+			}
+			seen[id] = true
+		}
+		file.Close()
+	}
+	if len(seen) != 2*lines {
+		t.Fatalf("日志丢失: 期望 %d 条，实际 %d 条", 2*lines, len(seen))
+	}
+	if files < 3 {
+		t.Fatalf("应发生轮转: %d 个文件", files)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package mlog
+
+import (
+	"os"
+	"syscall"
+)
+
+// flockSupported 当前平台是否支持 flock 模式
+const flockSupported = true
+
+// flockFile 对文件加排他锁
+func flockFile(f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+// funlockFile 释放文件锁
+func funlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
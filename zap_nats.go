@@ -0,0 +1,322 @@
+package mlog
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsDialTimeout NATS 建立连接的超时时间
+const natsDialTimeout = 3 * time.Second
+
+// NATSConfig NATS 输出配置
+type NATSConfig struct {
+	Enable bool   `mapstructure:"enable" json:"enable" yaml:"enable"` // 启用 NATS 输出
+	URL    string `mapstructure:"url" json:"url" yaml:"url"`          // 服务器地址，如 nats://127.0.0.1:4222，tls:// 使用 TLS
+	// 主题前缀（默认 logs），日志发布到 <前缀>.<服务名>.<级别>
+	SubjectPrefix string `mapstructure:"subject-prefix" json:"subject-prefix" yaml:"subject-prefix"`
+	Token         string `mapstructure:"token" json:"token" yaml:"token"`          // 令牌认证
+	Username      string `mapstructure:"username" json:"username" yaml:"username"` // 用户名认证
+	Password      string `mapstructure:"password" json:"password" yaml:"password"` // 密码
+	// 使用 JetStream 持久化，每条日志等待服务端确认，需预先创建包含上述主题的 Stream
+	JetStream         bool `mapstructure:"jetstream" json:"jetstream" yaml:"jetstream"`
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// natsPublisher NATS 发布客户端
+// 直接实现 NATS 文本协议：核心模式整批发布后以 PING/PONG 确认服务端已处理，
+// JetStream 模式为每条日志指定回复主题并等待发布确认
+type natsPublisher struct {
+	address  string
+	useTLS   bool
+	connect  []byte // CONNECT 命令
+	prefix   string // <前缀>.<服务名>.
+	jsInbox  string // JetStream 确认的回复主题前缀
+	jsEnable bool
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// newNATSCore 创建 NATS 输出 Core
+func newNATSCore(cfg NATSConfig, serviceName string) (*remoteCore, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("未配置 NATS 服务器地址")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("NATS 服务器地址格式错误: %w", err)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return nil, fmt.Errorf("不支持的 NATS 地址协议: %s", u.Scheme)
+	}
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	options := map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "mlog-" + serviceName,
+		"lang":     "go",
+		"version":  Version,
+		"protocol": 1,
+	}
+	if cfg.Token != "" {
+		options["auth_token"] = cfg.Token
+	}
+	if cfg.Username != "" {
+		options["user"] = cfg.Username
+		options["pass"] = cfg.Password
+	} else if u.User != nil {
+		options["user"] = u.User.Username()
+		options["pass"], _ = u.User.Password()
+	}
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := cfg.SubjectPrefix
+	if prefix == "" {
+		prefix = "logs"
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	p := &natsPublisher{
+		address:  address,
+		useTLS:   u.Scheme == "tls",
+		connect:  append(append([]byte("CONNECT "), connect...), "\r\n"...),
+		prefix:   strings.TrimSuffix(prefix, ".") + "." + natsSubjectToken(serviceName) + ".",
+		jsInbox:  "_INBOX." + hex.EncodeToString(id) + ".",
+		jsEnable: cfg.JetStream,
+	}
+	batcher := newRemoteBatcher("nats", cfg.RemoteBatchConfig, p.publish)
+	addSinkCloser(batcher)
+	addSinkCloser(p)
+	return newRemoteCore(batcher, "", ""), nil
+}
+
+// natsSubjectToken 将名称转换为合法的主题片段（替换分隔符、通配符和空白）
+func natsSubjectToken(name string) string {
+	if name == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\r', '\n':
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// publish 发布一批日志
+func (p *natsPublisher) publish(ctx context.Context, batch []remoteRecord) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.ensureConnLocked(ctx); err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		p.conn.SetDeadline(deadline)
+	}
+
+	var buf []byte
+	for i := range batch {
+		subject := p.prefix + batch[i].Entry.Level.String()
+		buf = append(buf, "PUB "...)
+		buf = append(buf, subject...)
+		if p.jsEnable {
+			buf = append(buf, ' ')
+			buf = append(buf, p.jsInbox...)
+			buf = strconv.AppendInt(buf, int64(i), 10)
+		}
+		buf = append(buf, ' ')
+		buf = strconv.AppendInt(buf, int64(len(batch[i].Data)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, batch[i].Data...)
+		buf = append(buf, "\r\n"...)
+	}
+	if !p.jsEnable {
+		buf = append(buf, "PING\r\n"...)
+	}
+	if _, err := p.conn.Write(buf); err != nil {
+		p.resetLocked()
+		return err
+	}
+
+	if !p.jsEnable {
+		// 收到 PONG 说明之前的 PUB 已被服务端处理
+		if err := p.waitLocked(func(string, []byte) bool { return false }); err != nil {
+			p.resetLocked()
+			return err
+		}
+		return nil
+	}
+
+	// 等待每条日志的 JetStream 确认，未确认或被拒绝的日志重试
+	acked := make([]bool, len(batch))
+	replied := make([]bool, len(batch))
+	pending, remaining := len(batch), len(batch)
+	var lastErr error
+	err := p.waitLocked(func(subject string, payload []byte) bool {
+		i, err := strconv.Atoi(strings.TrimPrefix(subject, p.jsInbox))
+		if err != nil || i < 0 || i >= len(batch) || replied[i] {
+			return true
+		}
+		replied[i] = true
+		pending--
+		var ack struct {
+			Error *struct {
+				Code        int    `json:"code"`
+				Description string `json:"description"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(payload, &ack); err != nil {
+			lastErr = err
+		} else if ack.Error != nil {
+			lastErr = fmt.Errorf("JetStream 发布失败: %d %s", ack.Error.Code, ack.Error.Description)
+		} else {
+			acked[i] = true
+			remaining--
+		}
+		return pending > 0
+	})
+	if remaining == 0 {
+		return nil
+	}
+	if err != nil {
+		// 连接出错或等待超时，之后的确认无法与批次对应，重建连接
+		p.resetLocked()
+		lastErr = err
+	}
+	retry := make([]remoteRecord, 0, remaining)
+	for i := range batch {
+		if !acked[i] {
+			retry = append(retry, batch[i])
+		}
+	}
+	return &remotePartialError{Retry: retry, Err: lastErr}
+}
+
+// ensureConnLocked 连接不可用时重新连接并完成握手，调用方需持有 mu
+func (p *natsPublisher) ensureConnLocked(ctx context.Context) error {
+	if p.conn != nil {
+		return nil
+	}
+	dialer := net.Dialer{Timeout: natsDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(natsDialTimeout))
+	reader := bufio.NewReader(conn)
+
+	// 服务端先发送 INFO
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("NATS 握手失败: %s", strings.TrimSpace(line))
+	}
+	if p.useTLS {
+		host, _, _ := net.SplitHostPort(p.address)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return err
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	handshake := append([]byte(nil), p.connect...)
+	if p.jsEnable {
+		handshake = append(handshake, "SUB "+p.jsInbox+"* 1\r\n"...)
+	}
+	handshake = append(handshake, "PING\r\n"...)
+	if _, err := conn.Write(handshake); err != nil {
+		conn.Close()
+		return err
+	}
+	p.conn, p.reader = conn, reader
+	if err := p.waitLocked(func(string, []byte) bool { return true }); err != nil {
+		p.resetLocked()
+		return fmt.Errorf("NATS 握手失败: %w", err)
+	}
+	return nil
+}
+
+// waitLocked 读取服务端消息直到收到 PONG 或 onMsg 返回 false，调用方需持有 mu
+// 期间回复服务端的 PING，-ERR 作为错误返回
+func (p *natsPublisher) waitLocked(onMsg func(subject string, payload []byte) bool) error {
+	for {
+		line, err := p.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case line == "+OK", strings.HasPrefix(line, "INFO "):
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS 服务端错误: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			args := strings.Fields(line)
+			if len(args) < 4 {
+				return fmt.Errorf("NATS 消息格式错误: %s", line)
+			}
+			size, err := strconv.Atoi(args[len(args)-1])
+			if err != nil {
+				return fmt.Errorf("NATS 消息格式错误: %s", line)
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(p.reader, payload); err != nil {
+				return err
+			}
+			if !onMsg(args[1], payload[:size]) {
+				return nil
+			}
+		}
+	}
+}
+
+// resetLocked 关闭当前连接，下一次发布时重连，调用方需持有 mu
+func (p *natsPublisher) resetLocked() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn, p.reader = nil, nil
+	}
+}
+
+// Close 关闭连接
+func (p *natsPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resetLocked()
+	return nil
+}
@@ -0,0 +1,135 @@
+package mlog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// natsTestMsg 测试服务端收到的一条 PUB
+type natsTestMsg struct {
+	subject string
+	payload string
+}
+
+// startNATSTestServer 启动只实现发布相关命令的 NATS 测试服务端
+// jsFail 返回 true 的消息以 JetStream 错误回复
+func startNATSTestServer(t *testing.T, jsFail func(n int) bool) (string, func() []natsTestMsg) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var (
+		mu   sync.Mutex
+		msgs []natsTestMsg
+	)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+				r := bufio.NewReader(conn)
+				published := 0
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					args := strings.Fields(line)
+					switch args[0] {
+					case "CONNECT", "SUB":
+					case "PING":
+						conn.Write([]byte("PONG\r\n"))
+					case "PUB":
+						size, _ := strconv.Atoi(args[len(args)-1])
+						payload := make([]byte, size+2)
+						io.ReadFull(r, payload)
+						published++
+						ok := jsFail == nil || !jsFail(published)
+						if ok {
+							mu.Lock()
+							msgs = append(msgs, natsTestMsg{args[1], string(payload[:size])})
+							mu.Unlock()
+						}
+						if len(args) == 4 {
+							ack := `{"stream":"LOGS","seq":1}`
+							if !ok {
+								ack = `{"error":{"code":503,"description":"unavailable"}}`
+							}
+							conn.Write([]byte("MSG " + args[2] + " 1 " + strconv.Itoa(len(ack)) + "\r\n" + ack + "\r\n"))
+						}
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String(), func() []natsTestMsg {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]natsTestMsg(nil), msgs...)
+	}
+}
+
+// TestNATSPublish 测试核心模式按服务名和级别生成主题
+func TestNATSPublish(t *testing.T) {
+	addr, received := startNATSTestServer(t, nil)
+	core, err := newNATSCore(NATSConfig{URL: "nats://" + addr}, "game.gate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	log := zap.New(core)
+	log.Info("登录")
+	log.Error("断线")
+	core.batcher.Close()
+
+	msgs := received()
+	if len(msgs) != 2 || msgs[0].subject != "logs.game_gate.info" || msgs[1].subject != "logs.game_gate.error" ||
+		!strings.Contains(msgs[1].payload, `"msg":"断线"`) {
+		t.Fatalf("发布内容错误: %+v", msgs)
+	}
+	if stats := core.batcher.stats(); stats.Sent != 2 {
+		t.Fatalf("统计错误: %+v", stats)
+	}
+}
+
+// TestNATSJetStreamRetry 测试 JetStream 只重试被拒绝的日志
+func TestNATSJetStreamRetry(t *testing.T) {
+	addr, received := startNATSTestServer(t, func(n int) bool { return n == 2 })
+	core, err := newNATSCore(NATSConfig{
+		URL:               "nats://" + addr,
+		SubjectPrefix:     "ops",
+		JetStream:         true,
+		RemoteBatchConfig: RemoteBatchConfig{RetryBackoffMs: 10},
+	}, "game")
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	log := zap.New(core)
+	for _, msg := range []string{"a", "b", "c"} {
+		log.Warn(msg)
+	}
+	core.batcher.Close()
+
+	msgs := received()
+	if len(msgs) != 3 || msgs[0].subject != "ops.game.warn" || !strings.Contains(msgs[2].payload, `"msg":"b"`) {
+		t.Fatalf("发布内容错误: %+v", msgs)
+	}
+	if stats := core.batcher.stats(); stats.Sent != 3 || stats.Failed != 0 {
+		t.Fatalf("统计错误: %+v", stats)
+	}
+}
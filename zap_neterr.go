@@ -0,0 +1,136 @@
+package mlog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NetErrClass 网络错误分类，作为 net_err_class 字段写入日志，取值保持稳定以便看板聚合
+type NetErrClass string
+
+const (
+	NetErrNone       NetErrClass = ""            // 无错误
+	NetErrTimeout    NetErrClass = "timeout"     // 读写或连接超时
+	NetErrReset      NetErrClass = "reset"       // 连接被对端重置
+	NetErrRefused    NetErrClass = "refused"     // 连接被拒绝
+	NetErrEOF        NetErrClass = "eof"         // 对端关闭连接
+	NetErrClosed     NetErrClass = "closed"      // 使用已关闭的连接
+	NetErrBrokenPipe NetErrClass = "broken_pipe" // 向已关闭的连接写入
+	NetErrDNS        NetErrClass = "dns"         // 域名解析失败
+	NetErrOther      NetErrClass = "other"       // 其他错误
+)
+
+// ClassifyNetError 将网络错误归类为稳定的 NetErrClass
+func ClassifyNetError(err error) NetErrClass {
+	if err == nil {
+		return NetErrNone
+	}
+
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return NetErrEOF
+	case errors.Is(err, net.ErrClosed):
+		return NetErrClosed
+	case errors.Is(err, syscall.ECONNRESET):
+		return NetErrReset
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return NetErrRefused
+	case errors.Is(err, syscall.EPIPE):
+		return NetErrBrokenPipe
+	case errors.Is(err, os.ErrDeadlineExceeded), errors.Is(err, context.DeadlineExceeded):
+		return NetErrTimeout
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return NetErrDNS
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return NetErrTimeout
+	}
+
+	// 回退到错误信息匹配，覆盖 Windows 等平台的错误码
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "timeout"), strings.Contains(msg, "timed out"):
+		return NetErrTimeout
+	case strings.Contains(msg, "connection reset"), strings.Contains(msg, "forcibly closed"):
+		return NetErrReset
+	case strings.Contains(msg, "connection refused"), strings.Contains(msg, "actively refused"):
+		return NetErrRefused
+	case strings.Contains(msg, "broken pipe"):
+		return NetErrBrokenPipe
+	case strings.Contains(msg, "use of closed network connection"):
+		return NetErrClosed
+	case strings.HasSuffix(msg, "eof"):
+		return NetErrEOF
+	}
+	return NetErrOther
+}
+
+// NetErrFields 返回描述网络错误的字段：net_err_class、net_shutdown 和 error
+// net_shutdown 为 true 表示错误发生在 SetStopNetFlag 之后，通常是停服关闭连接引起的噪声
+func NetErrFields(err error) []zap.Field {
+	return []zap.Field{
+		zap.String("net_err_class", string(ClassifyNetError(err))),
+		zap.Bool("net_shutdown", StopNetFlag()),
+		zap.Error(err),
+	}
+}
+
+// NetError 输出带网络错误分类字段的日志
+// 正常运行时以 Error 级别输出；设置 StopNetFlag 之后的网络错误降为 Info 级别，避免停服时刷屏告警
+func NetError(msg string, err error, fields ...zap.Field) {
+	level := zapcore.ErrorLevel
+	if StopNetFlag() {
+		level = zapcore.InfoLevel
+	}
+	if !isLevelEnabledFast(level) {
+		return
+	}
+
+	allFields := make([]zap.Field, 0, len(fields)+3)
+	allFields = append(allFields, NetErrFields(err)...)
+	allFields = append(allFields, fields...)
+
+	if al, ok := getAsyncLogger(); ok {
+		// 调用栈：用户代码 -> mlog.NetError()
+		al.logAsyncAt(level, captureCaller(1), msg, nil, allFields...)
+		return
+	}
+
+	logger, ok := getLogger()
+	if !ok {
+		logBeforeInit(level, 1, msg, allFields...)
+		return
+	}
+
+	// 调用栈：用户代码 -> mlog.NetError() -> logger.Check()
+	// 需要跳过 1 层：mlog.NetError()
+	if ce := logger.WithOptions(zap.AddCallerSkip(1)).Check(level, msg); ce != nil {
+		ce.Write(allFields...)
+	}
+}
+
+// isLevelEnabledFast 使用级别缓存快速检查指定级别是否启用
+func isLevelEnabledFast(level zapcore.Level) bool {
+	switch level {
+	case zapcore.DebugLevel:
+		return isDebugEnabledFast()
+	case zapcore.InfoLevel:
+		return isInfoEnabledFast()
+	case zapcore.WarnLevel:
+		return isWarnEnabledFast()
+	default:
+		return isErrorEnabledFast()
+	}
+}
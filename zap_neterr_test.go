@@ -0,0 +1,36 @@
+package mlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+// TestClassifyNetError 测试常见网络错误的分类
+func TestClassifyNetError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want NetErrClass
+	}{
+		{nil, NetErrNone},
+		{io.EOF, NetErrEOF},
+		{fmt.Errorf("read packet: %w", io.ErrUnexpectedEOF), NetErrEOF},
+		{&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, NetErrReset},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, NetErrRefused},
+		{&net.OpError{Op: "write", Net: "tcp", Err: syscall.EPIPE}, NetErrBrokenPipe},
+		{&net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, NetErrTimeout},
+		{net.ErrClosed, NetErrClosed},
+		{&net.DNSError{Err: "no such host", Name: "example.invalid"}, NetErrDNS},
+		{errors.New("wsarecv: An existing connection was forcibly closed by the remote host."), NetErrReset},
+		{errors.New("invalid packet header"), NetErrOther},
+	}
+	for _, c := range cases {
+		if got := ClassifyNetError(c.err); got != c.want {
+			t.Errorf("ClassifyNetError(%v) = %q，期望 %q", c.err, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package mlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// netSinkDialTimeout 网络输出建立连接的超时时间
+const netSinkDialTimeout = 3 * time.Second
+
+// NetSinkConfig 通用网络输出配置，按行写入 JSON 日志（NDJSON）或连续写入 MessagePack 日志
+type NetSinkConfig struct {
+	Enable       bool   `mapstructure:"enable" json:"enable" yaml:"enable"`                         // 启用网络输出
+	URL          string `mapstructure:"url" json:"url" yaml:"url"`                                  // 目标地址，如 tcp://127.0.0.1:5170、udp://127.0.0.1:5170
+	MaxBackoffMs int    `mapstructure:"max-backoff-ms" json:"max-backoff-ms" yaml:"max-backoff-ms"` // 重连退避间隔上限（毫秒，默认 30000）
+	// 日志编码：json（默认，每条日志以换行结尾）或 msgpack（每条日志一个 MessagePack map，不加分隔符；UDP 每条日志一个数据报）
+	Encoding string `mapstructure:"encoding" json:"encoding" yaml:"encoding"`
+	// 断线期间日志保留在缓冲区并持续重连，缓冲区满时丢弃新日志
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// netSinkWriter 网络输出连接，断开后在下一次发送时重连
+type netSinkWriter struct {
+	network   string
+	address   string
+	delimiter []byte // 每条日志后的分隔符，MessagePack 编码时为空
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// newNetSinkCore 创建网络输出 Core
+// 调用 SetStopNetFlag 后不再缓冲和发送日志，避免停服阶段阻塞在网络上
+func newNetSinkCore(cfg NetSinkConfig) (*remoteCore, error) {
+	network, address, err := parseNetSinkURL(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	encoding, err := parseSinkEncoding(cfg.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	if encoding == SinkEncodingProtobuf {
+		// Protobuf 消息没有边界，连续写入时无法拆分
+		return nil, errors.New("网络输出不支持 protobuf 编码，请使用 json 或 msgpack")
+	}
+
+	w := &netSinkWriter{network: network, address: address, delimiter: []byte("\n")}
+	if encoding == SinkEncodingMsgpack {
+		w.delimiter = nil
+	}
+	batcher := newRemoteBatcher("net", cfg.RemoteBatchConfig, w.sendBatch)
+	batcher.persistent = true
+	batcher.paused = StopNetFlag
+	if cfg.MaxBackoffMs > 0 {
+		batcher.maxBackoff = time.Duration(cfg.MaxBackoffMs) * time.Millisecond
+	}
+	addSinkCloser(batcher)
+	addSinkCloser(w)
+
+	core := newRemoteCore(batcher, "", "")
+	core.useEncoding(encoding, "", 0)
+	return core, nil
+}
+
+// parseNetSinkURL 解析 tcp://host:port 或 udp://host:port 形式的地址
+func parseNetSinkURL(raw string) (network, address string, err error) {
+	if raw == "" {
+		return "", "", errors.New("未配置网络输出地址")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("网络输出地址格式错误: %w", err)
+	}
+	switch u.Scheme {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+	default:
+		return "", "", fmt.Errorf("不支持的网络输出协议: %s", u.Scheme)
+	}
+	if u.Host == "" || u.Port() == "" {
+		return "", "", fmt.Errorf("网络输出地址缺少主机或端口: %s", raw)
+	}
+	return u.Scheme, u.Host, nil
+}
+
+// sendBatch 发送一批日志，每条日志后写入分隔符
+// TCP 整批合并为一次写入，UDP 每条日志一个数据报
+func (w *netSinkWriter) sendBatch(ctx context.Context, batch []remoteRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		dialer := net.Dialer{Timeout: netSinkDialTimeout}
+		conn, err := dialer.DialContext(ctx, w.network, w.address)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		w.conn.SetWriteDeadline(deadline)
+	}
+
+	if w.isStream() {
+		size := 0
+		for i := range batch {
+			size += len(batch[i].Data) + len(w.delimiter)
+		}
+		buf := make([]byte, 0, size)
+		for i := range batch {
+			buf = append(buf, batch[i].Data...)
+			buf = append(buf, w.delimiter...)
+		}
+		if _, err := w.conn.Write(buf); err != nil {
+			// 无法确定对端收到了多少数据，断开后整批重发
+			w.resetLocked()
+			return err
+		}
+		return nil
+	}
+
+	for i := range batch {
+		if _, err := w.conn.Write(append(batch[i].Data, w.delimiter...)); err != nil {
+			w.resetLocked()
+			// 已发送的条目不再重试
+			return &remotePartialError{Retry: batch[i:], Err: err}
+		}
+	}
+	return nil
+}
+
+// isStream 是否为面向流的连接
+func (w *netSinkWriter) isStream() bool {
+	return w.network == "tcp" || w.network == "tcp4" || w.network == "tcp6"
+}
+
+// resetLocked 关闭当前连接，下一次发送时重连，调用方需持有 mu
+func (w *netSinkWriter) resetLocked() {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// Close 关闭连接
+func (w *netSinkWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
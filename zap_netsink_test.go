@@ -0,0 +1,122 @@
+package mlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestNetSinkReconnect 测试 TCP 输出在服务端暂不可用时缓冲日志，恢复后重连补发
+func TestNetSinkReconnect(t *testing.T) {
+	// 先占用端口再关闭，模拟服务端未启动
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	core, err := newNetSinkCore(NetSinkConfig{
+		URL: "tcp://" + addr,
+		RemoteBatchConfig: RemoteBatchConfig{
+			FlushIntervalMs: 20,
+			MaxRetries:      1,
+			RetryBackoffMs:  20,
+		},
+		MaxBackoffMs: 50,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	log := zap.New(core)
+	log.Info("first", zap.Int("n", 1))
+	log.Info("second", zap.Int("n", 2))
+
+	// 超过重试次数后仍保留日志，直到服务端恢复
+	time.Sleep(200 * time.Millisecond)
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("无法重新监听端口: %v", err)
+	}
+	defer ln.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	scanner := bufio.NewScanner(conn)
+	for _, want := range []string{"first", "second"} {
+		if !scanner.Scan() {
+			t.Fatalf("未收到日志 %s: %v", want, scanner.Err())
+		}
+		var msg map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg["msg"] != want {
+			t.Fatalf("日志顺序错误: 期望 %s，实际 %v", want, msg)
+		}
+	}
+	core.batcher.Close()
+	if stats := core.batcher.stats(); stats.Sent != 2 || stats.Failed != 0 {
+		t.Fatalf("统计错误: %+v", stats)
+	}
+}
+
+// TestNetSinkStopNetFlag 测试设置停止网络标志后不再发送
+func TestNetSinkStopNetFlag(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	defer atomic.StoreInt32(&stopNetFlag, 0)
+
+	core, err := newNetSinkCore(NetSinkConfig{URL: "udp://" + conn.LocalAddr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	log := zap.New(core)
+	log.Info("before")
+	atomic.StoreInt32(&stopNetFlag, 1)
+	log.Info("after")
+	atomic.StoreInt32(&stopNetFlag, 0)
+	core.batcher.Close()
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg map[string]any
+	if err := json.Unmarshal(buf[:n], &msg); err != nil || msg["msg"] != "before" {
+		t.Fatalf("日志内容错误: %s", buf[:n])
+	}
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if n, _, err := conn.ReadFrom(buf); err == nil {
+		t.Fatalf("停止网络输出后仍收到日志: %s", buf[:n])
+	}
+}
+
+// TestParseNetSinkURL 测试地址解析
+func TestParseNetSinkURL(t *testing.T) {
+	if network, address, err := parseNetSinkURL("udp://10.0.0.1:514"); err != nil || network != "udp" || address != "10.0.0.1:514" {
+		t.Fatalf("解析错误: %s %s %v", network, address, err)
+	}
+	for _, raw := range []string{"", "http://a:1", "tcp://host"} {
+		if _, _, err := parseNetSinkURL(raw); err == nil {
+			t.Fatalf("%q 应解析失败", raw)
+		}
+	}
+}
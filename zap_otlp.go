@@ -0,0 +1,323 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// OTLP 日志导出的协议
+const (
+	OTLPProtocolGRPC         = "grpc"          // OTLP/gRPC（默认端口 4317）
+	OTLPProtocolHTTPProtobuf = "http/protobuf" // OTLP/HTTP（默认端口 4318）
+)
+
+// otlpGRPCPath OTLP 日志服务的 gRPC 方法路径
+const otlpGRPCPath = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+
+// otlpSeverity 日志级别对应的 OpenTelemetry SeverityNumber
+var otlpSeverity = map[zapcore.Level]uint64{
+	zapcore.DebugLevel:  5,  // DEBUG
+	zapcore.InfoLevel:   9,  // INFO
+	zapcore.WarnLevel:   13, // WARN
+	zapcore.ErrorLevel:  17, // ERROR
+	zapcore.DPanicLevel: 18, // ERROR2
+	zapcore.PanicLevel:  21, // FATAL
+	zapcore.FatalLevel:  22, // FATAL2
+}
+
+// OTLPConfig OpenTelemetry OTLP 日志导出配置
+type OTLPConfig struct {
+	Enable   bool   `mapstructure:"enable" json:"enable" yaml:"enable"`       // 启用 OTLP 日志导出
+	Endpoint string `mapstructure:"endpoint" json:"endpoint" yaml:"endpoint"` // Collector 地址，如 http://127.0.0.1:4317（https 使用 TLS）
+	Protocol string `mapstructure:"protocol" json:"protocol" yaml:"protocol"` // grpc 或 http/protobuf（默认 grpc）
+	// 附加的请求头（如认证信息）
+	Headers map[string]string `mapstructure:"headers" json:"headers" yaml:"headers"`
+	// 附加的资源属性，service.name、service.instance.id 和 service.version 自动填充
+	ResourceAttributes map[string]string `mapstructure:"resource-attributes" json:"resource-attributes" yaml:"resource-attributes"`
+	RemoteBatchConfig  `mapstructure:",squash" yaml:",inline"`
+}
+
+// otlpExporter OTLP 日志导出客户端
+// 使用标准库的 HTTP/2 直接实现 gRPC 一元调用，日志在写入时编码为 protobuf 的 LogRecord
+type otlpExporter struct {
+	url        string
+	grpc       bool
+	headers    map[string]string
+	resource   []byte // 编码后的 Resource 消息
+	scope      []byte // 编码后的 InstrumentationScope 消息
+	httpClient *http.Client
+}
+
+// newOTLPCore 创建 OTLP 日志导出 Core
+func newOTLPCore(cfg OTLPConfig, serviceName string, serviceID uint64) (*otlpCore, error) {
+	if cfg.Endpoint == "" {
+		return nil, errors.New("未配置 OTLP Collector 地址")
+	}
+	endpoint := strings.TrimSuffix(cfg.Endpoint, "/")
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		endpoint = "http://" + endpoint
+	}
+
+	exporter := &otlpExporter{headers: cfg.Headers}
+	protocols := new(http.Protocols)
+	switch cfg.Protocol {
+	case "", OTLPProtocolGRPC:
+		exporter.grpc = true
+		exporter.url = endpoint + otlpGRPCPath
+		// gRPC 只能使用 HTTP/2，明文地址使用 h2c
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+	case OTLPProtocolHTTPProtobuf:
+		exporter.url = endpoint + "/v1/logs"
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+	default:
+		return nil, fmt.Errorf("不支持的 OTLP 协议: %s", cfg.Protocol)
+	}
+	exporter.httpClient = &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{Protocols: protocols},
+	}
+
+	attrs := map[string]any{
+		"service.name":        serviceName,
+		"service.instance.id": strconv.FormatUint(serviceID, 10),
+		"service.version":     Version,
+	}
+	for k, v := range cfg.ResourceAttributes {
+		attrs[k] = v
+	}
+	exporter.resource = appendOTLPAttributes(nil, 1, attrs)
+	exporter.scope = appendPBString(appendPBString(nil, 1, "mlog"), 2, Version)
+
+	batcher := newRemoteBatcher("otlp", cfg.RemoteBatchConfig, exporter.export)
+	addSinkCloser(batcher)
+	return &otlpCore{LevelEnabler: atomicLevel, batcher: batcher}, nil
+}
+
+// export 发送一批日志
+func (e *otlpExporter) export(ctx context.Context, batch []remoteRecord) error {
+	// ExportLogsServiceRequest{resource_logs: [ResourceLogs{resource, scope_logs: [ScopeLogs{scope, log_records}]}]}
+	var scopeLogs []byte
+	scopeLogs = appendPBBytes(scopeLogs, 1, e.scope)
+	for i := range batch {
+		scopeLogs = appendPBBytes(scopeLogs, 2, batch[i].Data)
+	}
+	var resourceLogs []byte
+	resourceLogs = appendPBBytes(resourceLogs, 1, e.resource)
+	resourceLogs = appendPBBytes(resourceLogs, 2, scopeLogs)
+	request := appendPBBytes(nil, 1, resourceLogs)
+
+	body := request
+	contentType := "application/x-protobuf"
+	if e.grpc {
+		// gRPC 消息帧：1 字节压缩标志 + 4 字节长度
+		body = make([]byte, 5, 5+len(request))
+		binary.BigEndian.PutUint32(body[1:], uint32(len(request)))
+		body = append(body, request...)
+		contentType = "application/grpc"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if e.grpc {
+		req.Header.Set("TE", "trailers")
+	}
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// 读完响应体才能拿到 gRPC 的 trailer
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("OTLP 导出失败: %s %s", resp.Status, respBody)
+	}
+	if e.grpc {
+		status := resp.Trailer.Get("Grpc-Status")
+		if status == "" {
+			// 仅有头部的响应把状态放在响应头中
+			status = resp.Header.Get("Grpc-Status")
+		}
+		if status != "0" {
+			msg := resp.Trailer.Get("Grpc-Message")
+			if msg == "" {
+				msg = resp.Header.Get("Grpc-Message")
+			}
+			return fmt.Errorf("OTLP 导出失败: grpc-status=%s %s", status, msg)
+		}
+	}
+	return nil
+}
+
+// otlpCore 将日志编码为 OTLP LogRecord 的 zapcore.Core
+type otlpCore struct {
+	zapcore.LevelEnabler
+	batcher *remoteBatcher
+	fields  []zapcore.Field // 通过 With 附加的字段
+}
+
+func (c *otlpCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &otlpCore{LevelEnabler: c.LevelEnabler, batcher: c.batcher}
+	clone.fields = append(append(clone.fields, c.fields...), fields...)
+	return clone
+}
+
+func (c *otlpCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *otlpCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for i := range c.fields {
+		c.fields[i].AddTo(enc)
+	}
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	if entry.Caller.Defined {
+		enc.Fields["code.filepath"] = entry.Caller.File
+		enc.Fields["code.lineno"] = int64(entry.Caller.Line)
+		if entry.Caller.Function != "" {
+			enc.Fields["code.function"] = entry.Caller.Function
+		}
+	}
+	if entry.Stack != "" {
+		enc.Fields["exception.stacktrace"] = entry.Stack
+	}
+
+	// LogRecord
+	var record []byte
+	record = appendPBFixed64(record, 1, uint64(entry.Time.UnixNano()))
+	record = appendPBVarint(record, 2, otlpSeverity[entry.Level])
+	record = appendPBString(record, 3, strings.ToUpper(entry.Level.String()))
+	record = appendPBBytes(record, 5, appendOTLPAnyValue(nil, entry.Message))
+	record = appendOTLPAttributes(record, 6, enc.Fields)
+	record = appendPBFixed64(record, 11, uint64(time.Now().UnixNano()))
+
+	c.batcher.add(remoteRecord{Entry: entry, Data: record})
+	return nil
+}
+
+func (c *otlpCore) Sync() error {
+	return nil
+}
+
+// appendOTLPAttributes 追加 KeyValue 列表（按键排序，保证编码结果稳定）
+func appendOTLPAttributes(buf []byte, field int, attrs map[string]any) []byte {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		kv := appendPBString(nil, 1, k)
+		kv = appendPBBytes(kv, 2, appendOTLPAnyValue(nil, attrs[k]))
+		buf = appendPBBytes(buf, field, kv)
+	}
+	return buf
+}
+
+// appendOTLPAnyValue 编码 AnyValue 消息
+func appendOTLPAnyValue(buf []byte, v any) []byte {
+	switch val := v.(type) {
+	case string:
+		return appendPBString(buf, 1, val)
+	case bool:
+		b := uint64(0)
+		if val {
+			b = 1
+		}
+		return appendPBVarint(buf, 2, b)
+	case int:
+		return appendPBVarint(buf, 3, uint64(val))
+	case int64:
+		return appendPBVarint(buf, 3, uint64(val))
+	case int32:
+		return appendPBVarint(buf, 3, uint64(val))
+	case int16:
+		return appendPBVarint(buf, 3, uint64(val))
+	case int8:
+		return appendPBVarint(buf, 3, uint64(val))
+	case uint64:
+		if val > math.MaxInt64 {
+			return appendPBString(buf, 1, strconv.FormatUint(val, 10))
+		}
+		return appendPBVarint(buf, 3, val)
+	case uint32:
+		return appendPBVarint(buf, 3, uint64(val))
+	case uint16:
+		return appendPBVarint(buf, 3, uint64(val))
+	case uint8:
+		return appendPBVarint(buf, 3, uint64(val))
+	case uintptr:
+		return appendPBVarint(buf, 3, uint64(val))
+	case float64:
+		return appendPBFixed64(buf, 4, math.Float64bits(val))
+	case float32:
+		return appendPBFixed64(buf, 4, math.Float64bits(float64(val)))
+	case []byte:
+		return appendPBBytes(buf, 7, val)
+	case []any:
+		var arr []byte
+		for _, item := range val {
+			arr = appendPBBytes(arr, 1, appendOTLPAnyValue(nil, item))
+		}
+		return appendPBBytes(buf, 5, arr)
+	case map[string]any:
+		return appendPBBytes(buf, 6, appendOTLPAttributes(nil, 1, val))
+	case time.Time:
+		return appendPBString(buf, 1, val.Format(time.RFC3339Nano))
+	case time.Duration:
+		return appendPBString(buf, 1, val.String())
+	case nil:
+		return buf
+	default:
+		return appendPBString(buf, 1, fmt.Sprint(val))
+	}
+}
+
+// protobuf 编码辅助函数，只实现 OTLP 日志需要的线格式
+
+func appendPBTag(buf []byte, field int, wireType byte) []byte {
+	return binary.AppendUvarint(buf, uint64(field)<<3|uint64(wireType))
+}
+
+func appendPBVarint(buf []byte, field int, v uint64) []byte {
+	return binary.AppendUvarint(appendPBTag(buf, field, 0), v)
+}
+
+func appendPBFixed64(buf []byte, field int, v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(appendPBTag(buf, field, 1), v)
+}
+
+func appendPBBytes(buf []byte, field int, v []byte) []byte {
+	buf = binary.AppendUvarint(appendPBTag(buf, field, 2), uint64(len(v)))
+	return append(buf, v...)
+}
+
+func appendPBString(buf []byte, field int, v string) []byte {
+	buf = binary.AppendUvarint(appendPBTag(buf, field, 2), uint64(len(v)))
+	return append(buf, v...)
+}
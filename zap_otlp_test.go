@@ -0,0 +1,144 @@
+package mlog
+
+import (
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// decodePB 解码一层 protobuf 消息，返回字段号到原始值的映射（varint 和 fixed64 以 8 字节小端表示）
+func decodePB(t *testing.T, buf []byte) map[int][][]byte {
+	t.Helper()
+	fields := make(map[int][][]byte)
+	for len(buf) > 0 {
+		tag, n := binary.Uvarint(buf)
+		buf = buf[n:]
+		field, wireType := int(tag>>3), tag&7
+		switch wireType {
+		case 0:
+			v, n := binary.Uvarint(buf)
+			buf = buf[n:]
+			fields[field] = append(fields[field], binary.LittleEndian.AppendUint64(nil, v))
+		case 1:
+			fields[field] = append(fields[field], buf[:8])
+			buf = buf[8:]
+		case 2:
+			l, n := binary.Uvarint(buf)
+			buf = buf[n:]
+			fields[field] = append(fields[field], buf[:l])
+			buf = buf[l:]
+		default:
+			t.Fatalf("未知的线格式: %d", wireType)
+		}
+	}
+	return fields
+}
+
+// decodeOTLPAttributes 解码 KeyValue 列表中的字符串和整数值
+func decodeOTLPAttributes(t *testing.T, kvs [][]byte) map[string]any {
+	attrs := make(map[string]any)
+	for _, kv := range kvs {
+		f := decodePB(t, kv)
+		value := decodePB(t, f[2][0])
+		switch {
+		case value[1] != nil:
+			attrs[string(f[1][0])] = string(value[1][0])
+		case value[3] != nil:
+			attrs[string(f[1][0])] = int64(binary.LittleEndian.Uint64(value[3][0]))
+		}
+	}
+	return attrs
+}
+
+// TestOTLPGRPCExport 测试通过 h2c 上的 gRPC 导出日志
+func TestOTLPGRPCExport(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests [][]byte
+	)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || r.URL.Path != otlpGRPCPath || r.Header.Get("Content-Type") != "application/grpc" || r.Header.Get("X-Token") != "abc" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, body[5:])
+		mu.Unlock()
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Write([]byte{0, 0, 0, 0, 0})
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+
+	core, err := newOTLPCore(OTLPConfig{
+		Endpoint:           server.URL,
+		Headers:            map[string]string{"X-Token": "abc"},
+		ResourceAttributes: map[string]string{"deployment.environment": "test"},
+		RemoteBatchConfig:  RemoteBatchConfig{MaxRetries: -1},
+	}, "game", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	zap.New(core).With(zap.Int("room", 3)).Warn("房间已满")
+	core.batcher.Close()
+
+	if stats := core.batcher.stats(); stats.Sent != 1 {
+		t.Fatalf("导出失败: %+v", stats)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	resourceLogs := decodePB(t, decodePB(t, requests[0])[1][0])
+	resource := decodeOTLPAttributes(t, decodePB(t, resourceLogs[1][0])[1])
+	if resource["service.name"] != "game" || resource["service.instance.id"] != "7" ||
+		resource["service.version"] != Version || resource["deployment.environment"] != "test" {
+		t.Fatalf("资源属性错误: %v", resource)
+	}
+	scopeLogs := decodePB(t, resourceLogs[2][0])
+	record := decodePB(t, scopeLogs[2][0])
+	if binary.LittleEndian.Uint64(record[2][0]) != 13 || string(record[3][0]) != "WARN" {
+		t.Fatalf("严重程度错误: %v", record)
+	}
+	if body := decodePB(t, record[5][0]); string(body[1][0]) != "房间已满" {
+		t.Fatalf("日志内容错误: %q", body[1][0])
+	}
+	if attrs := decodeOTLPAttributes(t, record[6]); attrs["room"] != int64(3) {
+		t.Fatalf("日志属性错误: %v", attrs)
+	}
+}
+
+// TestOTLPGRPCError 测试 gRPC 状态码非 0 时视为失败
+func TestOTLPGRPCError(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Grpc-Status", "14")
+		w.Header().Set("Grpc-Message", "unavailable")
+	}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+
+	core, err := newOTLPCore(OTLPConfig{Endpoint: server.URL, RemoteBatchConfig: RemoteBatchConfig{MaxRetries: -1}}, "game", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	zap.New(core).Info("x")
+	core.batcher.Close()
+	if stats := core.batcher.stats(); stats.Failed != 1 {
+		t.Fatalf("gRPC 错误状态应计为失败: %+v", stats)
+	}
+}
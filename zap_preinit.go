@@ -0,0 +1,279 @@
+package mlog
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// PreInitPolicy 日志系统未初始化（InitialZap 之前或 Close 之后）时记录日志的处理策略
+type PreInitPolicy int32
+
+const (
+	// PreInitBuffer 默认策略：首次 InitialZap 之前缓存到内存（最多 preInitBufferLimit 条），
+	// InitialZap 完成后按原始时间和调用位置回放；Close 之后不再缓存，直接输出到 stderr
+	PreInitBuffer PreInitPolicy = iota
+	// PreInitStderr 直接输出到 stderr
+	PreInitStderr
+	// PreInitDrop 直接丢弃
+	PreInitDrop
+	// PreInitPanic 旧版行为：调用 ExitGame 直接 panic
+	PreInitPanic
+	// PreInitAutoInit 首次记录日志时自动初始化一个输出到 stderr 的最小 logger，
+	// 期间的日志同时缓存，InitialZap 完成后原地升级并回放到正式的日志文件
+	PreInitAutoInit
+)
+
+// preInitBufferLimit 初始化前日志缓冲区的最大条目数，超出部分丢弃并计数
+const preInitBufferLimit = 10000
+
+var (
+	// 全局初始化前日志策略（原子访问，允许在包 init 阶段并发设置）
+	globalPreInitPolicy int32 = int32(PreInitBuffer)
+
+	preInitMutex   sync.Mutex
+	preInitEntries []preInitEntry
+	preInitDropped int
+	// initializedOnce 是否已经调用过 InitialZap，之后未初始化（Close 之后）的日志不再缓存
+	initializedOnce atomic.Bool
+
+	// stderr 输出使用的 core，按需创建
+	preInitStderrOnce sync.Once
+	preInitStderrCore zapcore.Core
+
+	// 自动初始化的 logger 是否仍在使用（受 preInitMutex 保护）
+	autoInitActive bool
+	// 自动初始化使用的默认配置，可通过环境变量覆盖
+	autoInitLevel  = "info"
+	autoInitFormat = "console"
+)
+
+// preInitEntry 初始化前缓存的日志条目
+type preInitEntry struct {
+	entry  zapcore.Entry
+	fields []zap.Field
+}
+
+func init() {
+	// 初始化前启用所有级别的快速检查，让日志调用能够到达初始化前处理逻辑，
+	// 真正的级别过滤在回放时由 InitialZap 配置的级别完成
+	updateLevelCacheOptimized(zapcore.DebugLevel)
+
+	// 环境变量配置自动初始化：
+	//   MLOG_AUTO_INIT=1          启用自动初始化
+	//   MLOG_AUTO_INIT_LEVEL=info 自动初始化 logger 的级别
+	//   MLOG_AUTO_INIT_FORMAT=json 自动初始化 logger 的输出格式（console/json）
+	if v, err := strconv.ParseBool(os.Getenv("MLOG_AUTO_INIT")); err == nil && v {
+		SetPreInitPolicy(PreInitAutoInit)
+	}
+	if v := os.Getenv("MLOG_AUTO_INIT_LEVEL"); v != "" {
+		autoInitLevel = v
+	}
+	if v := os.Getenv("MLOG_AUTO_INIT_FORMAT"); v != "" {
+		autoInitFormat = v
+	}
+}
+
+// SetPreInitPolicy 设置日志系统未初始化时记录日志的处理策略
+func SetPreInitPolicy(policy PreInitPolicy) {
+	atomic.StoreInt32(&globalPreInitPolicy, int32(policy))
+}
+
+// GetPreInitPolicy 获取当前的初始化前日志处理策略
+func GetPreInitPolicy() PreInitPolicy {
+	return PreInitPolicy(atomic.LoadInt32(&globalPreInitPolicy))
+}
+
+// logBeforeInit 处理日志系统未初始化时的日志
+// callerSkip 为用户代码与本函数之间 mlog 内部函数的层数（与 zap.AddCallerSkip 含义一致）
+func logBeforeInit(level zapcore.Level, callerSkip int, msg string, fields ...zap.Field) {
+	logBeforeInitAt(level, captureCaller(callerSkip+1), msg, fields...)
+}
+
+// logBeforeInitAt 处理日志系统未初始化时的日志，caller 为入口处捕获的调用方位置
+func logBeforeInitAt(level zapcore.Level, caller zapcore.EntryCaller, msg string, fields ...zap.Field) {
+	policy := GetPreInitPolicy()
+	switch policy {
+	case PreInitDrop:
+		return
+	case PreInitPanic:
+		ExitGame("zapLogger 还没有初始化，请先调用 InitialZap")
+		return
+	}
+
+	entry := zapcore.Entry{
+		Level:   level,
+		Time:    time.Now(),
+		Message: msg,
+		Caller:  caller,
+	}
+
+	// Close 之后的日志没有对应的 InitialZap 回放，缓存策略下改为输出到 stderr
+	if policy == PreInitStderr || (policy == PreInitBuffer && initializedOnce.Load()) {
+		writePreInitStderr(entry, fields)
+		return
+	}
+
+	if policy == PreInitAutoInit {
+		if ce := autoInitialize().Core().Check(entry, nil); ce != nil {
+			ce.Write(fields...)
+		}
+		return
+	}
+
+	preInitMutex.Lock()
+	// 二次检查：等待锁期间 InitialZap 可能已经完成，此时直接写入，避免条目滞留在缓冲区
+	if logger, ok := getLogger(); ok {
+		preInitMutex.Unlock()
+		if ce := logger.Core().Check(entry, nil); ce != nil {
+			ce.Write(fields...)
+		}
+		return
+	}
+	if len(preInitEntries) >= preInitBufferLimit {
+		preInitDropped++
+		preInitMutex.Unlock()
+		return
+	}
+	preInitEntries = append(preInitEntries, preInitEntry{entry: entry, fields: fields})
+	preInitMutex.Unlock()
+}
+
+// writePreInitStderr 使用控制台编码器将日志写入 stderr
+func writePreInitStderr(entry zapcore.Entry, fields []zap.Field) {
+	preInitStderrOnce.Do(func() {
+		encoderConfig := zap.NewDevelopmentEncoderConfig()
+		encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("2006-01-02 15:04:05.000")
+		preInitStderrCore = zapcore.NewCore(
+			zapcore.NewConsoleEncoder(encoderConfig),
+			newConsoleWriteSyncer(os.Stderr),
+			zapcore.DebugLevel,
+		)
+	})
+	_ = preInitStderrCore.Write(entry, fields)
+}
+
+// replayPreInitEntries 将初始化前缓存的日志写入新创建的 logger
+// 条目保留原始时间戳和调用位置，并按新配置的级别过滤
+func replayPreInitEntries(logger *zap.Logger) {
+	preInitMutex.Lock()
+	entries := preInitEntries
+	dropped := preInitDropped
+	preInitEntries = nil
+	preInitDropped = 0
+	// 自动初始化的 logger 从此不再缓存，迟到的条目直接转发给新 logger
+	autoInitActive = false
+	preInitMutex.Unlock()
+
+	core := logger.Core()
+	for i := range entries {
+		if ce := core.Check(entries[i].entry, nil); ce != nil {
+			ce.Write(entries[i].fields...)
+		}
+	}
+
+	if dropped > 0 {
+		logger.Warn("初始化前日志缓冲区已满，部分日志被丢弃",
+			zap.Int("dropped", dropped),
+			zap.Int("limit", preInitBufferLimit))
+	}
+}
+
+// autoInitialize 自动初始化最小 logger（stderr + 回放缓存），已初始化时返回当前 logger
+func autoInitialize() *zap.Logger {
+	globalMutex.Lock()
+	defer globalMutex.Unlock()
+
+	// 二次检查：其他 goroutine 可能已经完成初始化
+	if logger, ok := getLogger(); ok {
+		return logger
+	}
+
+	level, err := zapcore.ParseLevel(autoInitLevel)
+	if err != nil {
+		level = zapcore.InfoLevel
+	}
+	atomicLevel = zap.NewAtomicLevelAt(level)
+	applyLevel(level)
+	zapConfig.Level = level.String()
+
+	encoderConfig := zap.NewDevelopmentEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("2006-01-02 15:04:05.000")
+	var encoder zapcore.Encoder
+	if autoInitFormat == "json" {
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	} else {
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	}
+
+	preInitMutex.Lock()
+	autoInitActive = true
+	preInitMutex.Unlock()
+
+	core := zapcore.NewTee(
+		zapcore.NewCore(encoder, newConsoleWriteSyncer(os.Stderr), atomicLevel),
+		&preInitBufferCore{LevelEnabler: atomicLevel},
+	)
+	logger := zap.New(core, zap.AddCaller())
+
+	atomic.StorePointer(&loggerPtr, unsafe.Pointer(logger))
+	zapLogger = logger
+	atomic.StoreInt32(&initialized, 1)
+	return logger
+}
+
+// preInitBufferCore 自动初始化期间使用的 core，将日志缓存起来等待 InitialZap 后回放
+type preInitBufferCore struct {
+	zapcore.LevelEnabler
+	fields []zap.Field
+}
+
+func (c *preInitBufferCore) With(fields []zapcore.Field) zapcore.Core {
+	merged := make([]zap.Field, 0, len(c.fields)+len(fields))
+	merged = append(merged, c.fields...)
+	merged = append(merged, fields...)
+	return &preInitBufferCore{LevelEnabler: c.LevelEnabler, fields: merged}
+}
+
+func (c *preInitBufferCore) Check(entry zapcore.Entry, check *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return check.AddCore(entry, c)
+	}
+	return check
+}
+
+func (c *preInitBufferCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	// 复制字段切片，避免调用方复用切片导致回放内容错乱
+	all := make([]zap.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+
+	preInitMutex.Lock()
+	if !autoInitActive {
+		// 已经升级为正式 logger，转发而不是缓存，保证不丢失
+		preInitMutex.Unlock()
+		if logger, ok := getLogger(); ok {
+			if ce := logger.Core().Check(entry, nil); ce != nil {
+				ce.Write(all...)
+			}
+		}
+		return nil
+	}
+	if len(preInitEntries) >= preInitBufferLimit {
+		preInitDropped++
+	} else {
+		preInitEntries = append(preInitEntries, preInitEntry{entry: entry, fields: all})
+	}
+	preInitMutex.Unlock()
+	return nil
+}
+
+func (c *preInitBufferCore) Sync() error {
+	return nil
+}
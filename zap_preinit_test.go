@@ -0,0 +1,114 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPreInitBufferReplay 测试初始化前的日志在 InitialZap 之后被回放到日志文件
+func TestPreInitBufferReplay(t *testing.T) {
+	Close()
+	SetPreInitPolicy(PreInitBuffer)
+	// 模拟首次 InitialZap 之前
+	initializedOnce.Store(false)
+
+	// 初始化前记录日志，旧版本中 Critical 会直接 panic
+	Info("初始化前的日志 %d", 1)
+	Critical("初始化前的严重日志")
+	DebugW("初始化前的调试日志")
+
+	dir := t.TempDir()
+	config := ZapConfig{
+		Level:      "info",
+		Format:     "console",
+		Director:   dir,
+		SingleFile: true,
+	}
+	InitialZap("preinit", 0, "info", &config)
+	Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "preinit", "all.log"))
+	if err != nil {
+		t.Fatalf("读取日志文件失败: %v", err)
+	}
+	content := string(data)
+	if !strings.Contains(content, "初始化前的日志 1") {
+		t.Errorf("初始化前的 Info 日志没有被回放: %s", content)
+	}
+	if !strings.Contains(content, "初始化前的严重日志") {
+		t.Errorf("初始化前的 Critical 日志没有被回放: %s", content)
+	}
+	if strings.Contains(content, "初始化前的调试日志") {
+		t.Errorf("低于配置级别的日志不应该被回放: %s", content)
+	}
+}
+
+// TestPreInitAfterClose 测试 Close 之后的日志不再缓存，默认策略下输出到 stderr
+func TestPreInitAfterClose(t *testing.T) {
+	Close()
+	SetPreInitPolicy(PreInitBuffer)
+	config := ZapConfig{Level: "info", Director: t.TempDir(), SingleFile: true}
+	InitialZap("preinit", 0, "info", &config)
+	Close()
+
+	Info("关闭之后的日志")
+
+	preInitMutex.Lock()
+	n := len(preInitEntries)
+	preInitMutex.Unlock()
+	if n != 0 {
+		t.Errorf("Close 之后不应该缓存日志，实际缓存 %d 条", n)
+	}
+}
+
+// TestPreInitDrop 测试丢弃策略不会 panic 也不会缓存
+func TestPreInitDrop(t *testing.T) {
+	Close()
+	SetPreInitPolicy(PreInitDrop)
+	defer SetPreInitPolicy(PreInitBuffer)
+
+	Disaster("丢弃的日志")
+
+	preInitMutex.Lock()
+	n := len(preInitEntries)
+	preInitMutex.Unlock()
+	if n != 0 {
+		t.Errorf("丢弃策略下不应该缓存日志，实际缓存 %d 条", n)
+	}
+}
+
+// TestPreInitAutoInit 测试自动初始化的 logger 在 InitialZap 后原地升级且日志不丢失
+func TestPreInitAutoInit(t *testing.T) {
+	Close()
+	SetPreInitPolicy(PreInitAutoInit)
+	defer SetPreInitPolicy(PreInitBuffer)
+
+	Warn("自动初始化前的日志")
+	if !isInitialized() {
+		t.Fatal("首次记录日志后应该自动初始化")
+	}
+	Warn("自动初始化后的日志")
+
+	dir := t.TempDir()
+	config := ZapConfig{
+		Level:      "info",
+		Format:     "console",
+		Director:   dir,
+		SingleFile: true,
+	}
+	InitialZap("autoinit", 0, "info", &config)
+	Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "autoinit", "all.log"))
+	if err != nil {
+		t.Fatalf("读取日志文件失败: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{"自动初始化前的日志", "自动初始化后的日志"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("日志 %q 没有在升级后写入文件: %s", want, content)
+		}
+	}
+}
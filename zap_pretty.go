@@ -0,0 +1,121 @@
+package mlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FormatJSONPretty 本地开发用的 JSON 格式：日志文件仍为紧凑的 JSON 行，控制台输出缩进、键名着色并截断堆栈
+const FormatJSONPretty = "json-pretty"
+
+// prettyStackLines 控制台美化输出时堆栈保留的行数
+const prettyStackLines = 12
+
+// prettyKeyColor 控制台美化输出时键名的颜色（青色）
+const prettyKeyColor = "\x1b[36m"
+
+// SetPrettyConsole 运行时开启或关闭控制台的 JSON 美化输出，只影响控制台，日志文件不变
+// Format 为 json-pretty 时初始化后默认开启；不是 JSON 的行（如 console 格式）原样输出
+func SetPrettyConsole(enabled bool) {
+	consoleSink.pretty.Store(enabled)
+}
+
+// PrettyConsole 返回控制台是否开启了 JSON 美化输出
+func PrettyConsole() bool {
+	return consoleSink.pretty.Load()
+}
+
+// prettyJSON 把一行或多行 JSON 日志格式化为缩进的多行文本，color 为 true 时键名着色
+// 键的顺序与原日志相同，堆栈字段只保留前 prettyStackLines 行；无法解析的行原样返回
+func prettyJSON(p []byte, color bool) []byte {
+	var out bytes.Buffer
+	for _, line := range bytes.SplitAfter(p, []byte("\n")) {
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) == 0 {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.UseNumber()
+		var pretty bytes.Buffer
+		if trimmed[0] != '{' || writePrettyValue(&pretty, dec, "", "", color) != nil || dec.More() {
+			out.Write(line)
+			continue
+		}
+		out.Write(pretty.Bytes())
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// writePrettyValue 从 dec 读取一个值并缩进输出，key 为该值所在的键名（用于识别堆栈字段）
+func writePrettyValue(out *bytes.Buffer, dec *json.Decoder, indent, key string, color bool) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		if s, isString := tok.(string); isString && key == stacktraceKey() {
+			tok = truncateLines(s, prettyStackLines)
+		}
+		data, err := json.Marshal(tok)
+		if err != nil {
+			return err
+		}
+		out.Write(data)
+		return nil
+	}
+	closing := byte('}')
+	if delim == '[' {
+		closing = ']'
+	}
+	out.WriteByte(byte(delim))
+	inner := indent + "  "
+	first := true
+	for dec.More() {
+		if !first {
+			out.WriteByte(',')
+		}
+		first = false
+		out.WriteString("\n" + inner)
+		childKey := ""
+		if delim == '{' {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			childKey, _ = tok.(string)
+			name, _ := json.Marshal(childKey)
+			if color {
+				out.WriteString(prettyKeyColor)
+				out.Write(name)
+				out.WriteString("\x1b[0m")
+			} else {
+				out.Write(name)
+			}
+			out.WriteString(": ")
+		}
+		if err := writePrettyValue(out, dec, inner, childKey, color); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	if !first {
+		out.WriteString("\n" + indent)
+	}
+	out.WriteByte(closing)
+	return nil
+}
+
+// truncateLines 只保留 s 的前 n 行，其余行替换为省略说明
+func truncateLines(s string, n int) string {
+	lines := strings.SplitN(s, "\n", n+1)
+	if len(lines) <= n {
+		return s
+	}
+	return strings.Join(lines[:n], "\n") + fmt.Sprintf("\n…(%d more lines)", strings.Count(lines[n], "\n")+1)
+}
@@ -0,0 +1,53 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPrettyJSON 测试缩进输出保持键的顺序、截断堆栈，非 JSON 行原样输出
+func TestPrettyJSON(t *testing.T) {
+	stack := strings.Repeat("main.main()\n", 20)
+	line := `{"level":"error","message":"存档失败","player":{"id":10086,"tags":["vip"]},"empty":{},"stacktrace":` + strings.ReplaceAll(`"`+stack+`"`, "\n", `\n`) + "}\n"
+	got := string(prettyJSON([]byte(line+"plain text\n"), false))
+	want := "{\n  \"level\": \"error\",\n  \"message\": \"存档失败\",\n  \"player\": {\n    \"id\": 10086,\n    \"tags\": [\n      \"vip\"\n    ]\n  },\n  \"empty\": {},\n  \"stacktrace\": "
+	if !strings.HasPrefix(got, want) {
+		t.Fatalf("缩进结果错误:\n%s", got)
+	}
+	if !strings.Contains(got, `…(9 more lines)"`) || !strings.HasSuffix(got, "}\nplain text\n") {
+		t.Fatalf("堆栈截断或非 JSON 行处理错误:\n%s", got)
+	}
+	if colored := string(prettyJSON([]byte(`{"level":"info"}`), true)); colored != "{\n  \x1b[36m\"level\"\x1b[0m: \"info\"\n}\n" {
+		t.Fatalf("键名着色错误: %q", colored)
+	}
+}
+
+// TestJSONPrettyFormat 测试 json-pretty 格式的日志文件为紧凑的 JSON 行，控制台美化输出可在运行时切换
+func TestJSONPrettyFormat(t *testing.T) {
+	oldConfig := zapConfig
+	defer func() { zapConfig = oldConfig }()
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: FormatJSONPretty}
+	InitialZap("gate", 2, "info", &config)
+	defer SetPrettyConsole(false)
+	if !PrettyConsole() {
+		t.Fatal("json-pretty 格式应开启控制台美化输出")
+	}
+	Info("玩家登录")
+	Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "2", "gate", "info.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1 || !strings.HasPrefix(string(data), "{") {
+		t.Fatalf("日志文件应为紧凑的 JSON 行: %s", data)
+	}
+	SetPrettyConsole(false)
+	if PrettyConsole() {
+		t.Fatal("SetPrettyConsole(false) 未生效")
+	}
+}
@@ -0,0 +1,190 @@
+package mlog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogEntry 和 FieldValue 的字段号，见 proto/logentry.proto
+const (
+	pbEntryTime       = 1
+	pbEntryLevel      = 2
+	pbEntryService    = 3
+	pbEntryServiceID  = 4
+	pbEntryCaller     = 5
+	pbEntryMessage    = 6
+	pbEntryFields     = 7
+	pbEntryStacktrace = 8
+	pbEntryLogger     = 9
+
+	pbValueString = 1
+	pbValueInt    = 2
+	pbValueUint   = 3
+	pbValueDouble = 4
+	pbValueBool   = 5
+	pbValueBytes  = 6
+	pbValueJSON   = 7
+)
+
+// protobufPool 编码结果的缓冲
+var protobufPool = buffer.NewPool()
+
+// protobufEncoder 把日志编码为 proto/logentry.proto 中的 LogEntry
+// 字段写入 fields，对象、数组和反射值编码为 JSON 文本；OpenNamespace 之后的字段键名为 namespace.key
+type protobufEncoder struct {
+	header []byte // 服务名和服务 ID，每条日志相同
+	fields []byte // 已编码的 fields 条目
+	prefix string // 当前 namespace 的键名前缀
+}
+
+// newProtobufEncoder 创建 Protobuf 编码器
+func newProtobufEncoder(serviceName string, serviceID uint64) zapcore.Encoder {
+	header := appendPBString(nil, pbEntryService, serviceName)
+	header = appendPBVarint(header, pbEntryServiceID, serviceID)
+	return &protobufEncoder{header: header}
+}
+
+func (e *protobufEncoder) Clone() zapcore.Encoder {
+	return &protobufEncoder{header: e.header, fields: append([]byte(nil), e.fields...), prefix: e.prefix}
+}
+
+func (e *protobufEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := e
+	if len(fields) > 0 {
+		final = e.Clone().(*protobufEncoder)
+		for i := range fields {
+			fields[i].AddTo(final)
+		}
+	}
+
+	out := appendPBFixed64(nil, pbEntryTime, uint64(entry.Time.UnixNano()))
+	out = appendPBString(out, pbEntryLevel, entry.Level.String())
+	out = append(out, e.header...)
+	if entry.Caller.Defined {
+		out = appendPBString(out, pbEntryCaller, entry.Caller.TrimmedPath())
+	}
+	out = appendPBString(out, pbEntryMessage, entry.Message)
+	out = append(out, final.fields...)
+	if entry.Stack != "" {
+		out = appendPBString(out, pbEntryStacktrace, entry.Stack)
+	}
+	if entry.LoggerName != "" {
+		out = appendPBString(out, pbEntryLogger, entry.LoggerName)
+	}
+	buf := protobufPool.Get()
+	buf.Write(out)
+	return buf, nil
+}
+
+// add 写入一个 fields 条目，value 为编码后的 FieldValue
+func (e *protobufEncoder) add(key string, value []byte) {
+	item := appendPBString(nil, 1, e.prefix+key)
+	item = appendPBBytes(item, 2, value)
+	e.fields = appendPBBytes(e.fields, pbEntryFields, item)
+}
+
+// addJSON 以 JSON 文本写入值，无法编码时返回错误且不写入
+func (e *protobufEncoder) addJSON(key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	e.add(key, appendPBBytes(nil, pbValueJSON, data))
+	return nil
+}
+
+func (e *protobufEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	enc := zapcore.NewMapObjectEncoder()
+	if err := enc.AddArray(key, marshaler); err != nil {
+		return err
+	}
+	return e.addJSON(key, enc.Fields[key])
+}
+
+func (e *protobufEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	enc := zapcore.NewMapObjectEncoder()
+	if err := marshaler.MarshalLogObject(enc); err != nil {
+		return err
+	}
+	return e.addJSON(key, enc.Fields)
+}
+
+func (e *protobufEncoder) AddReflected(key string, value interface{}) error {
+	return e.addJSON(key, value)
+}
+
+func (e *protobufEncoder) AddBinary(key string, value []byte) {
+	e.add(key, appendPBBytes(nil, pbValueBytes, value))
+}
+
+func (e *protobufEncoder) AddByteString(key string, value []byte) {
+	e.add(key, appendPBBytes(nil, pbValueString, value))
+}
+
+func (e *protobufEncoder) AddBool(key string, value bool) {
+	var v uint64
+	if value {
+		v = 1
+	}
+	e.add(key, appendPBVarint(nil, pbValueBool, v))
+}
+
+func (e *protobufEncoder) AddComplex128(key string, value complex128) {
+	s := strconv.FormatComplex(value, 'g', -1, 128)
+	e.AddString(key, s[1:len(s)-1])
+}
+
+func (e *protobufEncoder) AddComplex64(key string, value complex64) {
+	s := strconv.FormatComplex(complex128(value), 'g', -1, 64)
+	e.AddString(key, s[1:len(s)-1])
+}
+
+func (e *protobufEncoder) AddDuration(key string, value time.Duration) {
+	e.AddInt64(key, int64(value))
+}
+
+func (e *protobufEncoder) AddFloat64(key string, value float64) {
+	e.add(key, appendPBFixed64(nil, pbValueDouble, math.Float64bits(value)))
+}
+
+func (e *protobufEncoder) AddFloat32(key string, value float32) {
+	e.AddFloat64(key, float64(value))
+}
+
+func (e *protobufEncoder) AddInt(key string, value int)     { e.AddInt64(key, int64(value)) }
+func (e *protobufEncoder) AddInt32(key string, value int32) { e.AddInt64(key, int64(value)) }
+func (e *protobufEncoder) AddInt16(key string, value int16) { e.AddInt64(key, int64(value)) }
+func (e *protobufEncoder) AddInt8(key string, value int8)   { e.AddInt64(key, int64(value)) }
+
+// AddInt64 整数使用 sint64（zigzag）编码，负数也只占少量字节
+func (e *protobufEncoder) AddInt64(key string, value int64) {
+	e.add(key, binary.AppendUvarint(appendPBTag(nil, pbValueInt, 0), uint64(value<<1)^uint64(value>>63)))
+}
+
+func (e *protobufEncoder) AddString(key, value string) {
+	e.add(key, appendPBString(nil, pbValueString, value))
+}
+
+func (e *protobufEncoder) AddTime(key string, value time.Time) {
+	e.AddInt64(key, value.UnixNano())
+}
+
+func (e *protobufEncoder) AddUint(key string, value uint)       { e.AddUint64(key, uint64(value)) }
+func (e *protobufEncoder) AddUint32(key string, value uint32)   { e.AddUint64(key, uint64(value)) }
+func (e *protobufEncoder) AddUint16(key string, value uint16)   { e.AddUint64(key, uint64(value)) }
+func (e *protobufEncoder) AddUint8(key string, value uint8)     { e.AddUint64(key, uint64(value)) }
+func (e *protobufEncoder) AddUintptr(key string, value uintptr) { e.AddUint64(key, uint64(value)) }
+
+func (e *protobufEncoder) AddUint64(key string, value uint64) {
+	e.add(key, appendPBVarint(nil, pbValueUint, value))
+}
+
+func (e *protobufEncoder) OpenNamespace(key string) {
+	e.prefix += key + "."
+}
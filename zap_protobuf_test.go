@@ -0,0 +1,146 @@
+package mlog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestProtobufEncoder 测试 LogEntry 的各字段和字段值类型
+func TestProtobufEncoder(t *testing.T) {
+	enc := newProtobufEncoder("gate", 7)
+	enc.AddString("scene", "城镇")
+	now := time.Unix(1700000000, 123)
+	buf, err := enc.EncodeEntry(zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		Time:       now,
+		LoggerName: "login",
+		Message:    "登录缓慢",
+		Caller:     zapcore.NewEntryCaller(0, "/src/gate/login.go", 42, true),
+	}, []zapcore.Field{
+		zap.Int64("delta", -3),
+		zap.Uint64("player_id", 1001),
+		zap.Float64("ratio", 0.5),
+		zap.Bool("vip", true),
+		zap.Duration("cost", 2*time.Second),
+		zap.Binary("raw", []byte{1, 2}),
+		zap.Any("tags", map[string]int{"a": 1}),
+		zap.Namespace("req"),
+		zap.String("path", "/login"),
+		zap.Error(errors.New("超时")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := decodePB(t, buf.Bytes())
+	buf.Free()
+
+	if got := int64(binary.LittleEndian.Uint64(entry[pbEntryTime][0])); got != now.UnixNano() {
+		t.Fatalf("时间错误: %d", got)
+	}
+	for field, want := range map[int]string{
+		pbEntryLevel:   "warn",
+		pbEntryService: "gate",
+		pbEntryCaller:  "gate/login.go:42",
+		pbEntryMessage: "登录缓慢",
+		pbEntryLogger:  "login",
+	} {
+		if got := string(entry[field][0]); got != want {
+			t.Fatalf("字段 %d 错误: %q", field, got)
+		}
+	}
+	if got := binary.LittleEndian.Uint64(entry[pbEntryServiceID][0]); got != 7 {
+		t.Fatalf("服务 ID 错误: %d", got)
+	}
+	if entry[pbEntryStacktrace] != nil {
+		t.Fatal("不应输出堆栈")
+	}
+
+	values := make(map[string]map[int][][]byte)
+	for _, item := range entry[pbEntryFields] {
+		f := decodePB(t, item)
+		values[string(f[1][0])] = decodePB(t, f[2][0])
+	}
+	if len(values) != 10 {
+		t.Fatalf("字段数量错误: %d", len(values))
+	}
+	zigzag := func(b []byte) int64 {
+		v := binary.LittleEndian.Uint64(b)
+		return int64(v>>1) ^ -int64(v&1)
+	}
+	if got := string(values["scene"][pbValueString][0]); got != "城镇" {
+		t.Fatalf("scene 错误: %q", got)
+	}
+	if got := zigzag(values["delta"][pbValueInt][0]); got != -3 {
+		t.Fatalf("delta 错误: %d", got)
+	}
+	if got := binary.LittleEndian.Uint64(values["player_id"][pbValueUint][0]); got != 1001 {
+		t.Fatalf("player_id 错误: %d", got)
+	}
+	if got := math.Float64frombits(binary.LittleEndian.Uint64(values["ratio"][pbValueDouble][0])); got != 0.5 {
+		t.Fatalf("ratio 错误: %v", got)
+	}
+	if got := binary.LittleEndian.Uint64(values["vip"][pbValueBool][0]); got != 1 {
+		t.Fatalf("vip 错误: %d", got)
+	}
+	if got := zigzag(values["cost"][pbValueInt][0]); got != int64(2*time.Second) {
+		t.Fatalf("cost 错误: %d", got)
+	}
+	if got := values["raw"][pbValueBytes][0]; len(got) != 2 || got[1] != 2 {
+		t.Fatalf("raw 错误: %v", got)
+	}
+	var tags map[string]int
+	if err := json.Unmarshal(values["tags"][pbValueJSON][0], &tags); err != nil || tags["a"] != 1 {
+		t.Fatalf("tags 错误: %s", values["tags"][pbValueJSON][0])
+	}
+	if got := string(values["req.path"][pbValueString][0]); got != "/login" {
+		t.Fatalf("namespace 字段错误: %q", got)
+	}
+	if got := string(values["req.error"][pbValueString][0]); got != "超时" {
+		t.Fatalf("error 字段错误: %q", got)
+	}
+}
+
+// TestKafkaCoreProtobuf 测试 Kafka 输出使用 Protobuf 编码，With 的字段对之后的日志都生效
+func TestKafkaCoreProtobuf(t *testing.T) {
+	producer := &fakeKafkaProducer{}
+	SetKafkaProducer(producer)
+	defer SetKafkaProducer(nil)
+
+	core, err := newKafkaCore(KafkaConfig{
+		Topic:             "game-logs",
+		Encoding:          SinkEncodingProtobuf,
+		RemoteBatchConfig: RemoteBatchConfig{BatchSize: 10},
+	}, "game", 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	logger := zap.New(core).With(zap.String("region", "cn"))
+	logger.Info("登录")
+	logger.Info("下线")
+	if err := core.batcher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(producer.msgs) != 2 {
+		t.Fatalf("期望 2 条消息，实际 %d", len(producer.msgs))
+	}
+	for i, want := range []string{"登录", "下线"} {
+		msg := producer.msgs[i]
+		entry := decodePB(t, msg.Value)
+		if string(msg.Key) != "42" || string(entry[pbEntryMessage][0]) != want || string(entry[pbEntryService][0]) != "game" || len(entry[pbEntryFields]) != 1 {
+			t.Fatalf("第 %d 条消息错误: key=%s value=%x", i, msg.Key, msg.Value)
+		}
+	}
+
+	if _, err := newKafkaCore(KafkaConfig{Topic: "game-logs", Encoding: "xml"}, "game", 42); err == nil {
+		t.Fatal("不支持的编码应返回错误")
+	}
+}
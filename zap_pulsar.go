@@ -0,0 +1,107 @@
+package mlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Pulsar 主题划分方式
+const (
+	PulsarTopicPerService = "service" // 每个服务一个主题：<前缀>-<服务名>
+	PulsarTopicPerLevel   = "level"   // 每个级别一个主题：<前缀>-<级别>
+)
+
+// defaultPulsarTopicPrefix Pulsar 主题前缀的默认值
+const defaultPulsarTopicPrefix = "persistent://public/default/mlog"
+
+// PulsarConfig Pulsar 输出配置
+type PulsarConfig struct {
+	Enable bool `mapstructure:"enable" json:"enable" yaml:"enable"` // 启用 Pulsar 输出（需先调用 SetPulsarProducer）
+	// 主题前缀（默认 persistent://public/default/mlog）
+	TopicPrefix string `mapstructure:"topic-prefix" json:"topic-prefix" yaml:"topic-prefix"`
+	// 主题划分方式：service（默认，<前缀>-<服务名>）或 level（<前缀>-<级别>）
+	TopicPer          string `mapstructure:"topic-per" json:"topic-per" yaml:"topic-per"`
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// PulsarMessage 发送到 Pulsar 的一条消息
+type PulsarMessage struct {
+	Topic      string
+	Key        string            // 消息键，固定为服务ID，Key_Shared 订阅或分区主题下同一服务的日志有序
+	Payload    []byte            // JSON 编码的日志
+	EventTime  time.Time         // 日志产生时间
+	Properties map[string]string // level、service 等消息属性
+}
+
+// PulsarProducer Pulsar 生产者
+// mlog 不直接依赖 Pulsar 客户端库，由应用使用自己的客户端（如 apache/pulsar-client-go）实现，
+// 按 Topic 选择（或按需创建）Producer 后发送，Key 对应 ProducerMessage.Key。Send 返回错误时整批重试。
+type PulsarProducer interface {
+	Send(ctx context.Context, msgs []PulsarMessage) error
+}
+
+var (
+	pulsarProducer      PulsarProducer
+	pulsarProducerMutex sync.RWMutex
+)
+
+// SetPulsarProducer 设置 Pulsar 生产者，需要在 InitialZap 之前调用，传入 nil 取消
+func SetPulsarProducer(producer PulsarProducer) {
+	pulsarProducerMutex.Lock()
+	pulsarProducer = producer
+	pulsarProducerMutex.Unlock()
+}
+
+// getPulsarProducer 获取当前的 Pulsar 生产者
+func getPulsarProducer() PulsarProducer {
+	pulsarProducerMutex.RLock()
+	defer pulsarProducerMutex.RUnlock()
+	return pulsarProducer
+}
+
+// newPulsarCore 创建 Pulsar 输出 Core
+func newPulsarCore(cfg PulsarConfig, serviceName string, serviceID uint64) (*remoteCore, error) {
+	producer := getPulsarProducer()
+	if producer == nil {
+		return nil, errors.New("未设置 Pulsar 生产者，请先调用 SetPulsarProducer")
+	}
+	prefix := cfg.TopicPrefix
+	if prefix == "" {
+		prefix = defaultPulsarTopicPrefix
+	}
+	perLevel := false
+	switch cfg.TopicPer {
+	case "", PulsarTopicPerService:
+	case PulsarTopicPerLevel:
+		perLevel = true
+	default:
+		return nil, fmt.Errorf("不支持的 Pulsar 主题划分方式: %s", cfg.TopicPer)
+	}
+
+	serviceTopic := prefix + "-" + serviceName
+	key := strconv.FormatUint(serviceID, 10)
+	batcher := newRemoteBatcher("pulsar", cfg.RemoteBatchConfig, func(ctx context.Context, batch []remoteRecord) error {
+		msgs := make([]PulsarMessage, len(batch))
+		for i := range batch {
+			level := batch[i].Entry.Level.String()
+			topic := serviceTopic
+			if perLevel {
+				topic = prefix + "-" + level
+			}
+			msgs[i] = PulsarMessage{
+				Topic:      topic,
+				Key:        key,
+				Payload:    batch[i].Data,
+				EventTime:  batch[i].Entry.Time,
+				Properties: map[string]string{"level": level, "service": serviceName, "service_id": key},
+			}
+		}
+		return producer.Send(ctx, msgs)
+	})
+	addSinkCloser(batcher)
+	return newRemoteCore(batcher, "", key), nil
+}
@@ -0,0 +1,62 @@
+package mlog
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// fakePulsarProducer 记录消息的测试生产者
+type fakePulsarProducer struct {
+	mu   sync.Mutex
+	msgs []PulsarMessage
+}
+
+func (p *fakePulsarProducer) Send(ctx context.Context, msgs []PulsarMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.msgs = append(p.msgs, msgs...)
+	return nil
+}
+
+// TestPulsarTopics 测试按服务和按级别划分主题，消息键为服务ID
+func TestPulsarTopics(t *testing.T) {
+	producer := &fakePulsarProducer{}
+	SetPulsarProducer(producer)
+	defer SetPulsarProducer(nil)
+
+	for _, c := range []struct {
+		topicPer string
+		want     [2]string
+	}{
+		{"", [2]string{"persistent://public/default/mlog-world", "persistent://public/default/mlog-world"}},
+		{PulsarTopicPerLevel, [2]string{"persistent://public/default/mlog-info", "persistent://public/default/mlog-error"}},
+	} {
+		producer.msgs = nil
+		core, err := newPulsarCore(PulsarConfig{TopicPer: c.topicPer}, "world", 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		core.LevelEnabler = zapcore.DebugLevel
+		log := zap.New(core)
+		log.Info("进入场景")
+		log.Error("寻路失败")
+		core.batcher.Close()
+
+		if len(producer.msgs) != 2 {
+			t.Fatalf("%q: 消息条数错误: %+v", c.topicPer, producer.msgs)
+		}
+		for i, msg := range producer.msgs {
+			if msg.Topic != c.want[i] || msg.Key != "3" || msg.Properties["service"] != "world" {
+				t.Fatalf("%q: 第 %d 条消息错误: %+v", c.topicPer, i, msg)
+			}
+		}
+	}
+
+	if _, err := newPulsarCore(PulsarConfig{TopicPer: "zone"}, "world", 3); err == nil {
+		t.Fatal("不支持的主题划分方式应该返回错误")
+	}
+}
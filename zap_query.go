@@ -0,0 +1,196 @@
+package mlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogRecord 从 JSON 格式日志归档中解析出的单条日志
+// 键为日志中的字段名（包括 level、time、msg、caller 等公共字段），数字以 json.Number 保存以免丢失精度
+type LogRecord map[string]any
+
+// maxRecordLineSize 单行日志的最大长度
+const maxRecordLineSize = 4 * 1024 * 1024
+
+// ReadRecords 逐行读取 JSON 格式（format: json）的日志归档
+// 空行和无法解析的行（如控制台格式或被截断的行）会被跳过
+func ReadRecords(r io.Reader) ([]LogRecord, error) {
+	var records []LogRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		var record LogRecord
+		if err := decoder.Decode(&record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// FieldValue 读取单条日志中的字段并转换为类型 T，字段不存在或无法转换时返回 false
+// 支持的转换：string、bool、int、int32、int64、uint32、uint64、float64、time.Time、time.Duration，
+// 其余类型按原始解析值直接断言
+func FieldValue[T any](record LogRecord, key string) (T, bool) {
+	var zero T
+	raw, ok := record[key]
+	if !ok || raw == nil {
+		return zero, false
+	}
+	converted, ok := convertFieldValue(raw, any(zero))
+	if !ok {
+		return zero, false
+	}
+	value, ok := converted.(T)
+	return value, ok
+}
+
+// ExtractField 从多条日志中提取指定字段并转换为类型 T
+// 缺少该字段或类型不匹配的日志会被跳过，例如：
+//
+//	playerIDs := mlog.ExtractField[int64](records, "player_id")
+func ExtractField[T any](records []LogRecord, key string) []T {
+	values := make([]T, 0, len(records))
+	for _, record := range records {
+		if value, ok := FieldValue[T](record, key); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// convertFieldValue 将解析出的原始值转换为 target 对应的类型
+func convertFieldValue(raw any, target any) (any, bool) {
+	switch target.(type) {
+	case string:
+		switch v := raw.(type) {
+		case string:
+			return v, true
+		case json.Number:
+			return v.String(), true
+		case bool:
+			return strconv.FormatBool(v), true
+		}
+		return nil, false
+	case bool:
+		switch v := raw.(type) {
+		case bool:
+			return v, true
+		case string:
+			b, err := strconv.ParseBool(v)
+			return b, err == nil
+		}
+		return nil, false
+	case int:
+		n, ok := parseInt(raw, strconv.IntSize)
+		return int(n), ok
+	case int32:
+		n, ok := parseInt(raw, 32)
+		return int32(n), ok
+	case int64:
+		return parseInt(raw, 64)
+	case uint32:
+		n, ok := parseUint(raw, 32)
+		return uint32(n), ok
+	case uint64:
+		return parseUint(raw, 64)
+	case float64:
+		switch v := raw.(type) {
+		case json.Number:
+			f, err := v.Float64()
+			return f, err == nil
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			return f, err == nil
+		}
+		return nil, false
+	case time.Time:
+		switch v := raw.(type) {
+		case json.Number:
+			// epoch 系列时间格式，按数值大小区分秒、毫秒和纳秒
+			n, err := v.Int64()
+			if err != nil {
+				return nil, false
+			}
+			switch {
+			case n < 1e11:
+				return time.Unix(n, 0), true
+			case n < 1e14:
+				return time.UnixMilli(n), true
+			}
+			return time.Unix(0, n), true
+		case string:
+			// 字符串时间格式带有 Prefix，并可能使用 TimeFormat 配置的布局
+			v = strings.TrimPrefix(v, zapConfig.Prefix)
+			layouts := []string{time.RFC3339Nano, "2006-01-02T15:04:05.000Z0700", defaultTimeLayout}
+			if layout := zapConfig.timeLayout(); layout != "" {
+				layouts = append(layouts, layout)
+			}
+			// 不带时区的时间按写入时的时区解析
+			loc, err := zapConfig.timeLocation()
+			if loc == nil || err != nil {
+				loc = time.Local
+			}
+			for _, layout := range layouts {
+				if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+					return t, true
+				}
+			}
+		}
+		return nil, false
+	case time.Duration:
+		switch v := raw.(type) {
+		case string:
+			d, err := time.ParseDuration(v)
+			return d, err == nil
+		case json.Number:
+			// zap 默认以浮点秒数编码 Duration
+			f, err := v.Float64()
+			return time.Duration(f * float64(time.Second)), err == nil
+		}
+		return nil, false
+	default:
+		return raw, true
+	}
+}
+
+// parseInt 将数字或数字字符串解析为有符号整数
+func parseInt(raw any, bitSize int) (int64, bool) {
+	var s string
+	switch v := raw.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = v
+	default:
+		return 0, false
+	}
+	n, err := strconv.ParseInt(s, 10, bitSize)
+	return n, err == nil
+}
+
+// parseUint 将数字或数字字符串解析为无符号整数
+func parseUint(raw any, bitSize int) (uint64, bool) {
+	var s string
+	switch v := raw.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = v
+	default:
+		return 0, false
+	}
+	n, err := strconv.ParseUint(s, 10, bitSize)
+	return n, err == nil
+}
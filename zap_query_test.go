@@ -0,0 +1,39 @@
+package mlog
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestExtractField 测试从 JSON 日志归档中提取字段并做类型转换
+func TestExtractField(t *testing.T) {
+	archive := strings.Join([]string{
+		`{"level":"info","msg":"login","player_id":9007199254740993,"vip":true}`,
+		`2024-01-01 00:00:00.000 INFO console line`,
+		`{"level":"info","msg":"logout","player_id":"42"}`,
+		`{"level":"warn","msg":"no player"}`,
+		``,
+	}, "\n")
+
+	records, err := ReadRecords(strings.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("期望 3 条记录，实际 %d", len(records))
+	}
+
+	ids := ExtractField[int64](records, "player_id")
+	if len(ids) != 2 || ids[0] != 9007199254740993 || ids[1] != 42 {
+		t.Fatalf("player_id 提取错误: %v", ids)
+	}
+	if msgs := ExtractField[string](records, "msg"); len(msgs) != 3 || msgs[2] != "no player" {
+		t.Fatalf("msg 提取错误: %v", msgs)
+	}
+	if vip, ok := FieldValue[bool](records[0], "vip"); !ok || !vip {
+		t.Fatal("vip 字段应为 true")
+	}
+	if _, ok := FieldValue[int32](records[0], "player_id"); ok {
+		t.Fatal("超出 int32 范围时应转换失败")
+	}
+}
@@ -0,0 +1,121 @@
+package mlog
+
+import (
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactedValue 敏感字段脱敏后的值
+const redactedValue = "***"
+
+// redactCore 敏感字段脱敏
+// 位于所有输出之前，键名匹配 RedactKeys（不区分大小写）的字段值替换为 ***，消息中 key=value、key: value
+// 以及 "key":"value" 形式的片段同样替换；MaskRules 的正则规则应用于消息和字符串字段的值。
+// 文件、控制台和所有远程输出都只能看到脱敏后的内容。只检查顶层字段，zap.Object/zap.Any 编码的嵌套对象内部不做处理
+type redactCore struct {
+	zapcore.Core
+	redactor *redactor
+}
+
+// redactor 按键名和正则规则脱敏字段和消息
+type redactor struct {
+	keys    map[string]bool
+	pattern *regexp.Regexp // 消息中的 key=value 片段，未配置键名时为 nil
+	rules   []maskRule
+}
+
+// newRedactor 创建脱敏器，keys 和 rules 都为空时返回 nil
+func newRedactor(keys []string, rules []maskRule) *redactor {
+	r := &redactor{keys: make(map[string]bool, len(keys)), rules: rules}
+	quoted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" || r.keys[strings.ToLower(key)] {
+			continue
+		}
+		r.keys[strings.ToLower(key)] = true
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+	if len(quoted) == 0 && len(rules) == 0 {
+		return nil
+	}
+	if len(quoted) > 0 {
+		// 键名可以带引号，值为引号包围的字符串或到空白、逗号、分号、& 为止的片段
+		r.pattern = regexp.MustCompile(`(?i)(["']?\b(?:` + strings.Join(quoted, "|") + `)\b["']?\s*[=:]\s*)("[^"]*"|'[^']*'|[^\s,;&]+)`)
+	}
+	return r
+}
+
+// newRedactCore 按 RedactKeys 和 MaskRules 包装 Core，都未配置时原样返回
+// 无效的脱敏规则由 initZap 输出警告，这里直接跳过
+func newRedactCore(core zapcore.Core, c *ZapConfig) zapcore.Core {
+	rules, _ := compileMaskRules(c.MaskRules)
+	r := newRedactor(c.RedactKeys, rules)
+	if r == nil {
+		return core
+	}
+	return &redactCore{Core: core, redactor: r}
+}
+
+// message 替换消息中的敏感片段
+func (r *redactor) message(msg string) string {
+	return applyMaskRules(r.rules, r.keyValues(msg))
+}
+
+// keyValues 替换消息中 key=value 形式的敏感片段
+func (r *redactor) keyValues(msg string) string {
+	if r.pattern == nil || !strings.ContainsAny(msg, "=:") {
+		return msg
+	}
+	return r.pattern.ReplaceAllStringFunc(msg, func(match string) string {
+		m := r.pattern.FindStringSubmatch(match)
+		// 引号包围的值保留引号，消息中嵌入的 JSON 仍然有效
+		if quote := m[2][0]; quote == '"' || quote == '\'' {
+			return m[1] + string(quote) + redactedValue + string(quote)
+		}
+		return m[1] + redactedValue
+	})
+}
+
+// fields 返回脱敏后的字段，没有需要脱敏的字段时返回原切片
+func (r *redactor) fields(fields []zapcore.Field) []zapcore.Field {
+	var redacted []zapcore.Field
+	for i := range fields {
+		value := redactedValue
+		if !r.keys[strings.ToLower(fields[i].Key)] {
+			if len(r.rules) == 0 || fields[i].Type != zapcore.StringType {
+				continue
+			}
+			if value = applyMaskRules(r.rules, fields[i].String); value == fields[i].String {
+				continue
+			}
+		}
+		if redacted == nil {
+			redacted = append([]zapcore.Field(nil), fields...)
+		}
+		redacted[i] = zap.String(fields[i].Key, value)
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redactor.fields(fields)), redactor: c.redactor}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.redactor.message(entry.Message)
+	return writeChecked(c.Core, entry, c.redactor.fields(fields))
+}
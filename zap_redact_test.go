@@ -0,0 +1,41 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestRedactKeys 测试字段值和消息中的敏感片段脱敏，其他内容保留
+func TestRedactKeys(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "json", EnableAsync: true,
+		RedactKeys: []string{"password", "Token", "card_no"}}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	InfoW("玩家登录", zap.String("password", "hunter2"), zap.Int64("CARD_NO", 6222021234), zap.String("account", "alice"))
+	Info("回调 token=abc123&uid=10086 card_no: 6222 \"password\":\"hunter2\"")
+	GLOG().With(zap.String("token", "abc123")).Info("With 附加的字段")
+	Flush()
+
+	data, err := os.ReadFile(filepath.Join(dir, "2", "gate", "info.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, secret := range []string{"hunter2", "6222021234", "abc123", "6222 "} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("敏感内容 %q 未脱敏: %s", secret, out)
+		}
+	}
+	for _, kept := range []string{`"account":"alice"`, `"CARD_NO":"***"`, "token=***&uid=10086", `card_no: ***`, `\"password\":\"***\"`} {
+		if !strings.Contains(out, kept) {
+			t.Fatalf("缺少 %q: %s", kept, out)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package mlog
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redis Stream 输出的默认参数
+const (
+	defaultRedisStreamMaxLen = 100000
+	redisDialTimeout         = 3 * time.Second
+)
+
+// RedisStreamConfig Redis Stream 输出配置
+type RedisStreamConfig struct {
+	Enable   bool   `mapstructure:"enable" json:"enable" yaml:"enable"`       // 启用 Redis Stream 输出
+	Address  string `mapstructure:"address" json:"address" yaml:"address"`    // Redis 地址，如 127.0.0.1:6379
+	Username string `mapstructure:"username" json:"username" yaml:"username"` // ACL 用户名（Redis 6+）
+	Password string `mapstructure:"password" json:"password" yaml:"password"` // 密码
+	DB       int    `mapstructure:"db" json:"db" yaml:"db"`                   // 数据库编号
+	Stream   string `mapstructure:"stream" json:"stream" yaml:"stream"`       // Stream 键名（默认 mlog:<服务名>）
+	// Stream 最大长度（默认 100000），使用近似裁剪（MAXLEN ~），负数表示不裁剪
+	MaxLen            int `mapstructure:"max-len" json:"max-len" yaml:"max-len"`
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// redisStreamClient Redis Stream 客户端
+// 直接实现 RESP 协议，整批 XADD 命令以管道方式发送后依次读取回复
+type redisStreamClient struct {
+	address  string
+	username string
+	password string
+	db       int
+	stream   string
+	maxLen   int
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// newRedisStreamCore 创建 Redis Stream 输出 Core
+// 每条日志写入 level 和 data（JSON 编码的完整日志）两个字段，便于运维面板用 XREAD 实时查看
+func newRedisStreamCore(cfg RedisStreamConfig, serviceName string) (*remoteCore, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("未配置 Redis 地址")
+	}
+	stream := cfg.Stream
+	if stream == "" {
+		stream = "mlog:" + serviceName
+	}
+	c := &redisStreamClient{
+		address:  cfg.Address,
+		username: cfg.Username,
+		password: cfg.Password,
+		db:       cfg.DB,
+		stream:   stream,
+		maxLen:   cfg.MaxLen,
+	}
+	if c.maxLen == 0 {
+		c.maxLen = defaultRedisStreamMaxLen
+	}
+	batcher := newRemoteBatcher("redis", cfg.RemoteBatchConfig, c.xadd)
+	addSinkCloser(batcher)
+	addSinkCloser(c)
+	return newRemoteCore(batcher, "", ""), nil
+}
+
+// xadd 以管道方式写入一批日志
+func (c *redisStreamClient) xadd(ctx context.Context, batch []remoteRecord) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.ensureConnLocked(ctx); err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	}
+
+	var buf []byte
+	for i := range batch {
+		args := []string{"XADD", c.stream}
+		if c.maxLen > 0 {
+			args = append(args, "MAXLEN", "~", strconv.Itoa(c.maxLen))
+		}
+		args = append(args, "*", "level", batch[i].Entry.Level.String(), "data", string(batch[i].Data))
+		buf = appendRESPCommand(buf, args...)
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		c.resetLocked()
+		return err
+	}
+
+	var retry []remoteRecord
+	var lastErr error
+	for i := range batch {
+		if err := readRESPReply(c.reader); err != nil {
+			var replyErr redisReplyError
+			if !errors.As(err, &replyErr) {
+				// 连接出错，无法确定剩余命令是否执行，全部重试
+				c.resetLocked()
+				return &remotePartialError{Retry: append(retry, batch[i:]...), Err: err}
+			}
+			retry = append(retry, batch[i])
+			lastErr = err
+		}
+	}
+	if len(retry) > 0 {
+		return &remotePartialError{Retry: retry, Err: lastErr}
+	}
+	return nil
+}
+
+// ensureConnLocked 连接不可用时重新连接并完成认证和选库，调用方需持有 mu
+func (c *redisStreamClient) ensureConnLocked(ctx context.Context) error {
+	if c.conn != nil {
+		return nil
+	}
+	dialer := net.Dialer{Timeout: redisDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return err
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+
+	var buf []byte
+	replies := 0
+	if c.password != "" {
+		if c.username != "" {
+			buf = appendRESPCommand(buf, "AUTH", c.username, c.password)
+		} else {
+			buf = appendRESPCommand(buf, "AUTH", c.password)
+		}
+		replies++
+	}
+	if c.db != 0 {
+		buf = appendRESPCommand(buf, "SELECT", strconv.Itoa(c.db))
+		replies++
+	}
+	if replies == 0 {
+		return nil
+	}
+	conn.SetDeadline(time.Now().Add(redisDialTimeout))
+	if _, err := conn.Write(buf); err != nil {
+		c.resetLocked()
+		return err
+	}
+	for ; replies > 0; replies-- {
+		if err := readRESPReply(c.reader); err != nil {
+			c.resetLocked()
+			return fmt.Errorf("Redis 认证或选库失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// resetLocked 关闭当前连接，下一次写入时重连，调用方需持有 mu
+func (c *redisStreamClient) resetLocked() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn, c.reader = nil, nil
+	}
+}
+
+// Close 关闭连接
+func (c *redisStreamClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resetLocked()
+	return nil
+}
+
+// redisReplyError Redis 返回的错误回复
+type redisReplyError string
+
+func (e redisReplyError) Error() string {
+	return string(e)
+}
+
+// appendRESPCommand 追加 RESP 数组格式的命令
+func appendRESPCommand(buf []byte, args ...string) []byte {
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, "\r\n"...)
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+// readRESPReply 读取并丢弃一条回复，错误回复返回 redisReplyError
+func readRESPReply(r *bufio.Reader) error {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return errors.New("Redis 回复格式错误")
+	}
+	switch line[0] {
+	case '+', ':':
+		return nil
+	case '-':
+		return redisReplyError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return fmt.Errorf("Redis 回复格式错误: %s", line)
+		}
+		if n < 0 {
+			return nil
+		}
+		_, err = io.CopyN(io.Discard, r, int64(n)+2)
+		return err
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return fmt.Errorf("Redis 回复格式错误: %s", line)
+		}
+		for ; n > 0; n-- {
+			if err := readRESPReply(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("Redis 回复格式错误: %s", line)
+	}
+}
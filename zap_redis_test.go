@@ -0,0 +1,115 @@
+package mlog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// readRESPCommand 读取一条 RESP 数组格式的命令
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+// TestRedisStreamXAdd 测试认证、选库和 XADD 命令格式，错误回复的日志单独重试
+func TestRedisStreamXAdd(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	var (
+		mu       sync.Mutex
+		commands [][]string
+	)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		xadds := 0
+		for {
+			args, err := readRESPCommand(r)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			commands = append(commands, args)
+			mu.Unlock()
+			switch args[0] {
+			case "XADD":
+				xadds++
+				if xadds == 1 {
+					conn.Write([]byte("-OOM command not allowed\r\n"))
+				} else {
+					conn.Write([]byte("$3\r\n1-0\r\n"))
+				}
+			default:
+				conn.Write([]byte("+OK\r\n"))
+			}
+		}
+	}()
+
+	core, err := newRedisStreamCore(RedisStreamConfig{
+		Address:           ln.Addr().String(),
+		Password:          "secret",
+		DB:                2,
+		MaxLen:            1000,
+		RemoteBatchConfig: RemoteBatchConfig{RetryBackoffMs: 10},
+	}, "game")
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	log := zap.New(core)
+	log.Info("a")
+	log.Warn("b")
+	core.batcher.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(commands) != 5 ||
+		strings.Join(commands[0], " ") != "AUTH secret" || strings.Join(commands[1], " ") != "SELECT 2" {
+		t.Fatalf("命令错误: %v", commands)
+	}
+	xadd := commands[2]
+	if strings.Join(xadd[:8], " ") != "XADD mlog:game MAXLEN ~ 1000 * level info" || xadd[8] != "data" ||
+		!strings.Contains(xadd[9], `"msg":"a"`) {
+		t.Fatalf("XADD 命令错误: %v", xadd)
+	}
+	// 第一条被拒绝后单独重试
+	if !strings.Contains(commands[4][9], `"msg":"a"`) {
+		t.Fatalf("重试的日志错误: %v", commands[4])
+	}
+	if stats := core.batcher.stats(); stats.Sent != 2 || stats.Failed != 0 {
+		t.Fatalf("统计错误: %+v", stats)
+	}
+}
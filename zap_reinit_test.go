@@ -0,0 +1,65 @@
+package mlog
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TestReinitNoLeak 测试重复调用 InitialZap 不会增加打开的文件数和协程数
+func TestReinitNoLeak(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("通过 /proc/self/fd 统计文件句柄")
+	}
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{
+		Level:          "info",
+		Director:       dir,
+		MaxSize:        10,
+		EnableAsync:    true,
+		MaxTotalSizeMB: 100,
+		CleanStaleDirs: true,
+		RotateInterval: "1h",
+		BufferSizeKB:   16,
+	}
+	initAndLog := func() {
+		InitialZap("gate", 2, "info", &config)
+		InfoW("重复初始化")
+		InfoW("重复初始化", zap.String("folder", "net"))
+		ErrorW("重复初始化", zap.String("business", "pay"))
+		Flush()
+	}
+	defer Close()
+
+	initAndLog()
+	fds, goroutines := countFds(t), runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		initAndLog()
+	}
+
+	// 关闭后退出的协程可能还没有调度到，等待一段时间
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		n, g := countFds(t), runtime.NumGoroutine()
+		if n <= fds && g <= goroutines {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("重复初始化后资源增长: 文件句柄 %d -> %d，协程 %d -> %d", fds, n, goroutines, g)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func countFds(t *testing.T) int {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
@@ -0,0 +1,138 @@
+package mlog
+
+import (
+	"maps"
+	"slices"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// coreWrapper 一层可以热替换的 Core 包装
+type coreWrapper struct {
+	wrap func(core zapcore.Core) zapcore.Core
+}
+
+// hotCores 当前日志器中可以热更新的包装层，applyHotConfig 替换其中的包装函数，不重建日志器
+type hotCores struct {
+	sampling atomic.Pointer[coreWrapper] // 按级别采样
+	redact   atomic.Pointer[coreWrapper] // 敏感字段脱敏
+	config   atomic.Pointer[hotConfig]   // 当前生效的热更新配置项
+}
+
+// hotConfig 可以热更新的配置项
+// 初始化后热更新只替换这里的值，不修改 zapConfig，写日志的路径无锁读取 zapConfig 时不会与热更新竞争
+type hotConfig struct {
+	logInConsole bool
+	sampling     map[string]SamplingConfig
+	redactKeys   []string
+	maskRules    []MaskRule
+	routes       []LevelRouteConfig
+}
+
+// hotConfigOf 取出配置中可以热更新的配置项
+func hotConfigOf(c *ZapConfig) *hotConfig {
+	return &hotConfig{
+		logInConsole: c.LogInConsole,
+		sampling:     maps.Clone(c.Sampling),
+		redactKeys:   slices.Clone(c.RedactKeys),
+		maskRules:    slices.Clone(c.MaskRules),
+		routes:       slices.Clone(c.Routes),
+	}
+}
+
+// applyTo 用热更新的值覆盖配置副本中的对应配置项
+func (h *hotConfig) applyTo(c *ZapConfig) {
+	c.LogInConsole = h.logInConsole
+	c.Sampling = maps.Clone(h.sampling)
+	c.RedactKeys = slices.Clone(h.redactKeys)
+	c.MaskRules = slices.Clone(h.maskRules)
+	c.Routes = slices.Clone(h.routes)
+}
+
+// activeHotCores 当前日志器的可热更新包装层，未初始化时为 nil
+var activeHotCores atomic.Pointer[hotCores]
+
+// samplingWrapper 按采样配置包装 Core
+func samplingWrapper(configs map[string]SamplingConfig, dropped *droppedCounter) *coreWrapper {
+	return &coreWrapper{wrap: func(core zapcore.Core) zapcore.Core {
+		return newLevelSampledCore(core, configs, dropped)
+	}}
+}
+
+// redactWrapper 按脱敏配置包装 Core
+func redactWrapper(c *ZapConfig) *coreWrapper {
+	config := ZapConfig{RedactKeys: slices.Clone(c.RedactKeys), MaskRules: slices.Clone(c.MaskRules)}
+	return &coreWrapper{wrap: func(core zapcore.Core) zapcore.Core {
+		return newRedactCore(core, &config)
+	}}
+}
+
+// reloadableCore 按 slot 中当前的包装函数包装下层 Core
+// 包装函数被替换后，下一次写日志时按新的包装函数重新包装，下层 Core（去重、输出、文件）保持不变；
+// With 附加的字段在包装之后附加，与包装层直接调用 With 的效果一致
+type reloadableCore struct {
+	base   zapcore.Core
+	fields []zapcore.Field
+	slot   *atomic.Pointer[coreWrapper]
+	built  atomic.Pointer[builtCore]
+}
+
+// builtCore 按某个包装函数包装好的 Core
+type builtCore struct {
+	from *coreWrapper
+	core zapcore.Core
+}
+
+// newReloadableCore 创建按 slot 包装 base 的 Core
+func newReloadableCore(base zapcore.Core, slot *atomic.Pointer[coreWrapper]) zapcore.Core {
+	return &reloadableCore{base: base, slot: slot}
+}
+
+// current 返回按当前包装函数包装好的 Core
+func (c *reloadableCore) current() *builtCore {
+	w := c.slot.Load()
+	built := c.built.Load()
+	if built != nil && built.from == w {
+		return built
+	}
+	core := w.wrap(c.base)
+	if len(c.fields) > 0 {
+		core = core.With(c.fields)
+	}
+	next := &builtCore{from: w, core: core}
+	if !c.built.CompareAndSwap(built, next) {
+		// 其他协程已经完成包装
+		if other := c.built.Load(); other.from == w {
+			return other
+		}
+	}
+	return next
+}
+
+func (c *reloadableCore) Enabled(level zapcore.Level) bool {
+	return c.current().core.Enabled(level)
+}
+
+func (c *reloadableCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &reloadableCore{
+		base:   c.base,
+		fields: append(slices.Clip(c.fields), fields...),
+		slot:   c.slot,
+	}
+	built := c.current()
+	clone.built.Store(&builtCore{from: built.from, core: built.core.With(fields)})
+	return clone
+}
+
+func (c *reloadableCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.current().core.Check(entry, ce)
+}
+
+func (c *reloadableCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.current().core.Write(entry, fields)
+}
+
+func (c *reloadableCore) Sync() error {
+	return c.current().core.Sync()
+}
@@ -0,0 +1,85 @@
+package mlog
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestReloadableCore 测试替换包装函数后已有的 Logger 和 With 派生的 Logger 都按新的包装层写入，
+// 下层 Core 不重建
+func TestReloadableCore(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	var slot hotCores
+	slot.redact.Store(redactWrapper(&ZapConfig{}))
+	logger := zap.New(newReloadableCore(observed, &slot.redact))
+	child := logger.With(zap.String("token", "abc"))
+
+	child.Info("登录", zap.String("password", "hunter2"))
+	slot.redact.Store(redactWrapper(&ZapConfig{RedactKeys: []string{"password", "token"}}))
+	child.Info("登录", zap.String("password", "hunter2"))
+	logger.Info("password=hunter2")
+
+	entries := logs.TakeAll()
+	if len(entries) != 3 {
+		t.Fatalf("期望 3 条日志，实际 %d", len(entries))
+	}
+	if fields := entries[0].ContextMap(); fields["password"] != "hunter2" || fields["token"] != "abc" {
+		t.Fatalf("替换前不应脱敏: %v", fields)
+	}
+	if fields := entries[1].ContextMap(); fields["password"] != redactedValue || fields["token"] != redactedValue {
+		t.Fatalf("替换后 With 字段和日志字段都应脱敏: %v", fields)
+	}
+	if entries[2].Message != "password="+redactedValue {
+		t.Fatalf("替换后消息应脱敏: %s", entries[2].Message)
+	}
+}
+
+// TestHotConfigKeepsLogger 测试热更新控制台、采样、脱敏和路由时不重建日志器，已打开的场景日志保持可用
+func TestHotConfigKeepsLogger(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, SingleFile: true}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	logger := GLOG()
+	hot := activeHotCores.Load()
+	scene := Scene("dungeon-1")
+	applyHotConfig(func(c *ZapConfig) {
+		c.LogInConsole = true
+		c.Sampling = map[string]SamplingConfig{"info": {Initial: 1, Thereafter: 100}}
+		c.RedactKeys = []string{"password"}
+		c.Routes = []LevelRouteConfig{{Levels: "error+", Outputs: []string{OutputFile}}}
+	})
+	if GLOG() != logger || activeHotCores.Load() != hot {
+		t.Fatal("热更新不应重建日志器")
+	}
+	if !consoleEnabled.Load() || activeRoutes.Load() == nil {
+		t.Fatal("控制台开关和路由应立即生效")
+	}
+	applyHotConfig(func(c *ZapConfig) { c.LogInConsole = false })
+
+	for i := 0; i < 3; i++ {
+		InfoW("心跳", zap.String("password", "hunter2"))
+	}
+	scene.InfoW("进入副本", zap.String("password", "hunter2"))
+	// 场景文件直接写入，关闭日志系统时会被归档
+	sceneContent := readLogFile(t, scene.path)
+	if !strings.Contains(sceneContent, "进入副本") || strings.Contains(sceneContent, "hunter2") {
+		t.Fatalf("热更新后场景日志应保持打开并按新配置脱敏: %s", sceneContent)
+	}
+	Close()
+
+	content := readLogFile(t, filepath.Join(dir, "2/gate/all.log"))
+	if n := strings.Count(content, "心跳"); n != 1 {
+		t.Fatalf("采样后应只写入 1 条，实际 %d: %s", n, content)
+	}
+	if strings.Contains(content, "hunter2") {
+		t.Fatalf("应按新配置脱敏: %s", content)
+	}
+}
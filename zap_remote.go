@@ -0,0 +1,383 @@
+package mlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 远端输出批量发送的默认参数
+const (
+	defaultRemoteBufferSize    = 10000
+	defaultRemoteBatchSize     = 500
+	defaultRemoteFlushInterval = time.Second
+	defaultRemoteMaxRetries    = 3
+	defaultRemoteRetryBackoff  = 200 * time.Millisecond
+	defaultRemoteMaxBackoff    = 30 * time.Second
+	remoteCloseTimeout         = 5 * time.Second
+)
+
+// RemoteBatchConfig 远端日志输出的批量发送配置，各远端输出共用
+type RemoteBatchConfig struct {
+	BufferSize      int `mapstructure:"buffer-size" json:"buffer-size" yaml:"buffer-size"`                   // 内存缓冲条数，满时丢弃（默认 10000）
+	BatchSize       int `mapstructure:"batch-size" json:"batch-size" yaml:"batch-size"`                      // 每批最多条数（默认 500）
+	FlushIntervalMs int `mapstructure:"flush-interval-ms" json:"flush-interval-ms" yaml:"flush-interval-ms"` // 批次未满时的最长等待时间（毫秒，默认 1000）
+	MaxRetries      int `mapstructure:"max-retries" json:"max-retries" yaml:"max-retries"`                   // 发送失败的重试次数（默认 3，负数不重试）
+	RetryBackoffMs  int `mapstructure:"retry-backoff-ms" json:"retry-backoff-ms" yaml:"retry-backoff-ms"`    // 首次重试间隔（毫秒，默认 200，之后每次翻倍）
+}
+
+// remoteRecord 等待发送到远端的一条日志
+type remoteRecord struct {
+	Entry zapcore.Entry // 日志元数据
+	Key   string        // 分区键（未配置时为空）
+	Data  []byte        // 编码后的日志内容（不含换行）
+}
+
+// remoteSendFunc 批量发送函数，返回错误时整批重试
+type remoteSendFunc func(ctx context.Context, batch []remoteRecord) error
+
+// RemoteSinkStats 远端日志输出的统计信息
+type RemoteSinkStats struct {
+	Buffered int    `json:"buffered"` // 当前缓冲的条数
+	Sent     uint64 `json:"sent"`     // 累计发送成功的条数
+	Dropped  uint64 `json:"dropped"`  // 累计因缓冲区满被丢弃的条数
+	Failed   uint64 `json:"failed"`   // 累计重试后仍发送失败而丢弃的条数
+}
+
+// remoteBatcher 远端日志批量发送器
+// 日志写入有界缓冲区后立即返回，后台协程按批次发送并在失败时退避重试；
+// 缓冲区满或远端持续不可用时丢弃远端日志，文件日志不受影响
+type remoteBatcher struct {
+	name       string
+	send       remoteSendFunc
+	buffer     chan remoteRecord
+	batchSize  int
+	interval   time.Duration
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	// persistent 为 true 时发送失败一直重试直到关闭，期间新日志留在缓冲区
+	persistent bool
+	// paused 返回 true 时不再接收和发送日志（如停服阶段停止网络输出）
+	paused func() bool
+
+	sent    uint64
+	dropped uint64
+	failed  uint64
+
+	closeOnce sync.Once
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+var (
+	remoteBatchers      = make(map[string]*remoteBatcher)
+	remoteBatchersMutex sync.Mutex
+)
+
+// newRemoteBatcher 创建批量发送器并启动后台发送协程
+func newRemoteBatcher(name string, cfg RemoteBatchConfig, send remoteSendFunc) *remoteBatcher {
+	b := &remoteBatcher{
+		name:       name,
+		send:       send,
+		buffer:     make(chan remoteRecord, positiveOr(cfg.BufferSize, defaultRemoteBufferSize)),
+		batchSize:  positiveOr(cfg.BatchSize, defaultRemoteBatchSize),
+		interval:   defaultRemoteFlushInterval,
+		maxRetries: cfg.MaxRetries,
+		backoff:    defaultRemoteRetryBackoff,
+		maxBackoff: defaultRemoteMaxBackoff,
+		done:       make(chan struct{}),
+	}
+	if cfg.FlushIntervalMs > 0 {
+		b.interval = time.Duration(cfg.FlushIntervalMs) * time.Millisecond
+	}
+	if cfg.MaxRetries == 0 {
+		b.maxRetries = defaultRemoteMaxRetries
+	}
+	if cfg.RetryBackoffMs > 0 {
+		b.backoff = time.Duration(cfg.RetryBackoffMs) * time.Millisecond
+	}
+
+	remoteBatchersMutex.Lock()
+	remoteBatchers[name] = b
+	remoteBatchersMutex.Unlock()
+
+	b.wg.Add(1)
+	go b.run()
+	return b
+}
+
+// positiveOr value 大于 0 时返回 value，否则返回 fallback
+func positiveOr(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// add 将日志放入缓冲区，缓冲区满时丢弃
+func (b *remoteBatcher) add(record remoteRecord) {
+	if b.paused != nil && b.paused() {
+		return
+	}
+	select {
+	case b.buffer <- record:
+	default:
+		atomic.AddUint64(&b.dropped, 1)
+	}
+}
+
+// run 后台发送循环
+func (b *remoteBatcher) run() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	batch := make([]remoteRecord, 0, b.batchSize)
+	for {
+		select {
+		case record := <-b.buffer:
+			batch = append(batch, record)
+			if len(batch) >= b.batchSize {
+				batch = b.flush(batch)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				batch = b.flush(batch)
+			}
+		case <-b.done:
+			// 发送缓冲区中剩余的日志
+			for {
+				select {
+				case record := <-b.buffer:
+					batch = append(batch, record)
+					if len(batch) >= b.batchSize {
+						batch = b.flush(batch)
+					}
+				default:
+					if len(batch) > 0 {
+						b.flush(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// remotePartialError 批次部分发送成功时返回的错误
+// Retry 为需要重试的条目，Rejected 为被远端拒绝且不可重试的条数，其余条目视为发送成功
+type remotePartialError struct {
+	Retry    []remoteRecord
+	Rejected int
+	Err      error
+}
+
+func (e *remotePartialError) Error() string {
+	return fmt.Sprintf("%d 条待重试，%d 条被拒绝: %v", len(e.Retry), e.Rejected, e.Err)
+}
+
+func (e *remotePartialError) Unwrap() error {
+	return e.Err
+}
+
+// flush 发送一批日志，失败时按指数退避重试，返回清空后的批次切片
+func (b *remoteBatcher) flush(batch []remoteRecord) []remoteRecord {
+	backoff := b.backoff
+	pending := batch
+	for attempt := 0; ; attempt++ {
+		if b.paused != nil && b.paused() {
+			atomic.AddUint64(&b.failed, uint64(len(pending)))
+			break
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), remoteCloseTimeout)
+		err := b.send(ctx, pending)
+		cancel()
+		if err == nil {
+			atomic.AddUint64(&b.sent, uint64(len(pending)))
+			break
+		}
+		var partial *remotePartialError
+		if errors.As(err, &partial) {
+			// 部分成功：只重试失败的条目，不可重试的条目直接计入失败
+			atomic.AddUint64(&b.sent, uint64(len(pending)-len(partial.Retry)-partial.Rejected))
+			atomic.AddUint64(&b.failed, uint64(partial.Rejected))
+			pending = partial.Retry
+			if len(pending) == 0 {
+				break
+			}
+		}
+		if attempt >= b.maxRetries && (!b.persistent || b.closing()) {
+			atomic.AddUint64(&b.failed, uint64(len(pending)))
+			fmt.Fprintf(os.Stderr, "[mlog] 远端日志输出 %s 发送失败，丢弃 %d 条: %v\n", b.name, len(pending), err)
+			break
+		}
+		// 关闭过程中不再等待退避间隔，尽快完成剩余的重试
+		select {
+		case <-time.After(backoff):
+		case <-b.done:
+		}
+		backoff = min(backoff*2, b.maxBackoff)
+	}
+	clear(batch)
+	return batch[:0]
+}
+
+// closing 是否正在关闭
+func (b *remoteBatcher) closing() bool {
+	select {
+	case <-b.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// stats 获取统计信息
+func (b *remoteBatcher) stats() RemoteSinkStats {
+	return RemoteSinkStats{
+		Buffered: len(b.buffer),
+		Sent:     atomic.LoadUint64(&b.sent),
+		Dropped:  atomic.LoadUint64(&b.dropped),
+		Failed:   atomic.LoadUint64(&b.failed),
+	}
+}
+
+// Close 发送缓冲区中剩余的日志后停止后台协程，最多等待 remoteCloseTimeout
+func (b *remoteBatcher) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.done)
+	})
+	if !waitGroupTimeout(&b.wg, remoteCloseTimeout) {
+		return fmt.Errorf("远端日志输出 %s 关闭超时，剩余 %d 条未发送", b.name, len(b.buffer))
+	}
+	remoteBatchersMutex.Lock()
+	if remoteBatchers[b.name] == b {
+		delete(remoteBatchers, b.name)
+	}
+	remoteBatchersMutex.Unlock()
+	return nil
+}
+
+// RemoteSinkStatsAll 获取所有远端日志输出的统计信息（键为输出名称，如 kafka）
+func RemoteSinkStatsAll() map[string]RemoteSinkStats {
+	remoteBatchersMutex.Lock()
+	defer remoteBatchersMutex.Unlock()
+	stats := make(map[string]RemoteSinkStats, len(remoteBatchers))
+	for name, b := range remoteBatchers {
+		stats[name] = b.stats()
+	}
+	return stats
+}
+
+// remoteCore 将 JSON（或 MessagePack）编码后的日志交给批量发送器的 zapcore.Core
+// keyField 非空时以该字段的值作为分区键，字段不存在时使用 defaultKey
+type remoteCore struct {
+	zapcore.LevelEnabler
+	encoder    zapcore.Encoder
+	binary     bool // 二进制编码，编码结果没有行尾
+	batcher    *remoteBatcher
+	keyField   string
+	defaultKey string
+}
+
+// 远端输出的日志编码
+const (
+	SinkEncodingJSON     = "json"     // 默认，JSON
+	SinkEncodingMsgpack  = "msgpack"  // MessagePack，体积更小、收集端解析更快
+	SinkEncodingProtobuf = "protobuf" // proto/logentry.proto 定义的 LogEntry，各服务统一的线格式
+)
+
+// parseSinkEncoding 校验输出的编码配置，空字符串按 json 处理
+func parseSinkEncoding(encoding string) (string, error) {
+	switch encoding {
+	case "", SinkEncodingJSON:
+		return SinkEncodingJSON, nil
+	case SinkEncodingMsgpack, SinkEncodingProtobuf:
+		return encoding, nil
+	}
+	return "", fmt.Errorf("不支持的日志编码: %s", encoding)
+}
+
+// useEncoding 按编码替换编码器，字段名与 JSON 编码相同；Protobuf 编码的日志带有服务名和服务 ID
+func (c *remoteCore) useEncoding(encoding, serviceName string, serviceID uint64) {
+	switch encoding {
+	case SinkEncodingMsgpack:
+		c.encoder = newMsgpackEncoder(remoteEncoderConfig())
+		c.binary = true
+	case SinkEncodingProtobuf:
+		c.encoder = newProtobufEncoder(serviceName, serviceID)
+		c.binary = true
+	}
+}
+
+// remoteEncoderConfig 远端输出的编码配置
+func remoteEncoderConfig() zapcore.EncoderConfig {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	return encoderConfig
+}
+
+// newRemoteCore 创建远端输出 Core
+func newRemoteCore(batcher *remoteBatcher, keyField, defaultKey string) *remoteCore {
+	return &remoteCore{
+		LevelEnabler: atomicLevel,
+		encoder:      zapcore.NewJSONEncoder(remoteEncoderConfig()),
+		batcher:      batcher,
+		keyField:     keyField,
+		defaultKey:   defaultKey,
+	}
+}
+
+func (c *remoteCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.encoder = c.encoder.Clone()
+	for i := range fields {
+		fields[i].AddTo(clone.encoder)
+		if c.keyField != "" && fields[i].Key == c.keyField {
+			clone.defaultKey = fieldValueString(fields[i])
+		}
+	}
+	return &clone
+}
+
+func (c *remoteCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *remoteCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	key := c.defaultKey
+	if c.keyField != "" {
+		for i := range fields {
+			if fields[i].Key == c.keyField {
+				key = fieldValueString(fields[i])
+			}
+		}
+	}
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	if n := len(data); n > 0 && data[n-1] == '\n' && !c.binary {
+		data = data[:n-1]
+	}
+	c.batcher.add(remoteRecord{Entry: entry, Key: key, Data: append([]byte(nil), data...)})
+	buf.Free()
+	return nil
+}
+
+func (c *remoteCore) Sync() error {
+	return nil
+}
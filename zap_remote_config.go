@@ -0,0 +1,148 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// remoteConfigMaxBackoff 远程配置监听失败后重试的最长间隔
+const remoteConfigMaxBackoff = 30 * time.Second
+
+// RemoteConfigSource 远程配置中心（etcd、Consul 等）的配置键监听
+// Watch 先读取一次配置键的当前值，之后在值变化时调用 onChange，ctx 取消或连接出错时返回；
+// 值为与配置文件相同格式的 YAML/JSON 文档。etcd 可以用 clientv3 的 Get 和 Watch 实现，Consul 可以使用 NewConsulConfigSource
+type RemoteConfigSource interface {
+	Watch(ctx context.Context, key string, onChange func(value []byte)) error
+}
+
+// WatchRemoteConfig 监听远程配置中心的配置键，返回用于停止监听的函数
+// 配置键的值变化后在所有监听该键的分服上应用日志级别、采样、路由等可以热更新的配置项，规则与 WatchConfig 相同；
+// Watch 返回错误时按指数退避（最长 30 秒）重新监听，值无效时输出到 stderr 并保持当前配置
+func WatchRemoteConfig(source RemoteConfigSource, key string) (stop func(), err error) {
+	if source == nil {
+		return nil, errors.New("mlog: 远程配置源不能为空")
+	}
+	if key == "" {
+		return nil, errors.New("mlog: 远程配置键不能为空")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		backoff := time.Second
+		apply := func(value []byte) {
+			backoff = time.Second
+			if err := reloadWatchedConfig(value); err != nil {
+				fmt.Fprintf(os.Stderr, "[mlog] 应用远程配置失败 [%s]: %v，保持当前配置\n", key, err)
+			}
+		}
+		for {
+			err := source.Watch(ctx, key, apply)
+			if ctx.Err() != nil {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "[mlog] 监听远程配置失败 [%s]: %v，%v 后重试\n", key, err, backoff)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			backoff = min(backoff*2, remoteConfigMaxBackoff)
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}, nil
+}
+
+// consulConfigSource 通过 Consul KV 的 HTTP 阻塞查询监听配置键
+type consulConfigSource struct {
+	address string
+	token   string
+	wait    time.Duration
+	client  *http.Client
+}
+
+// NewConsulConfigSource 创建 Consul KV 配置源，address 如 http://127.0.0.1:8500，token 为空时不带 ACL 令牌
+func NewConsulConfigSource(address, token string) RemoteConfigSource {
+	const wait = 5 * time.Minute
+	return &consulConfigSource{
+		address: strings.TrimRight(address, "/"),
+		token:   token,
+		wait:    wait,
+		client:  &http.Client{Timeout: wait + wait/16 + 10*time.Second},
+	}
+}
+
+func (s *consulConfigSource) Watch(ctx context.Context, key string, onChange func(value []byte)) error {
+	var (
+		index uint64
+		last  []byte
+		seen  bool
+	)
+	for {
+		value, found, next, err := s.get(ctx, key, index)
+		if err != nil {
+			return err
+		}
+		if found && (!seen || !bytes.Equal(value, last)) {
+			onChange(value)
+			last, seen = value, true
+		}
+		// 索引变小表示 Consul 重建了数据，按文档重新读取一次；索引为 0 时按 1 阻塞查询，避免不停地立即返回
+		switch {
+		case next < index:
+			next = 0
+		case next == 0:
+			next = 1
+		}
+		index = next
+	}
+}
+
+// get 阻塞查询配置键，index 为 0 时立即返回当前值
+func (s *consulConfigSource) get(ctx context.Context, key string, index uint64) (value []byte, found bool, next uint64, err error) {
+	query := url.Values{"raw": {"true"}}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", fmt.Sprintf("%ds", int(s.wait/time.Second)))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		s.address+"/v1/kv/"+strings.TrimLeft(key, "/")+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, false, 0, err
+	}
+	if s.token != "" {
+		req.Header.Set("X-Consul-Token", s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, false, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, false, 0, fmt.Errorf("Consul 返回 %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	next, err = strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if err != nil {
+		return nil, false, 0, fmt.Errorf("Consul 响应缺少 X-Consul-Index")
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, next, nil
+	}
+	value, err = io.ReadAll(resp.Body)
+	return value, err == nil, next, err
+}
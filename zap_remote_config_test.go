@@ -0,0 +1,118 @@
+package mlog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// chanConfigSource 从通道读取配置值的测试配置源
+type chanConfigSource chan []byte
+
+func (s chanConfigSource) Watch(ctx context.Context, key string, onChange func(value []byte)) error {
+	for {
+		select {
+		case value := <-s:
+			onChange(value)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TestWatchRemoteConfig 测试远程配置只应用出现的配置项，路由变化时直接替换路由表
+func TestWatchRemoteConfig(t *testing.T) {
+	Close()
+	config := ZapConfig{Level: "info", Director: t.TempDir(), MaxSize: 10, SingleFile: true,
+		Sampling: map[string]SamplingConfig{"debug": {Initial: 10, Thereafter: 10}}}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	if _, err := WatchRemoteConfig(nil, "mlog"); err == nil {
+		t.Fatal("配置源为空时应返回错误")
+	}
+	source := make(chanConfigSource)
+	stop, err := WatchRemoteConfig(source, "mlog/gate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	source <- []byte(`{"level": "warn"}`)
+	source <- []byte(`routes: [{levels: "error+", outputs: [file]}]`)
+	source <- []byte(`routes: [{levels: "bogus", outputs: [file]}]`)
+	stop()
+
+	got := GetConfig()
+	if got.Level != "warn" || len(got.Sampling) != 1 {
+		t.Fatalf("只应修改出现的配置项: level=%s sampling=%v", got.Level, got.Sampling)
+	}
+	if len(got.Routes) != 1 || got.Routes[0].Levels != "error+" || activeRoutes.Load() == nil {
+		t.Fatalf("路由应按远程配置生效，无效的路由应忽略: %v", got.Routes)
+	}
+}
+
+// TestConsulConfigSource 测试 Consul 阻塞查询：首次读取当前值，索引变化后读取新值，值不变时不通知
+func TestConsulConfigSource(t *testing.T) {
+	changed := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/mlog/gate" || r.Header.Get("X-Consul-Token") != "acl" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Query().Get("index") {
+		case "":
+			w.Header().Set("X-Consul-Index", "10")
+			w.Write([]byte("level: warn"))
+		case "10":
+			select {
+			case <-changed:
+			case <-r.Context().Done():
+				return
+			}
+			w.Header().Set("X-Consul-Index", "11")
+			w.Write([]byte("level: debug"))
+		case "11":
+			// 索引不变时原样返回，模拟阻塞查询超时
+			w.Header().Set("X-Consul-Index", "12")
+			w.Write([]byte("level: debug"))
+		default:
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	values := make(chan string, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewConsulConfigSource(srv.URL+"/", "acl").Watch(ctx, "mlog/gate", func(value []byte) { values <- string(value) })
+	}()
+
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-values:
+			if got != want {
+				t.Fatalf("配置值应为 %q: %q", want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("等待配置值 %q 超时", want)
+		}
+	}
+	expect("level: warn")
+	close(changed)
+	expect("level: debug")
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+	if len(values) != 0 {
+		t.Fatalf("值不变时不应通知: %q", <-values)
+	}
+
+	if err := NewConsulConfigSource(srv.URL, "wrong").Watch(context.Background(), "mlog/gate", func([]byte) {}); err == nil {
+		t.Fatal("Consul 返回错误状态时应返回错误")
+	}
+}
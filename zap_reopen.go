@@ -0,0 +1,86 @@
+package mlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// InstallReopenHandler 安装重新打开日志文件的信号处理，返回用于卸载的函数
+// 收到指定信号（默认 SIGHUP）时关闭并重新打开所有日志文件，配合外部 logrotate 的 create 方式使用：
+// logrotate 重命名日志文件后在 postrotate 中发送 kill -HUP，之后的日志写入新文件，不会继续写入已被重命名或删除的文件
+func InstallReopenHandler(signals ...os.Signal) (uninstall func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+	signal.Notify(ch, signals...)
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				if err := ReopenLogFiles(); err != nil {
+					fmt.Fprintf(os.Stderr, "[mlog] 重新打开日志文件失败: %v\n", err)
+				}
+				InfoW("[ReopenHandler] 收到信号，已重新打开日志文件", zap.String("signal", sig.String()))
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		close(stop)
+	}
+}
+
+// ReopenLogFiles 关闭并重新打开所有日志文件，包括 business/folder/directory 特殊目录中缓存的文件
+// 文件按原路径重新打开，原文件已被移走时创建新文件
+func ReopenLogFiles() error {
+	coreMutex.RLock()
+	defer coreMutex.RUnlock()
+
+	var errs []error
+	for _, core := range zapCores {
+		if core != nil {
+			errs = append(errs, core.reopenFiles())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reopenFiles 重新打开主日志文件和所有特殊目录日志文件
+func (z *ZapCore) reopenFiles() error {
+	var errs []error
+	if z.fileSyncer != nil {
+		errs = append(errs, z.fileSyncer.reopen())
+	}
+	z.specialLoggersMutex.RLock()
+	for _, syncer := range z.specialSyncers {
+		errs = append(errs, syncer.reopen())
+	}
+	z.specialLoggersMutex.RUnlock()
+	return errors.Join(errs...)
+}
+
+// reopen 关闭当前文件，下一次写入时按相同路径重新打开
+func (f *fileWriteSyncer) reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	err := f.logger.Close()
+	f.logger = newLumberjackLogger(f.logger.Filename)
+	f.nextRotate.Store(0)
+	f.linked.Store(false)
+	f.sizeKnown.Store(false)
+	f.headerPending.Store(true)
+	return err
+}
@@ -0,0 +1,49 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestReopenLogFiles 测试外部重命名日志文件后重新打开，之后的日志写入新文件
+func TestReopenLogFiles(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	InfoW("轮转前")
+	InfoW("轮转前", zap.String("folder", "net"))
+	mainFile := filepath.Join(dir, "2", "gate", "info.log")
+	folderFile := filepath.Join(dir, "2", "gate", "net", "info.log")
+	for _, file := range []string{mainFile, folderFile} {
+		if err := os.Rename(file, file+".1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ReopenLogFiles(); err != nil {
+		t.Fatal(err)
+	}
+	InfoW("轮转后")
+	InfoW("轮转后", zap.String("folder", "net"))
+
+	for _, file := range []string{mainFile, folderFile} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("未重新创建日志文件: %v", err)
+		}
+		if !strings.Contains(string(data), "轮转后") || strings.Contains(string(data), "轮转前") {
+			t.Fatalf("%s 内容错误: %s", file, data)
+		}
+		old, _ := os.ReadFile(file + ".1")
+		if strings.Contains(string(old), "轮转后") {
+			t.Fatalf("重新打开后仍写入旧文件: %s", old)
+		}
+	}
+}
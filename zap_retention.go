@@ -0,0 +1,184 @@
+package mlog
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 日志文件的删除原因
+const (
+	CleanupReasonAge   = "age"   // 超过 RetentionDay
+	CleanupReasonCount = "count" // 同一个日志文件的备份数超过 MaxBackups
+	CleanupReasonSize  = "size"  // 日志目录总大小超过 MaxTotalSizeMB
+)
+
+// CleanupCandidate 保留策略将要删除的日志文件
+type CleanupCandidate struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Reason  string    `json:"reason"` // CleanupReasonAge、CleanupReasonCount 或 CleanupReasonSize
+}
+
+// PreviewCleanup 按当前配置的保留策略（RetentionDay、MaxBackups、MaxTotalSizeMB、CleanStaleDirs）计算日志目录中
+// 将要删除的文件，只返回结果不删除任何文件，用于在启用删除前确认策略是否符合预期。
+// 结果按修改时间从早到晚排序，正在写入的文件不会出现在结果中
+func PreviewCleanup() ([]CleanupCandidate, error) {
+	globalMutex.RLock()
+	policy := newRetentionPolicy()
+	root := currentDirector()
+	globalMutex.RUnlock()
+
+	if _, err := os.Stat(root); err != nil {
+		return nil, err
+	}
+	candidates, _ := policy.plan(root, time.Now())
+	return candidates, nil
+}
+
+// retentionPolicy 日志文件保留策略，统一计算按时间、数量和总大小需要删除的文件
+// 后台的总大小清理（logJanitor）和过期文件清理（logDirCleaner）都按它删除文件，PreviewCleanup 按它预览
+type retentionPolicy struct {
+	maxAge     time.Duration // 轮转文件按文件名中的时间保留的时长，0 表示不按时间清理
+	maxBackups int           // 同一个日志文件保留的备份数，0 表示不限制
+	maxBytes   int64         // 日志目录总大小上限，0 表示不限制
+	// stale 为 true 时不再写入的日志文件（包括已下线服务的 .log 文件和文件名模板生成的文件）按修改时间清理
+	stale bool
+	// compressed 为 true 时未压缩的备份文件即将被压缩，不删除
+	compressed bool
+	dated      *regexp.Regexp // 文件名模板带日期时匹配旧日期的文件，未配置时为 nil
+	named      *regexp.Regexp // 文件名模板生成的文件，未配置时为 nil
+}
+
+// retentionFile 计算保留策略时的候选文件，rotated 表示轮转文件（lumberjack 备份或旧日期的文件）
+type retentionFile struct {
+	CleanupCandidate
+	rotated bool
+}
+
+// newRetentionPolicy 按全局配置创建保留策略
+func newRetentionPolicy() retentionPolicy {
+	p := retentionPolicy{
+		maxBackups: zapConfig.MaxBackups,
+		maxBytes:   int64(zapConfig.MaxTotalSizeMB) * 1024 * 1024,
+		stale:      zapConfig.CleanStaleDirs,
+		compressed: zapConfig.EnableCompress,
+	}
+	if zapConfig.RetentionDay > 0 {
+		p.maxAge = time.Duration(zapConfig.RetentionDay) * 24 * time.Hour
+	}
+	if fp, err := parseFilePattern(zapConfig.FilePattern); err == nil && fp != nil {
+		p.named = fp.matcher()
+		if fp.hasDate {
+			p.dated = p.named
+		}
+	}
+	return p
+}
+
+// plan 计算 root 目录树中需要删除的文件，按修改时间从早到晚排序，同时返回删除后目录的总大小
+// 先按时间和数量选出过期的备份，再在剩余的轮转文件中从最早的开始选出超过总大小上限的部分
+func (p retentionPolicy) plan(root string, now time.Time) ([]CleanupCandidate, int64) {
+	active := activeLogFiles()
+	var (
+		total      int64
+		candidates = make(map[string]*retentionFile) // 可以删除的文件
+		groups     = make(map[string][]backupFile)   // 按 目录/文件名/扩展名 分组的备份，同一个日志文件的备份一起计数
+	)
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		total += info.Size()
+		name := d.Name()
+		if active[filepath.Clean(path)] || strings.HasSuffix(name, ".tmp") {
+			return nil
+		}
+		c := &retentionFile{CleanupCandidate: CleanupCandidate{Path: path, Size: info.Size(), ModTime: info.ModTime()}, rotated: true}
+		if m := lumberjackBackupPattern.FindStringSubmatch(name); m != nil {
+			if t, err := time.ParseInLocation(lumberjackBackupTimeFormat, m[2], time.Local); err == nil {
+				key := filepath.Join(filepath.Dir(path), m[1]+m[3])
+				groups[key] = append(groups[key], backupFile{path: path, time: t})
+			}
+			// 等待压缩的备份文件压缩后再删除
+			if !p.compressed || m[4] != "" {
+				candidates[path] = c
+			}
+		} else if p.dated != nil && p.dated.MatchString(name) {
+			candidates[path] = c
+		} else if p.stale && (strings.HasSuffix(name, ".log") || p.named != nil && p.named.MatchString(name)) {
+			c.rotated = false
+			candidates[path] = c
+		}
+		return nil
+	})
+
+	for _, backups := range groups {
+		for i, reason := range staleBackups(backups, p.maxBackups, p.maxAge, now) {
+			if c := candidates[backups[i].path]; c != nil && reason != "" {
+				c.Reason = reason
+			}
+		}
+	}
+	var planned, rest []CleanupCandidate
+	for _, c := range candidates {
+		if c.Reason == "" && p.stale && p.maxAge > 0 && now.Sub(c.ModTime) >= p.maxAge {
+			c.Reason = CleanupReasonAge
+		}
+		if c.Reason != "" {
+			planned = append(planned, c.CleanupCandidate)
+			total -= c.Size
+		} else if c.rotated {
+			// 只有轮转文件参与总大小清理，不再写入的 .log 文件只按时间清理
+			rest = append(rest, c.CleanupCandidate)
+		}
+	}
+	if p.maxBytes > 0 && total > p.maxBytes {
+		sortByModTime(rest)
+		for _, c := range rest {
+			if total <= p.maxBytes {
+				break
+			}
+			c.Reason = CleanupReasonSize
+			planned = append(planned, c)
+			total -= c.Size
+		}
+	}
+	sortByModTime(planned)
+	return planned, total
+}
+
+// staleBackups 按 MaxBackups 和 MaxAge 选出同一个日志文件需要删除的备份，backups 按时间从新到旧重新排序，
+// 返回与之对应的删除原因，空字符串表示保留
+func staleBackups(backups []backupFile, maxBackups int, maxAge time.Duration, now time.Time) []string {
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+	cutoff := now.Add(-maxAge)
+	reasons := make([]string, len(backups))
+	for i, b := range backups {
+		switch {
+		case maxAge > 0 && b.time.Before(cutoff):
+			reasons[i] = CleanupReasonAge
+		case maxBackups > 0 && i >= maxBackups:
+			reasons[i] = CleanupReasonCount
+		}
+	}
+	return reasons
+}
+
+// sortByModTime 按修改时间从早到晚排序
+func sortByModTime(files []CleanupCandidate) {
+	sort.Slice(files, func(a, b int) bool {
+		return files[a].ModTime.Before(files[b].ModTime)
+	})
+}
@@ -0,0 +1,64 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPreviewCleanup 测试按时间、数量和总大小计算待删除文件，预览不删除任何文件
+func TestPreviewCleanup(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, RetentionDay: 7, MaxBackups: 2, MaxTotalSizeMB: 1}
+	InitialZap("gate", 2, "info", &config)
+	// 关闭后台清理，只保留配置
+	Close()
+
+	now := time.Now()
+	write := func(rel string, size int, backup time.Time) string {
+		name := strings.Replace(rel, "{time}", backup.Format(lumberjackBackupTimeFormat), 1)
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, backup, backup)
+		return path
+	}
+	const kb = 1024
+	expired := write("3/login/info-{time}.log", kb, now.Add(-10*24*time.Hour))
+	extra := write("3/login/info-{time}.log", kb, now.Add(-3*time.Hour))
+	big := write("3/login/net/info-{time}.log", 900*kb, now.Add(-150*time.Minute))
+	keep := []string{
+		write("3/login/info-{time}.log", kb, now.Add(-2*time.Hour)),
+		write("3/login/info-{time}.log", kb, now.Add(-time.Hour)),
+		write("3/login/net/info-{time}.log", 200*kb, now.Add(-time.Hour)),
+		write("3/login/notes.txt", 10*kb, now.Add(-30*24*time.Hour)),
+	}
+
+	candidates, err := PreviewCleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ path, reason string }{
+		{expired, CleanupReasonAge},
+		{extra, CleanupReasonCount},
+		{big, CleanupReasonSize},
+	}
+	if len(candidates) != len(want) {
+		t.Fatalf("待删除文件数错误: %+v", candidates)
+	}
+	for i, w := range want {
+		if candidates[i].Path != w.path || candidates[i].Reason != w.reason {
+			t.Fatalf("第 %d 个待删除文件错误: %+v", i, candidates[i])
+		}
+	}
+	for _, path := range append(keep, expired, extra, big) {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("预览不应删除文件 %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package mlog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// cacheLinePad 缓存行填充，避免生产者和消费者的位置变量发生伪共享
+type cacheLinePad [64]byte
+
+// ringSlot 环形缓冲区槽位
+// seq 用于生产者和消费者之间的同步：
+//   - seq == pos     槽位空闲，位置为 pos 的生产者可以写入
+//   - seq == pos + 1 槽位已写入，消费者可以读取
+type ringSlot struct {
+	seq   uint64
+	entry AsyncLogEntry
+}
+
+// asyncRingBuffer 有界无锁多生产者单消费者（MPSC）环形缓冲区
+// 基于 Vyukov 有界队列算法，生产者通过 CAS 争抢写入位置，消费者单线程读取。
+// 槽位在创建时一次性分配并循环复用，入队不再产生额外的条目分配。
+type asyncRingBuffer struct {
+	_     cacheLinePad
+	tail  uint64 // 下一个写入位置（多生产者竞争）
+	_     cacheLinePad
+	head  uint64 // 下一个读取位置（仅消费者修改）
+	_     cacheLinePad
+	mask  uint64
+	slots []ringSlot
+}
+
+// newAsyncRingBuffer 创建环形缓冲区，容量向上取整为 2 的幂
+func newAsyncRingBuffer(capacity int) *asyncRingBuffer {
+	size := uint64(1)
+	for size < uint64(capacity) {
+		size <<= 1
+	}
+	r := &asyncRingBuffer{
+		mask:  size - 1,
+		slots: make([]ringSlot, size),
+	}
+	for i := range r.slots {
+		r.slots[i].seq = uint64(i)
+	}
+	return r
+}
+
+// tryPush 尝试写入一个条目，缓冲区已满时返回 false
+func (r *asyncRingBuffer) tryPush(entry *AsyncLogEntry) bool {
+	pos := atomic.LoadUint64(&r.tail)
+	for {
+		slot := &r.slots[pos&r.mask]
+		seq := atomic.LoadUint64(&slot.seq)
+		diff := int64(seq) - int64(pos)
+		switch {
+		case diff == 0:
+			// 槽位空闲，争抢写入位置
+			if atomic.CompareAndSwapUint64(&r.tail, pos, pos+1) {
+				slot.entry = *entry
+				atomic.StoreUint64(&slot.seq, pos+1)
+				return true
+			}
+			pos = atomic.LoadUint64(&r.tail)
+		case diff < 0:
+			// 槽位仍未被消费者读取，缓冲区已满
+			return false
+		default:
+			// 其他生产者已经抢占了该位置，重新读取写入位置
+			pos = atomic.LoadUint64(&r.tail)
+		}
+	}
+}
+
+// tryPushBatch 通过一次 CAS 连续占用多个槽位并写入一批条目
+// 条目数不能超过缓冲区容量，剩余空间不足时返回 false 且不写入任何条目
+func (r *asyncRingBuffer) tryPushBatch(entries []AsyncLogEntry) bool {
+	n := uint64(len(entries))
+	if n == 0 {
+		return true
+	}
+	if n > r.mask+1 {
+		return false
+	}
+	pos := atomic.LoadUint64(&r.tail)
+	for {
+		// 消费者按顺序读取，批次中最后一个槽位空闲即说明整批槽位都已空闲
+		last := pos + n - 1
+		seq := atomic.LoadUint64(&r.slots[last&r.mask].seq)
+		diff := int64(seq) - int64(last)
+		switch {
+		case diff == 0:
+			if atomic.CompareAndSwapUint64(&r.tail, pos, pos+n) {
+				for i := uint64(0); i < n; i++ {
+					slot := &r.slots[(pos+i)&r.mask]
+					slot.entry = entries[i]
+					atomic.StoreUint64(&slot.seq, pos+i+1)
+				}
+				return true
+			}
+			pos = atomic.LoadUint64(&r.tail)
+		case diff < 0:
+			return false
+		default:
+			pos = atomic.LoadUint64(&r.tail)
+		}
+	}
+}
+
+// peek 返回队首条目的指针但不取出，缓冲区为空时返回 nil
+// 只能由唯一的消费者 goroutine 调用，返回的指针在下一次 tryPop 之前有效
+func (r *asyncRingBuffer) peek() *AsyncLogEntry {
+	pos := r.head
+	slot := &r.slots[pos&r.mask]
+	if atomic.LoadUint64(&slot.seq) != pos+1 {
+		return nil
+	}
+	return &slot.entry
+}
+
+// tryPop 尝试读取一个条目，缓冲区为空时返回 false
+// 只能由唯一的消费者 goroutine 调用
+func (r *asyncRingBuffer) tryPop(out *AsyncLogEntry) bool {
+	pos := r.head
+	slot := &r.slots[pos&r.mask]
+	if atomic.LoadUint64(&slot.seq) != pos+1 {
+		// 为空，或者生产者已经占位但尚未写完
+		return false
+	}
+	*out = slot.entry
+	// 清空槽位，避免已处理的消息和字段被缓冲区长期引用
+	slot.entry = AsyncLogEntry{}
+	atomic.StoreUint64(&slot.seq, pos+r.mask+1)
+	atomic.StoreUint64(&r.head, pos+1)
+	return true
+}
+
+// len 返回当前缓冲区中的条目数（近似值）
+func (r *asyncRingBuffer) len() int {
+	tail := atomic.LoadUint64(&r.tail)
+	head := atomic.LoadUint64(&r.head)
+	if tail < head {
+		return 0
+	}
+	return int(tail - head)
+}
+
+// capacity 返回缓冲区容量
+func (r *asyncRingBuffer) capacity() int {
+	return len(r.slots)
+}
+
+// asyncQueue 异步日志队列：环形缓冲区及其独立的丢弃策略和统计
+type asyncQueue struct {
+	name       string
+	ring       *asyncRingBuffer
+	dropOnFull bool
+
+	// 队列统计（原子操作）
+	enqueued      uint64 // 累计入队条目数
+	processed     uint64 // 累计处理条目数
+	dropped       uint64 // 累计丢弃条目数
+	highWatermark int64  // 队列深度历史最高值
+	sampling      int32  // 是否正处于过载采样状态
+	sampledOut    uint64 // 累计因过载采样被跳过的条目数
+
+	saturatedSince int64  // 队列开始持续饱和的时间（UnixNano），未饱和时为 0
+	syncFallback   int32  // 是否正处于饱和同步写入状态
+	syncWrites     uint64 // 累计因队列饱和而同步写入的条目数
+
+	// 队列满且不丢弃时，生产者阻塞等待消费者腾出空位
+	spaceWaiters int32         // 正在等待空位的生产者数量
+	spaceMu      sync.Mutex    // 保护 space
+	space        chan struct{} // 消费者取出条目后关闭，唤醒所有等待的生产者
+}
+
+// newAsyncQueue 创建异步日志队列
+func newAsyncQueue(name string, bufferSize int, dropOnFull bool) *asyncQueue {
+	return &asyncQueue{
+		name:       name,
+		ring:       newAsyncRingBuffer(bufferSize),
+		dropOnFull: dropOnFull,
+	}
+}
+
+// updateHighWatermark 更新队列深度的历史最高值
+func (q *asyncQueue) updateHighWatermark() {
+	depth := int64(q.ring.len())
+	for {
+		current := atomic.LoadInt64(&q.highWatermark)
+		if depth <= current || atomic.CompareAndSwapInt64(&q.highWatermark, current, depth) {
+			return
+		}
+	}
+}
+
+// waitSpace 阻塞等待队列腾出空位，直到 push 成功；done 关闭时放弃并返回 false
+// 先登记等待再重试 push，消费者在登记之后取出的条目一定会唤醒本次等待，不会错过信号
+func (q *asyncQueue) waitSpace(push func() bool, done <-chan struct{}) bool {
+	atomic.AddInt32(&q.spaceWaiters, 1)
+	defer atomic.AddInt32(&q.spaceWaiters, -1)
+	for {
+		q.spaceMu.Lock()
+		if q.space == nil {
+			q.space = make(chan struct{})
+		}
+		space := q.space
+		q.spaceMu.Unlock()
+
+		if push() {
+			return true
+		}
+		select {
+		case <-space:
+		case <-done:
+			return false
+		}
+	}
+}
+
+// signalSpace 消费者取出条目后唤醒等待空位的生产者，没有等待者时只有一次原子读
+func (q *asyncQueue) signalSpace() {
+	if atomic.LoadInt32(&q.spaceWaiters) == 0 {
+		return
+	}
+	q.spaceMu.Lock()
+	if q.space != nil {
+		close(q.space)
+		q.space = nil
+	}
+	q.spaceMu.Unlock()
+}
+
+// stats 获取队列统计信息
+func (q *asyncQueue) stats() QueueStats {
+	return QueueStats{
+		Depth:         q.ring.len(),
+		Capacity:      q.ring.capacity(),
+		HighWatermark: int(atomic.LoadInt64(&q.highWatermark)),
+		Enqueued:      atomic.LoadUint64(&q.enqueued),
+		Processed:     atomic.LoadUint64(&q.processed),
+		Dropped:       atomic.LoadUint64(&q.dropped),
+		Sampling:      atomic.LoadInt32(&q.sampling) == 1,
+		SampledOut:    atomic.LoadUint64(&q.sampledOut),
+		SyncFallback:  atomic.LoadInt32(&q.syncFallback) == 1,
+		SyncWrites:    atomic.LoadUint64(&q.syncWrites),
+	}
+}
@@ -0,0 +1,250 @@
+package mlog
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestAsyncRingBufferMPSC 测试多生产者单消费者场景下条目不丢失且每个生产者内部有序
+func TestAsyncRingBufferMPSC(t *testing.T) {
+	ring := newAsyncRingBuffer(64)
+	producers := 8
+	perProducer := 10000
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				entry := AsyncLogEntry{Message: strconv.Itoa(id), Extras: []any{i}}
+				for !ring.tryPush(&entry) {
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+
+	next := make([]int, producers)
+	total := 0
+	var entry AsyncLogEntry
+	for total < producers*perProducer {
+		if !ring.tryPop(&entry) {
+			runtime.Gosched()
+			continue
+		}
+		id, _ := strconv.Atoi(entry.Message)
+		seq := entry.Extras[0].(int)
+		if seq != next[id] {
+			t.Fatalf("生产者 %d 的条目乱序: 期望 %d 实际 %d", id, next[id], seq)
+		}
+		next[id]++
+		total++
+	}
+	wg.Wait()
+
+	if ring.len() != 0 {
+		t.Errorf("全部消费后缓冲区应为空，实际剩余 %d", ring.len())
+	}
+}
+
+// TestAsyncRingBufferFull 测试缓冲区满时写入失败
+func TestAsyncRingBufferFull(t *testing.T) {
+	ring := newAsyncRingBuffer(3)
+	if ring.capacity() != 4 {
+		t.Fatalf("容量应向上取整为 4，实际 %d", ring.capacity())
+	}
+	entry := AsyncLogEntry{Message: "x"}
+	for i := 0; i < 4; i++ {
+		if !ring.tryPush(&entry) {
+			t.Fatalf("第 %d 次写入不应失败", i)
+		}
+	}
+	if ring.tryPush(&entry) {
+		t.Fatal("缓冲区已满时写入应失败")
+	}
+	var out AsyncLogEntry
+	if !ring.tryPop(&out) || out.Message != "x" {
+		t.Fatal("读取条目失败")
+	}
+	if !ring.tryPush(&entry) {
+		t.Fatal("读取后应可以继续写入")
+	}
+}
+
+// BenchmarkAsyncRingBuffer 测试环形缓冲区在多生产者下的入队性能
+func BenchmarkAsyncRingBuffer(b *testing.B) {
+	ring := newAsyncRingBuffer(1 << 16)
+	done := make(chan struct{})
+	go func() {
+		var entry AsyncLogEntry
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				if !ring.tryPop(&entry) {
+					runtime.Gosched()
+				}
+			}
+		}
+	}()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		entry := AsyncLogEntry{Message: "benchmark"}
+		for pb.Next() {
+			for !ring.tryPush(&entry) {
+				runtime.Gosched()
+			}
+		}
+	})
+	close(done)
+}
+
+// TestAsyncRingBufferBatch 测试批量写入占用连续槽位且空间不足时整批失败
+func TestAsyncRingBufferBatch(t *testing.T) {
+	ring := newAsyncRingBuffer(8)
+	batch := make([]AsyncLogEntry, 5)
+	for i := range batch {
+		batch[i].Message = strconv.Itoa(i)
+	}
+	if !ring.tryPushBatch(batch) {
+		t.Fatal("批量写入不应失败")
+	}
+	if ring.tryPushBatch(batch) {
+		t.Fatal("剩余空间不足时批量写入应失败")
+	}
+	var out AsyncLogEntry
+	for i := 0; i < 5; i++ {
+		if !ring.tryPop(&out) || out.Message != strconv.Itoa(i) {
+			t.Fatalf("第 %d 条读取结果错误: %q", i, out.Message)
+		}
+	}
+	if !ring.tryPushBatch(batch) {
+		t.Fatal("读取后批量写入应成功")
+	}
+}
+
+// TestAsyncLevelQueues 测试按级别分片的队列各自独立地满载丢弃，并按时间戳顺序合并读取
+func TestAsyncLevelQueues(t *testing.T) {
+	al := &AsyncLogger{notify: make(chan struct{}, 1), done: make(chan struct{})}
+	al.setupQueues(16, false, map[string]AsyncQueueConfig{
+		"debug": {BufferSize: 2, DropOnFull: true},
+		"bogus": {BufferSize: 2},
+	})
+	if len(al.queues) != 2 {
+		t.Fatalf("期望 2 个队列，实际 %d", len(al.queues))
+	}
+
+	base := time.Now()
+	for i := 0; i < 4; i++ {
+		al.enqueue(&AsyncLogEntry{Level: zapcore.DebugLevel, Message: "debug" + strconv.Itoa(i), Timestamp: base.Add(time.Duration(i*2) * time.Millisecond)})
+	}
+	al.enqueue(&AsyncLogEntry{Level: zapcore.ErrorLevel, Message: "error", Timestamp: base.Add(time.Millisecond)})
+
+	stats := al.LevelQueueStats()
+	if stats["debug"].Dropped != 2 || stats["debug"].Enqueued != 2 {
+		t.Fatalf("debug 队列统计错误: %+v", stats["debug"])
+	}
+	if stats["default"].Dropped != 0 || stats["default"].Enqueued != 1 {
+		t.Fatalf("默认队列统计错误: %+v", stats["default"])
+	}
+
+	want := []string{"debug0", "error", "debug1"}
+	var out AsyncLogEntry
+	for i, msg := range want {
+		if !al.tryPop(&out) || out.Message != msg {
+			t.Fatalf("第 %d 条期望 %q，实际 %q", i, msg, out.Message)
+		}
+	}
+	if al.tryPop(&out) {
+		t.Fatal("所有队列应已为空")
+	}
+	if total := al.QueueStats(); total.Processed != 3 || total.Dropped != 2 {
+		t.Fatalf("汇总统计错误: %+v", total)
+	}
+}
+
+// TestAsyncEnqueueBlocksWhenFull 测试队列满且不丢弃时生产者阻塞等待而不是自旋，消费者取出条目后被唤醒
+func TestAsyncEnqueueBlocksWhenFull(t *testing.T) {
+	al := &AsyncLogger{notify: make(chan struct{}, 1), done: make(chan struct{})}
+	al.setupQueues(4, false, nil)
+	q := al.queues[0]
+	for i := 0; i < q.ring.capacity(); i++ {
+		entry := AsyncLogEntry{Level: zapcore.InfoLevel, Message: "fill"}
+		al.enqueue(&entry)
+	}
+
+	producers := 3
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry := AsyncLogEntry{Level: zapcore.InfoLevel, Message: "blocked"}
+			if !al.enqueue(&entry) {
+				t.Error("阻塞的生产者未能入队")
+			}
+		}()
+	}
+
+	// 所有生产者都应停在 waitSpace 的 select 上
+	deadline := time.Now().Add(time.Second)
+	for parkedProducers() != producers {
+		if time.Now().After(deadline) {
+			t.Fatalf("期望 %d 个生产者阻塞等待，实际 %d", producers, parkedProducers())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	var entry AsyncLogEntry
+	popped := 0
+	for popped < producers {
+		if al.tryPop(&entry) {
+			popped++
+		}
+		time.Sleep(time.Millisecond)
+	}
+	wg.Wait()
+	if stats := al.QueueStats(); stats.Enqueued != uint64(q.ring.capacity()+producers) || stats.Dropped != 0 {
+		t.Fatalf("统计不符: %+v", stats)
+	}
+
+	// 关闭时阻塞的生产者放弃入队并计为丢弃
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		entry := AsyncLogEntry{Level: zapcore.InfoLevel, Message: "closing"}
+		if al.enqueue(&entry) {
+			t.Error("关闭时不应入队成功")
+		}
+	}()
+	for parkedProducers() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(al.done)
+	wg.Wait()
+	if dropped := al.QueueStats().Dropped; dropped != 1 {
+		t.Fatalf("期望丢弃 1 条，实际 %d", dropped)
+	}
+}
+
+// parkedProducers 统计阻塞在 waitSpace 中的 goroutine 数量
+func parkedProducers() int {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	parked := 0
+	for _, g := range strings.Split(string(buf), "\n\n") {
+		if strings.Contains(g, "[select]") && strings.Contains(g, "asyncQueue).waitSpace") {
+			parked++
+		}
+	}
+	return parked
+}
@@ -0,0 +1,87 @@
+package mlog
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// parseRotateInterval 解析按时间轮转的间隔，空字符串表示只按大小轮转
+// 间隔需要能整除 24h（如 1h、6h、24h），这样每天的轮转时刻固定在整点
+func parseRotateInterval(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("无效的轮转间隔 %q: %w", s, err)
+	}
+	if d < time.Minute || (24*time.Hour)%d != 0 {
+		return 0, fmt.Errorf("轮转间隔 %q 需要不小于 1m 且能整除 24h", s)
+	}
+	return d, nil
+}
+
+// rotateInterval 按时间轮转的间隔，未配置或配置无效时返回 0
+func (c *ZapConfig) rotateInterval() time.Duration {
+	d, _ := parseRotateInterval(c.RotateInterval)
+	return d
+}
+
+// rotatePeriodStart 返回 now 所在轮转周期的开始时刻，周期从本地时间零点起算
+func rotatePeriodStart(now time.Time, interval time.Duration) time.Time {
+	y, m, d := now.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	return midnight.Add(now.Sub(midnight) / interval * interval)
+}
+
+// rotateNextBoundary 返回 now 之后的下一个轮转时刻，最晚为次日零点（夏令时切换日的长度不是 24h）
+func rotateNextBoundary(now time.Time, interval time.Duration) time.Time {
+	y, m, d := now.Date()
+	nextMidnight := time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+	next := rotatePeriodStart(now, interval).Add(interval)
+	if next.After(nextMidnight) {
+		return nextMidnight
+	}
+	return next
+}
+
+// rotateIfDue 到达轮转时刻后在下一次写入前轮转文件
+// 首次写入时如果已有文件的修改时间早于当前周期，先轮转，保证每个文件只包含一个周期的日志
+func (f *fileWriteSyncer) rotateIfDue(now time.Time) {
+	if f.interval <= 0 {
+		return
+	}
+	next := f.nextRotate.Load()
+	if next != 0 && now.UnixNano() < next {
+		return
+	}
+	f.rotateMu.Lock()
+	defer f.rotateMu.Unlock()
+	next = f.nextRotate.Load()
+	if next != 0 && now.UnixNano() < next {
+		return
+	}
+	start := rotatePeriodStart(now, f.interval)
+	due := next != 0
+	if f.shared != nil {
+		// 多个进程同时跨过轮转时刻，只有第一个进程轮转，之后的进程看到的是本周期内写入的新文件
+		if err := f.shared.lock(); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 按时间轮转日志文件失败 [%s]: %v\n", f.logger.Filename, err)
+			return
+		}
+		defer f.shared.unlock()
+		due = false
+	}
+	if !due {
+		if fi, err := os.Stat(f.logger.Filename); err == nil && fi.Size() > 0 && fi.ModTime().Before(start) {
+			due = true
+		}
+	}
+	if due {
+		if err := f.rotateLocked(); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 按时间轮转日志文件失败 [%s]: %v\n", f.logger.Filename, err)
+		}
+	}
+	f.nextRotate.Store(rotateNextBoundary(now, f.interval).UnixNano())
+}
@@ -0,0 +1,152 @@
+package mlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultLumberjackMaxSizeMB lumberjack 在 MaxSize 为 0 时使用的文件大小上限
+const defaultLumberjackMaxSizeMB = 100
+
+// RotateHook 日志文件轮转回调
+type RotateHook func(oldPath, newPath string)
+
+var (
+	rotateHooks      []RotateHook
+	rotateHooksMutex sync.RWMutex
+	rotateHookCount  atomic.Int32
+)
+
+// OnRotate 注册日志文件轮转回调，可用于上传、计算校验和或通知索引服务
+// 按大小、按时间轮转以及文件名模板的日期变化都会触发，回调在后台协程中执行，不阻塞日志写入。
+// oldPath 为不再写入的文件（lumberjack 的备份文件，或日期变化前的文件），newPath 为之后写入的文件。
+// 启用压缩时备份文件会在后台被压缩并删除原文件，回调需要尽快处理，或改为处理加上 .gz/.zst 后缀的文件
+func OnRotate(hook RotateHook) {
+	if hook == nil {
+		return
+	}
+	rotateHooksMutex.Lock()
+	rotateHooks = append(rotateHooks, hook)
+	rotateHookCount.Store(int32(len(rotateHooks)))
+	rotateHooksMutex.Unlock()
+}
+
+// hasRotateHooks 是否注册了轮转回调或需要在轮转时记录校验和清单
+func hasRotateHooks() bool {
+	return rotateHookCount.Load() > 0 || manifestEnabled.Load()
+}
+
+// fireRotateHooks 在后台协程中依次调用所有轮转回调，回调 panic 不影响日志系统
+// 启用校验和清单时先记录 oldPath 的校验和，再调用回调，回调上传或删除文件不影响清单
+func fireRotateHooks(oldPath, newPath string) {
+	rotateHooksMutex.RLock()
+	hooks := append([]RotateHook(nil), rotateHooks...)
+	rotateHooksMutex.RUnlock()
+	manifest := manifestEnabled.Load()
+	if len(hooks) == 0 && !manifest {
+		return
+	}
+	go func() {
+		if manifest {
+			if err := recordManifest(oldPath); err != nil {
+				fmt.Fprintf(os.Stderr, "[mlog] 记录日志文件校验和失败 [%s]: %v\n", oldPath, err)
+			}
+		}
+		for _, hook := range hooks {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						fmt.Fprintf(os.Stderr, "[mlog] 轮转回调 panic [%s]: %v\n", oldPath, r)
+					}
+				}()
+				hook(oldPath, newPath)
+			}()
+		}
+	}()
+}
+
+// rotateLocked 轮转当前文件并触发回调，调用方需持有 f.mu 的读锁（flock 模式为写锁）和 f.rotateMu
+func (f *fileWriteSyncer) rotateLocked() error {
+	start := time.Now()
+	if err := f.logger.Rotate(); err != nil {
+		return err
+	}
+	f.size = 0
+	f.sizeKnown.Store(true)
+	f.headerPending.Store(true)
+	if f.shared != nil {
+		// lumberjack 轮转后新文件不是以追加方式打开的，换成新的 logger 在下一次写入时以追加方式重新打开
+		f.replaceLogger()
+	}
+	if hasRotateHooks() {
+		if backup := latestBackup(f.logger.Filename, start); backup != "" {
+			fireRotateHooks(backup, f.logger.Filename)
+		}
+	}
+	return nil
+}
+
+// writeTracked 注册了轮转回调时的写入：自行统计文件大小，在 lumberjack 按大小轮转之前主动轮转，
+// 这样才能得知轮转发生的时刻并触发回调
+func (f *fileWriteSyncer) writeTracked(p []byte) (int, error) {
+	f.rotateMu.Lock()
+	defer f.rotateMu.Unlock()
+	if !f.sizeKnown.Load() {
+		f.size = 0
+		if fi, err := os.Stat(f.logger.Filename); err == nil {
+			f.size = fi.Size()
+		}
+		f.sizeKnown.Store(true)
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.maxBytes() {
+		if err := f.rotateLocked(); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 轮转日志文件失败 [%s]: %v\n", f.logger.Filename, err)
+		}
+	}
+	n, err := f.writeFile(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// maxBytes 单个日志文件的大小上限（字节），与 lumberjack 的默认值一致
+func (f *fileWriteSyncer) maxBytes() int64 {
+	maxSize := int64(f.logger.MaxSize)
+	if maxSize <= 0 {
+		maxSize = defaultLumberjackMaxSizeMB
+	}
+	return maxSize * 1024 * 1024
+}
+
+// latestBackup 查找 lumberjack 在 since 之后为 filename 生成的最新备份文件，找不到时返回空字符串
+func latestBackup(filename string, since time.Time) string {
+	dir := filepath.Dir(filename)
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	since = since.Truncate(time.Millisecond)
+	var (
+		latest     string
+		latestTime time.Time
+	)
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		t, err := time.ParseInLocation(lumberjackBackupTimeFormat, name[len(prefix):len(name)-len(ext)], time.Local)
+		if err != nil || t.Before(since) || (latest != "" && !t.After(latestTime)) {
+			continue
+		}
+		latest, latestTime = filepath.Join(dir, name), t
+	}
+	return latest
+}
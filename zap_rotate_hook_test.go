@@ -0,0 +1,57 @@
+package mlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ai-mmo/lumberjack"
+)
+
+// TestOnRotate 测试按大小轮转时触发回调，回调收到备份文件和当前文件路径
+func TestOnRotate(t *testing.T) {
+	defer func() {
+		rotateHooksMutex.Lock()
+		rotateHooks = nil
+		rotateHookCount.Store(0)
+		rotateHooksMutex.Unlock()
+	}()
+	type event struct{ oldPath, newPath string }
+	events := make(chan event, 4)
+	OnRotate(func(oldPath, newPath string) {
+		events <- event{oldPath, newPath}
+	})
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "info.log")
+	f := &fileWriteSyncer{logger: &lumberjack.Logger{Filename: filename, MaxSize: 1, LocalTime: true}}
+	defer f.Close()
+
+	chunk := bytes.Repeat([]byte("x"), 600*1024)
+	f.Write(chunk)
+	f.Write([]byte("第二个文件\n"))
+	select {
+	case e := <-events:
+		t.Fatalf("未超过大小时不应轮转: %v", e)
+	default:
+	}
+	f.Write(chunk)
+
+	select {
+	case e := <-events:
+		if e.newPath != filename || !strings.HasPrefix(filepath.Base(e.oldPath), "info-") {
+			t.Fatalf("回调路径错误: %v", e)
+		}
+		if fi, err := os.Stat(e.oldPath); err != nil || fi.Size() != int64(len(chunk))+int64(len("第二个文件\n")) {
+			t.Fatalf("备份文件错误: %v %v", fi, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("未触发轮转回调")
+	}
+	if fi, _ := os.Stat(filename); fi.Size() != int64(len(chunk)) {
+		t.Fatalf("当前文件大小错误: %d", fi.Size())
+	}
+}
@@ -0,0 +1,64 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ai-mmo/lumberjack"
+)
+
+// TestRotateBoundary 测试轮转时刻对齐到整点和零点
+func TestRotateBoundary(t *testing.T) {
+	now := time.Date(2026, 3, 5, 13, 47, 12, 0, time.Local)
+	if got := rotateNextBoundary(now, time.Hour); !got.Equal(time.Date(2026, 3, 5, 14, 0, 0, 0, time.Local)) {
+		t.Fatalf("按小时轮转时刻错误: %v", got)
+	}
+	if got := rotateNextBoundary(now, 24*time.Hour); !got.Equal(time.Date(2026, 3, 6, 0, 0, 0, 0, time.Local)) {
+		t.Fatalf("按天轮转时刻错误: %v", got)
+	}
+	if got := rotatePeriodStart(now, 6*time.Hour); !got.Equal(time.Date(2026, 3, 5, 12, 0, 0, 0, time.Local)) {
+		t.Fatalf("周期开始时刻错误: %v", got)
+	}
+	for _, s := range []string{"7h", "30s", "abc"} {
+		if _, err := parseRotateInterval(s); err == nil {
+			t.Fatalf("轮转间隔 %s 应校验失败", s)
+		}
+	}
+}
+
+// TestRotateIfDue 测试跨过轮转时刻后写入前轮转，同一周期内不重复轮转
+func TestRotateIfDue(t *testing.T) {
+	dir := t.TempDir()
+	logger := &lumberjack.Logger{Filename: filepath.Join(dir, "info.log"), MaxSize: 100, LocalTime: true}
+	f := &fileWriteSyncer{logger: logger, interval: time.Hour}
+	defer f.Close()
+
+	now := time.Now()
+	f.rotateIfDue(now)
+	f.logger.Write([]byte("第一个周期\n"))
+	f.rotateIfDue(now.Add(time.Second))
+	f.logger.Write([]byte("第一个周期\n"))
+	if n := countLogFiles(t, dir); n != 1 {
+		t.Fatalf("同一周期内不应轮转: %d 个文件", n)
+	}
+
+	f.rotateIfDue(now.Add(time.Hour))
+	f.logger.Write([]byte("第二个周期\n"))
+	if n := countLogFiles(t, dir); n != 2 {
+		t.Fatalf("跨过整点后应轮转: %d 个文件", n)
+	}
+	data, _ := os.ReadFile(logger.Filename)
+	if string(data) != "第二个周期\n" {
+		t.Fatalf("当前文件内容错误: %q", data)
+	}
+}
+
+func countLogFiles(t *testing.T, dir string) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
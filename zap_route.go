@@ -0,0 +1,224 @@
+package mlog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 按级别路由可用的输出名，其余输出使用配置键名（如 syslog、kafka、alert-webhook）
+// 或 Sinks 中的实例名
+const (
+	OutputFile    = "file"    // 日志文件
+	OutputConsole = "console" // 控制台
+)
+
+// LevelRouteConfig 一个级别（或级别范围）的输出配置
+type LevelRouteConfig struct {
+	// 级别，如 debug；级别范围，如 info-warn；某级别及以上，如 error+；* 表示全部级别
+	Levels string `mapstructure:"levels" json:"levels" yaml:"levels"`
+	// 输出列表，如 [file, console, alert-webhook]
+	Outputs []string `mapstructure:"outputs" json:"outputs" yaml:"outputs"`
+}
+
+// levelRoutes 编译后的路由表，未被任何路由覆盖的级别保持默认输出：
+// 文件、按 LogInConsole 输出控制台，以及所有已启用的附加输出
+type levelRoutes struct {
+	outputs map[zapcore.Level]map[string]bool
+}
+
+// activeRoutes 当前生效的路由表，未配置路由时为 nil
+var activeRoutes atomic.Pointer[levelRoutes]
+
+// consoleEnabled 是否输出控制台（LogInConsole），初始化时设置，运行时修改不需要重建日志器
+var consoleEnabled atomic.Bool
+
+// newLevelRoutes 编译路由配置，同一级别被多条路由覆盖时输出取并集
+func newLevelRoutes(configs []LevelRouteConfig) (*levelRoutes, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	routes := &levelRoutes{outputs: make(map[zapcore.Level]map[string]bool)}
+	for _, cfg := range configs {
+		lo, hi, err := parseLevelRange(cfg.Levels)
+		if err != nil {
+			return nil, err
+		}
+		for l := lo; l <= hi; l++ {
+			set := routes.outputs[l]
+			if set == nil {
+				set = make(map[string]bool)
+				routes.outputs[l] = set
+			}
+			for _, output := range cfg.Outputs {
+				set[strings.TrimSpace(output)] = true
+			}
+		}
+	}
+	return routes, nil
+}
+
+// parseLevelRange 解析路由的级别表达式
+func parseLevelRange(spec string) (lo, hi zapcore.Level, err error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	switch {
+	case spec == "*" || spec == "":
+		return zapcore.DebugLevel, zapcore.FatalLevel, nil
+	case strings.HasSuffix(spec, "+"):
+		lo, err = zapcore.ParseLevel(strings.TrimSuffix(spec, "+"))
+		return lo, zapcore.FatalLevel, err
+	case strings.Contains(spec, "-"):
+		from, to, _ := strings.Cut(spec, "-")
+		if lo, err = zapcore.ParseLevel(from); err != nil {
+			return
+		}
+		if hi, err = zapcore.ParseLevel(to); err != nil {
+			return
+		}
+		if lo > hi {
+			err = fmt.Errorf("级别范围 %q 的起始级别高于结束级别", spec)
+		}
+		return
+	default:
+		lo, err = zapcore.ParseLevel(spec)
+		return lo, lo, err
+	}
+}
+
+// allows 判断 level 的日志是否写入 output
+func (r *levelRoutes) allows(level zapcore.Level, output string) bool {
+	set, ok := r.outputs[level]
+	if !ok {
+		if output == OutputConsole {
+			return consoleEnabled.Load()
+		}
+		return true
+	}
+	return set[output]
+}
+
+// warnUnknownOutputs 提示路由中引用了未知或未启用的输出，known 为当前可用的输出名
+func (r *levelRoutes) warnUnknownOutputs(known map[string]bool) {
+	reported := make(map[string]bool)
+	for _, set := range r.outputs {
+		for output := range set {
+			if !known[output] && !reported[output] {
+				reported[output] = true
+				fmt.Fprintf(os.Stderr, "[mlog] 日志路由引用了未启用的输出: %s\n", output)
+			}
+		}
+	}
+}
+
+// configuredOutputs 返回配置中已启用的输出名
+func configuredOutputs(c *ZapConfig) map[string]bool {
+	outputs := map[string]bool{
+		OutputFile:      true,
+		OutputConsole:   true,
+		"syslog":        c.Syslog.Enable,
+		"kafka":         c.Kafka.Enable,
+		"pulsar":        c.Pulsar.Enable,
+		"elasticsearch": c.Elasticsearch.Enable,
+		"gelf":          c.GELF.Enable,
+		"otlp":          c.OTLP.Enable,
+		"gcp-logging":   c.GCPLogging.Enable,
+		"azure-monitor": c.AzureMonitor.Enable,
+		"net-sink":      c.NetSink.Enable,
+		"http-sink":     c.HTTPSink.Enable,
+		"unix-socket":   c.UnixSocket.Enable,
+		"clickhouse":    c.ClickHouse.Enable,
+		"sqlite":        c.SQLite.Enable,
+		"nats":          c.NATS.Enable,
+		"mqtt":          c.MQTT.Enable,
+		"redis-stream":  c.RedisStream.Enable,
+		"collector":     c.Collector.Enable,
+		"alert-webhook": c.AlertWebhook.Enable,
+		"email-digest":  c.EmailDigest.Enable,
+	}
+	for _, sink := range c.Sinks {
+		if sink.Name != "" {
+			outputs[sink.Name] = true
+		} else {
+			outputs[sink.Type] = true
+		}
+	}
+	return outputs
+}
+
+// routeAllows 按当前路由表判断 level 的日志是否写入 output
+func routeAllows(level zapcore.Level, output string) bool {
+	if routes := activeRoutes.Load(); routes != nil {
+		return routes.allows(level, output)
+	}
+	if output == OutputConsole {
+		return consoleEnabled.Load()
+	}
+	return true
+}
+
+// consoleMirrorActive 未配置路由时 ZapCore 把日志同时输出到控制台，配置了路由时由 newRoutedConsoleCore 输出
+func consoleMirrorActive() bool {
+	return consoleEnabled.Load() && activeRoutes.Load() == nil
+}
+
+// consoleMirror ZapCore 同时输出控制台时使用的 WriteSyncer，每次写入时按控制台开关和路由决定是否输出，
+// 开关控制台和热更新路由不需要重建 ZapCore
+type consoleMirror struct{}
+
+func (consoleMirror) Write(p []byte) (int, error) {
+	if !consoleMirrorActive() {
+		return len(p), nil
+	}
+	return consoleSink.Write(p)
+}
+
+func (consoleMirror) Sync() error {
+	if !consoleMirrorActive() {
+		return nil
+	}
+	return consoleSink.Sync()
+}
+
+// newRoutedConsoleCore 配置了路由时单独输出控制台的 Core，ZapCore 此时只写文件
+// 未配置路由时不输出，路由热更新后立即按新的路由表生效
+func newRoutedConsoleCore() zapcore.Core {
+	return zapcore.NewCore(zapConfig.Encoder(), zapcore.AddSync(routedConsole{}), zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return activeRoutes.Load() != nil && atomicLevel.Enabled(l) && routeAllows(l, OutputConsole)
+	}))
+}
+
+// routedConsole 写入当前的控制台输出
+type routedConsole struct{}
+
+func (routedConsole) Write(p []byte) (int, error) { return consoleSink.Write(p) }
+func (routedConsole) Sync() error                 { return consoleSink.Sync() }
+
+// routedCore 只把路由表允许的级别交给内部 Core
+type routedCore struct {
+	zapcore.Core
+	output string
+}
+
+// routeOutput 按输出名包装附加输出的 Core
+func routeOutput(output string, core zapcore.Core) zapcore.Core {
+	return &routedCore{Core: core, output: output}
+}
+
+func (c *routedCore) Enabled(level zapcore.Level) bool {
+	return routeAllows(level, c.output) && c.Core.Enabled(level)
+}
+
+func (c *routedCore) With(fields []zapcore.Field) zapcore.Core {
+	return &routedCore{Core: c.Core.With(fields), output: c.output}
+}
+
+func (c *routedCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !routeAllows(entry.Level, c.output) {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
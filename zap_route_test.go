@@ -0,0 +1,70 @@
+package mlog
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestParseLevelRange 测试路由的级别表达式
+func TestParseLevelRange(t *testing.T) {
+	cases := []struct {
+		spec   string
+		lo, hi zapcore.Level
+	}{
+		{"debug", zapcore.DebugLevel, zapcore.DebugLevel},
+		{"info-warn", zapcore.InfoLevel, zapcore.WarnLevel},
+		{"error+", zapcore.ErrorLevel, zapcore.FatalLevel},
+		{"*", zapcore.DebugLevel, zapcore.FatalLevel},
+	}
+	for _, c := range cases {
+		lo, hi, err := parseLevelRange(c.spec)
+		if err != nil || lo != c.lo || hi != c.hi {
+			t.Fatalf("%s: 解析结果 %v-%v %v", c.spec, lo, hi, err)
+		}
+	}
+	for _, spec := range []string{"warn-info", "verbose"} {
+		if _, _, err := parseLevelRange(spec); err == nil {
+			t.Fatalf("%s: 应该解析失败", spec)
+		}
+	}
+}
+
+// TestLevelRoutes 测试按级别路由附加输出和控制台，未覆盖的级别保持默认输出
+func TestLevelRoutes(t *testing.T) {
+	routes, err := newLevelRoutes([]LevelRouteConfig{
+		{Levels: "debug", Outputs: []string{"file"}},
+		{Levels: "error+", Outputs: []string{"file", "console", "alert-webhook"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	activeRoutes.Store(routes)
+	defer activeRoutes.Store(nil)
+	oldLevel := atomicLevel
+	atomicLevel = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	defer func() { atomicLevel = oldLevel }()
+
+	webhook, webhookLogs := observer.New(zapcore.DebugLevel)
+	kafka, kafkaLogs := observer.New(zapcore.DebugLevel)
+	log := zap.New(zapcore.NewTee(routeOutput("alert-webhook", webhook), routeOutput("kafka", kafka)))
+	log.Debug("调试")
+	log.Info("上线")
+	log.Error("存档失败")
+
+	// info 没有被路由覆盖，写入所有已启用的输出
+	if got := webhookLogs.AllUntimed(); len(got) != 2 || got[0].Message != "上线" || got[1].Message != "存档失败" {
+		t.Fatalf("告警输出收到的日志错误: %v", got)
+	}
+	if got := kafkaLogs.AllUntimed(); len(got) != 1 || got[0].Message != "上线" {
+		t.Fatalf("Kafka 输出收到的日志错误: %v", got)
+	}
+	if routeAllows(zapcore.DebugLevel, OutputConsole) || !routeAllows(zapcore.ErrorLevel, OutputConsole) {
+		t.Fatal("控制台路由错误")
+	}
+	if !routeAllows(zapcore.DebugLevel, OutputFile) || routeAllows(zapcore.DebugLevel, "kafka") {
+		t.Fatal("debug 级别应该只写文件")
+	}
+}
@@ -0,0 +1,88 @@
+package mlog
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// samplerTick 采样计数的统计周期
+const samplerTick = time.Second
+
+// samplerDropped 各级别被 Core 采样丢弃的累计条数
+var samplerDropped [zapcore.FatalLevel - zapcore.DebugLevel + 1]uint64
+
+// levelSampledCore 按级别采样的 zapcore.Core
+// 配置了采样的级别经过 zap 采样器，每秒内相同消息前 Initial 条全部保留，之后每 Thereafter 条保留 1 条；
+// 未配置的级别直接写入
+type levelSampledCore struct {
+	zapcore.Core
+	sampled map[zapcore.Level]zapcore.Core
+}
+
+// newLevelSampledCore 根据配置创建按级别采样的 Core，没有有效配置时原样返回 core
+// dropped 非空时记录被丢弃的日志，由 droppedCore 附加到之后写出的相同日志上
+func newLevelSampledCore(core zapcore.Core, configs map[string]SamplingConfig, dropped *droppedCounter) zapcore.Core {
+	hook := func(entry zapcore.Entry, decision zapcore.SamplingDecision) {
+		recordSamplerDecision(entry, decision)
+		if decision&zapcore.LogDropped != 0 {
+			dropped.add(entry.Level, entry.Message)
+		}
+	}
+	sampled := make(map[zapcore.Level]zapcore.Core)
+	for name, cfg := range configs {
+		level, err := zapcore.ParseLevel(name)
+		if err != nil || level < zapcore.DebugLevel || level > zapcore.FatalLevel {
+			fmt.Fprintf(os.Stderr, "[mlog] 忽略无效的采样级别配置: %s\n", name)
+			continue
+		}
+		if cfg.Initial <= 0 && cfg.Thereafter <= 0 {
+			continue
+		}
+		sampled[level] = zapcore.NewSamplerWithOptions(core, samplerTick, cfg.Initial, cfg.Thereafter,
+			zapcore.SamplerHook(hook))
+	}
+	if len(sampled) == 0 {
+		return core
+	}
+	return &levelSampledCore{Core: core, sampled: sampled}
+}
+
+// recordSamplerDecision 统计被采样丢弃的日志条数
+func recordSamplerDecision(entry zapcore.Entry, decision zapcore.SamplingDecision) {
+	if decision&zapcore.LogDropped != 0 && entry.Level >= zapcore.DebugLevel && entry.Level <= zapcore.FatalLevel {
+		atomic.AddUint64(&samplerDropped[entry.Level-zapcore.DebugLevel], 1)
+	}
+}
+
+func (c *levelSampledCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &levelSampledCore{
+		Core:    c.Core.With(fields),
+		sampled: make(map[zapcore.Level]zapcore.Core, len(c.sampled)),
+	}
+	for level, core := range c.sampled {
+		clone.sampled[level] = core.With(fields)
+	}
+	return clone
+}
+
+func (c *levelSampledCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if sampler, ok := c.sampled[entry.Level]; ok {
+		return sampler.Check(entry, ce)
+	}
+	return c.Core.Check(entry, ce)
+}
+
+// SamplerDroppedCounts 获取各级别被 Core 采样丢弃的累计条数（键为级别名，只包含有丢弃的级别）
+func SamplerDroppedCounts() map[string]uint64 {
+	counts := make(map[string]uint64)
+	for i := range samplerDropped {
+		if n := atomic.LoadUint64(&samplerDropped[i]); n > 0 {
+			counts[(zapcore.DebugLevel + zapcore.Level(i)).String()] = n
+		}
+	}
+	return counts
+}
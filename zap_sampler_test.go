@@ -0,0 +1,45 @@
+package mlog
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestLevelSampledCore 测试只对配置了采样的级别进行采样
+func TestLevelSampledCore(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	core := newLevelSampledCore(observed, map[string]SamplingConfig{
+		"info":  {Initial: 2, Thereafter: 3},
+		"bogus": {Initial: 1},
+	}, nil)
+	logger := zap.New(core).With(zap.String("scene", "arena"))
+	before := SamplerDroppedCounts()["info"]
+
+	for i := 0; i < 8; i++ {
+		logger.Info("伤害事件")
+		logger.Warn("伤害事件")
+	}
+
+	var infos, warns int
+	for _, e := range logs.All() {
+		switch e.Level {
+		case zapcore.InfoLevel:
+			infos++
+		case zapcore.WarnLevel:
+			warns++
+		}
+		if e.ContextMap()["scene"] != "arena" {
+			t.Fatalf("With 字段丢失: %v", e.ContextMap())
+		}
+	}
+	// 前 2 条保留，之后第 3、6 条各保留 1 条
+	if infos != 4 || warns != 8 {
+		t.Fatalf("采样结果错误: info=%d warn=%d", infos, warns)
+	}
+	if dropped := SamplerDroppedCounts()["info"] - before; dropped != 4 {
+		t.Fatalf("丢弃计数错误: %d", dropped)
+	}
+}
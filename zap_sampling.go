@@ -0,0 +1,74 @@
+package mlog
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// adaptiveSampler 异步队列过载时的自适应采样器
+// 队列使用率超过高水位时，Debug/Info 日志按 1/N 采样，Warn 及以上级别始终保留；
+// 使用率回落到低水位以下后恢复全量日志。高低水位之间的滞回区间避免在阈值附近频繁切换。
+type adaptiveSampler struct {
+	high    float64 // 开始采样的队列使用率
+	low     float64 // 恢复全量日志的队列使用率
+	rate    int     // 采样期间每 rate 条保留 1 条
+	counter uint64  // 采样计数器
+}
+
+// newAdaptiveSampler 根据配置创建自适应采样器，未启用时返回 nil
+func newAdaptiveSampler(cfg AdaptiveSamplingConfig) *adaptiveSampler {
+	if !cfg.Enable {
+		return nil
+	}
+	s := &adaptiveSampler{
+		high: cfg.HighWatermark,
+		low:  cfg.LowWatermark,
+		rate: cfg.Rate,
+	}
+	if s.high <= 0 || s.high > 1 {
+		s.high = 0.8
+	}
+	if s.low <= 0 || s.low >= s.high {
+		s.low = s.high / 2
+	}
+	if s.rate <= 1 {
+		s.rate = 10
+	}
+	return s
+}
+
+// admit 判断一条日志是否写入队列
+// keep 为 false 时应跳过该日志；sampled 为 true 表示该日志是采样期间保留下来的代表条目
+func (s *adaptiveSampler) admit(q *asyncQueue, level zapcore.Level) (keep, sampled bool) {
+	active := s.updateState(q)
+	if level >= zapcore.WarnLevel || !active {
+		return true, false
+	}
+	if atomic.AddUint64(&s.counter, 1)%uint64(s.rate) == 0 {
+		return true, true
+	}
+	atomic.AddUint64(&q.sampledOut, 1)
+	return false, false
+}
+
+// updateState 根据队列当前使用率切换采样状态，返回切换后是否处于采样状态
+func (s *adaptiveSampler) updateState(q *asyncQueue) bool {
+	utilization := float64(q.ring.len()) / float64(q.ring.capacity())
+	active := atomic.LoadInt32(&q.sampling) == 1
+	switch {
+	case !active && utilization >= s.high:
+		if atomic.CompareAndSwapInt32(&q.sampling, 0, 1) {
+			fmt.Fprintf(os.Stderr, "[mlog] 异步队列 %s 使用率 %.0f%%，开始采样 Debug/Info 日志 (1/%d)\n", q.name, utilization*100, s.rate)
+		}
+		return true
+	case active && utilization <= s.low:
+		if atomic.CompareAndSwapInt32(&q.sampling, 1, 0) {
+			fmt.Fprintf(os.Stderr, "[mlog] 异步队列 %s 使用率回落至 %.0f%%，恢复全量日志\n", q.name, utilization*100)
+		}
+		return false
+	}
+	return active
+}
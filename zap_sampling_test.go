@@ -0,0 +1,47 @@
+package mlog
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestAdaptiveSampler 测试队列过载时采样 Debug/Info、始终保留 Warn，压力回落后恢复
+func TestAdaptiveSampler(t *testing.T) {
+	s := newAdaptiveSampler(AdaptiveSamplingConfig{Enable: true, HighWatermark: 0.75, LowWatermark: 0.25, Rate: 5})
+	q := newAsyncQueue("default", 16, false)
+
+	if keep, _ := s.admit(q, zapcore.DebugLevel); !keep {
+		t.Fatal("队列空闲时不应采样")
+	}
+
+	for i := 0; i < 12; i++ {
+		q.ring.tryPush(&AsyncLogEntry{})
+	}
+	kept, sampled := 0, 0
+	for i := 0; i < 20; i++ {
+		keep, s := s.admit(q, zapcore.InfoLevel)
+		if keep {
+			kept++
+		}
+		if s {
+			sampled++
+		}
+	}
+	if kept != 4 || sampled != 4 {
+		t.Fatalf("期望保留 4 条采样日志，实际保留 %d 条，标记 %d 条", kept, sampled)
+	}
+	if keep, sampled := s.admit(q, zapcore.WarnLevel); !keep || sampled {
+		t.Fatal("Warn 级别日志应始终保留且不标记采样")
+	}
+	if stats := q.stats(); !stats.Sampling || stats.SampledOut != 16 {
+		t.Fatalf("采样统计错误: %+v", stats)
+	}
+
+	var out AsyncLogEntry
+	for q.ring.tryPop(&out) {
+	}
+	if keep, sampled := s.admit(q, zapcore.DebugLevel); !keep || sampled {
+		t.Fatal("压力回落后应恢复全量日志")
+	}
+}
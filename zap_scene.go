@@ -0,0 +1,297 @@
+package mlog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SceneUploader 场景日志归档上传函数，在场景日志完成归档后调用
+// archivePath 为归档文件路径（启用压缩时为 .gz 文件）
+type SceneUploader func(sceneID, archivePath string) error
+
+var (
+	scenes        = make(map[string]*SceneLogger) // 当前打开的场景日志
+	scenesMutex   sync.Mutex
+	sceneUploader SceneUploader
+	// sceneFinalizeWG 跟踪后台进行中的场景归档，关闭日志系统时等待其完成
+	sceneFinalizeWG sync.WaitGroup
+)
+
+// SceneLogger 场景日志器
+// 每个场景实例的日志写入独立的分区文件 <服务日志目录>/scene/<sceneID>.log，
+// 场景卸载时刷新、压缩（EnableCompress）并可选上传，适合频繁创建和销毁场景实例的世界服。
+type SceneLogger struct {
+	id     string
+	path   string
+	file   *os.File
+	logger *zap.Logger
+	mu     sync.RWMutex
+	closed bool
+}
+
+// SetSceneUploader 设置场景日志归档上传函数，传入 nil 取消上传
+func SetSceneUploader(uploader SceneUploader) {
+	scenesMutex.Lock()
+	sceneUploader = uploader
+	scenesMutex.Unlock()
+}
+
+// Scene 获取场景日志器，场景首次使用时创建分区文件
+// 创建失败时返回的日志器会丢弃所有日志并在标准错误输出原因，调用方无需判空
+func Scene(sceneID string) *SceneLogger {
+	scenesMutex.Lock()
+	defer scenesMutex.Unlock()
+
+	if s, ok := scenes[sceneID]; ok {
+		return s
+	}
+	s, err := newSceneLogger(sceneID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] 创建场景日志失败 [%s]: %v\n", sceneID, err)
+		return &SceneLogger{id: sceneID, closed: true}
+	}
+	scenes[sceneID] = s
+	return s
+}
+
+// newSceneLogger 创建场景分区文件和对应的 zap logger
+func newSceneLogger(sceneID string) (*SceneLogger, error) {
+	dir := filepath.Join(serviceLogDir(), "scene")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, sanitizeSceneID(sceneID)+".log")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	levelEnabler := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l >= atomicLevel.Level()
+	})
+	core := newLimitCore(zapcore.NewCore(zapConfig.Encoder(), zapcore.AddSync(file), levelEnabler), &zapConfig)
+	// 与主日志器共用可热更新的脱敏层
+	if hot := activeHotCores.Load(); hot != nil {
+		core = newReloadableCore(core, &hot.redact)
+	} else {
+		core = newRedactCore(core, &zapConfig)
+	}
+	// 调用栈：用户代码 -> SceneLogger.Info() -> logger.Info()
+	logger := withMetadata(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))).With(zap.String("scene_id", sceneID))
+
+	return &SceneLogger{id: sceneID, path: path, file: file, logger: logger}, nil
+}
+
+// serviceLogDir 返回当前服务的日志目录，与 ZapCore 的目录规则一致
+func serviceLogDir() string {
+	coreMutex.RLock()
+	defer coreMutex.RUnlock()
+	if len(zapCores) == 0 || zapCores[0] == nil {
+		return currentDirector()
+	}
+	return logDirFor(zapCores[0].serviceName, zapCores[0].serviceID)
+}
+
+// logDirFor 返回指定服务的日志目录：<Director>/<服务ID>/<服务名>
+func logDirFor(serviceName string, serviceID uint64) string {
+	logDir := currentDirector()
+	if serviceID != 0 {
+		logDir = filepath.Join(logDir, fmt.Sprintf("%d", serviceID))
+	}
+	if serviceName != "" {
+		logDir = filepath.Join(logDir, serviceName)
+	}
+	return logDir
+}
+
+// sanitizeSceneID 将场景ID转换为安全的文件名
+func sanitizeSceneID(sceneID string) string {
+	if sceneID == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == '.' {
+			return '_'
+		}
+		return r
+	}, sceneID)
+}
+
+// ID 返回场景ID
+func (s *SceneLogger) ID() string {
+	return s.id
+}
+
+// log 在场景未归档时写入日志
+func (s *SceneLogger) log(level zapcore.Level, msg string, fields []zap.Field) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	if ce := s.logger.Check(level, msg); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
+// Debug 输出场景调试级别日志
+func (s *SceneLogger) Debug(msg string, args ...any) {
+	s.log(zapcore.DebugLevel, SafeFormat(msg, args...), nil)
+}
+
+// Info 输出场景信息级别日志
+func (s *SceneLogger) Info(msg string, args ...any) {
+	s.log(zapcore.InfoLevel, SafeFormat(msg, args...), nil)
+}
+
+// Warn 输出场景警告级别日志
+func (s *SceneLogger) Warn(msg string, args ...any) {
+	s.log(zapcore.WarnLevel, SafeFormat(msg, args...), nil)
+}
+
+// Error 输出场景错误级别日志
+func (s *SceneLogger) Error(msg string, args ...any) {
+	s.log(zapcore.ErrorLevel, SafeFormat(msg, args...), nil)
+}
+
+// DebugW 输出带结构化字段的场景调试级别日志
+func (s *SceneLogger) DebugW(msg string, fields ...zap.Field) {
+	s.log(zapcore.DebugLevel, msg, fields)
+}
+
+// InfoW 输出带结构化字段的场景信息级别日志
+func (s *SceneLogger) InfoW(msg string, fields ...zap.Field) {
+	s.log(zapcore.InfoLevel, msg, fields)
+}
+
+// WarnW 输出带结构化字段的场景警告级别日志
+func (s *SceneLogger) WarnW(msg string, fields ...zap.Field) {
+	s.log(zapcore.WarnLevel, msg, fields)
+}
+
+// ErrorW 输出带结构化字段的场景错误级别日志
+func (s *SceneLogger) ErrorW(msg string, fields ...zap.Field) {
+	s.log(zapcore.ErrorLevel, msg, fields)
+}
+
+// UnloadScene 卸载场景：停止写入并在后台完成归档（刷新、压缩、上传）
+// 归档在后台进行，不阻塞场景销毁流程；失败原因输出到标准错误
+func UnloadScene(sceneID string) {
+	scenesMutex.Lock()
+	s, ok := scenes[sceneID]
+	delete(scenes, sceneID)
+	scenesMutex.Unlock()
+	if !ok {
+		return
+	}
+
+	sceneFinalizeWG.Add(1)
+	go func() {
+		defer sceneFinalizeWG.Done()
+		if _, err := s.Finalize(); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 场景日志归档失败 [%s]: %v\n", sceneID, err)
+		}
+	}()
+}
+
+// Finalize 同步完成场景日志归档，返回归档文件路径
+// 归档后该场景日志器不再写入；同一场景ID之后再次调用 Scene 会创建新的分区
+func (s *SceneLogger) Finalize() (string, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return "", nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	scenesMutex.Lock()
+	if scenes[s.id] == s {
+		delete(scenes, s.id)
+	}
+	uploader := sceneUploader
+	scenesMutex.Unlock()
+
+	if err := s.file.Sync(); err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] 场景日志同步失败 [%s]: %v\n", s.id, err)
+	}
+	if err := s.file.Close(); err != nil {
+		return "", err
+	}
+
+	// 归档文件名带上卸载时间，同一场景ID多次加载不会互相覆盖
+	base := strings.TrimSuffix(s.path, ".log") + "-" + time.Now().Format("20060102-150405.000")
+	archivePath := base + ".log"
+	if zapConfig.EnableCompress {
+		archivePath += ".gz"
+		if err := gzipFile(s.path, archivePath); err != nil {
+			return "", err
+		}
+	} else if err := os.Rename(s.path, archivePath); err != nil {
+		return "", err
+	}
+
+	if uploader != nil {
+		if err := uploader(s.id, archivePath); err != nil {
+			return archivePath, fmt.Errorf("上传失败: %w", err)
+		}
+	}
+	return archivePath, nil
+}
+
+// gzipFile 将 src 压缩为 dst 并删除 src
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
+
+// closeAllScenes 归档所有打开的场景并等待后台归档完成
+func closeAllScenes() {
+	scenesMutex.Lock()
+	open := make([]*SceneLogger, 0, len(scenes))
+	for _, s := range scenes {
+		open = append(open, s)
+	}
+	scenesMutex.Unlock()
+
+	for _, s := range open {
+		if _, err := s.Finalize(); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 场景日志归档失败 [%s]: %v\n", s.id, err)
+		}
+	}
+	sceneFinalizeWG.Wait()
+}
@@ -0,0 +1,70 @@
+package mlog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSceneLifecycle 测试场景日志写入独立分区，并在卸载时压缩归档和上传
+func TestSceneLifecycle(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{
+		Level:          "info",
+		Format:         "console",
+		Director:       dir,
+		SingleFile:     true,
+		EnableCompress: true,
+	}
+	InitialZap("scene", 0, "info", &config)
+	defer Close()
+
+	uploaded := make(chan string, 1)
+	SetSceneUploader(func(sceneID, archivePath string) error {
+		uploaded <- archivePath
+		return nil
+	})
+	defer SetSceneUploader(nil)
+
+	scene := Scene("dungeon/1001")
+	if Scene("dungeon/1001") != scene {
+		t.Fatal("同一场景ID应返回同一个日志器")
+	}
+	scene.Info("boss 刷新 %d", 3)
+	scene.Debug("低于日志级别")
+
+	archive, err := scene.Finalize()
+	if err != nil {
+		t.Fatalf("归档失败: %v", err)
+	}
+	scene.Info("归档后的日志应被丢弃")
+
+	if got := <-uploaded; got != archive {
+		t.Fatalf("上传路径 %s 与归档路径 %s 不一致", got, archive)
+	}
+	if filepath.Dir(archive) != filepath.Join(dir, "scene", "scene") || !strings.HasSuffix(archive, ".log.gz") {
+		t.Fatalf("归档路径错误: %s", archive)
+	}
+
+	file, err := os.Open(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(gz)
+	content := string(data)
+	if !strings.Contains(content, "boss 刷新 3") || !strings.Contains(content, "dungeon/1001") {
+		t.Errorf("场景日志内容缺失: %s", content)
+	}
+	if strings.Contains(content, "低于日志级别") || strings.Contains(content, "归档后") {
+		t.Errorf("场景日志包含不应写入的内容: %s", content)
+	}
+}
@@ -0,0 +1,83 @@
+package mlog
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// seqFieldKey 序号字段的键名
+const seqFieldKey = "seq"
+
+// userSeqFieldKey 用户自己的 seq 字段改用的键名，避免与序号字段重复
+const userSeqFieldKey = "user_seq"
+
+// entrySeq 日志条目的进程内序号，同步和异步日志共用
+var entrySeq uint64
+
+// nextSeq 分配下一个序号
+func nextSeq() uint64 {
+	return atomic.AddUint64(&entrySeq, 1)
+}
+
+// seqCore 为每条日志添加单调递增的 seq 字段
+// 序号在采样、去重之前分配，消费方可以通过序号的间隔发现被丢弃的日志，并对毫秒级时间戳相同的条目排序。
+// 异步模式的条目使用入队时分配的序号；用户自己的 seq 字段改名为 user_seq
+type seqCore struct {
+	zapcore.Core
+}
+
+// asyncSeqMarker 异步条目入队时分配的序号的标记，私有类型保证用户字段无法冒充
+type asyncSeqMarker struct{}
+
+// asyncSeqField 携带入队序号的内部字段，类型为 SkipType，编码器不会输出；seqCore 将其替换为 seq 字段
+func asyncSeqField(seq uint64) zap.Field {
+	return zap.Field{Type: zapcore.SkipType, Integer: int64(seq), Interface: asyncSeqMarker{}}
+}
+
+// asyncSeq 取出 asyncSeqField 携带的序号
+func asyncSeq(f zapcore.Field) (uint64, bool) {
+	if _, ok := f.Interface.(asyncSeqMarker); ok && f.Type == zapcore.SkipType {
+		return uint64(f.Integer), true
+	}
+	return 0, false
+}
+
+// newSeqCore 按 SeqField 包装 Core，未开启时原样返回
+func newSeqCore(core zapcore.Core, c *ZapConfig) zapcore.Core {
+	if !c.SeqField {
+		return core
+	}
+	return &seqCore{Core: core}
+}
+
+func (c *seqCore) With(fields []zapcore.Field) zapcore.Core {
+	return &seqCore{Core: c.Core.With(fields)}
+}
+
+func (c *seqCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *seqCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	stamped := make([]zapcore.Field, 0, len(fields)+1)
+	var seq uint64
+	for _, f := range fields {
+		if n, ok := asyncSeq(f); ok {
+			seq = n
+			continue
+		}
+		if f.Key == seqFieldKey {
+			f.Key = userSeqFieldKey
+		}
+		stamped = append(stamped, f)
+	}
+	if seq == 0 {
+		seq = nextSeq()
+	}
+	return writeChecked(c.Core, entry, append(stamped, zap.Uint64(seqFieldKey, seq)))
+}
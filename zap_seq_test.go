@@ -0,0 +1,112 @@
+package mlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestSeqField 测试同步和异步日志的序号连续递增，用户自己的 seq 字段改名为 user_seq，不影响序号
+func TestSeqField(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		Close()
+		dir := t.TempDir()
+		config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "json", SingleFile: true, SeqField: true, EnableAsync: async}
+		InitialZap("gate", 2, "info", &config)
+
+		Info("第一条")
+		GLOG().With(zap.String("player", "alice")).Info("第二条")
+		WarnW("第三条")
+		InfoW("自带序号", zap.Uint64("seq", 7))
+		Close()
+
+		files, _ := filepath.Glob(filepath.Join(dir, "2", "gate", "*.log"))
+		if len(files) != 1 {
+			t.Fatalf("async=%v 日志文件数量错误: %v", async, files)
+		}
+		f, err := os.Open(files[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		var seqs []float64
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var entry map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				t.Fatal(err)
+			}
+			seq, ok := entry["seq"].(float64)
+			if !ok || strings.Count(scanner.Text(), `"seq":`) != 1 {
+				t.Fatalf("async=%v 应有且只有一个 seq 字段: %s", async, scanner.Text())
+			}
+			if entry["message"] == "自带序号" && entry["user_seq"] != 7.0 {
+				t.Fatalf("async=%v 用户的 seq 字段应改名为 user_seq: %s", async, scanner.Text())
+			}
+			seqs = append(seqs, seq)
+		}
+		f.Close()
+		// 异步模式下 GLOG() 同步写入，文件中的顺序可能与序号不同
+		sort.Float64s(seqs)
+		if len(seqs) != 4 || seqs[1] != seqs[0]+1 || seqs[2] != seqs[1]+1 || seqs[3] != seqs[2]+1 {
+			t.Fatalf("async=%v 序号应连续: %v", async, seqs)
+		}
+	}
+}
+
+// TestAsyncSeq 测试开启 SeqField 时异步条目携带单调递增的序号，未开启时不输出，时间戳相同时按序号合并多个队列
+func TestAsyncSeq(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	al := NewAsyncLogger(core, WithFlushInterval(0), WithSeqField(true))
+	for i := 0; i < 3; i++ {
+		al.Info("seq %d", i)
+	}
+	al.InfoW("自带序号", zap.Uint64("seq", 1))
+	al.Close()
+
+	var last uint64
+	for _, e := range logs.AllUntimed() {
+		seq, ok := e.ContextMap()["seq"].(uint64)
+		if !ok || seq <= last || len(e.Context) > 2 {
+			t.Fatalf("序号应单调递增: %v (上一个 %d)", e.ContextMap(), last)
+		}
+		last = seq
+	}
+	if entries := logs.AllUntimed(); len(entries) != 4 || entries[3].ContextMap()["user_seq"] != uint64(1) {
+		t.Fatalf("用户的 seq 字段应改名为 user_seq: %v", entries)
+	}
+
+	plain, plainLogs := observer.New(zapcore.DebugLevel)
+	al = NewAsyncLogger(plain, WithFlushInterval(0))
+	al.InfoW("未开启", zap.Uint64("seq", 1))
+	al.Close()
+	if entries := plainLogs.AllUntimed(); len(entries) != 1 || len(entries[0].Context) != 1 || entries[0].ContextMap()["seq"] != uint64(1) {
+		t.Fatalf("未开启 SeqField 时不应添加序号: %v", entries)
+	}
+
+	merge := &AsyncLogger{notify: make(chan struct{}, 1), done: make(chan struct{})}
+	merge.setupQueues(4, false, map[string]AsyncQueueConfig{"error": {BufferSize: 4}})
+	now := time.Now()
+	first := AsyncLogEntry{Level: zapcore.ErrorLevel, Message: "first", Timestamp: now}
+	first.stampSeq(false)
+	second := AsyncLogEntry{Level: zapcore.InfoLevel, Message: "second", Timestamp: now}
+	second.stampSeq(false)
+	merge.enqueue(&second)
+	merge.enqueue(&first)
+
+	var out AsyncLogEntry
+	for _, want := range []string{"first", "second"} {
+		if !merge.tryPop(&out) || out.Message != want {
+			t.Fatalf("期望 %q，实际 %q", want, out.Message)
+		}
+		out.releaseFields()
+	}
+}
@@ -0,0 +1,34 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestAlwaysSeparateErrors 测试单文件模式下 Error 日志额外写入 error.log
+func TestAlwaysSeparateErrors(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, SingleFile: true, AlwaysSeparateErrors: true}
+	InitialZap("gate", 2, "info", &config)
+	InfoW("普通日志")
+	ErrorW("错误日志")
+	Close()
+
+	all, err := os.ReadFile(filepath.Join(dir, "2", "gate", "all.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(all), "普通日志") || !strings.Contains(string(all), "错误日志") {
+		t.Fatalf("all.log 内容错误: %s", all)
+	}
+	errs, err := os.ReadFile(filepath.Join(dir, "2", "gate", "error.log"))
+	if err != nil {
+		t.Fatalf("未创建 error.log: %v", err)
+	}
+	if strings.Contains(string(errs), "普通日志") || !strings.Contains(string(errs), "错误日志") {
+		t.Fatalf("error.log 内容错误: %s", errs)
+	}
+}
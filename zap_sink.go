@@ -0,0 +1,163 @@
+package mlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Sink 可插拔的日志输出
+// 第三方输出实现该接口并通过 RegisterSink 注册后，即可在 ZapConfig.Sinks 中按类型名启用，
+// 无需修改 mlog 本身。Write 在写日志的协程（异步模式下为异步工作协程）中同步调用，
+// 耗时的网络输出应自行缓冲后批量发送
+type Sink interface {
+	Write(entry Entry) error // 写入一条日志
+	Sync() error             // 刷新缓冲
+	Close() error            // 关闭输出，关闭日志器或重新初始化时调用
+}
+
+// Entry 传递给 Sink 的日志条目
+// Fields 只在 Write 调用期间有效，Sink 需要在 Write 返回后继续使用时应先复制或编码
+type Entry struct {
+	zapcore.Entry                 // 时间、级别、消息、调用位置、堆栈
+	Fields        []zapcore.Field // 日志字段，通过 With 附加的字段在前
+}
+
+// FieldMap 将日志字段编码为 map，便于 Sink 序列化
+func (e Entry) FieldMap() map[string]any {
+	enc := zapcore.NewMapObjectEncoder()
+	for i := range e.Fields {
+		e.Fields[i].AddTo(enc)
+	}
+	return enc.Fields
+}
+
+// SinkConfig 可插拔输出配置
+type SinkConfig struct {
+	Type    string         `mapstructure:"type" json:"type" yaml:"type"`          // RegisterSink 注册的输出类型名
+	Name    string         `mapstructure:"name" json:"name" yaml:"name"`          // 输出实例名（默认同 Type），同类型配置多个时用于区分
+	Level   string         `mapstructure:"level" json:"level" yaml:"level"`       // 最低级别（默认跟随全局级别）
+	Options map[string]any `mapstructure:"options" json:"options" yaml:"options"` // 传给输出的自定义参数
+
+	// 以下字段在初始化时由 mlog 填写
+	ServiceName string `mapstructure:"-" json:"-" yaml:"-"`
+	ServiceID   uint64 `mapstructure:"-" json:"-" yaml:"-"`
+}
+
+// SinkFactory 根据配置创建输出
+type SinkFactory func(cfg SinkConfig) (Sink, error)
+
+var (
+	sinkFactoriesMutex sync.RWMutex
+	sinkFactories      = make(map[string]SinkFactory)
+)
+
+// RegisterSink 注册输出类型，重复注册同名类型时覆盖之前的注册
+// 需要在 InitialZap 之前调用，一般放在输出实现包的 init 中
+func RegisterSink(name string, factory SinkFactory) {
+	if name == "" || factory == nil {
+		panic("mlog: RegisterSink 的类型名和 factory 不能为空")
+	}
+	sinkFactoriesMutex.Lock()
+	sinkFactories[name] = factory
+	sinkFactoriesMutex.Unlock()
+}
+
+// RegisteredSinks 返回已注册的输出类型名（按字母排序）
+func RegisteredSinks() []string {
+	sinkFactoriesMutex.RLock()
+	defer sinkFactoriesMutex.RUnlock()
+	names := make([]string, 0, len(sinkFactories))
+	for name := range sinkFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newPluggableSinkCores 按配置创建所有可插拔输出的 Core，创建失败的输出只打印错误
+func newPluggableSinkCores(configs []SinkConfig, serviceName string, serviceID uint64) []zapcore.Core {
+	cores := make([]zapcore.Core, 0, len(configs))
+	for _, cfg := range configs {
+		cfg.ServiceName = serviceName
+		cfg.ServiceID = serviceID
+		if cfg.Name == "" {
+			cfg.Name = cfg.Type
+		}
+		core, err := newSinkCore(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化输出 %s 失败: %v\n", cfg.Name, err)
+			continue
+		}
+		cores = append(cores, routeOutput(cfg.Name, core))
+	}
+	return cores
+}
+
+// newSinkCore 创建单个可插拔输出的 Core，输出会登记到关闭列表
+func newSinkCore(cfg SinkConfig) (*sinkCore, error) {
+	sinkFactoriesMutex.RLock()
+	factory := sinkFactories[cfg.Type]
+	sinkFactoriesMutex.RUnlock()
+	if factory == nil {
+		return nil, fmt.Errorf("未注册的输出类型: %q", cfg.Type)
+	}
+
+	var enabler zapcore.LevelEnabler = atomicLevel
+	if cfg.Level != "" {
+		minLevel, err := zapcore.ParseLevel(cfg.Level)
+		if err != nil {
+			return nil, err
+		}
+		enabler = zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return l >= minLevel && atomicLevel.Enabled(l)
+		})
+	}
+
+	sink, err := factory(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if sink == nil {
+		return nil, errors.New("factory 返回了空的输出")
+	}
+	addSinkCloser(sink)
+	return &sinkCore{LevelEnabler: enabler, sink: sink}, nil
+}
+
+// sinkCore 将日志转交给 Sink 的 zapcore.Core
+type sinkCore struct {
+	zapcore.LevelEnabler
+	sink   Sink
+	fields []zapcore.Field // 通过 With 附加的字段
+}
+
+func (c *sinkCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(append([]zapcore.Field(nil), c.fields...), fields...)
+	return &clone
+}
+
+func (c *sinkCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *sinkCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	all := fields
+	if len(c.fields) > 0 {
+		all = append(append(make([]zapcore.Field, 0, len(c.fields)+len(fields)), c.fields...), fields...)
+	}
+	return c.sink.Write(Entry{Entry: entry, Fields: all})
+}
+
+func (c *sinkCore) Sync() error {
+	return c.sink.Sync()
+}
@@ -0,0 +1,316 @@
+package mlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ai-mmo/lumberjack"
+	"go.uber.org/zap/zapcore"
+)
+
+// consoleWriteSyncer 控制台输出
+// 控制台是无缓冲的终端或管道，对其调用 fsync 没有意义，且在终端和管道上会返回
+// "inappropriate ioctl"、"invalid argument" 等错误，因此 Sync 为空操作
+type consoleWriteSyncer struct {
+	mu   sync.Mutex
+	file *os.File
+	// 终端不支持颜色时去掉 ANSI 控制序列，见 ConsoleColor
+	stripColors atomic.Bool
+	// JSON 日志缩进输出，见 SetPrettyConsole
+	pretty atomic.Bool
+}
+
+// newConsoleWriteSyncer 创建控制台输出
+func newConsoleWriteSyncer(file *os.File) *consoleWriteSyncer {
+	return &consoleWriteSyncer{file: file}
+}
+
+func (c *consoleWriteSyncer) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pretty.Load() {
+		if _, err := c.file.Write(prettyJSON(p, !c.stripColors.Load())); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if c.stripColors.Load() {
+		if _, err := c.file.Write(stripANSI(p)); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	return c.file.Write(p)
+}
+
+// Sync 控制台不需要同步
+func (c *consoleWriteSyncer) Sync() error {
+	return nil
+}
+
+// fileWriteSyncer 日志文件输出
+// lumberjack 直接写入操作系统文件，写入返回后数据已经交给内核，这里的 Sync 只需保证
+// 文件输出自身的缓冲（如果有）被刷新，不受控制台同步结果影响
+type fileWriteSyncer struct {
+	// mu 保护 logger 的切换：写入持有读锁，按日期切换文件和关闭时持有写锁
+	mu     sync.RWMutex
+	logger *lumberjack.Logger
+	closed bool
+
+	// 按时间生成文件名（不含目录，与当前文件在同一目录），文件名模板带日期时设置，nameChecked 为上一次检查文件名的时间（Unix 秒）
+	fileName    func(time.Time) string
+	nameChecked atomic.Int64
+
+	// 指向当前日志文件的符号链接名（为空表示不维护），linked 表示当前文件的链接已更新
+	linkName string
+	linked   atomic.Bool
+
+	// 按时间轮转的间隔（0 表示只按大小轮转）和下一次轮转时刻（UnixNano）
+	interval   time.Duration
+	nextRotate atomic.Int64
+	// rotateMu 串行化主动轮转；注册了轮转回调时还保护 size，sizeKnown 为 false 时需要重新读取文件大小
+	rotateMu  sync.Mutex
+	size      int64
+	sizeKnown atomic.Bool
+
+	// 写入失败（如磁盘已满）后降级为只输出控制台，nextProbe 为下一次重新尝试写入文件的时刻（UnixNano）
+	degraded  atomic.Bool
+	nextProbe atomic.Int64
+	// consoleMirrored 为 true 时同一条日志经 consoleMirror 输出，控制台开启时降级期间不再重复输出
+	consoleMirrored bool
+
+	// flock 多进程模式下日志目录的进程间锁（其他模式为 nil），current 为上一次写入的文件
+	// 该模式下写入持有 mu 的写锁，可以直接替换 logger
+	shared  *dirLock
+	current os.FileInfo
+
+	// 配置了 FileHeader 时生成文件头（否则为 nil），headerPending 表示下一次写入前需要先写文件头
+	header        func(file string, now time.Time) []byte
+	headerPending atomic.Bool
+
+	// 配置了 BufferSizeKB 时的写缓冲（否则为 nil），写满或按 FlushIntervalMs 定时写入文件，Sync 和 Close 时立即写入
+	buffer *zapcore.BufferedWriteSyncer
+	// dirty 上一次 Sync 之后是否写入过文件，没有写入时 Sync 不做 fsync
+	dirty atomic.Bool
+}
+
+// newFileWriteSyncer 创建写入 filename 的日志文件输出
+func newFileWriteSyncer(filename string) *fileWriteSyncer {
+	f := &fileWriteSyncer{logger: newLumberjackLogger(filename), interval: zapConfig.rotateInterval()}
+	f.headerPending.Store(true)
+	if zapConfig.multiProcess() == MultiProcessFlock {
+		f.shared = newDirLock(filepath.Dir(filename))
+	}
+	if size := zapConfig.bufferSize(); size > 0 {
+		f.buffer = &zapcore.BufferedWriteSyncer{
+			WS:            unbufferedFile{f},
+			Size:          size,
+			FlushInterval: zapConfig.bufferFlushInterval(),
+		}
+	}
+	activeFileSyncers.Store(f, struct{}{})
+	return f
+}
+
+// activeFileSyncers 所有未关闭的日志文件输出，清理日志目录时跳过正在写入的文件
+var activeFileSyncers sync.Map
+
+// activeLogFiles 返回所有正在写入的日志文件路径
+func activeLogFiles() map[string]bool {
+	files := make(map[string]bool)
+	activeFileSyncers.Range(func(key, _ any) bool {
+		f := key.(*fileWriteSyncer)
+		f.mu.RLock()
+		files[filepath.Clean(f.logger.Filename)] = true
+		f.mu.RUnlock()
+		return true
+	})
+	return files
+}
+
+// newLumberjackLogger 按全局配置创建 lumberjack logger
+func newLumberjackLogger(filename string) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   filename,
+		MaxSize:    zapConfig.MaxSize,           // MB
+		MaxBackups: zapConfig.MaxBackups,        // 保留备份文件数量
+		MaxAge:     zapConfig.RetentionDay,      // 保留天数
+		Compress:   zapConfig.gzipCompression(), // 是否 gzip 压缩
+		LocalTime:  true,                        // 使用本地时间
+	}
+}
+
+func (f *fileWriteSyncer) Write(p []byte) (int, error) {
+	if f.buffer != nil {
+		return f.buffer.Write(p)
+	}
+	return f.write(p)
+}
+
+// write 直接写入文件
+func (f *fileWriteSyncer) write(p []byte) (int, error) {
+	now := time.Now()
+	f.switchFileIfDue(now)
+	if f.shared != nil {
+		// flock 模式下写入本来就串行，持有写锁以便在其他进程轮转后替换 logger
+		f.mu.Lock()
+		defer f.mu.Unlock()
+	} else {
+		f.mu.RLock()
+		defer f.mu.RUnlock()
+	}
+	if f.degraded.Load() {
+		return f.writeDegraded(p, now)
+	}
+	f.rotateIfDue(now)
+	var (
+		n   int
+		err error
+	)
+	if f.shared != nil {
+		n, err = f.writeShared(p)
+	} else if hasRotateHooks() || f.header != nil {
+		n, err = f.writeTracked(p)
+	} else {
+		n, err = f.writeFile(p)
+		f.sizeKnown.Store(false)
+	}
+	if n > 0 {
+		f.dirty.Store(true)
+	}
+	if err != nil && !f.closed {
+		return f.degrade(p, err, now)
+	}
+	if f.linkName != "" && err == nil && f.linked.CompareAndSwap(false, true) {
+		f.updateCurrentLink()
+	}
+	return n, err
+}
+
+// switchFileIfDue 文件名模板带日期时，日期变化后关闭旧文件并切换到新文件名，每秒最多检查一次
+func (f *fileWriteSyncer) switchFileIfDue(now time.Time) {
+	if f.fileName == nil {
+		return
+	}
+	sec := now.Unix()
+	if last := f.nameChecked.Load(); last == sec || !f.nameChecked.CompareAndSwap(last, sec) {
+		return
+	}
+	base := f.fileName(now)
+	f.mu.RLock()
+	same := filepath.Base(f.logger.Filename) == base
+	f.mu.RUnlock()
+	if same {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name := filepath.Join(filepath.Dir(f.logger.Filename), base)
+	if f.closed || f.logger.Filename == name {
+		return
+	}
+	if err := f.logger.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] 关闭日志文件失败 [%s]: %v\n", f.logger.Filename, err)
+	}
+	oldName := f.logger.Filename
+	f.logger = newLumberjackLogger(name)
+	f.nextRotate.Store(0)
+	f.linked.Store(false)
+	f.sizeKnown.Store(false)
+	f.headerPending.Store(true)
+	fireRotateHooks(oldName, name)
+}
+
+// Close 关闭当前的 lumberjack logger
+func (f *fileWriteSyncer) Close() error {
+	if f.buffer != nil {
+		// 写出缓冲中的日志并停止定时刷新，需要在持有写锁之前完成
+		if err := f.buffer.Stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 写出日志缓冲失败 [%s]: %v\n", f.logger.Filename, err)
+		}
+	}
+	activeFileSyncers.Delete(f)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	if f.degraded.CompareAndSwap(true, false) {
+		degradedFiles.Add(-1)
+	}
+	if f.shared != nil {
+		f.shared.Close()
+	}
+	return f.logger.Close()
+}
+
+// Sync 刷新文件输出：把缓冲中的日志写入文件，并对当前日志文件执行 fsync，返回后之前写入的日志已经落盘
+// 上一次 Sync 之后没有写入时不做 fsync，安静时段的定时刷新没有磁盘开销
+func (f *fileWriteSyncer) Sync() error {
+	if f.buffer != nil {
+		if err := f.buffer.Sync(); err != nil {
+			return err
+		}
+	}
+	if !f.dirty.Swap(false) {
+		return nil
+	}
+	// 持有写锁，fsync 期间文件不会被轮转或切换
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed || f.degraded.Load() {
+		return nil
+	}
+	return fsyncFile(f.logger.Filename)
+}
+
+// fsyncFile 对日志文件执行 fsync，文件尚未创建时不做处理
+// lumberjack 没有公开打开的文件，另外打开同一个文件执行 fsync 同样会写入该文件所有已写入的数据
+func fsyncFile(name string) error {
+	file, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
+
+// consoleSink 全局共享的控制台输出，所有 ZapCore 共用一把写锁，避免多个级别的日志在控制台上交错
+var consoleSink = newConsoleWriteSyncer(os.Stdout)
+
+// mirroredToConsole 同一条日志是否已经同时输出到控制台
+func (f *fileWriteSyncer) mirroredToConsole() bool {
+	return f.consoleMirrored && consoleMirrorActive()
+}
+
+// SyncFileSinks 只同步所有日志文件输出，不涉及控制台
+func SyncFileSinks() error {
+	coreMutex.RLock()
+	defer coreMutex.RUnlock()
+
+	var errs []error
+	for _, core := range zapCores {
+		if core != nil {
+			errs = append(errs, core.SyncFiles())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SyncConsoleSink 只同步控制台输出
+func SyncConsoleSink() error {
+	return consoleSink.Sync()
+}
+
+// ensure 接口实现
+var (
+	_ zapcore.WriteSyncer = (*consoleWriteSyncer)(nil)
+	_ zapcore.WriteSyncer = (*fileWriteSyncer)(nil)
+)
@@ -0,0 +1,74 @@
+package mlog
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ai-mmo/lumberjack"
+	"go.uber.org/zap/zapcore"
+)
+
+// failingWriteSyncer 写入总是失败的测试输出
+type failingWriteSyncer struct{}
+
+func (failingWriteSyncer) Write(p []byte) (int, error) { return 0, errors.New("磁盘故障") }
+func (failingWriteSyncer) Sync() error                 { return nil }
+
+// newBufferedTestSyncer 创建写缓冲下游为 ws 的文件输出
+func newBufferedTestSyncer(ws zapcore.WriteSyncer) *fileWriteSyncer {
+	return &fileWriteSyncer{buffer: &zapcore.BufferedWriteSyncer{WS: ws}}
+}
+
+// TestSyncFilesIndependently 测试某个文件输出同步失败时返回其错误，其余文件输出仍然被同步
+func TestSyncFilesIndependently(t *testing.T) {
+	var main, audit bytes.Buffer
+	bad := newBufferedTestSyncer(failingWriteSyncer{})
+	core := &ZapCore{
+		fileSyncer: newBufferedTestSyncer(zapcore.AddSync(&main)),
+		specialSyncers: map[string]*fileWriteSyncer{
+			"broken": bad,
+			"audit":  newBufferedTestSyncer(zapcore.AddSync(&audit)),
+		},
+	}
+	syncers := []*fileWriteSyncer{core.fileSyncer, bad, core.specialSyncers["audit"]}
+	defer func() {
+		for _, s := range syncers {
+			s.buffer.Stop()
+		}
+	}()
+	for _, s := range syncers {
+		s.Write([]byte("line\n"))
+	}
+
+	err := core.Sync()
+	if err == nil || !strings.Contains(err.Error(), "磁盘故障") {
+		t.Fatalf("应返回失败输出的同步错误: %v", err)
+	}
+	if main.String() != "line\n" || audit.String() != "line\n" {
+		t.Fatalf("其余文件输出应被同步: main=%q audit=%q", main.String(), audit.String())
+	}
+}
+
+// TestFileSyncFsync 测试写入后 Sync 对日志文件执行 fsync，之后没有写入时不再 fsync
+func TestFileSyncFsync(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "info.log")
+	f := &fileWriteSyncer{logger: &lumberjack.Logger{Filename: filename, MaxSize: 1}}
+	defer f.Close()
+
+	if err := f.Sync(); err != nil {
+		t.Fatalf("文件尚未创建时 Sync 不应失败: %v", err)
+	}
+	f.Write([]byte("落盘\n"))
+	if !f.dirty.Load() {
+		t.Fatal("写入后应标记为需要 fsync")
+	}
+	if err := f.Sync(); err != nil || f.dirty.Load() {
+		t.Fatalf("Sync 应执行 fsync 并清除标记: %v", err)
+	}
+	if err := fsyncFile(filepath.Join(t.TempDir(), "missing.log")); err != nil {
+		t.Fatalf("文件不存在时不应返回错误: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package mlog
+
+import (
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// memorySink 记录写入日志的测试输出
+type memorySink struct {
+	mu      sync.Mutex
+	cfg     SinkConfig
+	entries []Entry
+	fields  []map[string]any
+	closed  bool
+}
+
+func (s *memorySink) Write(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	s.fields = append(s.fields, entry.FieldMap())
+	return nil
+}
+
+func (s *memorySink) Sync() error { return nil }
+
+func (s *memorySink) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	return nil
+}
+
+// TestPluggableSink 测试注册输出、按配置创建、最低级别和 With 字段
+func TestPluggableSink(t *testing.T) {
+	var sink *memorySink
+	RegisterSink("test-memory", func(cfg SinkConfig) (Sink, error) {
+		sink = &memorySink{cfg: cfg}
+		return sink, nil
+	})
+
+	oldLevel := atomicLevel
+	atomicLevel = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	defer func() { atomicLevel = oldLevel }()
+	cores := newPluggableSinkCores([]SinkConfig{
+		{Type: "test-memory", Level: "warn", Options: map[string]any{"address": "127.0.0.1:9000"}},
+		{Type: "not-registered"},
+	}, "game", 7)
+	if len(cores) != 1 {
+		t.Fatalf("创建的输出数量错误: %d", len(cores))
+	}
+	if sink.cfg.Name != "test-memory" || sink.cfg.ServiceName != "game" || sink.cfg.ServiceID != 7 ||
+		sink.cfg.Options["address"] != "127.0.0.1:9000" {
+		t.Fatalf("传给 factory 的配置错误: %+v", sink.cfg)
+	}
+
+	log := zap.New(zapcore.NewTee(cores...)).With(zap.String("zone", "north"))
+	log.Info("低于输出级别")
+	log.Warn("背包已满", zap.Int64("player", 1001))
+
+	if len(sink.entries) != 1 || sink.entries[0].Message != "背包已满" || sink.entries[0].Level != zapcore.WarnLevel {
+		t.Fatalf("写入的日志错误: %+v", sink.entries)
+	}
+	if f := sink.fields[0]; f["zone"] != "north" || f["player"] != int64(1001) {
+		t.Fatalf("日志字段错误: %v", f)
+	}
+
+	coreMutex.Lock()
+	closeSinksLocked()
+	coreMutex.Unlock()
+	if !sink.closed {
+		t.Fatal("关闭日志器时没有关闭输出")
+	}
+}
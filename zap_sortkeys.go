@@ -0,0 +1,153 @@
+package mlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// sortedKeysPool 排序后的编码结果的缓冲
+var sortedKeysPool = buffer.NewPool()
+
+// sortedKeysEncoder JSON 编码器包装，字段按键名排序输出
+// 时间、级别、名称、调用位置和消息保持在最前，堆栈保持在最后，其余字段（含 With 添加的字段和嵌套对象的键）按键名排序；
+// 重复的键保留原有的先后顺序
+type sortedKeysEncoder struct {
+	zapcore.Encoder
+	head  map[string]bool // 保持在最前的键
+	stack string          // 堆栈的键名
+}
+
+// newSortedKeysEncoder 创建按键名排序的 JSON 编码器
+func newSortedKeysEncoder(enc zapcore.Encoder, config zapcore.EncoderConfig) zapcore.Encoder {
+	head := make(map[string]bool, 6)
+	for _, key := range []string{config.TimeKey, config.LevelKey, config.NameKey, config.CallerKey, config.FunctionKey, config.MessageKey} {
+		if key != "" {
+			head[key] = true
+		}
+	}
+	return &sortedKeysEncoder{Encoder: enc, head: head, stack: config.StacktraceKey}
+}
+
+func (e *sortedKeysEncoder) Clone() zapcore.Encoder {
+	return &sortedKeysEncoder{Encoder: e.Encoder.Clone(), head: e.head, stack: e.stack}
+}
+
+// sortedMember 顶层对象的一个键值对
+type sortedMember struct {
+	key   string
+	value json.RawMessage
+}
+
+// rank 键的分组：0 为最前的键，1 为字段，2 为堆栈
+func (e *sortedKeysEncoder) rank(key string) int {
+	switch {
+	case e.head[key]:
+		return 0
+	case key != "" && key == e.stack:
+		return 2
+	}
+	return 1
+}
+
+func (e *sortedKeysEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+	body := bytes.TrimRight(data, "\r\n")
+	members, err := parseJSONMembers(body)
+	if err != nil {
+		// 无法解析时原样输出，不丢失日志
+		return buf, nil
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		ri, rj := e.rank(members[i].key), e.rank(members[j].key)
+		if ri != rj {
+			return ri < rj
+		}
+		return ri == 1 && members[i].key < members[j].key
+	})
+
+	out := sortedKeysPool.Get()
+	out.AppendByte('{')
+	for i, m := range members {
+		if i > 0 {
+			out.AppendByte(',')
+		}
+		if err := appendJSONValue(out, m.key); err != nil {
+			out.Free()
+			return buf, nil
+		}
+		out.AppendByte(':')
+		if err := appendSortedJSON(out, m.value); err != nil {
+			out.Free()
+			return buf, nil
+		}
+	}
+	out.AppendByte('}')
+	out.Write(data[len(body):])
+	buf.Free()
+	return out, nil
+}
+
+// parseJSONMembers 解析 JSON 对象的顶层键值对，保留重复的键
+func parseJSONMembers(data []byte) ([]sortedMember, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("不是 JSON 对象")
+	}
+	var members []sortedMember
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		members = append(members, sortedMember{key: key, value: value})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("JSON 对象之后有多余的内容")
+	}
+	return members, nil
+}
+
+// appendSortedJSON 写入一个 JSON 值，对象和数组中嵌套对象的键按键名排序，数字保持原样
+func appendSortedJSON(out *buffer.Buffer, value json.RawMessage) error {
+	if len(value) == 0 || (value[0] != '{' && value[0] != '[') {
+		out.Write(value)
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	return appendJSONValue(out, v)
+}
+
+// appendJSONValue 以 JSON 编码写入值，不转义 HTML 字符，与 zap 的 JSON 编码一致
+func appendJSONValue(out *buffer.Buffer, v any) error {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	out.Write(bytes.TrimRight(b.Bytes(), "\n"))
+	return nil
+}
@@ -0,0 +1,46 @@
+package mlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestSortKeys 测试字段按键名排序，固定的键保持在最前，堆栈在最后，大整数和 HTML 字符保持原样
+func TestSortKeys(t *testing.T) {
+	config := ZapConfig{Format: FormatJSON, StacktraceKey: "stacktrace", SortKeys: true}
+	enc := config.Encoder()
+	enc.AddString("zone", "cn")
+	zap.Namespace("req").AddTo(enc)
+	enc.AddString("path", "/a?x=1&y=<2>")
+	enc.AddInt("code", 200)
+
+	buf, err := enc.EncodeEntry(zapcore.Entry{
+		Level:   zapcore.InfoLevel,
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Message: "登录",
+		Stack:   "main.main",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Free()
+	want := `{"level":"info","time":"2024-01-02 03:04:05.000","message":"登录","req":{"code":200,"path":"/a?x=1&y=<2>"},"zone":"cn","stacktrace":"main.main"}` + "\n"
+	if buf.String() != want {
+		t.Fatalf("排序结果错误:\n%s\n期望:\n%s", buf.String(), want)
+	}
+
+	fields := []zapcore.Field{zap.Uint64("player_id", 18446744073709551615), zap.Any("bag", map[string]int{"b": 2, "a": 1}), zap.Bool("ok", true)}
+	clone := config.Encoder()
+	buf2, err := clone.EncodeEntry(zapcore.Entry{Message: "保存"}, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf2.Free()
+	if !strings.HasSuffix(buf2.String(), `"message":"保存","bag":{"a":1,"b":2},"ok":true,"player_id":18446744073709551615}`+"\n") {
+		t.Fatalf("排序结果错误: %s", buf2.String())
+	}
+}
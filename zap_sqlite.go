@@ -0,0 +1,175 @@
+package mlog
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SQLite 输出的默认参数
+const (
+	defaultSQLiteDriver        = "sqlite"
+	defaultSQLiteRetentionDays = 7
+	sqlitePruneInterval        = time.Hour
+)
+
+// sqliteSchema 日志表结构，ts 为毫秒时间戳
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS logs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	ts INTEGER NOT NULL,
+	level TEXT NOT NULL,
+	directory TEXT NOT NULL DEFAULT '',
+	caller TEXT NOT NULL DEFAULT '',
+	message TEXT NOT NULL,
+	fields TEXT NOT NULL DEFAULT '{}'
+)`,
+	`CREATE INDEX IF NOT EXISTS idx_logs_ts ON logs(ts)`,
+	`CREATE INDEX IF NOT EXISTS idx_logs_level_ts ON logs(level, ts)`,
+	`CREATE INDEX IF NOT EXISTS idx_logs_directory_ts ON logs(directory, ts)`,
+}
+
+// SQLiteConfig 本地 SQLite 输出配置
+// mlog 不内置 SQLite 驱动，应用需要导入一个 database/sql 驱动，
+// 如 modernc.org/sqlite（驱动名 sqlite，纯 Go）或 github.com/mattn/go-sqlite3（驱动名 sqlite3）
+type SQLiteConfig struct {
+	Enable bool   `mapstructure:"enable" json:"enable" yaml:"enable"` // 启用 SQLite 输出
+	Driver string `mapstructure:"driver" json:"driver" yaml:"driver"` // database/sql 驱动名（默认 sqlite）
+	Path   string `mapstructure:"path" json:"path" yaml:"path"`       // 数据库文件路径（默认 <服务日志目录>/logs.db）
+	// 日志保留天数（默认 7），每小时清理一次过期记录，负数表示不清理
+	RetentionDays     int `mapstructure:"retention-days" json:"retention-days" yaml:"retention-days"`
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// sqliteStore SQLite 日志库
+type sqliteStore struct {
+	db        *sql.DB
+	retention time.Duration
+	lastPrune time.Time
+}
+
+// newSQLiteCore 创建 SQLite 输出 Core
+// 日志在后台按批次以事务写入，写入失败不影响文本日志文件
+func newSQLiteCore(cfg SQLiteConfig, serviceName string, serviceID uint64) (*sqliteCore, error) {
+	driver := cfg.Driver
+	if driver == "" {
+		driver = defaultSQLiteDriver
+	}
+	path := cfg.Path
+	if path == "" {
+		path = filepath.Join(logDirFor(serviceName, serviceID), "logs.db")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(driver, path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 SQLite 数据库失败（是否导入了 %s 驱动）: %w", driver, err)
+	}
+	// SQLite 同一时间只允许一个写入者，批量写入只需要一个连接
+	db.SetMaxOpenConns(1)
+	ctx, cancel := context.WithTimeout(context.Background(), remoteCloseTimeout)
+	defer cancel()
+	for _, stmt := range sqliteSchema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("创建 SQLite 日志表失败: %w", err)
+		}
+	}
+
+	store := &sqliteStore{db: db}
+	retentionDays := cfg.RetentionDays
+	if retentionDays == 0 {
+		retentionDays = defaultSQLiteRetentionDays
+	}
+	if retentionDays > 0 {
+		store.retention = time.Duration(retentionDays) * 24 * time.Hour
+	}
+	batcher := newRemoteBatcher("sqlite", cfg.RemoteBatchConfig, store.insert)
+	addSinkCloser(batcher)
+	addSinkCloser(store)
+	return &sqliteCore{LevelEnabler: atomicLevel, batcher: batcher}, nil
+}
+
+// insert 在一个事务中写入一批日志，并按间隔清理过期记录
+func (s *sqliteStore) insert(ctx context.Context, batch []remoteRecord) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO logs (ts, level, directory, caller, message, fields) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range batch {
+		entry := batch[i].Entry
+		caller := ""
+		if entry.Caller.Defined {
+			caller = entry.Caller.TrimmedPath()
+		}
+		if _, err := stmt.ExecContext(ctx, entry.Time.UnixMilli(), entry.Level.String(), batch[i].Key,
+			caller, entry.Message, string(batch[i].Data)); err != nil {
+			stmt.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	stmt.Close()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if s.retention > 0 && time.Since(s.lastPrune) >= sqlitePruneInterval {
+		s.lastPrune = time.Now()
+		cutoff := time.Now().Add(-s.retention).UnixMilli()
+		if _, err := s.db.ExecContext(ctx, "DELETE FROM logs WHERE ts < ?", cutoff); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 清理过期 SQLite 日志失败: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// Close 关闭数据库
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+// sqliteCore 将日志写入 SQLite 的 zapcore.Core
+// business/folder/directory 字段写入 directory 列，其余字段编码为 JSON 写入 fields 列
+type sqliteCore struct {
+	zapcore.LevelEnabler
+	batcher *remoteBatcher
+	fields  []zapcore.Field // 通过 With 附加的字段
+}
+
+func (c *sqliteCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(append([]zapcore.Field(nil), c.fields...), fields...)
+	return &clone
+}
+
+func (c *sqliteCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *sqliteCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	directory, fieldsJSON, err := encodeStructuredFields(entry, c.fields, fields)
+	if err != nil {
+		return err
+	}
+	c.batcher.add(remoteRecord{Entry: entry, Key: directory, Data: []byte(fieldsJSON)})
+	return nil
+}
+
+func (c *sqliteCore) Sync() error {
+	return nil
+}
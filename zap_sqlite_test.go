@@ -0,0 +1,96 @@
+package mlog
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// recordingDriver 记录执行语句的 database/sql 测试驱动
+type recordingDriver struct {
+	mu    sync.Mutex
+	execs []recordedExec
+}
+
+type recordedExec struct {
+	query string
+	args  []driver.Value
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) { return &recordingConn{d}, nil }
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{c.d, query}, nil
+}
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *recordingConn) Commit() error             { return nil }
+func (c *recordingConn) Rollback() error           { return nil }
+
+type recordingStmt struct {
+	d     *recordingDriver
+	query string
+}
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return -1 }
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	s.d.execs = append(s.d.execs, recordedExec{s.query, args})
+	s.d.mu.Unlock()
+	return driver.RowsAffected(1), nil
+}
+func (s *recordingStmt) Query([]driver.Value) (driver.Rows, error) { return nil, driver.ErrSkip }
+
+var testSQLiteDriver = &recordingDriver{}
+
+func init() {
+	sql.Register("mlog-test-sqlite", testSQLiteDriver)
+}
+
+// TestSQLiteInsert 测试建表、批量插入的列值和过期清理
+func TestSQLiteInsert(t *testing.T) {
+	core, err := newSQLiteCore(SQLiteConfig{
+		Driver: "mlog-test-sqlite",
+		Path:   filepath.Join(t.TempDir(), "logs.db"),
+	}, "game", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	zap.New(core).With(zap.String("folder", "battle")).Error("技能配置缺失", zap.Int("skill", 42))
+	core.batcher.Close()
+
+	testSQLiteDriver.mu.Lock()
+	defer testSQLiteDriver.mu.Unlock()
+	var inserts, prunes []recordedExec
+	tables := 0
+	for _, e := range testSQLiteDriver.execs {
+		switch {
+		case strings.HasPrefix(e.query, "CREATE TABLE IF NOT EXISTS logs"):
+			tables++
+		case strings.HasPrefix(e.query, "INSERT INTO logs"):
+			inserts = append(inserts, e)
+		case strings.HasPrefix(e.query, "DELETE FROM logs"):
+			prunes = append(prunes, e)
+		}
+	}
+	if tables != 1 || len(inserts) != 1 || len(prunes) != 1 {
+		t.Fatalf("执行的语句错误: %+v", testSQLiteDriver.execs)
+	}
+	args := inserts[0].args
+	if args[1] != "error" || args[2] != "battle" || args[4] != "技能配置缺失" || args[5] != `{"skill":42}` {
+		t.Fatalf("插入的列值错误: %v", args)
+	}
+	if cutoff := prunes[0].args[0].(int64); cutoff >= args[0].(int64) {
+		t.Fatalf("清理时间点错误: %d >= %d", cutoff, args[0])
+	}
+}
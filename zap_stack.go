@@ -0,0 +1,135 @@
+package mlog
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultStackDepth 自动附加的调用栈默认保留的帧数
+const defaultStackDepth = 32
+
+// stackPolicy 自动附加调用栈的策略
+type stackPolicy struct {
+	level    zapcore.Level
+	depth    int
+	skip     int
+	relative bool
+}
+
+// activeStackPolicy 当前日志器的调用栈策略，为空时不自动附加；异步日志在入队时按同一策略取栈
+var activeStackPolicy atomic.Pointer[stackPolicy]
+
+// mlogSourceDir mlog 源文件所在目录，用于识别调用栈中 mlog 内部的帧
+var mlogSourceDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// newStackPolicy 按 StacktraceLevel、StackDepth、StackSkip 创建调用栈策略，StacktraceLevel 为空时返回 nil
+func newStackPolicy(c *ZapConfig) (*stackPolicy, error) {
+	if c.StacktraceLevel == "" {
+		return nil, nil
+	}
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(c.StacktraceLevel)); err != nil {
+		return nil, fmt.Errorf("无效的调用栈级别 %s", c.StacktraceLevel)
+	}
+	return &stackPolicy{
+		level:    level,
+		depth:    positiveOr(c.StackDepth, defaultStackDepth),
+		skip:     max(c.StackSkip, 0),
+		relative: c.UseRelativePath,
+	}, nil
+}
+
+// captureStack 按当前策略获取调用栈，级别低于 StacktraceLevel 或未开启时返回空字符串
+// 跳过 mlog 和 zap 内部的帧，从业务代码的调用位置开始
+func captureStack(level zapcore.Level) string {
+	policy := activeStackPolicy.Load()
+	if policy == nil || level < policy.level {
+		return ""
+	}
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+	frames := runtime.CallersFrames(pcs)
+
+	var sb strings.Builder
+	internal, skip, n := true, policy.skip, 0
+	for n < policy.depth {
+		frame, more := frames.Next()
+		if internal && internalFrame(frame) {
+			if !more {
+				break
+			}
+			continue
+		}
+		internal = false
+		if skip > 0 {
+			skip--
+		} else {
+			if n > 0 {
+				sb.WriteByte('\n')
+			}
+			sb.WriteString(frame.Function)
+			sb.WriteString("\n\t")
+			sb.WriteString(frame.File)
+			sb.WriteByte(':')
+			sb.WriteString(strconv.Itoa(frame.Line))
+			n++
+		}
+		if !more {
+			break
+		}
+	}
+	stack := sb.String()
+	if policy.relative {
+		stack = convertStackPathsToRelative(stack)
+	}
+	return stack
+}
+
+// internalFrame 是否为 mlog（测试文件除外）或 zap 内部的帧
+func internalFrame(frame runtime.Frame) bool {
+	if strings.HasPrefix(frame.Function, "go.uber.org/zap") {
+		return true
+	}
+	return filepath.Dir(frame.File) == mlogSourceDir && !strings.HasSuffix(frame.File, "_test.go")
+}
+
+// stackCore 为 StacktraceLevel 及以上级别的日志附加调用栈
+// 已带调用栈的条目（zap.AddStacktrace、异步日志入队时获取的调用栈）保持不变
+type stackCore struct {
+	zapcore.Core
+}
+
+// newStackCore 按调用栈策略包装 Core，未开启时原样返回
+func newStackCore(core zapcore.Core, policy *stackPolicy) zapcore.Core {
+	if policy == nil {
+		return core
+	}
+	return &stackCore{Core: core}
+}
+
+func (c *stackCore) With(fields []zapcore.Field) zapcore.Core {
+	return &stackCore{Core: c.Core.With(fields)}
+}
+
+func (c *stackCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *stackCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if entry.Stack == "" {
+		entry.Stack = captureStack(entry.Level)
+	}
+	return writeChecked(c.Core, entry, fields)
+}
@@ -0,0 +1,82 @@
+package mlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// logWithHelper 业务封装的日志函数，用于测试 StackSkip
+func logWithHelper(msg string) {
+	Error(msg)
+}
+
+// readStackEntries 读取单文件模式下 JSON 日志的消息和调用栈
+func readStackEntries(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	f, err := os.Open(filepath.Join(dir, "2", "gate", "all.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stacks := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		stack, _ := entry["stacktrace"].(string)
+		stacks[entry["message"].(string)] = stack
+	}
+	return stacks
+}
+
+// TestStacktraceLevel 测试 Error 及以上级别自动附加调用栈，跳过内部帧并限制帧数
+func TestStacktraceLevel(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		Close()
+		dir := t.TempDir()
+		config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "json", SingleFile: true,
+			StacktraceLevel: "error", StackDepth: 2, EnableAsync: async}
+		InitialZap("gate", 2, "info", &config)
+		Warn("背包已满")
+		Error("存档失败")
+		Close()
+
+		stacks := readStackEntries(t, dir)
+		if stacks["背包已满"] != "" {
+			t.Fatalf("Warn 日志不应带调用栈: %q", stacks["背包已满"])
+		}
+		lines := strings.Split(stacks["存档失败"], "\n")
+		if len(lines) != 4 || !strings.HasSuffix(lines[0], ".TestStacktraceLevel") || !strings.Contains(lines[1], "zap_stack_test.go:") {
+			t.Fatalf("async=%v 调用栈错误: %q", async, stacks["存档失败"])
+		}
+	}
+}
+
+// TestStackSkipRelative 测试 StackSkip 跳过业务封装函数，UseRelativePath 时调用栈使用相对路径
+func TestStackSkipRelative(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "json", SingleFile: true,
+		StacktraceLevel: "error", StackSkip: 1, UseRelativePath: true}
+	InitialZap("gate", 2, "info", &config)
+	logWithHelper("存档失败")
+	Close()
+
+	stack := readStackEntries(t, dir)["存档失败"]
+	if !strings.HasPrefix(stack, "mlog.TestStackSkipRelative\n") || strings.Contains(stack, "logWithHelper") {
+		t.Fatalf("应跳过封装函数: %q", stack)
+	}
+	if strings.Contains(stack, mlogSourceDir) {
+		t.Fatalf("应使用相对路径: %q", stack)
+	}
+
+	if _, err := newStackPolicy(&ZapConfig{StacktraceLevel: "fatal-ish"}); err == nil {
+		t.Fatal("无效的级别应返回错误")
+	}
+}
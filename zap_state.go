@@ -0,0 +1,148 @@
+package mlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RuntimeState 日志系统的运行时状态快照
+// 用于在进程重启后恢复运维期间做过的调整（如临时调高的日志级别）
+type RuntimeState struct {
+	Version       string                     `json:"version"`        // 导出时的 mlog 版本
+	ExportedAt    time.Time                  `json:"exported_at"`    // 导出时间
+	Level         string                     `json:"level"`          // 全局日志级别
+	SafetyMode    LogSafetyMode              `json:"safety_mode"`    // 日志安全模式
+	FieldSnapshot bool                       `json:"field_snapshot"` // 异步字段快照开关
+	PreInitPolicy PreInitPolicy              `json:"preinit_policy"` // 初始化前日志的处理策略
+	Extensions    map[string]json.RawMessage `json:"extensions,omitempty"`
+}
+
+// runtimeStateProvider 扩展运行时状态的导出和导入函数
+type runtimeStateProvider struct {
+	export func() (any, error)
+	load   func(data json.RawMessage) error
+}
+
+var (
+	runtimeStateProviders      = make(map[string]runtimeStateProvider)
+	runtimeStateProvidersMutex sync.RWMutex
+)
+
+// registerRuntimeState 注册一项扩展运行时状态，导出时写入 Extensions[name]
+// 模块级别、过滤器、配额等按需注册，导入时缺失的项保持当前值不变
+func registerRuntimeState(name string, export func() (any, error), load func(data json.RawMessage) error) {
+	runtimeStateProvidersMutex.Lock()
+	runtimeStateProviders[name] = runtimeStateProvider{export: export, load: load}
+	runtimeStateProvidersMutex.Unlock()
+}
+
+// ExportRuntimeState 导出当前运行时状态为 JSON
+func ExportRuntimeState() ([]byte, error) {
+	state := RuntimeState{
+		Version:       Version,
+		ExportedAt:    time.Now(),
+		SafetyMode:    GetLogSafetyMode(),
+		FieldSnapshot: GetFieldSnapshot(),
+		PreInitPolicy: GetPreInitPolicy(),
+	}
+	globalMutex.RLock()
+	if isInitialized() {
+		state.Level = globalLevel().String()
+	}
+	globalMutex.RUnlock()
+
+	runtimeStateProvidersMutex.RLock()
+	names := make([]string, 0, len(runtimeStateProviders))
+	for name := range runtimeStateProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, err := runtimeStateProviders[name].export()
+		if err != nil {
+			runtimeStateProvidersMutex.RUnlock()
+			return nil, fmt.Errorf("导出运行时状态 %s 失败: %w", name, err)
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			runtimeStateProvidersMutex.RUnlock()
+			return nil, fmt.Errorf("导出运行时状态 %s 失败: %w", name, err)
+		}
+		if state.Extensions == nil {
+			state.Extensions = make(map[string]json.RawMessage)
+		}
+		state.Extensions[name] = data
+	}
+	runtimeStateProvidersMutex.RUnlock()
+
+	return json.MarshalIndent(state, "", "  ")
+}
+
+// ImportRuntimeState 从 JSON 恢复运行时状态
+// 日志级别通过 UpdateLevel 生效；未知的扩展项会被忽略，以兼容不同版本之间的状态文件
+func ImportRuntimeState(data []byte) error {
+	var state RuntimeState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("解析运行时状态失败: %w", err)
+	}
+
+	if state.Level != "" {
+		if _, err := zapcore.ParseLevel(state.Level); err != nil {
+			return fmt.Errorf("运行时状态中的日志级别无效: %s", state.Level)
+		}
+		if isInitialized() {
+			UpdateLevel(state.Level)
+		}
+	}
+	SetLogSafetyMode(state.SafetyMode)
+	SetFieldSnapshot(state.FieldSnapshot)
+	SetPreInitPolicy(state.PreInitPolicy)
+
+	runtimeStateProvidersMutex.RLock()
+	defer runtimeStateProvidersMutex.RUnlock()
+	for name, raw := range state.Extensions {
+		provider, ok := runtimeStateProviders[name]
+		if !ok {
+			continue
+		}
+		if err := provider.load(raw); err != nil {
+			return fmt.Errorf("导入运行时状态 %s 失败: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// SaveRuntimeState 将运行时状态写入文件（先写临时文件再重命名，避免写入一半时进程退出）
+func SaveRuntimeState(path string) error {
+	data, err := ExportRuntimeState()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadRuntimeState 从文件恢复运行时状态，文件不存在时不做任何修改并返回 nil
+func LoadRuntimeState(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ImportRuntimeState(data)
+}
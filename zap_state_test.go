@@ -0,0 +1,53 @@
+package mlog
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+// TestRuntimeStateRoundTrip 测试运行时状态导出后在重新初始化的日志系统中恢复
+func TestRuntimeStateRoundTrip(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Format: "console", Director: dir, SingleFile: true}
+	InitialZap("state", 0, "info", &config)
+
+	var quota int
+	registerRuntimeState("test_quota", func() (any, error) {
+		return quota, nil
+	}, func(data json.RawMessage) error {
+		return json.Unmarshal(data, &quota)
+	})
+	defer func() {
+		runtimeStateProvidersMutex.Lock()
+		delete(runtimeStateProviders, "test_quota")
+		runtimeStateProvidersMutex.Unlock()
+	}()
+
+	UpdateLevel("debug")
+	SetFieldSnapshot(true)
+	quota = 42
+	path := filepath.Join(dir, "state", "runtime.json")
+	if err := SaveRuntimeState(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟进程重启
+	Close()
+	SetFieldSnapshot(false)
+	quota = 0
+	InitialZap("state", 0, "info", &config)
+	defer Close()
+	if err := LoadRuntimeState(path); err != nil {
+		t.Fatal(err)
+	}
+	defer SetFieldSnapshot(false)
+
+	if atomicLevel.Level().String() != "debug" || !GetFieldSnapshot() || quota != 42 {
+		t.Fatalf("运行时状态未恢复: level=%s snapshot=%v quota=%d", atomicLevel.Level(), GetFieldSnapshot(), quota)
+	}
+	if err := ImportRuntimeState([]byte(`{"level":"loud"}`)); err == nil {
+		t.Fatal("无效的日志级别应返回错误")
+	}
+}
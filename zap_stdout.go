@@ -0,0 +1,48 @@
+package mlog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 日志输出模式
+const (
+	OutputModeFile   = "file"   // 按级别（或单文件）写入日志目录，默认模式
+	OutputModeStdout = "stdout" // 容器模式：不创建任何目录和文件，每条日志以一行 JSON 输出到标准输出
+)
+
+// stdoutMode 是否为容器模式
+func (c *ZapConfig) stdoutMode() bool {
+	return c.OutputMode == OutputModeStdout
+}
+
+// newStdoutCore 创建容器模式下输出 JSON 行的 Core
+// 日志采集器不按目录区分服务，因此每条日志带 service 和 service_id 字段；
+// business/folder/directory 保留为普通字段，级别不带颜色；Format 为 ecs 或 gcp 时按对应的字段名输出
+func newStdoutCore(ws zapcore.WriteSyncer, serviceName string, serviceID uint64) zapcore.Core {
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        "time",
+		NameKey:        "name",
+		LevelKey:       "level",
+		CallerKey:      "caller",
+		MessageKey:     "message",
+		StacktraceKey:  zapConfig.StacktraceKey,
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeCaller:   zapConfig.CallerEncoder(),
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+	}
+	levelEnabler := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l >= atomicLevel.Level()
+	})
+	if zapConfig.structuredFormat() {
+		encoder := zapConfig.Encoder()
+		addEncoderService(encoder, serviceName, serviceID)
+		return zapcore.NewCore(encoder, ws, levelEnabler)
+	}
+	return zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), ws, levelEnabler).With([]zapcore.Field{
+		zap.String("service", serviceName),
+		zap.Uint64("service_id", serviceID),
+	})
+}
@@ -0,0 +1,55 @@
+package mlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestStdoutCore 测试容器模式每条日志一行 JSON，带服务信息并保留分类字段
+func TestStdoutCore(t *testing.T) {
+	oldLevel := atomicLevel
+	atomicLevel = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	defer func() { atomicLevel = oldLevel }()
+
+	var buf bytes.Buffer
+	log := zap.New(newStdoutCore(zapcore.AddSync(&buf), "gate", 2))
+	log.Debug("不输出")
+	log.Warn("连接数过高", zap.String("folder", "net"), zap.Int("conns", 9000))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 1 {
+		t.Fatalf("输出行数错误: %s", buf.String())
+	}
+	var line map[string]any
+	if err := json.Unmarshal(lines[0], &line); err != nil {
+		t.Fatal(err)
+	}
+	if line["level"] != "warn" || line["message"] != "连接数过高" || line["service"] != "gate" ||
+		line["service_id"] != float64(2) || line["folder"] != "net" || line["conns"] != float64(9000) {
+		t.Fatalf("JSON 内容错误: %v", line)
+	}
+}
+
+// TestStdoutModeNoFiles 测试容器模式不创建日志目录
+func TestStdoutModeNoFiles(t *testing.T) {
+	Close()
+	dir := filepath.Join(t.TempDir(), "logs")
+	config := ZapConfig{
+		Level:      "info",
+		Director:   dir,
+		OutputMode: OutputModeStdout,
+	}
+	InitialZap("gate", 2, "info", &config)
+	Info("容器模式")
+	Close()
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("容器模式不应创建日志目录: %v", err)
+	}
+}
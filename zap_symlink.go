@@ -0,0 +1,39 @@
+package mlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// currentLinkName 当前日志文件符号链接的名称
+func (z *ZapCore) currentLinkName() string {
+	if z.singleFile() {
+		return "current.log"
+	}
+	return z.level.String() + "-current.log"
+}
+
+// updateCurrentLink 将符号链接指向当前日志文件，调用方需持有 f.mu 的读锁
+// 先创建临时链接再重命名覆盖，tail -F 等工具不会看到链接缺失的中间状态；链接使用相对路径，整个日志目录移动后仍然有效
+func (f *fileWriteSyncer) updateCurrentLink() {
+	filename := f.logger.Filename
+	if filepath.Base(filename) == f.linkName {
+		return
+	}
+	link := filepath.Join(filepath.Dir(filename), f.linkName)
+	if target, err := os.Readlink(link); err == nil && target == filepath.Base(filename) {
+		return
+	}
+	tmp := link + ".tmp"
+	os.Remove(tmp)
+	err := os.Symlink(filepath.Base(filename), tmp)
+	if err == nil {
+		if err = os.Rename(tmp, link); err != nil {
+			os.Remove(tmp)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] 更新当前日志文件链接失败 [%s]: %v\n", link, err)
+	}
+}
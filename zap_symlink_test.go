@@ -0,0 +1,62 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TestCurrentSymlink 测试按级别和特殊目录维护指向当前日志文件的符号链接
+func TestCurrentSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 创建符号链接需要额外权限")
+	}
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{
+		Level:          "info",
+		Director:       dir,
+		MaxSize:        10,
+		FilePattern:    "{level}-{date}.log",
+		CurrentSymlink: true,
+	}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+	InfoW("链接")
+	InfoW("链接", zap.String("folder", "net"))
+
+	want := "info-" + time.Now().Format("2006-01-02") + ".log"
+	for _, link := range []string{
+		filepath.Join(dir, "2", "gate", "info-current.log"),
+		filepath.Join(dir, "2", "gate", "net", "info-current.log"),
+	} {
+		target, err := os.Readlink(link)
+		if err != nil || target != want {
+			t.Fatalf("%s 链接错误: %q %v", link, target, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2", "gate", "warn-current.log")); !os.IsNotExist(err) {
+		t.Fatalf("没有日志的级别不应创建链接: %v", err)
+	}
+}
+
+// TestCurrentSymlinkSingleFile 测试单文件模式的链接名为 current.log
+func TestCurrentSymlinkSingleFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 创建符号链接需要额外权限")
+	}
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, SingleFile: true, CurrentSymlink: true}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+	Info("链接")
+
+	if target, err := os.Readlink(filepath.Join(dir, "2", "gate", "current.log")); err != nil || target != "all.log" {
+		t.Fatalf("链接错误: %q %v", target, err)
+	}
+}
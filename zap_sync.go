@@ -1,9 +1,12 @@
 package mlog
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -14,17 +17,85 @@ var (
 	coreMutex   sync.RWMutex
 	zapCores    []*ZapCore
 	zapLogger   *zap.Logger
+	// sinkClosers 附加输出（syslog 等）在关闭日志器时需要释放的资源，受 coreMutex 保护
+	sinkClosers []io.Closer
+	// maintenanceClosers 日志目录的后台维护任务，受 coreMutex 保护
+	maintenanceClosers []io.Closer
 )
 
 func initZap(serviceName string, serviceID uint64) (logger *zap.Logger) {
-	// 判断是否有Director文件夹
-	fi, err := os.Stat(zapConfig.Director)
-	if (err == nil && !fi.IsDir()) || os.IsNotExist(err) {
-		fmt.Printf("create %v directory\n", zapConfig.Director)
-		if err := os.MkdirAll(zapConfig.Director, os.ModePerm); err != nil {
-			panic(fmt.Sprintf("创建日志目录失败: %v\n", err))
+	director := zapConfig.Director
+	activeDirector.Store(&director)
+	// 判断是否有Director文件夹，容器模式不创建任何目录
+	if !zapConfig.stdoutMode() {
+		fi, err := os.Stat(zapConfig.Director)
+		if (err == nil && !fi.IsDir()) || os.IsNotExist(err) {
+			fmt.Printf("create %v directory\n", zapConfig.Director)
+			if err := os.MkdirAll(zapConfig.Director, os.ModePerm); err != nil {
+				panic(fmt.Sprintf("创建日志目录失败: %v\n", err))
+			}
 		}
 	}
+	if _, err := parseFilePattern(zapConfig.FilePattern); err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] %v，使用默认文件名\n", err)
+	}
+	if _, err := parseRotateInterval(zapConfig.RotateInterval); err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] %v，只按大小轮转\n", err)
+	}
+	if !builtinFormat(zapConfig.Format) && getEncoderFactory(zapConfig.Format) == nil {
+		fmt.Fprintf(os.Stderr, "[mlog] 未注册的日志格式 %s，使用 console 格式\n", zapConfig.Format)
+	}
+	if _, errs := compileMaskRules(zapConfig.MaskRules); len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "[mlog] %v，已跳过\n", errors.Join(errs...))
+	}
+	if zapConfig.ConsoleTemplate != "" {
+		if _, err := parseConsoleTemplate(zapConfig.ConsoleTemplate); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] %v，使用默认 console 布局\n", err)
+		}
+	}
+	if _, err := zapConfig.timeLocation(); err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] %v，使用本地时区\n", err)
+	}
+	if err := configureConsoleColors(zapConfig.ConsoleColor); err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] %v，使用 auto 模式\n", err)
+	}
+	// 容器模式下标准输出由采集器读取，保持 JSON 行
+	consoleSink.pretty.Store(zapConfig.Format == FormatJSONPretty && !zapConfig.stdoutMode())
+	if _, err := parseLevelColors(zapConfig.LevelColors); err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] %v，使用默认颜色\n", err)
+	}
+	if _, err := parseLevelNames(zapConfig.LevelNames); err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] %v，已跳过\n", err)
+	}
+	metadata, err := metadataFields(zapConfig.GlobalFields, serviceName, serviceID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] %v，已跳过\n", err)
+	}
+	activeMetadata.Store(&metadata)
+	stacks, err := newStackPolicy(&zapConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] %v，不自动附加调用栈\n", err)
+	}
+	activeStackPolicy.Store(stacks)
+	switch zapConfig.MultiProcess {
+	case "", MultiProcessPID:
+	case MultiProcessFlock:
+		if !flockSupported {
+			fmt.Fprintf(os.Stderr, "[mlog] 当前平台不支持 flock 多进程模式，使用 pid 模式\n")
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "[mlog] 不支持的多进程模式 %s，按单进程写入\n", zapConfig.MultiProcess)
+	}
+	// 按级别路由输出，需要在创建 ZapCore 之前生效
+	routes, err := newLevelRoutes(zapConfig.Routes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[mlog] 解析日志路由失败，使用默认输出: %v\n", err)
+	} else if routes != nil {
+		routes.warnUnknownOutputs(configuredOutputs(&zapConfig))
+	}
+	activeRoutes.Store(routes)
+	consoleEnabled.Store(zapConfig.LogInConsole)
+
 	// 清空之前的核心
 	coreMutex.Lock()
 	zapCores = make([]*ZapCore, 0)
@@ -32,13 +103,22 @@ func initZap(serviceName string, serviceID uint64) (logger *zap.Logger) {
 	levels := zapConfig.Levels()
 	cores := make([]zapcore.Core, 0)
 
-	if zapConfig.SingleFile {
+	if zapConfig.stdoutMode() {
+		// 容器模式：不使用 ZapCore 和 lumberjack，所有级别以 JSON 行写入标准输出
+		cores = append(cores, newStdoutCore(consoleSink, serviceName, serviceID))
+	} else if zapConfig.SingleFile {
 		// 【修复】单文件模式：只创建一个Debug级别的Core
 		// 这个Core会处理所有 >= Debug 且 >= atomicLevel 的日志
 		// 避免多个Core重复写入同一个文件
 		core := NewZapCoreWithService(zapcore.DebugLevel, serviceName, serviceID)
 		zapCores = append(zapCores, core)
 		cores = append(cores, core)
+		// Error 及以上级别额外写入 error.log，便于值班时只看高信号的日志
+		if zapConfig.AlwaysSeparateErrors {
+			errorCore := newErrorCopyCore(serviceName, serviceID)
+			zapCores = append(zapCores, errorCore)
+			cores = append(cores, errorCore)
+		}
 	} else {
 		// 多文件模式：为每个级别创建独立的Core
 		// 每个Core只处理自己级别的日志，写入对应的文件
@@ -50,7 +130,248 @@ func initZap(serviceName string, serviceID uint64) (logger *zap.Logger) {
 	}
 	coreMutex.Unlock()
 
-	logger = zap.New(zapcore.NewTee(cores...))
+	// 配置了路由时 ZapCore 只写文件，控制台由单独的 Core 按路由输出
+	// 未配置路由时该 Core 不输出，路由热更新后直接生效
+	// 容器模式下日志本身就输出到标准输出，不再单独输出控制台
+	if !zapConfig.stdoutMode() {
+		cores = append(cores, newRoutedConsoleCore())
+	}
+
+	// 最近日志缓冲区，用于生成反作弊证据包
+	if zapConfig.EvidenceBufferSize > 0 {
+		buffer := newRecentEntriesBuffer(zapConfig.EvidenceBufferSize, zapConfig.EvidencePlayerKey)
+		setRecentEntriesBuffer(buffer)
+		cores = append(cores, newRecentEntriesCore(buffer))
+	} else {
+		setRecentEntriesBuffer(nil)
+	}
+
+	// 调用位置日志量统计
+	if zapConfig.CallSiteStats {
+		cores = append(cores, newCallSiteCore())
+	}
+
+	// syslog 输出，连接失败时只输出文件日志
+	if zapConfig.Syslog.Enable {
+		writer, err := newSyslogWriter(zapConfig.Syslog, serviceName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 syslog 输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("syslog", newSyslogCore(writer, zapConfig.Syslog.RemoteBatchConfig)))
+		}
+	}
+
+	// Kafka 输出
+	if zapConfig.Kafka.Enable {
+		if core, err := newKafkaCore(zapConfig.Kafka, serviceName, serviceID); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 Kafka 输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("kafka", core))
+		}
+	}
+
+	// Pulsar 输出
+	if zapConfig.Pulsar.Enable {
+		if core, err := newPulsarCore(zapConfig.Pulsar, serviceName, serviceID); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 Pulsar 输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("pulsar", core))
+		}
+	}
+
+	// Graylog GELF 输出
+	if zapConfig.GELF.Enable {
+		if core, err := newGELFCore(zapConfig.GELF, serviceName, serviceID); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 GELF 输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("gelf", core))
+		}
+	}
+
+	// OpenTelemetry OTLP 日志导出
+	if zapConfig.OTLP.Enable {
+		if core, err := newOTLPCore(zapConfig.OTLP, serviceName, serviceID); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 OTLP 日志导出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("otlp", core))
+		}
+	}
+
+	// Elasticsearch 输出
+	if zapConfig.Elasticsearch.Enable {
+		if core, err := newElasticsearchCore(zapConfig.Elasticsearch, serviceName); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 Elasticsearch 输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("elasticsearch", core))
+		}
+	}
+
+	// Google Cloud Logging 输出
+	if zapConfig.GCPLogging.Enable {
+		if core, err := newGCPLoggingCore(zapConfig.GCPLogging, serviceName, serviceID); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 Cloud Logging 输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("gcp-logging", core))
+		}
+	}
+
+	// Azure Monitor（Log Analytics）输出
+	if zapConfig.AzureMonitor.Enable {
+		if core, err := newAzureMonitorCore(zapConfig.AzureMonitor, serviceName, serviceID); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 Azure Monitor 输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("azure-monitor", core))
+		}
+	}
+
+	// 通用 TCP/UDP 网络输出
+	if zapConfig.NetSink.Enable {
+		if core, err := newNetSinkCore(zapConfig.NetSink); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化网络输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("net-sink", core))
+		}
+	}
+
+	// 通用 HTTP 批量输出
+	if zapConfig.HTTPSink.Enable {
+		if core, err := newHTTPSinkCore(zapConfig.HTTPSink); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 HTTP 输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("http-sink", core))
+		}
+	}
+
+	// Unix 域套接字输出
+	if zapConfig.UnixSocket.Enable {
+		if core, err := newUnixSocketCore(zapConfig.UnixSocket); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 Unix 套接字输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("unix-socket", core))
+		}
+	}
+
+	// ClickHouse 输出
+	if zapConfig.ClickHouse.Enable {
+		if core, err := newClickHouseCore(zapConfig.ClickHouse, serviceName, serviceID); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 ClickHouse 输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("clickhouse", core))
+		}
+	}
+
+	// 本地 SQLite 输出
+	if zapConfig.SQLite.Enable {
+		if core, err := newSQLiteCore(zapConfig.SQLite, serviceName, serviceID); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 SQLite 输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("sqlite", core))
+		}
+	}
+
+	// NATS 输出
+	if zapConfig.NATS.Enable {
+		if core, err := newNATSCore(zapConfig.NATS, serviceName); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 NATS 输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("nats", core))
+		}
+	}
+
+	// MQTT 输出
+	if zapConfig.MQTT.Enable {
+		if core, err := newMQTTCore(zapConfig.MQTT, serviceName, serviceID); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 MQTT 输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("mqtt", core))
+		}
+	}
+
+	// Redis Stream 输出
+	if zapConfig.RedisStream.Enable {
+		if core, err := newRedisStreamCore(zapConfig.RedisStream, serviceName); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 Redis Stream 输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("redis-stream", core))
+		}
+	}
+
+	// 中心日志收集服务
+	if zapConfig.Collector.Enable {
+		if core, err := newCollectorCore(zapConfig.Collector, serviceName, serviceID); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化日志收集服务输出失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("collector", core))
+		}
+	}
+
+	// Critical/Disaster 告警 Webhook
+	if zapConfig.AlertWebhook.Enable {
+		if core, err := newAlertCore(zapConfig.AlertWebhook, serviceName, serviceID); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化告警 Webhook 失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("alert-webhook", core))
+		}
+	}
+
+	// Disaster 邮件摘要
+	if zapConfig.EmailDigest.Enable {
+		if core, err := newEmailDigestCore(zapConfig.EmailDigest, serviceName, serviceID); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化 Disaster 邮件摘要失败: %v\n", err)
+		} else {
+			cores = append(cores, routeOutput("email-digest", core))
+		}
+	}
+
+	// 通过 RegisterSink 注册的可插拔输出
+	cores = append(cores, newPluggableSinkCores(zapConfig.Sinks, serviceName, serviceID)...)
+
+	// 轮转文件校验和清单
+	manifestEnabled.Store(zapConfig.IntegrityManifest && !zapConfig.stdoutMode())
+
+	// 日志目录的后台维护任务
+	maintenance := startMaintenance(serviceName, serviceID)
+	coreMutex.Lock()
+	maintenanceClosers = maintenance
+	coreMutex.Unlock()
+
+	core := zapcore.NewTee(cores...)
+	// 按级别采样，同步模式下也能在 Core 层稀释日志洪峰
+	// 被采样、去重和异步队列丢弃的条数附加到之后写出的相同日志上
+	dropped := newDroppedCounter()
+	activeDropped.Store(dropped)
+	core = newDroppedCore(core, dropped)
+	// 采样和脱敏可以热更新，只替换这两层，下层的去重状态和所有输出保持不变
+	hot := &hotCores{}
+	hot.config.Store(hotConfigOf(&zapConfig))
+	hot.sampling.Store(samplingWrapper(zapConfig.Sampling, dropped))
+	hot.redact.Store(redactWrapper(&zapConfig))
+	activeHotCores.Store(hot)
+	core = newReloadableCore(core, &hot.sampling)
+	// 合并去重窗口内重复的日志
+	if zapConfig.EnableDedup {
+		dedup := newDedupCore(core, time.Duration(zapConfig.DedupWindowMs)*time.Millisecond)
+		dedup.state.dropped = dropped
+		activeDedup.Store(dedup.state)
+		core = dedup
+	} else {
+		activeDedup.Store(nil)
+	}
+	// 消息和字段大小限制，在脱敏之后截断，避免截断后敏感片段不再匹配
+	core = newLimitCore(core, &zapConfig)
+	// 敏感字段脱敏，在截断、去重和采样之前进行，它们和所有输出看到的都是脱敏后的内容
+	core = newReloadableCore(core, &hot.redact)
+	// 字段值转换在脱敏之前进行，转换后的内容同样会被脱敏和截断
+	core = newTransformCore(core)
+	// 序号在所有过滤之前分配，被采样、去重丢弃的日志体现为序号间隔
+	core = newSeqCore(core, &zapConfig)
+	// 调用栈在调用方的协程中获取
+	core = newStackCore(core, stacks)
+	// 按模块级别过滤，位于最外层（最先执行），被过滤的日志不分配序号
+	core = newModuleCore(core)
+
+	// 全局元数据字段
+	logger = withMetadata(zap.New(core))
 
 	if zapConfig.ShowLine {
 		// 修复 caller skip 设置：
@@ -59,5 +380,84 @@ func initZap(serviceName string, serviceID uint64) (logger *zap.Logger) {
 		// 注意：如果通过 mlog 包装函数调用，那些函数内部会有额外的 skip 处理
 		logger = logger.WithOptions(zap.AddCaller(), zap.AddCallerSkip(0))
 	}
+	if zapConfig.Development {
+		// 开发模式：DPanic 级别日志记录后 panic，生产模式只记录日志
+		logger = logger.WithOptions(zap.Development())
+	}
 	return logger
 }
+
+// startMaintenance 按当前日志目录启动后台维护任务（zstd 压缩、加密、总大小上限、过期清理、归档），返回用于停止的 Closer
+// SetDirector 修改日志目录后停止原有任务并按新目录重新启动
+func startMaintenance(serviceName string, serviceID uint64) []io.Closer {
+	var closers []io.Closer
+	// 轮转文件 zstd 压缩
+	if zapConfig.EnableCompress && !zapConfig.stdoutMode() {
+		switch zapConfig.CompressAlgo {
+		case "", CompressGzip:
+		case CompressZstd:
+			if zapConfig.zstdCompression() {
+				closers = append(closers, newZstdCompressor(logDirFor(serviceName, serviceID)))
+			} else {
+				fmt.Fprintf(os.Stderr, "[mlog] 未设置 zstd 编码器（SetZstdEncoder），轮转文件使用 gzip 压缩\n")
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "[mlog] 不支持的压缩算法 %s，轮转文件使用 gzip 压缩\n", zapConfig.CompressAlgo)
+		}
+	}
+
+	// 轮转日志文件加密，需要在压缩之后进行
+	if zapConfig.Encryption.Enable && !zapConfig.stdoutMode() {
+		if encryptor, err := newLogEncryptor(zapConfig.Encryption, logDirFor(serviceName, serviceID)); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化轮转日志加密失败，日志文件不加密: %v\n", err)
+		} else {
+			closers = append(closers, encryptor)
+		}
+	}
+
+	// 日志目录总大小上限
+	if zapConfig.MaxTotalSizeMB > 0 && !zapConfig.stdoutMode() {
+		closers = append(closers, newLogJanitor(currentDirector()))
+	}
+
+	// 过期日志文件和空目录清理
+	if zapConfig.CleanStaleDirs && !zapConfig.stdoutMode() {
+		closers = append(closers, newLogDirCleaner(currentDirector(), zapConfig.RetentionDay))
+	}
+
+	// 轮转日志文件归档
+	if zapConfig.Archive.Enable {
+		if archiver, err := newLogArchiver(zapConfig.Archive, serviceName, serviceID); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 初始化轮转日志归档失败: %v\n", err)
+		} else {
+			closers = append(closers, archiver)
+		}
+	}
+	return closers
+}
+
+// addSinkCloser 登记附加输出，关闭日志器时一并关闭
+func addSinkCloser(c io.Closer) {
+	coreMutex.Lock()
+	sinkClosers = append(sinkClosers, c)
+	coreMutex.Unlock()
+}
+
+// closeSinksLocked 关闭所有附加输出，调用方需持有 coreMutex
+func closeSinksLocked() {
+	for _, c := range sinkClosers {
+		if err := c.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 关闭附加日志输出失败: %v\n", err)
+		}
+	}
+	sinkClosers = nil
+	closeMaintenanceLocked()
+}
+
+// closeMaintenanceLocked 停止所有后台维护任务，调用方需持有 coreMutex
+func closeMaintenanceLocked() {
+	for _, c := range maintenanceClosers {
+		c.Close()
+	}
+	maintenanceClosers = nil
+}
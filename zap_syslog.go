@@ -0,0 +1,305 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// syslogFacilities syslog 设施名称与编号
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSeverities syslog 严重程度名称与编号
+var syslogSeverities = map[string]int{
+	"emerg": 0, "alert": 1, "crit": 2, "err": 3, "warning": 4, "notice": 5, "info": 6, "debug": 7,
+}
+
+// defaultSyslogSeverity 日志级别默认对应的 syslog 严重程度
+var defaultSyslogSeverity = map[zapcore.Level]int{
+	zapcore.DebugLevel:  7,
+	zapcore.InfoLevel:   6,
+	zapcore.WarnLevel:   4,
+	zapcore.ErrorLevel:  3,
+	zapcore.DPanicLevel: 2,
+	zapcore.PanicLevel:  1,
+	zapcore.FatalLevel:  0,
+}
+
+// syslogLocalAddresses 本地 syslog 守护进程的常见套接字路径
+var syslogLocalAddresses = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogConfig syslog 输出配置
+type SyslogConfig struct {
+	Enable   bool   `mapstructure:"enable" json:"enable" yaml:"enable"`       // 启用 syslog 输出
+	Network  string `mapstructure:"network" json:"network" yaml:"network"`    // 连接方式：空表示本地套接字，udp、tcp、unixgram、unix
+	Address  string `mapstructure:"address" json:"address" yaml:"address"`    // 服务器地址，本地套接字为空时自动探测 /dev/log 等路径
+	Facility string `mapstructure:"facility" json:"facility" yaml:"facility"` // 设施名称（默认 local0）
+	AppName  string `mapstructure:"app-name" json:"app-name" yaml:"app-name"` // APP-NAME 字段（默认为服务名）
+	Hostname string `mapstructure:"hostname" json:"hostname" yaml:"hostname"` // HOSTNAME 字段（默认为本机主机名）
+	// 日志级别到 syslog 严重程度的映射（如 warn: notice），未配置的级别使用默认映射
+	Severity          map[string]string `mapstructure:"severity" json:"severity" yaml:"severity"`
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// syslogWriter 按 RFC 5424 格式发送日志的 syslog 客户端
+// TCP 和 unix 流式连接使用 RFC 6587 的长度前缀分帧，数据报连接每条日志一个数据报；
+// 日志由批量发送器在后台协程中发送，syslog 不可用时连接和重连不阻塞记录日志的协程
+type syslogWriter struct {
+	network  string
+	address  string
+	facility int
+	appName  string
+	hostname string
+	procID   string
+	severity map[zapcore.Level]int
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// newSyslogWriter 根据配置创建 syslog 客户端并建立连接
+func newSyslogWriter(cfg SyslogConfig, serviceName string) (*syslogWriter, error) {
+	facility := 16
+	if cfg.Facility != "" {
+		f, ok := syslogFacilities[strings.ToLower(cfg.Facility)]
+		if !ok {
+			return nil, fmt.Errorf("未知的 syslog 设施: %s", cfg.Facility)
+		}
+		facility = f
+	}
+
+	severity := make(map[zapcore.Level]int, len(defaultSyslogSeverity))
+	for level, s := range defaultSyslogSeverity {
+		severity[level] = s
+	}
+	for name, sev := range cfg.Severity {
+		level, err := zapcore.ParseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 syslog 级别映射: %s", name)
+		}
+		s, ok := syslogSeverities[strings.ToLower(sev)]
+		if !ok {
+			return nil, fmt.Errorf("未知的 syslog 严重程度: %s", sev)
+		}
+		severity[level] = s
+	}
+
+	w := &syslogWriter{
+		network:  cfg.Network,
+		address:  cfg.Address,
+		facility: facility,
+		appName:  syslogHeaderField(cfg.AppName, serviceName),
+		hostname: syslogHeaderField(cfg.Hostname, ""),
+		procID:   strconv.Itoa(os.Getpid()),
+		severity: severity,
+	}
+	if cfg.Hostname == "" {
+		if host, err := os.Hostname(); err == nil {
+			w.hostname = syslogHeaderField(host, "")
+		}
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// syslogHeaderField 返回 RFC 5424 头部字段，空值使用 NILVALUE，空格替换为下划线
+func syslogHeaderField(value, fallback string) string {
+	if value == "" {
+		value = fallback
+	}
+	if value == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(value, " ", "_")
+}
+
+// connect 建立连接，本地模式下依次尝试常见的套接字路径
+func (w *syslogWriter) connect() error {
+	if w.network != "" {
+		conn, err := net.DialTimeout(w.network, w.address, 3*time.Second)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+		return nil
+	}
+
+	addresses := syslogLocalAddresses
+	if w.address != "" {
+		addresses = []string{w.address}
+	}
+	var errs []error
+	for _, addr := range addresses {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.DialTimeout(network, addr, 3*time.Second)
+			if err == nil {
+				w.conn = conn
+				return nil
+			}
+			errs = append(errs, err)
+		}
+	}
+	return fmt.Errorf("连接本地 syslog 失败: %w", errors.Join(errs...))
+}
+
+// format 构造 RFC 5424 消息
+func (w *syslogWriter) format(level zapcore.Level, t time.Time, msg []byte) []byte {
+	severity, ok := w.severity[level]
+	if !ok {
+		severity = 6
+	}
+	buf := make([]byte, 0, len(msg)+128)
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(w.facility*8+severity), 10)
+	buf = append(buf, ">1 "...)
+	buf = t.AppendFormat(buf, "2006-01-02T15:04:05.000000Z07:00")
+	buf = append(buf, ' ')
+	buf = append(buf, w.hostname...)
+	buf = append(buf, ' ')
+	buf = append(buf, w.appName...)
+	buf = append(buf, ' ')
+	buf = append(buf, w.procID...)
+	buf = append(buf, " - - "...)
+	return append(buf, msg...)
+}
+
+// sendBatch 逐条发送一批已格式化的消息
+func (w *syslogWriter) sendBatch(ctx context.Context, batch []remoteRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range batch {
+		if err := w.sendLocked(batch[i].Data); err != nil {
+			// 已发送的条目不再重试
+			return &remotePartialError{Retry: batch[i:], Err: err}
+		}
+	}
+	return nil
+}
+
+// sendLocked 发送一条消息，连接断开时重连一次后重试
+func (w *syslogWriter) sendLocked(frame []byte) error {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			if err = w.connect(); err != nil {
+				continue
+			}
+		}
+		if err = w.send(frame); err == nil {
+			return nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	return err
+}
+
+// send 按连接类型分帧发送
+func (w *syslogWriter) send(frame []byte) error {
+	switch w.conn.(type) {
+	case *net.TCPConn:
+		return w.sendOctetCounted(frame)
+	case *net.UnixConn:
+		if w.conn.LocalAddr().Network() == "unix" {
+			return w.sendOctetCounted(frame)
+		}
+	}
+	_, err := w.conn.Write(frame)
+	return err
+}
+
+// sendOctetCounted 使用 RFC 6587 长度前缀分帧发送
+func (w *syslogWriter) sendOctetCounted(frame []byte) error {
+	prefixed := strconv.AppendInt(make([]byte, 0, len(frame)+8), int64(len(frame)), 10)
+	prefixed = append(prefixed, ' ')
+	_, err := w.conn.Write(append(prefixed, frame...))
+	return err
+}
+
+// Close 关闭连接
+func (w *syslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// syslogCore 将日志发送到 syslog 的 zapcore.Core
+// 时间和严重程度写在 RFC 5424 头部，消息体为 JSON 编码的日志内容
+type syslogCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	writer  *syslogWriter
+	batcher *remoteBatcher
+}
+
+// newSyslogCore 创建 syslog 输出 Core 和批量发送器，关闭日志器时先发送剩余日志再关闭连接
+func newSyslogCore(writer *syslogWriter, cfg RemoteBatchConfig) *syslogCore {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = ""
+	batcher := newRemoteBatcher("syslog", cfg, writer.sendBatch)
+	addSinkCloser(batcher)
+	addSinkCloser(writer)
+	return &syslogCore{
+		LevelEnabler: atomicLevel,
+		encoder:      zapcore.NewJSONEncoder(encoderConfig),
+		writer:       writer,
+		batcher:      batcher,
+	}
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &syslogCore{
+		LevelEnabler: c.LevelEnabler,
+		encoder:      c.encoder.Clone(),
+		writer:       c.writer,
+		batcher:      c.batcher,
+	}
+	for i := range fields {
+		fields[i].AddTo(clone.encoder)
+	}
+	return clone
+}
+
+func (c *syslogCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *syslogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	frame := c.writer.format(entry.Level, entry.Time, bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	c.batcher.add(remoteRecord{Entry: entry, Data: frame})
+	return nil
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,132 @@
+package mlog
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// syslogLinePattern RFC 5424 消息头
+var syslogLinePattern = regexp.MustCompile(`^<(\d+)>1 \S+ host1 game-\d+ \d+ - - (\{.*\})$`)
+
+// TestSyslogUDP 测试通过 UDP 发送 RFC 5424 消息及严重程度映射
+func TestSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	writer, err := newSyslogWriter(SyslogConfig{
+		Network:  "udp",
+		Address:  conn.LocalAddr().String(),
+		Facility: "local3",
+		Hostname: "host1",
+		Severity: map[string]string{"warn": "notice"},
+	}, "game-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	core := newSyslogCore(writer, RemoteBatchConfig{FlushIntervalMs: 10})
+	defer core.batcher.Close()
+	core.LevelEnabler = zapcore.DebugLevel
+	logger := zap.New(core).With(zap.Int("room", 7))
+
+	logger.Warn("房间已满")
+	logger.Error("匹配失败")
+
+	buf := make([]byte, 2048)
+	for _, wantPri := range []int{19*8 + 5, 19*8 + 3} {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := syslogLinePattern.FindStringSubmatch(string(buf[:n]))
+		if m == nil || m[1] != strconv.Itoa(wantPri) || !strings.Contains(m[2], `"room":7`) {
+			t.Fatalf("消息格式错误: %q", buf[:n])
+		}
+	}
+}
+
+// TestSyslogTCPFraming 测试 TCP 连接使用长度前缀分帧
+func TestSyslogTCPFraming(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		length, _ := r.ReadString(' ')
+		n, _ := strconv.Atoi(strings.TrimSpace(length))
+		frame := make([]byte, n)
+		if _, err := io.ReadFull(r, frame); err == nil {
+			received <- string(frame)
+		}
+	}()
+
+	writer, err := newSyslogWriter(SyslogConfig{Network: "tcp", Address: ln.Addr().String(), Hostname: "host1"}, "game-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	frame := writer.format(zapcore.InfoLevel, time.Now(), []byte(`{"msg":"hello"}`))
+	if err := writer.sendBatch(context.Background(), []remoteRecord{{Data: frame}}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case frame := <-received:
+		if m := syslogLinePattern.FindStringSubmatch(frame); m == nil || m[1] != strconv.Itoa(16*8+6) {
+			t.Fatalf("帧内容错误: %q", frame)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("未收到消息")
+	}
+}
+
+// TestSyslogInvalidConfig 测试无效的设施和严重程度配置
+func TestSyslogInvalidConfig(t *testing.T) {
+	if _, err := newSyslogWriter(SyslogConfig{Network: "udp", Address: "127.0.0.1:514", Facility: "bogus"}, ""); err == nil {
+		t.Fatal("未知设施应返回错误")
+	}
+	if _, err := newSyslogWriter(SyslogConfig{Network: "udp", Address: "127.0.0.1:514", Severity: map[string]string{"info": "loud"}}, ""); err == nil {
+		t.Fatal("未知严重程度应返回错误")
+	}
+}
+
+// TestSyslogUnavailableDoesNotBlock 测试 syslog 不可用时记录日志立即返回，连接和重试在后台进行
+func TestSyslogUnavailableDoesNotBlock(t *testing.T) {
+	// 不可路由的地址，连接会一直等到超时
+	writer := &syslogWriter{network: "tcp", address: "10.255.255.1:514", facility: 16, appName: "game", hostname: "host1",
+		procID: "1", severity: defaultSyslogSeverity}
+	defer writer.Close()
+	core := newSyslogCore(writer, RemoteBatchConfig{BatchSize: 1, MaxRetries: -1})
+	defer core.batcher.Close()
+	core.LevelEnabler = zapcore.DebugLevel
+	logger := zap.New(core)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		logger.Error("syslog 不可用")
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("记录日志不应等待 syslog 连接: %v", elapsed)
+	}
+}
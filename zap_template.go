@@ -0,0 +1,205 @@
+package mlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// ConsoleTemplate 中可以使用的占位符
+const (
+	TemplateTime       = "time"       // 时间，按 TimeFormat 格式化
+	TemplateLevel      = "level"      // 级别，按 EncodeLevel、LevelColors、LevelNames 输出
+	TemplateLogger     = "logger"     // logger 名称（zap.Logger.Named）
+	TemplateCaller     = "caller"     // 调用位置，按 UseRelativePath 输出
+	TemplateMessage    = "msg"        // 消息
+	TemplateFields     = "fields"     // 全部字段，JSON 对象，没有字段时为空
+	TemplateStacktrace = "stacktrace" // 堆栈，模板中没有该占位符时堆栈输出在下一行
+)
+
+// templatePool 编码结果的缓冲
+var templatePool = buffer.NewPool()
+
+// templateSegment 模板的一段：placeholder 为空时为原样输出的文本
+type templateSegment struct {
+	text        string
+	placeholder string
+}
+
+// parseConsoleTemplate 解析 ConsoleTemplate，{{ 和 }} 输出为 { 和 }，未知的占位符返回错误
+func parseConsoleTemplate(tmpl string) ([]templateSegment, error) {
+	var (
+		segments []templateSegment
+		text     strings.Builder
+	)
+	for i := 0; i < len(tmpl); i++ {
+		switch c := tmpl[i]; {
+		case c == '{' && strings.HasPrefix(tmpl[i:], "{{"), c == '}' && strings.HasPrefix(tmpl[i:], "}}"):
+			text.WriteByte(c)
+			i++
+		case c == '{':
+			end := strings.IndexByte(tmpl[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("控制台模板缺少 }: %s", tmpl)
+			}
+			name := tmpl[i+1 : i+end]
+			switch name {
+			case TemplateTime, TemplateLevel, TemplateLogger, TemplateCaller, TemplateMessage, TemplateFields, TemplateStacktrace:
+			default:
+				return nil, fmt.Errorf("控制台模板中未知的占位符 {%s}", name)
+			}
+			if text.Len() > 0 {
+				segments = append(segments, templateSegment{text: text.String()})
+				text.Reset()
+			}
+			segments = append(segments, templateSegment{placeholder: name})
+			i += end
+		default:
+			text.WriteByte(c)
+		}
+	}
+	if text.Len() > 0 {
+		segments = append(segments, templateSegment{text: text.String()})
+	}
+	return segments, nil
+}
+
+// templateEncoder 按 ConsoleTemplate 排列一行日志的 console 编码器
+// 字段（含 With 添加的字段）由内部的 JSON 编码器编码为一个 JSON 对象，与 zap console 格式的字段部分相同；
+// 替换占位符后去掉行尾的空白，没有字段时不会留下多余的分隔符
+type templateEncoder struct {
+	zapcore.Encoder // 只编码字段的 JSON 编码器
+	config          zapcore.EncoderConfig
+	segments        []templateSegment
+	hasStack        bool
+}
+
+// newTemplateEncoder 按模板创建编码器，模板无效时返回错误
+func newTemplateEncoder(config zapcore.EncoderConfig, tmpl string) (zapcore.Encoder, error) {
+	segments, err := parseConsoleTemplate(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	e := &templateEncoder{
+		Encoder: zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+			EncodeTime:     config.EncodeTime,
+			EncodeDuration: config.EncodeDuration,
+			SkipLineEnding: true,
+		}),
+		config:   config,
+		segments: segments,
+	}
+	for _, s := range segments {
+		if s.placeholder == TemplateStacktrace {
+			e.hasStack = true
+		}
+	}
+	return e, nil
+}
+
+func (e *templateEncoder) Clone() zapcore.Encoder {
+	return &templateEncoder{Encoder: e.Encoder.Clone(), config: e.config, segments: e.segments, hasStack: e.hasStack}
+}
+
+func (e *templateEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	fieldsBuf, err := e.Encoder.EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer fieldsBuf.Free()
+	fieldsJSON := fieldsBuf.String()
+	if fieldsJSON == "{}" {
+		fieldsJSON = ""
+	}
+
+	buf := templatePool.Get()
+	for _, s := range e.segments {
+		switch s.placeholder {
+		case "":
+			buf.AppendString(s.text)
+		case TemplateTime:
+			if e.config.EncodeTime != nil {
+				appendPrimitive(buf, func(enc zapcore.PrimitiveArrayEncoder) { e.config.EncodeTime(entry.Time, enc) })
+			} else {
+				buf.AppendString(entry.Time.Format(defaultTimeLayout))
+			}
+		case TemplateLevel:
+			if e.config.EncodeLevel != nil {
+				appendPrimitive(buf, func(enc zapcore.PrimitiveArrayEncoder) { e.config.EncodeLevel(entry.Level, enc) })
+			} else {
+				buf.AppendString(entry.Level.String())
+			}
+		case TemplateLogger:
+			buf.AppendString(entry.LoggerName)
+		case TemplateCaller:
+			if entry.Caller.Defined && e.config.EncodeCaller != nil {
+				appendPrimitive(buf, func(enc zapcore.PrimitiveArrayEncoder) { e.config.EncodeCaller(entry.Caller, enc) })
+			}
+		case TemplateMessage:
+			buf.AppendString(entry.Message)
+		case TemplateFields:
+			buf.AppendString(fieldsJSON)
+		case TemplateStacktrace:
+			buf.AppendString(entry.Stack)
+		}
+	}
+	line := strings.TrimRight(buf.String(), " \t")
+	buf.Reset()
+	buf.AppendString(line)
+	if entry.Stack != "" && !e.hasStack && e.config.StacktraceKey != "" {
+		buf.AppendByte('\n')
+		buf.AppendString(entry.Stack)
+	}
+	if e.config.LineEnding != "" {
+		buf.AppendString(e.config.LineEnding)
+	} else {
+		buf.AppendString(zapcore.DefaultLineEnding)
+	}
+	return buf, nil
+}
+
+// appendPrimitive 调用 zap 的时间、级别或调用位置编码器，把输出的值以文本写入 buf，多个值以空格分隔
+func appendPrimitive(buf *buffer.Buffer, encode func(zapcore.PrimitiveArrayEncoder)) {
+	encode(&textArrayEncoder{buf: buf, start: buf.Len()})
+}
+
+// textArrayEncoder 把基本类型的值以文本追加到缓冲的 PrimitiveArrayEncoder
+type textArrayEncoder struct {
+	buf   *buffer.Buffer
+	start int
+}
+
+// sep 第二个及之后的值前加空格
+func (a *textArrayEncoder) sep() {
+	if a.buf.Len() > a.start {
+		a.buf.AppendByte(' ')
+	}
+}
+
+func (a *textArrayEncoder) AppendBool(v bool)         { a.sep(); a.buf.AppendBool(v) }
+func (a *textArrayEncoder) AppendByteString(v []byte) { a.sep(); a.buf.Write(v) }
+func (a *textArrayEncoder) AppendComplex128(v complex128) {
+	a.sep()
+	a.buf.AppendString(strconv.FormatComplex(v, 'g', -1, 128))
+}
+func (a *textArrayEncoder) AppendComplex64(v complex64) {
+	a.sep()
+	a.buf.AppendString(strconv.FormatComplex(complex128(v), 'g', -1, 64))
+}
+func (a *textArrayEncoder) AppendFloat64(v float64) { a.sep(); a.buf.AppendFloat(v, 64) }
+func (a *textArrayEncoder) AppendFloat32(v float32) { a.sep(); a.buf.AppendFloat(float64(v), 32) }
+func (a *textArrayEncoder) AppendInt(v int)         { a.AppendInt64(int64(v)) }
+func (a *textArrayEncoder) AppendInt64(v int64)     { a.sep(); a.buf.AppendInt(v) }
+func (a *textArrayEncoder) AppendInt32(v int32)     { a.AppendInt64(int64(v)) }
+func (a *textArrayEncoder) AppendInt16(v int16)     { a.AppendInt64(int64(v)) }
+func (a *textArrayEncoder) AppendInt8(v int8)       { a.AppendInt64(int64(v)) }
+func (a *textArrayEncoder) AppendString(v string)   { a.sep(); a.buf.AppendString(v) }
+func (a *textArrayEncoder) AppendUint(v uint)       { a.AppendUint64(uint64(v)) }
+func (a *textArrayEncoder) AppendUint64(v uint64)   { a.sep(); a.buf.AppendUint(v) }
+func (a *textArrayEncoder) AppendUint32(v uint32)   { a.AppendUint64(uint64(v)) }
+func (a *textArrayEncoder) AppendUint16(v uint16)   { a.AppendUint64(uint64(v)) }
+func (a *textArrayEncoder) AppendUint8(v uint8)     { a.AppendUint64(uint64(v)) }
+func (a *textArrayEncoder) AppendUintptr(v uintptr) { a.AppendUint64(uint64(v)) }
@@ -0,0 +1,49 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestConsoleTemplate 测试按模板排列 console 日志行，没有字段时不留下行尾空白
+func TestConsoleTemplate(t *testing.T) {
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "console", SingleFile: true, ShowLine: true,
+		UseRelativePath: true, EncodeLevel: "CapitalLevelEncoder", StacktraceLevel: "error",
+		ConsoleTemplate: "{time} |{level}| {{{caller}}} {msg} {fields}"}
+	InitialZap("gate", 2, "info", &config)
+	GLOG().With(zap.String("zone", "cn")).Info("玩家登录", zap.Int("player_id", 1001))
+	GLOG().Warn("背包已满")
+	GLOG().Error("存档失败")
+	Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "2", "gate", "all.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(data), "\n")
+	first := strings.SplitN(lines[0], " |", 2)
+	if len(first) != 2 || !strings.HasPrefix(first[1], "INFO| {") || !strings.HasSuffix(first[1], `} 玩家登录 {"zone":"cn","player_id":1001}`) {
+		t.Fatalf("第一行错误: %q", lines[0])
+	}
+	if !strings.Contains(first[1], "zap_template_test.go:") {
+		t.Fatalf("缺少调用位置: %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "} 背包已满") {
+		t.Fatalf("没有字段时不应留下行尾空白: %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], "} 存档失败") || !strings.HasPrefix(lines[3], "mlog.TestConsoleTemplate") {
+		t.Fatalf("堆栈应输出在下一行: %q", lines[2:])
+	}
+
+	for _, tmpl := range []string{"{time} {unknown}", "{time"} {
+		if _, err := parseConsoleTemplate(tmpl); err == nil {
+			t.Fatalf("无效的模板应返回错误: %s", tmpl)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package mlog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TimeFormat 的预设值，其他值按 Go 时间布局处理
+const (
+	TimeFormatRFC3339     = "rfc3339"      // 2006-01-02T15:04:05Z07:00
+	TimeFormatRFC3339Nano = "rfc3339nano"  // 2006-01-02T15:04:05.999999999Z07:00
+	TimeFormatEpoch       = "epoch"        // Unix 秒
+	TimeFormatEpochMillis = "epoch-millis" // Unix 毫秒
+	TimeFormatEpochNanos  = "epoch-nanos"  // Unix 纳秒
+)
+
+// defaultTimeLayout 未配置 TimeFormat 时的时间布局
+const defaultTimeLayout = "2006-01-02 15:04:05.000"
+
+// timeLayout 返回字符串时间格式的布局，epoch 系列返回空字符串
+func (c *ZapConfig) timeLayout() string {
+	switch strings.ToLower(c.TimeFormat) {
+	case "":
+		return defaultTimeLayout
+	case TimeFormatRFC3339:
+		return time.RFC3339
+	case TimeFormatRFC3339Nano:
+		return time.RFC3339Nano
+	case TimeFormatEpoch, TimeFormatEpochMillis, TimeFormatEpochNanos:
+		return ""
+	}
+	return c.TimeFormat
+}
+
+// timeLocation 返回 TimeZone 对应的时区，为空时返回 nil（使用日志时间自带的本地时区）
+func (c *ZapConfig) timeLocation() (*time.Location, error) {
+	if c.TimeZone == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("无效的时区 %s: %w", c.TimeZone, err)
+	}
+	return loc, nil
+}
+
+// timeEncoder 按 TimeFormat 和 TimeZone 返回时间编码器
+// 字符串格式在时间前加上 Prefix；epoch 系列输出为数字，与时区无关，不加 Prefix
+func (c *ZapConfig) timeEncoder() zapcore.TimeEncoder {
+	switch strings.ToLower(c.TimeFormat) {
+	case TimeFormatEpoch:
+		return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) { enc.AppendInt64(t.Unix()) }
+	case TimeFormatEpochMillis:
+		return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) { enc.AppendInt64(t.UnixMilli()) }
+	case TimeFormatEpochNanos:
+		return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) { enc.AppendInt64(t.UnixNano()) }
+	}
+	prefix, layout := c.Prefix, c.timeLayout()
+	// 时区无效时 initZap 已输出警告，这里使用本地时区
+	loc, _ := c.timeLocation()
+	return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+		if loc != nil {
+			t = t.In(loc)
+		}
+		enc.AppendString(prefix + t.Format(layout))
+	}
+}
@@ -0,0 +1,66 @@
+package mlog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestTimeFormat 测试 TimeFormat 和 TimeZone 对日志时间的影响，以及 FieldValue 能解析对应的时间
+func TestTimeFormat(t *testing.T) {
+	at := time.Date(2026, 3, 5, 8, 30, 15, 123456789, time.UTC)
+	cases := []struct {
+		config ZapConfig
+		want   string
+	}{
+		{ZapConfig{Format: "json", TimeZone: "UTC"}, `"2026-03-05 08:30:15.123"`},
+		{ZapConfig{Format: "json", TimeZone: "Asia/Shanghai", Prefix: "[gate] "}, `"[gate] 2026-03-05 16:30:15.123"`},
+		{ZapConfig{Format: "json", TimeFormat: TimeFormatRFC3339Nano, TimeZone: "UTC"}, `"2026-03-05T08:30:15.123456789Z"`},
+		{ZapConfig{Format: "json", TimeFormat: "2006/01/02 15:04", TimeZone: "UTC"}, `"2026/03/05 08:30"`},
+		{ZapConfig{Format: "json", TimeFormat: TimeFormatEpochMillis, Prefix: "[gate] "}, `1772699415123`},
+		{ZapConfig{Format: "json", TimeFormat: TimeFormatEpoch}, `1772699415`},
+	}
+	for _, c := range cases {
+		buf, err := c.config.Encoder().EncodeEntry(zapcore.Entry{Time: at, Message: "时间"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		line := buf.String()
+		if !strings.Contains(line, `"time":`+c.want) {
+			t.Fatalf("%s/%s 时间输出错误: %s", c.config.TimeFormat, c.config.TimeZone, line)
+		}
+
+		oldConfig := zapConfig
+		zapConfig = c.config
+		record, err := ReadRecords(strings.NewReader(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := FieldValue[time.Time](record[0], "time")
+		zapConfig = oldConfig
+		precision := time.Millisecond
+		switch c.config.TimeFormat {
+		case TimeFormatRFC3339Nano:
+			precision = time.Nanosecond
+		case TimeFormatEpoch:
+			precision = time.Second
+		case "2006/01/02 15:04":
+			precision = time.Minute
+		}
+		if !ok || !got.Equal(at.Truncate(precision)) {
+			t.Fatalf("%s 解析时间错误: %v %v", line, got, ok)
+		}
+	}
+
+	if _, err := (&ZapConfig{TimeZone: "Mars/Olympus"}).timeLocation(); err == nil {
+		t.Fatal("无效时区应返回错误")
+	}
+	var entry map[string]any
+	buf, _ := (&ZapConfig{Format: "json", TimeZone: "Mars/Olympus"}).Encoder().EncodeEntry(zapcore.Entry{Time: at}, nil)
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil || entry["time"] != at.Local().Format(defaultTimeLayout) {
+		t.Fatalf("无效时区应使用本地时区: %v %v", entry, err)
+	}
+}
@@ -0,0 +1,143 @@
+package mlog
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FieldTransformer 转换字段值，返回值按 zap.Any 编码，返回 nil 时不输出该字段
+type FieldTransformer func(value any) any
+
+var (
+	fieldTransformersMutex sync.Mutex
+	// fieldTransformers 按键名注册的转换函数，写时复制，写日志时无锁读取
+	fieldTransformers atomic.Pointer[map[string]FieldTransformer]
+)
+
+// RegisterFieldTransformer 为键名为 key 的字段注册转换函数，如把 proto 消息转换为紧凑 JSON、把定点数转换为字符串，
+// 调用处不必各自转换。fn 为 nil 时取消注册，重复注册同一键名时覆盖之前的注册。
+// 转换在脱敏和大小限制之前进行，对所有输出生效，只处理顶层字段；需要在 InitialZap 之前调用
+func RegisterFieldTransformer(key string, fn FieldTransformer) {
+	if key == "" {
+		panic("mlog: RegisterFieldTransformer 的键名不能为空")
+	}
+	fieldTransformersMutex.Lock()
+	defer fieldTransformersMutex.Unlock()
+	transformers := make(map[string]FieldTransformer)
+	if old := fieldTransformers.Load(); old != nil {
+		for k, v := range *old {
+			transformers[k] = v
+		}
+	}
+	if fn == nil {
+		delete(transformers, key)
+	} else {
+		transformers[key] = fn
+	}
+	fieldTransformers.Store(&transformers)
+}
+
+// transformCore 按 RegisterFieldTransformer 注册的函数转换字段值
+type transformCore struct {
+	zapcore.Core
+}
+
+// newTransformCore 注册了转换函数时包装 Core，否则原样返回
+func newTransformCore(core zapcore.Core) zapcore.Core {
+	if t := fieldTransformers.Load(); t == nil || len(*t) == 0 {
+		return core
+	}
+	return &transformCore{Core: core}
+}
+
+func (c *transformCore) With(fields []zapcore.Field) zapcore.Core {
+	return &transformCore{Core: c.Core.With(transformFields(fields))}
+}
+
+func (c *transformCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *transformCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return writeChecked(c.Core, entry, transformFields(fields))
+}
+
+// transformFields 返回转换后的字段，没有需要转换的字段时返回原切片
+func transformFields(fields []zapcore.Field) []zapcore.Field {
+	transformers := fieldTransformers.Load()
+	if transformers == nil || len(*transformers) == 0 {
+		return fields
+	}
+	var transformed []zapcore.Field
+	for i := range fields {
+		fn, ok := (*transformers)[fields[i].Key]
+		if !ok {
+			continue
+		}
+		value, ok := fieldValue(fields[i])
+		if !ok {
+			continue
+		}
+		if transformed == nil {
+			transformed = append([]zapcore.Field(nil), fields...)
+		}
+		transformed[i] = applyFieldTransformer(fields[i], fn, value)
+	}
+	if transformed == nil {
+		return fields
+	}
+	return transformed
+}
+
+// applyFieldTransformer 调用转换函数，转换函数 panic 时保留原字段
+func applyFieldTransformer(field zapcore.Field, fn FieldTransformer, value any) (result zapcore.Field) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 字段转换函数 panic [%s]: %v\n", field.Key, r)
+			result = field
+		}
+	}()
+	out := fn(value)
+	if out == nil {
+		return zap.Skip()
+	}
+	return zap.Any(field.Key, out)
+}
+
+// fieldValue 取出字段的原始值，Namespace、Skip 等没有值的字段返回 false
+func fieldValue(field zapcore.Field) (any, bool) {
+	switch field.Type {
+	case zapcore.StringType:
+		return field.String, true
+	case zapcore.BoolType:
+		return field.Integer == 1, true
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return field.Integer, true
+	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		return uint64(field.Integer), true
+	case zapcore.Float64Type:
+		return math.Float64frombits(uint64(field.Integer)), true
+	case zapcore.Float32Type:
+		return math.Float32frombits(uint32(field.Integer)), true
+	case zapcore.DurationType:
+		return time.Duration(field.Integer), true
+	case zapcore.TimeType:
+		if loc, ok := field.Interface.(*time.Location); ok {
+			return time.Unix(0, field.Integer).In(loc), true
+		}
+		return time.Unix(0, field.Integer), true
+	case zapcore.NamespaceType, zapcore.SkipType, zapcore.UnknownType:
+		return nil, false
+	}
+	return field.Interface, true
+}
@@ -0,0 +1,55 @@
+package mlog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// cents 以分为单位的金额，测试转换为字符串
+type cents int64
+
+// TestFieldTransformer 测试按键名转换字段值，返回 nil 时删除字段，转换后的值仍会被脱敏，panic 时保留原值
+func TestFieldTransformer(t *testing.T) {
+	RegisterFieldTransformer("amount", func(v any) any {
+		c := v.(cents)
+		return map[string]any{"yuan": int64(c) / 100, "fen": int64(c) % 100}
+	})
+	RegisterFieldTransformer("player_id", func(v any) any { return "p-" + strings.Repeat("x", int(v.(int64)%3)) })
+	RegisterFieldTransformer("debug_blob", func(v any) any { return nil })
+	RegisterFieldTransformer("token", func(v any) any { return "t-" + v.(string) })
+	RegisterFieldTransformer("broken", func(v any) any { panic("bad value") })
+	defer func() {
+		for _, key := range []string{"amount", "player_id", "debug_blob", "token", "broken"} {
+			RegisterFieldTransformer(key, nil)
+		}
+	}()
+
+	Close()
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: "json", SingleFile: true, RedactKeys: []string{"token"}}
+	InitialZap("gate", 2, "info", &config)
+	GLOG().With(zap.Int64("player_id", 1001)).Info("充值",
+		zap.Any("amount", cents(1234)), zap.Binary("debug_blob", []byte{1}), zap.String("token", "abc"), zap.Int("broken", 7))
+	Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "2", "gate", "all.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	amount, _ := json.Marshal(entry["amount"])
+	if string(amount) != `{"fen":34,"yuan":12}` || entry["player_id"] != "p-xx" || entry["token"] != "***" || entry["broken"] != float64(7) {
+		t.Fatalf("转换结果错误: %s", data)
+	}
+	if _, ok := entry["debug_blob"]; ok {
+		t.Fatalf("返回 nil 时应删除字段: %s", data)
+	}
+}
@@ -0,0 +1,82 @@
+package mlog
+
+import (
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// Duration 耗时字段，HumanizeUnits 开启时 console 格式输出为 12.3ms，JSON 格式始终输出秒数
+func Duration(key string, d time.Duration) zap.Field {
+	return zap.Duration(key, d)
+}
+
+// Bytes 字节数字段，HumanizeUnits 开启时 console 格式输出为 4.2MiB，JSON 格式始终输出数值
+func Bytes(key string, n int64) zap.Field {
+	return zap.Inline(byteSize{key: key, n: n})
+}
+
+// byteSize 字节数，按编码器决定输出数值还是带单位的字符串
+type byteSize struct {
+	key string
+	n   int64
+}
+
+func (b byteSize) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if _, ok := enc.(*humanizeEncoder); ok {
+		enc.AddString(b.key, humanizeBytes(b.n))
+		return nil
+	}
+	enc.AddInt64(b.key, b.n)
+	return nil
+}
+
+// humanizeBytes 按 1024 进制格式化字节数，如 512B、4.2MiB
+func humanizeBytes(n int64) string {
+	const units = "KMGTPE"
+	abs := uint64(n)
+	sign := ""
+	if n < 0 {
+		abs, sign = uint64(-n), "-"
+	}
+	if abs < 1024 {
+		return sign + strconv.FormatUint(abs, 10) + "B"
+	}
+	value, unit := float64(abs)/1024, 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	return sign + strconv.FormatFloat(value, 'f', 1, 64) + string(units[unit]) + "iB"
+}
+
+// humanizeEncoder console 和自定义格式的编码器包装，Bytes 字段输出为带单位的字符串
+// 日志调用的字段在 EncodeEntry 中转换，With 添加的字段由 byteSize 识别编码器类型后转换
+type humanizeEncoder struct {
+	zapcore.Encoder
+}
+
+func (e *humanizeEncoder) Clone() zapcore.Encoder {
+	return &humanizeEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *humanizeEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	var converted []zapcore.Field
+	for i := range fields {
+		b, ok := fields[i].Interface.(byteSize)
+		if !ok || fields[i].Type != zapcore.InlineMarshalerType {
+			continue
+		}
+		if converted == nil {
+			converted = append([]zapcore.Field(nil), fields...)
+		}
+		converted[i] = zap.String(b.key, humanizeBytes(b.n))
+	}
+	if converted != nil {
+		fields = converted
+	}
+	return e.Encoder.EncodeEntry(entry, fields)
+}
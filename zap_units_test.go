@@ -0,0 +1,55 @@
+package mlog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHumanizeBytes 测试字节数按 1024 进制格式化
+func TestHumanizeBytes(t *testing.T) {
+	cases := map[int64]string{0: "0B", 512: "512B", 1536: "1.5KiB", 4404019: "4.2MiB", -2048: "-2.0KiB", 3 << 40: "3.0TiB"}
+	for n, want := range cases {
+		if got := humanizeBytes(n); got != want {
+			t.Fatalf("humanizeBytes(%d) = %s，期望 %s", n, got, want)
+		}
+	}
+}
+
+// TestHumanizeUnits 测试 console 格式输出带单位的耗时和字节数，JSON 格式输出数值
+func TestHumanizeUnits(t *testing.T) {
+	oldConfig := zapConfig
+	defer func() { zapConfig = oldConfig }()
+
+	for _, format := range []string{"console", "json"} {
+		Close()
+		dir := t.TempDir()
+		config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, Format: format, HumanizeUnits: true}
+		InitialZap("gate", 2, "info", &config)
+		GLOG().With(Bytes("snapshot", 2048)).Info("保存存档", Duration("cost", 12300*time.Microsecond), Bytes("size", 4404019))
+		Close()
+
+		data, err := os.ReadFile(filepath.Join(dir, "2", "gate", "info.log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format == "console" {
+			for _, want := range []string{`"cost": "12.3ms"`, `"size": "4.2MiB"`, `"snapshot": "2.0KiB"`} {
+				if !strings.Contains(string(data), want) {
+					t.Fatalf("缺少 %s: %s", want, data)
+				}
+			}
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal(data, &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry["cost"] != 0.0123 || entry["size"] != float64(4404019) || entry["snapshot"] != float64(2048) {
+			t.Fatalf("JSON 格式应输出数值: %s", data)
+		}
+	}
+}
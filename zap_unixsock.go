@@ -0,0 +1,91 @@
+package mlog
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// UnixSocketConfig Unix 域套接字输出配置
+// 每条日志编码为 4 字节大端长度前缀 + JSON，供同一主机上的 sidecar 采集程序读取
+type UnixSocketConfig struct {
+	Enable       bool   `mapstructure:"enable" json:"enable" yaml:"enable"`                         // 启用 Unix 套接字输出
+	Path         string `mapstructure:"path" json:"path" yaml:"path"`                               // 套接字路径，如 /var/run/log-shipper.sock
+	MaxBackoffMs int    `mapstructure:"max-backoff-ms" json:"max-backoff-ms" yaml:"max-backoff-ms"` // 重连退避间隔上限（毫秒，默认 30000）
+	// 采集程序未启动或重启期间日志保留在缓冲区并持续重连，缓冲区满时丢弃新日志
+	RemoteBatchConfig `mapstructure:",squash" yaml:",inline"`
+}
+
+// unixSocketWriter Unix 套接字连接，断开后在下一次发送时重连
+type unixSocketWriter struct {
+	path string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// newUnixSocketCore 创建 Unix 套接字输出 Core
+func newUnixSocketCore(cfg UnixSocketConfig) (*remoteCore, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("未配置 Unix 套接字路径")
+	}
+	w := &unixSocketWriter{path: cfg.Path}
+	batcher := newRemoteBatcher("unix-socket", cfg.RemoteBatchConfig, w.sendBatch)
+	batcher.persistent = true
+	if cfg.MaxBackoffMs > 0 {
+		batcher.maxBackoff = time.Duration(cfg.MaxBackoffMs) * time.Millisecond
+	}
+	addSinkCloser(batcher)
+	addSinkCloser(w)
+	return newRemoteCore(batcher, "", ""), nil
+}
+
+// sendBatch 整批合并为一次写入，每条日志带 4 字节大端长度前缀
+func (w *unixSocketWriter) sendBatch(ctx context.Context, batch []remoteRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		dialer := net.Dialer{Timeout: netSinkDialTimeout}
+		conn, err := dialer.DialContext(ctx, "unix", w.path)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		w.conn.SetWriteDeadline(deadline)
+	}
+
+	size := 0
+	for i := range batch {
+		size += 4 + len(batch[i].Data)
+	}
+	buf := make([]byte, 0, size)
+	for i := range batch {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(batch[i].Data)))
+		buf = append(buf, batch[i].Data...)
+	}
+	if _, err := w.conn.Write(buf); err != nil {
+		// 无法确定对端收到了多少数据，断开后整批重发，采集端可能收到不完整的最后一帧
+		w.conn.Close()
+		w.conn = nil
+		return err
+	}
+	return nil
+}
+
+// Close 关闭连接
+func (w *unixSocketWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,53 @@
+package mlog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestUnixSocketFrames 测试每条日志以长度前缀帧写入 Unix 套接字
+func TestUnixSocketFrames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shipper.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("不支持 Unix 套接字: %v", err)
+	}
+	defer ln.Close()
+
+	core, err := newUnixSocketCore(UnixSocketConfig{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.LevelEnabler = zapcore.DebugLevel
+	log := zap.New(core)
+	log.Info("登录", zap.Int64("player", 1))
+	log.Warn("延迟过高")
+	go core.batcher.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, want := range []string{"登录", "延迟过高"} {
+		var size [4]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			t.Fatal(err)
+		}
+		frame := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(conn, frame); err != nil {
+			t.Fatal(err)
+		}
+		var entry map[string]any
+		if err := json.Unmarshal(frame, &entry); err != nil || entry["msg"] != want {
+			t.Fatalf("帧内容错误: %s", frame)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package mlog
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 配置校验错误的类别，可以用 errors.Is 判断 Validate、InitialZapE 返回的错误
+var (
+	ErrConfigInvalid      = errors.New("配置项的值无效")
+	ErrConfigConflict     = errors.New("配置项相互矛盾")
+	ErrDirectorUnwritable = errors.New("日志目录不可写")
+)
+
+// ConfigError 配置校验错误，Field 为配置键名（如 single-file-name）
+type ConfigError struct {
+	Field  string
+	Kind   error // ErrConfigInvalid、ErrConfigConflict 或 ErrDirectorUnwritable
+	Reason string
+	Err    error // 底层错误，可以为 nil
+}
+
+func (e *ConfigError) Error() string {
+	msg := fmt.Sprintf("mlog: %s [%s]: %s", e.Kind, e.Field, e.Reason)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ConfigError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Validate 校验配置，返回所有发现的问题，每个错误都是 *ConfigError
+// 检查无效的值（级别、格式、编码器、文件名模板、时区、颜色、路由等）、相互矛盾的配置
+// （如未开启 SingleFile 却设置了 SingleFileName、启用异步日志但缓冲区为 0）以及不可写的日志目录；
+// 不创建日志目录，但检查可写性时会在该目录（不存在时为最近的已存在的上级目录）中创建并立即删除一个临时文件。
+// InitialZap 不做校验（部分问题输出到 stderr 后按默认值处理），InitialZapE 则返回错误
+func (c *ZapConfig) Validate() []error {
+	var errs []error
+	invalid := func(field, reason string, err error) {
+		errs = append(errs, &ConfigError{Field: field, Kind: ErrConfigInvalid, Reason: reason, Err: err})
+	}
+	conflict := func(field, reason string) {
+		errs = append(errs, &ConfigError{Field: field, Kind: ErrConfigConflict, Reason: reason})
+	}
+
+	if c.Level != "" {
+		if _, err := zapcore.ParseLevel(c.Level); err != nil {
+			invalid("level", "未知的日志级别 "+c.Level, nil)
+		}
+	}
+	if !builtinFormat(c.Format) && getEncoderFactory(c.Format) == nil {
+		invalid("format", "未注册的日志格式 "+c.Format, nil)
+	}
+	switch c.EncodeLevel {
+	case "", "LowercaseLevelEncoder", "LowercaseColorLevelEncoder", "CapitalLevelEncoder", "CapitalColorLevelEncoder":
+	default:
+		invalid("encode-level", "未知的级别编码器 "+c.EncodeLevel, nil)
+	}
+	if c.SingleFileName != "" && !c.SingleFile {
+		conflict("single-file-name", "设置了单文件名，但未开启 single-file")
+	}
+	if c.AlwaysSeparateErrors && !c.SingleFile {
+		conflict("always-separate-errors", "只在 single-file 模式下生效")
+	}
+	if c.EnableAsync && c.AsyncBufferSize <= 0 {
+		conflict("async-buffer-size", "启用了异步日志，但缓冲区大小为 0")
+	}
+	switch c.CompressAlgo {
+	case "", CompressGzip, CompressZstd:
+	default:
+		invalid("compress-algo", "不支持的压缩算法 "+c.CompressAlgo, nil)
+	}
+	switch c.MultiProcess {
+	case "", MultiProcessPID:
+	case MultiProcessFlock:
+		if !flockSupported {
+			invalid("multi-process", "当前平台不支持 flock 多进程模式", nil)
+		}
+	default:
+		invalid("multi-process", "不支持的多进程模式 "+c.MultiProcess, nil)
+	}
+	switch c.ConsoleColor {
+	case "", ConsoleColorAuto, ConsoleColorAlways, ConsoleColorNever:
+	default:
+		invalid("console-color", "不支持的控制台颜色模式 "+c.ConsoleColor, nil)
+	}
+	if _, err := parseFilePattern(c.FilePattern); err != nil {
+		invalid("file-pattern", "文件名模板无效", err)
+	}
+	if _, err := parseRotateInterval(c.RotateInterval); err != nil {
+		invalid("rotate-interval", "轮转间隔无效", err)
+	}
+	if _, err := c.timeLocation(); err != nil {
+		invalid("time-zone", "时区无效", err)
+	}
+	if c.ConsoleTemplate != "" {
+		if _, err := parseConsoleTemplate(c.ConsoleTemplate); err != nil {
+			invalid("console-template", "控制台模板无效", err)
+		}
+	}
+	if _, ruleErrs := compileMaskRules(c.MaskRules); len(ruleErrs) > 0 {
+		invalid("mask-rules", "脱敏规则无效", errors.Join(ruleErrs...))
+	}
+	if _, err := parseLevelColors(c.LevelColors); err != nil {
+		invalid("level-colors", "级别颜色无效", err)
+	}
+	if _, err := parseLevelNames(c.LevelNames); err != nil {
+		invalid("level-names", "级别显示名无效", err)
+	}
+	if _, err := newStackPolicy(c); err != nil {
+		invalid("stacktrace-level", "调用栈配置无效", err)
+	}
+	if _, err := newLevelRoutes(c.Routes); err != nil {
+		invalid("routes", "路由无效", err)
+	}
+	if _, err := metadataFields(c.GlobalFields, "", 0); err != nil {
+		invalid("global-fields", "元数据字段无效", err)
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Sampling)) {
+		if level, err := zapcore.ParseLevel(name); err != nil || level < zapcore.DebugLevel || level > zapcore.FatalLevel {
+			invalid("sampling", "无效的采样级别 "+name, nil)
+		}
+	}
+
+	if !c.stdoutMode() {
+		if c.Director == "" {
+			invalid("director", "未设置日志目录", nil)
+		} else if err := checkDirWritable(c.Director); err != nil {
+			errs = append(errs, &ConfigError{Field: "director", Kind: ErrDirectorUnwritable, Reason: c.Director, Err: err})
+		}
+	}
+	return errs
+}
+
+// checkDirWritable 检查能否在 dir 中创建文件，dir 不存在时检查最近的已存在的上级目录
+func checkDirWritable(dir string) error {
+	path := dir
+	for {
+		info, err := os.Stat(path)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s 不是目录", path)
+			}
+			break
+		}
+		parent := filepath.Dir(path)
+		if !os.IsNotExist(err) || parent == path {
+			return err
+		}
+		path = parent
+	}
+	f, err := os.CreateTemp(path, ".mlog-validate-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,71 @@
+package mlog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestValidate 测试校验出无效的值、相互矛盾的配置和不可写的日志目录，错误可以按类别判断
+func TestValidate(t *testing.T) {
+	if errs := (&ZapConfig{Level: "info", Director: t.TempDir(), EnableAsync: true, AsyncBufferSize: 1000}).Validate(); len(errs) != 0 {
+		t.Fatalf("有效的配置不应返回错误: %v", errs)
+	}
+
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := ZapConfig{
+		Level:          "verbose",
+		Format:         "xml",
+		EncodeLevel:    "RainbowLevelEncoder",
+		SingleFileName: "game.log",
+		EnableAsync:    true,
+		Director:       filepath.Join(file, "logs"),
+		Sampling:       map[string]SamplingConfig{"loud": {Initial: 1}},
+	}
+	errs := config.Validate()
+	fields := make(map[string]error)
+	for _, err := range errs {
+		var ce *ConfigError
+		if !errors.As(err, &ce) {
+			t.Fatalf("错误应为 *ConfigError: %v", err)
+		}
+		fields[ce.Field] = ce.Kind
+	}
+	want := map[string]error{
+		"level":             ErrConfigInvalid,
+		"format":            ErrConfigInvalid,
+		"encode-level":      ErrConfigInvalid,
+		"single-file-name":  ErrConfigConflict,
+		"async-buffer-size": ErrConfigConflict,
+		"sampling":          ErrConfigInvalid,
+		"director":          ErrDirectorUnwritable,
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("错误数量不符: %v", errs)
+	}
+	for field, kind := range want {
+		if fields[field] != kind {
+			t.Fatalf("配置项 %s 应为 %v: %v", field, kind, errs)
+		}
+	}
+}
+
+// TestInitialZapE 测试配置无效时不初始化并返回错误，有效时正常初始化
+func TestInitialZapE(t *testing.T) {
+	Close()
+	err := InitialZapE("gate", 2, "info", &ZapConfig{Director: t.TempDir(), SingleFileName: "game.log"})
+	if !errors.Is(err, ErrConfigConflict) || isInitialized() {
+		t.Fatalf("配置无效时应返回错误且不初始化: %v", err)
+	}
+	if err := InitialZapE("gate", 2, "info", &ZapConfig{Director: t.TempDir(), SingleFile: true, SingleFileName: "game.log"}); err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+	if !isInitialized() || GetConfig().Level != "info" {
+		t.Fatal("配置有效时应完成初始化")
+	}
+}
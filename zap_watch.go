@@ -0,0 +1,199 @@
+package mlog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.yaml.in/yaml/v3"
+)
+
+// configWatchInterval WatchConfig 检查配置文件的间隔
+var configWatchInterval = time.Second
+
+// WatchConfig 监视 YAML/JSON 配置文件，返回用于停止监视的函数
+// 文件内容变化后立即应用其中的日志级别、控制台输出（log-in-console）、采样（sampling）、脱敏（redact-keys、mask-rules）
+// 和路由（routes）配置，文件中没有的配置项保持当前值，其他配置项需要重启生效。
+// 各项变化都直接应用到当前日志器，不重建日志器，已打开的日志文件和附加输出保持不变。
+// 文件被删除、无法解析或配置无效时输出到 stderr 并保持当前配置；日志系统未初始化时只记录文件内容，不做修改
+func WatchConfig(path string) (stop func(), err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("mlog: 读取配置文件失败: %w", err)
+	}
+	if _, err := parseWatchedConfig(data); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(configWatchInterval)
+		defer ticker.Stop()
+		last := data
+		for {
+			select {
+			case <-ticker.C:
+				data, err := os.ReadFile(path)
+				if err != nil || bytes.Equal(data, last) {
+					continue
+				}
+				last = data
+				if err := reloadWatchedConfig(data); err != nil {
+					fmt.Fprintf(os.Stderr, "[mlog] 重新加载配置失败 [%s]: %v，保持当前配置\n", path, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }, nil
+}
+
+// hotConfigKeys 可以热更新的配置项
+var hotConfigKeys = []string{"level", "log-in-console", "sampling", "redact-keys", "mask-rules", "routes"}
+
+// watchedConfig 配置文件中可以热更新的配置项，present 记录文件中出现的配置项
+type watchedConfig struct {
+	config  ZapConfig
+	present map[string]bool
+}
+
+// parseWatchedConfig 解析配置文件内容，JSON 按 YAML 的子集解析
+func parseWatchedConfig(data []byte) (*watchedConfig, error) {
+	w := &watchedConfig{present: make(map[string]bool)}
+	if err := yaml.Unmarshal(data, &w.config); err != nil {
+		return nil, fmt.Errorf("mlog: 解析配置文件失败: %w", err)
+	}
+	var keys map[string]any
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("mlog: 解析配置文件失败: %w", err)
+	}
+	for _, key := range hotConfigKeys {
+		_, w.present[key] = keys[key]
+	}
+	if w.config.Level != "" {
+		if _, err := zapcore.ParseLevel(w.config.Level); err != nil {
+			return nil, fmt.Errorf("mlog: 配置文件中的日志级别无效: %s", w.config.Level)
+		}
+	}
+	if _, err := newLevelRoutes(w.config.Routes); err != nil {
+		return nil, fmt.Errorf("mlog: 配置文件中的路由无效: %w", err)
+	}
+	return w, nil
+}
+
+// reloadWatchedConfig 把配置文件中可以热更新的配置项应用到当前日志器
+func reloadWatchedConfig(data []byte) error {
+	next, err := parseWatchedConfig(data)
+	if err != nil {
+		return err
+	}
+	if !isInitialized() {
+		return nil
+	}
+
+	applyHotConfig(func(c *ZapConfig) {
+		if next.present["level"] && next.config.Level != "" {
+			c.Level = next.config.Level
+		}
+		if next.present["log-in-console"] {
+			c.LogInConsole = next.config.LogInConsole
+		}
+		if next.present["sampling"] {
+			c.Sampling = next.config.Sampling
+		}
+		if next.present["redact-keys"] {
+			c.RedactKeys = next.config.RedactKeys
+		}
+		if next.present["mask-rules"] {
+			c.MaskRules = next.config.MaskRules
+		}
+		if next.present["routes"] {
+			c.Routes = next.config.Routes
+		}
+	})
+	return nil
+}
+
+// SetLogInConsole 运行时开启或关闭控制台输出，直接切换控制台开关，不重建日志器，已打开的日志文件和附加输出保持不变；
+// 未初始化时只修改配置，初始化后生效
+func SetLogInConsole(enabled bool) {
+	if !isInitialized() {
+		globalMutex.Lock()
+		zapConfig.LogInConsole = enabled
+		globalMutex.Unlock()
+		return
+	}
+	applyHotConfig(func(c *ZapConfig) { c.LogInConsole = enabled })
+}
+
+// hotConfigMutex 串行化 applyHotConfig，避免并发的热更新互相覆盖
+var hotConfigMutex sync.Mutex
+
+// applyHotConfig 修改当前配置并应用到日志器，不重建日志器：级别直接修改，控制台开关和路由表原子替换，
+// 采样和脱敏只替换 Core 链中对应的包装层，场景日志、附加输出、去重状态和异步日志器保持不变。
+// 修改后的配置项保存在 hotConfig 中，GetConfig 返回合并后的结果
+func applyHotConfig(update func(c *ZapConfig)) {
+	hotConfigMutex.Lock()
+	defer hotConfigMutex.Unlock()
+
+	current := GetConfig()
+	next := *current
+	update(&next)
+	level := next.Level
+
+	var changed []string
+	if next.LogInConsole != current.LogInConsole {
+		consoleEnabled.Store(next.LogInConsole)
+		changed = append(changed, "log-in-console")
+	}
+	if !slices.EqualFunc(current.Routes, next.Routes, func(a, b LevelRouteConfig) bool {
+		return a.Levels == b.Levels && slices.Equal(a.Outputs, b.Outputs)
+	}) {
+		if routes, err := newLevelRoutes(next.Routes); err != nil {
+			fmt.Fprintf(os.Stderr, "[mlog] 解析日志路由失败，保持当前路由: %v\n", err)
+			next.Routes = current.Routes
+		} else {
+			if routes != nil {
+				routes.warnUnknownOutputs(configuredOutputs(&next))
+			}
+			activeRoutes.Store(routes)
+			changed = append(changed, "routes")
+		}
+	}
+	hot := activeHotCores.Load()
+	if !maps.Equal(current.Sampling, next.Sampling) {
+		if hot != nil {
+			hot.sampling.Store(samplingWrapper(next.Sampling, activeDropped.Load()))
+		}
+		changed = append(changed, "sampling")
+	}
+	if !slices.Equal(current.RedactKeys, next.RedactKeys) || !slices.Equal(current.MaskRules, next.MaskRules) {
+		if _, errs := compileMaskRules(next.MaskRules); len(errs) > 0 {
+			fmt.Fprintf(os.Stderr, "[mlog] %v，已跳过\n", errors.Join(errs...))
+		}
+		if hot != nil {
+			hot.redact.Store(redactWrapper(&next))
+		}
+		changed = append(changed, "redact")
+	}
+	// 只替换热更新配置项的快照，zapConfig 保持不变，级别由 UpdateLevel 修改
+	if hot != nil {
+		hot.config.Store(hotConfigOf(&next))
+	}
+
+	if level != current.Level {
+		UpdateLevel(level)
+		InfoW("[HotConfig] 日志级别已更新", zap.String("level", level))
+	}
+	if len(changed) > 0 {
+		InfoW("[HotConfig] 配置已更新", zap.Strings("changed", changed))
+	}
+}
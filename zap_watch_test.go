@@ -0,0 +1,151 @@
+package mlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TestWatchConfig 测试修改配置文件后级别和脱敏配置立即生效，无效的配置保持当前配置
+func TestWatchConfig(t *testing.T) {
+	Close()
+	oldInterval := configWatchInterval
+	configWatchInterval = 10 * time.Millisecond
+	defer func() { configWatchInterval = oldInterval }()
+
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, SingleFile: true}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	path := filepath.Join(dir, "mlog.yaml")
+	writeConfig := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("level: info\n")
+	if _, err := WatchConfig(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Fatal("配置文件不存在时应返回错误")
+	}
+	stop, err := WatchConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); !cond(); time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("等待%s超时", what)
+			}
+		}
+	}
+
+	// 只修改级别：不重建日志器
+	logger := GLOG()
+	writeConfig("level: debug\n")
+	waitFor("级别更新", func() bool { return GetConfig().Level == "debug" })
+	if GLOG() != logger {
+		t.Fatal("只修改级别时不应重建日志器")
+	}
+	DebugW("调试日志")
+
+	// 无效的级别保持当前配置
+	writeConfig("level: verbose\n")
+	time.Sleep(50 * time.Millisecond)
+	if GetConfig().Level != "debug" {
+		t.Fatalf("无效的级别不应生效: %s", GetConfig().Level)
+	}
+
+	// 修改脱敏配置：只替换脱敏层，不重建日志器，级别保持不变
+	writeConfig(`{"redact-keys": ["password"]}`)
+	waitFor("脱敏配置更新", func() bool { return len(GetConfig().RedactKeys) == 1 })
+	if GLOG() != logger || GetConfig().Level != "debug" {
+		t.Fatalf("修改脱敏配置时不应重建日志器并保持级别: %s", GetConfig().Level)
+	}
+	InfoW("登录", zap.String("password", "hunter2"))
+	Close()
+
+	content := readLogFile(t, filepath.Join(dir, "2/gate/all.log"))
+	if !strings.Contains(content, "调试日志") {
+		t.Fatalf("调高级别后应写入调试日志: %s", content)
+	}
+	if strings.Contains(content, "hunter2") || !strings.Contains(content, "***") {
+		t.Fatalf("更新后应脱敏: %s", content)
+	}
+}
+
+// TestWatchConfigConcurrentLogging 测试热更新配置时其他协程持续写日志，配合 -race 检查写日志的路径不读取被替换的配置
+func TestWatchConfigConcurrentLogging(t *testing.T) {
+	Close()
+	oldInterval := configWatchInterval
+	configWatchInterval = 5 * time.Millisecond
+	defer func() { configWatchInterval = oldInterval }()
+
+	dir := t.TempDir()
+	config := ZapConfig{Level: "info", Director: dir, MaxSize: 10, ShowLine: true}
+	InitialZap("gate", 2, "info", &config)
+	defer Close()
+
+	path := filepath.Join(dir, "mlog.yaml")
+	if err := os.WriteFile(path, []byte("level: info\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stop, err := WatchConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				Info("战斗结算 %d", 1)
+				InfoW("登录", zap.String("password", "hunter2"), zap.String("directory", "battle"))
+			}
+		}()
+	}
+	steps := []struct {
+		content string
+		applied func(c *ZapConfig) bool
+	}{
+		{"redact-keys: [password]\n", func(c *ZapConfig) bool { return len(c.RedactKeys) == 1 }},
+		{"sampling: {info: {initial: 1, thereafter: 10}}\n", func(c *ZapConfig) bool { return len(c.Sampling) == 1 }},
+		{"routes: [{levels: \"*\", outputs: [file]}]\n", func(c *ZapConfig) bool { return len(c.Routes) == 1 }},
+		{"log-in-console: true\nlevel: debug\n", func(c *ZapConfig) bool { return c.LogInConsole && c.Level == "debug" }},
+	}
+	for _, step := range steps {
+		if err := os.WriteFile(path, []byte(step.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		for deadline := time.Now().Add(5 * time.Second); !step.applied(GetConfig()); time.Sleep(5 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("等待配置生效超时: %s", step.content)
+			}
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	got := GetConfig()
+	if len(got.RedactKeys) != 1 || len(got.Sampling) != 1 || len(got.Routes) != 1 || !got.LogInConsole {
+		t.Fatalf("热更新的配置项应全部保留: %v %v %v", got.RedactKeys, got.Sampling, got.Routes)
+	}
+}
@@ -0,0 +1,212 @@
+package mlog
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 轮转文件的压缩算法
+const (
+	CompressGzip = "gzip" // lumberjack 内置的 gzip 压缩，默认
+	CompressZstd = "zstd" // zstd 压缩，需先调用 SetZstdEncoder
+)
+
+// 轮转文件压缩后缀
+const (
+	gzipSuffix = ".gz"
+	zstdSuffix = ".zst"
+)
+
+// zstdCompressScanInterval 扫描待压缩备份文件的间隔
+const zstdCompressScanInterval = 10 * time.Second
+
+// ZstdEncoderFunc 创建 zstd 编码器，写入的数据压缩后输出到 w，Close 时写完帧尾
+type ZstdEncoderFunc func(w io.Writer) (io.WriteCloser, error)
+
+var (
+	zstdEncoder      ZstdEncoderFunc
+	zstdEncoderMutex sync.RWMutex
+)
+
+// SetZstdEncoder 设置 zstd 编码器，需要在 InitialZap 之前调用，传入 nil 取消
+// mlog 不直接依赖 zstd 库，由应用使用自己的实现，例如 klauspost/compress：
+//
+//	mlog.SetZstdEncoder(func(w io.Writer) (io.WriteCloser, error) {
+//		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedFastest))
+//	})
+func SetZstdEncoder(fn ZstdEncoderFunc) {
+	zstdEncoderMutex.Lock()
+	zstdEncoder = fn
+	zstdEncoderMutex.Unlock()
+}
+
+// getZstdEncoder 获取当前的 zstd 编码器
+func getZstdEncoder() ZstdEncoderFunc {
+	zstdEncoderMutex.RLock()
+	defer zstdEncoderMutex.RUnlock()
+	return zstdEncoder
+}
+
+// gzipCompression 是否由 lumberjack 以 gzip 压缩轮转文件
+func (c *ZapConfig) gzipCompression() bool {
+	return c.EnableCompress && !c.zstdCompression()
+}
+
+// zstdCompression 是否使用 zstd 压缩轮转文件，未设置编码器时回退到 gzip
+func (c *ZapConfig) zstdCompression() bool {
+	return c.EnableCompress && c.CompressAlgo == CompressZstd && getZstdEncoder() != nil
+}
+
+// zstdCompressor 轮转文件 zstd 压缩器
+// lumberjack 只支持 gzip，使用 zstd 时关闭 lumberjack 的压缩，由这里定期扫描服务日志目录，
+// 把未压缩的备份文件压缩为 .zst 后删除原文件。lumberjack 的 MaxBackups/MaxAge 清理不识别 .zst 文件，
+// 因此压缩后的文件也在这里按相同规则清理
+type zstdCompressor struct {
+	root       string // 服务日志目录
+	encoder    ZstdEncoderFunc
+	maxBackups int
+	maxAge     time.Duration
+	interval   time.Duration
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// backupFile 一个 lumberjack 备份文件，managed 为 true 表示由 mlog 自行清理（lumberjack 不识别的 .zst/.enc 文件）
+type backupFile struct {
+	path    string
+	time    time.Time
+	managed bool
+}
+
+// newZstdCompressor 创建 zstd 压缩器并启动后台扫描
+func newZstdCompressor(root string) *zstdCompressor {
+	c := &zstdCompressor{
+		root:       root,
+		encoder:    getZstdEncoder(),
+		maxBackups: zapConfig.MaxBackups,
+		maxAge:     time.Duration(zapConfig.RetentionDay) * 24 * time.Hour,
+		interval:   zstdCompressScanInterval,
+		done:       make(chan struct{}),
+	}
+	c.wg.Add(1)
+	go c.run()
+	return c
+}
+
+// run 后台扫描循环，启动时先扫描一次，压缩上次运行遗留的文件
+func (c *zstdCompressor) run() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		c.scan()
+		select {
+		case <-ticker.C:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// scan 压缩一轮未压缩的备份文件，并按 MaxBackups/MaxAge 清理 .zst 文件
+func (c *zstdCompressor) scan() {
+	active := activeLogFiles()
+	// 按 目录/文件名/扩展名 分组，同一个日志文件的备份一起清理
+	groups := make(map[string][]backupFile)
+	filepath.WalkDir(c.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		select {
+		case <-c.done:
+			return filepath.SkipAll
+		default:
+		}
+		m := lumberjackBackupPattern.FindStringSubmatch(d.Name())
+		if m == nil || m[4] == gzipSuffix || m[5] != "" || active[filepath.Clean(path)] {
+			return nil
+		}
+		t, err := time.ParseInLocation(lumberjackBackupTimeFormat, m[2], time.Local)
+		if err != nil {
+			return nil
+		}
+		b := backupFile{path: path, time: t, managed: m[4] == zstdSuffix}
+		if !b.managed {
+			if err := c.compress(path); err != nil {
+				fmt.Fprintf(os.Stderr, "[mlog] zstd 压缩轮转日志失败 %s: %v\n", path, err)
+			} else {
+				b.path, b.managed = path+zstdSuffix, true
+			}
+		}
+		key := filepath.Join(filepath.Dir(path), m[1]+m[3])
+		groups[key] = append(groups[key], b)
+		return nil
+	})
+	for _, backups := range groups {
+		removeStaleBackups(backups, c.maxBackups, c.maxAge)
+	}
+}
+
+// compress 将文件压缩为 .zst，先写入临时文件再重命名，中途退出不会留下不完整的 .zst 文件
+func (c *zstdCompressor) compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := path + zstdSuffix + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	enc, err := c.encoder(dst)
+	if err == nil {
+		if _, err = io.Copy(enc, src); err == nil {
+			err = enc.Close()
+		} else {
+			enc.Close()
+		}
+	}
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+zstdSuffix)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
+
+// removeStaleBackups 按 MaxBackups 和 MaxAge 删除同一个日志文件的过期备份中由 mlog 管理的文件，其余备份仍由 lumberjack 清理
+func removeStaleBackups(backups []backupFile, maxBackups int, maxAge time.Duration) {
+	for i, reason := range staleBackups(backups, maxBackups, maxAge, time.Now()) {
+		if b := backups[i]; b.managed && reason != "" {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "[mlog] 删除过期的轮转日志失败 %s: %v\n", b.path, err)
+			}
+		}
+	}
+}
+
+// Close 停止后台扫描，未压缩的文件在下次启动时压缩
+func (c *zstdCompressor) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	c.wg.Wait()
+	return nil
+}
@@ -0,0 +1,72 @@
+package mlog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeZstdEncoder 测试用编码器，原样写入数据
+type fakeZstdEncoder struct {
+	w io.Writer
+}
+
+func (e *fakeZstdEncoder) Write(p []byte) (int, error) {
+	return e.w.Write(p)
+}
+
+func (e *fakeZstdEncoder) Close() error {
+	return nil
+}
+
+// TestZstdCompressor 测试备份文件压缩为 .zst、正在写入的文件不压缩，以及按 MaxBackups 清理 .zst 文件
+func TestZstdCompressor(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "pay")
+	os.MkdirAll(dir, 0755)
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	oldest := write("info-2026-01-01T00-00-00.000.log.zst", "zst:最早")
+	middle := write("info-2026-01-02T00-00-00.000.log", "中间")
+	newest := write("info-2026-01-03T00-00-00.000.log", "最新")
+	gz := write("error-2026-01-01T00-00-00.000.log.gz", "gzip")
+	current := write("info.log", "当前")
+
+	c := &zstdCompressor{
+		root: root,
+		encoder: func(w io.Writer) (io.WriteCloser, error) {
+			if _, err := w.Write([]byte("zst:")); err != nil {
+				return nil, err
+			}
+			return &fakeZstdEncoder{w: w}, nil
+		},
+		maxBackups: 2,
+		done:       make(chan struct{}),
+	}
+	c.scan()
+
+	for path, exists := range map[string]bool{
+		oldest:                   false,
+		middle:                   false,
+		newest:                   false,
+		middle + zstdSuffix:      true,
+		newest + zstdSuffix:      true,
+		gz:                       true,
+		current:                  true,
+		current + zstdSuffix:     false,
+		newest + ".zst" + ".tmp": false,
+	} {
+		if _, err := os.Stat(path); (err == nil) != exists {
+			t.Fatalf("%s 存在状态错误: %v", path, err)
+		}
+	}
+	if data, _ := os.ReadFile(newest + zstdSuffix); string(data) != "zst:最新" {
+		t.Fatalf("压缩内容错误: %q", data)
+	}
+}